@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
@@ -8,63 +9,24 @@ import (
 	"log"
 	"net/http"
 	"strconv"
-	"sync"
 	"time"
 
-	"golang.org/x/crypto/bcrypt"
 	"pos-saas/internal/config"
 	handler "pos-saas/internal/handler/http"
 	"pos-saas/internal/middleware"
 	"pos-saas/internal/pkg/database"
 	"pos-saas/internal/pkg/jwt"
+	"pos-saas/internal/pkg/mailer"
+	"pos-saas/internal/pkg/oidc"
+	"pos-saas/internal/pkg/secretbox"
+	"pos-saas/internal/pkg/sms"
+	"pos-saas/internal/pkg/tracking"
 	"pos-saas/internal/repository"
+	"pos-saas/internal/security"
 	"pos-saas/internal/service"
 	"pos-saas/internal/usecase"
 )
 
-// In-memory storage for stub mode
-type Role struct {
-	ID              int       `json:"id"`
-	TenantID        int       `json:"tenant_id"`
-	RoleName        string    `json:"role_name"`
-	Name            string    `json:"name"`
-	Description     string    `json:"description"`
-	IsSystem        bool      `json:"is_system"`
-	PermissionsCount int      `json:"permissions_count"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
-}
-
-type InMemoryRBACStorage struct {
-	mu       sync.RWMutex
-	roles    map[int]*Role
-	nextID   int
-	userRoles map[int][]int // userId -> []roleId
-}
-
-var rbacStorage = &InMemoryRBACStorage{
-	roles:    make(map[int]*Role),
-	nextID:   2, // Start from 2 since Admin role is 1
-	userRoles: make(map[int][]int),
-}
-
-func init() {
-	// Initialize with Admin role
-	rbacStorage.roles[1] = &Role{
-		ID:              1,
-		TenantID:        1,
-		RoleName:        "Admin",
-		Name:            "Admin",
-		Description:     "System administrator with full access",
-		IsSystem:        true,
-		PermissionsCount: 6,
-		CreatedAt:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
-		UpdatedAt:       time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
-	}
-	// Assign Admin role to user 7 (the mock user)
-	rbacStorage.userRoles[7] = []int{1}
-}
-
 func main() {
 	// Load configuration
 	cfg, err := config.Load()
@@ -87,6 +49,65 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create token service: %v", err)
 	}
+	refreshExpiry, err := time.ParseDuration(cfg.JWT.RefreshExpiry)
+	if err != nil {
+		log.Fatalf("Invalid JWT_REFRESH_EXPIRY: %v", err)
+	}
+
+	// Two-factor secrets are encrypted at rest
+	totpKey := cfg.TOTP.EncryptionKey
+	if totpKey == "" {
+		totpKey = cfg.JWT.Secret
+	}
+	totpBox, err := secretbox.New(totpKey)
+	if err != nil {
+		log.Fatalf("Failed to create TOTP secret box: %v", err)
+	}
+
+	// SSO client secrets and PKCE verifiers are encrypted at rest
+	ssoKey := cfg.SSO.EncryptionKey
+	if ssoKey == "" {
+		ssoKey = cfg.JWT.Secret
+	}
+	ssoBox, err := secretbox.New(ssoKey)
+	if err != nil {
+		log.Fatalf("Failed to create SSO secret box: %v", err)
+	}
+
+	// Initialize mailer for account emails (password reset, verification)
+	mail, err := mailer.New(mailer.Config{
+		Driver:       cfg.Mail.Driver,
+		FromEmail:    cfg.Mail.FromEmail,
+		FromName:     cfg.Mail.FromName,
+		SMTPHost:     cfg.Mail.SMTPHost,
+		SMTPPort:     cfg.Mail.SMTPPort,
+		SMTPUsername: cfg.Mail.SMTPUsername,
+		SMTPPassword: cfg.Mail.SMTPPassword,
+		FileDir:      cfg.Mail.FileDir,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create mailer: %v", err)
+	}
+
+	// Initialize SMS sender for staff invitations
+	smsSender, err := sms.New(sms.Config{
+		Driver:           cfg.SMS.Driver,
+		From:             cfg.SMS.From,
+		FileDir:          cfg.SMS.FileDir,
+		TwilioAccountSID: cfg.SMS.TwilioAccountSID,
+		TwilioAuthToken:  cfg.SMS.TwilioAuthToken,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create SMS sender: %v", err)
+	}
+
+	// Security audit log for sign-in attempts; entries are buffered and flushed in the background
+	var auditLog *security.AuditLogManager
+	if db != nil {
+		auditLog = security.NewAuditLogManager(db, 50, "", nil)
+		auditLog.Start()
+		defer auditLog.Stop()
+	}
 
 	// Initialize repositories
 	authRepo := repository.NewAuthRepository(db)
@@ -94,28 +115,44 @@ func main() {
 	categoryRepo := repository.NewCategoryRepository(db)
 	userRepo := repository.NewUserRepository(db)
 	userSettingsRepo := repository.NewUserSettingsRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	accountTokenRepo := repository.NewAccountTokenRepository(db)
+	twoFactorRepo := repository.NewTwoFactorRepository(db)
+	loginThrottleRepo := repository.NewLoginThrottleRepository(db)
+	invitationRepo := repository.NewInvitationRepository(db)
+	ssoRepo := repository.NewSSORepository(db)
 
 	// Order Management repositories
 	orderRepo := repository.NewOrderRepository(db)
-
-	// Driver Management repository
-	// driverRepo := repository.NewDriverRepository(db)
-
-	// HR Module repositories (commented - not used in current phase)
-	// employeeRepo := repository.NewEmployeeRepository(db)
-	// attendanceRepo := repository.NewAttendanceRepository(db)
-	// salaryRepo := repository.NewSalaryRepository(db)
-	// leaveRepo := repository.NewLeaveRepository(db)
+	diningRepo := repository.NewDiningRepository(db)
+	feedbackRepo := repository.NewFeedbackRepository(db)
+
+	// Driver Management repositories
+	driverRepo := repository.NewDriverRepository(db)
+	dispatchRepo := repository.NewDispatchRepository(db)
+	earningsRepo := repository.NewEarningsRepository(db)
+	locationRepo := repository.NewLocationRepository(db)
+
+	// HR Module repositories
+	employeeRepo := repository.NewEmployeeRepository(db)
+	attendanceRepo := repository.NewAttendanceRepository(db)
+	salaryRepo := repository.NewSalaryRepository(db)
+	leaveRepo := repository.NewLeaveRepository(db)
+	rotaRepo := repository.NewRotaRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	leavePolicyRepo := repository.NewLeavePolicyRepository(db)
+	performanceRepo := repository.NewPerformanceRepository(db)
+	hrAuditRepo := repository.NewHRAuditRepository(db)
 
 	// Notification repository
 	notificationRepo := repository.NewNotificationRepository(db)
 
-	// RBAC repositories (Phase 2)
-// 	// roleRepo := repository.NewRoleRepository(db)
-// 	// rolePermissionRepo := repository.NewRolePermissionRepository(db)
-// 	// userRoleRepo := repository.NewUserRoleRepository(db)
-// 	// moduleDefRepo := repository.NewModuleDefinitionRepository(db)
-// 	// auditLogRepo := repository.NewPermissionAuditLogRepository(db)
+	// RBAC repositories
+	roleRepo := repository.NewRoleRepository(db)
+	rolePermissionRepo := repository.NewRolePermissionRepository(db)
+	userRoleRepo := repository.NewUserRoleRepository(db)
+	moduleDefRepo := repository.NewModuleDefinitionRepository(db)
+	auditLogRepo := repository.NewPermissionAuditLogRepository(db)
 
 	// Theme repository (Phase 1)
 	themeRepo := repository.NewThemeRepository(db)
@@ -136,19 +173,46 @@ func main() {
 	// websiteSettingsRepo := repository.NewWebsiteSettingsRepository(db)
 
 	// Initialize use cases
-	authUseCase := usecase.NewAuthUseCase(authRepo, tokenService)
+	sessionUC := usecase.NewSessionUseCase(sessionRepo, userRepo, userRoleRepo, tokenService, refreshExpiry)
+	accountUC := usecase.NewAccountUseCase(authRepo, userRepo, userSettingsRepo, accountTokenRepo, sessionUC, mail, cfg.Mail.AppURL)
+	twoFactorUC := usecase.NewTwoFactorUseCase(twoFactorRepo, userRepo, totpBox, cfg.TOTP.Issuer)
+	loginGuardUC := usecase.NewLoginGuardUseCase(loginThrottleRepo, userRepo, auditLog, mail, cfg.Mail.AppURL)
+
+	// RBAC use cases, needed by single sign-on to apply group role mappings
+	roleUC := usecase.NewRoleUseCase(roleRepo, rolePermissionRepo, moduleDefRepo, auditLogRepo)
+	permissionUC := usecase.NewPermissionUseCase(rolePermissionRepo, userRoleRepo, moduleDefRepo, roleRepo, auditLogRepo)
+	userRoleUC := usecase.NewUserRoleUseCase(userRoleRepo, roleRepo, userRepo, permissionUC, auditLogRepo)
+	// Issuers are fetched server-side; outside development only public https providers are allowed
+	oidcClient := oidc.NewClient(nil)
+	oidcClient.AllowLocal = cfg.Server.Env == "development"
+	ssoUC := usecase.NewSSOUseCase(ssoRepo, userRepo, userRoleRepo, invitationRepo, userRoleUC, oidcClient, ssoBox, cfg.SSO.APIURL, cfg.Mail.AppURL)
+	authUseCase := usecase.NewAuthUseCase(authRepo, sessionUC, accountUC, twoFactorUC, loginGuardUC, ssoUC, tokenService)
 	productUC := usecase.NewProductUseCase(productRepo, notificationRepo, "http://localhost:8080/uploads")
 	// NOTE: User settings use case reserved for Phase 2
 	notificationUC := usecase.NewNotificationUseCase(notificationRepo)
-	orderUC := usecase.NewOrderUseCase(orderRepo, productRepo)
+	dispatchUC := usecase.NewDispatchUseCase(driverRepo, dispatchRepo, orderRepo)
+	orderUC := usecase.NewOrderUseCase(orderRepo, productRepo, feedbackRepo, dispatchUC)
+	diningUC := usecase.NewDiningUseCase(diningRepo, orderRepo, orderUC)
+	orderTrackingUC := usecase.NewOrderTrackingUseCase(orderRepo, feedbackRepo, loginThrottleRepo, tracking.NewSigner(cfg.JWT.Secret))
+	reviewUC := usecase.NewReviewUseCase(feedbackRepo, orderRepo)
+	earningsUC := usecase.NewEarningsUseCase(earningsRepo, driverRepo, dispatchRepo, orderRepo)
+	driverLocationUC := usecase.NewDriverLocationUseCase(locationRepo)
+	driverAppUC := usecase.NewDriverAppUseCase(driverRepo, dispatchRepo, orderRepo, orderUC, dispatchUC, earningsUC, locationRepo, tokenService, "http://localhost:8080/uploads")
+
+	// HR Module use cases
+	attendanceUC := usecase.NewAttendanceUseCase(attendanceRepo, employeeRepo, rotaRepo, hrAuditRepo)
+	rotaUC := usecase.NewRotaUseCase(rotaRepo, employeeRepo, leaveRepo, notificationRepo, attendanceUC, hrAuditRepo)
+	payrollUC := usecase.NewPayrollUseCase(payrollRepo, salaryRepo, employeeRepo, attendanceRepo, leaveRepo, hrAuditRepo)
+	leaveUC := usecase.NewLeaveUseCase(leaveRepo, leavePolicyRepo, employeeRepo, notificationRepo, hrAuditRepo)
+	performanceUC := usecase.NewPerformanceUseCase(performanceRepo, employeeRepo, notificationRepo, hrAuditRepo)
+	hrAuditUC := usecase.NewHRAuditUseCase(hrAuditRepo)
+	selfServiceUC := usecase.NewEmployeeSelfServiceUseCase(employeeRepo, userRepo, leaveRepo, attendanceUC, leaveUC, hrAuditRepo)
 
 	// Driver Management use case
 // 	driverUC := usecase.NewDriverUseCase(driverRepo)
 
-	// RBAC use cases (Phase 2 - commented out, not used in current phase)
-// 	// roleUC := usecase.NewRoleUseCase(roleRepo, auditLogRepo)
-// 	// permissionUC := usecase.NewPermissionUseCase(rolePermissionRepo, userRoleRepo, moduleDefRepo, auditLogRepo)
-// 	// userRoleUC := usecase.NewUserRoleUseCase(userRoleRepo, auditLogRepo)
+	apiKeyUC := usecase.NewAPIKeyUseCase(db, userRepo, permissionUC, auditLog)
+	invitationUC := usecase.NewInvitationUseCase(invitationRepo, userRepo, employeeRepo, userRoleUC, permissionUC, sessionUC, mail, smsSender, cfg.Mail.AppURL)
 
 	// Theme service (Phase 1)
 	themeService := service.NewThemeService(themeRepo)
@@ -162,28 +226,47 @@ func main() {
 	// Initialize handlers
 	restaurantRepo := repository.NewRestaurantRepository(db)
 
-	authHandler := handler.NewAuthHandler(authUseCase)
+	authHandler := handler.NewAuthHandler(authUseCase, sessionUC, accountUC)
+	twoFactorHandler := handler.NewTwoFactorHandler(authUseCase, twoFactorUC)
+	loginSecurityHandler := handler.NewLoginSecurityHandler(loginGuardUC)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyUC)
+	invitationHandler := handler.NewInvitationHandler(invitationUC)
+	ssoHandler := handler.NewSSOHandler(ssoUC, authUseCase)
 	productHandler := handler.NewProductHandler(productUC)
 	categoryHandler := handler.NewCategoryHandler(categoryRepo)
-	publicMenuHandler := handler.NewPublicMenuHandler(productUC, restaurantRepo, categoryRepo)
+	publicMenuHandler := handler.NewPublicMenuHandler(productUC, restaurantRepo, categoryRepo, reviewUC)
 	userSettingsHandler := handler.NewUserSettingsHandler(userSettingsRepo, userRepo)
 	translationHandler := handler.NewTranslationHandler()
 
 	// Order Management handlers
-	publicOrderHandler := handler.NewPublicOrderHandler(orderUC, restaurantRepo)
+	publicOrderHandler := handler.NewPublicOrderHandler(orderUC, diningUC, orderTrackingUC, restaurantRepo)
+	diningHandler := handler.NewDiningHandler(diningUC, restaurantRepo)
+	reviewHandler := handler.NewReviewHandler(reviewUC, restaurantRepo)
+	adminOrderHandler := handler.NewAdminOrderHandler(orderUC)
+	dispatchHandler := handler.NewDispatchHandler(dispatchUC)
+	driverAppHandler := handler.NewDriverAppHandler(driverAppUC)
+	earningsHandler := handler.NewEarningsHandler(earningsUC)
+	driverLocationHandler := handler.NewDriverLocationHandler(driverLocationUC)
 
 	// Driver Management handler
 // 	adminDriverHandler := handler.NewAdminDriverHandler(driverUC, orderUC)
 
 	// HR Module handlers
+	attendanceHandler := handler.NewAttendanceHandler(attendanceUC)
+	rotaHandler := handler.NewRotaHandler(rotaUC)
+	payrollHandler := handler.NewPayrollHandler(payrollUC)
+	leaveHandler := handler.NewLeaveHandler(leaveUC)
+	performanceHandler := handler.NewPerformanceHandler(performanceUC)
+	hrAuditHandler := handler.NewHRAuditHandler(hrAuditUC)
+	selfServiceHandler := handler.NewEmployeeSelfServiceHandler(selfServiceUC)
 
 	// Notification handler
 	notificationHandler := handler.NewNotificationHandler(notificationUC)
 
-	// RBAC handlers (Phase 2)
-// 	rbacRoleHandler := handler.NewRBACRoleHandler(roleUC)
-// 	rbacPermissionHandler := handler.NewRBACPermissionHandler(permissionUC)
-// 	rbacUserRoleHandler := handler.NewRBACUserRoleHandler(userRoleUC)
+	// RBAC handlers
+	rbacRoleHandler := handler.NewRBACRoleHandler(roleUC)
+	rbacPermissionHandler := handler.NewRBACPermissionHandler(permissionUC)
+	rbacUserRoleHandler := handler.NewRBACUserRoleHandler(userRoleUC)
 
 	// Theme handler (Phase 1)
 	themeHandler := handler.NewAdminThemeHandler(themeService, nil)
@@ -204,8 +287,22 @@ func main() {
 	mux.HandleFunc("POST /api/v1/auth/register", authHandler.Register)
 	mux.HandleFunc("POST /api/v1/auth/login", authHandler.Login)
 	mux.HandleFunc("POST /api/v1/auth/login/confirm", authHandler.LoginConfirm)
+	mux.HandleFunc("POST /api/v1/auth/refresh", authHandler.Refresh)
+	mux.HandleFunc("POST /api/v1/auth/logout", authHandler.Logout)
+	mux.HandleFunc("POST /api/v1/driver/auth/login", driverAppHandler.Login)
 	mux.HandleFunc("POST /api/v1/auth/forgot-password", authHandler.ForgotPassword)
+	mux.HandleFunc("POST /api/v1/auth/reset-password", authHandler.ResetPassword)
+	mux.HandleFunc("POST /api/v1/auth/verify-email", authHandler.VerifyEmail)
+	mux.HandleFunc("POST /api/v1/auth/confirm-email-change", authHandler.ConfirmEmailChange)
+	mux.HandleFunc("POST /api/v1/auth/2fa/verify", twoFactorHandler.Verify)
+	mux.HandleFunc("POST /api/v1/auth/2fa/challenge/setup", twoFactorHandler.ChallengeSetup)
+	mux.HandleFunc("POST /api/v1/auth/invitations/lookup", invitationHandler.LookupInvitation)
+	mux.HandleFunc("POST /api/v1/auth/invitations/accept", invitationHandler.AcceptInvitation)
 	mux.HandleFunc("GET /api/v1/auth/check-subdomain", authHandler.CheckSubdomainAvailability)
+	mux.HandleFunc("GET /api/v1/auth/sso", ssoHandler.GetProvider)
+	mux.HandleFunc("GET /api/v1/auth/sso/start", ssoHandler.Start)
+	mux.HandleFunc("GET /api/v1/auth/sso/callback", ssoHandler.Callback)
+	mux.HandleFunc("POST /api/v1/auth/sso/exchange", ssoHandler.Exchange)
 
 	// Public routes - Menu API (no authentication required)
 	mux.HandleFunc("GET /api/v1/public/restaurants/{slug}/menu", publicMenuHandler.GetRestaurantMenu)
@@ -215,6 +312,17 @@ func main() {
 	mux.HandleFunc("GET /api/v1/public/restaurants/{slug}/categories/{categoryId}/products", publicMenuHandler.GetCategoryProducts)
 	mux.HandleFunc("GET /api/v1/public/restaurants/{slug}/products/{productId}", publicMenuHandler.GetProductDetails)
 	mux.HandleFunc("GET /api/v1/public/restaurants/{slug}/search", publicMenuHandler.SearchProducts)
+	mux.HandleFunc("GET /api/v1/public/restaurants/{slug}/search/suggest", publicMenuHandler.SuggestProducts)
+	mux.HandleFunc("GET /api/v1/public/allergens", publicMenuHandler.GetAllergens)
+	mux.HandleFunc("GET /api/v1/public/restaurants/{slug}/products/{productId}/reviews", reviewHandler.ListProductReviews)
+
+	// Public feedback - the review token is returned by order tracking once the order is delivered
+	mux.HandleFunc("GET /api/v1/public/reviews/{token}", reviewHandler.GetReviewRequest)
+	mux.HandleFunc("POST /api/v1/public/reviews/{token}", reviewHandler.SubmitFeedback)
+
+	// Public order tracking and cancellation - slug scoped, verified by customer phone or tracking token (no tenant headers)
+	mux.HandleFunc("GET /api/v1/public/restaurants/{slug}/orders/{orderNumber}", publicOrderHandler.TrackOrderByNumber)
+	mux.HandleFunc("DELETE /api/v1/public/restaurants/{slug}/orders/{orderNumber}", publicOrderHandler.CancelOrder)
 
 	// Public routes - Homepage API (no authentication required)
 	// NOTE: Phase 1 - now enabled for restaurant website preview
@@ -226,15 +334,12 @@ func main() {
 	// Public routes - Order API (minimal authentication required)
 	// These routes require X-Tenant-ID and X-Restaurant-ID headers from middleware context
 	// POST routes for order creation and validation
-	mux.Handle("POST /api/v1/public/orders", middleware.TenantContextMiddleware(http.HandlerFunc(publicOrderHandler.CreateOrder)))
+	// Dine-in guests send the table QR token (X-Table-Token or ?table=) instead of tenant headers
+	mux.Handle("POST /api/v1/public/orders", middleware.TableTokenMiddleware(diningUC)(http.HandlerFunc(publicOrderHandler.CreateOrder)))
 	mux.Handle("POST /api/v1/public/orders/validate", middleware.TenantContextMiddleware(http.HandlerFunc(publicOrderHandler.ValidateOrder)))
 
-	// GET route for order retrieval (supports both by ID and tracking)
-	// Handlers can use request context or query params to determine behavior
-	mux.Handle("GET /api/v1/public/orders/{id}", middleware.TenantContextMiddleware(http.HandlerFunc(publicOrderHandler.GetOrder)))
-
-	// DELETE route for order cancellation
-	mux.Handle("DELETE /api/v1/public/orders/{id}", middleware.TenantContextMiddleware(http.HandlerFunc(publicOrderHandler.CancelOrder)))
+	// Public dine-in route - resolves a scanned table QR code to its restaurant menu
+	mux.HandleFunc("GET /api/v1/public/tables/{token}", diningHandler.ResolveTable)
 
 	// Translation routes (public - no authentication required)
 	mux.HandleFunc("GET /api/v1/translations/health", translationHandler.HealthCheck)
@@ -256,28 +361,56 @@ func main() {
 	// NOTE: Protected routes are now registered individually with middleware wrapping below.
 	// This ensures proper execution order: Auth -> TenantContext -> Handler
 
+	// Access tokens are checked against their session so revocations apply on every instance.
+	// Sessions are not stored in stub mode.
+	var sessionValidator middleware.SessionValidator
+	if db != nil {
+		sessionValidator = sessionUC
+	}
+
+	// Integrations can use tenant API keys on routes that require a module permission; the
+	// key's scopes apply on top of its owner's permissions. Keys need the database.
+	var apiKeyAuth middleware.APIKeyAuthenticator
+	if db != nil {
+		apiKeyAuth = apiKeyUC
+	}
+
 	// Helper function to wrap handlers with auth and tenant middleware
 	wrapProtected := func(next http.Handler) http.Handler {
 		// Apply middleware in REVERSE order of execution
 		// Auth must execute FIRST to set claims in context, then Tenant can read them
 		// The last middleware applied is the outermost and executes first
-		wrapped := middleware.TenantContextMiddleware(next)        // This will be innermost (execute 2nd)
-		wrapped = middleware.AuthMiddleware(tokenService)(wrapped) // This will be outermost (execute 1st)
+		wrapped := middleware.TenantContextMiddleware(next)                               // This will be innermost (execute 2nd)
+		wrapped = middleware.AuthMiddleware(tokenService, sessionValidator, nil)(wrapped) // This will be outermost (execute 1st)
 		return wrapped
 	}
 
 	// Helper function to wrap handlers with auth, tenant, and permission middleware
-	// NOTE: Module IDs should match those created in database migrations (Phase 1)
-	// Common module IDs: Products=1, HR=2, Notifications=3, Orders=4, etc.
+	// NOTE: Module IDs match the modules table (migration 120)
+	// Module IDs: Products=1, HR=2, Notifications=3, Orders=4, Themes=5, Settings=6, Users=8, Roles=9
 	wrapWithPermission := func(next http.Handler, moduleID int64, permissionLevel string) http.Handler {
 		// Apply middleware in REVERSE order of execution
-		wrapped := middleware.WithRequiredPermission(moduleID, permissionLevel)(next)           // innermost
-// 		wrapped = middleware.PermissionMiddleware(permissionUC)(wrapped)                       // permission check
-		wrapped = middleware.TenantContextMiddleware(wrapped)                                  // tenant context
-		wrapped = middleware.AuthMiddleware(tokenService)(wrapped)                            // outermost (auth first)
+		wrapped := middleware.PermissionMiddleware(permissionUC)(next)                           // innermost (permission check)
+		wrapped = middleware.WithRequiredPermission(moduleID, permissionLevel)(wrapped)          // required module and level
+		wrapped = middleware.TenantContextMiddleware(wrapped)                                    // tenant context
+		wrapped = middleware.AuthMiddleware(tokenService, sessionValidator, apiKeyAuth)(wrapped) // outermost (auth first)
 		return wrapped
 	}
 
+	// Helper function for routes on a user's own account ({userId} in the path): the user
+	// only needs to be signed in, while anyone else's account needs the module permission
+	wrapSelfOrPermission := func(next http.Handler, moduleID int64, permissionLevel string) http.Handler {
+		permitted := middleware.PermissionMiddleware(permissionUC)(next)
+		permitted = middleware.WithRequiredPermission(moduleID, permissionLevel)(permitted)
+		return wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if userID, err := strconv.ParseInt(r.PathValue("userId"), 10, 64); err == nil && userID == middleware.GetUserID(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			permitted.ServeHTTP(w, r)
+		}))
+	}
+
 	// Register all protected routes with middleware wrapping
 	// Each route handler is individually wrapped with auth + tenant middleware
 
@@ -288,6 +421,14 @@ func main() {
 		w.Write([]byte(fmt.Sprintf(`{"user_id":%d,"email":"%s"}`, claims.UserID, claims.Email)))
 	})))
 
+	// Employee self-service for users linked to an HR employee record (no HR permission needed)
+	mux.Handle("GET /api/v1/me/employee", wrapProtected(http.HandlerFunc(selfServiceHandler.GetEmployee)))
+	mux.Handle("POST /api/v1/me/attendance/clock-in", wrapProtected(http.HandlerFunc(selfServiceHandler.ClockIn)))
+	mux.Handle("POST /api/v1/me/attendance/clock-out", wrapProtected(http.HandlerFunc(selfServiceHandler.ClockOut)))
+	mux.Handle("GET /api/v1/me/leaves", wrapProtected(http.HandlerFunc(selfServiceHandler.ListLeaves)))
+	mux.Handle("POST /api/v1/me/leaves", wrapProtected(http.HandlerFunc(selfServiceHandler.RequestLeave)))
+	mux.Handle("GET /api/v1/me/leave-balances", wrapProtected(http.HandlerFunc(selfServiceHandler.LeaveBalances)))
+
 	// Product management endpoints (require authentication + RBAC permission)
 	// Module ID 1 = Products (from migrations)
 	mux.Handle("POST /api/v1/products", wrapWithPermission(http.HandlerFunc(productHandler.CreateProduct), 1, "WRITE"))
@@ -306,85 +447,206 @@ func main() {
 
 	// HR Module - Employee management endpoints (require authentication + RBAC permission)
 	// Module ID 2 = HR (from migrations)
-
-	// RBAC - Role management endpoints (Phase 2)
-// 	mux.Handle("GET /api/v1/rbac/roles", wrapProtected(http.HandlerFunc(rbacRoleHandler.ListRoles)))
-// 	mux.Handle("GET /api/v1/rbac/roles/{id}", wrapProtected(http.HandlerFunc(rbacRoleHandler.GetRole)))
-// 	mux.Handle("POST /api/v1/rbac/roles", wrapProtected(http.HandlerFunc(rbacRoleHandler.CreateRole)))
-// 	mux.Handle("PUT /api/v1/rbac/roles/{id}", wrapProtected(http.HandlerFunc(rbacRoleHandler.UpdateRole)))
-// 	mux.Handle("DELETE /api/v1/rbac/roles/{id}", wrapProtected(http.HandlerFunc(rbacRoleHandler.DeleteRole)))
-// 
-// 	// RBAC - Permission management endpoints (Phase 2)
-// 	mux.Handle("POST /api/v1/rbac/permissions/assign", wrapProtected(http.HandlerFunc(rbacPermissionHandler.AssignPermission)))
-// 	mux.Handle("DELETE /api/v1/rbac/permissions/{roleId}/{moduleId}", wrapProtected(http.HandlerFunc(rbacPermissionHandler.RevokePermission)))
-// 	mux.Handle("GET /api/v1/rbac/roles/{roleId}/permissions", wrapProtected(http.HandlerFunc(rbacPermissionHandler.GetRolePermissions)))
-// 	mux.Handle("GET /api/v1/rbac/check-permission", wrapProtected(http.HandlerFunc(rbacPermissionHandler.CheckPermission)))
-// 	mux.Handle("GET /api/v1/rbac/me/permissions", wrapProtected(http.HandlerFunc(rbacPermissionHandler.GetUserPermissions)))
-// 
-// 	// RBAC - User-role assignment endpoints (Phase 2)
-// 	mux.Handle("POST /api/v1/rbac/users/{userId}/roles", wrapProtected(http.HandlerFunc(rbacUserRoleHandler.AssignRoleToUser)))
-// 	mux.Handle("DELETE /api/v1/rbac/users/{userId}/roles/{roleId}", wrapProtected(http.HandlerFunc(rbacUserRoleHandler.RemoveRoleFromUser)))
-// 	mux.Handle("GET /api/v1/rbac/users/{userId}/roles", wrapProtected(http.HandlerFunc(rbacUserRoleHandler.GetUserRoles)))
-// 	mux.Handle("GET /api/v1/rbac/me/roles", wrapProtected(http.HandlerFunc(rbacUserRoleHandler.GetMyRoles)))
-// 	mux.Handle("POST /api/v1/rbac/users-by-email/{email}/roles", wrapProtected(http.HandlerFunc(rbacUserRoleHandler.AssignRoleToUserByEmail)))
-// 	mux.Handle("DELETE /api/v1/rbac/users-by-email/{email}/roles/{roleId}", wrapProtected(http.HandlerFunc(rbacUserRoleHandler.RemoveRoleFromUserByEmail)))
-// 	mux.Handle("GET /api/v1/rbac/users-by-email/{email}/roles", wrapProtected(http.HandlerFunc(rbacUserRoleHandler.GetUserRolesByEmail)))
+	mux.Handle("PUT /api/v1/hr/employees/{id}/user", wrapWithPermission(http.HandlerFunc(selfServiceHandler.LinkUser), 2, "WRITE"))
+
+	// RBAC - Role management endpoints
+	// Module ID 9 = Roles & Permissions (from migrations)
+	mux.Handle("GET /api/v1/rbac/roles", wrapWithPermission(http.HandlerFunc(rbacRoleHandler.ListRoles), 9, "READ"))
+	mux.Handle("GET /api/v1/rbac/roles/{id}", wrapWithPermission(http.HandlerFunc(rbacRoleHandler.GetRole), 9, "READ"))
+	mux.Handle("POST /api/v1/rbac/roles", wrapWithPermission(http.HandlerFunc(rbacRoleHandler.CreateRole), 9, "WRITE"))
+	mux.Handle("PUT /api/v1/rbac/roles/{id}", wrapWithPermission(http.HandlerFunc(rbacRoleHandler.UpdateRole), 9, "WRITE"))
+	mux.Handle("DELETE /api/v1/rbac/roles/{id}", wrapWithPermission(http.HandlerFunc(rbacRoleHandler.DeleteRole), 9, "DELETE"))
+
+	// RBAC - Permission management endpoints
+	mux.Handle("GET /api/v1/rbac/modules", wrapWithPermission(http.HandlerFunc(rbacPermissionHandler.ListModules), 9, "READ"))
+	mux.Handle("POST /api/v1/rbac/permissions/assign", wrapWithPermission(http.HandlerFunc(rbacPermissionHandler.AssignPermission), 9, "WRITE"))
+	mux.Handle("DELETE /api/v1/rbac/permissions/{roleId}/{moduleId}", wrapWithPermission(http.HandlerFunc(rbacPermissionHandler.RevokePermission), 9, "DELETE"))
+	mux.Handle("GET /api/v1/rbac/roles/{roleId}/permissions", wrapWithPermission(http.HandlerFunc(rbacPermissionHandler.GetRolePermissions), 9, "READ"))
+	mux.Handle("GET /api/v1/rbac/audit-logs", wrapWithPermission(http.HandlerFunc(rbacPermissionHandler.ListAuditLogs), 9, "READ"))
+	mux.Handle("GET /api/v1/rbac/check-permission", wrapProtected(http.HandlerFunc(rbacPermissionHandler.CheckPermission)))
+	mux.Handle("GET /api/v1/rbac/me/permissions", wrapProtected(http.HandlerFunc(rbacPermissionHandler.GetUserPermissions)))
+
+	// RBAC - User-role assignment endpoints
+	mux.Handle("POST /api/v1/rbac/users/{userId}/roles", wrapWithPermission(http.HandlerFunc(rbacUserRoleHandler.AssignRoleToUser), 9, "WRITE"))
+	mux.Handle("DELETE /api/v1/rbac/users/{userId}/roles/{roleId}", wrapWithPermission(http.HandlerFunc(rbacUserRoleHandler.RemoveRoleFromUser), 9, "DELETE"))
+	mux.Handle("GET /api/v1/rbac/users/{userId}/roles", wrapWithPermission(http.HandlerFunc(rbacUserRoleHandler.GetUserRoles), 9, "READ"))
+	mux.Handle("GET /api/v1/rbac/users/{userId}/role-assignments", wrapWithPermission(http.HandlerFunc(rbacUserRoleHandler.GetUserRoleAssignments), 9, "READ"))
+	mux.Handle("GET /api/v1/rbac/me/roles", wrapProtected(http.HandlerFunc(rbacUserRoleHandler.GetMyRoles)))
+	mux.Handle("POST /api/v1/rbac/users-by-email/{email}/roles", wrapWithPermission(http.HandlerFunc(rbacUserRoleHandler.AssignRoleToUserByEmail), 9, "WRITE"))
+	mux.Handle("DELETE /api/v1/rbac/users-by-email/{email}/roles/{roleId}", wrapWithPermission(http.HandlerFunc(rbacUserRoleHandler.RemoveRoleFromUserByEmail), 9, "DELETE"))
+	mux.Handle("GET /api/v1/rbac/users-by-email/{email}/roles", wrapWithPermission(http.HandlerFunc(rbacUserRoleHandler.GetUserRolesByEmail), 9, "READ"))
 
 	// HR Module - Attendance management endpoints (require authentication + RBAC permission)
 	// Module ID 2 = HR (from migrations)
+	mux.Handle("GET /api/v1/hr/attendance", wrapWithPermission(http.HandlerFunc(attendanceHandler.ListAttendance), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/attendance/clock-in", wrapWithPermission(http.HandlerFunc(attendanceHandler.ClockIn), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/attendance/clock-out", wrapWithPermission(http.HandlerFunc(attendanceHandler.ClockOut), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/attendance/break-start", wrapWithPermission(http.HandlerFunc(attendanceHandler.StartBreak), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/attendance/break-end", wrapWithPermission(http.HandlerFunc(attendanceHandler.EndBreak), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/attendance/overtime", wrapWithPermission(http.HandlerFunc(attendanceHandler.ListPendingOvertime), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/attendance/{id}/approve-overtime", wrapWithPermission(http.HandlerFunc(attendanceHandler.ApproveOvertime), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/time-clock/kiosk", wrapWithPermission(http.HandlerFunc(attendanceHandler.KioskPunch), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/time-clock/settings", wrapWithPermission(http.HandlerFunc(attendanceHandler.GetSettings), 2, "READ"))
+	mux.Handle("PUT /api/v1/hr/time-clock/settings", wrapWithPermission(http.HandlerFunc(attendanceHandler.SaveSettings), 2, "WRITE"))
+	mux.Handle("PUT /api/v1/hr/employees/{id}/clock-credentials", wrapWithPermission(http.HandlerFunc(attendanceHandler.SetClockCredentials), 2, "WRITE"))
+
+	// HR Module - Shift scheduling endpoints (require authentication + RBAC permission)
+	// Module ID 2 = HR (from migrations)
+	mux.Handle("GET /api/v1/hr/shift-templates", wrapWithPermission(http.HandlerFunc(rotaHandler.ListShiftTemplates), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/shift-templates", wrapWithPermission(http.HandlerFunc(rotaHandler.CreateShiftTemplate), 2, "WRITE"))
+	mux.Handle("PUT /api/v1/hr/shift-templates/{id}", wrapWithPermission(http.HandlerFunc(rotaHandler.UpdateShiftTemplate), 2, "WRITE"))
+	mux.Handle("DELETE /api/v1/hr/shift-templates/{id}", wrapWithPermission(http.HandlerFunc(rotaHandler.DeleteShiftTemplate), 2, "DELETE"))
+	mux.Handle("GET /api/v1/hr/staffing-requirements", wrapWithPermission(http.HandlerFunc(rotaHandler.ListStaffingRequirements), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/staffing-requirements", wrapWithPermission(http.HandlerFunc(rotaHandler.CreateStaffingRequirement), 2, "WRITE"))
+	mux.Handle("DELETE /api/v1/hr/staffing-requirements/{id}", wrapWithPermission(http.HandlerFunc(rotaHandler.DeleteStaffingRequirement), 2, "DELETE"))
+	mux.Handle("GET /api/v1/hr/rotas", wrapWithPermission(http.HandlerFunc(rotaHandler.ListRotas), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/rotas", wrapWithPermission(http.HandlerFunc(rotaHandler.CreateRota), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/rotas/{id}", wrapWithPermission(http.HandlerFunc(rotaHandler.GetRota), 2, "READ"))
+	mux.Handle("GET /api/v1/hr/rotas/{id}/conflicts", wrapWithPermission(http.HandlerFunc(rotaHandler.GetConflicts), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/rotas/{id}/shifts", wrapWithPermission(http.HandlerFunc(rotaHandler.AddShift), 2, "WRITE"))
+	mux.Handle("PUT /api/v1/hr/rotas/{id}/shifts/{shiftId}", wrapWithPermission(http.HandlerFunc(rotaHandler.UpdateShift), 2, "WRITE"))
+	mux.Handle("DELETE /api/v1/hr/rotas/{id}/shifts/{shiftId}", wrapWithPermission(http.HandlerFunc(rotaHandler.DeleteShift), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/rotas/{id}/publish", wrapWithPermission(http.HandlerFunc(rotaHandler.PublishRota), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/rotas/{id}/unpublish", wrapWithPermission(http.HandlerFunc(rotaHandler.UnpublishRota), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/employees/{id}/shifts", wrapWithPermission(http.HandlerFunc(rotaHandler.ListEmployeeShifts), 2, "READ"))
 
 	// HR Module - Leave management endpoints (require authentication + RBAC permission)
 	// Module ID 2 = HR (from migrations)
+	mux.Handle("GET /api/v1/hr/leave-policies", wrapWithPermission(http.HandlerFunc(leaveHandler.ListPolicies), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/leave-policies", wrapWithPermission(http.HandlerFunc(leaveHandler.CreatePolicy), 2, "WRITE"))
+	mux.Handle("PUT /api/v1/hr/leave-policies/{id}", wrapWithPermission(http.HandlerFunc(leaveHandler.UpdatePolicy), 2, "WRITE"))
+	mux.Handle("DELETE /api/v1/hr/leave-policies/{id}", wrapWithPermission(http.HandlerFunc(leaveHandler.DeletePolicy), 2, "DELETE"))
+	mux.Handle("GET /api/v1/hr/holiday-calendars", wrapWithPermission(http.HandlerFunc(leaveHandler.ListCalendars), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/holiday-calendars", wrapWithPermission(http.HandlerFunc(leaveHandler.CreateCalendar), 2, "WRITE"))
+	mux.Handle("PUT /api/v1/hr/holiday-calendars/{id}", wrapWithPermission(http.HandlerFunc(leaveHandler.UpdateCalendar), 2, "WRITE"))
+	mux.Handle("DELETE /api/v1/hr/holiday-calendars/{id}", wrapWithPermission(http.HandlerFunc(leaveHandler.DeleteCalendar), 2, "DELETE"))
+	mux.Handle("POST /api/v1/hr/holiday-calendars/{id}/holidays", wrapWithPermission(http.HandlerFunc(leaveHandler.AddHoliday), 2, "WRITE"))
+	mux.Handle("DELETE /api/v1/hr/holiday-calendars/{id}/holidays/{holidayId}", wrapWithPermission(http.HandlerFunc(leaveHandler.DeleteHoliday), 2, "DELETE"))
+	mux.Handle("GET /api/v1/hr/leaves", wrapWithPermission(http.HandlerFunc(leaveHandler.ListLeaves), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/leaves", wrapWithPermission(http.HandlerFunc(leaveHandler.RequestLeave), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/leaves/pending-approvals", wrapWithPermission(http.HandlerFunc(leaveHandler.PendingApprovals), 2, "READ"))
+	mux.Handle("GET /api/v1/hr/leaves/{id}", wrapWithPermission(http.HandlerFunc(leaveHandler.GetLeave), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/leaves/{id}/approve", wrapWithPermission(http.HandlerFunc(leaveHandler.ApproveLeave), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/leaves/{id}/reject", wrapWithPermission(http.HandlerFunc(leaveHandler.RejectLeave), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/leaves/{id}/cancel", wrapWithPermission(http.HandlerFunc(leaveHandler.CancelLeave), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/employees/{id}/leave-balances", wrapWithPermission(http.HandlerFunc(leaveHandler.EmployeeBalances), 2, "READ"))
+	mux.Handle("GET /api/v1/hr/employees/{id}/leave-balances/history", wrapWithPermission(http.HandlerFunc(leaveHandler.BalanceHistory), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/employees/{id}/leave-balances/adjust", wrapWithPermission(http.HandlerFunc(leaveHandler.AdjustBalance), 2, "WRITE"))
+
+	// HR Module - Performance review endpoints (require authentication + RBAC permission)
+	// Module ID 2 = HR (from migrations)
+	mux.Handle("GET /api/v1/hr/review-criteria", wrapWithPermission(http.HandlerFunc(performanceHandler.ListCriteria), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/review-criteria", wrapWithPermission(http.HandlerFunc(performanceHandler.CreateCriterion), 2, "WRITE"))
+	mux.Handle("PUT /api/v1/hr/review-criteria/{id}", wrapWithPermission(http.HandlerFunc(performanceHandler.UpdateCriterion), 2, "WRITE"))
+	mux.Handle("DELETE /api/v1/hr/review-criteria/{id}", wrapWithPermission(http.HandlerFunc(performanceHandler.DeleteCriterion), 2, "DELETE"))
+	mux.Handle("GET /api/v1/hr/review-cycles", wrapWithPermission(http.HandlerFunc(performanceHandler.ListCycles), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/review-cycles", wrapWithPermission(http.HandlerFunc(performanceHandler.CreateCycle), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/review-cycles/{id}", wrapWithPermission(http.HandlerFunc(performanceHandler.GetCycle), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/review-cycles/{id}/close", wrapWithPermission(http.HandlerFunc(performanceHandler.CloseCycle), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/performance-reviews/pending", wrapWithPermission(http.HandlerFunc(performanceHandler.PendingReviews), 2, "READ"))
+	mux.Handle("GET /api/v1/hr/performance-reviews/{id}", wrapWithPermission(http.HandlerFunc(performanceHandler.GetReview), 2, "READ"))
+	mux.Handle("PUT /api/v1/hr/performance-reviews/{id}/self-assessment", wrapWithPermission(http.HandlerFunc(performanceHandler.SubmitSelfAssessment), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/performance-reviews/{id}/manager-assessment", wrapWithPermission(http.HandlerFunc(performanceHandler.SubmitManagerAssessment), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/performance-reviews/{id}/acknowledge", wrapWithPermission(http.HandlerFunc(performanceHandler.AcknowledgeReview), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/performance-reviews/{id}/finalize", wrapWithPermission(http.HandlerFunc(performanceHandler.FinalizeReview), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/employees/{id}/performance-reviews", wrapWithPermission(http.HandlerFunc(performanceHandler.EmployeeReviews), 2, "READ"))
+	mux.Handle("GET /api/v1/hr/employees/{id}/profile", wrapWithPermission(http.HandlerFunc(performanceHandler.EmployeeProfile), 2, "READ"))
+	mux.Handle("GET /api/v1/hr/employees/{id}/goals", wrapWithPermission(http.HandlerFunc(performanceHandler.EmployeeGoals), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/employees/{id}/goals", wrapWithPermission(http.HandlerFunc(performanceHandler.CreateGoal), 2, "WRITE"))
+	mux.Handle("PUT /api/v1/hr/goals/{id}", wrapWithPermission(http.HandlerFunc(performanceHandler.UpdateGoal), 2, "WRITE"))
+
+	// HR Module - Audit trail of HR changes
+	mux.Handle("GET /api/v1/hr/audit-logs", wrapWithPermission(http.HandlerFunc(hrAuditHandler.ListAuditLogs), 2, "READ"))
 
 	// HR Module - Salary/Payroll management endpoints (require authentication + RBAC permission)
 	// Module ID 2 = HR (from migrations)
-
-	// User Settings Management endpoints (require authentication)
+	mux.Handle("GET /api/v1/hr/payroll/settings", wrapWithPermission(http.HandlerFunc(payrollHandler.GetSettings), 2, "READ"))
+	mux.Handle("PUT /api/v1/hr/payroll/settings", wrapWithPermission(http.HandlerFunc(payrollHandler.SaveSettings), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/payroll/deduction-rules", wrapWithPermission(http.HandlerFunc(payrollHandler.ListDeductionRules), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/payroll/deduction-rules", wrapWithPermission(http.HandlerFunc(payrollHandler.CreateDeductionRule), 2, "WRITE"))
+	mux.Handle("PUT /api/v1/hr/payroll/deduction-rules/{id}", wrapWithPermission(http.HandlerFunc(payrollHandler.UpdateDeductionRule), 2, "WRITE"))
+	mux.Handle("DELETE /api/v1/hr/payroll/deduction-rules/{id}", wrapWithPermission(http.HandlerFunc(payrollHandler.DeleteDeductionRule), 2, "DELETE"))
+	mux.Handle("GET /api/v1/hr/payroll/runs", wrapWithPermission(http.HandlerFunc(payrollHandler.ListRuns), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/payroll/runs", wrapWithPermission(http.HandlerFunc(payrollHandler.CreateRun), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/payroll/runs/{id}", wrapWithPermission(http.HandlerFunc(payrollHandler.GetRun), 2, "READ"))
+	mux.Handle("POST /api/v1/hr/payroll/runs/{id}/recalculate", wrapWithPermission(http.HandlerFunc(payrollHandler.RecalculateRun), 2, "WRITE"))
+	mux.Handle("PUT /api/v1/hr/payroll/runs/{id}/salaries/{salaryId}", wrapWithPermission(http.HandlerFunc(payrollHandler.AdjustSalary), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/payroll/runs/{id}/approve", wrapWithPermission(http.HandlerFunc(payrollHandler.ApproveRun), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/payroll/runs/{id}/pay", wrapWithPermission(http.HandlerFunc(payrollHandler.PayRun), 2, "WRITE"))
+	mux.Handle("POST /api/v1/hr/payroll/runs/{id}/cancel", wrapWithPermission(http.HandlerFunc(payrollHandler.CancelRun), 2, "WRITE"))
+	mux.Handle("GET /api/v1/hr/payroll/runs/{id}/export", wrapWithPermission(http.HandlerFunc(payrollHandler.ExportRun), 2, "READ"))
+	mux.Handle("GET /api/v1/hr/payroll/runs/{id}/payslips/{salaryId}", wrapWithPermission(http.HandlerFunc(payrollHandler.ExportPayslip), 2, "READ"))
+
+	// Session management endpoints (require authentication)
+	mux.Handle("GET /api/v1/auth/sessions", wrapProtected(http.HandlerFunc(authHandler.ListSessions)))
+	mux.Handle("DELETE /api/v1/auth/sessions", wrapProtected(http.HandlerFunc(authHandler.RevokeOtherSessions)))
+	mux.Handle("DELETE /api/v1/auth/sessions/{id}", wrapProtected(http.HandlerFunc(authHandler.RevokeSession)))
+	mux.Handle("GET /api/v1/auth/restaurants", wrapProtected(http.HandlerFunc(authHandler.ListRestaurants)))
+	mux.Handle("POST /api/v1/auth/switch-restaurant", wrapProtected(http.HandlerFunc(authHandler.SwitchRestaurant)))
+	mux.Handle("POST /api/v1/auth/verify-email/resend", wrapProtected(http.HandlerFunc(authHandler.ResendEmailVerification)))
+
+	// Two-factor authentication of the signed-in user
+	mux.Handle("GET /api/v1/auth/2fa", wrapProtected(http.HandlerFunc(twoFactorHandler.Status)))
+	mux.Handle("POST /api/v1/auth/2fa/setup", wrapProtected(http.HandlerFunc(twoFactorHandler.Setup)))
+	mux.Handle("POST /api/v1/auth/2fa/enable", wrapProtected(http.HandlerFunc(twoFactorHandler.Enable)))
+	mux.Handle("POST /api/v1/auth/2fa/disable", wrapProtected(http.HandlerFunc(twoFactorHandler.Disable)))
+	mux.Handle("POST /api/v1/auth/2fa/recovery-codes", wrapProtected(http.HandlerFunc(twoFactorHandler.RegenerateRecoveryCodes)))
+	mux.Handle("DELETE /api/v1/auth/2fa/trusted-devices", wrapProtected(http.HandlerFunc(twoFactorHandler.ForgetTrustedDevices)))
+
+	// Sessions of tenant users (Module ID 8 = Users)
+	mux.Handle("GET /api/v1/users/{userId}/sessions", wrapWithPermission(http.HandlerFunc(authHandler.ListUserSessions), 8, "READ"))
+	mux.Handle("DELETE /api/v1/users/{userId}/sessions", wrapWithPermission(http.HandlerFunc(authHandler.RevokeUserSessions), 8, "DELETE"))
+	mux.Handle("DELETE /api/v1/users/{userId}/sessions/{id}", wrapWithPermission(http.HandlerFunc(authHandler.RevokeUserSession), 8, "DELETE"))
+	mux.Handle("DELETE /api/v1/users/{userId}/2fa", wrapWithPermission(http.HandlerFunc(twoFactorHandler.ResetUser), 8, "DELETE"))
+	mux.Handle("GET /api/v1/users/{userId}/login-status", wrapWithPermission(http.HandlerFunc(loginSecurityHandler.Status), 8, "READ"))
+	mux.Handle("POST /api/v1/users/{userId}/unlock", wrapWithPermission(http.HandlerFunc(loginSecurityHandler.Unlock), 8, "WRITE"))
+
+	// Staff invitations (Module ID 8 = Users); pre-assigning roles also needs Roles WRITE
+	mux.Handle("GET /api/v1/users/invitations", wrapWithPermission(http.HandlerFunc(invitationHandler.ListInvitations), 8, "READ"))
+	mux.Handle("POST /api/v1/users/invitations", wrapWithPermission(http.HandlerFunc(invitationHandler.CreateInvitation), 8, "WRITE"))
+	mux.Handle("POST /api/v1/users/invitations/{id}/resend", wrapWithPermission(http.HandlerFunc(invitationHandler.ResendInvitation), 8, "WRITE"))
+	mux.Handle("DELETE /api/v1/users/invitations/{id}", wrapWithPermission(http.HandlerFunc(invitationHandler.RevokeInvitation), 8, "DELETE"))
+
+	// Tenant two-factor policy (Module ID 6 = Settings)
+	mux.Handle("GET /api/v1/settings/two-factor", wrapWithPermission(http.HandlerFunc(twoFactorHandler.GetPolicy), 6, "READ"))
+	mux.Handle("PUT /api/v1/settings/two-factor", wrapWithPermission(http.HandlerFunc(twoFactorHandler.UpdatePolicy), 6, "WRITE"))
+
+	// Tenant single sign-on (Module ID 6 = Settings); role mappings also need Roles WRITE
+	mux.Handle("GET /api/v1/sso/oidc", wrapWithPermission(http.HandlerFunc(ssoHandler.GetConfig), 6, "READ"))
+	mux.Handle("PUT /api/v1/sso/oidc", wrapWithPermission(http.HandlerFunc(ssoHandler.SaveConfig), 6, "WRITE"))
+	mux.Handle("DELETE /api/v1/sso/oidc", wrapWithPermission(http.HandlerFunc(ssoHandler.DeleteConfig), 6, "DELETE"))
+
+	// Tenant API keys for integrations (Module ID 6 = Settings)
+	mux.Handle("GET /api/v1/api-keys", wrapWithPermission(http.HandlerFunc(apiKeyHandler.ListAPIKeys), 6, "READ"))
+	mux.Handle("POST /api/v1/api-keys", wrapWithPermission(http.HandlerFunc(apiKeyHandler.CreateAPIKey), 6, "WRITE"))
+	mux.Handle("PUT /api/v1/api-keys/{id}", wrapWithPermission(http.HandlerFunc(apiKeyHandler.UpdateAPIKey), 6, "WRITE"))
+	mux.Handle("DELETE /api/v1/api-keys/{id}", wrapWithPermission(http.HandlerFunc(apiKeyHandler.RevokeAPIKey), 6, "DELETE"))
+	mux.Handle("GET /api/v1/api-keys/{id}/usage", wrapWithPermission(http.HandlerFunc(apiKeyHandler.GetAPIKeyUsage), 6, "READ"))
+
+	// User Settings Management endpoints (Module ID 8 = Users)
 	// Use stub handlers when db is nil
 	if db == nil {
-		mux.Handle("GET /api/v1/users", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Handle("GET /api/v1/users", wrapWithPermission(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
 			w.Write([]byte(`{"success":true,"data":[{"id":7,"email":"yoseabdallah866@gmail.com","name":"Admin User","role":"owner","created_at":"2024-01-01T00:00:00Z"}]}`))
-		})))
+		}), 8, "READ"))
 	} else {
-		mux.Handle("GET /api/v1/users", wrapProtected(http.HandlerFunc(userSettingsHandler.ListUsers)))
+		mux.Handle("GET /api/v1/users", wrapWithPermission(http.HandlerFunc(userSettingsHandler.ListUsers), 8, "READ"))
 	}
-	mux.Handle("GET /api/v1/users/{userId}/settings", wrapProtected(http.HandlerFunc(userSettingsHandler.GetUserSettings)))
-	mux.Handle("PUT /api/v1/users/{userId}/settings/language", wrapProtected(http.HandlerFunc(userSettingsHandler.UpdateLanguage)))
-	mux.Handle("PUT /api/v1/users/{userId}/settings/theme", wrapProtected(http.HandlerFunc(userSettingsHandler.UpdateTheme)))
-	mux.Handle("PUT /api/v1/users/{userId}/settings/theme-colors", wrapProtected(http.HandlerFunc(userSettingsHandler.UpdateColors)))
-	mux.Handle("POST /api/v1/users/{userId}/settings/change-password", wrapProtected(http.HandlerFunc(userSettingsHandler.ChangePassword)))
-	mux.Handle("GET /api/v1/users/{userId}/profile", wrapProtected(http.HandlerFunc(userSettingsHandler.GetUserProfile)))
-	mux.Handle("PUT /api/v1/users/{userId}/profile", wrapProtected(http.HandlerFunc(userSettingsHandler.UpdateProfile)))
-
-	// User Management endpoints (create, update, delete users in tenant)
+	mux.Handle("GET /api/v1/users/{userId}/settings", wrapSelfOrPermission(http.HandlerFunc(userSettingsHandler.GetUserSettings), 8, "READ"))
+	mux.Handle("PUT /api/v1/users/{userId}/settings/language", wrapSelfOrPermission(http.HandlerFunc(userSettingsHandler.UpdateLanguage), 8, "WRITE"))
+	mux.Handle("PUT /api/v1/users/{userId}/settings/theme", wrapSelfOrPermission(http.HandlerFunc(userSettingsHandler.UpdateTheme), 8, "WRITE"))
+	mux.Handle("PUT /api/v1/users/{userId}/settings/theme-colors", wrapSelfOrPermission(http.HandlerFunc(userSettingsHandler.UpdateColors), 8, "WRITE"))
+	mux.Handle("POST /api/v1/users/{userId}/settings/change-password", wrapSelfOrPermission(http.HandlerFunc(userSettingsHandler.ChangePassword), 8, "WRITE"))
+	mux.Handle("POST /api/v1/users/{userId}/settings/change-email", wrapSelfOrPermission(http.HandlerFunc(authHandler.ChangeEmail), 8, "WRITE"))
+	mux.Handle("GET /api/v1/users/{userId}/profile", wrapSelfOrPermission(http.HandlerFunc(userSettingsHandler.GetUserProfile), 8, "READ"))
+	mux.Handle("PUT /api/v1/users/{userId}/profile", wrapSelfOrPermission(http.HandlerFunc(userSettingsHandler.UpdateProfile), 8, "WRITE"))
+
+	// User Management endpoints (update, delete users in tenant; Module ID 8 = Users)
+	// New users only join through invitations (POST /api/v1/users/invitations)
 	if db == nil {
 		// Stub handlers for user management when db is nil
-		mux.Handle("POST /api/v1/users", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			body, _ := io.ReadAll(r.Body)
-			var req struct {
-				Name     string `json:"name"`
-				Email    string `json:"email"`
-				Phone    string `json:"phone"`
-				Password string `json:"password"`
-			}
-			json.Unmarshal(body, &req)
-
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": true,
-				"data": map[string]interface{}{
-					"id":    8,
-					"email": req.Email,
-					"name":  req.Name,
-					"phone": req.Phone,
-					"status": "active",
-					"created_at": time.Now(),
-				},
-			})
-		})))
-
-		mux.Handle("PUT /api/v1/users/{id}", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Handle("PUT /api/v1/users/{id}", wrapWithPermission(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			id := r.PathValue("id")
 			body, _ := io.ReadAll(r.Body)
 			var req struct {
@@ -403,64 +665,17 @@ func main() {
 					"updated_at": time.Now(),
 				},
 			})
-		})))
+		}), 8, "WRITE"))
 
-		mux.Handle("DELETE /api/v1/users/{id}", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Handle("DELETE /api/v1/users/{id}", wrapWithPermission(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			w.Header().Set("Content-Type", "application/json")
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"success": true,
 			})
-		})))
+		}), 8, "DELETE"))
 	} else {
 		// Real database handlers for user management
-		mux.Handle("POST /api/v1/users", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			claims := middleware.GetUserClaims(r)
-			body, err := io.ReadAll(r.Body)
-			if err != nil {
-				http.Error(w, "Failed to read request body", http.StatusBadRequest)
-				return
-			}
-
-			var req struct {
-				Name     string `json:"name"`
-				Email    string `json:"email"`
-				Phone    string `json:"phone"`
-				Password string `json:"password"`
-			}
-			if err := json.Unmarshal(body, &req); err != nil {
-				http.Error(w, "Invalid request body", http.StatusBadRequest)
-				return
-			}
-
-			// Hash password
-			hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-			if err != nil {
-				http.Error(w, "Failed to hash password", http.StatusInternalServerError)
-				return
-			}
-
-			// Create user
-			user, err := userRepo.CreateUserInTenant(r.Context(), int64(claims.TenantID), req.Name, req.Email, req.Phone, string(hashedPassword), nil)
-			if err != nil {
-				http.Error(w, fmt.Sprintf("Failed to create user: %v", err), http.StatusInternalServerError)
-				return
-			}
-
-			w.Header().Set("Content-Type", "application/json")
-			json.NewEncoder(w).Encode(map[string]interface{}{
-				"success": true,
-				"data": map[string]interface{}{
-					"id":         user.ID,
-					"email":      user.Email,
-					"name":       user.Name,
-					"phone":      user.Phone,
-					"status":     user.Status,
-					"created_at": user.CreatedAt,
-				},
-			})
-		})))
-
-		mux.Handle("PUT /api/v1/users/{id}", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Handle("PUT /api/v1/users/{id}", wrapWithPermission(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			claims := middleware.GetUserClaims(r)
 			idStr := r.PathValue("id")
 			userID, err := strconv.ParseInt(idStr, 10, 64)
@@ -510,9 +725,9 @@ func main() {
 					"updated_at": user.UpdatedAt,
 				},
 			})
-		})))
+		}), 8, "WRITE"))
 
-		mux.Handle("DELETE /api/v1/users/{id}", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.Handle("DELETE /api/v1/users/{id}", wrapWithPermission(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			claims := middleware.GetUserClaims(r)
 			idStr := r.PathValue("id")
 			userID, err := strconv.ParseInt(idStr, 10, 64)
@@ -532,11 +747,71 @@ func main() {
 			json.NewEncoder(w).Encode(map[string]interface{}{
 				"success": true,
 			})
-		})))
+		}), 8, "DELETE"))
 	}
 
 	// Admin Order Management endpoints (require authentication)
 
+	// Dine-in table and tab management (Module ID 4 = Orders)
+	mux.Handle("GET /api/v1/dining/tables", wrapWithPermission(http.HandlerFunc(diningHandler.ListTables), 4, "READ"))
+	mux.Handle("POST /api/v1/dining/tables", wrapWithPermission(http.HandlerFunc(diningHandler.CreateTable), 4, "WRITE"))
+	mux.Handle("PUT /api/v1/dining/tables/{id}", wrapWithPermission(http.HandlerFunc(diningHandler.UpdateTable), 4, "WRITE"))
+	mux.Handle("POST /api/v1/dining/tables/{id}/regenerate-token", wrapWithPermission(http.HandlerFunc(diningHandler.RegenerateTableToken), 4, "WRITE"))
+	mux.Handle("DELETE /api/v1/dining/tables/{id}", wrapWithPermission(http.HandlerFunc(diningHandler.DeleteTable), 4, "DELETE"))
+	mux.Handle("GET /api/v1/dining/tabs", wrapWithPermission(http.HandlerFunc(diningHandler.ListOpenTabs), 4, "READ"))
+	mux.Handle("GET /api/v1/dining/tabs/{id}", wrapWithPermission(http.HandlerFunc(diningHandler.GetTab), 4, "READ"))
+	mux.Handle("GET /api/v1/dining/tabs/{id}/bill", wrapWithPermission(http.HandlerFunc(diningHandler.GetTabBill), 4, "READ"))
+	mux.Handle("POST /api/v1/dining/tabs/{id}/transfer", wrapWithPermission(http.HandlerFunc(diningHandler.TransferTab), 4, "WRITE"))
+	mux.Handle("POST /api/v1/dining/tabs/{id}/merge", wrapWithPermission(http.HandlerFunc(diningHandler.MergeTabs), 4, "WRITE"))
+	mux.Handle("POST /api/v1/dining/tabs/{id}/close", wrapWithPermission(http.HandlerFunc(diningHandler.CloseTab), 4, "WRITE"))
+
+	// Order status by staff - marking a delivery order ready starts dispatch (Module ID 4 = Orders)
+	mux.Handle("PUT /api/v1/admin/orders/{id}/status", wrapWithPermission(http.HandlerFunc(adminOrderHandler.UpdateOrderStatus), 4, "WRITE"))
+
+	// Driver dispatch - automatic offers with dispatcher override (Module ID 4 = Orders)
+	mux.Handle("GET /api/v1/dispatch/orders/{id}", wrapWithPermission(http.HandlerFunc(dispatchHandler.GetDispatchStatus), 4, "READ"))
+	mux.Handle("POST /api/v1/dispatch/orders/{id}", wrapWithPermission(http.HandlerFunc(dispatchHandler.DispatchOrder), 4, "WRITE"))
+	mux.Handle("POST /api/v1/dispatch/orders/{id}/assign", wrapWithPermission(http.HandlerFunc(dispatchHandler.ManualAssign), 4, "WRITE"))
+	mux.Handle("POST /api/v1/dispatch/offers/{id}/accept", wrapWithPermission(http.HandlerFunc(dispatchHandler.AcceptOffer), 4, "WRITE"))
+	mux.Handle("POST /api/v1/dispatch/offers/{id}/decline", wrapWithPermission(http.HandlerFunc(dispatchHandler.DeclineOffer), 4, "WRITE"))
+	mux.Handle("GET /api/v1/dispatch/orders/{id}/geofence-events", wrapWithPermission(http.HandlerFunc(driverLocationHandler.ListOrderEvents), 4, "READ"))
+	mux.Handle("GET /api/v1/dispatch/location-retention", wrapWithPermission(http.HandlerFunc(driverLocationHandler.GetRetentionPolicy), 4, "READ"))
+	mux.Handle("PUT /api/v1/dispatch/location-retention", wrapWithPermission(http.HandlerFunc(driverLocationHandler.SaveRetentionPolicy), 4, "WRITE"))
+	mux.Handle("GET /api/v1/dispatch/drivers/{id}/route", wrapWithPermission(http.HandlerFunc(dispatchHandler.GetDriverRoute), 4, "READ"))
+	mux.Handle("PUT /api/v1/dispatch/drivers/{id}/password", wrapWithPermission(http.HandlerFunc(driverAppHandler.SetPassword), 4, "WRITE"))
+
+	// Driver earnings and cash settlements
+	mux.Handle("GET /api/v1/dispatch/earning-rules", wrapWithPermission(http.HandlerFunc(earningsHandler.ListRules), 4, "READ"))
+	mux.Handle("PUT /api/v1/dispatch/earning-rules", wrapWithPermission(http.HandlerFunc(earningsHandler.SaveRule), 4, "WRITE"))
+	mux.Handle("DELETE /api/v1/dispatch/earning-rules/{id}", wrapWithPermission(http.HandlerFunc(earningsHandler.DeleteRule), 4, "DELETE"))
+	mux.Handle("GET /api/v1/dispatch/drivers/{id}/stats", wrapWithPermission(http.HandlerFunc(earningsHandler.GetDriverStats), 4, "READ"))
+	mux.Handle("GET /api/v1/dispatch/drivers/{id}/earnings", wrapWithPermission(http.HandlerFunc(earningsHandler.GetEarningsReport), 4, "READ"))
+	mux.Handle("GET /api/v1/dispatch/drivers/{id}/settlement", wrapWithPermission(http.HandlerFunc(earningsHandler.PreviewSettlement), 4, "READ"))
+	mux.Handle("POST /api/v1/dispatch/drivers/{id}/settlement", wrapWithPermission(http.HandlerFunc(earningsHandler.SettleDriver), 4, "WRITE"))
+	mux.Handle("GET /api/v1/dispatch/settlements", wrapWithPermission(http.HandlerFunc(earningsHandler.ListSettlements), 4, "READ"))
+	mux.Handle("GET /api/v1/dispatch/settlements/{id}", wrapWithPermission(http.HandlerFunc(earningsHandler.GetSettlement), 4, "READ"))
+	mux.Handle("GET /api/v1/dispatch/settlements/{id}/export", wrapWithPermission(http.HandlerFunc(earningsHandler.ExportSettlement), 4, "READ"))
+
+	// Driver app - authenticated with driver tokens only
+	wrapDriver := middleware.DriverAuthMiddleware(tokenService, driverAppUC)
+	mux.Handle("GET /api/v1/driver/me", wrapDriver(http.HandlerFunc(driverAppHandler.Me)))
+	mux.Handle("PUT /api/v1/driver/duty", wrapDriver(http.HandlerFunc(driverAppHandler.SetDuty)))
+	mux.Handle("GET /api/v1/driver/jobs", wrapDriver(http.HandlerFunc(driverAppHandler.ListJobs)))
+	mux.Handle("GET /api/v1/driver/route", wrapDriver(http.HandlerFunc(driverAppHandler.GetRoute)))
+	mux.Handle("GET /api/v1/driver/earnings", wrapDriver(http.HandlerFunc(driverAppHandler.GetEarnings)))
+	mux.Handle("POST /api/v1/driver/offers/{id}/accept", wrapDriver(http.HandlerFunc(driverAppHandler.AcceptOffer)))
+	mux.Handle("POST /api/v1/driver/offers/{id}/reject", wrapDriver(http.HandlerFunc(driverAppHandler.RejectOffer)))
+	mux.Handle("POST /api/v1/driver/jobs/{id}/pickup", wrapDriver(http.HandlerFunc(driverAppHandler.PickUp)))
+	mux.Handle("POST /api/v1/driver/jobs/{id}/deliver", wrapDriver(http.HandlerFunc(driverAppHandler.Deliver)))
+	mux.Handle("POST /api/v1/driver/location", wrapDriver(http.HandlerFunc(driverAppHandler.UpdateLocation)))
+	mux.Handle("GET /api/v1/driver/location/stream", wrapDriver(http.HandlerFunc(driverAppHandler.StreamLocation)))
+
+	// Review moderation (Module ID 1 = Products)
+	mux.Handle("GET /api/v1/reviews", wrapWithPermission(http.HandlerFunc(reviewHandler.ListReviews), 1, "READ"))
+	mux.Handle("GET /api/v1/reviews/summary", wrapWithPermission(http.HandlerFunc(reviewHandler.GetRatingSummary), 1, "READ"))
+	mux.Handle("POST /api/v1/reviews/{id}/approve", wrapWithPermission(http.HandlerFunc(reviewHandler.ApproveReview), 1, "WRITE"))
+	mux.Handle("POST /api/v1/reviews/{id}/hide", wrapWithPermission(http.HandlerFunc(reviewHandler.HideReview), 1, "WRITE"))
+
 	// Admin Driver Management endpoints (require authentication)
 // 	mux.Handle("POST /api/v1/admin/drivers", wrapProtected(http.HandlerFunc(adminDriverHandler.CreateDriver)))
 // 	mux.Handle("GET /api/v1/admin/drivers", wrapProtected(http.HandlerFunc(adminDriverHandler.ListDrivers)))
@@ -616,287 +891,21 @@ func main() {
 	mux.Handle("GET /api/v1/admin/theme-presets", wrapProtected(http.HandlerFunc(themeHandlerV2.ListThemePresets)))
 
 
-	// RBAC stub endpoints (returns mock data for development)
-	mux.Handle("GET /api/v1/rbac/roles", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		rbacStorage.mu.RLock()
-		defer rbacStorage.mu.RUnlock()
-
-		roles := make([]*Role, 0, len(rbacStorage.roles))
-		for _, role := range rbacStorage.roles {
-			roles = append(roles, role)
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    roles,
-		})
-	})))
-	mux.Handle("POST /api/v1/rbac/roles", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, "Failed to read request body", http.StatusBadRequest)
-			return
-		}
-
-		var req struct {
-			Name        string `json:"name"`
-			Description string `json:"description"`
-		}
-		if err := json.Unmarshal(body, &req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		rbacStorage.mu.Lock()
-		defer rbacStorage.mu.Unlock()
-
-		newRole := &Role{
-			ID:              rbacStorage.nextID,
-			TenantID:        1,
-			RoleName:        req.Name,
-			Name:            req.Name,
-			Description:     req.Description,
-			IsSystem:        false,
-			PermissionsCount: 0,
-			CreatedAt:       time.Now(),
-			UpdatedAt:       time.Now(),
-		}
-		rbacStorage.roles[rbacStorage.nextID] = newRole
-		rbacStorage.nextID++
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    newRole,
-		})
-	})))
-	mux.Handle("GET /api/v1/rbac/roles/{id}", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		idStr := r.PathValue("id")
-		id, err := strconv.Atoi(idStr)
-		if err != nil {
-			http.Error(w, "Invalid role ID", http.StatusBadRequest)
-			return
-		}
-
-		rbacStorage.mu.RLock()
-		defer rbacStorage.mu.RUnlock()
-
-		role, exists := rbacStorage.roles[id]
-		if !exists {
-			http.Error(w, "Role not found", http.StatusNotFound)
-			return
-		}
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    role,
-		})
-	})))
-	mux.Handle("PUT /api/v1/rbac/roles/{id}", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		idStr := r.PathValue("id")
-		id, err := strconv.Atoi(idStr)
-		if err != nil {
-			http.Error(w, "Invalid role ID", http.StatusBadRequest)
-			return
-		}
-
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, "Failed to read request body", http.StatusBadRequest)
-			return
-		}
-
-		var req struct {
-			Name        string `json:"name"`
-			Description string `json:"description"`
-		}
-		if err := json.Unmarshal(body, &req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		rbacStorage.mu.Lock()
-		defer rbacStorage.mu.Unlock()
-
-		role, exists := rbacStorage.roles[id]
-		if !exists {
-			http.Error(w, "Role not found", http.StatusNotFound)
-			return
-		}
-
-		// Don't allow editing system roles
-		if role.IsSystem {
-			http.Error(w, "Cannot edit system roles", http.StatusForbidden)
-			return
-		}
-
-		role.Name = req.Name
-		role.RoleName = req.Name
-		role.Description = req.Description
-		role.UpdatedAt = time.Now()
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    role,
-		})
-	})))
-	mux.Handle("DELETE /api/v1/rbac/roles/{id}", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		idStr := r.PathValue("id")
-		id, err := strconv.Atoi(idStr)
-		if err != nil {
-			http.Error(w, "Invalid role ID", http.StatusBadRequest)
-			return
-		}
-
-		rbacStorage.mu.Lock()
-		defer rbacStorage.mu.Unlock()
-
-		role, exists := rbacStorage.roles[id]
-		if !exists {
-			http.Error(w, "Role not found", http.StatusNotFound)
-			return
-		}
-
-		// Don't allow deleting system roles
-		if role.IsSystem {
-			http.Error(w, "Cannot delete system roles", http.StatusForbidden)
-			return
-		}
-
-		delete(rbacStorage.roles, id)
-
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-		})
-	})))
-	mux.Handle("GET /api/v1/rbac/me/roles", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		// Mock admin role for current user
-		w.Write([]byte(`{"success":true,"data":[{"id":1,"tenant_id":1,"role_name":"Admin","description":"System administrator with full access","is_system":true,"created_at":"2024-01-01T00:00:00Z","updated_at":"2024-01-01T00:00:00Z"}]}`))
-	})))
-	mux.Handle("GET /api/v1/rbac/users/{userId}/roles", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userIdStr := r.PathValue("userId")
-		userId, err := strconv.Atoi(userIdStr)
-		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
-			return
-		}
-
-		rbacStorage.mu.RLock()
-		defer rbacStorage.mu.RUnlock()
-
-		roleIds := rbacStorage.userRoles[userId]
-		roles := make([]*Role, 0, len(roleIds))
-		for _, roleId := range roleIds {
-			if role, exists := rbacStorage.roles[roleId]; exists {
-				roles = append(roles, role)
-			}
-		}
+	// Apply CORS middleware to all routes
+	handler := middleware.CORSMiddleware(mux)
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    roles,
-		})
-	})))
-	mux.Handle("POST /api/v1/rbac/users/{userId}/roles", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userIdStr := r.PathValue("userId")
-		userId, err := strconv.Atoi(userIdStr)
-		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
-			return
-		}
-
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			http.Error(w, "Failed to read request body", http.StatusBadRequest)
-			return
-		}
-
-		var req struct {
-			RoleId int `json:"roleId"`
-		}
-		if err := json.Unmarshal(body, &req); err != nil {
-			http.Error(w, "Invalid request body", http.StatusBadRequest)
-			return
-		}
-
-		rbacStorage.mu.Lock()
-		defer rbacStorage.mu.Unlock()
-
-		// Check if role exists
-		if _, exists := rbacStorage.roles[req.RoleId]; !exists {
-			http.Error(w, "Role not found", http.StatusNotFound)
-			return
-		}
-
-		// Add role to user if not already assigned
-		userRoles := rbacStorage.userRoles[userId]
-		for _, roleId := range userRoles {
-			if roleId == req.RoleId {
-				// Already assigned
-				w.Header().Set("Content-Type", "application/json")
-				json.NewEncoder(w).Encode(map[string]interface{}{
-					"success": true,
-				})
-				return
-			}
-		}
-		rbacStorage.userRoles[userId] = append(userRoles, req.RoleId)
+	// Resolve client addresses, honoring forwarding headers only from trusted proxies
+	clientIP, err := middleware.ClientIPMiddleware(cfg.Server.TrustedProxies)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	handler = clientIP(handler)
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-		})
-	})))
-	mux.Handle("DELETE /api/v1/rbac/users/{userId}/roles/{roleId}", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userIdStr := r.PathValue("userId")
-		userId, err := strconv.Atoi(userIdStr)
-		if err != nil {
-			http.Error(w, "Invalid user ID", http.StatusBadRequest)
-			return
-		}
-
-		roleIdStr := r.PathValue("roleId")
-		roleId, err := strconv.Atoi(roleIdStr)
-		if err != nil {
-			http.Error(w, "Invalid role ID", http.StatusBadRequest)
-			return
-		}
-
-		rbacStorage.mu.Lock()
-		defer rbacStorage.mu.Unlock()
-
-		userRoles := rbacStorage.userRoles[userId]
-		newRoles := make([]int, 0, len(userRoles))
-		for _, rId := range userRoles {
-			if rId != roleId {
-				newRoles = append(newRoles, rId)
-			}
-		}
-		rbacStorage.userRoles[userId] = newRoles
+	// Expire unanswered driver offers and fall back to the next driver
+	go dispatchUC.RunOfferExpiry(context.Background(), 10*time.Second)
 
-		w.Header().Set("Content-Type", "application/json")
-		json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-		})
-	})))
-	mux.Handle("GET /api/v1/rbac/me/permissions", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		// Mock admin permissions for all modules
-		w.Write([]byte(`{"success":true,"data":{"1":"ADMIN","2":"ADMIN","3":"ADMIN","4":"ADMIN","5":"ADMIN","6":"ADMIN"}}`))
-	})))
-	mux.Handle("GET /api/v1/rbac/check-permission", wrapProtected(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Set("Content-Type", "application/json")
-		// Return proper permission check response with has_permission (snake_case)
-		w.Write([]byte(`{"success":true,"has_permission":true,"module_id":6,"permission_level":"ADMIN"}`))
-	})))
-	// Apply CORS middleware to all routes
-	handler := middleware.CORSMiddleware(mux)
+	// Downsample and purge old driver location history per tenant policy
+	go driverLocationUC.RunRetention(context.Background(), time.Hour)
 
 	// Start server
 	log.Printf("🚀 Server started on port %s", cfg.Server.Port)
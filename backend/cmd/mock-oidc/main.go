@@ -0,0 +1,48 @@
+// Command mock-oidc runs a local OpenID provider for trying out single sign-on. Every sign-in
+// succeeds straight away as the configured user.
+//
+//	go run ./cmd/mock-oidc -email owner@example.com -groups pos-admins,kitchen
+//
+// Then configure the tenant with issuer http://localhost:9400, client ID pos-local and
+// client secret pos-local-secret.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"pos-saas/internal/pkg/oidc/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:9400", "Address to listen on")
+	clientID := flag.String("client-id", "pos-local", "Client ID to accept")
+	clientSecret := flag.String("client-secret", "pos-local-secret", "Client secret to accept; empty for a public client")
+	redirectURI := flag.String("redirect-uri", "http://localhost:8080/api/v1/auth/sso/callback", "Redirect URI to accept")
+	subject := flag.String("sub", "mock-user-1", "Subject of the signed-in user")
+	email := flag.String("email", "sso.user@example.com", "Email of the signed-in user")
+	name := flag.String("name", "SSO User", "Name of the signed-in user")
+	groups := flag.String("groups", "", "Comma-separated groups of the signed-in user; empty leaves out the groups claim")
+	unverified := flag.Bool("unverified-email", false, "Mark the email as not verified")
+	flag.Parse()
+
+	issuer, err := oidctest.NewIssuer(fmt.Sprintf("http://%s", *addr), *clientID, *clientSecret, *redirectURI)
+	if err != nil {
+		log.Fatalf("Failed to create issuer: %v", err)
+	}
+	issuer.User = oidctest.User{
+		Subject:       *subject,
+		Email:         *email,
+		EmailVerified: !*unverified,
+		Name:          *name,
+	}
+	if *groups != "" {
+		issuer.User.Groups = strings.Split(*groups, ",")
+	}
+
+	log.Printf("Mock OpenID provider at %s signing in %s", issuer.URL, *email)
+	log.Fatal(http.ListenAndServe(*addr, issuer))
+}
@@ -3,19 +3,32 @@ package auth
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"database/sql"
 	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
 	"time"
 )
 
+// API key errors
+var (
+	ErrInvalidAPIKey          = errors.New("invalid API key")
+	ErrAPIKeyExpired          = errors.New("API key expired")
+	ErrAPIKeyIPNotAllowed     = errors.New("IP not authorized")
+	ErrAPIKeyOriginNotAllowed = errors.New("origin not authorized")
+	ErrAPIKeyNotFound         = errors.New("API key not found")
+)
+
 // APIKey represents an API key
 type APIKey struct {
 	ID            int64
 	TenantID      int64
 	UserID        int64
+	RestaurantID  int64
 	KeyHash       string
 	KeyPrefix     string    // First 8 chars for display
 	Name          string
@@ -35,9 +48,11 @@ type APIKeyUsage struct {
 	KeyID         int64
 	TenantID      int64
 	RequestCount  int64
-	LastUsedAt    time.Time
+	LastUsedAt    *time.Time
 	TodayRequests int64
 	MonthlyRequests int64
+	ErrorRequests int64 // Responses with status 400 or above
+	AvgDurationMs float64
 }
 
 // APIKeyManager manages API keys for service-to-service communication
@@ -63,19 +78,21 @@ func NewAPIKeyManager(db *sql.DB, auditLogger AuditLogger) *APIKeyManager {
 	}
 }
 
-// GenerateAPIKey generates a new API key for a tenant/user
+// GenerateAPIKey generates a new API key for a tenant/user. The key acts for the user in
+// the restaurant and is only returned here; the database keeps its hash.
 func (akm *APIKeyManager) GenerateAPIKey(
 	ctx context.Context,
 	conn *sql.Conn,
 	tenantID int64,
 	userID int64,
+	restaurantID int64,
 	name string,
 	permissions []string,
 	allowedIPs []string,
 	allowedOrigins []string,
 	rateLimit int,
 	expiresInDays int,
-) (string, error) {
+) (string, int64, error) {
 	log.Printf("[API_KEY] Generating API key: tenant=%d, user=%d, name=%s",
 		tenantID, userID, name)
 
@@ -84,7 +101,7 @@ func (akm *APIKeyManager) GenerateAPIKey(
 	_, err := rand.Read(randomBytes)
 	if err != nil {
 		log.Printf("[API_KEY] ERROR: Failed to generate random bytes: %v", err)
-		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+		return "", 0, fmt.Errorf("failed to generate random bytes: %w", err)
 	}
 
 	// Create key with prefix
@@ -108,19 +125,19 @@ func (akm *APIKeyManager) GenerateAPIKey(
 
 	// Insert API key
 	query := `
-		INSERT INTO api_keys (tenant_id, user_id, key_hash, key_prefix, name, permissions,
+		INSERT INTO api_keys (tenant_id, user_id, restaurant_id, key_hash, key_prefix, name, permissions,
 		                       allowed_ips, allowed_origins, rate_limit, expires_at, is_active)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, true)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, true)
 		RETURNING id
 	`
 
 	var keyID int64
 	err = conn.QueryRowContext(ctx, query,
-		tenantID, userID, keyHash, keyPrefix, name,
+		tenantID, userID, restaurantID, keyHash, keyPrefix, name,
 		permissionsStr, allowedIPsStr, allowedOriginsStr, rateLimit, expiresAt).Scan(&keyID)
 	if err != nil {
 		log.Printf("[API_KEY] ERROR: Failed to create API key: %v", err)
-		return "", fmt.Errorf("failed to create API key: %w", err)
+		return "", 0, fmt.Errorf("failed to create API key: %w", err)
 	}
 
 	// Log creation
@@ -129,7 +146,7 @@ func (akm *APIKeyManager) GenerateAPIKey(
 	}
 
 	log.Printf("[API_KEY] API key generated successfully: id=%d, prefix=%s", keyID, keyPrefix)
-	return keyString, nil
+	return keyString, keyID, nil
 }
 
 // ValidateAPIKey validates an API key
@@ -142,40 +159,58 @@ func (akm *APIKeyManager) ValidateAPIKey(
 ) (*APIKey, error) {
 	log.Printf("[API_KEY] Validating API key from IP: %s", clientIP)
 
-	// Hash the provided key
+	// Look the key up by its indexed display prefix, then compare the hash
 	keyHash := hashSHA256(keyString)
+	keyPrefix := keyString[:min(len(keyString), 12)]
 
-	// Query for the key
 	query := `
-		SELECT id, tenant_id, user_id, key_hash, key_prefix, name, permissions,
+		SELECT id, tenant_id, user_id, restaurant_id, key_hash, key_prefix, name, permissions,
 		       allowed_ips, allowed_origins, rate_limit, last_used_at, expires_at, is_active
 		FROM api_keys
-		WHERE key_hash = $1 AND is_active = true
+		WHERE key_prefix = $1 AND is_active = true
 	`
 
+	rows, err := conn.QueryContext(ctx, query, keyPrefix)
+	if err != nil {
+		log.Printf("[API_KEY] ERROR: Failed to query API key: %v", err)
+		return nil, fmt.Errorf("failed to validate API key: %w", err)
+	}
+	defer rows.Close()
+
 	var apiKey APIKey
 	var permStr, ipsStr, originsStr string
 	var lastUsedAt *time.Time
 	var expiresAt *time.Time
+	found := false
 
-	err := conn.QueryRowContext(ctx, query, keyHash).Scan(
-		&apiKey.ID, &apiKey.TenantID, &apiKey.UserID, &apiKey.KeyHash, &apiKey.KeyPrefix,
-		&apiKey.Name, &permStr, &ipsStr, &originsStr, &apiKey.RateLimit,
-		&lastUsedAt, &expiresAt, &apiKey.IsActive)
-
-	if err == sql.ErrNoRows {
-		log.Printf("[API_KEY] ERROR: API key not found")
-		return nil, fmt.Errorf("invalid API key")
+	for rows.Next() {
+		var candidate APIKey
+		var candPerm, candIPs, candOrigins string
+		var candLastUsedAt, candExpiresAt *time.Time
+		if err := rows.Scan(
+			&candidate.ID, &candidate.TenantID, &candidate.UserID, &candidate.RestaurantID, &candidate.KeyHash, &candidate.KeyPrefix,
+			&candidate.Name, &candPerm, &candIPs, &candOrigins, &candidate.RateLimit,
+			&candLastUsedAt, &candExpiresAt, &candidate.IsActive); err != nil {
+			return nil, fmt.Errorf("failed to validate API key: %w", err)
+		}
+		if subtle.ConstantTimeCompare([]byte(candidate.KeyHash), []byte(keyHash)) == 1 {
+			apiKey, permStr, ipsStr, originsStr = candidate, candPerm, candIPs, candOrigins
+			lastUsedAt, expiresAt = candLastUsedAt, candExpiresAt
+			found = true
+		}
 	}
-	if err != nil {
-		log.Printf("[API_KEY] ERROR: Failed to query API key: %v", err)
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("failed to validate API key: %w", err)
 	}
+	if !found {
+		log.Printf("[API_KEY] ERROR: API key not found")
+		return nil, ErrInvalidAPIKey
+	}
 
 	// Check if key is expired
 	if expiresAt != nil && time.Now().After(*expiresAt) {
 		log.Printf("[API_KEY] ERROR: API key expired")
-		return nil, fmt.Errorf("API key expired")
+		return nil, ErrAPIKeyExpired
 	}
 
 	apiKey.LastUsedAt = lastUsedAt
@@ -190,7 +225,7 @@ func (akm *APIKeyManager) ValidateAPIKey(
 	if len(allowedIPs) > 0 {
 		if !stringInSlice(clientIP, allowedIPs) {
 			log.Printf("[API_KEY] ERROR: IP not whitelisted: %s", clientIP)
-			return nil, fmt.Errorf("IP not authorized")
+			return nil, ErrAPIKeyIPNotAllowed
 		}
 	}
 
@@ -198,7 +233,7 @@ func (akm *APIKeyManager) ValidateAPIKey(
 	if len(allowedOrigins) > 0 {
 		if !stringInSlice(clientOrigin, allowedOrigins) {
 			log.Printf("[API_KEY] ERROR: Origin not whitelisted: %s", clientOrigin)
-			return nil, fmt.Errorf("origin not authorized")
+			return nil, ErrAPIKeyOriginNotAllowed
 		}
 	}
 
@@ -238,7 +273,7 @@ func (akm *APIKeyManager) RevokeAPIKey(
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
 		log.Printf("[API_KEY] ERROR: API key not found")
-		return fmt.Errorf("API key not found")
+		return ErrAPIKeyNotFound
 	}
 
 	// Log revocation
@@ -250,7 +285,43 @@ func (akm *APIKeyManager) RevokeAPIKey(
 	return nil
 }
 
-// ListAPIKeys lists all API keys for a tenant
+// GetAPIKey returns a key of the tenant
+func (akm *APIKeyManager) GetAPIKey(
+	ctx context.Context,
+	conn *sql.Conn,
+	keyID int64,
+	tenantID int64,
+) (*APIKey, error) {
+	query := `
+		SELECT id, tenant_id, user_id, restaurant_id, key_hash, key_prefix, name, permissions,
+		       allowed_ips, allowed_origins, rate_limit, last_used_at, expires_at, is_active,
+		       created_at, updated_at
+		FROM api_keys
+		WHERE id = $1 AND tenant_id = $2
+	`
+
+	var apiKey APIKey
+	var permStr, ipsStr, originsStr string
+	err := conn.QueryRowContext(ctx, query, keyID, tenantID).Scan(
+		&apiKey.ID, &apiKey.TenantID, &apiKey.UserID, &apiKey.RestaurantID, &apiKey.KeyHash, &apiKey.KeyPrefix,
+		&apiKey.Name, &permStr, &ipsStr, &originsStr, &apiKey.RateLimit,
+		&apiKey.LastUsedAt, &apiKey.ExpiresAt, &apiKey.IsActive,
+		&apiKey.CreatedAt, &apiKey.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	apiKey.Permissions = deserializeStringArray(permStr)
+	apiKey.AllowedIPs = deserializeStringArray(ipsStr)
+	apiKey.AllowedOrigins = deserializeStringArray(originsStr)
+	return &apiKey, nil
+}
+
+// ListAPIKeys lists the API keys of a tenant created by a user, or all of the tenant's keys
+// when userID is 0
 func (akm *APIKeyManager) ListAPIKeys(
 	ctx context.Context,
 	conn *sql.Conn,
@@ -260,11 +331,11 @@ func (akm *APIKeyManager) ListAPIKeys(
 	log.Printf("[API_KEY] Listing API keys: tenant=%d, user=%d", tenantID, userID)
 
 	query := `
-		SELECT id, tenant_id, user_id, key_hash, key_prefix, name, permissions,
+		SELECT id, tenant_id, user_id, restaurant_id, key_hash, key_prefix, name, permissions,
 		       allowed_ips, allowed_origins, rate_limit, last_used_at, expires_at, is_active,
 		       created_at, updated_at
 		FROM api_keys
-		WHERE tenant_id = $1 AND user_id = $2
+		WHERE tenant_id = $1 AND ($2 = 0 OR user_id = $2)
 		ORDER BY created_at DESC
 	`
 
@@ -281,7 +352,7 @@ func (akm *APIKeyManager) ListAPIKeys(
 		var permStr, ipsStr, originsStr string
 
 		err := rows.Scan(
-			&apiKey.ID, &apiKey.TenantID, &apiKey.UserID, &apiKey.KeyHash, &apiKey.KeyPrefix,
+			&apiKey.ID, &apiKey.TenantID, &apiKey.UserID, &apiKey.RestaurantID, &apiKey.KeyHash, &apiKey.KeyPrefix,
 			&apiKey.Name, &permStr, &ipsStr, &originsStr, &apiKey.RateLimit,
 			&apiKey.LastUsedAt, &apiKey.ExpiresAt, &apiKey.IsActive,
 			&apiKey.CreatedAt, &apiKey.UpdatedAt)
@@ -312,28 +383,20 @@ func (akm *APIKeyManager) GetAPIKeyUsage(
 
 	query := `
 		SELECT
-			aku.key_id,
-			aku.tenant_id,
-			aku.request_count,
-			aku.last_used_at,
-			COUNT(CASE WHEN aku.created_at::date = CURRENT_DATE THEN 1 END) as today_requests,
-			COUNT(CASE WHEN DATE_TRUNC('month', aku.created_at) = DATE_TRUNC('month', CURRENT_DATE) THEN 1 END) as monthly_requests
-		FROM api_key_usage aku
-		WHERE aku.key_id = $1 AND aku.tenant_id = $2
-		GROUP BY aku.key_id, aku.tenant_id, aku.request_count, aku.last_used_at
+			COUNT(*),
+			MAX(created_at),
+			COUNT(*) FILTER (WHERE created_at >= CURRENT_DATE),
+			COUNT(*) FILTER (WHERE created_at >= DATE_TRUNC('month', CURRENT_DATE)),
+			COUNT(*) FILTER (WHERE status_code >= 400),
+			COALESCE(AVG(duration_ms), 0)
+		FROM api_key_usage
+		WHERE key_id = $1 AND tenant_id = $2
 	`
 
-	var usage APIKeyUsage
+	usage := APIKeyUsage{KeyID: keyID, TenantID: tenantID}
 	err := conn.QueryRowContext(ctx, query, keyID, tenantID).Scan(
-		&usage.KeyID, &usage.TenantID, &usage.RequestCount, &usage.LastUsedAt,
-		&usage.TodayRequests, &usage.MonthlyRequests)
-
-	if err == sql.ErrNoRows {
-		// No usage yet
-		usage.KeyID = keyID
-		usage.TenantID = tenantID
-		return &usage, nil
-	}
+		&usage.RequestCount, &usage.LastUsedAt, &usage.TodayRequests,
+		&usage.MonthlyRequests, &usage.ErrorRequests, &usage.AvgDurationMs)
 	if err != nil {
 		log.Printf("[API_KEY] ERROR: Failed to get usage: %v", err)
 		return nil, fmt.Errorf("failed to get usage: %w", err)
@@ -369,7 +432,7 @@ func (akm *APIKeyManager) UpdateAPIKeyPermissions(
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("API key not found")
+		return ErrAPIKeyNotFound
 	}
 
 	log.Printf("[API_KEY] Permissions updated successfully")
@@ -401,7 +464,7 @@ func (akm *APIKeyManager) UpdateAPIKeyRateLimit(
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("API key not found")
+		return ErrAPIKeyNotFound
 	}
 
 	log.Printf("[API_KEY] Rate limit updated successfully")
@@ -433,7 +496,7 @@ func (akm *APIKeyManager) ExtendAPIKeyExpiry(
 
 	rowsAffected, _ := result.RowsAffected()
 	if rowsAffected == 0 {
-		return fmt.Errorf("API key not found")
+		return ErrAPIKeyNotFound
 	}
 
 	log.Printf("[API_KEY] Expiry extended successfully")
@@ -459,16 +522,46 @@ func (akm *APIKeyManager) RecordAPIKeyUsage(
 	return err
 }
 
+// CountAPIKeyRequest counts a request made with a key in the current rate limit window and
+// returns the window's count including it, along with the time left in the window. The
+// count is incremented and read in one statement, so concurrent requests each see their own.
+func (akm *APIKeyManager) CountAPIKeyRequest(
+	ctx context.Context,
+	conn *sql.Conn,
+	keyID int64,
+	window time.Duration,
+) (int, time.Duration, error) {
+	now := time.Now()
+	windowStart := now.Truncate(window)
+
+	query := `
+		INSERT INTO api_key_rate_windows (key_id, window_start, request_count)
+		VALUES ($1, $2, 1)
+		ON CONFLICT (key_id, window_start)
+		DO UPDATE SET request_count = api_key_rate_windows.request_count + 1
+		RETURNING request_count
+	`
+
+	var count int
+	if err := conn.QueryRowContext(ctx, query, keyID, windowStart).Scan(&count); err != nil {
+		return 0, 0, err
+	}
+
+	// The first request of a window clears the key's earlier windows
+	if count == 1 {
+		cleanup := `DELETE FROM api_key_rate_windows WHERE key_id = $1 AND window_start < $2`
+		if _, err := conn.ExecContext(ctx, cleanup, keyID, windowStart); err != nil {
+			log.Printf("[API_KEY] WARNING: Failed to clear old rate limit windows: %v", err)
+		}
+	}
+	return count, windowStart.Add(window).Sub(now), nil
+}
+
 // Helper functions
 
 func hashSHA256(input string) string {
-	bytes := []byte(input)
-	hash := make([]byte, 32)
-	// Use a simple hash for now (in production, use crypto/sha256)
-	for i, b := range bytes {
-		hash[i%32] ^= b
-	}
-	return hex.EncodeToString(hash)
+	hash := sha256.Sum256([]byte(input))
+	return hex.EncodeToString(hash[:])
 }
 
 func serializeStringArray(arr []string) string {
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
@@ -12,11 +13,16 @@ type Config struct {
 	Server   ServerConfig
 	Database DatabaseConfig
 	JWT      JWTConfig
+	Mail     MailConfig
+	SMS      SMSConfig
+	TOTP     TOTPConfig
+	SSO      SSOConfig
 }
 
 type ServerConfig struct {
-	Port string
-	Env  string
+	Port           string
+	Env            string
+	TrustedProxies []string // Proxies whose X-Forwarded-For is trusted, as IP addresses or CIDR ranges
 }
 
 type DatabaseConfig struct {
@@ -29,8 +35,39 @@ type DatabaseConfig struct {
 }
 
 type JWTConfig struct {
-	Secret string
-	Expiry string
+	Secret        string
+	Expiry        string
+	RefreshExpiry string
+}
+
+type MailConfig struct {
+	Driver       string // log, file or smtp
+	FromEmail    string
+	FromName     string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	FileDir      string
+	AppURL       string // Dashboard URL used in links sent by email
+}
+
+type SMSConfig struct {
+	Driver           string // log, file or twilio
+	From             string // Sender number or alphanumeric ID
+	FileDir          string
+	TwilioAccountSID string
+	TwilioAuthToken  string
+}
+
+type TOTPConfig struct {
+	Issuer        string // Shown next to the account in authenticator apps
+	EncryptionKey string // Encrypts stored TOTP secrets; defaults to the JWT secret
+}
+
+type SSOConfig struct {
+	APIURL        string // Public URL of this API; identity providers redirect back to it
+	EncryptionKey string // Encrypts client secrets and PKCE verifiers; defaults to the JWT secret
 }
 
 func Load() (*Config, error) {
@@ -38,11 +75,13 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	dbPort, _ := strconv.Atoi(getEnv("DB_PORT", "5432"))
+	smtpPort, _ := strconv.Atoi(getEnv("SMTP_PORT", "1025"))
 
 	return &Config{
 		Server: ServerConfig{
-			Port: getEnv("PORT", "8080"),
-			Env:  getEnv("ENV", "development"),
+			Port:           getEnv("PORT", "8080"),
+			Env:            getEnv("ENV", "development"),
+			TrustedProxies: strings.Split(getEnv("TRUSTED_PROXIES", ""), ","),
 		},
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
@@ -53,8 +92,35 @@ func Load() (*Config, error) {
 			SSLMode:  getEnv("DB_SSLMODE", "disable"),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "your-secret-key-change-this"),
-			Expiry: getEnv("JWT_EXPIRY", "24h"),
+			Secret:        getEnv("JWT_SECRET", "your-secret-key-change-this"),
+			Expiry:        getEnv("JWT_EXPIRY", "24h"),
+			RefreshExpiry: getEnv("JWT_REFRESH_EXPIRY", "168h"),
+		},
+		Mail: MailConfig{
+			Driver:       getEnv("MAIL_DRIVER", "log"),
+			FromEmail:    getEnv("FROM_EMAIL", "noreply@pos.com"),
+			FromName:     getEnv("FROM_NAME", "Restaurant POS"),
+			SMTPHost:     getEnv("SMTP_HOST", "localhost"),
+			SMTPPort:     smtpPort,
+			SMTPUsername: getEnv("SMTP_USERNAME", ""),
+			SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+			FileDir:      getEnv("MAIL_FILE_DIR", "./tmp/mail"),
+			AppURL:       getEnv("APP_URL", "http://localhost:3002"),
+		},
+		SMS: SMSConfig{
+			Driver:           getEnv("SMS_DRIVER", "log"),
+			From:             getEnv("TWILIO_PHONE_NUMBER", ""),
+			FileDir:          getEnv("SMS_FILE_DIR", "./tmp/sms"),
+			TwilioAccountSID: getEnv("TWILIO_ACCOUNT_SID", ""),
+			TwilioAuthToken:  getEnv("TWILIO_AUTH_TOKEN", ""),
+		},
+		TOTP: TOTPConfig{
+			Issuer:        getEnv("TOTP_ISSUER", "Restaurant POS"),
+			EncryptionKey: getEnv("TOTP_ENCRYPTION_KEY", ""),
+		},
+		SSO: SSOConfig{
+			APIURL:        getEnv("API_URL", "http://localhost:8080"),
+			EncryptionKey: getEnv("SSO_ENCRYPTION_KEY", ""),
 		},
 	}, nil
 }
@@ -0,0 +1,131 @@
+package domain
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Account token purposes
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+)
+
+// Account token and password policy defaults
+const (
+	PasswordResetTTL     = time.Hour
+	EmailVerificationTTL = 48 * time.Hour
+	// EmailChangeTTL matches the expires_at default of email_change_requests
+	EmailChangeTTL = 24 * time.Hour
+	// AccountEmailCooldown is the minimum time between two reset or verification emails to an account
+	AccountEmailCooldown = time.Minute
+	// PasswordHistoryDepth is how many previous passwords cannot be reused
+	PasswordHistoryDepth = 5
+	MinPasswordLength    = 8
+	// MaxPasswordLength is the number of bytes bcrypt takes into account
+	MaxPasswordLength = 72
+)
+
+var (
+	ErrInvalidAccountToken = errors.New("invalid or expired token")
+	ErrPasswordReused      = errors.New("new password must be different from your recent passwords")
+	ErrEmailTaken          = errors.New("email is already used by another account in this organization")
+	ErrEmailUnchanged      = errors.New("new email must be different from the current email")
+	ErrEmailVerified       = errors.New("email is already verified")
+	ErrIncorrectPassword   = errors.New("current password is incorrect")
+	ErrAccountEmailTooSoon = errors.New("an email was sent recently, please wait a minute before asking again")
+)
+
+// AccountToken is a single-use token sent by email to reset a password or verify an address
+type AccountToken struct {
+	ID          int64
+	UserID      int
+	Purpose     string
+	TokenHash   string
+	ExpiresAt   time.Time
+	UsedAt      *time.Time
+	RequestedIP string
+	CreatedAt   time.Time
+}
+
+// ValidatePassword enforces the password policy: 8 to 72 bytes with at least one letter and one digit
+func ValidatePassword(password string) error {
+	if len(password) < MinPasswordLength {
+		return errors.New("password must be at least 8 characters")
+	}
+	if len(password) > MaxPasswordLength {
+		return errors.New("password must be at most 72 bytes")
+	}
+	var letter, digit bool
+	for _, c := range password {
+		switch {
+		case unicode.IsLetter(c):
+			letter = true
+		case unicode.IsDigit(c):
+			digit = true
+		}
+	}
+	if !letter || !digit {
+		return errors.New("password must contain at least one letter and one number")
+	}
+	return nil
+}
+
+// NormalizeEmail trims and lower-cases an address and checks that it is valid
+func NormalizeEmail(email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", errors.New("email is required")
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return "", errors.New("invalid email address")
+	}
+	return email, nil
+}
+
+// ResetPasswordRequest is sent to /api/v1/auth/reset-password with the token from the reset email
+type ResetPasswordRequest struct {
+	Token           string `json:"token"`
+	NewPassword     string `json:"new_password"`
+	ConfirmPassword string `json:"confirm_password"`
+}
+
+// Validate checks the token and the new password
+func (req *ResetPasswordRequest) Validate() error {
+	if strings.TrimSpace(req.Token) == "" {
+		return errors.New("token is required")
+	}
+	if req.NewPassword != req.ConfirmPassword {
+		return errors.New("new passwords do not match")
+	}
+	return ValidatePassword(req.NewPassword)
+}
+
+// AccountTokenRequest confirms an email verification or email change
+type AccountTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// ChangeEmailRequest asks to move an account to a new email address.
+// The change only applies once the link sent to the new address is opened.
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"new_email"`
+	CurrentPassword string `json:"current_password"`
+}
+
+// Validate normalizes the new email and requires the current password
+func (req *ChangeEmailRequest) Validate() error {
+	email, err := NormalizeEmail(req.NewEmail)
+	if err != nil {
+		return err
+	}
+	req.NewEmail = email
+	if req.CurrentPassword == "" {
+		return errors.New("current_password is required")
+	}
+	return nil
+}
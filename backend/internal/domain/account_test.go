@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidatePassword tests the password policy used by registration, reset and change
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		password string
+		wantErr  bool
+	}{
+		{"abc12345", false},
+		{"Passw0rd!", false},
+		{"short1", true},
+		{"onlyletters", true},
+		{"1234567890", true},
+		{strings.Repeat("a", 71) + "1", false},
+		{strings.Repeat("a", 72) + "1", true},
+	}
+	for _, tt := range tests {
+		if err := ValidatePassword(tt.password); (err != nil) != tt.wantErr {
+			t.Errorf("ValidatePassword(%q) error = %v, wantErr %v", tt.password, err, tt.wantErr)
+		}
+	}
+}
+
+// TestNormalizeEmail tests trimming, lower-casing and rejecting malformed addresses
+func TestNormalizeEmail(t *testing.T) {
+	got, err := NormalizeEmail("  Owner@Example.COM ")
+	if err != nil || got != "owner@example.com" {
+		t.Errorf("NormalizeEmail() = %q, %v, want owner@example.com", got, err)
+	}
+
+	for _, email := range []string{"", "not-an-email", "Owner <owner@example.com>"} {
+		if _, err := NormalizeEmail(email); err == nil {
+			t.Errorf("NormalizeEmail(%q) expected error", email)
+		}
+	}
+}
+
+// TestResetPasswordRequestValidate tests the token and password checks of a reset request
+func TestResetPasswordRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     ResetPasswordRequest
+		wantErr bool
+	}{
+		{"valid", ResetPasswordRequest{Token: "t", NewPassword: "abc12345", ConfirmPassword: "abc12345"}, false},
+		{"missing token", ResetPasswordRequest{NewPassword: "abc12345", ConfirmPassword: "abc12345"}, true},
+		{"mismatch", ResetPasswordRequest{Token: "t", NewPassword: "abc12345", ConfirmPassword: "abc123456"}, true},
+		{"weak password", ResetPasswordRequest{Token: "t", NewPassword: "password", ConfirmPassword: "password"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+// TestChangeEmailRequestValidate tests that the new email is normalized and the password required
+func TestChangeEmailRequestValidate(t *testing.T) {
+	req := ChangeEmailRequest{NewEmail: " New@Example.com", CurrentPassword: "secret"}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() unexpected error: %v", err)
+	}
+	if req.NewEmail != "new@example.com" {
+		t.Errorf("NewEmail = %q, want new@example.com", req.NewEmail)
+	}
+
+	req = ChangeEmailRequest{NewEmail: "new@example.com"}
+	if err := req.Validate(); err == nil {
+		t.Error("Validate() expected error without current password")
+	}
+}
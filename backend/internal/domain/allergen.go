@@ -0,0 +1,137 @@
+package domain
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// EU allergen codes (Regulation 1169/2011 Annex II)
+const (
+	AllergenGluten      = "gluten"
+	AllergenCrustaceans = "crustaceans"
+	AllergenEggs        = "eggs"
+	AllergenFish        = "fish"
+	AllergenPeanuts     = "peanuts"
+	AllergenSoy         = "soy"
+	AllergenMilk        = "milk"
+	AllergenTreeNuts    = "tree_nuts"
+	AllergenCelery      = "celery"
+	AllergenMustard     = "mustard"
+	AllergenSesame      = "sesame"
+	AllergenSulphites   = "sulphites"
+	AllergenLupin       = "lupin"
+	AllergenMolluscs    = "molluscs"
+)
+
+// Allergen is an entry of the controlled allergen vocabulary
+type Allergen struct {
+	Code         string `json:"code"`
+	NameEn       string `json:"name_en"`
+	NameAr       string `json:"name_ar"`
+	DisplayOrder int    `json:"display_order"`
+}
+
+// allergenAliases maps common free-text spellings to vocabulary codes
+var allergenAliases = map[string]string{
+	"gluten":      AllergenGluten,
+	"wheat":       AllergenGluten,
+	"cereals":     AllergenGluten,
+	"crustaceans": AllergenCrustaceans,
+	"crustacean":  AllergenCrustaceans,
+	"shellfish":   AllergenCrustaceans,
+	"shrimp":      AllergenCrustaceans,
+	"eggs":        AllergenEggs,
+	"egg":         AllergenEggs,
+	"fish":        AllergenFish,
+	"peanuts":     AllergenPeanuts,
+	"peanut":      AllergenPeanuts,
+	"soy":         AllergenSoy,
+	"soya":        AllergenSoy,
+	"soybeans":    AllergenSoy,
+	"milk":        AllergenMilk,
+	"dairy":       AllergenMilk,
+	"lactose":     AllergenMilk,
+	"tree_nuts":   AllergenTreeNuts,
+	"tree nuts":   AllergenTreeNuts,
+	"nuts":        AllergenTreeNuts,
+	"celery":      AllergenCelery,
+	"mustard":     AllergenMustard,
+	"sesame":      AllergenSesame,
+	"sulphites":   AllergenSulphites,
+	"sulfites":    AllergenSulphites,
+	"lupin":       AllergenLupin,
+	"molluscs":    AllergenMolluscs,
+	"mollusks":    AllergenMolluscs,
+}
+
+// NormalizeAllergenCode maps a free-text allergen to its vocabulary code
+func NormalizeAllergenCode(value string) (string, bool) {
+	code, ok := allergenAliases[strings.ToLower(strings.TrimSpace(value))]
+	return code, ok
+}
+
+// NormalizeAllergenCodes maps, de-duplicates and sorts allergen codes.
+// Unknown allergens are rejected so the vocabulary stays controlled.
+func NormalizeAllergenCodes(values []string) ([]string, error) {
+	seen := make(map[string]bool)
+	codes := []string{}
+	for _, v := range values {
+		if strings.TrimSpace(v) == "" {
+			continue
+		}
+		code, ok := NormalizeAllergenCode(v)
+		if !ok {
+			return nil, fmt.Errorf("unknown allergen: %s", v)
+		}
+		if !seen[code] {
+			seen[code] = true
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	return codes, nil
+}
+
+// FilterProducts applies dietary and allergen exclusion filters to a product list
+func FilterProducts(products []Product, dietary, excludeAllergens []string) []Product {
+	if len(dietary) == 0 && len(excludeAllergens) == 0 {
+		return products
+	}
+
+	filtered := make([]Product, 0, len(products))
+	for _, p := range products {
+		matches := true
+		for _, flag := range dietary {
+			if !p.MatchesDietary(flag) {
+				matches = false
+				break
+			}
+		}
+		if matches && !p.ContainsAnyAllergen(excludeAllergens) {
+			filtered = append(filtered, p)
+		}
+	}
+	return filtered
+}
+
+// OrderAllergenWarning flags an ordered item containing an allergen the customer must avoid
+type OrderAllergenWarning struct {
+	ProductID   int64    `json:"product_id"`
+	ProductName string   `json:"product_name"`
+	Allergens   []string `json:"allergens"`
+}
+
+// AllergenConflicts returns the allergens of a product that the customer must avoid
+func AllergenConflicts(productAllergens, avoid []string) []string {
+	conflicts := []string{}
+	for _, a := range productAllergens {
+		for _, x := range avoid {
+			if strings.EqualFold(a, x) {
+				conflicts = append(conflicts, a)
+				break
+			}
+		}
+	}
+	return conflicts
+}
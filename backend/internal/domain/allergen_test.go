@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"testing"
+)
+
+// TestNormalizeAllergenCodes tests mapping free-text allergens to vocabulary codes
+func TestNormalizeAllergenCodes(t *testing.T) {
+	codes, err := NormalizeAllergenCodes([]string{"Dairy", "peanut", " milk ", "", "Nuts"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{AllergenMilk, AllergenPeanuts, AllergenTreeNuts}
+	if len(codes) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, codes)
+	}
+	for i := range expected {
+		if codes[i] != expected[i] {
+			t.Errorf("expected %v, got %v", expected, codes)
+		}
+	}
+
+	if _, err := NormalizeAllergenCodes([]string{"chocolate"}); err == nil {
+		t.Error("expected error for unknown allergen")
+	}
+}
+
+// TestFilterProducts tests dietary and allergen exclusion filters on the public menu
+func TestFilterProducts(t *testing.T) {
+	products := []Product{
+		{ID: 1, IsVegan: true, IsGlutenFree: true},
+		{ID: 2, IsVegetarian: true, Allergens: []string{AllergenMilk, AllergenGluten}},
+		{ID: 3, Allergens: []string{AllergenPeanuts}},
+	}
+
+	if got := FilterProducts(products, nil, nil); len(got) != 3 {
+		t.Errorf("expected no filtering, got %d products", len(got))
+	}
+	if got := FilterProducts(products, []string{DietaryVegetarian}, nil); len(got) != 2 {
+		t.Errorf("expected 2 vegetarian products, got %d", len(got))
+	}
+	if got := FilterProducts(products, []string{DietaryGlutenFree}, nil); len(got) != 1 || got[0].ID != 1 {
+		t.Errorf("expected only gluten-free product, got %+v", got)
+	}
+	if got := FilterProducts(products, nil, []string{AllergenMilk, AllergenPeanuts}); len(got) != 1 || got[0].ID != 1 {
+		t.Errorf("expected only allergen-free product, got %+v", got)
+	}
+}
+
+// TestAllergenConflicts tests order allergen warnings
+func TestAllergenConflicts(t *testing.T) {
+	conflicts := AllergenConflicts([]string{AllergenMilk, AllergenEggs}, []string{AllergenEggs, AllergenPeanuts})
+	if len(conflicts) != 1 || conflicts[0] != AllergenEggs {
+		t.Errorf("expected eggs conflict, got %v", conflicts)
+	}
+	if conflicts := AllergenConflicts([]string{AllergenMilk}, nil); len(conflicts) != 0 {
+		t.Errorf("expected no conflicts, got %v", conflicts)
+	}
+}
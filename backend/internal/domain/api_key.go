@@ -0,0 +1,223 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// API key defaults
+const (
+	// APIKeyHeader carries an API key; "Authorization: Bearer sk_..." is accepted as well
+	APIKeyHeader = "X-API-Key"
+	// APIKeyPrefix starts every API key, so keys can be told apart from access tokens
+	APIKeyPrefix = "sk_"
+	// DefaultAPIKeyRateLimit and MaxAPIKeyRateLimit are requests per minute
+	DefaultAPIKeyRateLimit = 600
+	MaxAPIKeyRateLimit     = 6000
+	APIKeyRateWindow       = time.Minute
+	MaxAPIKeyExpiryDays    = 3650
+)
+
+var (
+	ErrInvalidAPIKey      = errors.New("invalid or expired API key")
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+	ErrAPIKeyNotAllowed   = errors.New("API key is not allowed from this IP address or origin")
+	ErrAPIKeyRateLimited  = errors.New("API key rate limit exceeded")
+	ErrAPIKeyManagement   = errors.New("API keys cannot manage API keys")
+	ErrAPIKeyScopeTooWide = errors.New("an API key cannot be granted more than your own permissions")
+)
+
+// IsAPIKey reports whether a credential looks like an API key rather than an access token
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
+
+// FormatAPIKeyScopes encodes module permissions as the key's permission strings, e.g. "4:WRITE"
+func FormatAPIKeyScopes(permissions []ModulePermissionRequest) []string {
+	scopes := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		scopes = append(scopes, fmt.Sprintf("%d:%s", p.ModuleID, p.PermissionLevel))
+	}
+	return scopes
+}
+
+// ParseAPIKeyScopes decodes the key's permission strings; malformed entries grant nothing
+func ParseAPIKeyScopes(scopes []string) UserPermissions {
+	permissions := UserPermissions{}
+	for _, scope := range scopes {
+		module, level, ok := strings.Cut(strings.TrimSpace(scope), ":")
+		if !ok {
+			continue
+		}
+		moduleID, err := strconv.ParseInt(module, 10, 64)
+		if err != nil || moduleID <= 0 {
+			continue
+		}
+		if _, known := PermissionValues[level]; known {
+			permissions.Grant(moduleID, level)
+		}
+	}
+	return permissions
+}
+
+// APIKeyScopeList turns scopes back into module permissions for display
+func APIKeyScopeList(scopes []string) []ModulePermissionRequest {
+	list := []ModulePermissionRequest{}
+	for moduleID, level := range ParseAPIKeyScopes(scopes) {
+		list = append(list, ModulePermissionRequest{ModuleID: moduleID, PermissionLevel: level})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ModuleID < list[j].ModuleID })
+	return list
+}
+
+// CreateAPIKeyRequest creates an API key acting for the signed-in user in their restaurant
+type CreateAPIKeyRequest struct {
+	Name           string                    `json:"name"`
+	Permissions    []ModulePermissionRequest `json:"permissions"`
+	AllowedIPs     []string                  `json:"allowed_ips"`
+	AllowedOrigins []string                  `json:"allowed_origins"`
+	RateLimit      int                       `json:"rate_limit"`
+	ExpiresInDays  int                       `json:"expires_in_days"`
+}
+
+// Validate checks the name, scopes, allow lists and limits, and fills in the default rate limit
+func (req *CreateAPIKeyRequest) Validate() error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(req.Name) > 100 {
+		return errors.New("name must be less than 100 characters")
+	}
+	if err := validateAPIKeyScopes(req.Permissions); err != nil {
+		return err
+	}
+	for _, ip := range req.AllowedIPs {
+		if net.ParseIP(ip) == nil {
+			return fmt.Errorf("allowed_ips: %q is not an IP address", ip)
+		}
+	}
+	for _, origin := range req.AllowedOrigins {
+		if strings.Contains(origin, ",") || !strings.HasPrefix(origin, "http") {
+			return fmt.Errorf("allowed_origins: %q is not an origin", origin)
+		}
+	}
+	if req.RateLimit == 0 {
+		req.RateLimit = DefaultAPIKeyRateLimit
+	}
+	if err := validateAPIKeyRateLimit(req.RateLimit); err != nil {
+		return err
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > MaxAPIKeyExpiryDays {
+		return fmt.Errorf("expires_in_days must be between 0 and %d", MaxAPIKeyExpiryDays)
+	}
+	return nil
+}
+
+// UpdateAPIKeyRequest changes a key's scopes or rate limit; omitted fields are kept
+type UpdateAPIKeyRequest struct {
+	Permissions *[]ModulePermissionRequest `json:"permissions,omitempty"`
+	RateLimit   *int                       `json:"rate_limit,omitempty"`
+}
+
+// Validate checks the fields that are set
+func (req *UpdateAPIKeyRequest) Validate() error {
+	if req.Permissions == nil && req.RateLimit == nil {
+		return errors.New("permissions or rate_limit is required")
+	}
+	if req.Permissions != nil {
+		if err := validateAPIKeyScopes(*req.Permissions); err != nil {
+			return err
+		}
+	}
+	if req.RateLimit != nil {
+		return validateAPIKeyRateLimit(*req.RateLimit)
+	}
+	return nil
+}
+
+func validateAPIKeyScopes(permissions []ModulePermissionRequest) error {
+	if len(permissions) == 0 {
+		return errors.New("permissions must list at least one module")
+	}
+	seen := make(map[int64]bool, len(permissions))
+	for i := range permissions {
+		if err := permissions[i].Validate(); err != nil {
+			return err
+		}
+		if permissions[i].PermissionLevel == PermissionNone {
+			return fmt.Errorf("module %d: permission_level must not be NONE", permissions[i].ModuleID)
+		}
+		if seen[permissions[i].ModuleID] {
+			return fmt.Errorf("module %d is listed more than once", permissions[i].ModuleID)
+		}
+		seen[permissions[i].ModuleID] = true
+	}
+	return nil
+}
+
+func validateAPIKeyRateLimit(limit int) error {
+	if limit < 1 || limit > MaxAPIKeyRateLimit {
+		return fmt.Errorf("rate_limit must be between 1 and %d requests per minute", MaxAPIKeyRateLimit)
+	}
+	return nil
+}
+
+// APIKey is an API key as shown to admins; the key itself is only returned on creation
+type APIKey struct {
+	ID             int64                     `json:"id"`
+	Name           string                    `json:"name"`
+	Prefix         string                    `json:"prefix"`
+	UserID         int64                     `json:"user_id"`
+	RestaurantID   int64                     `json:"restaurant_id"`
+	Permissions    []ModulePermissionRequest `json:"permissions"`
+	AllowedIPs     []string                  `json:"allowed_ips"`
+	AllowedOrigins []string                  `json:"allowed_origins"`
+	RateLimit      int                       `json:"rate_limit"`
+	IsActive       bool                      `json:"is_active"`
+	LastUsedAt     *time.Time                `json:"last_used_at,omitempty"`
+	ExpiresAt      *time.Time                `json:"expires_at,omitempty"`
+	CreatedAt      time.Time                 `json:"created_at"`
+}
+
+// CreatedAPIKey returns a new key; it cannot be retrieved again
+type CreatedAPIKey struct {
+	APIKey string `json:"api_key"`
+	Key    APIKey `json:"key"`
+}
+
+// APIKeyUsage is a key's request statistics
+type APIKeyUsage struct {
+	KeyID           int64      `json:"key_id"`
+	TotalRequests   int64      `json:"total_requests"`
+	TodayRequests   int64      `json:"today_requests"`
+	MonthlyRequests int64      `json:"monthly_requests"`
+	ErrorRequests   int64      `json:"error_requests"`
+	AvgDurationMs   float64    `json:"avg_duration_ms"`
+	LastUsedAt      *time.Time `json:"last_used_at,omitempty"`
+}
+
+// APIKeyPrincipal is the identity of a request authenticated with an API key
+type APIKeyPrincipal struct {
+	KeyID        int64
+	TenantID     int64
+	RestaurantID int64
+	UserID       int64
+	Email        string
+	Role         string
+	Scopes       UserPermissions
+}
+
+// APIKeyRateLimitError is returned when a key has used up its requests for the window
+type APIKeyRateLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *APIKeyRateLimitError) Error() string { return ErrAPIKeyRateLimited.Error() }
+
+func (e *APIKeyRateLimitError) Unwrap() error { return ErrAPIKeyRateLimited }
@@ -0,0 +1,79 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestAPIKeyScopes tests that scopes round-trip and malformed entries grant nothing
+func TestAPIKeyScopes(t *testing.T) {
+	scopes := FormatAPIKeyScopes([]ModulePermissionRequest{
+		{ModuleID: 4, PermissionLevel: PermissionWrite},
+		{ModuleID: 1, PermissionLevel: PermissionRead},
+	})
+	if len(scopes) != 2 || scopes[0] != "4:WRITE" || scopes[1] != "1:READ" {
+		t.Fatalf("FormatAPIKeyScopes = %v", scopes)
+	}
+
+	granted := ParseAPIKeyScopes(append(scopes, "x:READ", "2:OWNER", "3"))
+	if !granted.Allows(4, PermissionRead) || !granted.Allows(4, PermissionWrite) || granted.Allows(4, PermissionDelete) {
+		t.Error("module 4 should allow READ and WRITE only")
+	}
+	if granted.Allows(2, PermissionRead) || granted.Allows(3, PermissionRead) {
+		t.Error("malformed scopes should grant nothing")
+	}
+
+	list := APIKeyScopeList(scopes)
+	if len(list) != 2 || list[0].ModuleID != 1 || list[1].ModuleID != 4 {
+		t.Errorf("APIKeyScopeList = %v, want modules 1 and 4 in order", list)
+	}
+}
+
+// TestCreateAPIKeyRequestValidate tests the required fields and limits
+func TestCreateAPIKeyRequestValidate(t *testing.T) {
+	valid := func() CreateAPIKeyRequest {
+		return CreateAPIKeyRequest{
+			Name:        " Aggregator ",
+			Permissions: []ModulePermissionRequest{{ModuleID: 4, PermissionLevel: PermissionWrite}},
+		}
+	}
+
+	req := valid()
+	if err := req.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if req.Name != "Aggregator" || req.RateLimit != DefaultAPIKeyRateLimit {
+		t.Errorf("Validate should trim the name and default the rate limit, got %q and %d", req.Name, req.RateLimit)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(*CreateAPIKeyRequest)
+	}{
+		{"missing name", func(r *CreateAPIKeyRequest) { r.Name = "" }},
+		{"no scopes", func(r *CreateAPIKeyRequest) { r.Permissions = nil }},
+		{"NONE scope", func(r *CreateAPIKeyRequest) { r.Permissions[0].PermissionLevel = PermissionNone }},
+		{"bad IP", func(r *CreateAPIKeyRequest) { r.AllowedIPs = []string{"10.0.0"} }},
+		{"bad origin", func(r *CreateAPIKeyRequest) { r.AllowedOrigins = []string{"example.com"} }},
+		{"rate limit too high", func(r *CreateAPIKeyRequest) { r.RateLimit = MaxAPIKeyRateLimit + 1 }},
+		{"negative expiry", func(r *CreateAPIKeyRequest) { r.ExpiresInDays = -1 }},
+	}
+	for _, tt := range tests {
+		req := valid()
+		tt.modify(&req)
+		if err := req.Validate(); err == nil {
+			t.Errorf("%s: expected an error", tt.name)
+		}
+	}
+}
+
+// TestAPIKeyRateLimitError tests that the rate limit error unwraps to its sentinel
+func TestAPIKeyRateLimitError(t *testing.T) {
+	var err error = &APIKeyRateLimitError{RetryAfter: APIKeyRateWindow}
+	if !errors.Is(err, ErrAPIKeyRateLimited) {
+		t.Error("expected ErrAPIKeyRateLimited")
+	}
+	if !IsAPIKey("sk_abc") || IsAPIKey("eyJhbGciOi") {
+		t.Error("IsAPIKey should only match the sk_ prefix")
+	}
+}
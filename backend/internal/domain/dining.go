@@ -0,0 +1,173 @@
+package domain
+
+import (
+	"fmt"
+	"time"
+)
+
+// Tab statuses
+const (
+	TabStatusOpen   = "open"
+	TabStatusClosed = "closed"
+	TabStatusMerged = "merged"
+)
+
+// DiningTable represents a physical dine-in table with a QR code
+type DiningTable struct {
+	ID           int64     `json:"id"`
+	TenantID     int64     `json:"tenant_id"`
+	RestaurantID int64     `json:"restaurant_id"`
+	TableNumber  string    `json:"table_number"`
+	Label        string    `json:"label,omitempty"`
+	Area         string    `json:"area,omitempty"`
+	Seats        int       `json:"seats"`
+	QRToken      string    `json:"qr_token"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Populated on demand
+	OpenTab *TableTab `json:"open_tab,omitempty"`
+}
+
+// TableTab represents an open bill on a table that collects one or more order rounds
+type TableTab struct {
+	ID              int64      `json:"id"`
+	TenantID        int64      `json:"tenant_id"`
+	RestaurantID    int64      `json:"restaurant_id"`
+	TableID         int64      `json:"table_id"`
+	TabNumber       string     `json:"tab_number"`
+	Status          string     `json:"status"` // 'open', 'closed', 'merged'
+	GuestCount      int        `json:"guest_count"`
+	MergedIntoTabID *int64     `json:"merged_into_tab_id,omitempty"`
+	OpenedAt        time.Time  `json:"opened_at"`
+	ClosedAt        *time.Time `json:"closed_at,omitempty"`
+	ClosedBy        *int64     `json:"closed_by,omitempty"`
+	Subtotal        float64    `json:"subtotal"`
+	TaxAmount       float64    `json:"tax_amount"`
+	DiscountAmount  float64    `json:"discount_amount"`
+	ServiceCharge   float64    `json:"service_charge"`
+	TotalAmount     float64    `json:"total_amount"`
+	PaymentMethod   string     `json:"payment_method,omitempty"`
+	Notes           string     `json:"notes,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Rounds placed on this tab (populated on demand)
+	Orders []Order `json:"orders,omitempty"`
+}
+
+// TabBill is the final bill produced when a tab is closed
+type TabBill struct {
+	Tab            *TableTab     `json:"tab"`
+	Table          *DiningTable  `json:"table,omitempty"`
+	Lines          []TabBillLine `json:"lines"`
+	RoundCount     int           `json:"round_count"`
+	Subtotal       float64       `json:"subtotal"`
+	TaxAmount      float64       `json:"tax_amount"`
+	DiscountAmount float64       `json:"discount_amount"`
+	ServiceCharge  float64       `json:"service_charge"`
+	TotalAmount    float64       `json:"total_amount"`
+}
+
+// TabBillLine aggregates identical items across all rounds of a tab
+type TabBillLine struct {
+	ProductID   int64   `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	VariantName string  `json:"variant_name,omitempty"`
+	Quantity    int     `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	TotalPrice  float64 `json:"total_price"`
+}
+
+// CreateDiningTableRequest is the request for creating a table
+type CreateDiningTableRequest struct {
+	TableNumber string `json:"table_number" validate:"required"`
+	Label       string `json:"label"`
+	Area        string `json:"area"`
+	Seats       int    `json:"seats"`
+}
+
+// UpdateDiningTableRequest is the request for updating a table
+type UpdateDiningTableRequest struct {
+	Label    *string `json:"label"`
+	Area     *string `json:"area"`
+	Seats    *int    `json:"seats"`
+	IsActive *bool   `json:"is_active"`
+}
+
+// TransferTabRequest moves an open tab to another table
+type TransferTabRequest struct {
+	TargetTableID int64 `json:"target_table_id" validate:"required"`
+}
+
+// MergeTabsRequest merges source tabs into a target tab
+type MergeTabsRequest struct {
+	SourceTabIDs []int64 `json:"source_tab_ids" validate:"required,min=1"`
+}
+
+// CloseTabRequest closes a tab into a final bill
+type CloseTabRequest struct {
+	PaymentMethod  string  `json:"payment_method"`
+	DiscountAmount float64 `json:"discount_amount"`
+	ServiceCharge  float64 `json:"service_charge"`
+	Notes          string  `json:"notes"`
+}
+
+// BuildTabBill aggregates the rounds of a tab into bill lines and totals.
+// Cancelled rounds are excluded from the bill.
+func BuildTabBill(tab *TableTab, orders []Order, discount, serviceCharge float64) *TabBill {
+	bill := &TabBill{
+		Tab:            tab,
+		Lines:          []TabBillLine{},
+		DiscountAmount: discount,
+		ServiceCharge:  serviceCharge,
+	}
+
+	index := make(map[string]int)
+	for _, order := range orders {
+		if order.Status == "cancelled" {
+			continue
+		}
+		bill.RoundCount++
+		bill.Subtotal += order.Subtotal
+		bill.TaxAmount += order.TaxAmount
+
+		for _, item := range order.Items {
+			key := fmt.Sprintf("%d|%s|%.2f", item.ProductID, item.VariantName, item.UnitPrice)
+			if i, ok := index[key]; ok {
+				bill.Lines[i].Quantity += item.Quantity
+				bill.Lines[i].TotalPrice += item.TotalPrice
+				continue
+			}
+			index[key] = len(bill.Lines)
+			bill.Lines = append(bill.Lines, TabBillLine{
+				ProductID:   item.ProductID,
+				ProductName: item.ProductName,
+				VariantName: item.VariantName,
+				Quantity:    item.Quantity,
+				UnitPrice:   item.UnitPrice,
+				TotalPrice:  item.TotalPrice,
+			})
+		}
+	}
+
+	bill.TotalAmount = bill.Subtotal + bill.TaxAmount + bill.ServiceCharge - bill.DiscountAmount
+	if bill.TotalAmount < 0 {
+		bill.TotalAmount = 0
+	}
+	return bill
+}
+
+// GenerateTabNumber generates a human readable tab number
+// Format: TAB-YYYYMMDD-<table>-XXXXXX
+func GenerateTabNumber(tableNumber string, seq int64) string {
+	return fmt.Sprintf("TAB-%s-%s-%06d", time.Now().Format("20060102"), tableNumber, seq%1000000)
+}
+
+// Error definitions for dine-in operations
+var (
+	ErrTableNotFound = fmt.Errorf("table not found")
+	ErrTabNotFound   = fmt.Errorf("tab not found")
+	ErrTabNotOpen    = fmt.Errorf("tab is not open")
+)
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"testing"
+)
+
+// TestBuildTabBill tests aggregation of tab rounds into a final bill
+func TestBuildTabBill(t *testing.T) {
+	tab := &TableTab{ID: 1, TableID: 3, Status: TabStatusOpen}
+	orders := []Order{
+		{
+			Status:    "delivered",
+			Subtotal:  30,
+			TaxAmount: 3,
+			Items: []OrderItem{
+				{ProductID: 1, ProductName: "Burger", Quantity: 2, UnitPrice: 10, TotalPrice: 20},
+				{ProductID: 2, ProductName: "Cola", Quantity: 2, UnitPrice: 5, TotalPrice: 10},
+			},
+		},
+		{
+			Status:    "pending",
+			Subtotal:  15,
+			TaxAmount: 1.5,
+			Items: []OrderItem{
+				{ProductID: 1, ProductName: "Burger", Quantity: 1, UnitPrice: 10, TotalPrice: 10},
+				{ProductID: 2, ProductName: "Cola", Quantity: 1, UnitPrice: 5, TotalPrice: 5},
+			},
+		},
+		{
+			Status:    "cancelled",
+			Subtotal:  50,
+			TaxAmount: 5,
+			Items: []OrderItem{
+				{ProductID: 9, ProductName: "Steak", Quantity: 1, UnitPrice: 50, TotalPrice: 50},
+			},
+		},
+	}
+
+	bill := BuildTabBill(tab, orders, 5, 2)
+
+	if bill.RoundCount != 2 {
+		t.Errorf("expected 2 rounds, got %d", bill.RoundCount)
+	}
+	if len(bill.Lines) != 2 {
+		t.Fatalf("expected 2 bill lines, got %d", len(bill.Lines))
+	}
+	if bill.Lines[0].Quantity != 3 || bill.Lines[0].TotalPrice != 30 {
+		t.Errorf("expected 3 burgers totalling 30, got %d totalling %.2f", bill.Lines[0].Quantity, bill.Lines[0].TotalPrice)
+	}
+	if bill.Subtotal != 45 || bill.TaxAmount != 4.5 {
+		t.Errorf("unexpected subtotal/tax: %.2f/%.2f", bill.Subtotal, bill.TaxAmount)
+	}
+	if bill.TotalAmount != 46.5 {
+		t.Errorf("expected total 46.50, got %.2f", bill.TotalAmount)
+	}
+}
+
+// TestBuildTabBillNeverNegative tests that discounts cannot push the bill below zero
+func TestBuildTabBillNeverNegative(t *testing.T) {
+	bill := BuildTabBill(&TableTab{}, []Order{{Status: "pending", Subtotal: 10, TaxAmount: 1}}, 100, 0)
+	if bill.TotalAmount != 0 {
+		t.Errorf("expected total 0, got %.2f", bill.TotalAmount)
+	}
+}
@@ -0,0 +1,165 @@
+package domain
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// Dispatch offer statuses
+const (
+	OfferStatusOffered   = "offered"
+	OfferStatusAccepted  = "accepted"
+	OfferStatusDeclined  = "declined"
+	OfferStatusExpired   = "expired"
+	OfferStatusCancelled = "cancelled"
+)
+
+// Dispatch defaults
+const (
+	// DispatchOfferTimeout is how long a driver has to accept an offer
+	DispatchOfferTimeout = 45 * time.Second
+	// MaxDispatchAttempts is how many drivers are offered a job before it is left for manual dispatch
+	MaxDispatchAttempts = 5
+	// MaxDriverActiveOrders excludes drivers already carrying this many orders
+	MaxDriverActiveOrders = 3
+	// MaxDispatchRadiusKm excludes drivers known to be further away from the restaurant
+	MaxDispatchRadiusKm = 15.0
+)
+
+// DispatchWeights balances the components of a driver's dispatch score
+type DispatchWeights struct {
+	Distance float64
+	Load     float64
+	Rating   float64
+}
+
+// DefaultDispatchWeights favours the closest driver, then the least busy, then the best rated
+var DefaultDispatchWeights = DispatchWeights{Distance: 0.5, Load: 0.3, Rating: 0.2}
+
+// DispatchOffer is a delivery job offered to a driver
+type DispatchOffer struct {
+	ID           int64      `json:"id"`
+	TenantID     int64      `json:"tenant_id"`
+	RestaurantID int64      `json:"restaurant_id"`
+	OrderID      int64      `json:"order_id"`
+	DriverID     int        `json:"driver_id"`
+	Attempt      int        `json:"attempt"`
+	Status       string     `json:"status"`
+	Score        float64    `json:"score"`
+	DistanceKm   *float64   `json:"distance_km,omitempty"`
+	IsManual     bool       `json:"is_manual"`
+	AssignedBy   *int64     `json:"assigned_by,omitempty"`
+	OfferedAt    time.Time  `json:"offered_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RespondedAt  *time.Time `json:"responded_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// DispatchCandidate is an available driver ranked for a delivery job
+type DispatchCandidate struct {
+	Driver     Driver   `json:"driver"`
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+	Score      float64  `json:"score"`
+}
+
+// DispatchStatus is the dispatcher's view of an order's driver search
+type DispatchStatus struct {
+	OrderID      int64             `json:"order_id"`
+	State        string            `json:"state"` // 'offered', 'assigned', 'unassigned'
+	CurrentOffer *DispatchOffer    `json:"current_offer,omitempty"`
+	Assignment   *DriverAssignment `json:"assignment,omitempty"`
+	Offers       []DispatchOffer   `json:"offers"`
+}
+
+// ManualAssignRequest lets a dispatcher override the dispatch engine
+type ManualAssignRequest struct {
+	DriverID int    `json:"driver_id" validate:"required"`
+	Notes    string `json:"notes,omitempty"`
+}
+
+// OfferResponseRequest records a driver's answer to an offer
+type OfferResponseRequest struct {
+	DriverID int `json:"driver_id" validate:"required"`
+}
+
+// Error definitions for dispatch operations
+var (
+	ErrNoDriverAvailable = errors.New("no driver available")
+	ErrOfferNotFound     = errors.New("dispatch offer not found")
+	ErrOfferNotPending   = errors.New("dispatch offer is no longer open")
+	ErrOfferExpired      = errors.New("dispatch offer has expired")
+	ErrOrderNotDelivery  = errors.New("order is not a delivery order")
+)
+
+// NeedsDelivery reports whether an order has to be dispatched to a driver
+func (o *Order) NeedsDelivery() bool {
+	if o.OrderSource == OrderSourceDineIn || o.OrderSource == OrderSourceInStore {
+		return false
+	}
+	return o.DeliveryAddress != "" || o.DeliveryLatitude != 0 || o.DeliveryLongitude != 0
+}
+
+// RankDispatchCandidates scores available drivers for a job at the restaurant and returns them best first.
+// A driver's position is taken from their last recorded location, falling back to the current
+// position on the driver record. Drivers in excluded, at capacity or outside the dispatch radius are skipped.
+func RankDispatchCandidates(
+	drivers []Driver,
+	lastLocations map[int]*DriverLocation,
+	restaurantLat, restaurantLon *float64,
+	excluded map[int]bool,
+	weights DispatchWeights,
+) []DispatchCandidate {
+	candidates := make([]DispatchCandidate, 0, len(drivers))
+	for _, d := range drivers {
+		if excluded[d.ID] || d.ActiveOrdersCount >= MaxDriverActiveOrders {
+			continue
+		}
+
+		var distance *float64
+		lat, lon := d.CurrentLatitude, d.CurrentLongitude
+		if loc := lastLocations[d.ID]; loc != nil {
+			lat, lon = &loc.Latitude, &loc.Longitude
+		}
+		if lat != nil && lon != nil && restaurantLat != nil && restaurantLon != nil {
+			km := HaversineKm(*lat, *lon, *restaurantLat, *restaurantLon)
+			if km > MaxDispatchRadiusKm {
+				continue
+			}
+			distance = &km
+		}
+
+		candidates = append(candidates, DispatchCandidate{
+			Driver:     d,
+			DistanceKm: distance,
+			Score:      DispatchScore(distance, d.ActiveOrdersCount, d.AverageRating, weights),
+		})
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	return candidates
+}
+
+// DispatchScore combines distance, current load and rating into a score between 0 and 1.
+// Drivers without a known position get a neutral distance score; unrated drivers a neutral rating.
+func DispatchScore(distanceKm *float64, activeOrders int, rating float64, weights DispatchWeights) float64 {
+	distanceScore := 0.5
+	if distanceKm != nil {
+		distanceScore = 1 / (1 + *distanceKm)
+	}
+
+	loadScore := 1 / float64(1+activeOrders)
+
+	ratingScore := 0.6
+	if rating > 0 {
+		ratingScore = rating / 5
+	}
+
+	total := weights.Distance + weights.Load + weights.Rating
+	if total <= 0 {
+		return 0
+	}
+	return (weights.Distance*distanceScore + weights.Load*loadScore + weights.Rating*ratingScore) / total
+}
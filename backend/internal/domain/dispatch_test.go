@@ -0,0 +1,88 @@
+package domain
+
+import "testing"
+
+func floatPtr(v float64) *float64 { return &v }
+
+// TestRankDispatchCandidates tests driver ranking by distance, load and rating
+func TestRankDispatchCandidates(t *testing.T) {
+	restLat, restLon := floatPtr(30.0444), floatPtr(31.2357)
+
+	drivers := []Driver{
+		// Far away (about 13 km), idle, top rated
+		{ID: 1, CurrentLatitude: floatPtr(29.9792), CurrentLongitude: floatPtr(31.1342), AverageRating: 5},
+		// Next to the restaurant, idle
+		{ID: 2, CurrentLatitude: floatPtr(30.0450), CurrentLongitude: floatPtr(31.2360), AverageRating: 4},
+		// Next to the restaurant but at capacity
+		{ID: 3, CurrentLatitude: floatPtr(30.0444), CurrentLongitude: floatPtr(31.2357), ActiveOrdersCount: MaxDriverActiveOrders},
+		// Already offered this job
+		{ID: 4, CurrentLatitude: floatPtr(30.0444), CurrentLongitude: floatPtr(31.2357)},
+		// Outside the dispatch radius (Alexandria)
+		{ID: 5, CurrentLatitude: floatPtr(31.2001), CurrentLongitude: floatPtr(29.9187)},
+	}
+
+	candidates := RankDispatchCandidates(drivers, nil, restLat, restLon, map[int]bool{4: true}, DefaultDispatchWeights)
+	if len(candidates) != 2 {
+		t.Fatalf("expected 2 candidates, got %d", len(candidates))
+	}
+	if candidates[0].Driver.ID != 2 || candidates[1].Driver.ID != 1 {
+		t.Errorf("expected drivers ranked [2 1], got [%d %d]", candidates[0].Driver.ID, candidates[1].Driver.ID)
+	}
+	if candidates[0].DistanceKm == nil || *candidates[0].DistanceKm > 1 {
+		t.Errorf("expected nearby driver distance under 1 km, got %v", candidates[0].DistanceKm)
+	}
+}
+
+// TestRankDispatchCandidatesUsesLastLocation tests that recorded locations override the driver record
+func TestRankDispatchCandidatesUsesLastLocation(t *testing.T) {
+	restLat, restLon := floatPtr(30.0444), floatPtr(31.2357)
+	drivers := []Driver{
+		{ID: 1, CurrentLatitude: floatPtr(30.0444), CurrentLongitude: floatPtr(31.2357)},
+		{ID: 2},
+	}
+	locations := map[int]*DriverLocation{
+		1: {DriverID: 1, Latitude: 30.10, Longitude: 31.30},
+		2: {DriverID: 2, Latitude: 30.0445, Longitude: 31.2358},
+	}
+
+	candidates := RankDispatchCandidates(drivers, locations, restLat, restLon, nil, DefaultDispatchWeights)
+	if len(candidates) != 2 || candidates[0].Driver.ID != 2 {
+		t.Errorf("expected driver 2 first from last location, got %+v", candidates)
+	}
+}
+
+// TestDispatchScore tests the score components
+func TestDispatchScore(t *testing.T) {
+	near := DispatchScore(floatPtr(0.5), 0, 4, DefaultDispatchWeights)
+	far := DispatchScore(floatPtr(8), 0, 4, DefaultDispatchWeights)
+	busy := DispatchScore(floatPtr(0.5), 2, 4, DefaultDispatchWeights)
+
+	if near <= far {
+		t.Errorf("expected closer driver to score higher: near=%.3f far=%.3f", near, far)
+	}
+	if near <= busy {
+		t.Errorf("expected idle driver to score higher: idle=%.3f busy=%.3f", near, busy)
+	}
+	if s := DispatchScore(nil, 0, 0, DispatchWeights{}); s != 0 {
+		t.Errorf("expected 0 with zero weights, got %.3f", s)
+	}
+}
+
+// TestOrderNeedsDelivery tests which orders are dispatched to drivers
+func TestOrderNeedsDelivery(t *testing.T) {
+	tests := []struct {
+		order    Order
+		expected bool
+	}{
+		{Order{OrderSource: OrderSourceWebsite, DeliveryAddress: "12 Nile St"}, true},
+		{Order{OrderSource: OrderSourcePhone, DeliveryLatitude: 30.1, DeliveryLongitude: 31.2}, true},
+		{Order{OrderSource: OrderSourceDineIn, DeliveryAddress: "12 Nile St"}, false},
+		{Order{OrderSource: OrderSourceWebsite}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.order.NeedsDelivery(); got != tt.expected {
+			t.Errorf("NeedsDelivery(%+v) = %v, want %v", tt.order.OrderSource, got, tt.expected)
+		}
+	}
+}
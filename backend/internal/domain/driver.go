@@ -51,6 +51,9 @@ type DriverAssignment struct {
 	AssignmentNotes string     `json:"assignment_notes,omitempty"`
 	Rating          *int       `json:"rating,omitempty"`
 	RatingComment   string     `json:"rating_comment,omitempty"`
+	ProofType       string     `json:"proof_type,omitempty"` // photo, pin
+	ProofPhotoURL   string     `json:"proof_photo_url,omitempty"`
+	ProofPINVerified bool      `json:"proof_pin_verified"`
 	CreatedAt       time.Time  `json:"created_at"`
 	UpdatedAt       time.Time  `json:"updated_at"`
 }
@@ -77,6 +80,14 @@ type DriverStats struct {
 	AverageRating       float64 `json:"average_rating"`
 	CompletionRate      float64 `json:"completion_rate"`
 	ActiveOrders        int     `json:"active_orders"`
+
+	// Financials
+	TotalEarnings     float64    `json:"total_earnings"`
+	UnsettledEarnings float64    `json:"unsettled_earnings"`
+	UnsettledCash     float64    `json:"unsettled_cash"`
+	CashVariance      float64    `json:"cash_variance"` // sum over all settlements
+	SettlementsCount  int        `json:"settlements_count"`
+	LastSettledAt     *time.Time `json:"last_settled_at,omitempty"`
 }
 
 // CreateDriverRequest represents the request to create a new driver
@@ -134,4 +145,5 @@ type UpdateLocationRequest struct {
 	Latitude  float64 `json:"latitude" validate:"required"`
 	Longitude float64 `json:"longitude" validate:"required"`
 	Accuracy  *int    `json:"accuracy,omitempty"`
+	OrderID   *int    `json:"order_id,omitempty"`
 }
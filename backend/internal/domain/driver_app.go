@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"crypto/subtle"
+	"errors"
+	"sync"
+	"time"
+)
+
+// DriverRole is the JWT role carried by driver app tokens
+const DriverRole = "driver"
+
+// Delivery proof types
+const (
+	ProofTypePhoto = "photo"
+	ProofTypePIN   = "pin"
+)
+
+// Driver app defaults
+const (
+	// DeliveryPINLength is the number of digits of the code the customer gives the driver
+	DeliveryPINLength = 4
+	// LocationUpdateInterval is the minimum time between two stored locations of a driver
+	LocationUpdateInterval = 3 * time.Second
+	// MinDriverPasswordLength is the shortest password accepted for the driver app
+	MinDriverPasswordLength = 6
+)
+
+// DriverLoginRequest signs a driver in to the driver app with their email or phone number
+type DriverLoginRequest struct {
+	Login    string `json:"login" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+// DriverLoginResponse is returned after a successful driver login
+type DriverLoginResponse struct {
+	Token  string  `json:"token"`
+	Driver *Driver `json:"driver"`
+}
+
+// SetDriverPasswordRequest lets an admin set the driver app password of a driver
+type SetDriverPasswordRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// DriverDutyRequest puts a driver on or off duty
+type DriverDutyRequest struct {
+	OnDuty bool `json:"on_duty"`
+}
+
+// DriverJob is a job shown in the driver app: an open offer or an active assignment
+type DriverJob struct {
+	Offer      *DispatchOffer    `json:"offer,omitempty"`
+	Assignment *DriverAssignment `json:"assignment,omitempty"`
+	Order      *DriverJobOrder   `json:"order"`
+}
+
+// DriverJobOrder is the part of an order a driver needs to deliver it.
+// Customer contact details are only included once the job is assigned to the driver.
+type DriverJobOrder struct {
+	ID                   int64       `json:"id"`
+	OrderNumber          string      `json:"order_number"`
+	Status               string      `json:"status"`
+	CustomerName         string      `json:"customer_name,omitempty"`
+	CustomerPhone        string      `json:"customer_phone,omitempty"`
+	DeliveryAddress      string      `json:"delivery_address"`
+	DeliveryCity         string      `json:"delivery_city,omitempty"`
+	DeliveryArea         string      `json:"delivery_area,omitempty"`
+	DeliveryLatitude     float64     `json:"delivery_latitude,omitempty"`
+	DeliveryLongitude    float64     `json:"delivery_longitude,omitempty"`
+	DeliveryInstructions string      `json:"delivery_instructions,omitempty"`
+	TotalAmount          float64     `json:"total_amount"`
+	PaymentMethod        string      `json:"payment_method"`
+	PaymentStatus        string      `json:"payment_status"`
+	Items                []OrderItem `json:"items,omitempty"`
+}
+
+// DeliverRequest completes a delivery with the customer's PIN; a photo can be sent instead.
+// CashCollected is the cash taken for a cash order; it defaults to the amount due.
+type DeliverRequest struct {
+	PIN           string   `json:"pin,omitempty"`
+	CashCollected *float64 `json:"cash_collected,omitempty"`
+}
+
+// Error definitions for driver app operations
+var (
+	ErrInvalidDriverCredentials = errors.New("invalid login or password")
+	ErrDriverInactive           = errors.New("driver account is not active")
+	ErrDriverHasActiveJobs      = errors.New("cannot go off duty with active deliveries")
+	ErrJobNotFound              = errors.New("job not found")
+	ErrInvalidDeliveryPIN       = errors.New("invalid delivery PIN")
+	ErrDeliveryProofRequired    = errors.New("delivery proof is required: photo or customer PIN")
+	ErrInvalidCoordinates       = errors.New("invalid coordinates")
+)
+
+// NewDriverJobOrder builds the driver's view of an order; contact details are withheld from offers
+func NewDriverJobOrder(order *Order, assigned bool) *DriverJobOrder {
+	job := &DriverJobOrder{
+		ID:                   order.ID,
+		OrderNumber:          order.OrderNumber,
+		Status:               order.Status,
+		DeliveryAddress:      order.DeliveryAddress,
+		DeliveryCity:         order.DeliveryCity,
+		DeliveryArea:         order.DeliveryArea,
+		DeliveryLatitude:     order.DeliveryLatitude,
+		DeliveryLongitude:    order.DeliveryLongitude,
+		DeliveryInstructions: order.DeliveryInstructions,
+		TotalAmount:          order.TotalAmount,
+		PaymentMethod:        order.PaymentMethod,
+		PaymentStatus:        order.PaymentStatus,
+	}
+	if assigned {
+		job.CustomerName = order.CustomerName
+		job.CustomerPhone = order.CustomerPhone
+		job.Items = order.Items
+	}
+	return job
+}
+
+// VerifyDeliveryPIN compares the PIN given by the customer with the order's PIN in constant time
+func VerifyDeliveryPIN(expected, given string) bool {
+	if expected == "" || len(expected) != len(given) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(given)) == 1
+}
+
+// ValidCoordinates reports whether a latitude/longitude pair is on the globe and not the 0,0 default
+func ValidCoordinates(lat, lon float64) bool {
+	if lat == 0 && lon == 0 {
+		return false
+	}
+	return lat >= -90 && lat <= 90 && lon >= -180 && lon <= 180
+}
+
+// LocationThrottle rate limits location updates per driver.
+// A driver's update is accepted when at least the interval has passed since their last accepted update.
+type LocationThrottle struct {
+	interval time.Duration
+	mu       sync.Mutex
+	last     map[int]time.Time
+}
+
+// NewLocationThrottle creates a location throttle with the given minimum interval
+func NewLocationThrottle(interval time.Duration) *LocationThrottle {
+	return &LocationThrottle{interval: interval, last: make(map[int]time.Time)}
+}
+
+// Allow reports whether an update from the driver at the given time should be stored
+func (t *LocationThrottle) Allow(driverID int, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if last, ok := t.last[driverID]; ok && now.Sub(last) < t.interval {
+		return false
+	}
+	t.last[driverID] = now
+	return true
+}
+
+// Forget drops a driver's state, e.g. when they go off duty
+func (t *LocationThrottle) Forget(driverID int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.last, driverID)
+}
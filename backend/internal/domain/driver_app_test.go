@@ -0,0 +1,104 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestVerifyDeliveryPIN tests customer PIN checks at the door
+func TestVerifyDeliveryPIN(t *testing.T) {
+	tests := []struct {
+		expected string
+		given    string
+		valid    bool
+	}{
+		{"4821", "4821", true},
+		{"4821", "4822", false},
+		{"4821", "48210", false},
+		{"", "", false},
+		{"", "1234", false},
+	}
+
+	for _, tt := range tests {
+		if got := VerifyDeliveryPIN(tt.expected, tt.given); got != tt.valid {
+			t.Errorf("VerifyDeliveryPIN(%q, %q) = %v, want %v", tt.expected, tt.given, got, tt.valid)
+		}
+	}
+}
+
+// TestValidCoordinates tests location sanity checks
+func TestValidCoordinates(t *testing.T) {
+	tests := []struct {
+		lat, lon float64
+		valid    bool
+	}{
+		{30.0444, 31.2357, true},
+		{-33.86, 151.20, true},
+		{0, 0, false},
+		{91, 31, false},
+		{30, -181, false},
+	}
+
+	for _, tt := range tests {
+		if got := ValidCoordinates(tt.lat, tt.lon); got != tt.valid {
+			t.Errorf("ValidCoordinates(%v, %v) = %v, want %v", tt.lat, tt.lon, got, tt.valid)
+		}
+	}
+}
+
+// TestLocationThrottle tests per-driver rate limiting of location updates
+func TestLocationThrottle(t *testing.T) {
+	throttle := NewLocationThrottle(3 * time.Second)
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	if !throttle.Allow(1, start) {
+		t.Fatal("expected first update to be allowed")
+	}
+	if throttle.Allow(1, start.Add(time.Second)) {
+		t.Error("expected update within the interval to be dropped")
+	}
+	if !throttle.Allow(2, start.Add(time.Second)) {
+		t.Error("expected another driver's update to be allowed")
+	}
+	if !throttle.Allow(1, start.Add(3*time.Second)) {
+		t.Error("expected update after the interval to be allowed")
+	}
+
+	// A dropped update does not push back the next allowed one
+	if throttle.Allow(1, start.Add(5*time.Second)) {
+		t.Error("expected update within the interval to be dropped")
+	}
+	if !throttle.Allow(1, start.Add(6*time.Second)) {
+		t.Error("expected update 3s after the last stored one to be allowed")
+	}
+
+	throttle.Forget(1)
+	if !throttle.Allow(1, start.Add(6*time.Second+time.Millisecond)) {
+		t.Error("expected update to be allowed after Forget")
+	}
+}
+
+// TestNewDriverJobOrder tests that customer details are withheld from offers
+func TestNewDriverJobOrder(t *testing.T) {
+	order := &Order{
+		ID:              7,
+		OrderNumber:     "ORD-7",
+		CustomerName:    "Mona",
+		CustomerPhone:   "+201000000000",
+		DeliveryAddress: "12 Nile St",
+		Items:           []OrderItem{{ProductID: 1, Quantity: 2}},
+	}
+
+	offer := NewDriverJobOrder(order, false)
+	if offer.CustomerName != "" || offer.CustomerPhone != "" || offer.Items != nil {
+		t.Errorf("expected customer details withheld from offer, got %+v", offer)
+	}
+	if offer.DeliveryAddress != "12 Nile St" {
+		t.Errorf("expected delivery address on offer, got %q", offer.DeliveryAddress)
+	}
+
+	assigned := NewDriverJobOrder(order, true)
+	if assigned.CustomerPhone != "+201000000000" || len(assigned.Items) != 1 {
+		t.Errorf("expected customer details on assigned job, got %+v", assigned)
+	}
+}
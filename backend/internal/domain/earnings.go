@@ -0,0 +1,298 @@
+package domain
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"time"
+)
+
+// Settlement statuses
+const (
+	SettlementStatusBalanced = "balanced"
+	SettlementStatusShort    = "short"
+	SettlementStatusOver     = "over"
+)
+
+// CashTolerance is the largest cash difference still treated as balanced
+const CashTolerance = 0.01
+
+// EarningRule defines how a driver is paid.
+// A rule without DriverID is the restaurant default; a driver's own rule overrides it.
+type EarningRule struct {
+	ID              int64     `json:"id"`
+	TenantID        int64     `json:"tenant_id"`
+	RestaurantID    int64     `json:"restaurant_id"`
+	DriverID        *int      `json:"driver_id,omitempty"`
+	PerDelivery     float64   `json:"per_delivery"`
+	PerKm           float64   `json:"per_km"`
+	IncentiveEvery  int       `json:"incentive_every"` // bonus for every N deliveries in a settlement, 0 = none
+	IncentiveAmount float64   `json:"incentive_amount"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// EarningRuleRequest creates or replaces the restaurant default or a driver's rule
+type EarningRuleRequest struct {
+	DriverID        *int    `json:"driver_id,omitempty"`
+	PerDelivery     float64 `json:"per_delivery"`
+	PerKm           float64 `json:"per_km"`
+	IncentiveEvery  int     `json:"incentive_every"`
+	IncentiveAmount float64 `json:"incentive_amount"`
+}
+
+// Validate checks the rule amounts
+func (r *EarningRuleRequest) Validate() error {
+	if r.PerDelivery < 0 || r.PerKm < 0 || r.IncentiveEvery < 0 || r.IncentiveAmount < 0 {
+		return errors.New("earning rule amounts cannot be negative")
+	}
+	if r.IncentiveAmount > 0 && r.IncentiveEvery == 0 {
+		return errors.New("incentive_every is required with an incentive_amount")
+	}
+	return nil
+}
+
+// DeliveryEarning is what a driver earned and collected on one completed delivery
+type DeliveryEarning struct {
+	AssignmentID  int        `json:"assignment_id"`
+	OrderID       int64      `json:"order_id"`
+	OrderNumber   string     `json:"order_number"`
+	DriverID      int        `json:"driver_id"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	PaymentMethod string     `json:"payment_method"`
+	DistanceKm    float64    `json:"distance_km"`
+	Earning       float64    `json:"earning"`
+	CashExpected  float64    `json:"cash_expected"`
+	CashCollected float64    `json:"cash_collected"`
+	SettlementID  *int64     `json:"settlement_id,omitempty"`
+}
+
+// DriverSettlement reconciles a driver's deliveries and cash at the end of a shift
+type DriverSettlement struct {
+	ID               int64             `json:"id,omitempty"`
+	TenantID         int64             `json:"tenant_id"`
+	RestaurantID     int64             `json:"restaurant_id"`
+	DriverID         int               `json:"driver_id"`
+	PeriodStart      time.Time         `json:"period_start"`
+	PeriodEnd        time.Time         `json:"period_end"`
+	DeliveriesCount  int               `json:"deliveries_count"`
+	DistanceKm       float64           `json:"distance_km"`
+	DeliveryEarnings float64           `json:"delivery_earnings"`
+	Incentives       float64           `json:"incentives"`
+	TotalEarnings    float64           `json:"total_earnings"`
+	CashExpected     float64           `json:"cash_expected"`
+	CashCollected    float64           `json:"cash_collected"`
+	CashHandedIn     float64           `json:"cash_handed_in"`
+	CashVariance     float64           `json:"cash_variance"` // handed in minus expected
+	Status           string            `json:"status"`        // 'balanced', 'short', 'over'
+	Notes            string            `json:"notes,omitempty"`
+	SettledBy        *int64            `json:"settled_by,omitempty"`
+	SettledAt        *time.Time        `json:"settled_at,omitempty"`
+	Deliveries       []DeliveryEarning `json:"deliveries,omitempty"`
+}
+
+// SettleDriverRequest closes a driver's shift with the cash they handed in
+type SettleDriverRequest struct {
+	CashHandedIn float64 `json:"cash_handed_in"`
+	Notes        string  `json:"notes,omitempty"`
+}
+
+// SettlementFilters for listing settlements
+type SettlementFilters struct {
+	DriverID int
+	From     *time.Time
+	To       *time.Time
+	Page     int
+	Limit    int
+}
+
+// DriverEarningsReport lists a driver's deliveries and earnings over a period
+type DriverEarningsReport struct {
+	DriverID        int               `json:"driver_id"`
+	From            *time.Time        `json:"from,omitempty"`
+	To              *time.Time        `json:"to,omitempty"`
+	DeliveriesCount int               `json:"deliveries_count"`
+	DistanceKm      float64           `json:"distance_km"`
+	Earnings        float64           `json:"earnings"`
+	CashCollected   float64           `json:"cash_collected"`
+	Deliveries      []DeliveryEarning `json:"deliveries"`
+}
+
+// Error definitions for driver earnings
+var (
+	ErrEarningRuleNotFound  = errors.New("earning rule not found")
+	ErrSettlementNotFound   = errors.New("settlement not found")
+	ErrNothingToSettle      = errors.New("driver has no unsettled deliveries")
+	ErrDeliveriesSettled    = errors.New("deliveries were settled concurrently")
+	ErrInvalidExportFormat  = errors.New("invalid export format. allowed: csv, json")
+	ErrNegativeCashHandedIn = errors.New("cash_handed_in cannot be negative")
+)
+
+// RoundMoney rounds an amount to cents
+func RoundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// DeliveryPay returns the earning for one delivery of the given distance
+func (r *EarningRule) DeliveryPay(distanceKm float64) float64 {
+	if r == nil {
+		return 0
+	}
+	return RoundMoney(r.PerDelivery + r.PerKm*distanceKm)
+}
+
+// Incentive returns the bonus for the number of deliveries in a settlement
+func (r *EarningRule) Incentive(deliveries int) float64 {
+	if r == nil || r.IncentiveEvery <= 0 {
+		return 0
+	}
+	return RoundMoney(float64(deliveries/r.IncentiveEvery) * r.IncentiveAmount)
+}
+
+// CashDue returns the cash a driver has to collect for an order: the total of unpaid cash orders
+func CashDue(order *Order) float64 {
+	if order.PaymentMethod != "cash" || order.PaymentStatus == "paid" {
+		return 0
+	}
+	return RoundMoney(order.TotalAmount)
+}
+
+// SettlementStatusFor classifies a cash variance
+func SettlementStatusFor(variance float64) string {
+	switch {
+	case variance <= -CashTolerance:
+		return SettlementStatusShort
+	case variance >= CashTolerance:
+		return SettlementStatusOver
+	default:
+		return SettlementStatusBalanced
+	}
+}
+
+// BuildSettlement totals a driver's unsettled deliveries against the cash handed in.
+// The period runs from the first delivery (or since, when given) to now.
+func BuildSettlement(rule *EarningRule, deliveries []DeliveryEarning, since *time.Time, cashHandedIn float64, now time.Time) *DriverSettlement {
+	s := &DriverSettlement{
+		PeriodStart:     now,
+		PeriodEnd:       now,
+		DeliveriesCount: len(deliveries),
+		CashHandedIn:    RoundMoney(cashHandedIn),
+		Deliveries:      deliveries,
+	}
+	if since != nil {
+		s.PeriodStart = *since
+	}
+
+	for _, d := range deliveries {
+		s.DistanceKm += d.DistanceKm
+		s.DeliveryEarnings += d.Earning
+		s.CashExpected += d.CashExpected
+		s.CashCollected += d.CashCollected
+		if since == nil && d.CompletedAt != nil && d.CompletedAt.Before(s.PeriodStart) {
+			s.PeriodStart = *d.CompletedAt
+		}
+	}
+
+	s.DistanceKm = math.Round(s.DistanceKm*100) / 100
+	s.DeliveryEarnings = RoundMoney(s.DeliveryEarnings)
+	s.CashExpected = RoundMoney(s.CashExpected)
+	s.CashCollected = RoundMoney(s.CashCollected)
+	s.Incentives = rule.Incentive(len(deliveries))
+	s.TotalEarnings = RoundMoney(s.DeliveryEarnings + s.Incentives)
+	s.CashVariance = RoundMoney(s.CashHandedIn - s.CashExpected)
+	s.Status = SettlementStatusFor(s.CashVariance)
+	return s
+}
+
+// NewDriverEarningsReport totals a list of deliveries
+func NewDriverEarningsReport(driverID int, from, to *time.Time, deliveries []DeliveryEarning) *DriverEarningsReport {
+	report := &DriverEarningsReport{DriverID: driverID, From: from, To: to, DeliveriesCount: len(deliveries), Deliveries: deliveries}
+	for _, d := range deliveries {
+		report.DistanceKm += d.DistanceKm
+		report.Earnings += d.Earning
+		report.CashCollected += d.CashCollected
+	}
+	report.DistanceKm = math.Round(report.DistanceKm*100) / 100
+	report.Earnings = RoundMoney(report.Earnings)
+	report.CashCollected = RoundMoney(report.CashCollected)
+	return report
+}
+
+var deliveryEarningsCSVHeader = []string{
+	"assignment_id", "order_number", "completed_at", "payment_method",
+	"distance_km", "earning", "cash_expected", "cash_collected",
+}
+
+// WriteDeliveryEarningsCSV writes deliveries as CSV rows under a header
+func WriteDeliveryEarningsCSV(w *csv.Writer, deliveries []DeliveryEarning) error {
+	if err := w.Write(deliveryEarningsCSVHeader); err != nil {
+		return err
+	}
+	for _, d := range deliveries {
+		completedAt := ""
+		if d.CompletedAt != nil {
+			completedAt = d.CompletedAt.Format(time.RFC3339)
+		}
+		err := w.Write([]string{
+			fmt.Sprint(d.AssignmentID), d.OrderNumber, completedAt, d.PaymentMethod,
+			money(d.DistanceKm), money(d.Earning), money(d.CashExpected), money(d.CashCollected),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteSettlementCSV writes a settlement summary followed by its deliveries
+func WriteSettlementCSV(out io.Writer, s *DriverSettlement) error {
+	w := csv.NewWriter(out)
+	rows := [][]string{
+		{"settlement_id", fmt.Sprint(s.ID)},
+		{"driver_id", fmt.Sprint(s.DriverID)},
+		{"period_start", s.PeriodStart.Format(time.RFC3339)},
+		{"period_end", s.PeriodEnd.Format(time.RFC3339)},
+		{"deliveries", fmt.Sprint(s.DeliveriesCount)},
+		{"distance_km", money(s.DistanceKm)},
+		{"delivery_earnings", money(s.DeliveryEarnings)},
+		{"incentives", money(s.Incentives)},
+		{"total_earnings", money(s.TotalEarnings)},
+		{"cash_expected", money(s.CashExpected)},
+		{"cash_collected", money(s.CashCollected)},
+		{"cash_handed_in", money(s.CashHandedIn)},
+		{"cash_variance", money(s.CashVariance)},
+		{"status", s.Status},
+		{},
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	if err := WriteDeliveryEarningsCSV(w, s.Deliveries); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// WriteEarningsReportCSV writes a driver's deliveries followed by a totals row
+func WriteEarningsReportCSV(out io.Writer, report *DriverEarningsReport) error {
+	w := csv.NewWriter(out)
+	if err := WriteDeliveryEarningsCSV(w, report.Deliveries); err != nil {
+		return err
+	}
+	err := w.Write([]string{
+		"total", fmt.Sprint(report.DeliveriesCount), "", "",
+		money(report.DistanceKm), money(report.Earnings), "", money(report.CashCollected),
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", v)
+}
@@ -0,0 +1,110 @@
+package domain
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEarningRulePay tests per-delivery pay and volume incentives
+func TestEarningRulePay(t *testing.T) {
+	rule := &EarningRule{PerDelivery: 10, PerKm: 2.5, IncentiveEvery: 5, IncentiveAmount: 20}
+
+	if got := rule.DeliveryPay(3.2); got != 18 {
+		t.Errorf("DeliveryPay(3.2) = %v, want 18", got)
+	}
+	if got := rule.Incentive(4); got != 0 {
+		t.Errorf("Incentive(4) = %v, want 0", got)
+	}
+	if got := rule.Incentive(11); got != 40 {
+		t.Errorf("Incentive(11) = %v, want 40", got)
+	}
+
+	var none *EarningRule
+	if none.DeliveryPay(5) != 0 || none.Incentive(10) != 0 {
+		t.Error("expected no pay without a rule")
+	}
+}
+
+// TestCashDue tests which orders the driver collects cash for
+func TestCashDue(t *testing.T) {
+	tests := []struct {
+		name     string
+		order    Order
+		expected float64
+	}{
+		{"unpaid cash", Order{PaymentMethod: "cash", PaymentStatus: "pending", TotalAmount: 125.456}, 125.46},
+		{"prepaid cash", Order{PaymentMethod: "cash", PaymentStatus: "paid", TotalAmount: 80}, 0},
+		{"card", Order{PaymentMethod: "card", PaymentStatus: "pending", TotalAmount: 80}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CashDue(&tt.order); got != tt.expected {
+				t.Errorf("CashDue() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestBuildSettlement tests shift totals and cash reconciliation
+func TestBuildSettlement(t *testing.T) {
+	now := time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC)
+	first := now.Add(-6 * time.Hour)
+	second := now.Add(-2 * time.Hour)
+	rule := &EarningRule{PerDelivery: 10, IncentiveEvery: 2, IncentiveAmount: 15}
+
+	deliveries := []DeliveryEarning{
+		{AssignmentID: 1, OrderNumber: "ORD-1", CompletedAt: &second, PaymentMethod: "cash", DistanceKm: 2.4, Earning: 10, CashExpected: 100, CashCollected: 100},
+		{AssignmentID: 2, OrderNumber: "ORD-2", CompletedAt: &first, PaymentMethod: "card", DistanceKm: 1.1, Earning: 10},
+	}
+
+	s := BuildSettlement(rule, deliveries, nil, 95, now)
+	if s.DeliveriesCount != 2 || s.DistanceKm != 3.5 {
+		t.Errorf("unexpected totals: %d deliveries, %.2f km", s.DeliveriesCount, s.DistanceKm)
+	}
+	if s.DeliveryEarnings != 20 || s.Incentives != 15 || s.TotalEarnings != 35 {
+		t.Errorf("unexpected earnings: %v + %v = %v", s.DeliveryEarnings, s.Incentives, s.TotalEarnings)
+	}
+	if s.CashExpected != 100 || s.CashVariance != -5 || s.Status != SettlementStatusShort {
+		t.Errorf("unexpected cash: expected %v, variance %v, status %s", s.CashExpected, s.CashVariance, s.Status)
+	}
+	if !s.PeriodStart.Equal(first) || !s.PeriodEnd.Equal(now) {
+		t.Errorf("unexpected period %v - %v", s.PeriodStart, s.PeriodEnd)
+	}
+
+	since := now.Add(-8 * time.Hour)
+	s = BuildSettlement(rule, deliveries, &since, 100.004, now)
+	if !s.PeriodStart.Equal(since) {
+		t.Errorf("expected period to start at the previous settlement, got %v", s.PeriodStart)
+	}
+	if s.Status != SettlementStatusBalanced {
+		t.Errorf("expected balanced settlement, got %s (variance %v)", s.Status, s.CashVariance)
+	}
+
+	if got := SettlementStatusFor(0.5); got != SettlementStatusOver {
+		t.Errorf("SettlementStatusFor(0.5) = %s, want over", got)
+	}
+}
+
+// TestWriteSettlementCSV tests the settlement export
+func TestWriteSettlementCSV(t *testing.T) {
+	now := time.Date(2024, 5, 1, 22, 0, 0, 0, time.UTC)
+	deliveries := []DeliveryEarning{
+		{AssignmentID: 7, OrderNumber: "ORD-7", CompletedAt: &now, PaymentMethod: "cash", DistanceKm: 1.5, Earning: 12.5, CashExpected: 40, CashCollected: 40},
+	}
+	s := BuildSettlement(&EarningRule{PerDelivery: 12.5}, deliveries, nil, 40, now)
+	s.ID = 3
+
+	var buf bytes.Buffer
+	if err := WriteSettlementCSV(&buf, s); err != nil {
+		t.Fatalf("WriteSettlementCSV() error = %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"settlement_id,3", "cash_variance,0.00", "status,balanced", "7,ORD-7,2024-05-01T22:00:00Z,cash,1.50,12.50,40.00,40.00"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected CSV to contain %q, got:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// Geofence events emitted from driver location updates
+const (
+	GeofenceArrivedAtRestaurant = "arrived_at_restaurant"
+	GeofencePickedUp            = "picked_up"
+	GeofenceArrivedAtCustomer   = "arrived_at_customer"
+)
+
+// Geofence defaults
+const (
+	// RestaurantGeofenceRadiusKm is how close a driver has to be to count as at the restaurant
+	RestaurantGeofenceRadiusKm = 0.1
+	// RestaurantGeofenceExitKm is how far a driver has to move away to count as having left
+	// the restaurant; the gap to the arrival radius absorbs GPS jitter at the door
+	RestaurantGeofenceExitKm = 0.2
+	// CustomerGeofenceRadiusKm is how close a driver has to be to count as at the drop-off
+	CustomerGeofenceRadiusKm = 0.075
+	// MaxGeofenceAccuracyMeters is the worst reported accuracy still used for geofencing
+	MaxGeofenceAccuracyMeters = 100
+)
+
+// GeofenceEvent is an arrival or departure detected for a driver assignment
+type GeofenceEvent struct {
+	ID           int64     `json:"id"`
+	AssignmentID int       `json:"assignment_id"`
+	DriverID     int       `json:"driver_id"`
+	OrderID      int       `json:"order_id"`
+	Event        string    `json:"event"`
+	Latitude     float64   `json:"latitude"`
+	Longitude    float64   `json:"longitude"`
+	OccurredAt   time.Time `json:"occurred_at"`
+}
+
+// GeofenceTarget is what geofencing needs to know about one of a driver's assignments
+type GeofenceTarget struct {
+	AssignmentID int
+	OrderID      int
+	Status       string // assignment status
+	Restaurant   *RoutePoint
+	DropOff      *RoutePoint
+	Emitted      map[string]bool // events already recorded for the assignment
+}
+
+// LocationUpdateResult is the answer to a driver location update
+type LocationUpdateResult struct {
+	Stored bool            `json:"stored"`
+	Events []GeofenceEvent `json:"events,omitempty"`
+}
+
+// DetectGeofenceEvents returns the events a location triggers for an assignment, in order.
+// A driver arrives at the restaurant inside its radius, picks up when leaving it again after
+// arriving, and arrives at the customer inside the drop-off radius once the order is on its way.
+func DetectGeofenceEvents(t *GeofenceTarget, lat, lon float64) []string {
+	var events []string
+	emitted := func(event string) bool {
+		if t.Emitted[event] {
+			return true
+		}
+		for _, e := range events {
+			if e == event {
+				return true
+			}
+		}
+		return false
+	}
+
+	if t.Restaurant != nil {
+		km := HaversineKm(t.Restaurant.Latitude, t.Restaurant.Longitude, lat, lon)
+		waiting := t.Status == "pending" || t.Status == "accepted"
+		if waiting && !emitted(GeofenceArrivedAtRestaurant) && km <= RestaurantGeofenceRadiusKm {
+			events = append(events, GeofenceArrivedAtRestaurant)
+		}
+		if emitted(GeofenceArrivedAtRestaurant) && !emitted(GeofencePickedUp) && km > RestaurantGeofenceExitKm {
+			events = append(events, GeofencePickedUp)
+		}
+	}
+
+	if t.DropOff != nil && !emitted(GeofenceArrivedAtCustomer) &&
+		(t.Status == "in_progress" || emitted(GeofencePickedUp)) {
+		if HaversineKm(t.DropOff.Latitude, t.DropOff.Longitude, lat, lon) <= CustomerGeofenceRadiusKm {
+			events = append(events, GeofenceArrivedAtCustomer)
+		}
+	}
+	return events
+}
+
+// GeofenceAccuracyOK reports whether a location is precise enough for geofencing
+func GeofenceAccuracyOK(accuracy *int) bool {
+	return accuracy == nil || *accuracy <= MaxGeofenceAccuracyMeters
+}
+
+// LocationRetentionPolicy controls how long a tenant's driver location history is kept:
+// every point for KeepFullDays, then one point per DownsampleIntervalSeconds per driver
+// until PurgeAfterDays, after which points are deleted.
+type LocationRetentionPolicy struct {
+	TenantID                  int64      `json:"tenant_id"`
+	KeepFullDays              int        `json:"keep_full_days"`
+	DownsampleIntervalSeconds int        `json:"downsample_interval_seconds"`
+	PurgeAfterDays            int        `json:"purge_after_days"`
+	UpdatedAt                 *time.Time `json:"updated_at,omitempty"`
+}
+
+// Location retention defaults and limits
+const (
+	DefaultKeepFullDays              = 7
+	DefaultDownsampleIntervalSeconds = 60
+	DefaultPurgeAfterDays            = 90
+	MinDownsampleIntervalSeconds     = 10
+	MaxDownsampleIntervalSeconds     = 3600
+)
+
+// LocationRetentionResult reports what a retention run removed for a tenant
+type LocationRetentionResult struct {
+	TenantID    int64 `json:"tenant_id"`
+	Downsampled int64 `json:"downsampled"`
+	Purged      int64 `json:"purged"`
+}
+
+// ErrInvalidRetentionPolicy is returned for an inconsistent retention policy
+var ErrInvalidRetentionPolicy = errors.New("invalid retention policy: keep_full_days must be at least 1, purge_after_days at least keep_full_days and downsample_interval_seconds between 10 and 3600")
+
+// DefaultLocationRetentionPolicy returns the policy used by tenants that have not set one
+func DefaultLocationRetentionPolicy(tenantID int64) *LocationRetentionPolicy {
+	return &LocationRetentionPolicy{
+		TenantID:                  tenantID,
+		KeepFullDays:              DefaultKeepFullDays,
+		DownsampleIntervalSeconds: DefaultDownsampleIntervalSeconds,
+		PurgeAfterDays:            DefaultPurgeAfterDays,
+	}
+}
+
+// Validate checks the policy limits
+func (p *LocationRetentionPolicy) Validate() error {
+	if p.KeepFullDays < 1 || p.PurgeAfterDays < p.KeepFullDays ||
+		p.DownsampleIntervalSeconds < MinDownsampleIntervalSeconds || p.DownsampleIntervalSeconds > MaxDownsampleIntervalSeconds {
+		return ErrInvalidRetentionPolicy
+	}
+	return nil
+}
+
+// Cutoffs returns the times before which points are downsampled and purged
+func (p *LocationRetentionPolicy) Cutoffs(now time.Time) (downsampleBefore, purgeBefore time.Time) {
+	day := 24 * time.Hour
+	return now.Add(-time.Duration(p.KeepFullDays) * day), now.Add(-time.Duration(p.PurgeAfterDays) * day)
+}
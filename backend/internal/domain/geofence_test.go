@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestDetectGeofenceEvents tests arrivals and departures along a delivery
+func TestDetectGeofenceEvents(t *testing.T) {
+	restaurant := &RoutePoint{Latitude: 30.0444, Longitude: 31.2357}
+	dropOff := &RoutePoint{OrderID: 9, Latitude: 30.0600, Longitude: 31.2500}
+
+	tests := []struct {
+		name     string
+		status   string
+		emitted  map[string]bool
+		lat, lon float64
+		expected []string
+	}{
+		{"approaching the restaurant", "accepted", nil, 30.0470, 31.2357, nil},
+		{"at the restaurant", "accepted", nil, 30.0448, 31.2357, []string{GeofenceArrivedAtRestaurant}},
+		{"at the restaurant again", "accepted", map[string]bool{GeofenceArrivedAtRestaurant: true}, 30.0448, 31.2357, nil},
+		{"just outside the door", "accepted", map[string]bool{GeofenceArrivedAtRestaurant: true}, 30.0455, 31.2357, nil},
+		{"leaving the restaurant", "accepted", map[string]bool{GeofenceArrivedAtRestaurant: true}, 30.0500, 31.2400, []string{GeofencePickedUp}},
+		{"leaving without arriving", "accepted", nil, 30.0500, 31.2400, nil},
+		{"at the customer", "in_progress", map[string]bool{GeofenceArrivedAtRestaurant: true, GeofencePickedUp: true}, 30.0603, 31.2500, []string{GeofenceArrivedAtCustomer}},
+		{"at the customer before pick-up", "accepted", nil, 30.0603, 31.2500, nil},
+		{"picked up in the app, at the customer", "in_progress", nil, 30.0603, 31.2500, []string{GeofenceArrivedAtCustomer}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := &GeofenceTarget{AssignmentID: 1, OrderID: 9, Status: tt.status, Restaurant: restaurant, DropOff: dropOff, Emitted: tt.emitted}
+			got := DetectGeofenceEvents(target, tt.lat, tt.lon)
+			if len(got) != len(tt.expected) {
+				t.Fatalf("DetectGeofenceEvents() = %v, want %v", got, tt.expected)
+			}
+			for i := range got {
+				if got[i] != tt.expected[i] {
+					t.Errorf("DetectGeofenceEvents() = %v, want %v", got, tt.expected)
+				}
+			}
+		})
+	}
+
+	accuracy := 250
+	if GeofenceAccuracyOK(&accuracy) {
+		t.Error("expected a 250 m fix to be too imprecise for geofencing")
+	}
+}
+
+// TestLocationRetentionPolicy tests policy validation and cutoffs
+func TestLocationRetentionPolicy(t *testing.T) {
+	policy := DefaultLocationRetentionPolicy(1)
+	if err := policy.Validate(); err != nil {
+		t.Fatalf("expected default policy to be valid, got %v", err)
+	}
+
+	now := time.Date(2024, 5, 31, 12, 0, 0, 0, time.UTC)
+	downsampleBefore, purgeBefore := policy.Cutoffs(now)
+	if !downsampleBefore.Equal(now.AddDate(0, 0, -DefaultKeepFullDays)) || !purgeBefore.Equal(now.AddDate(0, 0, -DefaultPurgeAfterDays)) {
+		t.Errorf("unexpected cutoffs %v, %v", downsampleBefore, purgeBefore)
+	}
+
+	invalid := []LocationRetentionPolicy{
+		{KeepFullDays: 0, DownsampleIntervalSeconds: 60, PurgeAfterDays: 30},
+		{KeepFullDays: 30, DownsampleIntervalSeconds: 60, PurgeAfterDays: 7},
+		{KeepFullDays: 7, DownsampleIntervalSeconds: 5, PurgeAfterDays: 30},
+		{KeepFullDays: 7, DownsampleIntervalSeconds: 7200, PurgeAfterDays: 30},
+	}
+	for _, p := range invalid {
+		if err := p.Validate(); err != ErrInvalidRetentionPolicy {
+			t.Errorf("expected %+v to be invalid, got %v", p, err)
+		}
+	}
+}
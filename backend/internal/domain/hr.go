@@ -65,6 +65,7 @@ type Employee struct {
 	UpdatedAt time.Time  `json:"updated_at"`
 	CreatedBy *int       `json:"created_by,omitempty"`
 	UpdatedBy *int       `json:"updated_by,omitempty"`
+	UserID    *int       `json:"user_id,omitempty"` // Login account, for self-service
 
 	// Relations (populated on demand)
 	Roles []Role `json:"roles,omitempty"`
@@ -197,6 +198,8 @@ type Salary struct {
 	TenantID       int       `json:"tenant_id"`
 	RestaurantID   int       `json:"restaurant_id"`
 	EmployeeID     int       `json:"employee_id"`
+	EmployeeName   string    `json:"employee_name,omitempty"`
+	PayrollRunID   *int      `json:"payroll_run_id,omitempty"`
 
 	// Payroll Period
 	PayPeriodStart time.Time  `json:"pay_period_start"`
@@ -284,7 +287,7 @@ type Leave struct {
 	// Leave Period
 	StartDate      time.Time `json:"start_date"`
 	EndDate        time.Time `json:"end_date"`
-	TotalDays      int       `json:"total_days"`
+	TotalDays      float64   `json:"total_days"`
 	IsHalfDay      bool      `json:"is_half_day"`
 	HalfDayPeriod  string    `json:"half_day_period,omitempty"` // 'morning', 'afternoon'
 
@@ -306,6 +309,9 @@ type Leave struct {
 	IsApproved bool   `json:"is_approved"`
 
 	// Approval Chain
+	CurrentApprovalLevel int             `json:"current_approval_level"`
+	Approvals            []LeaveApproval `json:"approvals,omitempty"`
+
 	ApprovedBy    *int       `json:"approved_by,omitempty"`
 	ApprovedAt    *time.Time `json:"approved_at,omitempty"`
 	ApprovalNotes string     `json:"approval_notes,omitempty"`
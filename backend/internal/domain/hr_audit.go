@@ -0,0 +1,74 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// HR audit entity types
+const (
+	AuditEmployee            = "employee"
+	AuditAttendance          = "attendance"
+	AuditSalary              = "salary"
+	AuditLeave               = "leave"
+	AuditPerformanceReview   = "performance_review"
+	AuditTimeClockSettings   = "time_clock_settings"
+	AuditShiftTemplate       = "shift_template"
+	AuditStaffingRequirement = "staffing_requirement"
+	AuditRota                = "rota"
+	AuditShift               = "shift"
+	AuditPayrollSettings     = "payroll_settings"
+	AuditDeductionRule       = "deduction_rule"
+	AuditPayrollRun          = "payroll_run"
+	AuditLeavePolicy         = "leave_policy"
+	AuditHolidayCalendar     = "holiday_calendar"
+	AuditLeaveBalance        = "leave_balance"
+	AuditReviewCycle         = "review_cycle"
+	AuditReviewCriterion     = "review_criterion"
+	AuditPerformanceGoal     = "performance_goal"
+)
+
+// HR audit actions
+const (
+	AuditCreate      = "create"
+	AuditUpdate      = "update"
+	AuditDelete      = "delete"
+	AuditApprove     = "approve"
+	AuditReject      = "reject"
+	AuditCancel      = "cancel"
+	AuditSubmit      = "submit"
+	AuditAcknowledge = "acknowledge"
+	AuditFinalize    = "finalize"
+	AuditPublish     = "publish"
+	AuditUnpublish   = "unpublish"
+	AuditPay         = "pay"
+)
+
+// HRAuditLog is an entry in the HR audit trail
+type HRAuditLog struct {
+	ID            int             `json:"id"`
+	TenantID      int             `json:"tenant_id"`
+	RestaurantID  int             `json:"restaurant_id"`
+	EntityType    string          `json:"entity_type"`
+	EntityID      int             `json:"entity_id"`
+	EntityName    string          `json:"entity_name,omitempty"`
+	Action        string          `json:"action"`
+	OldValues     json.RawMessage `json:"old_values,omitempty"`
+	NewValues     json.RawMessage `json:"new_values,omitempty"`
+	ChangedFields []string        `json:"changed_fields,omitempty"`
+	UserID        *int            `json:"user_id,omitempty"`
+	UserName      string          `json:"user_name,omitempty"`
+	UserEmail     string          `json:"user_email,omitempty"`
+	Reason        string          `json:"reason,omitempty"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// HRAuditFilter narrows the audit trail listing
+type HRAuditFilter struct {
+	EntityType string
+	EntityID   int
+	UserID     int
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+}
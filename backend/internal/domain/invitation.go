@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Invitation delivery channels
+const (
+	InvitationChannelEmail = "email"
+	InvitationChannelSMS   = "sms"
+)
+
+// Invitation statuses, derived from the accepted, revoked and expiry timestamps
+const (
+	InvitationPending  = "pending"
+	InvitationAccepted = "accepted"
+	InvitationRevoked  = "revoked"
+	InvitationExpired  = "expired"
+)
+
+const (
+	// InvitationTTL is how long an invitation link stays valid; resending starts a new period
+	InvitationTTL = 7 * 24 * time.Hour
+	// RolesModuleID is the Roles & Permissions module; pre-assigning roles needs WRITE on it
+	RolesModuleID = 9
+)
+
+var (
+	ErrUserLimitReached      = errors.New("the organization has reached its user limit")
+	ErrInvitationNotFound    = errors.New("invitation not found")
+	ErrInvalidInvitation     = errors.New("invalid or expired invitation")
+	ErrInvitationNotPending  = errors.New("invitation has already been accepted or revoked")
+	ErrInvitationRoles       = errors.New("assigning roles requires permission to manage roles")
+	ErrInvitationNotSent     = errors.New("invitation was saved but could not be sent, try resending it")
+	ErrEmployeeAlreadyLinked = errors.New("employee is already linked to another account")
+	ErrUserAlreadyLinked     = errors.New("account is already linked to another employee of this restaurant")
+	ErrNoEmployeeRecord      = errors.New("your account is not linked to an employee of this restaurant")
+	ErrInvitationEmailInUse  = errors.New("this email already has an account in another organization; ask for the invitation to be sent by email")
+	ErrInvitationPassword    = errors.New("this email already has an account in another organization; enter the password you use there")
+)
+
+// CreateInvitationRequest invites someone to join the tenant. The account is created in the
+// inviter's restaurant with the listed roles; EmployeeID links it to an existing HR record.
+type CreateInvitationRequest struct {
+	Name       string  `json:"name"`
+	Email      string  `json:"email"`
+	Phone      string  `json:"phone,omitempty"`
+	Channel    string  `json:"channel"` // 'email' (default) or 'sms'
+	RoleIDs    []int64 `json:"role_ids,omitempty"`
+	EmployeeID *int    `json:"employee_id,omitempty"`
+}
+
+// Validate normalizes the email and checks the channel. Invitees always need an email,
+// as it is what they sign in with; SMS invitations also need a phone number.
+func (req *CreateInvitationRequest) Validate() error {
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return errors.New("name is required")
+	}
+	if len(req.Name) > 255 {
+		return errors.New("name must be less than 255 characters")
+	}
+	email, err := NormalizeEmail(req.Email)
+	if err != nil {
+		return err
+	}
+	req.Email = email
+
+	req.Phone = strings.TrimSpace(req.Phone)
+	switch req.Channel {
+	case "":
+		req.Channel = InvitationChannelEmail
+	case InvitationChannelEmail, InvitationChannelSMS:
+	default:
+		return errors.New("channel must be email or sms")
+	}
+	if req.Phone != "" && !validPhone(req.Phone) {
+		return errors.New("phone must be in international format, e.g. +15551234567")
+	}
+	if req.Channel == InvitationChannelSMS && req.Phone == "" {
+		return errors.New("phone is required for sms invitations")
+	}
+
+	seen := make(map[int64]bool, len(req.RoleIDs))
+	roles := req.RoleIDs[:0]
+	for _, id := range req.RoleIDs {
+		if id <= 0 {
+			return errors.New("role_ids must be valid role IDs")
+		}
+		if !seen[id] {
+			seen[id] = true
+			roles = append(roles, id)
+		}
+	}
+	req.RoleIDs = roles
+
+	if req.EmployeeID != nil && *req.EmployeeID <= 0 {
+		return errors.New("employee_id must be a valid employee ID")
+	}
+	return nil
+}
+
+// validPhone accepts E.164 numbers: a plus sign and 8 to 15 digits
+func validPhone(phone string) bool {
+	if !strings.HasPrefix(phone, "+") || len(phone) < 9 || len(phone) > 16 {
+		return false
+	}
+	for _, c := range phone[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// UserInvitation is an invitation as shown to admins; the token is only ever sent to the invitee
+type UserInvitation struct {
+	ID           int64      `json:"id"`
+	TenantID     int        `json:"tenant_id"`
+	RestaurantID *int       `json:"restaurant_id,omitempty"`
+	EmployeeID   *int       `json:"employee_id,omitempty"`
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	Phone        string     `json:"phone,omitempty"`
+	Channel      string     `json:"channel"`
+	RoleIDs      []int64    `json:"role_ids"`
+	Status       string     `json:"status"`
+	InvitedBy    *int       `json:"invited_by,omitempty"`
+	UserID       *int       `json:"user_id,omitempty"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	AcceptedAt   *time.Time `json:"accepted_at,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	TokenHash    string     `json:"-"`
+}
+
+// StatusAt returns the invitation's status at the given time
+func (inv *UserInvitation) StatusAt(now time.Time) string {
+	switch {
+	case inv.AcceptedAt != nil:
+		return InvitationAccepted
+	case inv.RevokedAt != nil:
+		return InvitationRevoked
+	case !now.Before(inv.ExpiresAt):
+		return InvitationExpired
+	}
+	return InvitationPending
+}
+
+// UserSeats counts the tenant's users against its plan. Pending invitations hold a seat
+// until they are accepted, revoked or expire.
+type UserSeats struct {
+	MaxUsers           int `json:"max_users"`
+	ActiveUsers        int `json:"active_users"`
+	PendingInvitations int `json:"pending_invitations"`
+}
+
+// Available returns how many more people can be invited
+func (s UserSeats) Available() int {
+	if n := s.MaxUsers - s.ActiveUsers - s.PendingInvitations; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// InvitationList is returned by GET /api/v1/users/invitations
+type InvitationList struct {
+	Invitations []UserInvitation `json:"invitations"`
+	Seats       UserSeats        `json:"seats"`
+}
+
+// InvitationPreview is what the invitee sees before accepting
+type InvitationPreview struct {
+	OrganizationName string    `json:"organization_name"`
+	Name             string    `json:"name"`
+	Email            string    `json:"email"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// AcceptInvitationRequest is sent to /api/v1/auth/invitations/accept with the token from
+// the invitation link and the password the invitee chose
+type AcceptInvitationRequest struct {
+	Token           string `json:"token"`
+	Name            string `json:"name,omitempty"` // Overrides the name given by the inviter
+	Password        string `json:"password"`
+	ConfirmPassword string `json:"confirm_password"`
+	DeviceName      string `json:"device_name,omitempty"`
+}
+
+// Validate checks the token and the chosen password
+func (req *AcceptInvitationRequest) Validate() error {
+	if strings.TrimSpace(req.Token) == "" {
+		return errors.New("token is required")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if len(req.Name) > 255 {
+		return errors.New("name must be less than 255 characters")
+	}
+	if req.Password != req.ConfirmPassword {
+		return errors.New("passwords do not match")
+	}
+	return ValidatePassword(req.Password)
+}
+
+// LinkEmployeeUserRequest links an employee to a login account; a null user_id unlinks it
+type LinkEmployeeUserRequest struct {
+	UserID *int `json:"user_id"`
+}
@@ -0,0 +1,86 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCreateInvitationRequestValidate tests normalization and channel rules of invitations
+func TestCreateInvitationRequestValidate(t *testing.T) {
+	req := CreateInvitationRequest{Name: " Sara ", Email: " Sara@Example.com ", RoleIDs: []int64{3, 3, 5}}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if req.Name != "Sara" || req.Email != "sara@example.com" || req.Channel != InvitationChannelEmail {
+		t.Errorf("normalized request = %+v", req)
+	}
+	if len(req.RoleIDs) != 2 || req.RoleIDs[0] != 3 || req.RoleIDs[1] != 5 {
+		t.Errorf("RoleIDs = %v, want [3 5]", req.RoleIDs)
+	}
+
+	invalid := map[string]CreateInvitationRequest{
+		"missing name":       {Email: "a@example.com"},
+		"bad email":          {Name: "A", Email: "not-an-email"},
+		"unknown channel":    {Name: "A", Email: "a@example.com", Channel: "fax"},
+		"sms without phone":  {Name: "A", Email: "a@example.com", Channel: InvitationChannelSMS},
+		"local phone number": {Name: "A", Email: "a@example.com", Channel: InvitationChannelSMS, Phone: "0555123456"},
+		"bad role":           {Name: "A", Email: "a@example.com", RoleIDs: []int64{0}},
+	}
+	for name, req := range invalid {
+		if err := req.Validate(); err == nil {
+			t.Errorf("%s: Validate() expected an error", name)
+		}
+	}
+
+	sms := CreateInvitationRequest{Name: "A", Email: "a@example.com", Channel: InvitationChannelSMS, Phone: "+966555123456"}
+	if err := sms.Validate(); err != nil {
+		t.Errorf("sms invitation: Validate() error = %v", err)
+	}
+}
+
+// TestUserInvitationStatus tests that the status follows the invitation's timestamps
+func TestUserInvitationStatus(t *testing.T) {
+	now := time.Now()
+	inv := UserInvitation{ExpiresAt: now.Add(time.Hour)}
+	if got := inv.StatusAt(now); got != InvitationPending {
+		t.Errorf("open invitation status = %s", got)
+	}
+	if got := inv.StatusAt(now.Add(2 * time.Hour)); got != InvitationExpired {
+		t.Errorf("expired invitation status = %s", got)
+	}
+	inv.RevokedAt = &now
+	if got := inv.StatusAt(now); got != InvitationRevoked {
+		t.Errorf("revoked invitation status = %s", got)
+	}
+	inv.AcceptedAt = &now
+	if got := inv.StatusAt(now.Add(2 * time.Hour)); got != InvitationAccepted {
+		t.Errorf("accepted invitation status = %s", got)
+	}
+}
+
+// TestUserSeatsAvailable tests that pending invitations hold seats
+func TestUserSeatsAvailable(t *testing.T) {
+	if got := (UserSeats{MaxUsers: 5, ActiveUsers: 3, PendingInvitations: 1}).Available(); got != 1 {
+		t.Errorf("Available() = %d, want 1", got)
+	}
+	if got := (UserSeats{MaxUsers: 5, ActiveUsers: 6}).Available(); got != 0 {
+		t.Errorf("Available() over the limit = %d, want 0", got)
+	}
+}
+
+// TestAcceptInvitationRequestValidate tests the password rules applied on acceptance
+func TestAcceptInvitationRequestValidate(t *testing.T) {
+	ok := AcceptInvitationRequest{Token: "t", Password: "secret123", ConfirmPassword: "secret123"}
+	if err := ok.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for name, req := range map[string]AcceptInvitationRequest{
+		"missing token": {Password: "secret123", ConfirmPassword: "secret123"},
+		"mismatch":      {Token: "t", Password: "secret123", ConfirmPassword: "secret124"},
+		"weak password": {Token: "t", Password: "short", ConfirmPassword: "short"},
+	} {
+		if err := req.Validate(); err == nil {
+			t.Errorf("%s: Validate() expected an error", name)
+		}
+	}
+}
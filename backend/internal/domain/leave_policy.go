@@ -0,0 +1,526 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Leave accrual frequencies
+const (
+	AccrualNone     = "none"
+	AccrualMonthly  = "monthly"
+	AccrualAnnually = "annually"
+)
+
+// Leave balance transaction kinds
+const (
+	BalanceCarryOver  = "carry_over"
+	BalanceAccrual    = "accrual"
+	BalanceUsage      = "usage"
+	BalanceReversal   = "reversal"
+	BalanceAdjustment = "adjustment"
+)
+
+// Leave approval statuses
+const (
+	ApprovalPending   = "pending"
+	ApprovalApproved  = "approved"
+	ApprovalRejected  = "rejected"
+	ApprovalCancelled = "cancelled"
+)
+
+// DefaultWeekendDays are the days off used when a restaurant has no holiday calendar (Friday and Saturday)
+var DefaultWeekendDays = []int{int(time.Friday), int(time.Saturday)}
+
+// LeaveTypes are the leave types a leave request or policy can have
+var LeaveTypes = []string{
+	"annual", "sick", "casual", "maternity", "paternity",
+	"unpaid", "compensatory", "emergency", "bereavement", "study", "other",
+}
+
+// IsLeaveType reports whether leaveType is a known leave type
+func IsLeaveType(leaveType string) bool {
+	for _, t := range LeaveTypes {
+		if t == leaveType {
+			return true
+		}
+	}
+	return false
+}
+
+// LeavePolicy is a restaurant's rules for one leave type
+type LeavePolicy struct {
+	ID                    int       `json:"id"`
+	TenantID              int       `json:"tenant_id"`
+	RestaurantID          int       `json:"restaurant_id"`
+	LeaveType             string    `json:"leave_type"`
+	LeaveCategory         string    `json:"leave_category"` // 'paid', 'unpaid'
+	TrackBalance          bool      `json:"track_balance"`
+	AccrualFrequency      string    `json:"accrual_frequency"` // 'none', 'monthly', 'annually'
+	AccrualDays           float64   `json:"accrual_days"`
+	MaxCarryOver          *float64  `json:"max_carry_over,omitempty"` // nil carries the whole balance over
+	ProbationMonths       int       `json:"probation_months"`
+	AccrueDuringProbation bool      `json:"accrue_during_probation"`
+	ApprovalLevels        int       `json:"approval_levels"`
+	HolidayCalendarID     *int      `json:"holiday_calendar_id,omitempty"`
+	IsActive              bool      `json:"is_active"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// DefaultLeavePolicy is used for leave types the restaurant has no policy for: no balance is
+// kept and the employee's manager approves
+func DefaultLeavePolicy(leaveType string) *LeavePolicy {
+	category := "paid"
+	if leaveType == "unpaid" {
+		category = "unpaid"
+	}
+	return &LeavePolicy{
+		LeaveType:             leaveType,
+		LeaveCategory:         category,
+		AccrualFrequency:      AccrualNone,
+		AccrueDuringProbation: true,
+		ApprovalLevels:        1,
+		IsActive:              true,
+	}
+}
+
+// LeavePolicyRequest creates or replaces a leave policy
+type LeavePolicyRequest struct {
+	LeaveType             string   `json:"leave_type" validate:"required"`
+	LeaveCategory         string   `json:"leave_category,omitempty"`
+	TrackBalance          *bool    `json:"track_balance,omitempty"`
+	AccrualFrequency      string   `json:"accrual_frequency,omitempty"`
+	AccrualDays           float64  `json:"accrual_days"`
+	MaxCarryOver          *float64 `json:"max_carry_over,omitempty"`
+	ProbationMonths       int      `json:"probation_months"`
+	AccrueDuringProbation *bool    `json:"accrue_during_probation,omitempty"`
+	ApprovalLevels        int      `json:"approval_levels,omitempty"`
+	HolidayCalendarID     *int     `json:"holiday_calendar_id,omitempty"`
+	IsActive              *bool    `json:"is_active,omitempty"`
+}
+
+// Validate checks the leave type, accrual and approval settings
+func (r *LeavePolicyRequest) Validate() error {
+	if !IsLeaveType(r.LeaveType) {
+		return fmt.Errorf("leave_type must be one of %s", strings.Join(LeaveTypes, ", "))
+	}
+	if r.LeaveCategory != "" && r.LeaveCategory != "paid" && r.LeaveCategory != "unpaid" {
+		return errors.New("leave_category must be paid or unpaid")
+	}
+	switch r.AccrualFrequency {
+	case "", AccrualNone, AccrualMonthly, AccrualAnnually:
+	default:
+		return errors.New("accrual_frequency must be one of none, monthly, annually")
+	}
+	if r.AccrualDays < 0 || r.AccrualDays > 366 {
+		return errors.New("accrual_days must be between 0 and 366")
+	}
+	if r.MaxCarryOver != nil && *r.MaxCarryOver < 0 {
+		return errors.New("max_carry_over must not be negative")
+	}
+	if r.ProbationMonths < 0 || r.ProbationMonths > 24 {
+		return errors.New("probation_months must be between 0 and 24")
+	}
+	if r.ApprovalLevels < 0 || r.ApprovalLevels > 5 {
+		return errors.New("approval_levels must be between 1 and 5")
+	}
+	return nil
+}
+
+// Policy builds the policy described by the request, filling in defaults
+func (r *LeavePolicyRequest) Policy(tenantID, restaurantID int) *LeavePolicy {
+	policy := DefaultLeavePolicy(r.LeaveType)
+	policy.TenantID = tenantID
+	policy.RestaurantID = restaurantID
+	policy.TrackBalance = true
+	policy.AccrualFrequency = AccrualMonthly
+	policy.AccrualDays = r.AccrualDays
+	policy.MaxCarryOver = r.MaxCarryOver
+	policy.ProbationMonths = r.ProbationMonths
+	policy.HolidayCalendarID = r.HolidayCalendarID
+	if r.LeaveCategory != "" {
+		policy.LeaveCategory = r.LeaveCategory
+	}
+	if r.TrackBalance != nil {
+		policy.TrackBalance = *r.TrackBalance
+	}
+	if r.AccrualFrequency != "" {
+		policy.AccrualFrequency = r.AccrualFrequency
+	}
+	if r.AccrueDuringProbation != nil {
+		policy.AccrueDuringProbation = *r.AccrueDuringProbation
+	}
+	if r.ApprovalLevels > 0 {
+		policy.ApprovalLevels = r.ApprovalLevels
+	}
+	if r.IsActive != nil {
+		policy.IsActive = *r.IsActive
+	}
+	return policy
+}
+
+// ProbationEnd returns the date the employee's probation for this leave type ends
+func (p *LeavePolicy) ProbationEnd(hireDate time.Time) time.Time {
+	return dateOnly(hireDate).AddDate(0, p.ProbationMonths, 0)
+}
+
+// AccruedEntitlement returns the days the policy has credited the employee in year as of asOf.
+// Monthly accrual credits AccrualDays at the start of each month from the first full month
+// of employment; annual accrual credits the year's days up front, prorated in the hire year.
+// Without accrual during probation, crediting starts when probation ends.
+func (p *LeavePolicy) AccruedEntitlement(hireDate time.Time, year int, asOf time.Time) float64 {
+	if p.AccrualFrequency == AccrualNone || p.AccrualDays == 0 {
+		return 0
+	}
+	yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	asOf = dateOnly(asOf)
+	if asOf.Before(yearStart) {
+		return 0
+	}
+
+	// First month that earns leave: the hire month when hired on the 1st, otherwise the next one
+	from := dateOnly(hireDate)
+	if !p.AccrueDuringProbation {
+		from = p.ProbationEnd(hireDate)
+	}
+	first := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if from.Day() != 1 {
+		first = first.AddDate(0, 1, 0)
+	}
+	if first.Before(yearStart) {
+		first = yearStart
+	}
+	if first.Year() > year {
+		return 0
+	}
+
+	switch p.AccrualFrequency {
+	case AccrualAnnually:
+		if asOf.Before(first) {
+			return 0
+		}
+		months := 12 - int(first.Month()) + 1
+		return math.Round(p.AccrualDays*float64(months)/12*100) / 100
+	default:
+		last := asOf
+		if last.Year() > year {
+			last = time.Date(year, time.December, 1, 0, 0, 0, 0, time.UTC)
+		}
+		months := (last.Year()-first.Year())*12 + int(last.Month()) - int(first.Month()) + 1
+		if months <= 0 {
+			return 0
+		}
+		return math.Round(p.AccrualDays*float64(months)*100) / 100
+	}
+}
+
+// CarryOver returns how much of last year's balance moves into the new year
+func (p *LeavePolicy) CarryOver(previous *LeaveBalance) float64 {
+	if previous == nil {
+		return 0
+	}
+	available := previous.Available()
+	if available <= 0 {
+		return 0
+	}
+	if p.MaxCarryOver != nil && available > *p.MaxCarryOver {
+		return *p.MaxCarryOver
+	}
+	return available
+}
+
+// HolidayCalendar holds the weekend days and public holidays excluded from leave days
+type HolidayCalendar struct {
+	ID           int             `json:"id"`
+	TenantID     int             `json:"tenant_id"`
+	RestaurantID int             `json:"restaurant_id"`
+	Name         string          `json:"name"`
+	WeekendDays  []int           `json:"weekend_days"` // 0 = Sunday ... 6 = Saturday
+	IsDefault    bool            `json:"is_default"`
+	Holidays     []PublicHoliday `json:"holidays"`
+	CreatedAt    time.Time       `json:"created_at"`
+	UpdatedAt    time.Time       `json:"updated_at"`
+}
+
+// HolidayCalendarRequest creates or replaces a holiday calendar
+type HolidayCalendarRequest struct {
+	Name        string `json:"name" validate:"required"`
+	WeekendDays []int  `json:"weekend_days"`
+	IsDefault   bool   `json:"is_default"`
+}
+
+// Validate checks the name and weekend days
+func (r *HolidayCalendarRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is required")
+	}
+	if len(r.WeekendDays) > 6 {
+		return errors.New("weekend_days must leave at least one working day")
+	}
+	for _, d := range r.WeekendDays {
+		if d < 0 || d > 6 {
+			return errors.New("weekend_days must be between 0 (Sunday) and 6 (Saturday)")
+		}
+	}
+	return nil
+}
+
+// PublicHoliday is a day off in a holiday calendar
+type PublicHoliday struct {
+	ID          int       `json:"id"`
+	CalendarID  int       `json:"calendar_id"`
+	HolidayDate time.Time `json:"holiday_date"`
+	Name        string    `json:"name"`
+}
+
+// PublicHolidayRequest adds a public holiday to a calendar
+type PublicHolidayRequest struct {
+	HolidayDate string `json:"holiday_date" validate:"required"` // YYYY-MM-DD
+	Name        string `json:"name" validate:"required"`
+}
+
+// Holiday parses and checks the request
+func (r *PublicHolidayRequest) Holiday(calendarID int) (*PublicHoliday, error) {
+	date, err := time.Parse(DateLayout, r.HolidayDate)
+	if err != nil {
+		return nil, errors.New("invalid holiday_date, expected YYYY-MM-DD")
+	}
+	if strings.TrimSpace(r.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+	return &PublicHoliday{CalendarID: calendarID, HolidayDate: date, Name: strings.TrimSpace(r.Name)}, nil
+}
+
+// CountLeaveDays counts the working days of a leave, skipping weekend days and public holidays.
+// A half-day leave counts 0.5 when it falls on a working day.
+func CountLeaveDays(start, end time.Time, halfDay bool, calendar *HolidayCalendar) float64 {
+	weekend := DefaultWeekendDays
+	holidays := map[string]bool{}
+	if calendar != nil {
+		weekend = calendar.WeekendDays
+		for _, h := range calendar.Holidays {
+			holidays[h.HolidayDate.Format(DateLayout)] = true
+		}
+	}
+	isOff := func(d time.Time) bool {
+		for _, w := range weekend {
+			if int(d.Weekday()) == w {
+				return true
+			}
+		}
+		return holidays[d.Format(DateLayout)]
+	}
+
+	var days float64
+	for d, last := dateOnly(start), dateOnly(end); !d.After(last); d = d.AddDate(0, 0, 1) {
+		if !isOff(d) {
+			days++
+		}
+	}
+	if halfDay && days > 0 {
+		return 0.5
+	}
+	return days
+}
+
+// LeaveBalance is an employee's balance of one leave type for a year
+type LeaveBalance struct {
+	ID           int       `json:"id"`
+	TenantID     int       `json:"tenant_id"`
+	RestaurantID int       `json:"restaurant_id"`
+	EmployeeID   int       `json:"employee_id"`
+	LeaveType    string    `json:"leave_type"`
+	Year         int       `json:"year"`
+	CarriedOver  float64   `json:"carried_over"`
+	Accrued      float64   `json:"accrued"`
+	Used         float64   `json:"used"`
+	Adjusted     float64   `json:"adjusted"`
+	Pending      float64   `json:"pending"` // days in requests awaiting approval
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// Available returns the days that can still be taken, not counting pending requests
+func (b *LeaveBalance) Available() float64 {
+	return math.Round((b.CarriedOver+b.Accrued+b.Adjusted-b.Used)*100) / 100
+}
+
+// Bookable returns the days a new request can use: available days less pending requests
+func (b *LeaveBalance) Bookable() float64 {
+	return math.Round((b.Available()-b.Pending)*100) / 100
+}
+
+// LeaveBalanceTransaction is a change to a leave balance
+type LeaveBalanceTransaction struct {
+	ID        int       `json:"id"`
+	BalanceID int       `json:"balance_id"`
+	LeaveID   *int      `json:"leave_id,omitempty"`
+	Kind      string    `json:"kind"` // 'carry_over', 'accrual', 'usage', 'reversal', 'adjustment'
+	Days      float64   `json:"days"`
+	Notes     string    `json:"notes,omitempty"`
+	CreatedBy *int      `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// LeaveBalanceAdjustmentRequest adds or removes days from an employee's balance by hand
+type LeaveBalanceAdjustmentRequest struct {
+	LeaveType string  `json:"leave_type" validate:"required"`
+	Year      int     `json:"year,omitempty"` // defaults to the current year
+	Days      float64 `json:"days" validate:"required"`
+	Notes     string  `json:"notes" validate:"required"`
+}
+
+// Validate checks the leave type, days and reason
+func (r *LeaveBalanceAdjustmentRequest) Validate() error {
+	if !IsLeaveType(r.LeaveType) {
+		return fmt.Errorf("leave_type must be one of %s", strings.Join(LeaveTypes, ", "))
+	}
+	if r.Days == 0 {
+		return errors.New("days must not be zero")
+	}
+	if strings.TrimSpace(r.Notes) == "" {
+		return errors.New("notes are required to adjust a leave balance")
+	}
+	return nil
+}
+
+// LeaveApproval is one level of a leave request's approval chain
+type LeaveApproval struct {
+	ID                 int        `json:"id"`
+	LeaveID            int        `json:"leave_id"`
+	Level              int        `json:"level"`
+	ApproverEmployeeID *int       `json:"approver_employee_id,omitempty"` // nil: any HR user approves
+	ApproverName       string     `json:"approver_name,omitempty"`
+	Status             string     `json:"status"` // 'pending', 'approved', 'rejected', 'cancelled'
+	ActedBy            *int       `json:"acted_by,omitempty"`
+	ActedAt            *time.Time `json:"acted_at,omitempty"`
+	Notes              string     `json:"notes,omitempty"`
+	CreatedAt          time.Time  `json:"created_at"`
+}
+
+// BuildApprovalChain walks up the employee's managers for the policy's number of levels.
+// manager returns the manager of an employee, or nil at the top. When the employee has no
+// manager the chain is a single HR approval.
+func BuildApprovalChain(employee *Employee, levels int, manager func(*Employee) (*Employee, error)) ([]LeaveApproval, error) {
+	chain := []LeaveApproval{}
+	seen := map[int]bool{employee.ID: true}
+	current := employee
+	for len(chain) < levels {
+		next, err := manager(current)
+		if err != nil {
+			return nil, err
+		}
+		if next == nil || seen[next.ID] {
+			break
+		}
+		seen[next.ID] = true
+		approverID := next.ID
+		chain = append(chain, LeaveApproval{
+			Level:              len(chain) + 1,
+			ApproverEmployeeID: &approverID,
+			ApproverName:       strings.TrimSpace(next.FirstName + " " + next.LastName),
+			Status:             ApprovalPending,
+		})
+		current = next
+	}
+	if len(chain) == 0 {
+		chain = append(chain, LeaveApproval{Level: 1, Status: ApprovalPending})
+	}
+	return chain, nil
+}
+
+// CurrentApproval returns the pending approval at the leave's current level, or nil when the
+// leave has no approval chain (requests made before chains existed are approved by HR)
+func (l *Leave) CurrentApproval() *LeaveApproval {
+	for i := range l.Approvals {
+		if l.Approvals[i].Level == l.CurrentApprovalLevel && l.Approvals[i].Status == ApprovalPending {
+			return &l.Approvals[i]
+		}
+	}
+	return nil
+}
+
+// IsFinalApproval reports whether the current level is the last of the chain
+func (l *Leave) IsFinalApproval() bool {
+	for _, a := range l.Approvals {
+		if a.Level > l.CurrentApprovalLevel {
+			return false
+		}
+	}
+	return true
+}
+
+// CreateLeaveRequest requests leave for an employee
+type CreateLeaveRequest struct {
+	EmployeeID       int    `json:"employee_id" validate:"required"`
+	LeaveType        string `json:"leave_type" validate:"required"`
+	StartDate        string `json:"start_date" validate:"required"` // YYYY-MM-DD
+	EndDate          string `json:"end_date" validate:"required"`   // YYYY-MM-DD
+	IsHalfDay        bool   `json:"is_half_day"`
+	HalfDayPeriod    string `json:"half_day_period,omitempty"` // 'morning', 'afternoon'
+	Reason           string `json:"reason" validate:"required"`
+	ContactNumber    string `json:"contact_number,omitempty"`
+	ContactAddress   string `json:"contact_address,omitempty"`
+	EmergencyContact string `json:"emergency_contact,omitempty"`
+}
+
+// Dates parses and checks the request's dates and details
+func (r *CreateLeaveRequest) Dates() (time.Time, time.Time, error) {
+	if r.EmployeeID <= 0 {
+		return time.Time{}, time.Time{}, errors.New("employee_id is required")
+	}
+	if !IsLeaveType(r.LeaveType) {
+		return time.Time{}, time.Time{}, fmt.Errorf("leave_type must be one of %s", strings.Join(LeaveTypes, ", "))
+	}
+	if strings.TrimSpace(r.Reason) == "" {
+		return time.Time{}, time.Time{}, errors.New("reason is required")
+	}
+	start, err := time.Parse(DateLayout, r.StartDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid start_date, expected YYYY-MM-DD")
+	}
+	end, err := time.Parse(DateLayout, r.EndDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid end_date, expected YYYY-MM-DD")
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, errors.New("end_date must not be before start_date")
+	}
+	if end.Year() != start.Year() {
+		return time.Time{}, time.Time{}, errors.New("leave must not span two years, request each year separately")
+	}
+	if r.IsHalfDay {
+		if !start.Equal(end) {
+			return time.Time{}, time.Time{}, errors.New("a half-day leave must start and end on the same day")
+		}
+		if r.HalfDayPeriod != "morning" && r.HalfDayPeriod != "afternoon" {
+			return time.Time{}, time.Time{}, errors.New("half_day_period must be morning or afternoon")
+		}
+	}
+	return start, end, nil
+}
+
+// LeaveDecisionRequest approves, rejects or cancels a leave with a note
+type LeaveDecisionRequest struct {
+	Notes string `json:"notes,omitempty"`
+}
+
+// Leave errors
+var (
+	ErrLeaveNotFound            = errors.New("leave not found")
+	ErrLeaveNotPending          = errors.New("leave is not awaiting approval")
+	ErrLeaveNotCancellable      = errors.New("only pending or approved leave can be cancelled")
+	ErrLeaveOverlaps            = errors.New("employee already has leave requested for some of these days")
+	ErrLeaveNoWorkingDays       = errors.New("leave must include at least one working day")
+	ErrLeaveInProbation         = errors.New("employee is still on probation for this leave type")
+	ErrInsufficientLeaveBalance = errors.New("leave request exceeds the employee's leave balance")
+	ErrNotLeaveApprover         = errors.New("you are not the approver for this level of the leave request")
+	ErrLeavePolicyNotFound      = errors.New("leave policy not found")
+	ErrLeavePolicyExists        = errors.New("a policy already exists for this leave type")
+	ErrHolidayCalendarNotFound  = errors.New("holiday calendar not found")
+	ErrPublicHolidayExists      = errors.New("calendar already has a holiday on this date")
+)
@@ -0,0 +1,162 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestCountLeaveDays tests that weekend days and public holidays are not counted
+func TestCountLeaveDays(t *testing.T) {
+	date := func(d int) time.Time { return time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC) }
+	holidays := &HolidayCalendar{
+		WeekendDays: DefaultWeekendDays,
+		Holidays:    []PublicHoliday{{HolidayDate: date(4), Name: "Holiday"}},
+	}
+	saturdaySunday := &HolidayCalendar{WeekendDays: []int{int(time.Saturday), int(time.Sunday)}}
+
+	tests := []struct {
+		name     string
+		start    time.Time
+		end      time.Time
+		halfDay  bool
+		calendar *HolidayCalendar
+		want     float64
+	}{
+		{"default weekend", date(3), date(9), false, nil, 5},
+		{"public holiday", date(3), date(9), false, holidays, 4},
+		{"saturday and sunday weekend", date(3), date(9), false, saturdaySunday, 5},
+		{"only weekend days", date(7), date(8), false, nil, 0},
+		{"half day", date(3), date(3), true, nil, 0.5},
+		{"half day on weekend", date(7), date(7), true, nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountLeaveDays(tt.start, tt.end, tt.halfDay, tt.calendar); got != tt.want {
+				t.Errorf("CountLeaveDays() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAccruedEntitlement tests monthly and annual accrual, the hire month and probation
+func TestAccruedEntitlement(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	monthly := &LeavePolicy{AccrualFrequency: AccrualMonthly, AccrualDays: 2, AccrueDuringProbation: true}
+	annually := &LeavePolicy{AccrualFrequency: AccrualAnnually, AccrualDays: 24, AccrueDuringProbation: true}
+	probation := &LeavePolicy{AccrualFrequency: AccrualMonthly, AccrualDays: 2, ProbationMonths: 3}
+
+	tests := []struct {
+		name   string
+		policy *LeavePolicy
+		hired  time.Time
+		year   int
+		asOf   time.Time
+		want   float64
+	}{
+		{"hired mid-month starts next month", monthly, date(2024, 3, 15), 2024, date(2024, 6, 10), 6},
+		{"hired on the first counts the month", monthly, date(2024, 3, 1), 2024, date(2024, 6, 10), 8},
+		{"past year accrues in full", monthly, date(2024, 3, 15), 2024, date(2025, 2, 1), 18},
+		{"later year starts in january", monthly, date(2024, 3, 15), 2025, date(2025, 2, 10), 4},
+		{"no accrual during probation", probation, date(2024, 3, 15), 2024, date(2024, 6, 10), 0},
+		{"accrual after probation", probation, date(2024, 3, 15), 2024, date(2024, 8, 1), 4},
+		{"annual prorated in hire year", annually, date(2024, 3, 15), 2024, date(2024, 5, 1), 18},
+		{"annual before first month", annually, date(2024, 3, 15), 2024, date(2024, 3, 20), 0},
+		{"annual full year", annually, date(2024, 3, 15), 2025, date(2025, 1, 1), 24},
+		{"no accrual", &LeavePolicy{AccrualFrequency: AccrualNone, AccrualDays: 2}, date(2024, 1, 1), 2024, date(2024, 6, 1), 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.AccruedEntitlement(tt.hired, tt.year, tt.asOf); got != tt.want {
+				t.Errorf("AccruedEntitlement() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestLeaveBalanceCarryOver tests the carry-over cap and the bookable balance
+func TestLeaveBalanceCarryOver(t *testing.T) {
+	previous := &LeaveBalance{CarriedOver: 2, Accrued: 24, Used: 20, Adjusted: 1}
+	if got := previous.Available(); got != 7 {
+		t.Fatalf("Available() = %v, want 7", got)
+	}
+
+	capped := &LeavePolicy{MaxCarryOver: floatPtr(5)}
+	if got := capped.CarryOver(previous); got != 5 {
+		t.Errorf("capped carry-over = %v, want 5", got)
+	}
+	if got := (&LeavePolicy{}).CarryOver(previous); got != 7 {
+		t.Errorf("uncapped carry-over = %v, want 7", got)
+	}
+	if got := capped.CarryOver(&LeaveBalance{Accrued: 2, Used: 3}); got != 0 {
+		t.Errorf("carry-over of an overdrawn balance = %v, want 0", got)
+	}
+	if got := capped.CarryOver(nil); got != 0 {
+		t.Errorf("carry-over without a previous balance = %v, want 0", got)
+	}
+
+	previous.Pending = 4.5
+	if got := previous.Bookable(); got != 2.5 {
+		t.Errorf("Bookable() = %v, want 2.5", got)
+	}
+}
+
+// TestBuildApprovalChain tests walking up managers, cycles and the HR fallback
+func TestBuildApprovalChain(t *testing.T) {
+	employees := map[int]*Employee{
+		1: {ID: 1, FirstName: "Sara", LastName: "Ali", ManagerID: intPtr(2)},
+		2: {ID: 2, FirstName: "Omar", LastName: "Hassan", ManagerID: intPtr(3)},
+		3: {ID: 3, FirstName: "Mona", LastName: "Adel", ManagerID: intPtr(1)},
+		4: {ID: 4},
+	}
+	manager := func(e *Employee) (*Employee, error) {
+		if e.ManagerID == nil {
+			return nil, nil
+		}
+		return employees[*e.ManagerID], nil
+	}
+
+	chain, err := BuildApprovalChain(employees[1], 2, manager)
+	if err != nil {
+		t.Fatalf("BuildApprovalChain() error = %v", err)
+	}
+	if len(chain) != 2 || *chain[0].ApproverEmployeeID != 2 || *chain[1].ApproverEmployeeID != 3 || chain[1].Level != 2 {
+		t.Errorf("unexpected chain %+v", chain)
+	}
+	if chain[0].ApproverName != "Omar Hassan" || chain[0].Status != ApprovalPending {
+		t.Errorf("unexpected first approval %+v", chain[0])
+	}
+
+	if chain, _ := BuildApprovalChain(employees[1], 5, manager); len(chain) != 2 {
+		t.Errorf("expected the manager cycle to stop the chain at 2 levels, got %d", len(chain))
+	}
+
+	chain, _ = BuildApprovalChain(employees[4], 2, manager)
+	if len(chain) != 1 || chain[0].ApproverEmployeeID != nil || chain[0].Level != 1 {
+		t.Errorf("expected a single HR approval, got %+v", chain)
+	}
+}
+
+// TestLeaveApprovalLevels tests finding the current approval and the last level
+func TestLeaveApprovalLevels(t *testing.T) {
+	leave := &Leave{
+		CurrentApprovalLevel: 1,
+		Approvals: []LeaveApproval{
+			{ID: 10, Level: 1, Status: ApprovalPending},
+			{ID: 11, Level: 2, Status: ApprovalPending},
+		},
+	}
+	if a := leave.CurrentApproval(); a == nil || a.ID != 10 || leave.IsFinalApproval() {
+		t.Errorf("expected level 1 to be current and not final")
+	}
+
+	leave.Approvals[0].Status = ApprovalApproved
+	leave.CurrentApprovalLevel = 2
+	if a := leave.CurrentApproval(); a == nil || a.ID != 11 || !leave.IsFinalApproval() {
+		t.Errorf("expected level 2 to be current and final")
+	}
+
+	legacy := &Leave{CurrentApprovalLevel: 1}
+	if legacy.CurrentApproval() != nil || !legacy.IsFinalApproval() {
+		t.Errorf("expected a leave without a chain to be decided in one step")
+	}
+}
@@ -0,0 +1,66 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestLoginBackoff tests the free attempts, the doubling and the cap
+func TestLoginBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{3, 0},
+		{4, time.Second},
+		{5, 2 * time.Second},
+		{8, 16 * time.Second},
+		{13, 512 * time.Second},
+		{14, MaxLoginBackoff},
+		{100, MaxLoginBackoff},
+	}
+	for _, tt := range tests {
+		if got := LoginBackoff(tt.failures, AccountFreeAttempts); got != tt.want {
+			t.Errorf("LoginBackoff(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+// TestLoginThrottleRetryAfter tests that the longest of the backoff and the lock applies
+func TestLoginThrottleRetryAfter(t *testing.T) {
+	now := time.Now()
+	blocked := now.Add(time.Minute)
+	locked := now.Add(30 * time.Minute)
+	expired := now.Add(-time.Minute)
+
+	var none *LoginThrottle
+	if none.RetryAfter(now) != 0 || none.IsLocked(now) {
+		t.Error("a missing throttle should not block")
+	}
+
+	throttle := &LoginThrottle{BlockedUntil: &blocked, LockedUntil: &locked}
+	if got := throttle.RetryAfter(now); got != 30*time.Minute {
+		t.Errorf("RetryAfter = %v, want 30m", got)
+	}
+	if !throttle.IsLocked(now) {
+		t.Error("expected the account to be locked")
+	}
+
+	throttle = &LoginThrottle{BlockedUntil: &expired, LockedUntil: &expired}
+	if throttle.RetryAfter(now) != 0 || throttle.IsLocked(now) {
+		t.Error("an expired backoff and lock should not block")
+	}
+}
+
+// TestLoginErrorUnwrap tests that callers can tell lockouts from bad credentials
+func TestLoginErrorUnwrap(t *testing.T) {
+	var err error = &LoginError{Err: ErrAccountLocked, RetryAfter: AccountLockDuration}
+	if !errors.Is(err, ErrAccountLocked) || errors.Is(err, ErrInvalidCredentials) {
+		t.Errorf("unexpected unwrap result for %v", err)
+	}
+	if LoginThrottleKey("  Owner@Example.COM ") != "owner@example.com" {
+		t.Error("LoginThrottleKey should trim and lower-case the email")
+	}
+}
@@ -13,6 +13,8 @@ const (
 	NotificationTypeInventory    NotificationType = "inventory"
 	NotificationTypeAttendance   NotificationType = "attendance"
 	NotificationTypeSystem       NotificationType = "system"
+	NotificationTypeShift        NotificationType = "shift"
+	NotificationTypePerformance  NotificationType = "performance"
 )
 
 // NotificationModule represents the module that generated the notification
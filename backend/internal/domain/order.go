@@ -6,6 +6,15 @@ import (
 	"time"
 )
 
+// Order sources
+const (
+	OrderSourceWebsite   = "website"
+	OrderSourceMobileApp = "mobile_app"
+	OrderSourcePhone     = "phone"
+	OrderSourceInStore   = "in_store"
+	OrderSourceDineIn    = "dine_in"
+)
+
 // Order represents a customer order from a restaurant
 type Order struct {
 	ID                    int64          `json:"id"`
@@ -32,6 +41,7 @@ type Order struct {
 	TaxAmount             float64        `json:"tax_amount"`
 	DiscountAmount        float64        `json:"discount_amount"`
 	DeliveryFee           float64        `json:"delivery_fee"`
+	TipAmount             float64        `json:"tip_amount"`
 	TotalAmount           float64        `json:"total_amount"`
 
 	// Payment Information
@@ -47,11 +57,18 @@ type Order struct {
 
 	// Additional Information
 	Notes                 string         `json:"notes,omitempty"`
-	OrderSource           string         `json:"order_source"` // 'website', 'mobile_app', 'phone', 'in_store'
+	OrderSource           string         `json:"order_source"` // 'website', 'mobile_app', 'phone', 'in_store', 'dine_in'
+
+	// Dine-in Information
+	TableID               *int64         `json:"table_id,omitempty"`
+	TabID                 *int64         `json:"tab_id,omitempty"`
 
 	// Order Items
 	Items                 []OrderItem    `json:"items"`
 
+	// Allergen warnings for items the customer must avoid (computed on creation, not persisted)
+	AllergenWarnings      []OrderAllergenWarning `json:"allergen_warnings,omitempty"`
+
 	// Audit
 	CreatedAt             time.Time      `json:"created_at"`
 	UpdatedAt             time.Time      `json:"updated_at"`
@@ -102,7 +119,7 @@ type OrderStatusHistory struct {
 type CreateOrderRequest struct {
 	CustomerName          string                   `json:"customer_name" validate:"required"`
 	CustomerEmail         string                   `json:"customer_email"`
-	CustomerPhone         string                   `json:"customer_phone" validate:"required"`
+	CustomerPhone         string                   `json:"customer_phone" validate:"required_unless=OrderSource dine_in"`
 
 	DeliveryAddress       string                   `json:"delivery_address"`
 	DeliveryCity          string                   `json:"delivery_city"`
@@ -117,6 +134,12 @@ type CreateOrderRequest struct {
 
 	Notes                 string                   `json:"notes"`
 
+	// Tip for the staff, pooled into payroll
+	TipAmount             float64                  `json:"tip_amount"`
+
+	// Allergens the customer must avoid, e.g. ["peanuts", "milk"]
+	AvoidAllergens        []string                 `json:"avoid_allergens"`
+
 	Items                 []CreateOrderItemRequest `json:"items" validate:"required,min=1"`
 }
 
@@ -188,6 +211,17 @@ func ValidStatus(status string) bool {
 	return false
 }
 
+// ValidOrderSource checks if an order source is valid
+func ValidOrderSource(source string) bool {
+	validSources := []string{OrderSourceWebsite, OrderSourceMobileApp, OrderSourcePhone, OrderSourceInStore, OrderSourceDineIn}
+	for _, s := range validSources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
 // ValidPaymentStatus checks if a payment status is valid
 func ValidPaymentStatus(status string) bool {
 	validStatuses := []string{"pending", "paid", "failed", "refunded"}
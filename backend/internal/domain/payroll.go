@@ -0,0 +1,781 @@
+package domain
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Payroll run statuses
+const (
+	PayrollRunDraft     = "draft"
+	PayrollRunApproved  = "approved"
+	PayrollRunPaid      = "paid"
+	PayrollRunCancelled = "cancelled"
+)
+
+// Deduction rule types
+const (
+	DeductionRuleTaxBracket = "tax_bracket" // progressive: rate applies to gross pay between min and max income
+	DeductionRulePercentage = "percentage"  // rate applies to gross pay, capped at max income when set
+	DeductionRuleFixed      = "fixed"       // a fixed amount per pay period
+)
+
+// Salary components a deduction rule adds to
+const (
+	DeductionTax             = "tax"
+	DeductionSocialInsurance = "social_insurance"
+	DeductionHealthInsurance = "health_insurance"
+	DeductionPension         = "pension"
+	DeductionOther           = "other"
+)
+
+// How the tip pool is shared among staff
+const (
+	TipPoolByHours = "hours"
+	TipPoolEqual   = "equal"
+	TipPoolNone    = "none"
+)
+
+// Payroll defaults
+const (
+	DefaultOvertimeMultiplier  = 1.5
+	DefaultWorkingDaysPerMonth = 26
+	defaultHoursPerWeek        = 40.0
+	maxPayrollPeriodDays       = 31
+)
+
+// PayrollSettings are a restaurant's rules for computing pay
+type PayrollSettings struct {
+	TenantID            int       `json:"tenant_id"`
+	RestaurantID        int       `json:"restaurant_id"`
+	OvertimeMultiplier  float64   `json:"overtime_multiplier"`
+	WorkingDaysPerMonth int       `json:"working_days_per_month"`
+	TipPoolMethod       string    `json:"tip_pool_method"` // 'hours', 'equal', 'none'
+	PaymentMethod       string    `json:"payment_method"`  // 'cash', 'bank_transfer', 'check', 'mobile_money'
+	UpdatedAt           time.Time `json:"updated_at"`
+	UpdatedBy           *int      `json:"updated_by,omitempty"`
+}
+
+// DefaultPayrollSettings returns the settings used until a restaurant saves its own
+func DefaultPayrollSettings(tenantID, restaurantID int) *PayrollSettings {
+	return &PayrollSettings{
+		TenantID:            tenantID,
+		RestaurantID:        restaurantID,
+		OvertimeMultiplier:  DefaultOvertimeMultiplier,
+		WorkingDaysPerMonth: DefaultWorkingDaysPerMonth,
+		TipPoolMethod:       TipPoolByHours,
+		PaymentMethod:       "bank_transfer",
+	}
+}
+
+// Validate checks the multiplier, working days, tip pool method and payment method
+func (s *PayrollSettings) Validate() error {
+	if s.OvertimeMultiplier < 1 || s.OvertimeMultiplier > 5 {
+		return errors.New("overtime_multiplier must be between 1 and 5")
+	}
+	if s.WorkingDaysPerMonth < 1 || s.WorkingDaysPerMonth > 31 {
+		return errors.New("working_days_per_month must be between 1 and 31")
+	}
+	switch s.TipPoolMethod {
+	case TipPoolByHours, TipPoolEqual, TipPoolNone:
+	default:
+		return errors.New("tip_pool_method must be one of hours, equal, none")
+	}
+	switch s.PaymentMethod {
+	case "cash", "bank_transfer", "check", "mobile_money":
+	default:
+		return errors.New("payment_method must be one of cash, bank_transfer, check, mobile_money")
+	}
+	return nil
+}
+
+// DeductionRule is a tax bracket, contribution percentage or fixed amount taken from gross pay
+type DeductionRule struct {
+	ID           int       `json:"id"`
+	TenantID     int       `json:"tenant_id"`
+	RestaurantID int       `json:"restaurant_id"`
+	Name         string    `json:"name"`
+	RuleType     string    `json:"rule_type"` // 'tax_bracket', 'percentage', 'fixed'
+	Component    string    `json:"component"` // 'tax', 'social_insurance', 'health_insurance', 'pension', 'other'
+	Rate         float64   `json:"rate"`      // percent
+	Amount       float64   `json:"amount"`
+	MinIncome    float64   `json:"min_income"`
+	MaxIncome    *float64  `json:"max_income,omitempty"`
+	MaxDeduction *float64  `json:"max_deduction,omitempty"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// DeductionRuleRequest creates or replaces a deduction rule
+type DeductionRuleRequest struct {
+	Name         string   `json:"name" validate:"required"`
+	RuleType     string   `json:"rule_type" validate:"required"`
+	Component    string   `json:"component" validate:"required"`
+	Rate         float64  `json:"rate"`
+	Amount       float64  `json:"amount"`
+	MinIncome    float64  `json:"min_income"`
+	MaxIncome    *float64 `json:"max_income,omitempty"`
+	MaxDeduction *float64 `json:"max_deduction,omitempty"`
+	IsActive     *bool    `json:"is_active,omitempty"`
+}
+
+// Validate checks the rule type, component and amounts
+func (r *DeductionRuleRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is required")
+	}
+	switch r.RuleType {
+	case DeductionRuleTaxBracket, DeductionRulePercentage:
+		if r.Rate <= 0 || r.Rate > 100 {
+			return errors.New("rate must be between 0 and 100")
+		}
+	case DeductionRuleFixed:
+		if r.Amount <= 0 {
+			return errors.New("amount must be greater than 0 for a fixed deduction")
+		}
+	default:
+		return errors.New("rule_type must be one of tax_bracket, percentage, fixed")
+	}
+	switch r.Component {
+	case DeductionTax, DeductionSocialInsurance, DeductionHealthInsurance, DeductionPension, DeductionOther:
+	default:
+		return errors.New("component must be one of tax, social_insurance, health_insurance, pension, other")
+	}
+	if r.MinIncome < 0 {
+		return errors.New("min_income must not be negative")
+	}
+	if r.MaxIncome != nil && *r.MaxIncome <= r.MinIncome {
+		return errors.New("max_income must be greater than min_income")
+	}
+	if r.MaxDeduction != nil && *r.MaxDeduction < 0 {
+		return errors.New("max_deduction must not be negative")
+	}
+	return nil
+}
+
+// Deduct returns the amount the rule takes from gross pay
+func (r *DeductionRule) Deduct(gross float64) float64 {
+	var amount float64
+	switch r.RuleType {
+	case DeductionRuleTaxBracket:
+		if gross <= r.MinIncome {
+			return 0
+		}
+		upper := gross
+		if r.MaxIncome != nil && *r.MaxIncome < upper {
+			upper = *r.MaxIncome
+		}
+		amount = (upper - r.MinIncome) * r.Rate / 100
+	case DeductionRulePercentage:
+		if gross < r.MinIncome {
+			return 0
+		}
+		base := gross
+		if r.MaxIncome != nil && *r.MaxIncome < base {
+			base = *r.MaxIncome
+		}
+		amount = base * r.Rate / 100
+	case DeductionRuleFixed:
+		if gross < r.MinIncome {
+			return 0
+		}
+		amount = r.Amount
+	}
+	if r.MaxDeduction != nil && amount > *r.MaxDeduction {
+		amount = *r.MaxDeduction
+	}
+	return RoundMoney(amount)
+}
+
+// PayrollRun is the payroll of one restaurant for a period
+type PayrollRun struct {
+	ID              int        `json:"id"`
+	TenantID        int        `json:"tenant_id"`
+	RestaurantID    int        `json:"restaurant_id"`
+	PeriodStart     time.Time  `json:"period_start"`
+	PeriodEnd       time.Time  `json:"period_end"`
+	Status          string     `json:"status"` // 'draft', 'approved', 'paid', 'cancelled'
+	TipPool         float64    `json:"tip_pool"`
+	EmployeeCount   int        `json:"employee_count"`
+	TotalGross      float64    `json:"total_gross"`
+	TotalDeductions float64    `json:"total_deductions"`
+	TotalNet        float64    `json:"total_net"`
+	Notes           string     `json:"notes,omitempty"`
+	CreatedBy       *int       `json:"created_by,omitempty"`
+	ApprovedBy      *int       `json:"approved_by,omitempty"`
+	ApprovedAt      *time.Time `json:"approved_at,omitempty"`
+	PaidBy          *int       `json:"paid_by,omitempty"`
+	PaidAt          *time.Time `json:"paid_at,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	Salaries []Salary `json:"salaries,omitempty"`
+}
+
+// CreatePayrollRunRequest starts a payroll run for a period of up to a month
+type CreatePayrollRunRequest struct {
+	PeriodStart string `json:"period_start" validate:"required"` // YYYY-MM-DD
+	PeriodEnd   string `json:"period_end" validate:"required"`   // YYYY-MM-DD
+	Notes       string `json:"notes,omitempty"`
+}
+
+// Period parses and checks the requested period
+func (r *CreatePayrollRunRequest) Period() (time.Time, time.Time, error) {
+	start, err := time.Parse(DateLayout, r.PeriodStart)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid period_start, expected YYYY-MM-DD")
+	}
+	end, err := time.Parse(DateLayout, r.PeriodEnd)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("invalid period_end, expected YYYY-MM-DD")
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, errors.New("period_end must not be before period_start")
+	}
+	if periodDays(start, end) > maxPayrollPeriodDays {
+		return time.Time{}, time.Time{}, fmt.Errorf("payroll period must not be longer than %d days", maxPayrollPeriodDays)
+	}
+	return start, end, nil
+}
+
+// PayPayrollRunRequest marks an approved run as paid
+type PayPayrollRunRequest struct {
+	PaymentReference string `json:"payment_reference,omitempty"`
+}
+
+// SalaryAdjustmentRequest changes the hand-entered parts of a draft run's salary; deductions are recalculated
+type SalaryAdjustmentRequest struct {
+	Bonus            *float64 `json:"bonus,omitempty"`
+	Commission       *float64 `json:"commission,omitempty"`
+	Allowances       *float64 `json:"allowances,omitempty"`
+	OtherEarnings    *float64 `json:"other_earnings,omitempty"`
+	LoanDeduction    *float64 `json:"loan_deduction,omitempty"`
+	AdvanceDeduction *float64 `json:"advance_deduction,omitempty"`
+	Notes            *string  `json:"notes,omitempty"`
+}
+
+// Apply copies the adjustments onto the salary
+func (r *SalaryAdjustmentRequest) Apply(sal *Salary) error {
+	for _, field := range []struct {
+		name  string
+		value *float64
+		dest  *float64
+	}{
+		{"bonus", r.Bonus, &sal.Bonus},
+		{"commission", r.Commission, &sal.Commission},
+		{"allowances", r.Allowances, &sal.Allowances},
+		{"other_earnings", r.OtherEarnings, &sal.OtherEarnings},
+		{"loan_deduction", r.LoanDeduction, &sal.LoanDeduction},
+		{"advance_deduction", r.AdvanceDeduction, &sal.AdvanceDeduction},
+	} {
+		if field.value == nil {
+			continue
+		}
+		if *field.value < 0 {
+			return fmt.Errorf("%s must not be negative", field.name)
+		}
+		*field.dest = RoundMoney(*field.value)
+	}
+	if r.Notes != nil {
+		sal.Notes = *r.Notes
+	}
+	return nil
+}
+
+// PayrollInput is what a payroll run knows about one employee for the period
+type PayrollInput struct {
+	Employee        Employee
+	Attendance      []Attendance
+	UnpaidLeaveDays float64
+}
+
+// PayrollEarningsDetails explains how a generated salary was computed; stored in earnings_details
+type PayrollEarningsDetails struct {
+	PaymentFrequency        string  `json:"payment_frequency"`
+	HourlyRate              float64 `json:"hourly_rate"`
+	RegularHours            float64 `json:"regular_hours"`
+	UnpaidLeaveDays         float64 `json:"unpaid_leave_days,omitempty"`
+	UnpaidLeaveDeduction    float64 `json:"unpaid_leave_deduction,omitempty"`
+	UnapprovedOvertimeHours float64 `json:"unapproved_overtime_hours,omitempty"`
+}
+
+// Payroll errors
+var (
+	ErrPayrollRunNotFound    = errors.New("payroll run not found")
+	ErrPayrollRunNotDraft    = errors.New("payroll run can only be changed while it is a draft")
+	ErrPayrollRunNotApproved = errors.New("payroll run must be approved before it is marked as paid")
+	ErrPayrollRunClosed      = errors.New("payroll run is already paid or cancelled")
+	ErrPayrollRunOverlaps    = errors.New("another payroll run already covers part of this period")
+	ErrPayrollSalaryExists   = errors.New("a salary already exists for an employee in this period")
+	ErrPayrollNoEmployees    = errors.New("no employees have pay for this period")
+	ErrDeductionRuleNotFound = errors.New("deduction rule not found")
+	ErrSalaryNotFound        = errors.New("salary not found")
+	ErrSalaryNotApproved     = errors.New("salary must be approved before it is marked as paid")
+	ErrDeductionsExceedGross = errors.New("deductions must not exceed gross pay")
+)
+
+// UnpaidLeaveDays returns how many of an approved leave's days are unpaid and fall within the period
+func UnpaidLeaveDays(leave *Leave, start, end time.Time) float64 {
+	if leave.LeaveCategory != "unpaid" && leave.LeaveType != "unpaid" {
+		return 0
+	}
+	from, to := dateOnly(leave.StartDate), dateOnly(leave.EndDate)
+	overlapStart, overlapEnd := from, to
+	if overlapStart.Before(start) {
+		overlapStart = start
+	}
+	if overlapEnd.After(end) {
+		overlapEnd = end
+	}
+	if overlapEnd.Before(overlapStart) {
+		return 0
+	}
+	if leave.IsHalfDay {
+		return 0.5
+	}
+	// total_days excludes the weekend, so a leave that crosses the period edge counts pro rata
+	overlap, span := periodDays(overlapStart, overlapEnd), periodDays(from, to)
+	if overlap == span {
+		return leave.TotalDays
+	}
+	return math.Round(leave.TotalDays*float64(overlap)/float64(span)*10) / 10
+}
+
+// ComputeSalary works out an employee's base pay, overtime and attendance summary for the run.
+// Tips and deductions are added afterwards by BuildPayroll.
+func ComputeSalary(run *PayrollRun, settings *PayrollSettings, in *PayrollInput) *Salary {
+	emp := &in.Employee
+	sal := &Salary{
+		TenantID:       run.TenantID,
+		RestaurantID:   run.RestaurantID,
+		EmployeeID:     emp.ID,
+		EmployeeName:   strings.TrimSpace(emp.FirstName + " " + emp.LastName),
+		PayPeriodStart: run.PeriodStart,
+		PayPeriodEnd:   run.PeriodEnd,
+		Month:          int(run.PeriodEnd.Month()),
+		Year:           run.PeriodEnd.Year(),
+		Currency:       emp.SalaryCurrency,
+		PaymentMethod:  settings.PaymentMethod,
+		Status:         "pending",
+	}
+	if run.ID > 0 {
+		runID := run.ID
+		sal.PayrollRunID = &runID
+	}
+
+	details := PayrollEarningsDetails{PaymentFrequency: emp.PaymentFrequency}
+	var overtimeHours float64
+	for _, att := range in.Attendance {
+		if att.ClockIn != nil {
+			sal.DaysWorked++
+		}
+		if att.Status == "absent" {
+			sal.DaysAbsent++
+		}
+		if att.TotalHours != nil {
+			sal.TotalHoursWorked += *att.TotalHours
+		}
+		details.RegularHours += att.RegularHours
+		sal.TotalOvertimeHours += att.OvertimeHours
+		if att.OvertimeApproved {
+			overtimeHours += att.OvertimeHours
+		} else {
+			details.UnapprovedOvertimeHours += att.OvertimeHours
+		}
+	}
+	sal.TotalHoursWorked = math.Round(sal.TotalHoursWorked*100) / 100
+	sal.TotalOvertimeHours = math.Round(sal.TotalOvertimeHours*100) / 100
+	details.RegularHours = math.Round(details.RegularHours*100) / 100
+	details.UnapprovedOvertimeHours = math.Round(details.UnapprovedOvertimeHours*100) / 100
+
+	hoursPerWeek := emp.WorkingHoursPerWeek
+	if hoursPerWeek <= 0 {
+		hoursPerWeek = defaultHoursPerWeek
+	}
+	hoursPerMonth := hoursPerWeek * 52 / 12
+	days := float64(periodDays(run.PeriodStart, run.PeriodEnd))
+
+	var base, hourlyRate float64
+	switch emp.PaymentFrequency {
+	case "hourly":
+		hourlyRate = emp.BaseSalary
+		base = emp.BaseSalary * details.RegularHours
+	case "daily":
+		hourlyRate = emp.BaseSalary / (hoursPerMonth / float64(settings.WorkingDaysPerMonth))
+		base = emp.BaseSalary * float64(sal.DaysWorked)
+	default:
+		monthly := emp.BaseSalary
+		switch emp.PaymentFrequency {
+		case "weekly":
+			monthly = emp.BaseSalary * 52 / 12
+			base = emp.BaseSalary * days / 7
+		case "bi_weekly":
+			monthly = emp.BaseSalary * 26 / 12
+			base = emp.BaseSalary * days / 14
+		default:
+			base = emp.BaseSalary * monthFraction(run.PeriodStart, run.PeriodEnd)
+		}
+		hourlyRate = monthly / hoursPerMonth
+
+		// Salaried staff are not paid for approved unpaid leave
+		if in.UnpaidLeaveDays > 0 {
+			deduction := math.Min(base, monthly/float64(settings.WorkingDaysPerMonth)*in.UnpaidLeaveDays)
+			details.UnpaidLeaveDays = in.UnpaidLeaveDays
+			details.UnpaidLeaveDeduction = RoundMoney(deduction)
+			base -= deduction
+		}
+	}
+	details.HourlyRate = RoundMoney(hourlyRate)
+
+	sal.BaseSalary = RoundMoney(base)
+	sal.OvertimeHours = math.Round(overtimeHours*100) / 100
+	sal.OvertimeRate = RoundMoney(hourlyRate * settings.OvertimeMultiplier)
+	sal.OvertimeAmount = RoundMoney(sal.OvertimeHours * sal.OvertimeRate)
+	sal.EarningsDetails, _ = json.Marshal(details)
+
+	notes := []string{fmt.Sprintf("%s pay at %s/hour", payFrequencyLabel(emp.PaymentFrequency), money(details.HourlyRate))}
+	if details.UnpaidLeaveDays > 0 {
+		notes = append(notes, fmt.Sprintf("%g unpaid leave day(s) deducted", details.UnpaidLeaveDays))
+	}
+	if sal.OvertimeHours > 0 {
+		notes = append(notes, fmt.Sprintf("%g approved overtime hour(s) at %gx", sal.OvertimeHours, settings.OvertimeMultiplier))
+	}
+	if details.UnapprovedOvertimeHours > 0 {
+		notes = append(notes, fmt.Sprintf("%g overtime hour(s) not approved and unpaid", details.UnapprovedOvertimeHours))
+	}
+	sal.CalculationNotes = strings.Join(notes, "; ")
+	return sal
+}
+
+// DistributeTips shares the tip pool among the salaries by hours worked or equally among
+// staff who worked in the period. Rounding differences go to the first share.
+func DistributeTips(salaries []Salary, pool float64, method string) {
+	if pool <= 0 || method == TipPoolNone {
+		return
+	}
+	var weights []float64
+	var total float64
+	for _, sal := range salaries {
+		var weight float64
+		if sal.TotalHoursWorked > 0 {
+			weight = 1
+			if method == TipPoolByHours {
+				weight = sal.TotalHoursWorked
+			}
+		}
+		weights = append(weights, weight)
+		total += weight
+	}
+	if total == 0 {
+		return
+	}
+
+	first, shared := -1, 0.0
+	for i := range salaries {
+		if weights[i] == 0 {
+			continue
+		}
+		salaries[i].Tips = RoundMoney(pool * weights[i] / total)
+		shared += salaries[i].Tips
+		if first < 0 {
+			first = i
+		}
+	}
+	salaries[first].Tips = RoundMoney(salaries[first].Tips + pool - shared)
+}
+
+// ApplyDeductions recalculates gross pay, the rule-based deductions and net pay. Loan and
+// advance deductions are kept; rules never take more than what is left of gross pay.
+func ApplyDeductions(sal *Salary, rules []DeductionRule) error {
+	sal.GrossSalary = RoundMoney(sal.BaseSalary + sal.OvertimeAmount + sal.Bonus + sal.Commission +
+		sal.Allowances + sal.Tips + sal.OtherEarnings)
+
+	remaining := sal.GrossSalary - sal.LoanDeduction - sal.AdvanceDeduction
+	if remaining < 0 {
+		return ErrDeductionsExceedGross
+	}
+
+	sal.Tax, sal.SocialInsurance, sal.HealthInsurance, sal.Pension, sal.OtherDeductions = 0, 0, 0, 0, 0
+	details := map[string]float64{}
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.IsActive {
+			continue
+		}
+		amount := math.Min(rule.Deduct(sal.GrossSalary), RoundMoney(remaining))
+		if amount <= 0 {
+			continue
+		}
+		remaining -= amount
+		details[rule.Name] = RoundMoney(details[rule.Name] + amount)
+		switch rule.Component {
+		case DeductionTax:
+			sal.Tax += amount
+		case DeductionSocialInsurance:
+			sal.SocialInsurance += amount
+		case DeductionHealthInsurance:
+			sal.HealthInsurance += amount
+		case DeductionPension:
+			sal.Pension += amount
+		default:
+			sal.OtherDeductions += amount
+		}
+	}
+	sal.Tax = RoundMoney(sal.Tax)
+	sal.SocialInsurance = RoundMoney(sal.SocialInsurance)
+	sal.HealthInsurance = RoundMoney(sal.HealthInsurance)
+	sal.Pension = RoundMoney(sal.Pension)
+	sal.OtherDeductions = RoundMoney(sal.OtherDeductions)
+	sal.DeductionsDetails, _ = json.Marshal(details)
+
+	sal.TotalDeductions = RoundMoney(sal.Tax + sal.SocialInsurance + sal.HealthInsurance + sal.Pension +
+		sal.LoanDeduction + sal.AdvanceDeduction + sal.OtherDeductions)
+	sal.NetSalary = RoundMoney(sal.GrossSalary - sal.TotalDeductions)
+	return nil
+}
+
+// BuildPayroll computes every employee's salary for the run, shares the tip pool and applies
+// the deduction rules. Employees with nothing to pay are left out.
+func BuildPayroll(run *PayrollRun, settings *PayrollSettings, rules []DeductionRule, inputs []PayrollInput, tipPool float64) ([]Salary, error) {
+	salaries := []Salary{}
+	for i := range inputs {
+		sal := ComputeSalary(run, settings, &inputs[i])
+		if sal.BaseSalary == 0 && sal.OvertimeAmount == 0 && sal.TotalHoursWorked == 0 {
+			continue
+		}
+		salaries = append(salaries, *sal)
+	}
+	if len(salaries) == 0 {
+		return nil, ErrPayrollNoEmployees
+	}
+
+	DistributeTips(salaries, tipPool, settings.TipPoolMethod)
+	for i := range salaries {
+		if err := ApplyDeductions(&salaries[i], rules); err != nil {
+			return nil, err
+		}
+	}
+
+	run.TipPool = RoundMoney(tipPool)
+	run.SummarizeSalaries(salaries)
+	return salaries, nil
+}
+
+// SummarizeSalaries sets the run's employee count and totals
+func (run *PayrollRun) SummarizeSalaries(salaries []Salary) {
+	run.EmployeeCount = len(salaries)
+	run.TotalGross, run.TotalDeductions, run.TotalNet = 0, 0, 0
+	for _, sal := range salaries {
+		run.TotalGross += sal.GrossSalary
+		run.TotalDeductions += sal.TotalDeductions
+		run.TotalNet += sal.NetSalary
+	}
+	run.TotalGross = RoundMoney(run.TotalGross)
+	run.TotalDeductions = RoundMoney(run.TotalDeductions)
+	run.TotalNet = RoundMoney(run.TotalNet)
+}
+
+// PayslipLine is one earning or deduction on a payslip
+type PayslipLine struct {
+	Label  string  `json:"label"`
+	Amount float64 `json:"amount"`
+}
+
+// Payslip is an employee's pay statement for a payroll run
+type Payslip struct {
+	PayrollRunID   int           `json:"payroll_run_id"`
+	EmployeeID     int           `json:"employee_id"`
+	EmployeeCode   string        `json:"employee_code"`
+	EmployeeName   string        `json:"employee_name"`
+	Position       string        `json:"position"`
+	Department     string        `json:"department,omitempty"`
+	PayPeriodStart string        `json:"pay_period_start"`
+	PayPeriodEnd   string        `json:"pay_period_end"`
+	Currency       string        `json:"currency"`
+	DaysWorked     int           `json:"days_worked"`
+	HoursWorked    float64       `json:"hours_worked"`
+	Earnings       []PayslipLine `json:"earnings"`
+	Deductions     []PayslipLine `json:"deductions"`
+	GrossPay       float64       `json:"gross_pay"`
+	TotalDeduction float64       `json:"total_deductions"`
+	NetPay         float64       `json:"net_pay"`
+	Status         string        `json:"status"`
+	Notes          string        `json:"notes,omitempty"`
+}
+
+// NewPayslip builds the payslip for a run salary. Deductions are listed by rule name.
+func NewPayslip(runID int, emp *Employee, sal *Salary) *Payslip {
+	slip := &Payslip{
+		PayrollRunID:   runID,
+		EmployeeID:     sal.EmployeeID,
+		EmployeeCode:   emp.EmployeeCode,
+		EmployeeName:   strings.TrimSpace(emp.FirstName + " " + emp.LastName),
+		Position:       emp.Position,
+		Department:     emp.Department,
+		PayPeriodStart: sal.PayPeriodStart.Format(DateLayout),
+		PayPeriodEnd:   sal.PayPeriodEnd.Format(DateLayout),
+		Currency:       sal.Currency,
+		DaysWorked:     sal.DaysWorked,
+		HoursWorked:    sal.TotalHoursWorked,
+		Earnings:       []PayslipLine{},
+		Deductions:     []PayslipLine{},
+		GrossPay:       sal.GrossSalary,
+		TotalDeduction: sal.TotalDeductions,
+		NetPay:         sal.NetSalary,
+		Status:         sal.Status,
+		Notes:          sal.Notes,
+	}
+
+	addLine := func(lines *[]PayslipLine, label string, amount float64) {
+		if amount != 0 {
+			*lines = append(*lines, PayslipLine{Label: label, Amount: amount})
+		}
+	}
+	addLine(&slip.Earnings, "Base pay", sal.BaseSalary)
+	if sal.OvertimeAmount != 0 {
+		addLine(&slip.Earnings, fmt.Sprintf("Overtime (%g h @ %s)", sal.OvertimeHours, money(sal.OvertimeRate)), sal.OvertimeAmount)
+	}
+	addLine(&slip.Earnings, "Bonus", sal.Bonus)
+	addLine(&slip.Earnings, "Commission", sal.Commission)
+	addLine(&slip.Earnings, "Allowances", sal.Allowances)
+	addLine(&slip.Earnings, "Tips", sal.Tips)
+	addLine(&slip.Earnings, "Other earnings", sal.OtherEarnings)
+
+	var byRule map[string]float64
+	_ = json.Unmarshal(sal.DeductionsDetails, &byRule)
+	names := make([]string, 0, len(byRule))
+	for name := range byRule {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		addLine(&slip.Deductions, name, byRule[name])
+	}
+	if len(byRule) == 0 {
+		// Salaries entered by hand have no per-rule breakdown
+		addLine(&slip.Deductions, "Tax", sal.Tax)
+		addLine(&slip.Deductions, "Social insurance", sal.SocialInsurance)
+		addLine(&slip.Deductions, "Health insurance", sal.HealthInsurance)
+		addLine(&slip.Deductions, "Pension", sal.Pension)
+		addLine(&slip.Deductions, "Other deductions", sal.OtherDeductions)
+	}
+	addLine(&slip.Deductions, "Loan repayment", sal.LoanDeduction)
+	addLine(&slip.Deductions, "Salary advance", sal.AdvanceDeduction)
+	return slip
+}
+
+// WritePayslipCSV writes a payslip as label/amount rows
+func WritePayslipCSV(out io.Writer, slip *Payslip) error {
+	w := csv.NewWriter(out)
+	rows := [][]string{
+		{"payroll_run_id", fmt.Sprint(slip.PayrollRunID)},
+		{"employee_code", slip.EmployeeCode},
+		{"employee_name", slip.EmployeeName},
+		{"position", slip.Position},
+		{"pay_period_start", slip.PayPeriodStart},
+		{"pay_period_end", slip.PayPeriodEnd},
+		{"currency", slip.Currency},
+		{"days_worked", fmt.Sprint(slip.DaysWorked)},
+		{"hours_worked", money(slip.HoursWorked)},
+		{},
+		{"earnings", "amount"},
+	}
+	for _, line := range slip.Earnings {
+		rows = append(rows, []string{line.Label, money(line.Amount)})
+	}
+	rows = append(rows, []string{"gross_pay", money(slip.GrossPay)}, []string{}, []string{"deductions", "amount"})
+	for _, line := range slip.Deductions {
+		rows = append(rows, []string{line.Label, money(line.Amount)})
+	}
+	rows = append(rows,
+		[]string{"total_deductions", money(slip.TotalDeduction)},
+		[]string{},
+		[]string{"net_pay", money(slip.NetPay)},
+	)
+	if err := w.WriteAll(rows); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// WritePayrollRunCSV writes the payroll register: one row per salary followed by the run totals
+func WritePayrollRunCSV(out io.Writer, run *PayrollRun) error {
+	w := csv.NewWriter(out)
+	header := []string{
+		"employee_id", "employee_name", "days_worked", "hours_worked", "base_pay",
+		"overtime_hours", "overtime_pay", "tips", "other_earnings", "gross_pay",
+		"tax", "social_insurance", "health_insurance", "pension", "other_deductions",
+		"total_deductions", "net_pay", "status",
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+	for _, sal := range run.Salaries {
+		err := w.Write([]string{
+			fmt.Sprint(sal.EmployeeID), sal.EmployeeName, fmt.Sprint(sal.DaysWorked),
+			money(sal.TotalHoursWorked), money(sal.BaseSalary), money(sal.OvertimeHours),
+			money(sal.OvertimeAmount), money(sal.Tips),
+			money(sal.Bonus + sal.Commission + sal.Allowances + sal.OtherEarnings),
+			money(sal.GrossSalary), money(sal.Tax), money(sal.SocialInsurance),
+			money(sal.HealthInsurance), money(sal.Pension),
+			money(sal.LoanDeduction + sal.AdvanceDeduction + sal.OtherDeductions),
+			money(sal.TotalDeductions), money(sal.NetSalary), sal.Status,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	err := w.Write([]string{
+		"total", fmt.Sprint(run.EmployeeCount), "", "", "", "", "", money(run.TipPool), "",
+		money(run.TotalGross), "", "", "", "", "", money(run.TotalDeductions), money(run.TotalNet), run.Status,
+	})
+	if err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// monthFraction returns how many months the inclusive period covers, counting each day
+// as a fraction of its own month so that a calendar month is exactly 1
+func monthFraction(start, end time.Time) float64 {
+	var months float64
+	for d := start; !d.After(end); d = d.AddDate(0, 0, 1) {
+		daysInMonth := time.Date(d.Year(), d.Month()+1, 0, 0, 0, 0, 0, time.UTC).Day()
+		months += 1 / float64(daysInMonth)
+	}
+	return months
+}
+
+// periodDays returns the number of calendar days in the inclusive period
+func periodDays(start, end time.Time) int {
+	days := 0
+	for d, last := dateOnly(start), dateOnly(end); !d.After(last); d = d.AddDate(0, 0, 1) {
+		days++
+	}
+	return days
+}
+
+func dateOnly(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func payFrequencyLabel(frequency string) string {
+	switch frequency {
+	case "hourly", "daily", "weekly":
+		return strings.ToUpper(frequency[:1]) + frequency[1:]
+	case "bi_weekly":
+		return "Bi-weekly"
+	default:
+		return "Monthly"
+	}
+}
@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestDeductionRuleDeduct tests progressive tax brackets, capped percentages and fixed deductions
+func TestDeductionRuleDeduct(t *testing.T) {
+	brackets := []DeductionRule{
+		{Name: "Tax 0%", RuleType: DeductionRuleTaxBracket, Component: DeductionTax, Rate: 0, MaxIncome: floatPtr(2000), IsActive: true},
+		{Name: "Tax 10%", RuleType: DeductionRuleTaxBracket, Component: DeductionTax, Rate: 10, MinIncome: 2000, MaxIncome: floatPtr(5000), IsActive: true},
+		{Name: "Tax 20%", RuleType: DeductionRuleTaxBracket, Component: DeductionTax, Rate: 20, MinIncome: 5000, IsActive: true},
+	}
+	tests := []struct {
+		gross float64
+		want  float64
+	}{
+		{1500, 0},
+		{3000, 100},
+		{6000, 500},
+	}
+	for _, tt := range tests {
+		var tax float64
+		for i := range brackets {
+			tax += brackets[i].Deduct(tt.gross)
+		}
+		if tax != tt.want {
+			t.Errorf("tax on %v = %v, want %v", tt.gross, tax, tt.want)
+		}
+	}
+
+	insurance := DeductionRule{RuleType: DeductionRulePercentage, Rate: 11, MaxIncome: floatPtr(4000)}
+	if got := insurance.Deduct(6000); got != 440 {
+		t.Errorf("insurance capped at max income = %v, want 440", got)
+	}
+	fixed := DeductionRule{RuleType: DeductionRuleFixed, Amount: 50, MinIncome: 1000}
+	if got := fixed.Deduct(800); got != 0 {
+		t.Errorf("fixed deduction below min income = %v, want 0", got)
+	}
+	fixed.MaxDeduction = floatPtr(30)
+	if got := fixed.Deduct(1200); got != 30 {
+		t.Errorf("fixed deduction with cap = %v, want 30", got)
+	}
+}
+
+// TestComputeSalary tests prorated base pay, unpaid leave, approved overtime and hourly pay
+func TestComputeSalary(t *testing.T) {
+	run := &PayrollRun{
+		TenantID:     1,
+		RestaurantID: 1,
+		PeriodStart:  time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:    time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+	}
+	settings := DefaultPayrollSettings(1, 1)
+	day := func(hours, overtime float64, approved bool) Attendance {
+		in := run.PeriodStart
+		return Attendance{ClockIn: &in, TotalHours: &hours, RegularHours: hours - overtime, OvertimeHours: overtime, OvertimeApproved: approved}
+	}
+
+	monthly := &PayrollInput{
+		Employee:        Employee{ID: 1, BaseSalary: 5200, PaymentFrequency: "monthly", WorkingHoursPerWeek: 40},
+		Attendance:      []Attendance{day(10, 2, true), day(9, 1, false), day(8, 0, false)},
+		UnpaidLeaveDays: 2,
+	}
+	sal := ComputeSalary(run, settings, monthly)
+	// 5200 / 26 working days = 200 per day of unpaid leave
+	if sal.BaseSalary != 4800 {
+		t.Errorf("base salary = %v, want 4800", sal.BaseSalary)
+	}
+	// 5200 / (40 * 52 / 12) = 30/hour, 45 at 1.5x; only the approved 2 hours are paid
+	if sal.OvertimeRate != 45 || sal.OvertimeHours != 2 || sal.OvertimeAmount != 90 {
+		t.Errorf("overtime = %v h at %v = %v, want 2 h at 45 = 90", sal.OvertimeHours, sal.OvertimeRate, sal.OvertimeAmount)
+	}
+	if sal.DaysWorked != 3 || sal.TotalHoursWorked != 27 || sal.TotalOvertimeHours != 3 {
+		t.Errorf("unexpected attendance summary %+v", sal)
+	}
+	var details PayrollEarningsDetails
+	if err := json.Unmarshal(sal.EarningsDetails, &details); err != nil || details.UnapprovedOvertimeHours != 1 {
+		t.Errorf("expected 1 unapproved overtime hour in details, got %s", sal.EarningsDetails)
+	}
+
+	half := *run
+	half.PeriodEnd = time.Date(2024, 6, 15, 0, 0, 0, 0, time.UTC)
+	if sal := ComputeSalary(&half, settings, &PayrollInput{Employee: monthly.Employee}); sal.BaseSalary != 2600 {
+		t.Errorf("half month base salary = %v, want 2600", sal.BaseSalary)
+	}
+
+	hourly := &PayrollInput{
+		Employee:        Employee{ID: 2, BaseSalary: 20, PaymentFrequency: "hourly"},
+		Attendance:      []Attendance{day(8, 0, false), day(6, 0, false)},
+		UnpaidLeaveDays: 1,
+	}
+	if sal := ComputeSalary(run, settings, hourly); sal.BaseSalary != 280 {
+		t.Errorf("hourly base salary = %v, want 280", sal.BaseSalary)
+	}
+}
+
+// TestBuildPayroll tests tip pooling, deductions and run totals
+func TestBuildPayroll(t *testing.T) {
+	run := &PayrollRun{
+		PeriodStart: time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		PeriodEnd:   time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+	}
+	settings := DefaultPayrollSettings(1, 1)
+	hours := func(h float64) []Attendance {
+		in := run.PeriodStart
+		return []Attendance{{ClockIn: &in, TotalHours: &h, RegularHours: h}}
+	}
+	inputs := []PayrollInput{
+		{Employee: Employee{ID: 1, BaseSalary: 3000, PaymentFrequency: "monthly"}, Attendance: hours(20)},
+		{Employee: Employee{ID: 2, BaseSalary: 3000, PaymentFrequency: "monthly"}, Attendance: hours(10)},
+		{Employee: Employee{ID: 3, BaseSalary: 15, PaymentFrequency: "hourly"}},
+	}
+	rules := []DeductionRule{
+		{Name: "Social insurance", RuleType: DeductionRulePercentage, Component: DeductionSocialInsurance, Rate: 10, IsActive: true},
+		{Name: "Inactive", RuleType: DeductionRuleFixed, Component: DeductionOther, Amount: 100},
+	}
+
+	salaries, err := BuildPayroll(run, settings, rules, inputs, 100)
+	if err != nil {
+		t.Fatalf("BuildPayroll() error = %v", err)
+	}
+	if len(salaries) != 2 {
+		t.Fatalf("expected the hourly employee with no hours to be left out, got %d salaries", len(salaries))
+	}
+	if salaries[0].Tips+salaries[1].Tips != 100 || salaries[0].Tips != 66.67 {
+		t.Errorf("tips split by hours = %v and %v, want 66.67 and 33.33", salaries[0].Tips, salaries[1].Tips)
+	}
+	if salaries[0].SocialInsurance != 306.67 || salaries[0].OtherDeductions != 0 {
+		t.Errorf("deductions = %v social insurance, %v other", salaries[0].SocialInsurance, salaries[0].OtherDeductions)
+	}
+	if run.EmployeeCount != 2 || run.TotalGross != 6100 || run.TotalNet != 5490 {
+		t.Errorf("unexpected run totals %+v", run)
+	}
+
+	settings.TipPoolMethod = TipPoolEqual
+	salaries, _ = BuildPayroll(run, settings, nil, inputs, 100)
+	if salaries[0].Tips != 50 || salaries[1].Tips != 50 {
+		t.Errorf("equal tips = %v and %v, want 50 each", salaries[0].Tips, salaries[1].Tips)
+	}
+
+	if _, err := BuildPayroll(run, settings, nil, inputs[2:], 100); err != ErrPayrollNoEmployees {
+		t.Errorf("expected ErrPayrollNoEmployees, got %v", err)
+	}
+}
+
+// TestApplyDeductionsLimits tests that deductions never exceed gross pay
+func TestApplyDeductionsLimits(t *testing.T) {
+	sal := &Salary{BaseSalary: 500, LoanDeduction: 450}
+	rules := []DeductionRule{{Name: "Meals", RuleType: DeductionRuleFixed, Component: DeductionOther, Amount: 100, IsActive: true}}
+	if err := ApplyDeductions(sal, rules); err != nil {
+		t.Fatalf("ApplyDeductions() error = %v", err)
+	}
+	if sal.OtherDeductions != 50 || sal.NetSalary != 0 {
+		t.Errorf("expected the rule to take only the remaining 50, got %v and net %v", sal.OtherDeductions, sal.NetSalary)
+	}
+
+	sal.AdvanceDeduction = 100
+	if err := ApplyDeductions(sal, rules); err != ErrDeductionsExceedGross {
+		t.Errorf("expected ErrDeductionsExceedGross, got %v", err)
+	}
+}
+
+// TestUnpaidLeaveDays tests leave category, half days and leaves crossing the period edge
+func TestUnpaidLeaveDays(t *testing.T) {
+	start := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC)
+	date := func(m time.Month, d int) time.Time { return time.Date(2024, m, d, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name  string
+		leave Leave
+		want  float64
+	}{
+		{"paid leave", Leave{LeaveType: "annual", LeaveCategory: "paid", StartDate: date(6, 3), EndDate: date(6, 5), TotalDays: 3}, 0},
+		{"inside period", Leave{LeaveType: "unpaid", LeaveCategory: "unpaid", StartDate: date(6, 3), EndDate: date(6, 5), TotalDays: 3}, 3},
+		{"half day", Leave{LeaveType: "casual", LeaveCategory: "unpaid", StartDate: date(6, 10), EndDate: date(6, 10), IsHalfDay: true}, 0.5},
+		{"crosses period end", Leave{LeaveType: "unpaid", LeaveCategory: "unpaid", StartDate: date(6, 29), EndDate: date(7, 2), TotalDays: 4}, 2},
+		{"outside period", Leave{LeaveType: "unpaid", LeaveCategory: "unpaid", StartDate: date(7, 1), EndDate: date(7, 2), TotalDays: 2}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnpaidLeaveDays(&tt.leave, start, end); got != tt.want {
+				t.Errorf("UnpaidLeaveDays() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
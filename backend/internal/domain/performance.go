@@ -0,0 +1,486 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Performance review statuses
+const (
+	ReviewDraft        = "draft"
+	ReviewSubmitted    = "submitted"
+	ReviewAcknowledged = "acknowledged"
+	ReviewDisputed     = "disputed"
+	ReviewFinalized    = "finalized"
+)
+
+// Performance goal statuses
+const (
+	GoalNotStarted = "not_started"
+	GoalInProgress = "in_progress"
+	GoalCompleted  = "completed"
+	GoalMissed     = "missed"
+	GoalCancelled  = "cancelled"
+)
+
+// ReviewTypes are the kinds of review a cycle can run
+var ReviewTypes = []string{"probation", "annual", "mid_year", "quarterly", "ad_hoc"}
+
+var criterionKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// ReviewCycle is a round of performance reviews over a period
+type ReviewCycle struct {
+	ID                   int        `json:"id"`
+	TenantID             int        `json:"tenant_id"`
+	RestaurantID         int        `json:"restaurant_id"`
+	Name                 string     `json:"name"`
+	ReviewType           string     `json:"review_type"` // 'probation', 'annual', 'mid_year', 'quarterly', 'ad_hoc'
+	PeriodStart          time.Time  `json:"period_start"`
+	PeriodEnd            time.Time  `json:"period_end"`
+	SelfAssessmentDue    *time.Time `json:"self_assessment_due,omitempty"`
+	ManagerAssessmentDue *time.Time `json:"manager_assessment_due,omitempty"`
+	Status               string     `json:"status"` // 'active', 'closed'
+	ReviewCount          int        `json:"review_count"`
+	FinalizedCount       int        `json:"finalized_count"`
+	CreatedBy            *int       `json:"created_by,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+
+	Reviews []PerformanceReview `json:"reviews,omitempty"`
+}
+
+// CreateReviewCycleRequest opens a review cycle for the given employees, or every active employee
+type CreateReviewCycleRequest struct {
+	Name                 string `json:"name" validate:"required"`
+	ReviewType           string `json:"review_type" validate:"required"`
+	PeriodStart          string `json:"period_start" validate:"required"` // YYYY-MM-DD
+	PeriodEnd            string `json:"period_end" validate:"required"`   // YYYY-MM-DD
+	SelfAssessmentDue    string `json:"self_assessment_due,omitempty"`    // YYYY-MM-DD
+	ManagerAssessmentDue string `json:"manager_assessment_due,omitempty"` // YYYY-MM-DD
+	EmployeeIDs          []int  `json:"employee_ids,omitempty"`
+}
+
+// Cycle parses and checks the request
+func (r *CreateReviewCycleRequest) Cycle(tenantID, restaurantID int) (*ReviewCycle, error) {
+	if strings.TrimSpace(r.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+	valid := false
+	for _, t := range ReviewTypes {
+		valid = valid || t == r.ReviewType
+	}
+	if !valid {
+		return nil, fmt.Errorf("review_type must be one of %s", strings.Join(ReviewTypes, ", "))
+	}
+	start, err := time.Parse(DateLayout, r.PeriodStart)
+	if err != nil {
+		return nil, errors.New("invalid period_start, expected YYYY-MM-DD")
+	}
+	end, err := time.Parse(DateLayout, r.PeriodEnd)
+	if err != nil {
+		return nil, errors.New("invalid period_end, expected YYYY-MM-DD")
+	}
+	if end.Before(start) {
+		return nil, errors.New("period_end must not be before period_start")
+	}
+
+	cycle := &ReviewCycle{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		Name:         strings.TrimSpace(r.Name),
+		ReviewType:   r.ReviewType,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+		Status:       "active",
+	}
+	if r.SelfAssessmentDue != "" {
+		due, err := time.Parse(DateLayout, r.SelfAssessmentDue)
+		if err != nil {
+			return nil, errors.New("invalid self_assessment_due, expected YYYY-MM-DD")
+		}
+		cycle.SelfAssessmentDue = &due
+	}
+	if r.ManagerAssessmentDue != "" {
+		due, err := time.Parse(DateLayout, r.ManagerAssessmentDue)
+		if err != nil {
+			return nil, errors.New("invalid manager_assessment_due, expected YYYY-MM-DD")
+		}
+		if cycle.SelfAssessmentDue != nil && due.Before(*cycle.SelfAssessmentDue) {
+			return nil, errors.New("manager_assessment_due must not be before self_assessment_due")
+		}
+		cycle.ManagerAssessmentDue = &due
+	}
+	return cycle, nil
+}
+
+// ReviewCriterion is a rating criterion used in performance reviews
+type ReviewCriterion struct {
+	ID           int       `json:"id"`
+	TenantID     int       `json:"tenant_id"`
+	RestaurantID int       `json:"restaurant_id"`
+	Key          string    `json:"key"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description,omitempty"`
+	Weight       float64   `json:"weight"`
+	SortOrder    int       `json:"sort_order"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ReviewCriterionRequest creates or replaces a rating criterion
+type ReviewCriterionRequest struct {
+	Key         string  `json:"key" validate:"required"`
+	Name        string  `json:"name" validate:"required"`
+	Description string  `json:"description,omitempty"`
+	Weight      float64 `json:"weight,omitempty"` // defaults to 1
+	SortOrder   int     `json:"sort_order"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+// Criterion checks the request and builds the criterion
+func (r *ReviewCriterionRequest) Criterion(tenantID, restaurantID int) (*ReviewCriterion, error) {
+	if !criterionKeyPattern.MatchString(r.Key) {
+		return nil, errors.New("key must be 2-50 lowercase letters, digits or underscores, starting with a letter")
+	}
+	if strings.TrimSpace(r.Name) == "" {
+		return nil, errors.New("name is required")
+	}
+	if r.Weight < 0 || r.Weight > 100 {
+		return nil, errors.New("weight must be between 0 and 100")
+	}
+	criterion := &ReviewCriterion{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		Key:          r.Key,
+		Name:         strings.TrimSpace(r.Name),
+		Description:  r.Description,
+		Weight:       r.Weight,
+		SortOrder:    r.SortOrder,
+		IsActive:     true,
+	}
+	if criterion.Weight == 0 {
+		criterion.Weight = 1
+	}
+	if r.IsActive != nil {
+		criterion.IsActive = *r.IsActive
+	}
+	return criterion, nil
+}
+
+// ValidateRatings checks that every active criterion is rated between 1 and 5 and that no
+// unknown criterion is rated
+func ValidateRatings(ratings map[string]float64, criteria []ReviewCriterion) error {
+	active := map[string]bool{}
+	for _, c := range criteria {
+		if c.IsActive {
+			active[c.Key] = true
+		}
+	}
+	if len(active) == 0 {
+		return ErrNoReviewCriteria
+	}
+	for key, rating := range ratings {
+		if !active[key] {
+			return fmt.Errorf("ratings must only use active review criteria, %q is not one", key)
+		}
+		if rating < 1 || rating > 5 {
+			return fmt.Errorf("rating for %q must be between 1 and 5", key)
+		}
+	}
+	missing := []string{}
+	for key := range active {
+		if _, ok := ratings[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("ratings are required for %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// WeightedRating averages the ratings by criterion weight, rounded to two decimals
+func WeightedRating(ratings map[string]float64, criteria []ReviewCriterion) float64 {
+	var sum, weights float64
+	for _, c := range criteria {
+		rating, ok := ratings[c.Key]
+		if !ok || !c.IsActive {
+			continue
+		}
+		sum += rating * c.Weight
+		weights += c.Weight
+	}
+	if weights == 0 {
+		return 0
+	}
+	return math.Round(sum/weights*100) / 100
+}
+
+// PerformanceReview is an employee's review: a self assessment, the manager's assessment,
+// the employee's acknowledgement and HR's sign-off
+type PerformanceReview struct {
+	ID                 int       `json:"id"`
+	TenantID           int       `json:"tenant_id"`
+	RestaurantID       int       `json:"restaurant_id"`
+	EmployeeID         int       `json:"employee_id"`
+	EmployeeName       string    `json:"employee_name,omitempty"`
+	CycleID            *int      `json:"cycle_id,omitempty"`
+	ReviewPeriodStart  time.Time `json:"review_period_start"`
+	ReviewPeriodEnd    time.Time `json:"review_period_end"`
+	ReviewDate         time.Time `json:"review_date"`
+	ReviewType         string    `json:"review_type"`
+	ReviewerID         *int      `json:"reviewer_id,omitempty"` // user account of the reviewer
+	ReviewerEmployeeID *int      `json:"reviewer_employee_id,omitempty"`
+	ReviewerName       string    `json:"reviewer_name,omitempty"`
+
+	// Assessments
+	SelfRatings        map[string]float64 `json:"self_ratings"`
+	SelfComments       string             `json:"self_comments,omitempty"`
+	SelfSubmittedAt    *time.Time         `json:"self_submitted_at,omitempty"`
+	Ratings            map[string]float64 `json:"ratings"` // manager ratings by criterion key
+	OverallRating      *float64           `json:"overall_rating,omitempty"`
+	ManagerSubmittedAt *time.Time         `json:"manager_submitted_at,omitempty"`
+
+	// Feedback
+	Strengths               string `json:"strengths,omitempty"`
+	AreasForImprovement     string `json:"areas_for_improvement,omitempty"`
+	Achievements            string `json:"achievements,omitempty"`
+	ActionPlan              string `json:"action_plan,omitempty"`
+	TrainingRecommendations string `json:"training_recommendations,omitempty"`
+	ReviewerComments        string `json:"reviewer_comments,omitempty"`
+	EmployeeComments        string `json:"employee_comments,omitempty"`
+	HRComments              string `json:"hr_comments,omitempty"`
+
+	// Recommendations
+	PromotionRecommended          bool       `json:"promotion_recommended"`
+	SalaryIncreaseRecommended     bool       `json:"salary_increase_recommended"`
+	RecommendedIncreasePercentage *float64   `json:"recommended_increase_percentage,omitempty"`
+	NextReviewDate                *time.Time `json:"next_review_date,omitempty"`
+
+	// Status
+	Status               string     `json:"status"` // 'draft', 'submitted', 'acknowledged', 'disputed', 'finalized'
+	EmployeeAcknowledged bool       `json:"employee_acknowledged"`
+	AcknowledgedAt       *time.Time `json:"acknowledged_at,omitempty"`
+	IsFinalized          bool       `json:"is_finalized"`
+	FinalizedAt          *time.Time `json:"finalized_at,omitempty"`
+	FinalizedBy          *int       `json:"finalized_by,omitempty"`
+
+	Goals     []PerformanceGoal `json:"goals,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// SelfAssessmentRequest is the employee's own assessment
+type SelfAssessmentRequest struct {
+	Ratings      map[string]float64 `json:"ratings" validate:"required"`
+	Comments     string             `json:"comments,omitempty"`
+	Achievements string             `json:"achievements,omitempty"`
+}
+
+// ManagerAssessmentRequest is the reviewer's assessment, submitted to the employee
+type ManagerAssessmentRequest struct {
+	Ratings                       map[string]float64 `json:"ratings" validate:"required"`
+	Strengths                     string             `json:"strengths,omitempty"`
+	AreasForImprovement           string             `json:"areas_for_improvement,omitempty"`
+	ActionPlan                    string             `json:"action_plan,omitempty"`
+	TrainingRecommendations       string             `json:"training_recommendations,omitempty"`
+	ReviewerComments              string             `json:"reviewer_comments" validate:"required"`
+	PromotionRecommended          bool               `json:"promotion_recommended"`
+	SalaryIncreaseRecommended     bool               `json:"salary_increase_recommended"`
+	RecommendedIncreasePercentage *float64           `json:"recommended_increase_percentage,omitempty"`
+	NextReviewDate                string             `json:"next_review_date,omitempty"` // YYYY-MM-DD
+	Goals                         []GoalRequest      `json:"goals,omitempty"`
+}
+
+// Apply checks the assessment against the criteria and writes it to the review
+func (r *ManagerAssessmentRequest) Apply(review *PerformanceReview, criteria []ReviewCriterion) error {
+	if err := ValidateRatings(r.Ratings, criteria); err != nil {
+		return err
+	}
+	if strings.TrimSpace(r.ReviewerComments) == "" {
+		return errors.New("reviewer_comments are required")
+	}
+	if r.RecommendedIncreasePercentage != nil && (*r.RecommendedIncreasePercentage < 0 || *r.RecommendedIncreasePercentage > 100) {
+		return errors.New("recommended_increase_percentage must be between 0 and 100")
+	}
+	for i := range r.Goals {
+		if _, err := r.Goals[i].Goal(review.TenantID, review.RestaurantID, review.EmployeeID); err != nil {
+			return err
+		}
+	}
+	if r.NextReviewDate != "" {
+		next, err := time.Parse(DateLayout, r.NextReviewDate)
+		if err != nil {
+			return errors.New("invalid next_review_date, expected YYYY-MM-DD")
+		}
+		review.NextReviewDate = &next
+	}
+
+	overall := WeightedRating(r.Ratings, criteria)
+	review.Ratings = r.Ratings
+	review.OverallRating = &overall
+	review.Strengths = r.Strengths
+	review.AreasForImprovement = r.AreasForImprovement
+	review.ActionPlan = r.ActionPlan
+	review.TrainingRecommendations = r.TrainingRecommendations
+	review.ReviewerComments = strings.TrimSpace(r.ReviewerComments)
+	review.PromotionRecommended = r.PromotionRecommended
+	review.SalaryIncreaseRecommended = r.SalaryIncreaseRecommended
+	review.RecommendedIncreasePercentage = r.RecommendedIncreasePercentage
+	review.Status = ReviewSubmitted
+	return nil
+}
+
+// AcknowledgeReviewRequest is the employee's response to a submitted review
+type AcknowledgeReviewRequest struct {
+	Agree    bool   `json:"agree"`
+	Comments string `json:"comments,omitempty"`
+}
+
+// FinalizeReviewRequest is HR's sign-off on a review
+type FinalizeReviewRequest struct {
+	HRComments string `json:"hr_comments,omitempty"`
+}
+
+// PerformanceGoal is a goal tracked for an employee
+type PerformanceGoal struct {
+	ID           int        `json:"id"`
+	TenantID     int        `json:"tenant_id"`
+	RestaurantID int        `json:"restaurant_id"`
+	EmployeeID   int        `json:"employee_id"`
+	ReviewID     *int       `json:"review_id,omitempty"`
+	Title        string     `json:"title"`
+	Description  string     `json:"description,omitempty"`
+	TargetDate   *time.Time `json:"target_date,omitempty"`
+	Progress     int        `json:"progress"` // percent
+	Status       string     `json:"status"`   // 'not_started', 'in_progress', 'completed', 'missed', 'cancelled'
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+	CreatedBy    *int       `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// GoalRequest creates a goal
+type GoalRequest struct {
+	Title       string `json:"title" validate:"required"`
+	Description string `json:"description,omitempty"`
+	TargetDate  string `json:"target_date,omitempty"` // YYYY-MM-DD
+	ReviewID    *int   `json:"review_id,omitempty"`
+}
+
+// Goal checks the request and builds the goal
+func (r *GoalRequest) Goal(tenantID, restaurantID, employeeID int) (*PerformanceGoal, error) {
+	if strings.TrimSpace(r.Title) == "" {
+		return nil, errors.New("goal title is required")
+	}
+	goal := &PerformanceGoal{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		EmployeeID:   employeeID,
+		ReviewID:     r.ReviewID,
+		Title:        strings.TrimSpace(r.Title),
+		Description:  r.Description,
+		Status:       GoalNotStarted,
+	}
+	if r.TargetDate != "" {
+		target, err := time.Parse(DateLayout, r.TargetDate)
+		if err != nil {
+			return nil, errors.New("invalid target_date, expected YYYY-MM-DD")
+		}
+		goal.TargetDate = &target
+	}
+	return goal, nil
+}
+
+// GoalProgressRequest updates a goal's progress or status
+type GoalProgressRequest struct {
+	Progress *int   `json:"progress,omitempty"`
+	Status   string `json:"status,omitempty"`
+}
+
+// Apply updates the goal. Reaching 100% completes it; progress on a new goal starts it.
+func (r *GoalProgressRequest) Apply(goal *PerformanceGoal) error {
+	if goal.Status == GoalCompleted || goal.Status == GoalCancelled {
+		return ErrGoalClosed
+	}
+	if r.Progress != nil {
+		if *r.Progress < 0 || *r.Progress > 100 {
+			return errors.New("progress must be between 0 and 100")
+		}
+		goal.Progress = *r.Progress
+		switch {
+		case goal.Progress == 100:
+			goal.Status = GoalCompleted
+		case goal.Progress > 0 && goal.Status == GoalNotStarted:
+			goal.Status = GoalInProgress
+		}
+	}
+	switch r.Status {
+	case "":
+	case GoalNotStarted, GoalInProgress, GoalMissed, GoalCancelled:
+		goal.Status = r.Status
+	case GoalCompleted:
+		goal.Status = GoalCompleted
+		goal.Progress = 100
+	default:
+		return errors.New("status must be one of not_started, in_progress, completed, missed, cancelled")
+	}
+	return nil
+}
+
+// EmployeeProfile is an employee's record with their review history and goals
+type EmployeeProfile struct {
+	Employee      *Employee           `json:"employee"`
+	Reviews       []PerformanceReview `json:"reviews"`
+	Goals         []PerformanceGoal   `json:"goals"`
+	AverageRating *float64            `json:"average_rating,omitempty"` // over finalized reviews
+}
+
+// AverageFinalizedRating averages the overall rating of finalized reviews, or nil when there are none
+func AverageFinalizedRating(reviews []PerformanceReview) *float64 {
+	var sum float64
+	count := 0
+	for _, r := range reviews {
+		if r.Status == ReviewFinalized && r.OverallRating != nil {
+			sum += *r.OverallRating
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	avg := math.Round(sum/float64(count)*100) / 100
+	return &avg
+}
+
+// RatingsJSON encodes ratings for storage, never as null
+func RatingsJSON(ratings map[string]float64) []byte {
+	if ratings == nil {
+		return []byte("{}")
+	}
+	data, _ := json.Marshal(ratings)
+	return data
+}
+
+// Performance errors
+var (
+	ErrReviewCycleNotFound       = errors.New("review cycle not found")
+	ErrReviewCycleClosed         = errors.New("review cycle is closed")
+	ErrPerformanceReviewNotFound = errors.New("performance review not found")
+	ErrReviewCriterionNotFound   = errors.New("review criterion not found")
+	ErrReviewCriterionExists     = errors.New("a review criterion with this key already exists")
+	ErrNoReviewCriteria          = errors.New("no active review criteria are configured")
+	ErrReviewWrongStatus         = errors.New("performance review is not at a stage that allows this")
+	ErrNotReviewParticipant      = errors.New("you are not allowed to act on this part of the performance review")
+	ErrNoEmployeesToReview       = errors.New("no active employees to review")
+	ErrGoalNotFound              = errors.New("performance goal not found")
+	ErrGoalClosed                = errors.New("completed or cancelled goals cannot be changed")
+)
@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+)
+
+func testCriteria() []ReviewCriterion {
+	return []ReviewCriterion{
+		{Key: "service", Weight: 2, IsActive: true},
+		{Key: "teamwork", Weight: 1, IsActive: true},
+		{Key: "legacy", Weight: 1, IsActive: false},
+	}
+}
+
+// TestValidateRatings tests that every active criterion must be rated on the 1-5 scale
+func TestValidateRatings(t *testing.T) {
+	tests := []struct {
+		name    string
+		ratings map[string]float64
+		wantErr bool
+	}{
+		{"all active rated", map[string]float64{"service": 4, "teamwork": 3}, false},
+		{"missing criterion", map[string]float64{"service": 4}, true},
+		{"inactive criterion", map[string]float64{"service": 4, "teamwork": 3, "legacy": 2}, true},
+		{"unknown criterion", map[string]float64{"service": 4, "teamwork": 3, "speed": 2}, true},
+		{"below scale", map[string]float64{"service": 0, "teamwork": 3}, true},
+		{"above scale", map[string]float64{"service": 5.5, "teamwork": 3}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRatings(tt.ratings, testCriteria())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateRatings() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := ValidateRatings(map[string]float64{}, nil); !errors.Is(err, ErrNoReviewCriteria) {
+		t.Errorf("ValidateRatings() without criteria error = %v, want %v", err, ErrNoReviewCriteria)
+	}
+}
+
+// TestWeightedRating tests that the overall rating is weighted and ignores inactive criteria
+func TestWeightedRating(t *testing.T) {
+	ratings := map[string]float64{"service": 5, "teamwork": 2, "legacy": 1}
+	if got := WeightedRating(ratings, testCriteria()); got != 4 {
+		t.Errorf("WeightedRating() = %v, want 4", got)
+	}
+	if got := WeightedRating(map[string]float64{"service": 4, "teamwork": 3}, testCriteria()); got != 3.67 {
+		t.Errorf("WeightedRating() = %v, want 3.67", got)
+	}
+}
+
+// TestManagerAssessmentApply tests that a valid assessment submits the review with its overall rating
+func TestManagerAssessmentApply(t *testing.T) {
+	review := &PerformanceReview{Status: ReviewDraft}
+	req := &ManagerAssessmentRequest{
+		Ratings:          map[string]float64{"service": 5, "teamwork": 2},
+		ReviewerComments: "Strong quarter",
+		NextReviewDate:   "2025-06-30",
+		Goals:            []GoalRequest{{Title: "Train a new starter"}},
+	}
+	if err := req.Apply(review, testCriteria()); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	if review.Status != ReviewSubmitted {
+		t.Errorf("Status = %q, want %q", review.Status, ReviewSubmitted)
+	}
+	if review.OverallRating == nil || *review.OverallRating != 4 {
+		t.Errorf("OverallRating = %v, want 4", review.OverallRating)
+	}
+	if review.NextReviewDate == nil || review.NextReviewDate.Format(DateLayout) != "2025-06-30" {
+		t.Errorf("NextReviewDate = %v, want 2025-06-30", review.NextReviewDate)
+	}
+
+	invalid := []*ManagerAssessmentRequest{
+		{Ratings: map[string]float64{"service": 5, "teamwork": 2}},
+		{Ratings: map[string]float64{"service": 5}, ReviewerComments: "ok"},
+		{Ratings: map[string]float64{"service": 5, "teamwork": 2}, ReviewerComments: "ok", Goals: []GoalRequest{{}}},
+		{Ratings: map[string]float64{"service": 5, "teamwork": 2}, ReviewerComments: "ok", RecommendedIncreasePercentage: floatPtr(-1)},
+	}
+	for i, req := range invalid {
+		draft := &PerformanceReview{Status: ReviewDraft}
+		if err := req.Apply(draft, testCriteria()); err == nil {
+			t.Errorf("Apply() invalid request %d: expected an error", i)
+		}
+		if draft.Status != ReviewDraft {
+			t.Errorf("Apply() invalid request %d changed status to %q", i, draft.Status)
+		}
+	}
+}
+
+// TestGoalProgressApply tests progress and status changes on a goal
+func TestGoalProgressApply(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       string
+		req          GoalProgressRequest
+		wantStatus   string
+		wantProgress int
+		wantErr      bool
+	}{
+		{"progress starts goal", GoalNotStarted, GoalProgressRequest{Progress: intPtr(40)}, GoalInProgress, 40, false},
+		{"full progress completes goal", GoalInProgress, GoalProgressRequest{Progress: intPtr(100)}, GoalCompleted, 100, false},
+		{"completing sets full progress", GoalInProgress, GoalProgressRequest{Status: GoalCompleted}, GoalCompleted, 100, false},
+		{"missed", GoalInProgress, GoalProgressRequest{Status: GoalMissed}, GoalMissed, 0, false},
+		{"progress out of range", GoalInProgress, GoalProgressRequest{Progress: intPtr(120)}, GoalInProgress, 0, true},
+		{"unknown status", GoalInProgress, GoalProgressRequest{Status: "paused"}, GoalInProgress, 0, true},
+		{"completed goal is closed", GoalCompleted, GoalProgressRequest{Progress: intPtr(50)}, GoalCompleted, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goal := &PerformanceGoal{Status: tt.status}
+			err := tt.req.Apply(goal)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if goal.Status != tt.wantStatus || goal.Progress != tt.wantProgress {
+				t.Errorf("Apply() = %s at %d%%, want %s at %d%%", goal.Status, goal.Progress, tt.wantStatus, tt.wantProgress)
+			}
+		})
+	}
+}
+
+// TestCreateReviewCycleRequest tests the checks on a new review cycle
+func TestCreateReviewCycleRequest(t *testing.T) {
+	valid := CreateReviewCycleRequest{Name: "H1 2025", ReviewType: "mid_year", PeriodStart: "2025-01-01", PeriodEnd: "2025-06-30"}
+	cycle, err := valid.Cycle(1, 2)
+	if err != nil {
+		t.Fatalf("Cycle() error = %v", err)
+	}
+	if cycle.Status != "active" || cycle.RestaurantID != 2 {
+		t.Errorf("Cycle() = %+v, want an active cycle of restaurant 2", cycle)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(r *CreateReviewCycleRequest)
+	}{
+		{"missing name", func(r *CreateReviewCycleRequest) { r.Name = " " }},
+		{"unknown type", func(r *CreateReviewCycleRequest) { r.ReviewType = "weekly" }},
+		{"end before start", func(r *CreateReviewCycleRequest) { r.PeriodEnd = "2024-12-31" }},
+		{"bad date", func(r *CreateReviewCycleRequest) { r.PeriodStart = "01/01/2025" }},
+		{"manager due before self due", func(r *CreateReviewCycleRequest) {
+			r.SelfAssessmentDue, r.ManagerAssessmentDue = "2025-07-15", "2025-07-10"
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+			if _, err := req.Cycle(1, 2); err == nil {
+				t.Error("Cycle() expected an error")
+			}
+		})
+	}
+}
+
+// TestAverageFinalizedRating tests that only finalized reviews count towards the average
+func TestAverageFinalizedRating(t *testing.T) {
+	if got := AverageFinalizedRating(nil); got != nil {
+		t.Errorf("AverageFinalizedRating(nil) = %v, want nil", *got)
+	}
+	reviews := []PerformanceReview{
+		{Status: ReviewFinalized, OverallRating: floatPtr(4)},
+		{Status: ReviewFinalized, OverallRating: floatPtr(3.5)},
+		{Status: ReviewSubmitted, OverallRating: floatPtr(1)},
+		{Status: ReviewFinalized},
+	}
+	if got := AverageFinalizedRating(reviews); got == nil || *got != 3.75 {
+		t.Errorf("AverageFinalizedRating() = %v, want 3.75", got)
+	}
+}
@@ -9,17 +9,17 @@ import (
 
 // AuditLog represents a record of permission changes for compliance and security
 type PermissionAuditLog struct {
-	ID        int64
-	TenantID  int64
-	AdminID   int64  // User ID of admin who made the change
-	UserID    *int64 // User ID affected by the change (nullable)
-	RoleID    *int64 // Role ID affected by the change (nullable)
-	ModuleID  *int64 // Module ID affected by the change (nullable)
-	Action    string // CREATE_ROLE, UPDATE_ROLE, DELETE_ROLE, ASSIGN_PERMISSION, REVOKE_PERMISSION, ASSIGN_USER_ROLE, REVOKE_USER_ROLE
-	Details   AuditDetails
-	IPAddress string // Source IP address
-	UserAgent string // Browser/client user agent
-	CreatedAt time.Time
+	ID        int64        `json:"id"`
+	TenantID  int64        `json:"tenant_id"`
+	AdminID   int64        `json:"admin_id"`            // User ID of admin who made the change
+	UserID    *int64       `json:"user_id,omitempty"`   // User ID affected by the change (nullable)
+	RoleID    *int64       `json:"role_id,omitempty"`   // Role ID affected by the change (nullable)
+	ModuleID  *int64       `json:"module_id,omitempty"` // Module ID affected by the change (nullable)
+	Action    string       `json:"action"`              // CREATE_ROLE, UPDATE_ROLE, DELETE_ROLE, ASSIGN_PERMISSION, REVOKE_PERMISSION, ASSIGN_USER_ROLE, REVOKE_USER_ROLE
+	Details   AuditDetails `json:"details"`
+	IPAddress string       `json:"ip_address,omitempty"` // Source IP address
+	UserAgent string       `json:"user_agent,omitempty"` // Browser/client user agent
+	CreatedAt time.Time    `json:"created_at"`
 }
 
 // AuditDetails contains additional metadata about the change
@@ -155,3 +155,23 @@ func NewPermissionAuditLog(
 		CreatedAt: time.Now(),
 	}
 }
+
+// PermissionActor is the admin making a permission change: their tenant and current
+// restaurant, and where the request came from
+type PermissionActor struct {
+	TenantID     int64
+	RestaurantID int64
+	UserID       int64
+	IPAddress    string
+	UserAgent    string
+	Reason       string // Recorded with changes not made by hand, e.g. "sso group mapping"
+}
+
+// AuditLog starts an audit log entry for a change made by this actor
+func (a PermissionActor) AuditLog(action string) *PermissionAuditLog {
+	entry := NewPermissionAuditLog(a.TenantID, a.UserID, action)
+	entry.IPAddress = a.IPAddress
+	entry.UserAgent = a.UserAgent
+	entry.Details.Reason = a.Reason
+	return entry
+}
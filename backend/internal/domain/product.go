@@ -75,6 +75,10 @@ type Product struct {
 	Featured     bool   `json:"featured"`
 	MainImageURL string `json:"main_image_url,omitempty"`
 
+	// Ratings (aggregated from approved reviews)
+	RatingAverage float64 `json:"rating_average"`
+	RatingCount   int     `json:"rating_count"`
+
 	// Status
 	Status    string    `json:"status"` // 'active', 'inactive', 'discontinued'
 	CreatedBy int       `json:"created_by"`
@@ -177,6 +181,7 @@ type UpdateProductRequest struct {
 	NameAr      *string  `json:"name_ar"`
 	Price       *float64 `json:"price"`
 	IsAvailable *bool    `json:"is_available"`
+	Allergens   []string `json:"allergens"` // nil leaves allergens unchanged, [] clears them
 }
 
 // ProductListResponse is paginated list response
@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Review statuses
+const (
+	ReviewStatusPending  = "pending"
+	ReviewStatusApproved = "approved"
+	ReviewStatusHidden   = "hidden"
+)
+
+// Review request statuses
+const (
+	ReviewRequestPending   = "pending"
+	ReviewRequestCompleted = "completed"
+	ReviewRequestExpired   = "expired"
+)
+
+// ReviewRequestTTL is how long a customer can leave feedback after delivery
+const ReviewRequestTTL = 14 * 24 * time.Hour
+
+// ReviewRequest invites a customer to review a delivered order
+type ReviewRequest struct {
+	ID            int64      `json:"id"`
+	TenantID      int64      `json:"tenant_id"`
+	RestaurantID  int64      `json:"restaurant_id"`
+	OrderID       int64      `json:"order_id"`
+	Token         string     `json:"token"`
+	CustomerName  string     `json:"customer_name"`
+	CustomerEmail string     `json:"customer_email,omitempty"`
+	CustomerPhone string     `json:"customer_phone,omitempty"`
+	Status        string     `json:"status"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// OrderFeedback holds the customer ratings of a delivered order
+type OrderFeedback struct {
+	ID              int64     `json:"id"`
+	TenantID        int64     `json:"tenant_id"`
+	RestaurantID    int64     `json:"restaurant_id"`
+	OrderID         int64     `json:"order_id"`
+	OverallRating   int       `json:"overall_rating"`
+	FoodRating      *int      `json:"food_rating,omitempty"`
+	DeliveryRating  *int      `json:"delivery_rating,omitempty"`
+	Comment         string    `json:"comment,omitempty"`
+	DeliveryComment string    `json:"delivery_comment,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// ProductReview is a customer review of a single ordered item
+type ProductReview struct {
+	ID           int64      `json:"id"`
+	TenantID     int64      `json:"tenant_id"`
+	RestaurantID int64      `json:"restaurant_id"`
+	ProductID    int64      `json:"product_id"`
+	ProductName  string     `json:"product_name,omitempty"`
+	OrderID      int64      `json:"order_id"`
+	CustomerName string     `json:"customer_name"`
+	Rating       int        `json:"rating"`
+	Comment      string     `json:"comment,omitempty"`
+	Status       string     `json:"status"`
+	ModeratedBy  *int64     `json:"moderated_by,omitempty"`
+	ModeratedAt  *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ReviewRequestView is what the customer sees when opening a review link
+type ReviewRequestView struct {
+	OrderNumber  string         `json:"order_number"`
+	CustomerName string         `json:"customer_name"`
+	Status       string         `json:"status"`
+	ExpiresAt    time.Time      `json:"expires_at"`
+	Items        []OrderItem    `json:"items"`
+	Feedback     *OrderFeedback `json:"feedback,omitempty"`
+}
+
+// RatingSummary aggregates ratings for a restaurant
+type RatingSummary struct {
+	AverageRating         float64     `json:"average_rating"`
+	TotalRatings          int         `json:"total_ratings"`
+	AverageFoodRating     float64     `json:"average_food_rating"`
+	AverageDeliveryRating float64     `json:"average_delivery_rating"`
+	Distribution          map[int]int `json:"distribution"` // stars -> count
+}
+
+// SubmitFeedbackRequest is the customer feedback for a delivered order
+type SubmitFeedbackRequest struct {
+	OverallRating   int                    `json:"overall_rating" validate:"required,min=1,max=5"`
+	FoodRating      *int                   `json:"food_rating"`
+	DeliveryRating  *int                   `json:"delivery_rating"`
+	Comment         string                 `json:"comment"`
+	DeliveryComment string                 `json:"delivery_comment"`
+	Items           []ProductReviewRequest `json:"items"`
+}
+
+// ProductReviewRequest rates one item of the order
+type ProductReviewRequest struct {
+	ProductID int64  `json:"product_id" validate:"required"`
+	Rating    int    `json:"rating" validate:"required,min=1,max=5"`
+	Comment   string `json:"comment"`
+}
+
+// ModerateReviewRequest approves or hides a review
+type ModerateReviewRequest struct {
+	Status string `json:"status" validate:"required,oneof=approved hidden"`
+}
+
+// ReviewListFilters filters reviews for moderation
+type ReviewListFilters struct {
+	Status    string
+	ProductID int64
+	Page      int
+	Limit     int
+}
+
+// Error definitions for feedback operations
+var (
+	ErrReviewRequestNotFound = errors.New("review request not found")
+	ErrReviewRequestUsed     = errors.New("feedback has already been submitted")
+	ErrReviewRequestExpired  = errors.New("review request has expired")
+	ErrReviewNotFound        = errors.New("review not found")
+)
+
+func validRating(r int) bool {
+	return r >= 1 && r <= 5
+}
+
+// Validate checks the feedback ratings and item reviews
+func (req *SubmitFeedbackRequest) Validate() error {
+	if !validRating(req.OverallRating) {
+		return errors.New("overall rating must be between 1 and 5")
+	}
+	if req.FoodRating != nil && !validRating(*req.FoodRating) {
+		return errors.New("food rating must be between 1 and 5")
+	}
+	if req.DeliveryRating != nil && !validRating(*req.DeliveryRating) {
+		return errors.New("delivery rating must be between 1 and 5")
+	}
+	seen := make(map[int64]bool)
+	for _, item := range req.Items {
+		if !validRating(item.Rating) {
+			return errors.New("item rating must be between 1 and 5")
+		}
+		if seen[item.ProductID] {
+			return errors.New("each product can only be reviewed once per order")
+		}
+		seen[item.ProductID] = true
+	}
+	return nil
+}
+
+// ValidReviewStatus checks if a moderation status is valid
+func ValidReviewStatus(status string) bool {
+	return status == ReviewStatusApproved || status == ReviewStatusHidden || status == ReviewStatusPending
+}
+
+// RatingDistribution returns a star -> count map with every star level present
+func RatingDistribution(counts map[int]int) map[int]int {
+	distribution := make(map[int]int, 5)
+	for stars := 1; stars <= 5; stars++ {
+		distribution[stars] = counts[stars]
+	}
+	return distribution
+}
+
+// RoundRating rounds an average rating to two decimals
+func RoundRating(avg float64) float64 {
+	return math.Round(avg*100) / 100
+}
+
+// ReviewableItems returns the items of the feedback that were part of the order.
+// Reviews for products not on the order are dropped.
+func ReviewableItems(order *Order, items []ProductReviewRequest) []ProductReviewRequest {
+	ordered := make(map[int64]bool, len(order.Items))
+	for _, item := range order.Items {
+		ordered[item.ProductID] = true
+	}
+
+	reviewable := make([]ProductReviewRequest, 0, len(items))
+	for _, item := range items {
+		if ordered[item.ProductID] {
+			reviewable = append(reviewable, item)
+		}
+	}
+	return reviewable
+}
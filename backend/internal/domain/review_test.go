@@ -0,0 +1,55 @@
+package domain
+
+import "testing"
+
+func intPtr(v int) *int { return &v }
+
+// TestSubmitFeedbackValidate tests rating bounds and duplicate item reviews
+func TestSubmitFeedbackValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     SubmitFeedbackRequest
+		wantErr bool
+	}{
+		{"valid", SubmitFeedbackRequest{OverallRating: 5, FoodRating: intPtr(4), DeliveryRating: intPtr(3)}, false},
+		{"missing overall", SubmitFeedbackRequest{}, true},
+		{"food out of range", SubmitFeedbackRequest{OverallRating: 4, FoodRating: intPtr(6)}, true},
+		{"delivery out of range", SubmitFeedbackRequest{OverallRating: 4, DeliveryRating: intPtr(0)}, true},
+		{"item out of range", SubmitFeedbackRequest{OverallRating: 4, Items: []ProductReviewRequest{{ProductID: 1, Rating: 9}}}, true},
+		{"duplicate item", SubmitFeedbackRequest{OverallRating: 4, Items: []ProductReviewRequest{{ProductID: 1, Rating: 5}, {ProductID: 1, Rating: 4}}}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.req.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestReviewableItems tests that only ordered products can be reviewed
+func TestReviewableItems(t *testing.T) {
+	order := &Order{Items: []OrderItem{{ProductID: 1}, {ProductID: 2}}}
+	items := []ProductReviewRequest{{ProductID: 1, Rating: 5}, {ProductID: 3, Rating: 1}}
+
+	got := ReviewableItems(order, items)
+	if len(got) != 1 || got[0].ProductID != 1 {
+		t.Errorf("expected only product 1 to be reviewable, got %+v", got)
+	}
+}
+
+// TestRatingDistribution tests that every star level is present
+func TestRatingDistribution(t *testing.T) {
+	dist := RatingDistribution(map[int]int{5: 3, 1: 1})
+	if len(dist) != 5 {
+		t.Fatalf("expected 5 star levels, got %d", len(dist))
+	}
+	if dist[5] != 3 || dist[1] != 1 || dist[3] != 0 {
+		t.Errorf("unexpected distribution %v", dist)
+	}
+	if RoundRating(4.666) != 4.67 {
+		t.Errorf("RoundRating(4.666) = %v, want 4.67", RoundRating(4.666))
+	}
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"time"
 )
 
@@ -55,15 +57,15 @@ func (r *SimpleRole) CanDelete() bool {
 // ModuleDefinition represents a system module that can have permissions assigned
 // Examples: Products, HR, Notifications, Settings, etc.
 type ModuleDefinition struct {
-	ID          int64
-	Name        string // PRODUCTS, HR, NOTIFICATIONS, SETTINGS, etc.
-	DisplayName string
-	Description string
-	Icon        string // Icon name for UI display
-	Path        string // URL path like "/dashboard/products"
-	IsActive    bool
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"` // PRODUCTS, HR, NOTIFICATIONS, SETTINGS, etc.
+	DisplayName string    `json:"display_name"`
+	Description string    `json:"description,omitempty"`
+	Icon        string    `json:"icon,omitempty"` // Icon name for UI display
+	Path        string    `json:"path,omitempty"` // URL path like "/dashboard/products"
+	IsActive    bool      `json:"is_active"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
 }
 
 // ModuleNames defines standard module names
@@ -158,13 +160,13 @@ func (p *PermissionLevel) IsAdmin() bool {
 
 // RolePermission represents the permission a role has for a specific module
 type RolePermission struct {
-	ID              int64
-	TenantID        int64
-	RoleID          int64
-	ModuleID        int64
-	PermissionLevel string // READ, WRITE, DELETE, ADMIN, NONE
-	CreatedAt       time.Time
-	UpdatedAt       time.Time
+	ID              int64     `json:"id"`
+	TenantID        int64     `json:"tenant_id"`
+	RoleID          int64     `json:"role_id"`
+	ModuleID        int64     `json:"module_id"`
+	PermissionLevel string    `json:"permission_level"` // READ, WRITE, DELETE, ADMIN, NONE
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 // Validate checks if role permission has required fields
@@ -221,13 +223,16 @@ func (rp *RolePermission) HasPermissionLevel(requiredLevel string) bool {
 
 // UserRole represents a user assigned to a role
 type UserRole struct {
-	ID         int64
-	TenantID   int64
-	UserID     int64
-	RoleID     int64
-	AssignedBy int64 // User ID of admin who assigned this role
-	CreatedAt  time.Time
-	UpdatedAt  time.Time
+	ID             int64     `json:"id"`
+	TenantID       int64     `json:"tenant_id"`
+	UserID         int64     `json:"user_id"`
+	RoleID         int64     `json:"role_id"`
+	RoleName       string    `json:"role_name,omitempty"`
+	RestaurantID   *int64    `json:"restaurant_id"` // nil: the role applies in every restaurant
+	RestaurantName string    `json:"restaurant_name,omitempty"`
+	AssignedBy     int64     `json:"assigned_by,omitempty"` // User ID of admin who assigned this role
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // Validate checks if user role has required fields
@@ -246,3 +251,159 @@ func (ur *UserRole) Validate() error {
 
 	return nil
 }
+
+// RBAC errors
+var (
+	ErrRoleNotFound        = errors.New("role not found")
+	ErrSystemRoleImmutable = errors.New("system roles cannot be changed or deleted")
+	ErrRoleExists          = errors.New("a role with this name already exists")
+	ErrLastAdministrator   = errors.New("the tenant must keep at least one administrator")
+	ErrModuleNotFound      = errors.New("module not found")
+	ErrRBACUserNotFound    = errors.New("user not found in this tenant")
+	ErrPermissionDenied    = errors.New("permission denied")
+	ErrRestaurantNotFound  = errors.New("restaurant not found in this tenant")
+	ErrRestaurantAccess    = errors.New("you have no role in this restaurant")
+)
+
+// HasFullAccess reports whether this is the tenant's system ADMIN role, which grants
+// ADMIN on every module without any role_permissions rows
+func (r *Role) HasFullAccess() bool {
+	return r.IsSystemRole && r.RoleCode == RoleAdmin
+}
+
+// ModulePermissionRequest sets a role's permission level on one module
+type ModulePermissionRequest struct {
+	ModuleID        int64  `json:"module_id"`
+	PermissionLevel string `json:"permission_level"`
+}
+
+// Validate checks the module and permission level
+func (p *ModulePermissionRequest) Validate() error {
+	if p.ModuleID <= 0 {
+		return errors.New("module_id is required")
+	}
+	if _, ok := PermissionValues[p.PermissionLevel]; !ok {
+		return errors.New("invalid permission level")
+	}
+	return nil
+}
+
+// RBACRoleRequest creates or updates a role from the RBAC settings.
+// When Permissions is set it replaces all of the role's module permissions.
+type RBACRoleRequest struct {
+	RoleName    string                    `json:"role_name"`
+	Description string                    `json:"description"`
+	Permissions []ModulePermissionRequest `json:"permissions,omitempty"`
+}
+
+// Validate checks the role name and permissions
+func (req *RBACRoleRequest) Validate() error {
+	req.RoleName = strings.TrimSpace(req.RoleName)
+	if req.RoleName == "" {
+		return errors.New("role_name is required")
+	}
+	if len(req.RoleName) > 100 {
+		return errors.New("role_name must be less than 100 characters")
+	}
+	seen := make(map[int64]bool, len(req.Permissions))
+	for i := range req.Permissions {
+		if err := req.Permissions[i].Validate(); err != nil {
+			return err
+		}
+		if seen[req.Permissions[i].ModuleID] {
+			return fmt.Errorf("module %d is listed more than once", req.Permissions[i].ModuleID)
+		}
+		seen[req.Permissions[i].ModuleID] = true
+	}
+	return nil
+}
+
+// AssignUserRoleRequest gives a user a role in every restaurant of the tenant, or only in
+// the listed restaurants
+type AssignUserRoleRequest struct {
+	RoleID        int64   `json:"role_id"`
+	RestaurantIDs []int64 `json:"restaurant_ids,omitempty"`
+}
+
+// Validate checks the role and drops repeated restaurants
+func (req *AssignUserRoleRequest) Validate() error {
+	if req.RoleID <= 0 {
+		return errors.New("role_id is required")
+	}
+	seen := make(map[int64]bool, len(req.RestaurantIDs))
+	restaurantIDs := req.RestaurantIDs[:0]
+	for _, id := range req.RestaurantIDs {
+		if id <= 0 {
+			return errors.New("invalid restaurant ID")
+		}
+		if !seen[id] {
+			seen[id] = true
+			restaurantIDs = append(restaurantIDs, id)
+		}
+	}
+	req.RestaurantIDs = restaurantIDs
+	return nil
+}
+
+// RestaurantAccess is a restaurant a user can switch to
+type RestaurantAccess struct {
+	ID      int    `json:"id"`
+	Name    string `json:"name"`
+	Slug    string `json:"slug"`
+	Current bool   `json:"current"`
+}
+
+// RoleCodeFromName derives a role code from a role name, e.g. "Shift Lead" becomes "SHIFT_LEAD"
+func RoleCodeFromName(name string) string {
+	var b strings.Builder
+	underscore := false
+	for _, c := range strings.ToUpper(strings.TrimSpace(name)) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+			b.WriteRune(c)
+			underscore = false
+		case b.Len() > 0 && !underscore:
+			b.WriteByte('_')
+			underscore = true
+		}
+	}
+	code := strings.TrimSuffix(b.String(), "_")
+	if len(code) > 50 {
+		code = strings.TrimSuffix(code[:50], "_")
+	}
+	return code
+}
+
+// RBACRole is a role with its module permissions
+type RBACRole struct {
+	Role
+	ModulePermissions []RolePermission `json:"module_permissions"`
+}
+
+// UserPermissions maps module IDs to a user's effective permission level,
+// the highest level granted by any of the user's roles
+type UserPermissions map[int64]string
+
+// Grant raises the user's level on a module to level unless it is already higher
+func (p UserPermissions) Grant(moduleID int64, level string) {
+	if PermissionValues[level] > PermissionValues[p[moduleID]] {
+		p[moduleID] = level
+	}
+}
+
+// Level returns the user's level on a module, NONE when nothing is granted
+func (p UserPermissions) Level(moduleID int64) string {
+	if level, ok := p[moduleID]; ok {
+		return level
+	}
+	return PermissionNone
+}
+
+// Allows reports whether the user's level on a module meets the required level
+func (p UserPermissions) Allows(moduleID int64, required string) bool {
+	requiredValue, ok := PermissionValues[required]
+	if !ok {
+		return false
+	}
+	return PermissionValues[p.Level(moduleID)] >= requiredValue
+}
@@ -0,0 +1,138 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestRBACRoleRequestValidate tests the checks on a role created or updated from the RBAC settings
+func TestRBACRoleRequestValidate(t *testing.T) {
+	req := &RBACRoleRequest{
+		RoleName:    "  Shift Lead ",
+		Permissions: []ModulePermissionRequest{{ModuleID: 1, PermissionLevel: PermissionWrite}},
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if req.RoleName != "Shift Lead" {
+		t.Errorf("RoleName = %q, want it trimmed", req.RoleName)
+	}
+
+	tests := []struct {
+		name string
+		req  RBACRoleRequest
+	}{
+		{"missing name", RBACRoleRequest{RoleName: " "}},
+		{"long name", RBACRoleRequest{RoleName: strings.Repeat("a", 101)}},
+		{"missing module", RBACRoleRequest{RoleName: "Cook", Permissions: []ModulePermissionRequest{{PermissionLevel: PermissionRead}}}},
+		{"unknown level", RBACRoleRequest{RoleName: "Cook", Permissions: []ModulePermissionRequest{{ModuleID: 1, PermissionLevel: "OWNER"}}}},
+		{"duplicate module", RBACRoleRequest{RoleName: "Cook", Permissions: []ModulePermissionRequest{
+			{ModuleID: 1, PermissionLevel: PermissionRead},
+			{ModuleID: 1, PermissionLevel: PermissionWrite},
+		}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); err == nil {
+				t.Error("Validate() expected an error")
+			}
+		})
+	}
+}
+
+// TestRoleCodeFromName tests that role names become upper-case codes
+func TestRoleCodeFromName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Shift Lead", "SHIFT_LEAD"},
+		{"  bar-staff (evening) ", "BAR_STAFF_EVENING"},
+		{"Chef 2", "CHEF_2"},
+		{"Café manager", "CAF_MANAGER"},
+		{"!!!", ""},
+		{strings.Repeat("ab ", 30), "AB_AB_AB_AB_AB_AB_AB_AB_AB_AB_AB_AB_AB_AB_AB_AB_AB"},
+	}
+	for _, tt := range tests {
+		if got := RoleCodeFromName(tt.name); got != tt.want {
+			t.Errorf("RoleCodeFromName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestUserPermissions tests that a user gets the highest level granted by any role
+func TestUserPermissions(t *testing.T) {
+	perms := UserPermissions{}
+	perms.Grant(1, PermissionRead)
+	perms.Grant(1, PermissionDelete)
+	perms.Grant(1, PermissionWrite)
+	perms.Grant(2, PermissionNone)
+
+	if got := perms.Level(1); got != PermissionDelete {
+		t.Errorf("Level(1) = %q, want %q", got, PermissionDelete)
+	}
+	if got := perms.Level(3); got != PermissionNone {
+		t.Errorf("Level(3) = %q, want %q", got, PermissionNone)
+	}
+
+	tests := []struct {
+		moduleID int64
+		required string
+		want     bool
+	}{
+		{1, PermissionRead, true},
+		{1, PermissionDelete, true},
+		{1, PermissionAdmin, false},
+		{2, PermissionRead, false},
+		{3, PermissionRead, false},
+		{1, "OWNER", false},
+	}
+	for _, tt := range tests {
+		if got := perms.Allows(tt.moduleID, tt.required); got != tt.want {
+			t.Errorf("Allows(%d, %q) = %v, want %v", tt.moduleID, tt.required, got, tt.want)
+		}
+	}
+}
+
+// TestRoleHasFullAccess tests that only the system ADMIN role has full access
+func TestRoleHasFullAccess(t *testing.T) {
+	tests := []struct {
+		name string
+		role Role
+		want bool
+	}{
+		{"system admin", Role{RoleCode: RoleAdmin, IsSystemRole: true}, true},
+		{"custom admin code", Role{RoleCode: RoleAdmin}, false},
+		{"system manager", Role{RoleCode: RoleManager, IsSystemRole: true}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.HasFullAccess(); got != tt.want {
+			t.Errorf("%s: HasFullAccess() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestAssignUserRoleRequestValidate tests role assignments limited to restaurants
+func TestAssignUserRoleRequestValidate(t *testing.T) {
+	req := AssignUserRoleRequest{RoleID: 3, RestaurantIDs: []int64{2, 5, 2}}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if len(req.RestaurantIDs) != 2 || req.RestaurantIDs[0] != 2 || req.RestaurantIDs[1] != 5 {
+		t.Errorf("RestaurantIDs = %v, want [2 5]", req.RestaurantIDs)
+	}
+
+	tenantWide := AssignUserRoleRequest{RoleID: 3}
+	if err := tenantWide.Validate(); err != nil || len(tenantWide.RestaurantIDs) != 0 {
+		t.Errorf("tenant-wide assignment: err = %v, restaurants = %v", err, tenantWide.RestaurantIDs)
+	}
+
+	for name, bad := range map[string]AssignUserRoleRequest{
+		"missing role":   {RestaurantIDs: []int64{1}},
+		"bad restaurant": {RoleID: 3, RestaurantIDs: []int64{0}},
+	} {
+		if err := bad.Validate(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rota statuses
+const (
+	RotaStatusDraft     = "draft"
+	RotaStatusPublished = "published"
+)
+
+// Rota conflict types. Leave and overlap conflicts block publishing; the others are warnings.
+const (
+	ConflictLeave        = "leave"
+	ConflictOverlap      = "overlap"
+	ConflictMaxHours     = "max_hours"
+	ConflictUnderstaffed = "understaffed"
+)
+
+// LateGraceMinutes is how late an employee can clock in before being marked late
+const LateGraceMinutes = 5
+
+// DateLayout is the format of rota and shift dates in requests
+const DateLayout = "2006-01-02"
+
+// ShiftTemplate is a reusable set of shift times
+type ShiftTemplate struct {
+	ID           int       `json:"id"`
+	TenantID     int       `json:"tenant_id"`
+	RestaurantID int       `json:"restaurant_id"`
+	Name         string    `json:"name"`
+	StartTime    string    `json:"start_time"` // HH:MM
+	EndTime      string    `json:"end_time"`   // HH:MM, before start_time for overnight shifts
+	BreakMinutes int       `json:"break_minutes"`
+	RoleID       *int      `json:"role_id,omitempty"`
+	Color        string    `json:"color,omitempty"`
+	IsActive     bool      `json:"is_active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ShiftTemplateRequest creates or updates a shift template
+type ShiftTemplateRequest struct {
+	Name         string `json:"name" validate:"required"`
+	StartTime    string `json:"start_time" validate:"required"`
+	EndTime      string `json:"end_time" validate:"required"`
+	BreakMinutes int    `json:"break_minutes"`
+	RoleID       *int   `json:"role_id,omitempty"`
+	Color        string `json:"color,omitempty"`
+	IsActive     *bool  `json:"is_active,omitempty"`
+}
+
+// Validate checks the template name and times
+func (r *ShiftTemplateRequest) Validate() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return errors.New("name is required")
+	}
+	return validateShiftTimes(r.StartTime, r.EndTime, r.BreakMinutes)
+}
+
+// StaffingRequirement is the minimum number of employees of a role on shift
+// during a time window on a weekday
+type StaffingRequirement struct {
+	ID           int       `json:"id"`
+	TenantID     int       `json:"tenant_id"`
+	RestaurantID int       `json:"restaurant_id"`
+	RoleID       int       `json:"role_id"`
+	DayOfWeek    int       `json:"day_of_week"` // 0 = Sunday
+	StartTime    string    `json:"start_time"`
+	EndTime      string    `json:"end_time"`
+	MinStaff     int       `json:"min_staff"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// StaffingRequirementRequest creates a staffing requirement
+type StaffingRequirementRequest struct {
+	RoleID    int    `json:"role_id" validate:"required"`
+	DayOfWeek int    `json:"day_of_week"`
+	StartTime string `json:"start_time" validate:"required"`
+	EndTime   string `json:"end_time" validate:"required"`
+	MinStaff  int    `json:"min_staff" validate:"required"`
+}
+
+// Validate checks the requirement
+func (r *StaffingRequirementRequest) Validate() error {
+	if r.RoleID <= 0 {
+		return errors.New("role_id is required")
+	}
+	if r.DayOfWeek < 0 || r.DayOfWeek > 6 {
+		return errors.New("day_of_week must be between 0 (Sunday) and 6 (Saturday)")
+	}
+	if r.MinStaff <= 0 {
+		return errors.New("min_staff must be at least 1")
+	}
+	return validateShiftTimes(r.StartTime, r.EndTime, 0)
+}
+
+// Rota is the weekly shift schedule of a restaurant, starting on a Monday
+type Rota struct {
+	ID           int            `json:"id"`
+	TenantID     int            `json:"tenant_id"`
+	RestaurantID int            `json:"restaurant_id"`
+	WeekStart    time.Time      `json:"week_start"`
+	Status       string         `json:"status"` // 'draft', 'published'
+	Notes        string         `json:"notes,omitempty"`
+	PublishedAt  *time.Time     `json:"published_at,omitempty"`
+	PublishedBy  *int           `json:"published_by,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+	CreatedBy    *int           `json:"created_by,omitempty"`
+	Shifts       []Shift        `json:"shifts,omitempty"`
+	Conflicts    []RotaConflict `json:"conflicts,omitempty"`
+}
+
+// CreateRotaRequest starts a rota for a week; any date of the week can be given
+type CreateRotaRequest struct {
+	WeekStart string `json:"week_start" validate:"required"` // YYYY-MM-DD
+	Notes     string `json:"notes,omitempty"`
+}
+
+// PublishRotaRequest publishes a rota; Force publishes despite blocking conflicts
+type PublishRotaRequest struct {
+	Force bool `json:"force"`
+}
+
+// PublishRotaResponse is returned after publishing a rota
+type PublishRotaResponse struct {
+	Rota     *Rota `json:"rota"`
+	Notified int   `json:"notified"`
+}
+
+// Shift is an employee's scheduled shift in a rota
+type Shift struct {
+	ID           int       `json:"id"`
+	TenantID     int       `json:"tenant_id"`
+	RestaurantID int       `json:"restaurant_id"`
+	RotaID       int       `json:"rota_id"`
+	EmployeeID   int       `json:"employee_id"`
+	EmployeeName string    `json:"employee_name,omitempty"`
+	RoleID       *int      `json:"role_id,omitempty"`
+	TemplateID   *int      `json:"template_id,omitempty"`
+	ShiftDate    time.Time `json:"shift_date"`
+	StartTime    string    `json:"start_time"` // HH:MM
+	EndTime      string    `json:"end_time"`   // HH:MM, before start_time for overnight shifts
+	BreakMinutes int       `json:"break_minutes"`
+	Notes        string    `json:"notes,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// ShiftRequest adds or changes a shift. Times and role default to the template's when a template is given.
+type ShiftRequest struct {
+	EmployeeID   int    `json:"employee_id" validate:"required"`
+	ShiftDate    string `json:"shift_date" validate:"required"` // YYYY-MM-DD
+	TemplateID   *int   `json:"template_id,omitempty"`
+	RoleID       *int   `json:"role_id,omitempty"`
+	StartTime    string `json:"start_time,omitempty"`
+	EndTime      string `json:"end_time,omitempty"`
+	BreakMinutes *int   `json:"break_minutes,omitempty"`
+	Notes        string `json:"notes,omitempty"`
+}
+
+// RotaConflict is a scheduling problem found in a rota
+type RotaConflict struct {
+	Type       string `json:"type"`
+	Blocking   bool   `json:"blocking"`
+	EmployeeID int    `json:"employee_id,omitempty"`
+	ShiftID    int    `json:"shift_id,omitempty"`
+	RoleID     int    `json:"role_id,omitempty"`
+	Date       string `json:"date,omitempty"`
+	Message    string `json:"message"`
+}
+
+// Error definitions for rotas
+var (
+	ErrRotaNotFound          = errors.New("rota not found")
+	ErrShiftNotFound         = errors.New("shift not found")
+	ErrShiftTemplateNotFound = errors.New("shift template not found")
+	ErrRotaPublished         = errors.New("rota is published; unpublish it before changing shifts")
+	ErrRotaExists            = errors.New("a rota already exists for this week")
+	ErrShiftOutsideRota      = errors.New("shift date must be within the rota week")
+	ErrRotaHasConflicts      = errors.New("rota has blocking conflicts")
+	ErrInvalidShiftTime      = errors.New("invalid time, expected HH:MM")
+)
+
+// ParseClock parses an HH:MM (or HH:MM:SS) time of day into the offset from midnight
+func ParseClock(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	layout := "15:04"
+	if strings.Count(s, ":") == 2 {
+		layout = "15:04:05"
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return 0, ErrInvalidShiftTime
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute + time.Duration(t.Second())*time.Second, nil
+}
+
+// FormatClock normalizes a time of day to HH:MM
+func FormatClock(s string) string {
+	d, err := ParseClock(s)
+	if err != nil {
+		return s
+	}
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
+func validateShiftTimes(start, end string, breakMinutes int) error {
+	from, err := ParseClock(start)
+	if err != nil {
+		return err
+	}
+	to, err := ParseClock(end)
+	if err != nil {
+		return err
+	}
+	if from == to {
+		return errors.New("end_time cannot equal start_time")
+	}
+	if breakMinutes < 0 {
+		return errors.New("break_minutes cannot be negative")
+	}
+	length := to - from
+	if length < 0 {
+		length += 24 * time.Hour
+	}
+	if time.Duration(breakMinutes)*time.Minute >= length {
+		return errors.New("break_minutes must be shorter than the shift")
+	}
+	return nil
+}
+
+// clockWindow returns the start and end of a time window on a date; windows ending at or before
+// their start run into the next day
+func clockWindow(date time.Time, start, end string) (time.Time, time.Time) {
+	from, _ := ParseClock(start)
+	to, _ := ParseClock(end)
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	if to <= from {
+		to += 24 * time.Hour
+	}
+	return day.Add(from), day.Add(to)
+}
+
+// WeekStartOf returns the Monday of the week containing date
+func WeekStartOf(date time.Time) time.Time {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	offset := (int(day.Weekday()) + 6) % 7 // days since Monday
+	return day.AddDate(0, 0, -offset)
+}
+
+// Bounds returns when the shift starts and ends
+func (s *Shift) Bounds() (time.Time, time.Time) {
+	return clockWindow(s.ShiftDate, s.StartTime, s.EndTime)
+}
+
+// Hours returns the paid length of the shift, breaks excluded
+func (s *Shift) Hours() float64 {
+	start, end := s.Bounds()
+	return end.Sub(start).Hours() - float64(s.BreakMinutes)/60
+}
+
+// NewShift builds a shift from a request, filling times and role from the template when given.
+// The shift date is interpreted in loc.
+func NewShift(req *ShiftRequest, template *ShiftTemplate, loc *time.Location) (*Shift, error) {
+	if req.EmployeeID <= 0 {
+		return nil, errors.New("employee_id is required")
+	}
+	date, err := time.ParseInLocation(DateLayout, req.ShiftDate, loc)
+	if err != nil {
+		return nil, errors.New("invalid shift_date, expected YYYY-MM-DD")
+	}
+
+	shift := &Shift{EmployeeID: req.EmployeeID, ShiftDate: date, RoleID: req.RoleID, Notes: req.Notes}
+	if template != nil {
+		shift.TemplateID = &template.ID
+		shift.StartTime, shift.EndTime, shift.BreakMinutes = template.StartTime, template.EndTime, template.BreakMinutes
+		if shift.RoleID == nil {
+			shift.RoleID = template.RoleID
+		}
+	}
+	if req.StartTime != "" {
+		shift.StartTime = req.StartTime
+	}
+	if req.EndTime != "" {
+		shift.EndTime = req.EndTime
+	}
+	if req.BreakMinutes != nil {
+		shift.BreakMinutes = *req.BreakMinutes
+	}
+	if shift.StartTime == "" || shift.EndTime == "" {
+		return nil, errors.New("start_time and end_time are required without a template")
+	}
+	if err := validateShiftTimes(shift.StartTime, shift.EndTime, shift.BreakMinutes); err != nil {
+		return nil, err
+	}
+	shift.StartTime, shift.EndTime = FormatClock(shift.StartTime), FormatClock(shift.EndTime)
+	return shift, nil
+}
+
+// DetectRotaConflicts checks a week of shifts against approved leave, overlapping shifts,
+// each employee's contracted weekly hours and the restaurant's staffing requirements
+func DetectRotaConflicts(weekStart time.Time, shifts []Shift, employees map[int]*Employee, leaves []Leave, requirements []StaffingRequirement) []RotaConflict {
+	conflicts := []RotaConflict{}
+
+	byEmployee := map[int][]Shift{}
+	for _, s := range shifts {
+		byEmployee[s.EmployeeID] = append(byEmployee[s.EmployeeID], s)
+	}
+	employeeIDs := make([]int, 0, len(byEmployee))
+	for id := range byEmployee {
+		employeeIDs = append(employeeIDs, id)
+	}
+	sort.Ints(employeeIDs)
+
+	for _, employeeID := range employeeIDs {
+		list := byEmployee[employeeID]
+		sort.Slice(list, func(i, j int) bool {
+			a, _ := list[i].Bounds()
+			b, _ := list[j].Bounds()
+			return a.Before(b)
+		})
+
+		hours := 0.0
+		for i, s := range list {
+			hours += s.Hours()
+			date := s.ShiftDate.Format(DateLayout)
+
+			if i > 0 {
+				_, prevEnd := list[i-1].Bounds()
+				if start, _ := s.Bounds(); start.Before(prevEnd) {
+					conflicts = append(conflicts, RotaConflict{
+						Type: ConflictOverlap, Blocking: true, EmployeeID: employeeID, ShiftID: s.ID, Date: date,
+						Message: fmt.Sprintf("shift overlaps the employee's %s-%s shift", list[i-1].StartTime, list[i-1].EndTime),
+					})
+				}
+			}
+
+			for _, l := range leaves {
+				if l.EmployeeID == employeeID && l.Status == "approved" && onOrBetween(s.ShiftDate, l.StartDate, l.EndDate) {
+					conflicts = append(conflicts, RotaConflict{
+						Type: ConflictLeave, Blocking: true, EmployeeID: employeeID, ShiftID: s.ID, Date: date,
+						Message: fmt.Sprintf("employee is on approved %s leave", l.LeaveType),
+					})
+					break
+				}
+			}
+		}
+
+		if e := employees[employeeID]; e != nil && e.WorkingHoursPerWeek > 0 && hours > e.WorkingHoursPerWeek {
+			conflicts = append(conflicts, RotaConflict{
+				Type: ConflictMaxHours, EmployeeID: employeeID,
+				Message: fmt.Sprintf("scheduled for %.1f hours, more than the %.1f contracted weekly hours", hours, e.WorkingHoursPerWeek),
+			})
+		}
+	}
+
+	for _, req := range requirements {
+		for d := 0; d < 7; d++ {
+			date := weekStart.AddDate(0, 0, d)
+			if int(date.Weekday()) != req.DayOfWeek {
+				continue
+			}
+			from, to := clockWindow(date, req.StartTime, req.EndTime)
+			staffed := 0
+			for _, s := range shifts {
+				if s.RoleID == nil || *s.RoleID != req.RoleID {
+					continue
+				}
+				if start, end := s.Bounds(); !start.After(from) && !end.Before(to) {
+					staffed++
+				}
+			}
+			if staffed < req.MinStaff {
+				conflicts = append(conflicts, RotaConflict{
+					Type: ConflictUnderstaffed, RoleID: req.RoleID, Date: date.Format(DateLayout),
+					Message: fmt.Sprintf("%d of %d required staff scheduled for %s-%s", staffed, req.MinStaff, FormatClock(req.StartTime), FormatClock(req.EndTime)),
+				})
+			}
+		}
+	}
+	return conflicts
+}
+
+// HasBlockingConflicts reports whether any conflict prevents publishing
+func HasBlockingConflicts(conflicts []RotaConflict) bool {
+	for _, c := range conflicts {
+		if c.Blocking {
+			return true
+		}
+	}
+	return false
+}
+
+// onOrBetween reports whether date falls within the calendar days from start to end
+func onOrBetween(date, start, end time.Time) bool {
+	d := date.Format(DateLayout)
+	return d >= start.Format(DateLayout) && d <= end.Format(DateLayout)
+}
+
+// ApplyShiftSchedule sets an attendance record's scheduled times from a published shift
+// and derives lateness and early departure from its clock times
+func ApplyShiftSchedule(att *Attendance, shift *Shift) {
+	start, end := shift.Bounds()
+	scheduledIn, scheduledOut := shift.StartTime, shift.EndTime
+	att.ScheduledClockIn, att.ScheduledClockOut = &scheduledIn, &scheduledOut
+
+	att.IsLate, att.LateByMinutes = false, 0
+	if att.ClockIn != nil {
+		if late := int(att.ClockIn.Sub(start).Minutes()); late > LateGraceMinutes {
+			att.IsLate, att.LateByMinutes = true, late
+		}
+	}
+
+	att.IsEarlyDeparture, att.EarlyDepartureMinutes = false, 0
+	if att.ClockOut != nil {
+		if early := int(end.Sub(*att.ClockOut).Minutes()); early > 0 {
+			att.IsEarlyDeparture, att.EarlyDepartureMinutes = true, early
+		}
+	}
+
+	if att.Status == "present" && att.IsLate {
+		att.Status = "late"
+	} else if att.Status == "late" && !att.IsLate {
+		att.Status = "present"
+	}
+}
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestClockParsing tests parsing and normalizing times of day
+func TestClockParsing(t *testing.T) {
+	if d, err := ParseClock("08:30"); err != nil || d != 8*time.Hour+30*time.Minute {
+		t.Errorf("ParseClock(08:30) = %v, %v", d, err)
+	}
+	if got := FormatClock("22:00:00"); got != "22:00" {
+		t.Errorf("FormatClock(22:00:00) = %q, want 22:00", got)
+	}
+	if _, err := ParseClock("25:00"); err != ErrInvalidShiftTime {
+		t.Errorf("expected ErrInvalidShiftTime, got %v", err)
+	}
+}
+
+// TestWeekStartOf tests that dates resolve to the Monday of their week
+func TestWeekStartOf(t *testing.T) {
+	monday := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	for _, d := range []time.Time{monday, time.Date(2024, 6, 5, 15, 0, 0, 0, time.UTC), time.Date(2024, 6, 9, 23, 0, 0, 0, time.UTC)} {
+		if got := WeekStartOf(d); !got.Equal(monday) {
+			t.Errorf("WeekStartOf(%v) = %v, want %v", d, got, monday)
+		}
+	}
+}
+
+// TestNewShift tests building shifts from templates and overrides
+func TestNewShift(t *testing.T) {
+	template := &ShiftTemplate{ID: 3, StartTime: "22:00", EndTime: "06:00", BreakMinutes: 30, RoleID: intPtr(2)}
+
+	shift, err := NewShift(&ShiftRequest{EmployeeID: 1, ShiftDate: "2024-06-03", TemplateID: intPtr(3)}, template, time.UTC)
+	if err != nil {
+		t.Fatalf("NewShift() error = %v", err)
+	}
+	if shift.RoleID == nil || *shift.RoleID != 2 || shift.StartTime != "22:00" {
+		t.Errorf("expected the template's role and times, got %+v", shift)
+	}
+	if got := shift.Hours(); got != 7.5 {
+		t.Errorf("Hours() = %v, want 7.5 for an overnight shift", got)
+	}
+
+	if _, err := NewShift(&ShiftRequest{EmployeeID: 1, ShiftDate: "2024-06-03"}, nil, time.UTC); err == nil {
+		t.Error("expected times to be required without a template")
+	}
+	if _, err := NewShift(&ShiftRequest{EmployeeID: 1, ShiftDate: "2024-06-03", StartTime: "09:00", EndTime: "10:00", BreakMinutes: intPtr(60)}, nil, time.UTC); err == nil {
+		t.Error("expected a break as long as the shift to be rejected")
+	}
+}
+
+// TestDetectRotaConflicts tests leave, overlap, hours and staffing checks
+func TestDetectRotaConflicts(t *testing.T) {
+	weekStart := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	day := func(d int) time.Time { return weekStart.AddDate(0, 0, d) }
+	cook := intPtr(5)
+
+	shifts := []Shift{
+		{ID: 1, EmployeeID: 1, RoleID: cook, ShiftDate: day(0), StartTime: "08:00", EndTime: "16:00"},
+		{ID: 2, EmployeeID: 1, RoleID: cook, ShiftDate: day(0), StartTime: "15:00", EndTime: "20:00"},
+		{ID: 3, EmployeeID: 2, RoleID: cook, ShiftDate: day(2), StartTime: "08:00", EndTime: "16:00"},
+	}
+	employees := map[int]*Employee{
+		1: {ID: 1, WorkingHoursPerWeek: 10},
+		2: {ID: 2, WorkingHoursPerWeek: 40},
+	}
+	leaves := []Leave{{EmployeeID: 2, Status: "approved", LeaveType: "annual", StartDate: day(1), EndDate: day(3)}}
+	requirements := []StaffingRequirement{
+		{RoleID: 5, DayOfWeek: int(time.Monday), StartTime: "09:00", EndTime: "12:00", MinStaff: 1},
+		{RoleID: 5, DayOfWeek: int(time.Tuesday), StartTime: "09:00", EndTime: "12:00", MinStaff: 1},
+	}
+
+	conflicts := DetectRotaConflicts(weekStart, shifts, employees, leaves, requirements)
+
+	found := map[string]RotaConflict{}
+	for _, c := range conflicts {
+		found[c.Type] = c
+	}
+	if c, ok := found[ConflictOverlap]; !ok || c.ShiftID != 2 || !c.Blocking {
+		t.Errorf("expected a blocking overlap on shift 2, got %+v", conflicts)
+	}
+	if c, ok := found[ConflictLeave]; !ok || c.ShiftID != 3 || !c.Blocking {
+		t.Errorf("expected a blocking leave conflict on shift 3, got %+v", conflicts)
+	}
+	if c, ok := found[ConflictMaxHours]; !ok || c.EmployeeID != 1 || c.Blocking {
+		t.Errorf("expected a max hours warning for employee 1, got %+v", conflicts)
+	}
+	if c, ok := found[ConflictUnderstaffed]; !ok || c.Date != "2024-06-04" {
+		t.Errorf("expected Tuesday to be understaffed, got %+v", conflicts)
+	}
+	if len(conflicts) != 4 {
+		t.Errorf("expected 4 conflicts, got %+v", conflicts)
+	}
+	if !HasBlockingConflicts(conflicts) {
+		t.Error("expected blocking conflicts")
+	}
+}
+
+// TestApplyShiftSchedule tests lateness and early departure against a shift
+func TestApplyShiftSchedule(t *testing.T) {
+	date := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	shift := &Shift{ShiftDate: date, StartTime: "09:00", EndTime: "17:00"}
+	at := func(h, m int) *time.Time {
+		v := time.Date(2024, 6, 3, h, m, 0, 0, time.UTC)
+		return &v
+	}
+
+	tests := []struct {
+		name      string
+		clockIn   *time.Time
+		clockOut  *time.Time
+		late      int
+		early     int
+		status    string
+		oldStatus string
+	}{
+		{"on time", at(8, 55), nil, 0, 0, "present", "present"},
+		{"within grace", at(9, 4), nil, 0, 0, "present", "present"},
+		{"late", at(9, 20), nil, 20, 0, "late", "present"},
+		{"left early", at(9, 0), at(16, 30), 0, 30, "present", "late"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			att := &Attendance{ClockIn: tt.clockIn, ClockOut: tt.clockOut, Status: tt.oldStatus}
+			ApplyShiftSchedule(att, shift)
+			if att.LateByMinutes != tt.late || att.IsLate != (tt.late > 0) {
+				t.Errorf("late = %v/%d, want %d", att.IsLate, att.LateByMinutes, tt.late)
+			}
+			if att.EarlyDepartureMinutes != tt.early || att.IsEarlyDeparture != (tt.early > 0) {
+				t.Errorf("early = %v/%d, want %d", att.IsEarlyDeparture, att.EarlyDepartureMinutes, tt.early)
+			}
+			if att.Status != tt.status {
+				t.Errorf("status = %q, want %q", att.Status, tt.status)
+			}
+			if att.ScheduledClockIn == nil || *att.ScheduledClockIn != "09:00" {
+				t.Errorf("expected scheduled clock in 09:00, got %v", att.ScheduledClockIn)
+			}
+		})
+	}
+}
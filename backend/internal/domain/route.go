@@ -0,0 +1,177 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// Batching and routing defaults
+const (
+	// MaxBatchSize is the most orders a driver carries in one run
+	MaxBatchSize = MaxDriverActiveOrders
+	// BatchRadiusKm is the furthest two drop-offs can be apart to be batched
+	BatchRadiusKm = 2.5
+	// BatchPromiseWindow is the largest gap between the promised times of batched orders
+	BatchPromiseWindow = 20 * time.Minute
+	// DropOffServiceTime is the time spent handing over an order at each stop
+	DropOffServiceTime = 3 * time.Minute
+	// RouteRefreshInterval is the minimum time between route recomputations from location updates
+	RouteRefreshInterval = 30 * time.Second
+)
+
+// RoutePoint is a drop-off location to be sequenced
+type RoutePoint struct {
+	OrderID   int64
+	Latitude  float64
+	Longitude float64
+}
+
+// RouteStop is a drop-off in a driver's planned route
+type RouteStop struct {
+	Sequence    int        `json:"sequence"`
+	OrderID     int64      `json:"order_id"`
+	OrderNumber string     `json:"order_number,omitempty"`
+	Latitude    float64    `json:"latitude,omitempty"`
+	Longitude   float64    `json:"longitude,omitempty"`
+	LegKm       float64    `json:"leg_km"`
+	ETA         *time.Time `json:"eta,omitempty"`
+}
+
+// DeliveryRoute is the optimized drop-off sequence of a driver's active orders
+type DeliveryRoute struct {
+	DriverID       int         `json:"driver_id"`
+	StartLatitude  *float64    `json:"start_latitude,omitempty"`
+	StartLongitude *float64    `json:"start_longitude,omitempty"`
+	ViaRestaurant  bool        `json:"via_restaurant"` // some orders still have to be picked up
+	TotalKm        float64     `json:"total_km"`
+	Stops          []RouteStop `json:"stops"`
+	ComputedAt     time.Time   `json:"computed_at"`
+}
+
+// HasDropOffLocation reports whether an order has delivery coordinates
+func (o *Order) HasDropOffLocation() bool {
+	return o.DeliveryLatitude != 0 || o.DeliveryLongitude != 0
+}
+
+// BatchCompatible reports whether two delivery orders can be carried by the same driver:
+// same restaurant, drop-offs close to each other and promised times close together.
+func BatchCompatible(a, b *Order) bool {
+	if a.ID == b.ID || a.TenantID != b.TenantID || a.RestaurantID != b.RestaurantID {
+		return false
+	}
+	if !a.NeedsDelivery() || !b.NeedsDelivery() || !a.HasDropOffLocation() || !b.HasDropOffLocation() {
+		return false
+	}
+	if HaversineKm(a.DeliveryLatitude, a.DeliveryLongitude, b.DeliveryLatitude, b.DeliveryLongitude) > BatchRadiusKm {
+		return false
+	}
+	if a.EstimatedDeliveryTime != nil && b.EstimatedDeliveryTime != nil {
+		gap := a.EstimatedDeliveryTime.Sub(*b.EstimatedDeliveryTime)
+		if gap < 0 {
+			gap = -gap
+		}
+		if gap > BatchPromiseWindow {
+			return false
+		}
+	}
+	return true
+}
+
+// OptimizeRoute orders drop-offs for a driver leaving from the start position.
+// It builds a nearest-neighbour tour and improves it with 2-opt over haversine distances.
+// The route is open: it ends at the last drop-off.
+func OptimizeRoute(startLat, startLon float64, points []RoutePoint) []RoutePoint {
+	if len(points) < 2 {
+		return append([]RoutePoint(nil), points...)
+	}
+
+	// Nearest neighbour
+	remaining := append([]RoutePoint(nil), points...)
+	route := make([]RoutePoint, 0, len(points))
+	lat, lon := startLat, startLon
+	for len(remaining) > 0 {
+		best := 0
+		bestKm := math.Inf(1)
+		for i, p := range remaining {
+			if km := HaversineKm(lat, lon, p.Latitude, p.Longitude); km < bestKm {
+				best, bestKm = i, km
+			}
+		}
+		next := remaining[best]
+		route = append(route, next)
+		remaining = append(remaining[:best], remaining[best+1:]...)
+		lat, lon = next.Latitude, next.Longitude
+	}
+
+	// 2-opt: reverse route[i..k] while that shortens the path
+	dist := func(i, j int) float64 {
+		// Index -1 is the start position
+		aLat, aLon := startLat, startLon
+		if i >= 0 {
+			aLat, aLon = route[i].Latitude, route[i].Longitude
+		}
+		return HaversineKm(aLat, aLon, route[j].Latitude, route[j].Longitude)
+	}
+	const epsilon = 1e-9
+	for improved := true; improved; {
+		improved = false
+		for i := 0; i < len(route)-1; i++ {
+			for k := i + 1; k < len(route); k++ {
+				delta := dist(i-1, k) - dist(i-1, i)
+				if k+1 < len(route) {
+					delta += dist(i, k+1) - dist(k, k+1)
+				}
+				if delta < -epsilon {
+					for a, b := i, k; a < b; a, b = a+1, b-1 {
+						route[a], route[b] = route[b], route[a]
+					}
+					improved = true
+				}
+			}
+		}
+	}
+	return route
+}
+
+// RouteDistanceKm returns the length of a route from the start position through the points in order
+func RouteDistanceKm(startLat, startLon float64, points []RoutePoint) float64 {
+	total := 0.0
+	lat, lon := startLat, startLon
+	for _, p := range points {
+		total += HaversineKm(lat, lon, p.Latitude, p.Longitude)
+		lat, lon = p.Latitude, p.Longitude
+	}
+	return total
+}
+
+// PlanStops turns a sequenced route into stops with arrival times.
+// Each leg is driven at speedKmh and every stop adds the drop-off service time before the next leg.
+func PlanStops(startLat, startLon float64, points []RoutePoint, departAt time.Time, speedKmh float64) []RouteStop {
+	stops := make([]RouteStop, 0, len(points))
+	lat, lon := startLat, startLon
+	at := departAt
+	for i, p := range points {
+		km := HaversineKm(lat, lon, p.Latitude, p.Longitude)
+		at = at.Add(TravelTime(km, speedKmh))
+		eta := at
+		stops = append(stops, RouteStop{
+			Sequence:  i + 1,
+			OrderID:   p.OrderID,
+			Latitude:  p.Latitude,
+			Longitude: p.Longitude,
+			LegKm:     math.Round(km*100) / 100,
+			ETA:       &eta,
+		})
+		at = at.Add(DropOffServiceTime)
+		lat, lon = p.Latitude, p.Longitude
+	}
+	return stops
+}
+
+// TravelTime returns how long it takes to drive a distance at the given speed
+func TravelTime(km, speedKmh float64) time.Duration {
+	if speedKmh <= 0 {
+		return 0
+	}
+	return time.Duration(km / speedKmh * float64(time.Hour))
+}
@@ -0,0 +1,119 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestBatchCompatible tests which delivery orders can share a driver's run
+func TestBatchCompatible(t *testing.T) {
+	promised := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	later := promised.Add(45 * time.Minute)
+	soon := promised.Add(10 * time.Minute)
+
+	base := Order{ID: 1, TenantID: 1, RestaurantID: 1, OrderSource: OrderSourceWebsite,
+		DeliveryLatitude: 30.0444, DeliveryLongitude: 31.2357, EstimatedDeliveryTime: &promised}
+
+	tests := []struct {
+		name     string
+		other    Order
+		expected bool
+	}{
+		{"nearby, similar promise", Order{ID: 2, TenantID: 1, RestaurantID: 1, OrderSource: OrderSourceWebsite,
+			DeliveryLatitude: 30.0500, DeliveryLongitude: 31.2400, EstimatedDeliveryTime: &soon}, true},
+		{"too far apart", Order{ID: 3, TenantID: 1, RestaurantID: 1, OrderSource: OrderSourceWebsite,
+			DeliveryLatitude: 30.1000, DeliveryLongitude: 31.3000}, false},
+		{"promised much later", Order{ID: 4, TenantID: 1, RestaurantID: 1, OrderSource: OrderSourceWebsite,
+			DeliveryLatitude: 30.0450, DeliveryLongitude: 31.2360, EstimatedDeliveryTime: &later}, false},
+		{"other restaurant", Order{ID: 5, TenantID: 1, RestaurantID: 2, OrderSource: OrderSourceWebsite,
+			DeliveryLatitude: 30.0450, DeliveryLongitude: 31.2360}, false},
+		{"no drop-off coordinates", Order{ID: 6, TenantID: 1, RestaurantID: 1, OrderSource: OrderSourceWebsite,
+			DeliveryAddress: "12 Nile St"}, false},
+		{"same order", base, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BatchCompatible(&base, &tt.other); got != tt.expected {
+				t.Errorf("BatchCompatible() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestOptimizeRoute tests drop-off sequencing
+func TestOptimizeRoute(t *testing.T) {
+	// Stops along a line east of the start, given out of order
+	points := []RoutePoint{
+		{OrderID: 3, Latitude: 30.0, Longitude: 31.03},
+		{OrderID: 1, Latitude: 30.0, Longitude: 31.01},
+		{OrderID: 2, Latitude: 30.0, Longitude: 31.02},
+	}
+	route := OptimizeRoute(30.0, 31.0, points)
+	if len(route) != 3 || route[0].OrderID != 1 || route[1].OrderID != 2 || route[2].OrderID != 3 {
+		t.Errorf("expected stops [1 2 3], got %+v", route)
+	}
+	if points[0].OrderID != 3 {
+		t.Error("expected input slice to be left unchanged")
+	}
+}
+
+// TestOptimizeRouteImprovesNearestNeighbour tests that 2-opt removes a detour left by nearest neighbour
+func TestOptimizeRouteImprovesNearestNeighbour(t *testing.T) {
+	// Nearest neighbour goes to the close stop west of the start first, then has to cross back east
+	points := []RoutePoint{
+		{OrderID: 1, Latitude: 30.0, Longitude: 30.995},
+		{OrderID: 2, Latitude: 30.0, Longitude: 31.006},
+		{OrderID: 3, Latitude: 30.0, Longitude: 31.02},
+		{OrderID: 4, Latitude: 30.0, Longitude: 30.97},
+	}
+
+	naive := RouteDistanceKm(30.0, 31.0, []RoutePoint{points[0], points[1], points[2], points[3]})
+	route := OptimizeRoute(30.0, 31.0, points)
+	optimized := RouteDistanceKm(30.0, 31.0, route)
+
+	if optimized >= naive {
+		t.Errorf("expected optimized route shorter than %.2f km, got %.2f km", naive, optimized)
+	}
+	if len(route) != len(points) {
+		t.Fatalf("expected %d stops, got %d", len(points), len(route))
+	}
+}
+
+// TestPlanStops tests ETAs along a route
+func TestPlanStops(t *testing.T) {
+	depart := time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	points := []RoutePoint{
+		{OrderID: 1, Latitude: 30.0, Longitude: 31.0},
+		{OrderID: 2, Latitude: 30.0, Longitude: 31.0},
+	}
+
+	// Starting at the first drop-off: no travel, then one service stop before the second
+	stops := PlanStops(30.0, 31.0, points, depart, AverageDeliverySpeedKmh)
+	if len(stops) != 2 || stops[0].Sequence != 1 || stops[1].Sequence != 2 {
+		t.Fatalf("unexpected stops: %+v", stops)
+	}
+	if !stops[0].ETA.Equal(depart) {
+		t.Errorf("expected first ETA %v, got %v", depart, stops[0].ETA)
+	}
+	if want := depart.Add(DropOffServiceTime); !stops[1].ETA.Equal(want) {
+		t.Errorf("expected second ETA %v, got %v", want, stops[1].ETA)
+	}
+
+	if d := TravelTime(25, 25); d != time.Hour {
+		t.Errorf("expected 1h to drive 25 km at 25 km/h, got %v", d)
+	}
+}
+
+// TestEstimateETAMinutesUsesRouteETA tests that a batched order keeps its later route ETA
+func TestEstimateETAMinutesUsesRouteETA(t *testing.T) {
+	now := time.Now()
+	lat, lon := 30.0444, 31.2357
+	routed := now.Add(30 * time.Minute)
+
+	order := &Order{Status: "out_for_delivery", DeliveryLatitude: 30.0444, DeliveryLongitude: 31.2400, EstimatedDeliveryTime: &routed}
+	eta := EstimateETAMinutes(order, &TrackingDriver{Latitude: &lat, Longitude: &lon}, now)
+	if eta == nil || *eta != 30 {
+		t.Errorf("expected route ETA of 30 minutes, got %v", eta)
+	}
+}
@@ -0,0 +1,235 @@
+package domain
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Dietary flags exposed as search filters and facets
+const (
+	DietaryVegetarian = "vegetarian"
+	DietaryVegan      = "vegan"
+	DietaryGlutenFree = "gluten_free"
+	DietarySpicy      = "spicy"
+)
+
+// ProductSearchRequest holds public menu search parameters
+type ProductSearchRequest struct {
+	Query            string   `json:"q"`
+	Lang             string   `json:"lang"`
+	CategoryID       *int     `json:"category_id,omitempty"`
+	Dietary          []string `json:"dietary,omitempty"`           // e.g. ["vegan", "gluten_free"]
+	ExcludeAllergens []string `json:"exclude_allergens,omitempty"` // e.g. ["peanuts"]
+	Limit            int      `json:"limit"`
+}
+
+// ProductSearchHit is a matched product with its relevance score
+type ProductSearchHit struct {
+	Product        Product `json:"product"`
+	Rank           float64 `json:"rank"`
+	CategoryName   string  `json:"category_name,omitempty"`
+	CategoryNameAr string  `json:"category_name_ar,omitempty"`
+}
+
+// FacetCount is a single facet bucket
+type FacetCount struct {
+	Value   string `json:"value"`
+	Label   string `json:"label,omitempty"`
+	LabelAr string `json:"label_ar,omitempty"`
+	Count   int    `json:"count"`
+}
+
+// SearchFacets groups the facet buckets returned with search results
+type SearchFacets struct {
+	Categories []FacetCount `json:"categories"`
+	Dietary    []FacetCount `json:"dietary"`
+	Allergens  []FacetCount `json:"allergens"`
+}
+
+// ProductSearchResult is the response of a menu search
+type ProductSearchResult struct {
+	Query    string       `json:"query"`
+	Total    int          `json:"total"`
+	Products []Product    `json:"products"`
+	Facets   SearchFacets `json:"facets"`
+}
+
+// SearchSuggestion is an autocomplete entry
+type SearchSuggestion struct {
+	ProductID int    `json:"product_id"`
+	Text      string `json:"text"`
+	Lang      string `json:"lang"`
+}
+
+// arabicFolding maps Arabic letter variants to their normalized form
+var arabicFolding = map[rune]rune{
+	'أ': 'ا',
+	'إ': 'ا',
+	'آ': 'ا',
+	'ٱ': 'ا',
+	'ى': 'ي',
+	'ة': 'ه',
+}
+
+// NormalizeArabic mirrors the normalize_arabic SQL function: it strips diacritics
+// and tatweel and folds alef, yaa and taa marbuta variants
+func NormalizeArabic(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if (r >= 'ً' && r <= 'ْ') || r == 'ٰ' || r == 'ـ' {
+			continue
+		}
+		if folded, ok := arabicFolding[r]; ok {
+			r = folded
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// NormalizeSearchQuery lowercases, normalizes Arabic and collapses whitespace
+func NormalizeSearchQuery(q string) string {
+	return strings.Join(strings.Fields(strings.ToLower(NormalizeArabic(q))), " ")
+}
+
+// BuildPrefixTSQuery builds a prefix-matching tsquery ("chick:* & tik:*") from free text.
+// Only letters and digits are kept so the result is always a valid tsquery.
+func BuildPrefixTSQuery(q string) string {
+	terms := strings.FieldsFunc(NormalizeSearchQuery(q), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+	return strings.Join(terms, " & ")
+}
+
+// ParseAllergens decodes the allergens column (JSON array or comma separated list)
+func ParseAllergens(raw string) []string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return []string{}
+	}
+
+	var allergens []string
+	if err := json.Unmarshal([]byte(raw), &allergens); err != nil {
+		allergens = strings.Split(raw, ",")
+	}
+
+	result := make([]string, 0, len(allergens))
+	for _, a := range allergens {
+		if a = strings.ToLower(strings.TrimSpace(a)); a != "" {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// MatchesDietary reports whether a product satisfies a dietary flag
+func (p *Product) MatchesDietary(flag string) bool {
+	switch flag {
+	case DietaryVegetarian:
+		return p.IsVegetarian || p.IsVegan
+	case DietaryVegan:
+		return p.IsVegan
+	case DietaryGlutenFree:
+		return p.IsGlutenFree
+	case DietarySpicy:
+		return p.IsSpicy
+	}
+	return false
+}
+
+// ContainsAnyAllergen reports whether a product contains any of the given allergens
+func (p *Product) ContainsAnyAllergen(allergens []string) bool {
+	for _, a := range p.Allergens {
+		for _, excluded := range allergens {
+			if strings.EqualFold(a, strings.TrimSpace(excluded)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// FilterSearchHits applies category, dietary and allergen filters to search hits
+func FilterSearchHits(hits []ProductSearchHit, req *ProductSearchRequest) []ProductSearchHit {
+	filtered := make([]ProductSearchHit, 0, len(hits))
+	for _, hit := range hits {
+		if req.CategoryID != nil && hit.Product.CategoryID != *req.CategoryID {
+			continue
+		}
+		matches := true
+		for _, flag := range req.Dietary {
+			if !hit.Product.MatchesDietary(flag) {
+				matches = false
+				break
+			}
+		}
+		if !matches || hit.Product.ContainsAnyAllergen(req.ExcludeAllergens) {
+			continue
+		}
+		filtered = append(filtered, hit)
+	}
+	return filtered
+}
+
+// BuildSearchFacets counts categories, dietary flags and allergens across search hits
+func BuildSearchFacets(hits []ProductSearchHit) SearchFacets {
+	facets := SearchFacets{
+		Categories: []FacetCount{},
+		Dietary:    []FacetCount{},
+		Allergens:  []FacetCount{},
+	}
+
+	categoryIndex := make(map[int]int)
+	allergenCounts := make(map[string]int)
+	dietaryCounts := make(map[string]int)
+
+	for _, hit := range hits {
+		p := hit.Product
+		if i, ok := categoryIndex[p.CategoryID]; ok {
+			facets.Categories[i].Count++
+		} else {
+			categoryIndex[p.CategoryID] = len(facets.Categories)
+			facets.Categories = append(facets.Categories, FacetCount{
+				Value:   strconv.Itoa(p.CategoryID),
+				Label:   hit.CategoryName,
+				LabelAr: hit.CategoryNameAr,
+				Count:   1,
+			})
+		}
+
+		for _, flag := range []string{DietaryVegetarian, DietaryVegan, DietaryGlutenFree, DietarySpicy} {
+			if p.MatchesDietary(flag) {
+				dietaryCounts[flag]++
+			}
+		}
+		for _, a := range p.Allergens {
+			allergenCounts[a]++
+		}
+	}
+
+	for _, flag := range []string{DietaryVegetarian, DietaryVegan, DietaryGlutenFree, DietarySpicy} {
+		if dietaryCounts[flag] > 0 {
+			facets.Dietary = append(facets.Dietary, FacetCount{Value: flag, Count: dietaryCounts[flag]})
+		}
+	}
+	for a, count := range allergenCounts {
+		facets.Allergens = append(facets.Allergens, FacetCount{Value: a, Count: count})
+	}
+
+	sort.SliceStable(facets.Categories, func(i, j int) bool {
+		return facets.Categories[i].Count > facets.Categories[j].Count
+	})
+	sort.Slice(facets.Allergens, func(i, j int) bool {
+		if facets.Allergens[i].Count != facets.Allergens[j].Count {
+			return facets.Allergens[i].Count > facets.Allergens[j].Count
+		}
+		return facets.Allergens[i].Value < facets.Allergens[j].Value
+	})
+	return facets
+}
@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"testing"
+)
+
+// TestNormalizeArabic tests diacritic stripping and letter folding
+func TestNormalizeArabic(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"أرز", "ارز"},
+		{"إفطار", "افطار"},
+		{"آيس كريم", "ايس كريم"},
+		{"شَاوَرْمَا", "شاورما"},
+		{"دجاجـــة", "دجاجه"},
+		{"حلوى", "حلوي"},
+		{"Burger", "Burger"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeArabic(tt.input); got != tt.expected {
+			t.Errorf("NormalizeArabic(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestBuildPrefixTSQuery tests autocomplete tsquery construction
+func TestBuildPrefixTSQuery(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"Chick", "chick:*"},
+		{"  chicken   tik ", "chicken:* & tik:*"},
+		{"mac & cheese!", "mac:* & cheese:*"},
+		{"':*|", ""},
+		{"شاورمَا", "شاورما:*"},
+	}
+
+	for _, tt := range tests {
+		if got := BuildPrefixTSQuery(tt.input); got != tt.expected {
+			t.Errorf("BuildPrefixTSQuery(%q) = %q, want %q", tt.input, got, tt.expected)
+		}
+	}
+}
+
+// TestParseAllergens tests decoding of the allergens column
+func TestParseAllergens(t *testing.T) {
+	if got := ParseAllergens(`["Peanuts", " milk "]`); len(got) != 2 || got[0] != "peanuts" || got[1] != "milk" {
+		t.Errorf("unexpected JSON allergens: %v", got)
+	}
+	if got := ParseAllergens("gluten, eggs"); len(got) != 2 || got[1] != "eggs" {
+		t.Errorf("unexpected CSV allergens: %v", got)
+	}
+	if got := ParseAllergens(""); len(got) != 0 {
+		t.Errorf("expected no allergens, got %v", got)
+	}
+}
+
+// TestSearchFacetsAndFilters tests facet counting and filter application
+func TestSearchFacetsAndFilters(t *testing.T) {
+	hits := []ProductSearchHit{
+		{Product: Product{ID: 1, CategoryID: 1, IsVegan: true, IsGlutenFree: true}, CategoryName: "Salads"},
+		{Product: Product{ID: 2, CategoryID: 1, IsVegetarian: true, Allergens: []string{"milk"}}, CategoryName: "Salads"},
+		{Product: Product{ID: 3, CategoryID: 2, Allergens: []string{"peanuts", "milk"}}, CategoryName: "Mains"},
+	}
+
+	facets := BuildSearchFacets(hits)
+	if len(facets.Categories) != 2 || facets.Categories[0].Label != "Salads" || facets.Categories[0].Count != 2 {
+		t.Errorf("unexpected category facets: %+v", facets.Categories)
+	}
+	if len(facets.Allergens) != 2 || facets.Allergens[0].Value != "milk" || facets.Allergens[0].Count != 2 {
+		t.Errorf("unexpected allergen facets: %+v", facets.Allergens)
+	}
+	if len(facets.Dietary) == 0 || facets.Dietary[0].Value != DietaryVegetarian || facets.Dietary[0].Count != 2 {
+		t.Errorf("unexpected dietary facets: %+v", facets.Dietary)
+	}
+
+	filtered := FilterSearchHits(hits, &ProductSearchRequest{Dietary: []string{DietaryVegetarian}})
+	if len(filtered) != 2 {
+		t.Errorf("expected 2 vegetarian hits, got %d", len(filtered))
+	}
+
+	filtered = FilterSearchHits(hits, &ProductSearchRequest{ExcludeAllergens: []string{"Milk"}})
+	if len(filtered) != 1 || filtered[0].Product.ID != 1 {
+		t.Errorf("expected only milk-free hit, got %+v", filtered)
+	}
+}
@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Session defaults
+const (
+	// DefaultRefreshTokenTTL is how long a refresh token stays valid when it is not rotated
+	DefaultRefreshTokenTTL = 7 * 24 * time.Hour
+	// MaxDeviceNameLength caps the device name stored on a session
+	MaxDeviceNameLength = 100
+)
+
+// Reasons recorded when a session is revoked
+const (
+	SessionRevokedLogout        = "logout"
+	SessionRevokedByUser        = "revoked_by_user"
+	SessionRevokedByAdmin       = "revoked_by_admin"
+	SessionRevokedTokenReuse    = "refresh_token_reuse"
+	SessionRevokedUserDisabled  = "user_disabled"
+	SessionRevokedPasswordReset = "password_reset"
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used; the session has been revoked")
+	ErrSessionNotFound     = errors.New("session not found")
+	ErrSessionRevoked      = errors.New("session has been revoked or has expired")
+)
+
+// UserSession is a signed-in device of a user. Each session holds one chain of rotating
+// refresh tokens; revoking the session invalidates its refresh token and access tokens.
+type UserSession struct {
+	ID            string     `json:"id"`
+	TenantID      int        `json:"tenant_id"`
+	UserID        int        `json:"user_id"`
+	RestaurantID  *int       `json:"restaurant_id,omitempty"` // Switched-to restaurant; nil is the user's default
+	DeviceName    string     `json:"device_name"`
+	UserAgent     string     `json:"user_agent"`
+	IPAddress     string     `json:"ip_address"`
+	CreatedAt     time.Time  `json:"created_at"`
+	LastSeenAt    time.Time  `json:"last_seen_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	RevokedReason string     `json:"revoked_reason,omitempty"`
+	Current       bool       `json:"current"`
+}
+
+// IsActive reports whether the session can still be used at the given time
+func (s *UserSession) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// SessionClient describes the device a session is opened or refreshed from
+type SessionClient struct {
+	DeviceName string
+	UserAgent  string
+	IPAddress  string
+}
+
+// Normalize fills in the device name from the user agent and trims both to their column sizes
+func (c SessionClient) Normalize() SessionClient {
+	c.DeviceName = strings.TrimSpace(c.DeviceName)
+	if c.DeviceName == "" {
+		c.DeviceName = DeviceNameFromUserAgent(c.UserAgent)
+	}
+	if len(c.DeviceName) > MaxDeviceNameLength {
+		c.DeviceName = c.DeviceName[:MaxDeviceNameLength]
+	}
+	if len(c.UserAgent) > 500 {
+		c.UserAgent = c.UserAgent[:500]
+	}
+	return c
+}
+
+// DeviceNameFromUserAgent gives a short readable name such as "Chrome on Windows"
+func DeviceNameFromUserAgent(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	browser := ""
+	switch {
+	case strings.Contains(ua, "edg/"):
+		browser = "Edge"
+	case strings.Contains(ua, "opr/") || strings.Contains(ua, "opera"):
+		browser = "Opera"
+	case strings.Contains(ua, "firefox/"):
+		browser = "Firefox"
+	case strings.Contains(ua, "chrome/") || strings.Contains(ua, "crios/"):
+		browser = "Chrome"
+	case strings.Contains(ua, "safari/"):
+		browser = "Safari"
+	case strings.Contains(ua, "okhttp") || strings.Contains(ua, "dart"):
+		browser = "App"
+	}
+
+	platform := ""
+	switch {
+	case strings.Contains(ua, "iphone") || strings.Contains(ua, "ipad"):
+		platform = "iOS"
+	case strings.Contains(ua, "android"):
+		platform = "Android"
+	case strings.Contains(ua, "windows"):
+		platform = "Windows"
+	case strings.Contains(ua, "mac os"):
+		platform = "macOS"
+	case strings.Contains(ua, "linux"):
+		platform = "Linux"
+	}
+
+	switch {
+	case browser != "" && platform != "":
+		return browser + " on " + platform
+	case browser != "":
+		return browser
+	case platform != "":
+		return platform
+	}
+	return "Unknown device"
+}
+
+// HashToken returns the SHA-256 hex digest stored in place of a refresh or account token
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// RefreshTokenRequest is sent to /api/v1/auth/refresh and /api/v1/auth/logout
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// SwitchRestaurantRequest is sent to /api/v1/auth/switch-restaurant
+type SwitchRestaurantRequest struct {
+	RestaurantID int `json:"restaurant_id"`
+}
@@ -0,0 +1,76 @@
+package domain
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestDeviceNameFromUserAgent tests the readable device names shown in the session list
+func TestDeviceNameFromUserAgent(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36", "Chrome on Windows"},
+		{"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0", "Edge on Windows"},
+		{"Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.0 Mobile/15E148 Safari/604.1", "Safari on iOS"},
+		{"Mozilla/5.0 (Macintosh; Intel Mac OS X 14.1; rv:121.0) Gecko/20100101 Firefox/121.0", "Firefox on macOS"},
+		{"okhttp/4.12.0", "App"},
+		{"", "Unknown device"},
+		{"curl/8.4.0", "Unknown device"},
+	}
+	for _, tt := range tests {
+		if got := DeviceNameFromUserAgent(tt.userAgent); got != tt.want {
+			t.Errorf("DeviceNameFromUserAgent(%q) = %q, want %q", tt.userAgent, got, tt.want)
+		}
+	}
+}
+
+// TestSessionClientNormalize tests the device name fallback and the column size limits
+func TestSessionClientNormalize(t *testing.T) {
+	client := SessionClient{DeviceName: "  ", UserAgent: "Mozilla/5.0 (Linux; Android 14) Chrome/120.0 Mobile"}.Normalize()
+	if client.DeviceName != "Chrome on Android" {
+		t.Errorf("DeviceName = %q, want the name derived from the user agent", client.DeviceName)
+	}
+
+	client = SessionClient{DeviceName: strings.Repeat("x", 150), UserAgent: strings.Repeat("y", 600)}.Normalize()
+	if len(client.DeviceName) != MaxDeviceNameLength || len(client.UserAgent) != 500 {
+		t.Errorf("Normalize() kept %d and %d characters, want %d and 500", len(client.DeviceName), len(client.UserAgent), MaxDeviceNameLength)
+	}
+}
+
+// TestHashToken tests that tokens are stored as stable SHA-256 digests
+func TestHashToken(t *testing.T) {
+	hash := HashToken("token")
+	if len(hash) != 64 {
+		t.Fatalf("HashToken() length = %d, want 64", len(hash))
+	}
+	if hash != HashToken("token") {
+		t.Error("HashToken() is not stable")
+	}
+	if hash == HashToken("token2") {
+		t.Error("HashToken() gave the same digest for different tokens")
+	}
+}
+
+// TestUserSessionIsActive tests that revoked and expired sessions are inactive
+func TestUserSessionIsActive(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name    string
+		session UserSession
+		want    bool
+	}{
+		{"active", UserSession{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expired", UserSession{ExpiresAt: now.Add(-time.Second)}, false},
+		{"revoked", UserSession{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.session.IsActive(now); got != tt.want {
+			t.Errorf("%s: IsActive() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package domain
+
+import (
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// SSOLoginTTL is how long a user has to complete sign-in at the identity provider
+	SSOLoginTTL = 10 * time.Minute
+	// SSOHandoffTTL is how long the frontend has to exchange the code it is redirected with
+	SSOHandoffTTL = 2 * time.Minute
+	// SSOStateCookie ties a sign-in to the browser that started it; it holds the state's hash
+	SSOStateCookie = "pos_sso_state"
+	// DefaultSSOGroupsClaim is the ID token claim read for group-to-role mapping
+	DefaultSSOGroupsClaim = "groups"
+)
+
+var (
+	ErrSSONotConfigured      = errors.New("single sign-on is not configured for this organization")
+	ErrSSOInvalidState       = errors.New("invalid or expired single sign-on request")
+	ErrSSOLoginFailed        = errors.New("single sign-on failed")
+	ErrSSOEmailNotAllowed    = errors.New("your email domain is not allowed to sign in to this organization")
+	ErrSSOEmailNotVerified   = errors.New("the identity provider has not verified your email address")
+	ErrSSOUserNotProvisioned = errors.New("no account exists for you in this organization; ask an administrator for an invitation")
+	ErrSSOAccountDisabled    = errors.New("your account in this organization is disabled")
+	ErrSSOIssuerUnreachable  = errors.New("issuer_url is invalid: its OpenID configuration could not be loaded")
+	ErrSSONotLinked          = errors.New("sign in with single sign-on once before disabling password login, so you are not locked out")
+	ErrSSOManagement         = errors.New("API keys cannot change single sign-on settings")
+	ErrPasswordLoginDisabled = errors.New("password sign-in is disabled for this organization; sign in with single sign-on")
+)
+
+// SSOConfig is a tenant's OpenID Connect identity provider. The client secret is stored
+// encrypted and never returned.
+type SSOConfig struct {
+	TenantID              int              `json:"tenant_id"`
+	IssuerURL             string           `json:"issuer_url"`
+	ClientID              string           `json:"client_id"`
+	ClientSecret          string           `json:"-"`
+	HasClientSecret       bool             `json:"has_client_secret"`
+	Scopes                []string         `json:"scopes"`
+	GroupsClaim           string           `json:"groups_claim"`
+	AllowedDomains        []string         `json:"allowed_domains"`
+	JITProvisioning       bool             `json:"jit_provisioning"`
+	DefaultRestaurantID   *int             `json:"default_restaurant_id,omitempty"`
+	PasswordLoginDisabled bool             `json:"password_login_disabled"`
+	Enabled               bool             `json:"enabled"`
+	RoleMappings          []SSORoleMapping `json:"role_mappings"`
+	CallbackURL           string           `json:"callback_url"` // Redirect URI to register at the provider
+	UpdatedAt             time.Time        `json:"updated_at"`
+}
+
+// SSORoleMapping grants a role to members of a provider group, tenant-wide or in one restaurant
+type SSORoleMapping struct {
+	Group        string `json:"group"`
+	RoleID       int64  `json:"role_id"`
+	RestaurantID *int64 `json:"restaurant_id,omitempty"`
+}
+
+// AllowsEmail reports whether an email's domain may sign in; no allowed domains allows any
+func (c *SSOConfig) AllowsEmail(email string) bool {
+	if len(c.AllowedDomains) == 0 {
+		return true
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(email[at+1:])
+	for _, allowed := range c.AllowedDomains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// MappedRoles returns the role assignments the mappings grant to members of these groups
+func (c *SSOConfig) MappedRoles(groups []string) []SSORoleMapping {
+	member := make(map[string]bool, len(groups))
+	for _, group := range groups {
+		member[group] = true
+	}
+	var roles []SSORoleMapping
+	for _, mapping := range c.RoleMappings {
+		if member[mapping.Group] {
+			roles = append(roles, mapping)
+		}
+	}
+	return roles
+}
+
+// SSOConfigRequest is sent to PUT /api/v1/sso/oidc. An empty client secret keeps the stored one.
+type SSOConfigRequest struct {
+	IssuerURL             string           `json:"issuer_url"`
+	ClientID              string           `json:"client_id"`
+	ClientSecret          string           `json:"client_secret,omitempty"`
+	Scopes                []string         `json:"scopes,omitempty"`
+	GroupsClaim           string           `json:"groups_claim,omitempty"`
+	AllowedDomains        []string         `json:"allowed_domains,omitempty"`
+	JITProvisioning       bool             `json:"jit_provisioning"`
+	DefaultRestaurantID   *int             `json:"default_restaurant_id,omitempty"`
+	PasswordLoginDisabled bool             `json:"password_login_disabled"`
+	Enabled               bool             `json:"enabled"`
+	RoleMappings          []SSORoleMapping `json:"role_mappings,omitempty"`
+}
+
+// Validate normalizes the request. The issuer must use https except on localhost, the
+// openid scope is always requested, and password login can only be disabled while SSO is on.
+func (req *SSOConfigRequest) Validate() error {
+	req.IssuerURL = strings.TrimSuffix(strings.TrimSpace(req.IssuerURL), "/")
+	if req.IssuerURL == "" {
+		return errors.New("issuer_url is required")
+	}
+	issuer, err := url.Parse(req.IssuerURL)
+	if err != nil || issuer.Host == "" || issuer.RawQuery != "" || issuer.Fragment != "" {
+		return errors.New("issuer_url must be a valid URL")
+	}
+	if issuer.Scheme != "https" && !(issuer.Scheme == "http" && isLocalHost(issuer.Hostname())) {
+		return errors.New("issuer_url must use https")
+	}
+	if len(req.IssuerURL) > 500 {
+		return errors.New("issuer_url must be less than 500 characters")
+	}
+
+	req.ClientID = strings.TrimSpace(req.ClientID)
+	if req.ClientID == "" {
+		return errors.New("client_id is required")
+	}
+	if len(req.ClientID) > 255 {
+		return errors.New("client_id must be less than 255 characters")
+	}
+	req.ClientSecret = strings.TrimSpace(req.ClientSecret)
+
+	scopes := []string{"openid"}
+	for _, scope := range req.Scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || scope == "openid" {
+			continue
+		}
+		if strings.ContainsAny(scope, " \t\"") {
+			return errors.New("scopes must not contain spaces or quotes")
+		}
+		scopes = append(scopes, scope)
+	}
+	if len(scopes) == 1 {
+		scopes = append(scopes, "email", "profile")
+	}
+	req.Scopes = scopes
+
+	req.GroupsClaim = strings.TrimSpace(req.GroupsClaim)
+	if req.GroupsClaim == "" {
+		req.GroupsClaim = DefaultSSOGroupsClaim
+	}
+	if len(req.GroupsClaim) > 100 {
+		return errors.New("groups_claim must be less than 100 characters")
+	}
+
+	domains := make([]string, 0, len(req.AllowedDomains))
+	for _, domain := range req.AllowedDomains {
+		domain = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(domain), "@"))
+		if domain == "" {
+			continue
+		}
+		if !strings.Contains(domain, ".") || strings.ContainsAny(domain, "@/ ") {
+			return errors.New("allowed_domains must be domain names, e.g. example.com")
+		}
+		domains = append(domains, domain)
+	}
+	req.AllowedDomains = domains
+
+	if req.DefaultRestaurantID != nil && *req.DefaultRestaurantID <= 0 {
+		return errors.New("default_restaurant_id must be a valid restaurant ID")
+	}
+	if req.PasswordLoginDisabled && !req.Enabled {
+		return errors.New("password login can only be disabled while single sign-on is enabled")
+	}
+
+	type mappingKey struct {
+		group      string
+		role       int64
+		restaurant int64
+	}
+	seen := make(map[mappingKey]bool, len(req.RoleMappings))
+	mappings := make([]SSORoleMapping, 0, len(req.RoleMappings))
+	for _, mapping := range req.RoleMappings {
+		mapping.Group = strings.TrimSpace(mapping.Group)
+		if mapping.Group == "" {
+			return errors.New("role_mappings group is required")
+		}
+		if len(mapping.Group) > 255 {
+			return errors.New("role_mappings group must be less than 255 characters")
+		}
+		if mapping.RoleID <= 0 {
+			return errors.New("role_mappings role_id must be a valid role ID")
+		}
+		key := mappingKey{group: mapping.Group, role: mapping.RoleID}
+		if mapping.RestaurantID != nil {
+			if *mapping.RestaurantID <= 0 {
+				return errors.New("role_mappings restaurant_id must be a valid restaurant ID")
+			}
+			key.restaurant = *mapping.RestaurantID
+		}
+		if !seen[key] {
+			seen[key] = true
+			mappings = append(mappings, mapping)
+		}
+	}
+	req.RoleMappings = mappings
+	return nil
+}
+
+func isLocalHost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// SafeRedirectPath returns path if it is a local path of the frontend, otherwise ""; it keeps
+// the sign-in flow from redirecting to other sites
+func SafeRedirectPath(path string) string {
+	if !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.HasPrefix(path, "/\\") ||
+		len(path) > 500 || strings.ContainsAny(path, "\r\n") {
+		return ""
+	}
+	return path
+}
+
+// SSOProviderInfo tells the login page whether a tenant offers single sign-on
+type SSOProviderInfo struct {
+	TenantSlug            string `json:"tenant_slug"`
+	Enabled               bool   `json:"enabled"`
+	PasswordLoginDisabled bool   `json:"password_login_disabled"`
+	StartURL              string `json:"start_url,omitempty"`
+}
+
+// SSOLoginRequest is a sign-in in progress at the identity provider
+type SSOLoginRequest struct {
+	ID           int64
+	TenantID     int
+	Nonce        string
+	CodeVerifier string // Sealed while stored
+	RedirectPath string
+	ExpiresAt    time.Time
+}
+
+// SSOExchangeRequest is sent to /api/v1/auth/sso/exchange with the code from the
+// /sso/complete redirect
+type SSOExchangeRequest struct {
+	Code       string `json:"code"`
+	DeviceName string `json:"device_name,omitempty"`
+	// TrustedDeviceToken comes from the remember-device cookie, not the body
+	TrustedDeviceToken string `json:"-"`
+}
@@ -0,0 +1,86 @@
+package domain
+
+import "testing"
+
+// TestSSOConfigRequestValidate tests normalization of identity provider settings
+func TestSSOConfigRequestValidate(t *testing.T) {
+	restaurant := int64(4)
+	req := SSOConfigRequest{
+		IssuerURL:      " https://login.example.com/tenant/ ",
+		ClientID:       " pos ",
+		Scopes:         []string{"email", "openid", "groups"},
+		AllowedDomains: []string{" @Example.com ", ""},
+		Enabled:        true,
+		RoleMappings: []SSORoleMapping{
+			{Group: " admins ", RoleID: 1},
+			{Group: "admins", RoleID: 1},
+			{Group: "admins", RoleID: 1, RestaurantID: &restaurant},
+		},
+	}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if req.IssuerURL != "https://login.example.com/tenant" || req.ClientID != "pos" || req.GroupsClaim != DefaultSSOGroupsClaim {
+		t.Errorf("normalized request = %+v", req)
+	}
+	if len(req.Scopes) != 3 || req.Scopes[0] != "openid" {
+		t.Errorf("Scopes = %v, want openid first and no duplicates", req.Scopes)
+	}
+	if len(req.AllowedDomains) != 1 || req.AllowedDomains[0] != "example.com" {
+		t.Errorf("AllowedDomains = %v", req.AllowedDomains)
+	}
+	if len(req.RoleMappings) != 2 {
+		t.Errorf("RoleMappings = %v, want the duplicate removed", req.RoleMappings)
+	}
+
+	local := SSOConfigRequest{IssuerURL: "http://localhost:9400", ClientID: "pos"}
+	if err := local.Validate(); err != nil || len(local.Scopes) != 3 {
+		t.Errorf("local issuer: Validate() error = %v, scopes = %v", err, local.Scopes)
+	}
+
+	invalid := map[string]SSOConfigRequest{
+		"missing issuer":         {ClientID: "pos"},
+		"plain http":             {IssuerURL: "http://login.example.com", ClientID: "pos"},
+		"missing client":         {IssuerURL: "https://login.example.com"},
+		"bad domain":             {IssuerURL: "https://login.example.com", ClientID: "pos", AllowedDomains: []string{"a@b.com"}},
+		"password off, sso off":  {IssuerURL: "https://login.example.com", ClientID: "pos", PasswordLoginDisabled: true},
+		"mapping without group":  {IssuerURL: "https://login.example.com", ClientID: "pos", RoleMappings: []SSORoleMapping{{RoleID: 1}}},
+		"mapping without role":   {IssuerURL: "https://login.example.com", ClientID: "pos", RoleMappings: []SSORoleMapping{{Group: "a"}}},
+		"scope with whitespace":  {IssuerURL: "https://login.example.com", ClientID: "pos", Scopes: []string{"a b"}},
+		"issuer with a fragment": {IssuerURL: "https://login.example.com/#x", ClientID: "pos"},
+	}
+	for name, req := range invalid {
+		if err := req.Validate(); err == nil {
+			t.Errorf("%s: Validate() expected an error", name)
+		}
+	}
+}
+
+// TestSSOConfigRules tests domain restrictions, group mapping and redirect paths
+func TestSSOConfigRules(t *testing.T) {
+	cfg := SSOConfig{
+		AllowedDomains: []string{"example.com"},
+		RoleMappings:   []SSORoleMapping{{Group: "admins", RoleID: 1}, {Group: "kitchen", RoleID: 2}},
+	}
+	if !cfg.AllowsEmail("sara@Example.com") || cfg.AllowsEmail("sara@other.com") || cfg.AllowsEmail("sara") {
+		t.Error("AllowsEmail() does not follow the allowed domains")
+	}
+	if roles := cfg.MappedRoles([]string{"kitchen", "sales"}); len(roles) != 1 || roles[0].RoleID != 2 {
+		t.Errorf("MappedRoles() = %v", roles)
+	}
+	if open := (&SSOConfig{}); !open.AllowsEmail("anyone@anywhere.org") {
+		t.Error("AllowsEmail() refused an email with no domain restriction")
+	}
+
+	for path, want := range map[string]string{
+		"/orders?tab=open":    "/orders?tab=open",
+		"//evil.example.com":  "",
+		"/\\evil.example.com": "",
+		"https://evil.com":    "",
+		"":                    "",
+	} {
+		if got := SafeRedirectPath(path); got != want {
+			t.Errorf("SafeRedirectPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
@@ -0,0 +1,285 @@
+package domain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Time clock punch actions
+const (
+	PunchClockIn    = "clock_in"
+	PunchClockOut   = "clock_out"
+	PunchBreakStart = "break_start"
+	PunchBreakEnd   = "break_end"
+)
+
+// Time clock devices recorded on attendance
+const (
+	ClockDeviceWeb    = "web"
+	ClockDeviceMobile = "mobile"
+	ClockDeviceKiosk  = "kiosk"
+)
+
+// Time clock defaults
+const (
+	DefaultGeofenceRadiusMeters = 150
+	DefaultRegularHoursPerDay   = 8.0
+)
+
+// TimeClockSettings are a restaurant's rules for clocking in and out
+type TimeClockSettings struct {
+	TenantID                 int       `json:"tenant_id"`
+	RestaurantID             int       `json:"restaurant_id"`
+	RequireGeofence          bool      `json:"require_geofence"`
+	GeofenceRadiusMeters     int       `json:"geofence_radius_meters"`
+	KioskEnabled             bool      `json:"kiosk_enabled"`
+	RegularHoursPerDay       float64   `json:"regular_hours_per_day"`
+	OvertimeRequiresApproval bool      `json:"overtime_requires_approval"`
+	UpdatedAt                time.Time `json:"updated_at"`
+	UpdatedBy                *int      `json:"updated_by,omitempty"`
+}
+
+// DefaultTimeClockSettings returns the settings used until a restaurant saves its own
+func DefaultTimeClockSettings(tenantID, restaurantID int) *TimeClockSettings {
+	return &TimeClockSettings{
+		TenantID:                 tenantID,
+		RestaurantID:             restaurantID,
+		GeofenceRadiusMeters:     DefaultGeofenceRadiusMeters,
+		RegularHoursPerDay:       DefaultRegularHoursPerDay,
+		OvertimeRequiresApproval: true,
+	}
+}
+
+// Validate checks the geofence radius and day length
+func (s *TimeClockSettings) Validate() error {
+	if s.GeofenceRadiusMeters < 10 || s.GeofenceRadiusMeters > 5000 {
+		return errors.New("geofence_radius_meters must be between 10 and 5000")
+	}
+	if s.RegularHoursPerDay <= 0 || s.RegularHoursPerDay > 24 {
+		return errors.New("regular_hours_per_day must be between 0 and 24")
+	}
+	return nil
+}
+
+// ClockLocation is where an employee clocked in or out, stored as {lat, lng, accuracy}
+type ClockLocation struct {
+	Latitude       float64 `json:"lat"`
+	Longitude      float64 `json:"lng"`
+	AccuracyMeters *int    `json:"accuracy,omitempty"`
+}
+
+// ClockPunchRequest clocks an employee in or out, or starts or ends their break
+type ClockPunchRequest struct {
+	EmployeeID     int      `json:"employee_id" validate:"required"`
+	Latitude       *float64 `json:"latitude,omitempty"`
+	Longitude      *float64 `json:"longitude,omitempty"`
+	AccuracyMeters *int     `json:"accuracy_meters,omitempty"`
+	Device         string   `json:"device,omitempty"` // 'web', 'mobile'
+	Notes          string   `json:"notes,omitempty"`
+}
+
+// Location returns the punch location, or nil when none was sent
+func (r *ClockPunchRequest) Location() (*ClockLocation, error) {
+	if r.Latitude == nil && r.Longitude == nil {
+		return nil, nil
+	}
+	if r.Latitude == nil || r.Longitude == nil {
+		return nil, errors.New("latitude and longitude must be sent together")
+	}
+	if *r.Latitude < -90 || *r.Latitude > 90 || *r.Longitude < -180 || *r.Longitude > 180 {
+		return nil, errors.New("invalid latitude or longitude")
+	}
+	return &ClockLocation{Latitude: *r.Latitude, Longitude: *r.Longitude, AccuracyMeters: r.AccuracyMeters}, nil
+}
+
+// KioskPunchRequest is a punch at the shared time clock, identified by badge or by employee code and PIN
+type KioskPunchRequest struct {
+	Action       string `json:"action" validate:"required"` // 'clock_in', 'clock_out', 'break_start', 'break_end'
+	BadgeCode    string `json:"badge_code,omitempty"`
+	EmployeeCode string `json:"employee_code,omitempty"`
+	PIN          string `json:"pin,omitempty"`
+}
+
+// Validate checks the action and that the employee can be identified
+func (r *KioskPunchRequest) Validate() error {
+	if !IsPunchAction(r.Action) {
+		return errors.New("action must be one of clock_in, clock_out, break_start, break_end")
+	}
+	if strings.TrimSpace(r.BadgeCode) == "" && (strings.TrimSpace(r.EmployeeCode) == "" || r.PIN == "") {
+		return errors.New("badge_code or employee_code and pin are required")
+	}
+	return nil
+}
+
+// KioskPunchResponse is returned to the kiosk after a punch
+type KioskPunchResponse struct {
+	EmployeeID   int         `json:"employee_id"`
+	EmployeeName string      `json:"employee_name"`
+	Action       string      `json:"action"`
+	Attendance   *Attendance `json:"attendance"`
+}
+
+// ClockCredentialsRequest sets an employee's kiosk PIN and badge; an empty string clears one
+type ClockCredentialsRequest struct {
+	PIN       *string `json:"pin,omitempty"`
+	BadgeCode *string `json:"badge_code,omitempty"`
+}
+
+// Validate checks the PIN format
+func (r *ClockCredentialsRequest) Validate() error {
+	if r.PIN == nil && r.BadgeCode == nil {
+		return errors.New("pin or badge_code is required")
+	}
+	if r.PIN != nil && *r.PIN != "" {
+		if err := ValidateClockPIN(*r.PIN); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Time clock errors
+var (
+	ErrAlreadyClockedIn      = errors.New("employee already clocked in today")
+	ErrNotClockedIn          = errors.New("employee has not clocked in today")
+	ErrAlreadyClockedOut     = errors.New("employee already clocked out today")
+	ErrAlreadyOnBreak        = errors.New("employee is already on a break")
+	ErrNotOnBreak            = errors.New("employee is not on a break")
+	ErrLocationRequired      = errors.New("location is required to clock in or out at this restaurant")
+	ErrOutsideGeofence       = errors.New("location is outside the restaurant geofence")
+	ErrRestaurantNoLocation  = errors.New("restaurant location must be set to require a geofence")
+	ErrKioskDisabled         = errors.New("kiosk mode is disabled for this restaurant")
+	ErrInvalidKioskLogin     = errors.New("invalid badge or employee code and PIN")
+	ErrOvertimeNotPending    = errors.New("attendance has no overtime awaiting approval")
+	ErrBadgeCodeTaken        = errors.New("badge code is already assigned to another employee")
+	ErrAttendanceNotFound    = errors.New("attendance not found")
+	ErrInvalidClockPINFormat = errors.New("pin must be 4 to 8 digits")
+)
+
+// IsPunchAction reports whether action is a known time clock action
+func IsPunchAction(action string) bool {
+	switch action {
+	case PunchClockIn, PunchClockOut, PunchBreakStart, PunchBreakEnd:
+		return true
+	}
+	return false
+}
+
+// ValidateClockPIN checks that a kiosk PIN is 4 to 8 digits
+func ValidateClockPIN(pin string) error {
+	if len(pin) < 4 || len(pin) > 8 {
+		return ErrInvalidClockPINFormat
+	}
+	for _, c := range pin {
+		if c < '0' || c > '9' {
+			return ErrInvalidClockPINFormat
+		}
+	}
+	return nil
+}
+
+// CheckGeofence verifies a punch location is within the restaurant's radius when the
+// restaurant requires it. Restaurant coordinates may be nil when they were never set.
+func CheckGeofence(settings *TimeClockSettings, restaurantLat, restaurantLon *float64, loc *ClockLocation) error {
+	if !settings.RequireGeofence {
+		return nil
+	}
+	if restaurantLat == nil || restaurantLon == nil {
+		return ErrRestaurantNoLocation
+	}
+	if loc == nil {
+		return ErrLocationRequired
+	}
+	meters := HaversineKm(*restaurantLat, *restaurantLon, loc.Latitude, loc.Longitude) * 1000
+	if meters > float64(settings.GeofenceRadiusMeters) {
+		return fmt.Errorf("%w (%.0f m away, limit %d m)", ErrOutsideGeofence, meters, settings.GeofenceRadiusMeters)
+	}
+	return nil
+}
+
+// MarshalLocation encodes a punch location for the attendance record
+func MarshalLocation(loc *ClockLocation) json.RawMessage {
+	if loc == nil {
+		return nil
+	}
+	data, _ := json.Marshal(loc)
+	return data
+}
+
+// OnBreak reports whether the employee is currently on a break
+func (a *Attendance) OnBreak() bool {
+	return a.BreakStart != nil && a.BreakEnd == nil
+}
+
+// StartBreak starts a break; a day can have several breaks, their minutes accumulate
+func (a *Attendance) StartBreak(now time.Time) error {
+	if err := a.checkOnShift(); err != nil {
+		return err
+	}
+	if a.OnBreak() {
+		return ErrAlreadyOnBreak
+	}
+	a.BreakStart, a.BreakEnd = &now, nil
+	return nil
+}
+
+// EndBreak ends the current break and adds its length to the day's break minutes
+func (a *Attendance) EndBreak(now time.Time) error {
+	if err := a.checkOnShift(); err != nil {
+		return err
+	}
+	if !a.OnBreak() {
+		return ErrNotOnBreak
+	}
+	a.BreakEnd = &now
+	a.TotalBreakMinutes += int(now.Sub(*a.BreakStart).Minutes())
+	return nil
+}
+
+// Finish clocks the employee out, ending any break in progress
+func (a *Attendance) Finish(now time.Time) error {
+	if err := a.checkOnShift(); err != nil {
+		return err
+	}
+	if a.OnBreak() {
+		if err := a.EndBreak(now); err != nil {
+			return err
+		}
+	}
+	a.ClockOut = &now
+	return nil
+}
+
+// CalculateHours sets total, regular and overtime hours from the clock times and breaks.
+// Hours beyond regularHoursPerDay are overtime and, when required, await a manager's approval.
+func (a *Attendance) CalculateHours(regularHoursPerDay float64, overtimeRequiresApproval bool) {
+	if a.ClockIn == nil || a.ClockOut == nil {
+		return
+	}
+	worked := a.ClockOut.Sub(*a.ClockIn).Hours() - float64(a.TotalBreakMinutes)/60
+	total := math.Max(0, math.Round(worked*100)/100)
+	a.TotalHours = &total
+
+	a.RegularHours = math.Min(total, regularHoursPerDay)
+	a.OvertimeHours = math.Round((total-a.RegularHours)*100) / 100
+	a.IsOvertime = a.OvertimeHours > 0
+	a.OvertimeApproved = false
+	a.RequiresApproval = a.IsOvertime && overtimeRequiresApproval
+	if a.IsOvertime && !overtimeRequiresApproval {
+		a.OvertimeApproved = true
+	}
+}
+
+func (a *Attendance) checkOnShift() error {
+	if a.ClockIn == nil {
+		return ErrNotClockedIn
+	}
+	if a.ClockOut != nil {
+		return ErrAlreadyClockedOut
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package domain
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestAttendanceBreaksAndHours tests breaks, clock-out and the hours split into regular and overtime
+func TestAttendanceBreaksAndHours(t *testing.T) {
+	start := time.Date(2024, 6, 3, 8, 0, 0, 0, time.UTC)
+	att := &Attendance{}
+
+	if err := att.StartBreak(start); err != ErrNotClockedIn {
+		t.Fatalf("expected ErrNotClockedIn, got %v", err)
+	}
+	att.ClockIn = &start
+
+	if err := att.StartBreak(start.Add(3 * time.Hour)); err != nil {
+		t.Fatalf("StartBreak() error = %v", err)
+	}
+	if err := att.StartBreak(start.Add(3 * time.Hour)); err != ErrAlreadyOnBreak {
+		t.Errorf("expected ErrAlreadyOnBreak, got %v", err)
+	}
+	if err := att.EndBreak(start.Add(3*time.Hour + 20*time.Minute)); err != nil {
+		t.Fatalf("EndBreak() error = %v", err)
+	}
+	if err := att.EndBreak(start.Add(4 * time.Hour)); err != ErrNotOnBreak {
+		t.Errorf("expected ErrNotOnBreak, got %v", err)
+	}
+
+	// A second break still running at clock-out is closed automatically
+	if err := att.StartBreak(start.Add(6 * time.Hour)); err != nil {
+		t.Fatalf("StartBreak() error = %v", err)
+	}
+	if err := att.Finish(start.Add(6*time.Hour + 10*time.Minute)); err != nil {
+		t.Fatalf("Finish() error = %v", err)
+	}
+	if att.TotalBreakMinutes != 30 || att.OnBreak() {
+		t.Errorf("expected 30 break minutes and no open break, got %d, %v", att.TotalBreakMinutes, att.OnBreak())
+	}
+	if err := att.Finish(start.Add(7 * time.Hour)); err != ErrAlreadyClockedOut {
+		t.Errorf("expected ErrAlreadyClockedOut, got %v", err)
+	}
+
+	att.CalculateHours(8, true)
+	if att.TotalHours == nil || *att.TotalHours != 5.67 || att.RegularHours != 5.67 || att.IsOvertime {
+		t.Errorf("unexpected hours %v, regular %v, overtime %v", att.TotalHours, att.RegularHours, att.IsOvertime)
+	}
+
+	end := start.Add(10*time.Hour + 30*time.Minute)
+	att.ClockOut = &end
+	att.CalculateHours(8, true)
+	if att.RegularHours != 8 || att.OvertimeHours != 2 || !att.IsOvertime || !att.RequiresApproval || att.OvertimeApproved {
+		t.Errorf("expected 2 overtime hours awaiting approval, got %+v", att)
+	}
+
+	att.CalculateHours(8, false)
+	if !att.OvertimeApproved || att.RequiresApproval {
+		t.Error("expected overtime to be approved when approval is not required")
+	}
+}
+
+// TestCheckGeofence tests the restaurant radius check
+func TestCheckGeofence(t *testing.T) {
+	lat, lon := 30.0444, 31.2357
+	settings := DefaultTimeClockSettings(1, 1)
+
+	if err := CheckGeofence(settings, nil, nil, nil); err != nil {
+		t.Errorf("expected no check when the geofence is off, got %v", err)
+	}
+
+	settings.RequireGeofence = true
+	tests := []struct {
+		name string
+		loc  *ClockLocation
+		want error
+	}{
+		{"at the door", &ClockLocation{Latitude: 30.0450, Longitude: 31.2357}, nil},
+		{"down the road", &ClockLocation{Latitude: 30.0500, Longitude: 31.2357}, ErrOutsideGeofence},
+		{"no location", nil, ErrLocationRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckGeofence(settings, &lat, &lon, tt.loc); !errors.Is(err, tt.want) {
+				t.Errorf("CheckGeofence() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if err := CheckGeofence(settings, nil, nil, tests[0].loc); err != ErrRestaurantNoLocation {
+		t.Errorf("expected ErrRestaurantNoLocation, got %v", err)
+	}
+}
+
+// TestKioskCredentials tests PIN format and kiosk request validation
+func TestKioskCredentials(t *testing.T) {
+	for pin, valid := range map[string]bool{"1234": true, "12345678": true, "123": false, "123456789": false, "12a4": false} {
+		if err := ValidateClockPIN(pin); (err == nil) != valid {
+			t.Errorf("ValidateClockPIN(%q) = %v, want valid %v", pin, err, valid)
+		}
+	}
+
+	valid := []KioskPunchRequest{
+		{Action: PunchClockIn, BadgeCode: "B-100"},
+		{Action: PunchBreakEnd, EmployeeCode: "EMP001", PIN: "1234"},
+	}
+	for _, req := range valid {
+		if err := req.Validate(); err != nil {
+			t.Errorf("expected %+v to be valid, got %v", req, err)
+		}
+	}
+
+	invalid := []KioskPunchRequest{
+		{Action: "lunch", BadgeCode: "B-100"},
+		{Action: PunchClockOut, EmployeeCode: "EMP001"},
+		{Action: PunchClockOut},
+	}
+	for _, req := range invalid {
+		if err := req.Validate(); err == nil {
+			t.Errorf("expected %+v to be invalid", req)
+		}
+	}
+}
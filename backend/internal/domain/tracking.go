@@ -0,0 +1,126 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// AverageDeliverySpeedKmh is used to estimate arrival time from the driver position
+const AverageDeliverySpeedKmh = 25.0
+
+// Failed tracking verifications back off in login_throttles under their own scopes
+const (
+	ThrottleScopeTrackingOrder = "tracking_order" // Keyed by TrackingThrottleKey
+	ThrottleScopeTrackingIP    = "tracking_ip"    // Keyed by the client IP address
+	// TrackingOrderFreeAttempts and TrackingIPFreeAttempts are the failures allowed before
+	// backoff starts
+	TrackingOrderFreeAttempts = 5
+	TrackingIPFreeAttempts    = 20
+)
+
+var ErrTrackingThrottled = errors.New("too many failed attempts, please wait before trying again")
+
+// TrackingThrottleKey is the throttle key of an order number of a restaurant. Order numbers
+// that do not exist get one too, so throttling does not reveal which exist.
+func TrackingThrottleKey(restaurantID int64, orderNumber string) string {
+	return fmt.Sprintf("%d:%s", restaurantID, strings.ToUpper(strings.TrimSpace(orderNumber)))
+}
+
+// OrderTracking is the public, customer-facing view of an order
+type OrderTracking struct {
+	OrderNumber           string               `json:"order_number"`
+	Status                string               `json:"status"`
+	PaymentStatus         string               `json:"payment_status"`
+	OrderSource           string               `json:"order_source"`
+	CustomerName          string               `json:"customer_name"`
+	Items                 []OrderItem          `json:"items"`
+	TotalAmount           float64              `json:"total_amount"`
+	CreatedAt             time.Time            `json:"created_at"`
+	EstimatedDeliveryTime *time.Time           `json:"estimated_delivery_time,omitempty"`
+	ETAMinutes            *int                 `json:"eta_minutes,omitempty"`
+	Driver                *TrackingDriver      `json:"driver,omitempty"`
+	DeliveryPIN           string               `json:"delivery_pin,omitempty"`
+	ReviewToken           string               `json:"review_token,omitempty"` // Set once delivered, until feedback is left
+	StatusHistory         []OrderStatusHistory `json:"status_history"`
+}
+
+// TrackingDriver is the driver information shown to a customer while an order is out for delivery
+type TrackingDriver struct {
+	FirstName   string     `json:"first_name"`
+	VehicleType string     `json:"vehicle_type,omitempty"`
+	Latitude    *float64   `json:"latitude,omitempty"`
+	Longitude   *float64   `json:"longitude,omitempty"`
+	UpdatedAt   *time.Time `json:"updated_at,omitempty"`
+}
+
+// HaversineKm returns the great-circle distance between two coordinates in kilometres
+func HaversineKm(lat1, lon1, lat2, lon2 float64) float64 {
+	const earthRadiusKm = 6371.0
+	toRad := func(deg float64) float64 { return deg * math.Pi / 180 }
+
+	dLat := toRad(lat2 - lat1)
+	dLon := toRad(lon2 - lon1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRad(lat1))*math.Cos(toRad(lat2))*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return earthRadiusKm * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// EstimateETAMinutes estimates minutes until delivery.
+// While out for delivery the driver position is used, unless the stored route estimate is later;
+// otherwise the stored estimate.
+func EstimateETAMinutes(order *Order, driver *TrackingDriver, now time.Time) *int {
+	if order.Status == "delivered" || order.Status == "cancelled" {
+		return nil
+	}
+
+	if order.Status == "out_for_delivery" && driver != nil && driver.Latitude != nil && driver.Longitude != nil &&
+		(order.DeliveryLatitude != 0 || order.DeliveryLongitude != 0) {
+		km := HaversineKm(*driver.Latitude, *driver.Longitude, order.DeliveryLatitude, order.DeliveryLongitude)
+		minutes := int(math.Ceil(km / AverageDeliverySpeedKmh * 60))
+		// A batched order is dropped off after earlier stops, so a later route ETA wins
+		if order.EstimatedDeliveryTime != nil {
+			if routed := int(math.Ceil(order.EstimatedDeliveryTime.Sub(now).Minutes())); routed > minutes {
+				minutes = routed
+			}
+		}
+		if minutes < 1 {
+			minutes = 1
+		}
+		return &minutes
+	}
+
+	if order.EstimatedDeliveryTime != nil {
+		minutes := int(math.Ceil(order.EstimatedDeliveryTime.Sub(now).Minutes()))
+		if minutes < 0 {
+			minutes = 0
+		}
+		return &minutes
+	}
+	return nil
+}
+
+// PhoneMatches compares two phone numbers ignoring formatting and country prefixes.
+// At least 7 trailing digits must match.
+func PhoneMatches(stored, provided string) bool {
+	digits := func(s string) string {
+		return strings.Map(func(r rune) rune {
+			if unicode.IsDigit(r) {
+				return r
+			}
+			return -1
+		}, s)
+	}
+
+	a, b := digits(stored), digits(provided)
+	if len(a) < 7 || len(b) < 7 {
+		return false
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	return strings.HasSuffix(b, a)
+}
@@ -0,0 +1,63 @@
+package domain
+
+import (
+	"testing"
+	"time"
+)
+
+// TestPhoneMatches tests customer phone verification for order tracking
+func TestPhoneMatches(t *testing.T) {
+	tests := []struct {
+		stored   string
+		provided string
+		expected bool
+	}{
+		{"+20 100 123 4567", "01001234567", true},
+		{"01001234567", "+201001234567", true},
+		{"(555) 123-4567", "555 123 4567", true},
+		{"01001234567", "01001234568", false},
+		{"01001234567", "4567", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		if got := PhoneMatches(tt.stored, tt.provided); got != tt.expected {
+			t.Errorf("PhoneMatches(%q, %q) = %v, want %v", tt.stored, tt.provided, got, tt.expected)
+		}
+	}
+}
+
+// TestHaversineKm tests the great-circle distance calculation
+func TestHaversineKm(t *testing.T) {
+	// Cairo Tahrir Square to Giza Pyramids is roughly 13 km
+	km := HaversineKm(30.0444, 31.2357, 29.9792, 31.1342)
+	if km < 11 || km > 14 {
+		t.Errorf("unexpected distance %.2f km", km)
+	}
+	if d := HaversineKm(30, 31, 30, 31); d != 0 {
+		t.Errorf("expected 0 km for identical points, got %.4f", d)
+	}
+}
+
+// TestEstimateETAMinutes tests ETA from driver position and stored estimates
+func TestEstimateETAMinutes(t *testing.T) {
+	now := time.Now()
+	lat, lon := 30.0444, 31.2357
+
+	order := &Order{Status: "out_for_delivery", DeliveryLatitude: 30.0444, DeliveryLongitude: 31.2857}
+	eta := EstimateETAMinutes(order, &TrackingDriver{Latitude: &lat, Longitude: &lon}, now)
+	if eta == nil || *eta < 8 || *eta > 14 {
+		t.Errorf("unexpected driver ETA: %v", eta)
+	}
+
+	estimated := now.Add(20 * time.Minute)
+	order = &Order{Status: "preparing", EstimatedDeliveryTime: &estimated}
+	if eta := EstimateETAMinutes(order, nil, now); eta == nil || *eta != 20 {
+		t.Errorf("expected 20 minute ETA, got %v", eta)
+	}
+
+	order = &Order{Status: "delivered", EstimatedDeliveryTime: &estimated}
+	if eta := EstimateETAMinutes(order, nil, now); eta != nil {
+		t.Errorf("expected no ETA for delivered order, got %d", *eta)
+	}
+}
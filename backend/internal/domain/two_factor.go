@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"errors"
+	"strings"
+	"time"
+)
+
+// Tenant two-factor policies
+const (
+	TwoFactorPolicyOptional = "optional" // Users decide whether to enable 2FA
+	TwoFactorPolicyManagers = "managers" // Required for owners, admins and managers
+	TwoFactorPolicyAll      = "all"      // Required for every user of the tenant
+)
+
+// Two-factor defaults
+const (
+	TwoFactorChallengeTTL = 5 * time.Minute
+	// MaxTwoFactorAttempts is how many wrong codes a challenge accepts before it is spent
+	MaxTwoFactorAttempts = 5
+	TrustedDeviceTTL     = 30 * 24 * time.Hour
+	RecoveryCodeCount    = 10
+	// TrustedDeviceCookie holds the remember-device token
+	TrustedDeviceCookie = "pos_trusted_device"
+)
+
+var (
+	ErrInvalidTwoFactorChallenge = errors.New("invalid or expired two-factor challenge, please sign in again")
+	ErrInvalidTwoFactorCode      = errors.New("invalid authentication code")
+	ErrTwoFactorNotEnabled       = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorAlreadyEnabled   = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorSetupRequired    = errors.New("two-factor authentication must be set up before signing in")
+	ErrTwoFactorRequired         = errors.New("two-factor authentication is required by your organization")
+)
+
+// managerRoles are the users.role values covered by the managers policy
+var managerRoles = map[string]bool{"owner": true, "admin": true, "manager": true}
+
+// ValidTwoFactorPolicy reports whether a policy value is known
+func ValidTwoFactorPolicy(policy string) bool {
+	switch policy {
+	case TwoFactorPolicyOptional, TwoFactorPolicyManagers, TwoFactorPolicyAll:
+		return true
+	}
+	return false
+}
+
+// TwoFactorPolicyRequires reports whether a tenant policy makes 2FA mandatory for a user role
+func TwoFactorPolicyRequires(policy, role string) bool {
+	switch policy {
+	case TwoFactorPolicyAll:
+		return true
+	case TwoFactorPolicyManagers:
+		return managerRoles[strings.ToLower(role)]
+	}
+	return false
+}
+
+// NormalizeRecoveryCode upper-cases a recovery code and drops separators, so codes can be
+// typed with or without the dash
+func NormalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
+
+// TwoFactor is a user's TOTP enrollment
+type TwoFactor struct {
+	UserID          int
+	SecretEncrypted string
+	EnabledAt       *time.Time
+	LastUsedStep    int64
+	CreatedAt       time.Time
+}
+
+// TwoFactorChallenge is a pending second login step
+type TwoFactorChallenge struct {
+	ID            int64
+	UserID        int
+	SetupRequired bool
+	Attempts      int
+	ExpiresAt     time.Time
+}
+
+// TwoFactorChallengeResponse is returned by login instead of tokens when a second step is needed
+type TwoFactorChallengeResponse struct {
+	Success           bool   `json:"success"`
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	SetupRequired     bool   `json:"setup_required"`
+	ChallengeToken    string `json:"challenge_token"`
+	ExpiresIn         int64  `json:"expires_in"`
+	Message           string `json:"message"`
+}
+
+// TwoFactorVerifyRequest completes a login challenge with a TOTP code or a recovery code
+type TwoFactorVerifyRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+	Code           string `json:"code"`
+	RecoveryCode   string `json:"recovery_code"`
+	RememberDevice bool   `json:"remember_device"`
+	DeviceName     string `json:"device_name"`
+}
+
+// Validate requires the challenge and exactly one kind of code
+func (req *TwoFactorVerifyRequest) Validate() error {
+	if strings.TrimSpace(req.ChallengeToken) == "" {
+		return errors.New("challenge_token is required")
+	}
+	if strings.TrimSpace(req.Code) == "" && strings.TrimSpace(req.RecoveryCode) == "" {
+		return errors.New("code or recovery_code is required")
+	}
+	return nil
+}
+
+// TwoFactorChallengeRequest identifies a login challenge
+type TwoFactorChallengeRequest struct {
+	ChallengeToken string `json:"challenge_token"`
+}
+
+// TwoFactorPasswordRequest confirms a 2FA change with the current password and, once
+// enabled, a code from the authenticator app
+type TwoFactorPasswordRequest struct {
+	CurrentPassword string `json:"current_password"`
+	Code            string `json:"code"`
+}
+
+// TwoFactorCodeRequest carries a code from the authenticator app
+type TwoFactorCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// TwoFactorSetup is the pending enrollment shown to the user: the secret for manual entry
+// and the otpauth:// URI for the QR code
+type TwoFactorSetup struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+	Issuer          string `json:"issuer"`
+	Account         string `json:"account"`
+}
+
+// TwoFactorStatus describes a user's 2FA state
+type TwoFactorStatus struct {
+	Enabled                bool       `json:"enabled"`
+	EnabledAt              *time.Time `json:"enabled_at,omitempty"`
+	Required               bool       `json:"required"`
+	Policy                 string     `json:"policy"`
+	RecoveryCodesRemaining int        `json:"recovery_codes_remaining"`
+	TrustedDevices         int        `json:"trusted_devices"`
+}
+
+// RecoveryCodesResponse returns newly generated recovery codes; they are only shown once
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// TwoFactorPolicyRequest updates the tenant's two-factor policy
+type TwoFactorPolicyRequest struct {
+	Policy string `json:"policy"`
+}
@@ -0,0 +1,56 @@
+package domain
+
+import "testing"
+
+// TestTwoFactorPolicyRequires tests which roles each tenant policy covers
+func TestTwoFactorPolicyRequires(t *testing.T) {
+	tests := []struct {
+		policy string
+		role   string
+		want   bool
+	}{
+		{TwoFactorPolicyOptional, "owner", false},
+		{TwoFactorPolicyManagers, "owner", true},
+		{TwoFactorPolicyManagers, "Manager", true},
+		{TwoFactorPolicyManagers, "cashier", false},
+		{TwoFactorPolicyAll, "cashier", true},
+		{"unknown", "owner", false},
+	}
+	for _, tt := range tests {
+		if got := TwoFactorPolicyRequires(tt.policy, tt.role); got != tt.want {
+			t.Errorf("TwoFactorPolicyRequires(%q, %q) = %v, want %v", tt.policy, tt.role, got, tt.want)
+		}
+	}
+
+	if ValidTwoFactorPolicy("required") {
+		t.Error("ValidTwoFactorPolicy accepted an unknown policy")
+	}
+}
+
+// TestNormalizeRecoveryCode tests that codes match however they are typed
+func TestNormalizeRecoveryCode(t *testing.T) {
+	for _, code := range []string{"ABCDE-FGHJK", "abcde-fghjk", " abcde fghjk ", "ABCDEFGHJK"} {
+		if got := NormalizeRecoveryCode(code); got != "ABCDEFGHJK" {
+			t.Errorf("NormalizeRecoveryCode(%q) = %q, want ABCDEFGHJK", code, got)
+		}
+	}
+}
+
+// TestTwoFactorVerifyRequestValidate tests that a challenge and a code are required
+func TestTwoFactorVerifyRequestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		req     TwoFactorVerifyRequest
+		wantErr bool
+	}{
+		{"totp code", TwoFactorVerifyRequest{ChallengeToken: "c", Code: "123456"}, false},
+		{"recovery code", TwoFactorVerifyRequest{ChallengeToken: "c", RecoveryCode: "ABCDE-FGHJK"}, false},
+		{"missing challenge", TwoFactorVerifyRequest{Code: "123456"}, true},
+		{"missing code", TwoFactorVerifyRequest{ChallengeToken: "c"}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"errors"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -20,6 +21,9 @@ type User struct {
 	LastLogin    *time.Time `json:"last_login,omitempty"`
 	CreatedAt    time.Time  `json:"created_at"`
 	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// EmailVerifiedAt is nil until the user opens the verification link sent to their email
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 }
 
 // VerifyPassword checks if the provided password matches the user's password hash
@@ -35,16 +39,25 @@ type RegisterRequest struct {
 	RestaurantName  string `json:"restaurant_name"`
 	RestaurantSlug  string `json:"restaurant_slug"` // Subdomain for website (e.g., "my-restaurant")
 	Phone           string `json:"phone"`
+	DeviceName      string `json:"device_name,omitempty"` // Shown in the session list; derived from the user agent when empty
 }
 
 type LoginRequest struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email      string `json:"email"`
+	Password   string `json:"password"`
+	DeviceName string `json:"device_name,omitempty"`
+	// TrustedDeviceToken comes from the remember-device cookie, not the body
+	TrustedDeviceToken string `json:"-"`
 }
 
 type AuthResponse struct {
-	User  *User  `json:"user"`
-	Token string `json:"token"`
+	User         *User  `json:"user"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"` // Access token lifetime in seconds
+	SessionID    string `json:"session_id,omitempty"`
+	// RecoveryCodes is only set when 2FA was enrolled as part of signing in
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
 }
 
 type ForgotPasswordRequest struct {
@@ -66,10 +79,22 @@ type MultiTenantLoginResponse struct {
 	MultipleTenants    bool           `json:"multiple_tenants"`
 	Tenants            []TenantInfo   `json:"tenants"`
 	Message            string         `json:"message,omitempty"`
+	// SelectionToken must be sent back to /api/v1/auth/login/confirm with the chosen tenant
+	SelectionToken string `json:"selection_token"`
+	ExpiresIn      int64  `json:"expires_in"` // Selection token lifetime in seconds
 }
 
+// TenantSelectionTTL is how long a tenant selection token can be used after the password step
+const TenantSelectionTTL = 5 * time.Minute
+
+var ErrInvalidTenantSelection = errors.New("invalid or expired tenant selection, please sign in again")
+
 // TenantSelectionRequest is sent to /api/v1/auth/login/confirm
 type TenantSelectionRequest struct {
-	Email    string `json:"email"`
-	TenantID int    `json:"tenant_id"`
+	SelectionToken string `json:"selection_token"`
+	Email          string `json:"email,omitempty"` // Optional; must match the selection token when sent
+	TenantID       int    `json:"tenant_id"`
+	DeviceName     string `json:"device_name,omitempty"`
+	// TrustedDeviceToken comes from the remember-device cookie, not the body
+	TrustedDeviceToken string `json:"-"`
 }
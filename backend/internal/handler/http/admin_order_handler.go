@@ -0,0 +1,65 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// AdminOrderHandler handles order management by restaurant staff
+type AdminOrderHandler struct {
+	orderUC *usecase.OrderUseCase
+}
+
+// NewAdminOrderHandler creates new admin order handler
+func NewAdminOrderHandler(orderUC *usecase.OrderUseCase) *AdminOrderHandler {
+	return &AdminOrderHandler{orderUC: orderUC}
+}
+
+// respondAdminOrderError maps order errors to HTTP status codes
+func respondAdminOrderError(w http.ResponseWriter, err error) {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "cannot transition"):
+		respondError(w, http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "invalid"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// UpdateOrderStatus moves an order through its lifecycle.
+// Marking a delivery order ready starts automatic driver dispatch.
+// PUT /api/v1/admin/orders/{id}/status
+func (h *AdminOrderHandler) UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req domain.UpdateOrderStatusRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Status == "" {
+		respondError(w, http.StatusBadRequest, "status is required")
+		return
+	}
+
+	tenantID, restaurantID := middleware.GetTenantID(r), middleware.GetRestaurantID(r)
+	if err := h.orderUC.UpdateOrderStatus(tenantID, restaurantID, id, &req); err != nil {
+		respondAdminOrderError(w, err)
+		return
+	}
+
+	order, err := h.orderUC.GetOrder(tenantID, restaurantID, id)
+	if err != nil {
+		respondAdminOrderError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, order)
+}
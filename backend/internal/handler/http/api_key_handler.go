@@ -0,0 +1,154 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// APIKeyHandler manages the tenant's API keys for integrations
+type APIKeyHandler struct {
+	apiKeys *usecase.APIKeyUseCase
+}
+
+func NewAPIKeyHandler(apiKeys *usecase.APIKeyUseCase) *APIKeyHandler {
+	return &APIKeyHandler{apiKeys: apiKeys}
+}
+
+// signedInUser refuses requests made with an API key: keys are managed by people
+func signedInUser(w http.ResponseWriter, r *http.Request) bool {
+	if middleware.GetAPIKey(r) != nil {
+		respondError(w, http.StatusForbidden, domain.ErrAPIKeyManagement.Error())
+		return false
+	}
+	return true
+}
+
+// ListAPIKeys returns the tenant's API keys
+// GET /api/v1/api-keys
+func (h *APIKeyHandler) ListAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if !signedInUser(w, r) {
+		return
+	}
+
+	keys, err := h.apiKeys.List(r.Context(), middleware.GetTenantID(r))
+	if err != nil {
+		respondAPIKeyError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: keys})
+}
+
+// CreateAPIKey issues a key acting for the signed-in user in their current restaurant.
+// The key is only returned in this response.
+// POST /api/v1/api-keys
+func (h *APIKeyHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !signedInUser(w, r) {
+		return
+	}
+
+	var req domain.CreateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	created, err := h.apiKeys.Create(r.Context(), middleware.GetTenantID(r), middleware.GetUserID(r), middleware.GetRestaurantID(r), &req)
+	if err != nil {
+		respondAPIKeyError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, APIResponse{Success: true, Data: created, Message: "Store this key now, it will not be shown again"})
+}
+
+// UpdateAPIKey changes a key's scopes or rate limit
+// PUT /api/v1/api-keys/{id}
+func (h *APIKeyHandler) UpdateAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !signedInUser(w, r) {
+		return
+	}
+
+	keyID, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	var req domain.UpdateAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	key, err := h.apiKeys.Update(r.Context(), middleware.GetTenantID(r), middleware.GetUserID(r), keyID, &req)
+	if err != nil {
+		respondAPIKeyError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: key})
+}
+
+// RevokeAPIKey disables a key
+// DELETE /api/v1/api-keys/{id}
+func (h *APIKeyHandler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !signedInUser(w, r) {
+		return
+	}
+
+	keyID, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	if err := h.apiKeys.Revoke(r.Context(), middleware.GetTenantID(r), keyID); err != nil {
+		respondAPIKeyError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "API key revoked"})
+}
+
+// GetAPIKeyUsage returns a key's request statistics
+// GET /api/v1/api-keys/{id}/usage
+func (h *APIKeyHandler) GetAPIKeyUsage(w http.ResponseWriter, r *http.Request) {
+	if !signedInUser(w, r) {
+		return
+	}
+
+	keyID, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	usage, err := h.apiKeys.Usage(r.Context(), middleware.GetTenantID(r), keyID)
+	if err != nil {
+		respondAPIKeyError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: usage})
+}
+
+func respondAPIKeyError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrAPIKeyNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrAPIKeyScopeTooWide):
+		respondError(w, http.StatusForbidden, err.Error())
+	case strings.Contains(err.Error(), "required"), strings.Contains(err.Error(), "must"),
+		strings.Contains(err.Error(), "invalid"), strings.Contains(err.Error(), "is not"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "Failed to process API key request")
+	}
+}
@@ -0,0 +1,261 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// AttendanceHandler handles the HR time clock: punches, kiosk mode, settings and overtime approval
+type AttendanceHandler struct {
+	attendanceUC *usecase.AttendanceUseCase
+}
+
+// NewAttendanceHandler creates new attendance handler
+func NewAttendanceHandler(attendanceUC *usecase.AttendanceUseCase) *AttendanceHandler {
+	return &AttendanceHandler{attendanceUC: attendanceUC}
+}
+
+// respondAttendanceError maps time clock errors to HTTP status codes
+func respondAttendanceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidKioskLogin):
+		respondError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrOutsideGeofence),
+		errors.Is(err, domain.ErrKioskDisabled):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrAttendanceNotFound),
+		strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrAlreadyClockedIn),
+		errors.Is(err, domain.ErrAlreadyClockedOut),
+		errors.Is(err, domain.ErrNotClockedIn),
+		errors.Is(err, domain.ErrAlreadyOnBreak),
+		errors.Is(err, domain.ErrNotOnBreak),
+		errors.Is(err, domain.ErrOvertimeNotPending),
+		errors.Is(err, domain.ErrBadgeCodeTaken):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrLocationRequired),
+		errors.Is(err, domain.ErrRestaurantNoLocation),
+		errors.Is(err, domain.ErrInvalidClockPINFormat),
+		strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "must"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// decodePunch reads a punch request, which must name the employee
+func decodePunch(w http.ResponseWriter, r *http.Request) (*domain.ClockPunchRequest, bool) {
+	var req domain.ClockPunchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.EmployeeID <= 0 {
+		respondError(w, http.StatusBadRequest, "employee_id is required")
+		return nil, false
+	}
+	return &req, true
+}
+
+// ClockIn records an employee's arrival
+// POST /api/v1/hr/attendance/clock-in
+func (h *AttendanceHandler) ClockIn(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePunch(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	attendance, err := h.attendanceUC.ClockIn(tenantID, restaurantID, req, middleware.ClientIP(r))
+	if err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, attendance)
+}
+
+// ClockOut records an employee's departure and calculates their hours
+// POST /api/v1/hr/attendance/clock-out
+func (h *AttendanceHandler) ClockOut(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePunch(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	attendance, err := h.attendanceUC.ClockOut(tenantID, restaurantID, req, middleware.ClientIP(r))
+	if err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, attendance)
+}
+
+// StartBreak starts an employee's break
+// POST /api/v1/hr/attendance/break-start
+func (h *AttendanceHandler) StartBreak(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePunch(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	attendance, err := h.attendanceUC.StartBreak(tenantID, restaurantID, req.EmployeeID)
+	if err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, attendance)
+}
+
+// EndBreak ends an employee's break
+// POST /api/v1/hr/attendance/break-end
+func (h *AttendanceHandler) EndBreak(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodePunch(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	attendance, err := h.attendanceUC.EndBreak(tenantID, restaurantID, req.EmployeeID)
+	if err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, attendance)
+}
+
+// KioskPunch clocks in or out, or starts or ends a break, at the shared time clock by badge or PIN
+// POST /api/v1/hr/time-clock/kiosk
+func (h *AttendanceHandler) KioskPunch(w http.ResponseWriter, r *http.Request) {
+	var req domain.KioskPunchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	resp, err := h.attendanceUC.KioskPunch(tenantID, restaurantID, &req, middleware.ClientIP(r))
+	if err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// ListAttendance returns attendance records, by default for the current week
+// GET /api/v1/hr/attendance?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *AttendanceHandler) ListAttendance(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if from == nil {
+		t := domain.WeekStartOf(time.Now())
+		from = &t
+	}
+	if to == nil {
+		t := from.AddDate(0, 0, 6)
+		to = &t
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	records, err := h.attendanceUC.ListAttendance(tenantID, restaurantID, *from, *to)
+	if err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, records)
+}
+
+// ListPendingOvertime returns attendance with overtime awaiting approval
+// GET /api/v1/hr/attendance/overtime
+func (h *AttendanceHandler) ListPendingOvertime(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	records, err := h.attendanceUC.ListPendingOvertime(tenantID, restaurantID)
+	if err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, records)
+}
+
+// ApproveOvertime approves an attendance record's overtime
+// POST /api/v1/hr/attendance/{id}/approve-overtime
+func (h *AttendanceHandler) ApproveOvertime(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid attendance ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	attendance, err := h.attendanceUC.ApproveOvertime(tenantID, restaurantID, int(id), int(middleware.GetUserID(r)))
+	if err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, attendance)
+}
+
+// GetSettings returns the restaurant's time clock settings
+// GET /api/v1/hr/time-clock/settings
+func (h *AttendanceHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	settings, err := h.attendanceUC.GetSettings(tenantID, restaurantID)
+	if err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, settings)
+}
+
+// SaveSettings replaces the restaurant's time clock settings
+// PUT /api/v1/hr/time-clock/settings
+func (h *AttendanceHandler) SaveSettings(w http.ResponseWriter, r *http.Request) {
+	var settings domain.TimeClockSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	saved, err := h.attendanceUC.SaveSettings(tenantID, restaurantID, &settings, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, saved)
+}
+
+// SetClockCredentials sets or clears an employee's kiosk PIN and badge
+// PUT /api/v1/hr/employees/{id}/clock-credentials
+func (h *AttendanceHandler) SetClockCredentials(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+	var req domain.ClockCredentialsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	if err := h.attendanceUC.SetClockCredentials(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r))); err != nil {
+		respondAttendanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Clock credentials updated",
+	})
+}
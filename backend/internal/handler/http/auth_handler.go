@@ -2,19 +2,35 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
 	"pos-saas/internal/usecase"
 )
 
 type AuthHandler struct {
-	useCase *usecase.AuthUseCase
+	useCase  *usecase.AuthUseCase
+	sessions *usecase.SessionUseCase
+	accounts *usecase.AccountUseCase
 }
 
-func NewAuthHandler(useCase *usecase.AuthUseCase) *AuthHandler {
-	return &AuthHandler{useCase: useCase}
+func NewAuthHandler(useCase *usecase.AuthUseCase, sessions *usecase.SessionUseCase, accounts *usecase.AccountUseCase) *AuthHandler {
+	return &AuthHandler{useCase: useCase, sessions: sessions, accounts: accounts}
+}
+
+// sessionClient describes the device a request comes from
+func sessionClient(r *http.Request, deviceName string) domain.SessionClient {
+	return domain.SessionClient{
+		DeviceName: deviceName,
+		UserAgent:  r.UserAgent(),
+		IPAddress:  middleware.ClientIP(r),
+	}
 }
 
 // Register handles user registration
@@ -25,7 +41,7 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	response, err := h.useCase.Register(&req)
+	response, err := h.useCase.Register(r.Context(), &req, sessionClient(r, req.DeviceName))
 	if err != nil {
 		respondError(w, http.StatusBadRequest, err.Error())
 		return
@@ -49,10 +65,20 @@ func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	fmt.Printf("Request decoded - Email: %s, Password length: %d\n", req.Email, len(req.Password))
+	req.TrustedDeviceToken = trustedDeviceToken(r)
 
-	response, err := h.useCase.Login(&req)
+	response, err := h.useCase.Login(r.Context(), &req, sessionClient(r, req.DeviceName))
 	if err != nil {
 		fmt.Printf("ERROR: Login failed: %v\n", err)
+		var loginErr *domain.LoginError
+		if errors.As(err, &loginErr) {
+			respondLoginError(w, loginErr)
+			return
+		}
+		if errors.Is(err, domain.ErrPasswordLoginDisabled) {
+			respondError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		respondError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
@@ -74,11 +100,16 @@ func (h *AuthHandler) LoginConfirm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	fmt.Printf("Request decoded - Email: %s, TenantID: %d\n", req.Email, req.TenantID)
+	fmt.Printf("Request decoded - TenantID: %d\n", req.TenantID)
+	req.TrustedDeviceToken = trustedDeviceToken(r)
 
-	response, err := h.useCase.LoginConfirm(&req)
+	response, err := h.useCase.LoginConfirm(r.Context(), &req, sessionClient(r, req.DeviceName))
 	if err != nil {
 		fmt.Printf("ERROR: LoginConfirm failed: %v\n", err)
+		if errors.Is(err, domain.ErrPasswordLoginDisabled) {
+			respondError(w, http.StatusForbidden, err.Error())
+			return
+		}
 		respondError(w, http.StatusUnauthorized, err.Error())
 		return
 	}
@@ -111,14 +142,325 @@ func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := h.useCase.ForgotPassword(&req)
+	if err := h.accounts.ForgotPassword(r.Context(), &req, middleware.ClientIP(r)); err != nil {
+		respondAccountError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "If an account exists for this email, a password reset link has been sent",
+	})
+}
+
+// ResetPassword sets a new password with the token from a reset email
+// POST /api/v1/auth/reset-password
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req domain.ResetPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.accounts.ResetPassword(r.Context(), &req, middleware.ClientIP(r)); err != nil {
+		respondAccountError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "Password has been reset. Please sign in with your new password",
+	})
+}
+
+// VerifyEmail confirms an email address with the token from a verification email
+// POST /api/v1/auth/verify-email
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var req domain.AccountTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.accounts.VerifyEmail(r.Context(), &req); err != nil {
+		respondAccountError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "Email address verified",
+	})
+}
+
+// ResendEmailVerification sends a new verification email to the signed-in user
+// POST /api/v1/auth/verify-email/resend
+func (h *AuthHandler) ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	if err := h.accounts.ResendEmailVerification(r.Context(), int(middleware.GetUserID(r)), middleware.ClientIP(r)); err != nil {
+		respondAccountError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "Verification email sent",
+	})
+}
+
+// ChangeEmail asks to move the signed-in user to a new email address
+// POST /api/v1/users/{userId}/settings/change-email
+func (h *AuthHandler) ChangeEmail(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
 	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	if userID != middleware.GetUserID(r) {
+		respondError(w, http.StatusForbidden, "Unauthorized")
+		return
+	}
+
+	var req domain.ChangeEmailRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.accounts.RequestEmailChange(r.Context(), int(userID), &req); err != nil {
+		respondAccountError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "A confirmation link has been sent to the new email address",
+	})
+}
+
+// ConfirmEmailChange applies an email change with the token sent to the new address
+// POST /api/v1/auth/confirm-email-change
+func (h *AuthHandler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	var req domain.AccountTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.accounts.ConfirmEmailChange(r.Context(), &req); err != nil {
+		respondAccountError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{
+		"message": "Email address updated",
+	})
+}
+
+func respondAccountError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidAccountToken), errors.Is(err, domain.ErrPasswordReused),
+		errors.Is(err, domain.ErrEmailUnchanged):
 		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrIncorrectPassword):
+		respondError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrEmailTaken), errors.Is(err, domain.ErrEmailVerified):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrAccountEmailTooSoon):
+		respondError(w, http.StatusTooManyRequests, err.Error())
+	case strings.Contains(err.Error(), "required"), strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "must"), strings.Contains(err.Error(), "do not match"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "Failed to process account request")
+	}
+}
+
+// Refresh exchanges a refresh token for new access and refresh tokens
+// POST /api/v1/auth/refresh
+func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
+	var req domain.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	response, err := h.sessions.Refresh(r.Context(), req.RefreshToken, sessionClient(r, ""))
+	if err != nil {
+		respondSessionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// Logout revokes the session of a refresh token
+// POST /api/v1/auth/logout
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req domain.RefreshTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.sessions.Logout(r.Context(), req.RefreshToken); err != nil {
+		respondSessionError(w, err)
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]string{
-		"message": "Password reset email sent",
+		"message": "Logged out",
+	})
+}
+
+// ListSessions lists the signed-in user's active sessions
+// GET /api/v1/auth/sessions
+func (h *AuthHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserClaims(r)
+	sessions, err := h.sessions.ListSessions(r.Context(), claims.TenantID, claims.UserID, claims.SessionID)
+	if err != nil {
+		respondSessionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: sessions})
+}
+
+// RevokeSession signs the user out of one of their sessions
+// DELETE /api/v1/auth/sessions/{id}
+func (h *AuthHandler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserClaims(r)
+	if err := h.sessions.RevokeSession(r.Context(), claims.TenantID, claims.UserID, r.PathValue("id"), domain.SessionRevokedByUser); err != nil {
+		respondSessionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Session revoked"})
+}
+
+// RevokeOtherSessions signs the user out of every session except the current one
+// DELETE /api/v1/auth/sessions
+func (h *AuthHandler) RevokeOtherSessions(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserClaims(r)
+	revoked, err := h.sessions.RevokeOtherSessions(r.Context(), claims.TenantID, claims.UserID, claims.SessionID)
+	if err != nil {
+		respondSessionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: map[string]int64{"revoked": revoked}})
+}
+
+// ListRestaurants lists the restaurants the signed-in user can switch to
+// GET /api/v1/auth/restaurants
+func (h *AuthHandler) ListRestaurants(w http.ResponseWriter, r *http.Request) {
+	claims := middleware.GetUserClaims(r)
+	restaurants, err := h.sessions.Restaurants(r.Context(), claims.TenantID, claims.UserID, int(middleware.GetRestaurantID(r)))
+	if err != nil {
+		respondSessionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: restaurants})
+}
+
+// SwitchRestaurant issues an access token for another restaurant the user has a role in.
+// The refresh token is unchanged and keeps issuing tokens for the new restaurant.
+// POST /api/v1/auth/switch-restaurant
+func (h *AuthHandler) SwitchRestaurant(w http.ResponseWriter, r *http.Request) {
+	var req domain.SwitchRestaurantRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	claims := middleware.GetUserClaims(r)
+	response, err := h.sessions.SwitchRestaurant(r.Context(), claims.TenantID, claims.UserID, claims.SessionID, req.RestaurantID)
+	if err != nil {
+		respondSessionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// ListUserSessions lists the active sessions of a user of the tenant
+// GET /api/v1/users/{userId}/sessions
+func (h *AuthHandler) ListUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	sessions, err := h.sessions.ListSessions(r.Context(), int(middleware.GetTenantID(r)), int(userID), middleware.GetSessionID(r))
+	if err != nil {
+		respondSessionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: sessions})
+}
+
+// RevokeUserSession revokes a session of a user of the tenant
+// DELETE /api/v1/users/{userId}/sessions/{id}
+func (h *AuthHandler) RevokeUserSession(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.sessions.RevokeSession(r.Context(), int(middleware.GetTenantID(r)), int(userID), r.PathValue("id"), domain.SessionRevokedByAdmin); err != nil {
+		respondSessionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Session revoked"})
+}
+
+// RevokeUserSessions signs a user of the tenant out of every session
+// DELETE /api/v1/users/{userId}/sessions
+func (h *AuthHandler) RevokeUserSessions(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	revoked, err := h.sessions.RevokeAllSessions(r.Context(), int(middleware.GetTenantID(r)), int(userID), domain.SessionRevokedByAdmin)
+	if err != nil {
+		respondSessionError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: map[string]int64{"revoked": revoked}})
+}
+
+func respondSessionError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidRefreshToken), errors.Is(err, domain.ErrRefreshTokenReused),
+		errors.Is(err, domain.ErrSessionRevoked), errors.Is(err, domain.ErrRBACUserNotFound):
+		respondError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrRestaurantAccess):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrSessionNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case strings.Contains(err.Error(), "required"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "Failed to process session request")
+	}
+}
+
+// respondLoginError reports a rejected or throttled login. Throttled attempts get 429 and a
+// Retry-After header; captcha_required tells the client to show a CAPTCHA.
+func respondLoginError(w http.ResponseWriter, err *domain.LoginError) {
+	retryAfter := int64(math.Ceil(err.RetryAfter.Seconds()))
+	code := http.StatusUnauthorized
+	if !errors.Is(err, domain.ErrInvalidCredentials) {
+		code = http.StatusTooManyRequests
+		w.Header().Set("Retry-After", strconv.FormatInt(retryAfter, 10))
+	}
+	respondJSON(w, code, map[string]interface{}{
+		"error":            err.Error(),
+		"retry_after":      retryAfter,
+		"captcha_required": err.CaptchaRequired,
+		"locked":           errors.Is(err, domain.ErrAccountLocked),
 	})
 }
 
@@ -0,0 +1,282 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/repository"
+	"pos-saas/internal/usecase"
+)
+
+// DiningHandler handles dine-in tables and tabs
+type DiningHandler struct {
+	diningUC       *usecase.DiningUseCase
+	restaurantRepo *repository.RestaurantRepository
+}
+
+// NewDiningHandler creates new dining handler
+func NewDiningHandler(diningUC *usecase.DiningUseCase, restaurantRepo *repository.RestaurantRepository) *DiningHandler {
+	return &DiningHandler{
+		diningUC:       diningUC,
+		restaurantRepo: restaurantRepo,
+	}
+}
+
+// respondDiningError maps dine-in errors to HTTP status codes
+func respondDiningError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrTableNotFound), errors.Is(err, domain.ErrTabNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrTabNotOpen),
+		strings.Contains(err.Error(), "already has an open tab"),
+		strings.Contains(err.Error(), "duplicate key"):
+		respondError(w, http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "cannot"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+func pathID(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(r.PathValue(name), 10, 64)
+}
+
+// ResolveTable resolves a scanned QR token so the menu can be opened pre-bound to the table
+// GET /api/v1/public/tables/{token}
+func (h *DiningHandler) ResolveTable(w http.ResponseWriter, r *http.Request) {
+	table, err := h.diningUC.ResolveTableToken(r.PathValue("token"))
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+
+	restaurant, err := h.restaurantRepo.GetByID(table.RestaurantID)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Restaurant not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]interface{}{
+			"table_token":     table.QRToken,
+			"table_number":    table.TableNumber,
+			"label":           table.Label,
+			"area":            table.Area,
+			"restaurant_slug": restaurant.Slug,
+			"restaurant_name": restaurant.Name,
+		},
+	})
+}
+
+// ListTables lists the restaurant's tables with their open tabs
+// GET /api/v1/dining/tables
+func (h *DiningHandler) ListTables(w http.ResponseWriter, r *http.Request) {
+	tables, err := h.diningUC.ListTables(middleware.GetTenantID(r), middleware.GetRestaurantID(r))
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, tables)
+}
+
+// CreateTable creates a table and its QR token
+// POST /api/v1/dining/tables
+func (h *DiningHandler) CreateTable(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateDiningTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	table, err := h.diningUC.CreateTable(middleware.GetTenantID(r), middleware.GetRestaurantID(r), &req)
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, table)
+}
+
+// UpdateTable updates a table
+// PUT /api/v1/dining/tables/{id}
+func (h *DiningHandler) UpdateTable(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid table ID")
+		return
+	}
+
+	var req domain.UpdateDiningTableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	table, err := h.diningUC.UpdateTable(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id, &req)
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, table)
+}
+
+// RegenerateTableToken issues a new QR token for a table
+// POST /api/v1/dining/tables/{id}/regenerate-token
+func (h *DiningHandler) RegenerateTableToken(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid table ID")
+		return
+	}
+
+	table, err := h.diningUC.RegenerateTableToken(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id)
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, table)
+}
+
+// DeleteTable removes a table
+// DELETE /api/v1/dining/tables/{id}
+func (h *DiningHandler) DeleteTable(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid table ID")
+		return
+	}
+
+	if err := h.diningUC.DeleteTable(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id); err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListOpenTabs lists all open tabs
+// GET /api/v1/dining/tabs
+func (h *DiningHandler) ListOpenTabs(w http.ResponseWriter, r *http.Request) {
+	tabs, err := h.diningUC.ListOpenTabs(middleware.GetTenantID(r), middleware.GetRestaurantID(r))
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, tabs)
+}
+
+// GetTab retrieves a tab with its order rounds
+// GET /api/v1/dining/tabs/{id}
+func (h *DiningHandler) GetTab(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tab ID")
+		return
+	}
+
+	tab, err := h.diningUC.GetTab(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id)
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, tab)
+}
+
+// GetTabBill previews the bill of a tab
+// GET /api/v1/dining/tabs/{id}/bill
+func (h *DiningHandler) GetTabBill(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tab ID")
+		return
+	}
+
+	bill, err := h.diningUC.GetTabBill(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id)
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, bill)
+}
+
+// TransferTab moves a tab to another table
+// POST /api/v1/dining/tabs/{id}/transfer
+func (h *DiningHandler) TransferTab(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tab ID")
+		return
+	}
+
+	var req domain.TransferTabRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.TargetTableID == 0 {
+		respondError(w, http.StatusBadRequest, "target_table_id is required")
+		return
+	}
+
+	tab, err := h.diningUC.TransferTab(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id, &req)
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, tab)
+}
+
+// MergeTabs merges other tabs into this tab
+// POST /api/v1/dining/tabs/{id}/merge
+func (h *DiningHandler) MergeTabs(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tab ID")
+		return
+	}
+
+	var req domain.MergeTabsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tab, err := h.diningUC.MergeTabs(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id, &req)
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, tab)
+}
+
+// CloseTab closes a tab into its final bill
+// POST /api/v1/dining/tabs/{id}/close
+func (h *DiningHandler) CloseTab(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid tab ID")
+		return
+	}
+
+	var req domain.CloseTabRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	var closedBy *int64
+	if userID := middleware.GetUserID(r); userID > 0 {
+		closedBy = &userID
+	}
+
+	bill, err := h.diningUC.CloseTab(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id, closedBy, &req)
+	if err != nil {
+		respondDiningError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, bill)
+}
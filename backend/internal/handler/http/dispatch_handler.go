@@ -0,0 +1,172 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// DispatchHandler handles driver dispatch for delivery orders
+type DispatchHandler struct {
+	dispatchUC *usecase.DispatchUseCase
+}
+
+// NewDispatchHandler creates new dispatch handler
+func NewDispatchHandler(dispatchUC *usecase.DispatchUseCase) *DispatchHandler {
+	return &DispatchHandler{dispatchUC: dispatchUC}
+}
+
+// respondDispatchError maps dispatch errors to HTTP status codes
+func respondDispatchError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrOfferNotFound),
+		strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrOfferNotPending),
+		errors.Is(err, domain.ErrNoDriverAvailable),
+		strings.Contains(err.Error(), "already has a driver"):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrOfferExpired):
+		respondError(w, http.StatusGone, err.Error())
+	case errors.Is(err, domain.ErrOrderNotDelivery),
+		strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "cannot"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// DispatchOrder starts or resumes automatic dispatch for an order
+// POST /api/v1/dispatch/orders/{id}
+func (h *DispatchHandler) DispatchOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	offer, err := h.dispatchUC.DispatchOrder(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), id)
+	if err != nil {
+		respondDispatchError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, offer)
+}
+
+// GetDispatchStatus returns the offers and driver assignment of an order
+// GET /api/v1/dispatch/orders/{id}
+func (h *DispatchHandler) GetDispatchStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	status, err := h.dispatchUC.GetDispatchStatus(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), id)
+	if err != nil {
+		respondDispatchError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, status)
+}
+
+// ManualAssign assigns an order to a chosen driver, overriding automatic dispatch
+// POST /api/v1/dispatch/orders/{id}/assign
+func (h *DispatchHandler) ManualAssign(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	var req domain.ManualAssignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	assignment, err := h.dispatchUC.ManualAssign(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), id, &req, middleware.GetUserID(r))
+	if err != nil {
+		respondDispatchError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, assignment)
+}
+
+// GetDriverRoute returns a driver's planned drop-off sequence with ETAs
+// GET /api/v1/dispatch/drivers/{id}/route
+func (h *DispatchHandler) GetDriverRoute(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+
+	route, err := h.dispatchUC.GetDriverRoute(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), int(id))
+	if err != nil {
+		respondDispatchError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, route)
+}
+
+// AcceptOffer records a driver accepting a job offer (e.g. confirmed by phone)
+// POST /api/v1/dispatch/offers/{id}/accept
+func (h *DispatchHandler) AcceptOffer(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := h.decodeOfferResponse(w, r)
+	if !ok {
+		return
+	}
+
+	assignment, err := h.dispatchUC.AcceptOffer(r.Context(), id, req.DriverID)
+	if err != nil {
+		respondDispatchError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, assignment)
+}
+
+// DeclineOffer records a driver declining a job offer; the next driver is offered the job
+// POST /api/v1/dispatch/offers/{id}/decline
+func (h *DispatchHandler) DeclineOffer(w http.ResponseWriter, r *http.Request) {
+	id, req, ok := h.decodeOfferResponse(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.dispatchUC.DeclineOffer(r.Context(), id, req.DriverID); err != nil {
+		respondDispatchError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Offer declined",
+	})
+}
+
+// decodeOfferResponse reads the offer ID and driver, and checks the offer belongs to the restaurant
+func (h *DispatchHandler) decodeOfferResponse(w http.ResponseWriter, r *http.Request) (int64, *domain.OfferResponseRequest, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid offer ID")
+		return 0, nil, false
+	}
+
+	if _, err := h.dispatchUC.GetOffer(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id); err != nil {
+		respondDispatchError(w, err)
+		return 0, nil, false
+	}
+
+	var req domain.OfferResponseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.DriverID <= 0 {
+		respondError(w, http.StatusBadRequest, "driver_id is required")
+		return 0, nil, false
+	}
+	return id, &req, true
+}
@@ -0,0 +1,324 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// locationStreamIdleTimeout closes a location stream that has gone quiet
+const locationStreamIdleTimeout = 60 * time.Second
+
+// DriverAppHandler handles the driver-facing app API
+type DriverAppHandler struct {
+	driverAppUC *usecase.DriverAppUseCase
+	upgrader    websocket.Upgrader
+}
+
+// NewDriverAppHandler creates new driver app handler
+func NewDriverAppHandler(driverAppUC *usecase.DriverAppUseCase) *DriverAppHandler {
+	return &DriverAppHandler{
+		driverAppUC: driverAppUC,
+		upgrader: websocket.Upgrader{
+			ReadBufferSize:  1024,
+			WriteBufferSize: 1024,
+			// The stream is authenticated by the driver token, not by cookies,
+			// and the native app does not send a browser origin
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// respondDriverAppError maps driver app errors to HTTP status codes
+func respondDriverAppError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidDriverCredentials):
+		respondError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrDriverInactive):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrJobNotFound),
+		errors.Is(err, domain.ErrOfferNotFound),
+		strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrOfferNotPending),
+		errors.Is(err, domain.ErrDriverHasActiveJobs):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrOfferExpired):
+		respondError(w, http.StatusGone, err.Error())
+	case errors.Is(err, domain.ErrInvalidDeliveryPIN),
+		errors.Is(err, domain.ErrDeliveryProofRequired),
+		errors.Is(err, domain.ErrInvalidCoordinates),
+		strings.Contains(err.Error(), "cannot"),
+		strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "must be"),
+		strings.Contains(err.Error(), "exceeds"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// Login signs a driver in to the app
+// POST /api/v1/driver/auth/login
+func (h *DriverAppHandler) Login(w http.ResponseWriter, r *http.Request) {
+	var req domain.DriverLoginRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	resp, err := h.driverAppUC.Login(r.Context(), &req)
+	if err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// SetPassword sets a driver's app password
+// PUT /api/v1/dispatch/drivers/{id}/password
+func (h *DriverAppHandler) SetPassword(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+
+	var req domain.SetDriverPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.driverAppUC.SetPassword(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), int(id), &req); err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Driver password updated",
+	})
+}
+
+// Me returns the signed-in driver
+// GET /api/v1/driver/me
+func (h *DriverAppHandler) Me(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, middleware.GetDriver(r))
+}
+
+// SetDuty puts the driver on or off duty
+// PUT /api/v1/driver/duty
+func (h *DriverAppHandler) SetDuty(w http.ResponseWriter, r *http.Request) {
+	var req domain.DriverDutyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	driver, err := h.driverAppUC.SetDuty(r.Context(), middleware.GetDriver(r), &req)
+	if err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, driver)
+}
+
+// ListJobs returns the driver's open offers and active deliveries
+// GET /api/v1/driver/jobs
+func (h *DriverAppHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	jobs, err := h.driverAppUC.ListJobs(r.Context(), middleware.GetDriver(r))
+	if err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, jobs)
+}
+
+// GetRoute returns the driver's optimized drop-off sequence with ETAs
+// GET /api/v1/driver/route
+func (h *DriverAppHandler) GetRoute(w http.ResponseWriter, r *http.Request) {
+	route, err := h.driverAppUC.GetRoute(r.Context(), middleware.GetDriver(r))
+	if err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, route)
+}
+
+// GetEarnings returns the driver's unsettled deliveries, earnings and cash to hand in
+// GET /api/v1/driver/earnings
+func (h *DriverAppHandler) GetEarnings(w http.ResponseWriter, r *http.Request) {
+	earnings, err := h.driverAppUC.GetEarnings(r.Context(), middleware.GetDriver(r))
+	if err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, earnings)
+}
+
+// AcceptOffer accepts a job offer
+// POST /api/v1/driver/offers/{id}/accept
+func (h *DriverAppHandler) AcceptOffer(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid offer ID")
+		return
+	}
+
+	job, err := h.driverAppUC.AcceptOffer(r.Context(), middleware.GetDriver(r), id)
+	if err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+// RejectOffer declines a job offer
+// POST /api/v1/driver/offers/{id}/reject
+func (h *DriverAppHandler) RejectOffer(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid offer ID")
+		return
+	}
+
+	if err := h.driverAppUC.RejectOffer(r.Context(), middleware.GetDriver(r), id); err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Offer rejected",
+	})
+}
+
+// PickUp marks a job as collected from the restaurant; the order goes out for delivery
+// POST /api/v1/driver/jobs/{id}/pickup
+func (h *DriverAppHandler) PickUp(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	job, err := h.driverAppUC.PickUp(r.Context(), middleware.GetDriver(r), int(id))
+	if err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+// Deliver completes a job with proof of delivery.
+// Accepts JSON {"pin": "1234", "cash_collected": 12.5} or a multipart form with a "photo" file
+// and optional "pin" and "cash_collected" fields.
+// POST /api/v1/driver/jobs/{id}/deliver
+func (h *DriverAppHandler) Deliver(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid job ID")
+		return
+	}
+
+	var req domain.DeliverRequest
+	var photo *multipart.FileHeader
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		if err := r.ParseMultipartForm(10 << 20); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid form data")
+			return
+		}
+		req.PIN = r.FormValue("pin")
+		if v := r.FormValue("cash_collected"); v != "" {
+			cash, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, "Invalid cash_collected")
+				return
+			}
+			req.CashCollected = &cash
+		}
+		if _, header, err := r.FormFile("photo"); err == nil {
+			photo = header
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	job, err := h.driverAppUC.Deliver(r.Context(), middleware.GetDriver(r), int(id), &req, photo)
+	if err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, job)
+}
+
+// UpdateLocation records a single location update, for clients that cannot keep a stream open
+// POST /api/v1/driver/location
+func (h *DriverAppHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	var req domain.UpdateLocationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	result, err := h.driverAppUC.RecordLocation(r.Context(), middleware.GetDriver(r), &req)
+	if err != nil {
+		respondDriverAppError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, result)
+}
+
+// StreamLocation upgrades to a WebSocket that receives location updates from the driver app.
+// Each message is an UpdateLocationRequest and is answered with {"stored": bool, "events": [...]}
+// or {"error": "..."}; updates sent faster than the location interval are acknowledged but not
+// stored. Events are the geofence arrivals and departures the update triggered.
+// GET /api/v1/driver/location/stream
+func (h *DriverAppHandler) StreamLocation(w http.ResponseWriter, r *http.Request) {
+	driver := middleware.GetDriver(r)
+
+	ws, err := h.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("driver app: failed to upgrade location stream for driver %d: %v", driver.ID, err)
+		return
+	}
+	defer ws.Close()
+
+	ws.SetReadLimit(4096)
+	ws.SetReadDeadline(time.Now().Add(locationStreamIdleTimeout))
+	ws.SetPongHandler(func(string) error {
+		return ws.SetReadDeadline(time.Now().Add(locationStreamIdleTimeout))
+	})
+
+	for {
+		var req domain.UpdateLocationRequest
+		if err := ws.ReadJSON(&req); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("driver app: location stream of driver %d closed: %v", driver.ID, err)
+			}
+			return
+		}
+		ws.SetReadDeadline(time.Now().Add(locationStreamIdleTimeout))
+
+		var reply interface{}
+		result, err := h.driverAppUC.RecordLocation(r.Context(), driver, &req)
+		if err != nil {
+			reply = map[string]interface{}{"error": err.Error()}
+		} else {
+			reply = result
+		}
+		if err := ws.WriteJSON(reply); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// DriverLocationHandler handles geofence events and location history retention
+type DriverLocationHandler struct {
+	locationUC *usecase.DriverLocationUseCase
+}
+
+// NewDriverLocationHandler creates new driver location handler
+func NewDriverLocationHandler(locationUC *usecase.DriverLocationUseCase) *DriverLocationHandler {
+	return &DriverLocationHandler{locationUC: locationUC}
+}
+
+// ListOrderEvents returns the driver arrivals and departures detected for an order
+// GET /api/v1/dispatch/orders/{id}/geofence-events
+func (h *DriverLocationHandler) ListOrderEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid order ID")
+		return
+	}
+
+	events, err := h.locationUC.ListOrderEvents(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, events)
+}
+
+// GetRetentionPolicy returns how long the tenant's driver location history is kept
+// GET /api/v1/dispatch/location-retention
+func (h *DriverLocationHandler) GetRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.locationUC.GetRetentionPolicy(middleware.GetTenantID(r))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, policy)
+}
+
+// SaveRetentionPolicy sets how long the tenant's driver location history is kept
+// PUT /api/v1/dispatch/location-retention
+func (h *DriverLocationHandler) SaveRetentionPolicy(w http.ResponseWriter, r *http.Request) {
+	var policy domain.LocationRetentionPolicy
+	if err := json.NewDecoder(r.Body).Decode(&policy); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	saved, err := h.locationUC.SaveRetentionPolicy(middleware.GetTenantID(r), &policy)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidRetentionPolicy) {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, saved)
+}
@@ -0,0 +1,271 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// EarningsHandler handles driver earnings rules, reports and cash settlements
+type EarningsHandler struct {
+	earningsUC *usecase.EarningsUseCase
+}
+
+// NewEarningsHandler creates new earnings handler
+func NewEarningsHandler(earningsUC *usecase.EarningsUseCase) *EarningsHandler {
+	return &EarningsHandler{earningsUC: earningsUC}
+}
+
+// respondEarningsError maps earnings errors to HTTP status codes
+func respondEarningsError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrEarningRuleNotFound),
+		errors.Is(err, domain.ErrSettlementNotFound),
+		strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrNothingToSettle),
+		errors.Is(err, domain.ErrDeliveriesSettled):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvalidExportFormat),
+		errors.Is(err, domain.ErrNegativeCashHandedIn),
+		strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "cannot"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// parseDateRange reads optional from/to query dates (YYYY-MM-DD); to covers the whole day
+func parseDateRange(r *http.Request) (*time.Time, *time.Time, error) {
+	var from, to *time.Time
+	if v := r.URL.Query().Get("from"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid from date, expected YYYY-MM-DD")
+		}
+		from = &t
+	}
+	if v := r.URL.Query().Get("to"); v != "" {
+		t, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid to date, expected YYYY-MM-DD")
+		}
+		t = t.Add(24*time.Hour - time.Nanosecond)
+		to = &t
+	}
+	return from, to, nil
+}
+
+// respondFile writes an export as a download
+func respondFile(w http.ResponseWriter, data []byte, contentType, filename string) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", filename))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// ListRules returns the restaurant's earning rules
+// GET /api/v1/dispatch/earning-rules
+func (h *EarningsHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.earningsUC.ListRules(middleware.GetTenantID(r), middleware.GetRestaurantID(r))
+	if err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, rules)
+}
+
+// SaveRule creates or replaces the restaurant default rule or a driver's rule
+// PUT /api/v1/dispatch/earning-rules
+func (h *EarningsHandler) SaveRule(w http.ResponseWriter, r *http.Request) {
+	var req domain.EarningRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	rule, err := h.earningsUC.SaveRule(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), &req)
+	if err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, rule)
+}
+
+// DeleteRule removes an earning rule
+// DELETE /api/v1/dispatch/earning-rules/{id}
+func (h *EarningsHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rule ID")
+		return
+	}
+
+	if err := h.earningsUC.DeleteRule(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id); err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Earning rule deleted",
+	})
+}
+
+// GetDriverStats returns a driver's delivery, earnings and cash figures
+// GET /api/v1/dispatch/drivers/{id}/stats
+func (h *EarningsHandler) GetDriverStats(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+
+	stats, err := h.earningsUC.GetDriverStats(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), int(id))
+	if err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, stats)
+}
+
+// GetEarningsReport returns a driver's deliveries and earnings in a period
+// GET /api/v1/dispatch/drivers/{id}/earnings?from=YYYY-MM-DD&to=YYYY-MM-DD&format=json|csv
+func (h *EarningsHandler) GetEarningsReport(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	report, err := h.earningsUC.GetEarningsReport(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), int(id), from, to)
+	if err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" || format == "json" {
+		respondJSON(w, http.StatusOK, report)
+		return
+	}
+	data, contentType, filename, err := h.earningsUC.ExportEarningsReport(report, format)
+	if err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+	respondFile(w, data, contentType, filename)
+}
+
+// PreviewSettlement returns the driver's unsettled shift without closing it
+// GET /api/v1/dispatch/drivers/{id}/settlement
+func (h *EarningsHandler) PreviewSettlement(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+
+	settlement, err := h.earningsUC.PreviewSettlement(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), int(id))
+	if err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, settlement)
+}
+
+// SettleDriver closes the driver's shift with the cash they handed in
+// POST /api/v1/dispatch/drivers/{id}/settlement
+func (h *EarningsHandler) SettleDriver(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid driver ID")
+		return
+	}
+
+	var req domain.SettleDriverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	settlement, err := h.earningsUC.SettleDriver(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), int(id), &req, middleware.GetUserID(r))
+	if err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, settlement)
+}
+
+// ListSettlements returns settlements, optionally for one driver and period
+// GET /api/v1/dispatch/settlements?driver_id=&from=&to=&page=&limit=
+func (h *EarningsHandler) ListSettlements(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filters := &domain.SettlementFilters{From: from, To: to}
+	filters.DriverID, _ = strconv.Atoi(r.URL.Query().Get("driver_id"))
+	filters.Page, _ = strconv.Atoi(r.URL.Query().Get("page"))
+	filters.Limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+
+	settlements, total, err := h.earningsUC.ListSettlements(middleware.GetTenantID(r), middleware.GetRestaurantID(r), filters)
+	if err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"settlements": settlements,
+		"total":       total,
+		"page":        filters.Page,
+		"limit":       filters.Limit,
+	})
+}
+
+// GetSettlement returns a settlement with its deliveries
+// GET /api/v1/dispatch/settlements/{id}
+func (h *EarningsHandler) GetSettlement(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid settlement ID")
+		return
+	}
+
+	settlement, err := h.earningsUC.GetSettlement(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id)
+	if err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, settlement)
+}
+
+// ExportSettlement downloads a settlement
+// GET /api/v1/dispatch/settlements/{id}/export?format=csv|json
+func (h *EarningsHandler) ExportSettlement(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid settlement ID")
+		return
+	}
+
+	data, contentType, filename, err := h.earningsUC.ExportSettlement(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id, r.URL.Query().Get("format"))
+	if err != nil {
+		respondEarningsError(w, err)
+		return
+	}
+	respondFile(w, data, contentType, filename)
+}
@@ -0,0 +1,161 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// EmployeeSelfServiceHandler links employees to accounts and serves the signed-in
+// employee's own attendance and leave
+type EmployeeSelfServiceHandler struct {
+	selfServiceUC *usecase.EmployeeSelfServiceUseCase
+}
+
+func NewEmployeeSelfServiceHandler(selfServiceUC *usecase.EmployeeSelfServiceUseCase) *EmployeeSelfServiceHandler {
+	return &EmployeeSelfServiceHandler{selfServiceUC: selfServiceUC}
+}
+
+// respondSelfServiceError reports a missing employee link, and otherwise the HR module's error
+func respondSelfServiceError(w http.ResponseWriter, err error, respond func(http.ResponseWriter, error)) {
+	switch {
+	case errors.Is(err, domain.ErrNoEmployeeRecord), errors.Is(err, domain.ErrRBACUserNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrUserAlreadyLinked):
+		respondError(w, http.StatusConflict, err.Error())
+	default:
+		respond(w, err)
+	}
+}
+
+// LinkUser links an employee to a login account; {"user_id": null} unlinks it
+// PUT /api/v1/hr/employees/{id}/user
+func (h *EmployeeSelfServiceHandler) LinkUser(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+	var req domain.LinkEmployeeUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	employee, err := h.selfServiceUC.LinkUser(r.Context(), tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondSelfServiceError(w, err, respondAttendanceError)
+		return
+	}
+	respondJSON(w, http.StatusOK, employee)
+}
+
+// GetEmployee returns the signed-in user's employee record
+// GET /api/v1/me/employee
+func (h *EmployeeSelfServiceHandler) GetEmployee(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	employee, err := h.selfServiceUC.Employee(tenantID, restaurantID, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondSelfServiceError(w, err, respondAttendanceError)
+		return
+	}
+	respondJSON(w, http.StatusOK, employee)
+}
+
+// decodeOwnPunch reads an optional punch body; the employee is always the signed-in user
+func decodeOwnPunch(w http.ResponseWriter, r *http.Request) (*domain.ClockPunchRequest, bool) {
+	var req domain.ClockPunchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return nil, false
+	}
+	return &req, true
+}
+
+// ClockIn records the signed-in employee's arrival
+// POST /api/v1/me/attendance/clock-in
+func (h *EmployeeSelfServiceHandler) ClockIn(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeOwnPunch(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	attendance, err := h.selfServiceUC.ClockIn(tenantID, restaurantID, int(middleware.GetUserID(r)), req, middleware.ClientIP(r))
+	if err != nil {
+		respondSelfServiceError(w, err, respondAttendanceError)
+		return
+	}
+	respondJSON(w, http.StatusOK, attendance)
+}
+
+// ClockOut records the signed-in employee's departure
+// POST /api/v1/me/attendance/clock-out
+func (h *EmployeeSelfServiceHandler) ClockOut(w http.ResponseWriter, r *http.Request) {
+	req, ok := decodeOwnPunch(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	attendance, err := h.selfServiceUC.ClockOut(tenantID, restaurantID, int(middleware.GetUserID(r)), req, middleware.ClientIP(r))
+	if err != nil {
+		respondSelfServiceError(w, err, respondAttendanceError)
+		return
+	}
+	respondJSON(w, http.StatusOK, attendance)
+}
+
+// ListLeaves returns the signed-in employee's leave requests
+// GET /api/v1/me/leaves
+func (h *EmployeeSelfServiceHandler) ListLeaves(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	leaves, err := h.selfServiceUC.Leaves(tenantID, restaurantID, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondSelfServiceError(w, err, respondLeaveError)
+		return
+	}
+	respondJSON(w, http.StatusOK, leaves)
+}
+
+// RequestLeave submits a leave request for the signed-in employee
+// POST /api/v1/me/leaves
+func (h *EmployeeSelfServiceHandler) RequestLeave(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateLeaveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	leave, err := h.selfServiceUC.RequestLeave(tenantID, restaurantID, int(middleware.GetUserID(r)), &req)
+	if err != nil {
+		respondSelfServiceError(w, err, respondLeaveError)
+		return
+	}
+	respondJSON(w, http.StatusCreated, leave)
+}
+
+// LeaveBalances returns the signed-in employee's leave balances for ?year= (default this year)
+// GET /api/v1/me/leave-balances
+func (h *EmployeeSelfServiceHandler) LeaveBalances(w http.ResponseWriter, r *http.Request) {
+	year, ok := leaveYear(r)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid year")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	balances, err := h.selfServiceUC.LeaveBalances(tenantID, restaurantID, int(middleware.GetUserID(r)), year)
+	if err != nil {
+		respondSelfServiceError(w, err, respondLeaveError)
+		return
+	}
+	respondJSON(w, http.StatusOK, balances)
+}
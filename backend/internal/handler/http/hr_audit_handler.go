@@ -0,0 +1,54 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/usecase"
+)
+
+// HRAuditHandler serves the HR audit trail
+type HRAuditHandler struct {
+	auditUC *usecase.HRAuditUseCase
+}
+
+// NewHRAuditHandler creates new HR audit handler
+func NewHRAuditHandler(auditUC *usecase.HRAuditUseCase) *HRAuditHandler {
+	return &HRAuditHandler{auditUC: auditUC}
+}
+
+// ListAuditLogs returns HR changes, filtered by entity_type, entity_id, user_id, from, to and limit
+// GET /api/v1/hr/audit-logs
+func (h *HRAuditHandler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := domain.HRAuditFilter{EntityType: query.Get("entity_type")}
+	for name, dst := range map[string]*int{
+		"entity_id": &filter.EntityID,
+		"user_id":   &filter.UserID,
+		"limit":     &filter.Limit,
+	} {
+		if v := query.Get(name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 0 {
+				respondError(w, http.StatusBadRequest, "Invalid "+name)
+				return
+			}
+			*dst = n
+		}
+	}
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.From, filter.To = from, to
+
+	tenantID, restaurantID := hrScope(r)
+	logs, err := h.auditUC.ListAuditLogs(tenantID, restaurantID, filter)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, logs)
+}
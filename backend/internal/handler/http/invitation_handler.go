@@ -0,0 +1,145 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// InvitationHandler invites staff and lets invitees accept
+type InvitationHandler struct {
+	invitations *usecase.InvitationUseCase
+}
+
+func NewInvitationHandler(invitations *usecase.InvitationUseCase) *InvitationHandler {
+	return &InvitationHandler{invitations: invitations}
+}
+
+// ListInvitations returns the tenant's invitations and how many seats are left
+// GET /api/v1/users/invitations
+func (h *InvitationHandler) ListInvitations(w http.ResponseWriter, r *http.Request) {
+	list, err := h.invitations.List(r.Context(), int(middleware.GetTenantID(r)))
+	if err != nil {
+		respondInvitationError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: list})
+}
+
+// CreateInvitation invites someone to the tenant by email or SMS
+// POST /api/v1/users/invitations
+func (h *InvitationHandler) CreateInvitation(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	invitation, err := h.invitations.Invite(r.Context(), permissionActor(r), &req)
+	if err != nil {
+		respondInvitationError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, APIResponse{Success: true, Data: invitation})
+}
+
+// ResendInvitation sends an invitation again with a new link
+// POST /api/v1/users/invitations/{id}/resend
+func (h *InvitationHandler) ResendInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	invitation, err := h.invitations.Resend(r.Context(), int(middleware.GetTenantID(r)), id)
+	if err != nil {
+		respondInvitationError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: invitation})
+}
+
+// RevokeInvitation cancels an open invitation
+// DELETE /api/v1/users/invitations/{id}
+func (h *InvitationHandler) RevokeInvitation(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid invitation ID")
+		return
+	}
+
+	if err := h.invitations.Revoke(r.Context(), int(middleware.GetTenantID(r)), id); err != nil {
+		respondInvitationError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Invitation revoked"})
+}
+
+// LookupInvitation shows the invitee who the invitation is for before they accept
+// POST /api/v1/auth/invitations/lookup
+func (h *InvitationHandler) LookupInvitation(w http.ResponseWriter, r *http.Request) {
+	var req domain.AccountTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	preview, err := h.invitations.Preview(r.Context(), req.Token)
+	if err != nil {
+		respondInvitationError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, preview)
+}
+
+// AcceptInvitation creates the invitee's account with the password they chose and signs them in
+// POST /api/v1/auth/invitations/accept
+func (h *InvitationHandler) AcceptInvitation(w http.ResponseWriter, r *http.Request) {
+	var req domain.AcceptInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	response, err := h.invitations.Accept(r.Context(), &req, sessionClient(r, req.DeviceName))
+	if err != nil {
+		respondInvitationError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, response)
+}
+
+func respondInvitationError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvitationNotFound), errors.Is(err, domain.ErrRoleNotFound),
+		strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrInvalidInvitation), errors.Is(err, domain.ErrInvitationPassword):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, domain.ErrInvitationRoles), errors.Is(err, domain.ErrPermissionDenied):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrUserLimitReached), errors.Is(err, domain.ErrEmailTaken),
+		errors.Is(err, domain.ErrInvitationNotPending), errors.Is(err, domain.ErrEmployeeAlreadyLinked),
+		errors.Is(err, domain.ErrInvitationEmailInUse):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInvitationNotSent):
+		respondError(w, http.StatusBadGateway, err.Error())
+	case strings.Contains(err.Error(), "required"), strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "must"), strings.Contains(err.Error(), "do not match"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "Failed to process invitation request")
+	}
+}
@@ -0,0 +1,449 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// LeaveHandler handles leave requests and approvals, leave policies, holiday calendars and leave balances
+type LeaveHandler struct {
+	leaveUC *usecase.LeaveUseCase
+}
+
+// NewLeaveHandler creates new leave handler
+func NewLeaveHandler(leaveUC *usecase.LeaveUseCase) *LeaveHandler {
+	return &LeaveHandler{leaveUC: leaveUC}
+}
+
+// respondLeaveError maps leave errors to HTTP status codes
+func respondLeaveError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrLeaveNotFound),
+		errors.Is(err, domain.ErrLeavePolicyNotFound),
+		errors.Is(err, domain.ErrHolidayCalendarNotFound),
+		strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrNotLeaveApprover):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrLeaveNotPending),
+		errors.Is(err, domain.ErrLeaveNotCancellable),
+		errors.Is(err, domain.ErrLeaveOverlaps),
+		errors.Is(err, domain.ErrLeavePolicyExists),
+		errors.Is(err, domain.ErrPublicHolidayExists):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrInsufficientLeaveBalance),
+		errors.Is(err, domain.ErrLeaveInProbation),
+		errors.Is(err, domain.ErrLeaveNoWorkingDays):
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+	case strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "must"),
+		strings.Contains(err.Error(), "not active"),
+		strings.Contains(err.Error(), "no policy"),
+		strings.Contains(err.Error(), "open on"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// leaveYear reads the ?year= query parameter, defaulting to the current year
+func leaveYear(r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("year")
+	if v == "" {
+		return time.Now().Year(), true
+	}
+	year, err := strconv.Atoi(v)
+	if err != nil || year < 2000 || year > 2100 {
+		return 0, false
+	}
+	return year, true
+}
+
+// ListPolicies returns the restaurant's leave policies
+// GET /api/v1/hr/leave-policies
+func (h *LeaveHandler) ListPolicies(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	policies, err := h.leaveUC.ListPolicies(tenantID, restaurantID)
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, policies)
+}
+
+// CreatePolicy creates the policy of a leave type
+// POST /api/v1/hr/leave-policies
+func (h *LeaveHandler) CreatePolicy(w http.ResponseWriter, r *http.Request) {
+	var req domain.LeavePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	policy, err := h.leaveUC.CreatePolicy(tenantID, restaurantID, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, policy)
+}
+
+// UpdatePolicy replaces a leave policy
+// PUT /api/v1/hr/leave-policies/{id}
+func (h *LeaveHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid leave policy ID")
+		return
+	}
+	var req domain.LeavePolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	policy, err := h.leaveUC.UpdatePolicy(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, policy)
+}
+
+// DeletePolicy deletes a leave policy
+// DELETE /api/v1/hr/leave-policies/{id}
+func (h *LeaveHandler) DeletePolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid leave policy ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	if err := h.leaveUC.DeletePolicy(tenantID, restaurantID, int(id), int(middleware.GetUserID(r))); err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Leave policy deleted",
+	})
+}
+
+// ListCalendars returns the restaurant's holiday calendars with their holidays
+// GET /api/v1/hr/holiday-calendars
+func (h *LeaveHandler) ListCalendars(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	calendars, err := h.leaveUC.ListCalendars(tenantID, restaurantID)
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, calendars)
+}
+
+// CreateCalendar creates a holiday calendar
+// POST /api/v1/hr/holiday-calendars
+func (h *LeaveHandler) CreateCalendar(w http.ResponseWriter, r *http.Request) {
+	var req domain.HolidayCalendarRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	calendar, err := h.leaveUC.SaveCalendar(tenantID, restaurantID, 0, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, calendar)
+}
+
+// UpdateCalendar replaces a holiday calendar's name, weekend days and default flag
+// PUT /api/v1/hr/holiday-calendars/{id}
+func (h *LeaveHandler) UpdateCalendar(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid holiday calendar ID")
+		return
+	}
+	var req domain.HolidayCalendarRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	calendar, err := h.leaveUC.SaveCalendar(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, calendar)
+}
+
+// DeleteCalendar deletes a holiday calendar
+// DELETE /api/v1/hr/holiday-calendars/{id}
+func (h *LeaveHandler) DeleteCalendar(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid holiday calendar ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	if err := h.leaveUC.DeleteCalendar(tenantID, restaurantID, int(id), int(middleware.GetUserID(r))); err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Holiday calendar deleted",
+	})
+}
+
+// AddHoliday adds a public holiday to a calendar
+// POST /api/v1/hr/holiday-calendars/{id}/holidays
+func (h *LeaveHandler) AddHoliday(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid holiday calendar ID")
+		return
+	}
+	var req domain.PublicHolidayRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	holiday, err := h.leaveUC.AddHoliday(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, holiday)
+}
+
+// DeleteHoliday removes a public holiday from a calendar
+// DELETE /api/v1/hr/holiday-calendars/{id}/holidays/{holidayId}
+func (h *LeaveHandler) DeleteHoliday(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid holiday calendar ID")
+		return
+	}
+	holidayID, err := pathID(r, "holidayId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid holiday ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	if err := h.leaveUC.DeleteHoliday(tenantID, restaurantID, int(id), int(holidayID), int(middleware.GetUserID(r))); err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Public holiday deleted",
+	})
+}
+
+// ListLeaves returns leave requests within ?from= and ?to=, the current year by default
+// GET /api/v1/hr/leaves
+func (h *LeaveHandler) ListLeaves(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	year := time.Now().Year()
+	if from == nil {
+		start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+		from = &start
+	}
+	if to == nil {
+		end := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+		to = &end
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	leaves, err := h.leaveUC.ListLeaves(tenantID, restaurantID, *from, *to)
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, leaves)
+}
+
+// RequestLeave requests leave for an employee, checked against the leave policy and balance
+// POST /api/v1/hr/leaves
+func (h *LeaveHandler) RequestLeave(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateLeaveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	leave, err := h.leaveUC.RequestLeave(tenantID, restaurantID, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, leave)
+}
+
+// GetLeave returns a leave request with its approval chain
+// GET /api/v1/hr/leaves/{id}
+func (h *LeaveHandler) GetLeave(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid leave ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	leave, err := h.leaveUC.GetLeave(tenantID, restaurantID, int(id))
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, leave)
+}
+
+// PendingApprovals returns the leave requests waiting on the current user
+// GET /api/v1/hr/leaves/pending-approvals
+func (h *LeaveHandler) PendingApprovals(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	leaves, err := h.leaveUC.PendingApprovals(tenantID, restaurantID, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, leaves)
+}
+
+// ApproveLeave approves the current level of a leave request
+// POST /api/v1/hr/leaves/{id}/approve
+func (h *LeaveHandler) ApproveLeave(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.leaveUC.ApproveLeave)
+}
+
+// RejectLeave rejects a leave request
+// POST /api/v1/hr/leaves/{id}/reject
+func (h *LeaveHandler) RejectLeave(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.leaveUC.RejectLeave)
+}
+
+// CancelLeave cancels a pending or approved leave request
+// POST /api/v1/hr/leaves/{id}/cancel
+func (h *LeaveHandler) CancelLeave(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.leaveUC.CancelLeave)
+}
+
+// decide runs an approval, rejection or cancellation with the notes in the body
+func (h *LeaveHandler) decide(w http.ResponseWriter, r *http.Request, action func(tenantID, restaurantID, id, userID int, notes string) (*domain.Leave, error)) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid leave ID")
+		return
+	}
+	var req domain.LeaveDecisionRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	leave, err := action(tenantID, restaurantID, int(id), int(middleware.GetUserID(r)), strings.TrimSpace(req.Notes))
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, leave)
+}
+
+// EmployeeBalances returns an employee's leave balances for ?year=
+// GET /api/v1/hr/employees/{id}/leave-balances
+func (h *LeaveHandler) EmployeeBalances(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+	year, ok := leaveYear(r)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid year")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	balances, err := h.leaveUC.EmployeeBalances(tenantID, restaurantID, int(id), year)
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, balances)
+}
+
+// BalanceHistory returns the changes to an employee's leave balances in ?year=
+// GET /api/v1/hr/employees/{id}/leave-balances/history
+func (h *LeaveHandler) BalanceHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+	year, ok := leaveYear(r)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "Invalid year")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	history, err := h.leaveUC.BalanceHistory(tenantID, restaurantID, int(id), year)
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, history)
+}
+
+// AdjustBalance adds days to or removes days from an employee's leave balance
+// POST /api/v1/hr/employees/{id}/leave-balances/adjust
+func (h *LeaveHandler) AdjustBalance(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+	var req domain.LeaveBalanceAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	balance, err := h.leaveUC.AdjustBalance(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondLeaveError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, balance)
+}
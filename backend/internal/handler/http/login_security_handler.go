@@ -0,0 +1,67 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// LoginSecurityHandler lets admins see and lift the sign-in lockout of the tenant's users
+type LoginSecurityHandler struct {
+	guard *usecase.LoginGuardUseCase
+}
+
+func NewLoginSecurityHandler(guard *usecase.LoginGuardUseCase) *LoginSecurityHandler {
+	return &LoginSecurityHandler{guard: guard}
+}
+
+// Status returns the lockout state and recent failed sign-ins of a user
+// GET /api/v1/users/{userId}/login-status
+func (h *LoginSecurityHandler) Status(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	status, err := h.guard.Status(r.Context(), int(middleware.GetTenantID(r)), int(userID))
+	if err != nil {
+		respondLoginSecurityError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: status})
+}
+
+// Unlock lifts the lockout of a user and forgets their failed sign-ins
+// POST /api/v1/users/{userId}/unlock
+func (h *LoginSecurityHandler) Unlock(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	err = h.guard.Unlock(r.Context(), int(middleware.GetTenantID(r)), int(userID), int(middleware.GetUserID(r)), sessionClient(r, ""))
+	if err != nil {
+		respondLoginSecurityError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Account unlocked"})
+}
+
+func respondLoginSecurityError(w http.ResponseWriter, err error) {
+	if errors.Is(err, domain.ErrRBACUserNotFound) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if errors.Is(err, domain.ErrAccountLockShared) {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	respondError(w, http.StatusInternalServerError, "Failed to process login security request")
+}
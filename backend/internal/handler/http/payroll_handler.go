@@ -0,0 +1,350 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// PayrollHandler handles payroll runs, payroll settings, deduction rules and payslips
+type PayrollHandler struct {
+	payrollUC *usecase.PayrollUseCase
+}
+
+// NewPayrollHandler creates new payroll handler
+func NewPayrollHandler(payrollUC *usecase.PayrollUseCase) *PayrollHandler {
+	return &PayrollHandler{payrollUC: payrollUC}
+}
+
+// respondPayrollError maps payroll errors to HTTP status codes
+func respondPayrollError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrPayrollRunNotFound),
+		errors.Is(err, domain.ErrDeductionRuleNotFound),
+		errors.Is(err, domain.ErrSalaryNotFound),
+		strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrPayrollRunNotDraft),
+		errors.Is(err, domain.ErrPayrollRunNotApproved),
+		errors.Is(err, domain.ErrPayrollRunClosed),
+		errors.Is(err, domain.ErrPayrollRunOverlaps),
+		errors.Is(err, domain.ErrPayrollSalaryExists),
+		errors.Is(err, domain.ErrSalaryNotApproved):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrPayrollNoEmployees),
+		errors.Is(err, domain.ErrDeductionsExceedGross),
+		errors.Is(err, domain.ErrInvalidExportFormat),
+		strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "must"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// GetSettings returns the restaurant's payroll settings
+// GET /api/v1/hr/payroll/settings
+func (h *PayrollHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	settings, err := h.payrollUC.GetSettings(tenantID, restaurantID)
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, settings)
+}
+
+// SaveSettings replaces the restaurant's payroll settings
+// PUT /api/v1/hr/payroll/settings
+func (h *PayrollHandler) SaveSettings(w http.ResponseWriter, r *http.Request) {
+	var settings domain.PayrollSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	saved, err := h.payrollUC.SaveSettings(tenantID, restaurantID, &settings, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, saved)
+}
+
+// ListDeductionRules returns the restaurant's deduction rules
+// GET /api/v1/hr/payroll/deduction-rules
+func (h *PayrollHandler) ListDeductionRules(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	rules, err := h.payrollUC.ListDeductionRules(tenantID, restaurantID)
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, rules)
+}
+
+// CreateDeductionRule creates a tax bracket, contribution percentage or fixed deduction
+// POST /api/v1/hr/payroll/deduction-rules
+func (h *PayrollHandler) CreateDeductionRule(w http.ResponseWriter, r *http.Request) {
+	var req domain.DeductionRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	rule, err := h.payrollUC.CreateDeductionRule(tenantID, restaurantID, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, rule)
+}
+
+// UpdateDeductionRule replaces a deduction rule
+// PUT /api/v1/hr/payroll/deduction-rules/{id}
+func (h *PayrollHandler) UpdateDeductionRule(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid deduction rule ID")
+		return
+	}
+	var req domain.DeductionRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	rule, err := h.payrollUC.UpdateDeductionRule(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, rule)
+}
+
+// DeleteDeductionRule deletes a deduction rule
+// DELETE /api/v1/hr/payroll/deduction-rules/{id}
+func (h *PayrollHandler) DeleteDeductionRule(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid deduction rule ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	if err := h.payrollUC.DeleteDeductionRule(tenantID, restaurantID, int(id), int(middleware.GetUserID(r))); err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Deduction rule deleted",
+	})
+}
+
+// ListRuns returns the restaurant's payroll runs
+// GET /api/v1/hr/payroll/runs
+func (h *PayrollHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	runs, err := h.payrollUC.ListRuns(tenantID, restaurantID)
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, runs)
+}
+
+// CreateRun generates a draft payroll run for a period
+// POST /api/v1/hr/payroll/runs
+func (h *PayrollHandler) CreateRun(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreatePayrollRunRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	run, err := h.payrollUC.CreateRun(tenantID, restaurantID, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, run)
+}
+
+// GetRun returns a payroll run with its salaries
+// GET /api/v1/hr/payroll/runs/{id}
+func (h *PayrollHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	run, err := h.payrollUC.GetRun(tenantID, restaurantID, id)
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, run)
+}
+
+// RecalculateRun regenerates a draft run's salaries
+// POST /api/v1/hr/payroll/runs/{id}/recalculate
+func (h *PayrollHandler) RecalculateRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	run, err := h.payrollUC.RecalculateRun(tenantID, restaurantID, id, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, run)
+}
+
+// AdjustSalary changes the bonus, allowances and loan or advance deductions of a draft run's salary
+// PUT /api/v1/hr/payroll/runs/{id}/salaries/{salaryId}
+func (h *PayrollHandler) AdjustSalary(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+	salaryID, err := pathID(r, "salaryId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid salary ID")
+		return
+	}
+	var req domain.SalaryAdjustmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	salary, err := h.payrollUC.AdjustSalary(tenantID, restaurantID, id, int(salaryID), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, salary)
+}
+
+// ApproveRun approves a draft run
+// POST /api/v1/hr/payroll/runs/{id}/approve
+func (h *PayrollHandler) ApproveRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	run, err := h.payrollUC.ApproveRun(tenantID, restaurantID, id, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, run)
+}
+
+// PayRun marks an approved run and its salaries as paid
+// POST /api/v1/hr/payroll/runs/{id}/pay
+func (h *PayrollHandler) PayRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+	var req domain.PayPayrollRunRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	run, err := h.payrollUC.PayRun(tenantID, restaurantID, id, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, run)
+}
+
+// CancelRun cancels a run that has not been paid
+// POST /api/v1/hr/payroll/runs/{id}/cancel
+func (h *PayrollHandler) CancelRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	run, err := h.payrollUC.CancelRun(tenantID, restaurantID, id, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, run)
+}
+
+// ExportRun downloads a run's payroll register
+// GET /api/v1/hr/payroll/runs/{id}/export?format=csv|json
+func (h *PayrollHandler) ExportRun(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	data, contentType, filename, err := h.payrollUC.ExportRun(tenantID, restaurantID, id, r.URL.Query().Get("format"))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondFile(w, data, contentType, filename)
+}
+
+// ExportPayslip downloads an employee's payslip from a run
+// GET /api/v1/hr/payroll/runs/{id}/payslips/{salaryId}?format=csv|json
+func (h *PayrollHandler) ExportPayslip(w http.ResponseWriter, r *http.Request) {
+	id, ok := payrollRunID(w, r)
+	if !ok {
+		return
+	}
+	salaryID, err := pathID(r, "salaryId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid salary ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	data, contentType, filename, err := h.payrollUC.ExportPayslip(tenantID, restaurantID, id, int(salaryID), r.URL.Query().Get("format"))
+	if err != nil {
+		respondPayrollError(w, err)
+		return
+	}
+	respondFile(w, data, contentType, filename)
+}
+
+// payrollRunID reads the payroll run ID from the path
+func payrollRunID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid payroll run ID")
+		return 0, false
+	}
+	return int(id), true
+}
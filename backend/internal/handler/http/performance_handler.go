@@ -0,0 +1,414 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// PerformanceHandler handles review criteria, review cycles, performance reviews and goals
+type PerformanceHandler struct {
+	performanceUC *usecase.PerformanceUseCase
+}
+
+// NewPerformanceHandler creates new performance handler
+func NewPerformanceHandler(performanceUC *usecase.PerformanceUseCase) *PerformanceHandler {
+	return &PerformanceHandler{performanceUC: performanceUC}
+}
+
+// respondPerformanceError maps performance errors to HTTP status codes
+func respondPerformanceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrReviewCycleNotFound),
+		errors.Is(err, domain.ErrPerformanceReviewNotFound),
+		errors.Is(err, domain.ErrReviewCriterionNotFound),
+		errors.Is(err, domain.ErrGoalNotFound),
+		strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrNotReviewParticipant):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrReviewCycleClosed),
+		errors.Is(err, domain.ErrReviewWrongStatus),
+		errors.Is(err, domain.ErrReviewCriterionExists),
+		errors.Is(err, domain.ErrGoalClosed):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrNoReviewCriteria),
+		errors.Is(err, domain.ErrNoEmployeesToReview):
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+	case strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "must"),
+		strings.Contains(err.Error(), "is not a review of"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// ListCriteria returns the restaurant's rating criteria
+// GET /api/v1/hr/review-criteria
+func (h *PerformanceHandler) ListCriteria(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	criteria, err := h.performanceUC.ListCriteria(tenantID, restaurantID)
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, criteria)
+}
+
+// CreateCriterion creates a rating criterion
+// POST /api/v1/hr/review-criteria
+func (h *PerformanceHandler) CreateCriterion(w http.ResponseWriter, r *http.Request) {
+	var req domain.ReviewCriterionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	criterion, err := h.performanceUC.CreateCriterion(tenantID, restaurantID, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, criterion)
+}
+
+// UpdateCriterion replaces a rating criterion
+// PUT /api/v1/hr/review-criteria/{id}
+func (h *PerformanceHandler) UpdateCriterion(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid review criterion ID")
+		return
+	}
+	var req domain.ReviewCriterionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	criterion, err := h.performanceUC.UpdateCriterion(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, criterion)
+}
+
+// DeleteCriterion deletes a rating criterion
+// DELETE /api/v1/hr/review-criteria/{id}
+func (h *PerformanceHandler) DeleteCriterion(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid review criterion ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	if err := h.performanceUC.DeleteCriterion(tenantID, restaurantID, int(id), int(middleware.GetUserID(r))); err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Review criterion deleted",
+	})
+}
+
+// ListCycles returns the restaurant's review cycles
+// GET /api/v1/hr/review-cycles
+func (h *PerformanceHandler) ListCycles(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	cycles, err := h.performanceUC.ListCycles(tenantID, restaurantID)
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, cycles)
+}
+
+// CreateCycle opens a review cycle with a review for each employee in it
+// POST /api/v1/hr/review-cycles
+func (h *PerformanceHandler) CreateCycle(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateReviewCycleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	cycle, err := h.performanceUC.CreateCycle(tenantID, restaurantID, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, cycle)
+}
+
+// GetCycle returns a review cycle with its reviews
+// GET /api/v1/hr/review-cycles/{id}
+func (h *PerformanceHandler) GetCycle(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid review cycle ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	cycle, err := h.performanceUC.GetCycle(tenantID, restaurantID, int(id))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, cycle)
+}
+
+// CloseCycle closes a review cycle
+// POST /api/v1/hr/review-cycles/{id}/close
+func (h *PerformanceHandler) CloseCycle(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid review cycle ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	cycle, err := h.performanceUC.CloseCycle(tenantID, restaurantID, int(id), int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, cycle)
+}
+
+// PendingReviews returns the reviews waiting on the user's manager assessment
+// GET /api/v1/hr/performance-reviews/pending
+func (h *PerformanceHandler) PendingReviews(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	reviews, err := h.performanceUC.PendingReviews(tenantID, restaurantID, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, reviews)
+}
+
+// GetReview returns a performance review with the goals set in it
+// GET /api/v1/hr/performance-reviews/{id}
+func (h *PerformanceHandler) GetReview(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid performance review ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	review, err := h.performanceUC.GetReview(tenantID, restaurantID, int(id))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, review)
+}
+
+// SubmitSelfAssessment stores the employee's own assessment
+// PUT /api/v1/hr/performance-reviews/{id}/self-assessment
+func (h *PerformanceHandler) SubmitSelfAssessment(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid performance review ID")
+		return
+	}
+	var req domain.SelfAssessmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	review, err := h.performanceUC.SubmitSelfAssessment(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, review)
+}
+
+// SubmitManagerAssessment stores the reviewer's assessment and sends the review to the employee
+// POST /api/v1/hr/performance-reviews/{id}/manager-assessment
+func (h *PerformanceHandler) SubmitManagerAssessment(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid performance review ID")
+		return
+	}
+	var req domain.ManagerAssessmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	review, err := h.performanceUC.SubmitManagerAssessment(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, review)
+}
+
+// AcknowledgeReview records the employee's acceptance of or dispute with their review
+// POST /api/v1/hr/performance-reviews/{id}/acknowledge
+func (h *PerformanceHandler) AcknowledgeReview(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid performance review ID")
+		return
+	}
+	var req domain.AcknowledgeReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	review, err := h.performanceUC.AcknowledgeReview(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, review)
+}
+
+// FinalizeReview records HR's sign-off on a review
+// POST /api/v1/hr/performance-reviews/{id}/finalize
+func (h *PerformanceHandler) FinalizeReview(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid performance review ID")
+		return
+	}
+	var req domain.FinalizeReviewRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	review, err := h.performanceUC.FinalizeReview(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, review)
+}
+
+// EmployeeReviews returns an employee's review history
+// GET /api/v1/hr/employees/{id}/performance-reviews
+func (h *PerformanceHandler) EmployeeReviews(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	reviews, err := h.performanceUC.EmployeeReviews(tenantID, restaurantID, int(id))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, reviews)
+}
+
+// EmployeeProfile returns an employee with their review history and goals
+// GET /api/v1/hr/employees/{id}/profile
+func (h *PerformanceHandler) EmployeeProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	profile, err := h.performanceUC.EmployeeProfile(tenantID, restaurantID, int(id))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, profile)
+}
+
+// EmployeeGoals returns an employee's goals
+// GET /api/v1/hr/employees/{id}/goals
+func (h *PerformanceHandler) EmployeeGoals(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	goals, err := h.performanceUC.EmployeeGoals(tenantID, restaurantID, int(id))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, goals)
+}
+
+// CreateGoal sets a goal for an employee
+// POST /api/v1/hr/employees/{id}/goals
+func (h *PerformanceHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+	var req domain.GoalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	goal, err := h.performanceUC.CreateGoal(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, goal)
+}
+
+// UpdateGoal records progress on a goal or changes its status
+// PUT /api/v1/hr/goals/{id}
+func (h *PerformanceHandler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid goal ID")
+		return
+	}
+	var req domain.GoalProgressRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	goal, err := h.performanceUC.UpdateGoalProgress(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondPerformanceError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, goal)
+}
@@ -119,6 +119,7 @@ func (h *ProductHandler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(errMsg, "required") ||
 			strings.Contains(errMsg, "must be") ||
 			strings.Contains(errMsg, "invalid") ||
+			strings.Contains(errMsg, "unknown allergen") ||
 			strings.Contains(errMsg, "limit") {
 			respondError(w, http.StatusBadRequest, errMsg)
 		} else {
@@ -320,7 +321,8 @@ func (h *ProductHandler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(errMsg, "not found") ||
 			strings.Contains(errMsg, "required") ||
 			strings.Contains(errMsg, "must be") ||
-			strings.Contains(errMsg, "invalid") {
+			strings.Contains(errMsg, "invalid") ||
+			strings.Contains(errMsg, "unknown allergen") {
 			respondError(w, http.StatusBadRequest, errMsg)
 		} else {
 			respondError(w, http.StatusInternalServerError, errMsg)
@@ -3,6 +3,7 @@ package http
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"pos-saas/internal/domain"
 	"pos-saas/internal/repository"
@@ -14,6 +15,7 @@ type PublicMenuHandler struct {
 	productUC      *usecase.ProductUseCase
 	restaurantRepo *repository.RestaurantRepository
 	categoryRepo   *repository.CategoryRepository
+	reviewUC       *usecase.ReviewUseCase
 }
 
 // NewPublicMenuHandler creates a new public menu handler
@@ -21,16 +23,18 @@ func NewPublicMenuHandler(
 	productUC *usecase.ProductUseCase,
 	restaurantRepo *repository.RestaurantRepository,
 	categoryRepo *repository.CategoryRepository,
+	reviewUC *usecase.ReviewUseCase,
 ) *PublicMenuHandler {
 	return &PublicMenuHandler{
 		productUC:      productUC,
 		restaurantRepo: restaurantRepo,
 		categoryRepo:   categoryRepo,
+		reviewUC:       reviewUC,
 	}
 }
 
 // GetRestaurantMenu returns the full menu for a restaurant
-// GET /api/v1/public/restaurants/{slug}/menu
+// GET /api/v1/public/restaurants/{slug}/menu?dietary=vegan&exclude_allergens=peanuts,milk
 func (h *PublicMenuHandler) GetRestaurantMenu(w http.ResponseWriter, r *http.Request) {
 	slug := r.PathValue("slug")
 	language := r.URL.Query().Get("lang")
@@ -58,6 +62,8 @@ func (h *PublicMenuHandler) GetRestaurantMenu(w http.ResponseWriter, r *http.Req
 		respondError(w, http.StatusInternalServerError, "Failed to load products")
 		return
 	}
+	dietary, excludeAllergens := menuFilters(r)
+	products = domain.FilterProducts(products, dietary, excludeAllergens)
 
 	// 4. Attach images (in a real app, do this more efficiently or in usecase)
 	// For now, list returns main_image_url which is enough for menu list usually.
@@ -97,6 +103,8 @@ func (h *PublicMenuHandler) GetCategoryProducts(w http.ResponseWriter, r *http.R
 		respondError(w, http.StatusInternalServerError, "Failed to load products")
 		return
 	}
+	dietary, excludeAllergens := menuFilters(r)
+	products = domain.FilterProducts(products, dietary, excludeAllergens)
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"products": products,
@@ -147,12 +155,13 @@ func convertImages(imgs []domain.ProductImage) []domain.ProductImage {
 	return imgs
 }
 
-// SearchProducts searches for products by name or description
-// GET /api/v1/public/restaurants/{slug}/search?q=pizza
+// SearchProducts runs a ranked full-text search with facets
+// GET /api/v1/public/restaurants/{slug}/search?q=pizza&category_id=3&dietary=vegan,gluten_free&exclude_allergens=peanuts
 func (h *PublicMenuHandler) SearchProducts(w http.ResponseWriter, r *http.Request) {
 	slug := r.PathValue("slug")
-	query := r.URL.Query().Get("q")
-	language := r.URL.Query().Get("lang")
+	params := r.URL.Query()
+	query := params.Get("q")
+	language := params.Get("lang")
 	if language == "" {
 		language = "en"
 	}
@@ -168,19 +177,99 @@ func (h *PublicMenuHandler) SearchProducts(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	products, err := h.productUC.SearchPublic(r.Context(), restaurant.ID, query, language)
+	dietary, excludeAllergens := menuFilters(r)
+	req := &domain.ProductSearchRequest{
+		Query:            query,
+		Lang:             language,
+		Dietary:          dietary,
+		ExcludeAllergens: excludeAllergens,
+	}
+	if categoryID, err := strconv.Atoi(params.Get("category_id")); err == nil {
+		req.CategoryID = &categoryID
+	}
+	if limit, err := strconv.Atoi(params.Get("limit")); err == nil {
+		req.Limit = limit
+	}
+
+	result, err := h.productUC.SearchMenu(restaurant.ID, req)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Search failed")
 		return
 	}
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"query":    query,
-		"results":  len(products),
-		"products": products,
+		"query":    result.Query,
+		"results":  result.Total,
+		"products": result.Products,
+		"facets":   result.Facets,
+	})
+}
+
+// SuggestProducts returns autocomplete suggestions
+// GET /api/v1/public/restaurants/{slug}/search/suggest?q=chi
+func (h *PublicMenuHandler) SuggestProducts(w http.ResponseWriter, r *http.Request) {
+	slug := r.PathValue("slug")
+	language := r.URL.Query().Get("lang")
+	if language == "" {
+		language = "en"
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	restaurant, err := h.restaurantRepo.GetBySlug(slug)
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Restaurant not found")
+		return
+	}
+
+	suggestions, err := h.productUC.SuggestMenu(restaurant.ID, r.URL.Query().Get("q"), language, limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Suggestions failed")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"suggestions": suggestions,
 	})
 }
 
+// GetAllergens returns the allergen vocabulary with translations
+// GET /api/v1/public/allergens
+func (h *PublicMenuHandler) GetAllergens(w http.ResponseWriter, r *http.Request) {
+	allergens, err := h.productUC.ListAllergens()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to load allergens")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"allergens": allergens,
+	})
+}
+
+// menuFilters reads the dietary and exclude_allergens query parameters.
+// Allergen aliases ("dairy", "nuts") are mapped to vocabulary codes.
+func menuFilters(r *http.Request) (dietary []string, excludeAllergens []string) {
+	dietary = splitParam(r.URL.Query().Get("dietary"))
+	for _, a := range splitParam(r.URL.Query().Get("exclude_allergens")) {
+		if code, ok := domain.NormalizeAllergenCode(a); ok {
+			a = code
+		}
+		excludeAllergens = append(excludeAllergens, a)
+	}
+	return dietary, excludeAllergens
+}
+
+// splitParam splits a comma separated query parameter
+func splitParam(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 // GetRestaurantProducts returns all products for a restaurant
 // GET /api/v1/public/restaurants/{slug}/products
 func (h *PublicMenuHandler) GetRestaurantProducts(w http.ResponseWriter, r *http.Request) {
@@ -201,6 +290,8 @@ func (h *PublicMenuHandler) GetRestaurantProducts(w http.ResponseWriter, r *http
 		respondError(w, http.StatusInternalServerError, "Failed to load products")
 		return
 	}
+	dietary, excludeAllergens := menuFilters(r)
+	products = domain.FilterProducts(products, dietary, excludeAllergens)
 
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"products": products,
@@ -244,6 +335,12 @@ func (h *PublicMenuHandler) GetRestaurantInfo(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	// Rating summary is optional, don't fail restaurant info if it's not available
+	var ratingSummary *domain.RatingSummary
+	if h.reviewUC != nil {
+		ratingSummary, _ = h.reviewUC.GetRatingSummary(int64(restaurant.ID))
+	}
+
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"id":                  restaurant.ID,
 		"tenant_id":           restaurant.TenantID,
@@ -262,6 +359,7 @@ func (h *PublicMenuHandler) GetRestaurantInfo(w http.ResponseWriter, r *http.Req
 		"delivery_enabled":    restaurant.DeliveryEnabled,
 		"reservation_enabled": restaurant.ReservationEnabled,
 		"status":              restaurant.Status,
+		"rating_summary":      ratingSummary,
 		"created_at":          restaurant.CreatedAt,
 		"updated_at":          restaurant.UpdatedAt,
 	})
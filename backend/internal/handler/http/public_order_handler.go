@@ -2,9 +2,9 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
 	"strings"
 
 	"pos-saas/internal/domain"
@@ -16,16 +16,22 @@ import (
 // PublicOrderHandler handles public-facing order API requests (minimal authentication)
 type PublicOrderHandler struct {
 	orderUC        *usecase.OrderUseCase
+	diningUC       *usecase.DiningUseCase
+	trackingUC     *usecase.OrderTrackingUseCase
 	restaurantRepo *repository.RestaurantRepository
 }
 
 // NewPublicOrderHandler creates a new public order handler
 func NewPublicOrderHandler(
 	orderUC *usecase.OrderUseCase,
+	diningUC *usecase.DiningUseCase,
+	trackingUC *usecase.OrderTrackingUseCase,
 	restaurantRepo *repository.RestaurantRepository,
 ) *PublicOrderHandler {
 	return &PublicOrderHandler{
 		orderUC:        orderUC,
+		diningUC:       diningUC,
+		trackingUC:     trackingUC,
 		restaurantRepo: restaurantRepo,
 	}
 }
@@ -33,6 +39,7 @@ func NewPublicOrderHandler(
 // CreateOrder creates a new customer order
 // POST /api/v1/public/orders
 // Request body: CreateOrderRequest
+// With a table token (X-Table-Token or ?table=) the order is added as a round to the table's open tab
 // Returns: Created Order with ID
 func (h *PublicOrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	// Get tenant and restaurant from context
@@ -66,11 +73,18 @@ func (h *PublicOrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request)
 		req.OrderSource = "website"
 	}
 
-	// Create order via usecase
-	order, err := h.orderUC.CreateOrder(tenantID, restaurantID, &req)
+	// Create order via usecase; dine-in orders go onto the table's open tab
+	var order *domain.Order
+	var err error
+	if table := middleware.GetDiningTable(r); table != nil && h.diningUC != nil {
+		order, err = h.diningUC.PlaceTableOrder(table, &req)
+	} else {
+		order, err = h.orderUC.CreateOrder(tenantID, restaurantID, &req)
+	}
 	if err != nil {
 		// Check error type and respond appropriately
 		if strings.Contains(err.Error(), "validation failed") ||
+			strings.Contains(err.Error(), "tab is not open") ||
 			strings.Contains(err.Error(), "not found") ||
 			strings.Contains(err.Error(), "not available") ||
 			strings.Contains(err.Error(), "insufficient") {
@@ -84,213 +98,86 @@ func (h *PublicOrderHandler) CreateOrder(w http.ResponseWriter, r *http.Request)
 	// Log order creation
 	fmt.Printf("Order created: %s for customer %s\n", order.OrderNumber, order.CustomerName)
 
+	// Tracking token lets the customer follow the order without an account
+	trackingToken := ""
+	if h.trackingUC != nil {
+		trackingToken = h.trackingUC.TrackingToken(order)
+	}
+
 	// Return created order
 	respondJSON(w, http.StatusCreated, map[string]interface{}{
 		"success": true,
 		"data": map[string]interface{}{
-			"id":            order.ID,
-			"order_number":  order.OrderNumber,
+			"id":             order.ID,
+			"order_number":   order.OrderNumber,
+			"tracking_token": trackingToken,
 			"customer_name": order.CustomerName,
 			"total_amount":  order.TotalAmount,
 			"status":        order.Status,
+			"order_source":  order.OrderSource,
+			"table_id":      order.TableID,
+			"tab_id":        order.TabID,
 			"created_at":    order.CreatedAt,
 		},
+		"allergen_warnings": order.AllergenWarnings,
 	})
 }
 
-// GetOrder retrieves order details by ID
-// GET /api/v1/public/orders/{id}
-// Returns: Full Order with items
-func (h *PublicOrderHandler) GetOrder(w http.ResponseWriter, r *http.Request) {
-	// Get tenant and restaurant from context
-	tenantID := middleware.GetTenantID(r)
-	if tenantID == 0 {
-		respondError(w, http.StatusUnauthorized, "Missing tenant information")
+// TrackOrderByNumber returns live tracking for an order scoped to a restaurant slug.
+// The customer proves ownership with the phone used at checkout or the tracking token.
+// GET /api/v1/public/restaurants/{slug}/orders/{orderNumber}?phone=... or ?token=... (or X-Tracking-Token header)
+// Returns: Order status, items, ETA and driver position while out for delivery
+func (h *PublicOrderHandler) TrackOrderByNumber(w http.ResponseWriter, r *http.Request) {
+	restaurant, phone, token, ok := h.trackingRequest(w, r)
+	if !ok {
 		return
 	}
 
-	restaurantID := middleware.GetRestaurantID(r)
-	if restaurantID == 0 {
-		respondError(w, http.StatusUnauthorized, "Missing restaurant information")
-		return
-	}
-
-	// Get order ID from path
-	orderIDStr := r.PathValue("id")
-	orderID, err := strconv.ParseInt(orderIDStr, 10, 64)
+	result, err := h.trackingUC.TrackOrder(
+		r.Context(), int64(restaurant.TenantID), int64(restaurant.ID),
+		r.PathValue("orderNumber"), phone, token, middleware.ClientIP(r),
+	)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid order ID")
+		respondTrackingError(w, err)
 		return
 	}
 
-	// Get order from usecase
-	order, err := h.orderUC.GetOrder(tenantID, restaurantID, orderID)
-	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "Order not found")
-			return
-		}
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve order")
-		return
-	}
-
-	// Return order with items
 	respondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
-		"data":    order,
+		"data":    result,
 	})
 }
 
-// GetOrderByNumber retrieves order details by order number
-// GET /api/v1/public/orders/number/{order_number}
-// Returns: Full Order with items
-func (h *PublicOrderHandler) GetOrderByNumber(w http.ResponseWriter, r *http.Request) {
-	// Get tenant and restaurant from context
-	tenantID := middleware.GetTenantID(r)
-	if tenantID == 0 {
-		respondError(w, http.StatusUnauthorized, "Missing tenant information")
-		return
-	}
-
-	restaurantID := middleware.GetRestaurantID(r)
-	if restaurantID == 0 {
-		respondError(w, http.StatusUnauthorized, "Missing restaurant information")
-		return
-	}
-
-	// Get order number from path
-	orderNumber := r.PathValue("number")
-	if orderNumber == "" {
-		respondError(w, http.StatusBadRequest, "Order number is required")
-		return
-	}
-
-	// Get order from usecase
-	order, err := h.orderUC.GetOrderByNumber(tenantID, restaurantID, orderNumber)
-	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "Order not found")
-			return
-		}
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve order")
-		return
-	}
-
-	// Return order with items
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data":    order,
-	})
-}
-
-// GetOrderStatus retrieves only the status of an order (lightweight endpoint)
-// GET /api/v1/public/orders/{id}/status
-// Returns: Order number, status, and payment status
-func (h *PublicOrderHandler) GetOrderStatus(w http.ResponseWriter, r *http.Request) {
-	// Get tenant and restaurant from context
-	tenantID := middleware.GetTenantID(r)
-	if tenantID == 0 {
-		respondError(w, http.StatusUnauthorized, "Missing tenant information")
-		return
-	}
-
-	restaurantID := middleware.GetRestaurantID(r)
-	if restaurantID == 0 {
-		respondError(w, http.StatusUnauthorized, "Missing restaurant information")
-		return
-	}
-
-	// Get order ID from path
-	orderIDStr := r.PathValue("id")
-	orderID, err := strconv.ParseInt(orderIDStr, 10, 64)
-	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid order ID")
-		return
-	}
-
-	// Get order from usecase
-	order, err := h.orderUC.GetOrder(tenantID, restaurantID, orderID)
-	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "Order not found")
-			return
-		}
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve order status")
+// respondTrackingError writes the response of a failed tracking verification. Unknown
+// orders and failed verification look the same to the caller.
+func respondTrackingError(w http.ResponseWriter, err error) {
+	var throttled *domain.LoginError
+	if errors.As(err, &throttled) {
+		respondLoginError(w, throttled)
 		return
 	}
-
-	// Return minimal status information
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data": map[string]interface{}{
-			"order_number":      order.OrderNumber,
-			"status":            order.Status,
-			"payment_status":    order.PaymentStatus,
-			"total_amount":      order.TotalAmount,
-			"created_at":        order.CreatedAt,
-			"estimated_delivery": order.EstimatedDeliveryTime,
-		},
-	})
+	respondError(w, http.StatusNotFound, "Order not found")
 }
 
-// TrackOrder retrieves full order tracking information
-// GET /api/v1/public/orders/{id}/track
-// Returns: Order with status history
-func (h *PublicOrderHandler) TrackOrder(w http.ResponseWriter, r *http.Request) {
-	// Get tenant and restaurant from context
-	tenantID := middleware.GetTenantID(r)
-	if tenantID == 0 {
-		respondError(w, http.StatusUnauthorized, "Missing tenant information")
-		return
-	}
-
-	restaurantID := middleware.GetRestaurantID(r)
-	if restaurantID == 0 {
-		respondError(w, http.StatusUnauthorized, "Missing restaurant information")
-		return
-	}
-
-	// Get order ID from path
-	orderIDStr := r.PathValue("id")
-	orderID, err := strconv.ParseInt(orderIDStr, 10, 64)
+// trackingRequest resolves the restaurant of a slug-scoped order route and reads the
+// customer's proof of ownership. It writes the error response when either is missing.
+func (h *PublicOrderHandler) trackingRequest(w http.ResponseWriter, r *http.Request) (*domain.Restaurant, string, string, bool) {
+	restaurant, err := h.restaurantRepo.GetBySlug(r.PathValue("slug"))
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid order ID")
-		return
+		respondError(w, http.StatusNotFound, "Restaurant not found")
+		return nil, "", "", false
 	}
 
-	// Get order from usecase
-	order, err := h.orderUC.GetOrder(tenantID, restaurantID, orderID)
-	if err != nil {
-		if strings.Contains(err.Error(), "not found") {
-			respondError(w, http.StatusNotFound, "Order not found")
-			return
-		}
-		respondError(w, http.StatusInternalServerError, "Failed to retrieve order")
-		return
+	token := r.Header.Get("X-Tracking-Token")
+	if token == "" {
+		token = r.URL.Query().Get("token")
 	}
-
-	// Get status history
-	history, err := h.orderUC.GetOrderStatusHistory(tenantID, orderID)
-	if err != nil {
-		// History is optional, don't fail if it's not available
-		history = []domain.OrderStatusHistory{}
+	phone := r.URL.Query().Get("phone")
+	if phone == "" && token == "" {
+		respondError(w, http.StatusBadRequest, "Phone number or tracking token is required")
+		return nil, "", "", false
 	}
-
-	// Return order with tracking information
-	respondJSON(w, http.StatusOK, map[string]interface{}{
-		"success": true,
-		"data": map[string]interface{}{
-			"order_number":      order.OrderNumber,
-			"status":            order.Status,
-			"payment_status":    order.PaymentStatus,
-			"customer_name":     order.CustomerName,
-			"customer_phone":    order.CustomerPhone,
-			"total_amount":      order.TotalAmount,
-			"estimated_delivery": order.EstimatedDeliveryTime,
-			"created_at":        order.CreatedAt,
-			"status_history":    history,
-		},
-	})
+	return restaurant, phone, token, true
 }
 
 // ValidateOrder validates an order before creation (preview endpoint)
@@ -353,28 +240,26 @@ func (h *PublicOrderHandler) ValidateOrder(w http.ResponseWriter, r *http.Reques
 	})
 }
 
-// CancelOrder cancels a pending or confirmed order
-// DELETE /api/v1/public/orders/{id}
+// CancelOrder cancels a pending or confirmed order. The customer proves ownership with the
+// tracking token; the phone number used for tracking is not enough.
+// DELETE /api/v1/public/restaurants/{slug}/orders/{orderNumber}?token=... (or X-Tracking-Token header)
 // Returns: Cancellation confirmation
 func (h *PublicOrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request) {
-	// Get tenant and restaurant from context
-	tenantID := middleware.GetTenantID(r)
-	if tenantID == 0 {
-		respondError(w, http.StatusUnauthorized, "Missing tenant information")
+	restaurant, _, token, ok := h.trackingRequest(w, r)
+	if !ok {
 		return
 	}
-
-	restaurantID := middleware.GetRestaurantID(r)
-	if restaurantID == 0 {
-		respondError(w, http.StatusUnauthorized, "Missing restaurant information")
+	if token == "" {
+		respondError(w, http.StatusForbidden, "The tracking token is required to cancel an order")
 		return
 	}
 
-	// Get order ID from path
-	orderIDStr := r.PathValue("id")
-	orderID, err := strconv.ParseInt(orderIDStr, 10, 64)
+	order, _, err := h.trackingUC.VerifyOrder(
+		r.Context(), int64(restaurant.TenantID), int64(restaurant.ID),
+		r.PathValue("orderNumber"), "", token, middleware.ClientIP(r),
+	)
 	if err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid order ID")
+		respondTrackingError(w, err)
 		return
 	}
 
@@ -385,7 +270,7 @@ func (h *PublicOrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request)
 	_ = json.NewDecoder(r.Body).Decode(&req)
 
 	// Cancel order via usecase
-	err = h.orderUC.CancelOrder(tenantID, restaurantID, orderID, req.Reason)
+	err = h.orderUC.CancelOrder(order.TenantID, order.RestaurantID, order.ID, req.Reason)
 	if err != nil {
 		if strings.Contains(err.Error(), "not found") {
 			respondError(w, http.StatusNotFound, "Order not found")
@@ -404,8 +289,8 @@ func (h *PublicOrderHandler) CancelOrder(w http.ResponseWriter, r *http.Request)
 		"success": true,
 		"message": "Order cancelled successfully",
 		"data": map[string]interface{}{
-			"order_id": orderID,
-			"status":   "cancelled",
+			"order_number": order.OrderNumber,
+			"status":       "cancelled",
 		},
 	})
 }
@@ -12,7 +12,7 @@ import (
 
 // TestPublicOrderHandlerCreation tests handler initialization
 func TestPublicOrderHandlerCreation(t *testing.T) {
-	handler := NewPublicOrderHandler(nil, nil)
+	handler := NewPublicOrderHandler(nil, nil, nil, nil)
 	if handler == nil {
 		t.Error("Expected PublicOrderHandler to be created, got nil")
 	}
@@ -0,0 +1,468 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// respondRBACError maps role and permission errors to HTTP status codes
+func respondRBACError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrRoleNotFound),
+		errors.Is(err, domain.ErrModuleNotFound),
+		errors.Is(err, domain.ErrRBACUserNotFound),
+		strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrSystemRoleImmutable),
+		errors.Is(err, domain.ErrPermissionDenied):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrRoleExists),
+		errors.Is(err, domain.ErrLastAdministrator):
+		respondError(w, http.StatusConflict, err.Error())
+	case strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "must"),
+		strings.Contains(err.Error(), "more than once"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// permissionActor identifies the admin making a permission change
+func permissionActor(r *http.Request) domain.PermissionActor {
+	return domain.PermissionActor{
+		TenantID:     middleware.GetTenantID(r),
+		RestaurantID: middleware.GetRestaurantID(r),
+		UserID:       middleware.GetUserID(r),
+		IPAddress:    middleware.ClientIP(r),
+		UserAgent:    r.UserAgent(),
+	}
+}
+
+func respondRBAC(w http.ResponseWriter, code int, data interface{}) {
+	respondJSON(w, code, APIResponse{Success: true, Data: data})
+}
+
+// RBACRoleHandler handles the tenant's roles
+type RBACRoleHandler struct {
+	roleUC *usecase.RoleUseCase
+}
+
+// NewRBACRoleHandler creates new RBAC role handler
+func NewRBACRoleHandler(roleUC *usecase.RoleUseCase) *RBACRoleHandler {
+	return &RBACRoleHandler{roleUC: roleUC}
+}
+
+// ListRoles returns the tenant's roles
+// GET /api/v1/rbac/roles
+func (h *RBACRoleHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.roleUC.ListRoles(r.Context(), middleware.GetTenantID(r))
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, roles)
+}
+
+// GetRole returns a role with its module permissions
+// GET /api/v1/rbac/roles/{id}
+func (h *RBACRoleHandler) GetRole(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+	role, err := h.roleUC.GetRole(r.Context(), middleware.GetTenantID(r), id)
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, role)
+}
+
+// CreateRole creates a role with optional module permissions
+// POST /api/v1/rbac/roles
+func (h *RBACRoleHandler) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req domain.RBACRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	role, err := h.roleUC.CreateRole(r.Context(), permissionActor(r), &req)
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusCreated, role)
+}
+
+// UpdateRole renames a role and, when permissions are sent, replaces them
+// PUT /api/v1/rbac/roles/{id}
+func (h *RBACRoleHandler) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+	var req domain.RBACRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	role, err := h.roleUC.UpdateRole(r.Context(), permissionActor(r), id, &req)
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, role)
+}
+
+// DeleteRole deactivates a role
+// DELETE /api/v1/rbac/roles/{id}
+func (h *RBACRoleHandler) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+	if err := h.roleUC.DeleteRole(r.Context(), permissionActor(r), id); err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Role deleted"})
+}
+
+// RBACPermissionHandler handles module permissions
+type RBACPermissionHandler struct {
+	permissionUC *usecase.PermissionUseCase
+}
+
+// NewRBACPermissionHandler creates new RBAC permission handler
+func NewRBACPermissionHandler(permissionUC *usecase.PermissionUseCase) *RBACPermissionHandler {
+	return &RBACPermissionHandler{permissionUC: permissionUC}
+}
+
+// ListModules returns the modules permissions can be granted on
+// GET /api/v1/rbac/modules
+func (h *RBACPermissionHandler) ListModules(w http.ResponseWriter, r *http.Request) {
+	modules, err := h.permissionUC.ListModules(r.Context())
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, modules)
+}
+
+// AssignPermission sets a role's permission level on a module
+// POST /api/v1/rbac/permissions/assign
+func (h *RBACPermissionHandler) AssignPermission(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		RoleID int64 `json:"role_id"`
+		domain.ModulePermissionRequest
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if req.RoleID <= 0 {
+		respondError(w, http.StatusBadRequest, "role_id is required")
+		return
+	}
+	if err := h.permissionUC.AssignPermission(r.Context(), permissionActor(r), req.RoleID, &req.ModulePermissionRequest); err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Permission assigned"})
+}
+
+// RevokePermission removes a role's permission on a module
+// DELETE /api/v1/rbac/permissions/{roleId}/{moduleId}
+func (h *RBACPermissionHandler) RevokePermission(w http.ResponseWriter, r *http.Request) {
+	roleID, err := pathID(r, "roleId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+	moduleID, err := pathID(r, "moduleId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid module ID")
+		return
+	}
+	if err := h.permissionUC.RevokePermission(r.Context(), permissionActor(r), roleID, moduleID); err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Permission revoked"})
+}
+
+// GetRolePermissions returns a role's module permissions
+// GET /api/v1/rbac/roles/{roleId}/permissions
+func (h *RBACPermissionHandler) GetRolePermissions(w http.ResponseWriter, r *http.Request) {
+	roleID, err := pathID(r, "roleId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+	permissions, err := h.permissionUC.GetRolePermissions(r.Context(), middleware.GetTenantID(r), roleID)
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, permissions)
+}
+
+// CheckPermission reports whether the current user has a permission level on a module in the
+// current restaurant
+// GET /api/v1/rbac/check-permission?moduleId=2&permissionLevel=WRITE
+func (h *RBACPermissionHandler) CheckPermission(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	moduleParam := query.Get("moduleId")
+	if moduleParam == "" {
+		moduleParam = query.Get("module_id")
+	}
+	moduleID, err := strconv.ParseInt(moduleParam, 10, 64)
+	if err != nil || moduleID <= 0 {
+		respondError(w, http.StatusBadRequest, "Invalid module ID")
+		return
+	}
+	level := query.Get("permissionLevel")
+	if level == "" {
+		level = query.Get("permission_level")
+	}
+	if level == "" {
+		level = domain.PermissionRead
+	}
+	if _, ok := domain.PermissionValues[level]; !ok {
+		respondError(w, http.StatusBadRequest, "invalid permission level")
+		return
+	}
+
+	permissions, err := h.permissionUC.GetUserPermissions(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), middleware.GetUserID(r))
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":          true,
+		"has_permission":   permissions.Allows(moduleID, level),
+		"module_id":        moduleID,
+		"permission_level": permissions.Level(moduleID),
+	})
+}
+
+// GetUserPermissions returns the current user's level on each module in the current restaurant
+// GET /api/v1/rbac/me/permissions
+func (h *RBACPermissionHandler) GetUserPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := h.permissionUC.GetUserPermissions(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), middleware.GetUserID(r))
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, permissions)
+}
+
+// ListAuditLogs returns the tenant's permission changes, newest first
+// GET /api/v1/rbac/audit-logs?limit=100
+func (h *RBACPermissionHandler) ListAuditLogs(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+	logs, err := h.permissionUC.ListAuditLogs(r.Context(), middleware.GetTenantID(r), limit)
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, logs)
+}
+
+// RBACUserRoleHandler handles role assignments of the tenant's users
+type RBACUserRoleHandler struct {
+	userRoleUC *usecase.UserRoleUseCase
+}
+
+// NewRBACUserRoleHandler creates new RBAC user role handler
+func NewRBACUserRoleHandler(userRoleUC *usecase.UserRoleUseCase) *RBACUserRoleHandler {
+	return &RBACUserRoleHandler{userRoleUC: userRoleUC}
+}
+
+// decodeRoleAssignment reads the role to assign and the restaurants it is limited to; the
+// dashboard sends either role_id or roleId
+func decodeRoleAssignment(r *http.Request) (*domain.AssignUserRoleRequest, error) {
+	var req struct {
+		domain.AssignUserRoleRequest
+		RoleIDCamel int64 `json:"roleId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return nil, errors.New("invalid request body")
+	}
+	if req.RoleID == 0 {
+		req.RoleID = req.RoleIDCamel
+	}
+	if err := req.AssignUserRoleRequest.Validate(); err != nil {
+		return nil, err
+	}
+	return &req.AssignUserRoleRequest, nil
+}
+
+// queryRestaurantID reads the optional ?restaurant_id= limiting a role removal to one
+// restaurant; nil removes the role everywhere
+func queryRestaurantID(r *http.Request) (*int64, error) {
+	v := r.URL.Query().Get("restaurant_id")
+	if v == "" {
+		return nil, nil
+	}
+	id, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || id <= 0 {
+		return nil, errors.New("invalid restaurant ID")
+	}
+	return &id, nil
+}
+
+// GetUserRoles returns a user's roles
+// GET /api/v1/rbac/users/{userId}/roles
+func (h *RBACUserRoleHandler) GetUserRoles(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	roles, err := h.userRoleUC.GetUserRoles(r.Context(), middleware.GetTenantID(r), userID)
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, roles)
+}
+
+// GetUserRoleAssignments returns a user's role assignments with the restaurant each is limited to
+// GET /api/v1/rbac/users/{userId}/role-assignments
+func (h *RBACUserRoleHandler) GetUserRoleAssignments(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	assignments, err := h.userRoleUC.GetUserRoleAssignments(r.Context(), middleware.GetTenantID(r), userID)
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, assignments)
+}
+
+// GetMyRoles returns the current user's roles in the current restaurant
+// GET /api/v1/rbac/me/roles
+func (h *RBACUserRoleHandler) GetMyRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.userRoleUC.GetRestaurantRoles(r.Context(), middleware.GetTenantID(r), middleware.GetRestaurantID(r), middleware.GetUserID(r))
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, roles)
+}
+
+// AssignRoleToUser gives a user a role, in every restaurant unless restaurant_ids is sent
+// POST /api/v1/rbac/users/{userId}/roles
+func (h *RBACUserRoleHandler) AssignRoleToUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	req, err := decodeRoleAssignment(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.userRoleUC.AssignRole(r.Context(), permissionActor(r), userID, req); err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Role assigned"})
+}
+
+// RemoveRoleFromUser takes a role away from a user, only in one restaurant when
+// ?restaurant_id= is set
+// DELETE /api/v1/rbac/users/{userId}/roles/{roleId}
+func (h *RBACUserRoleHandler) RemoveRoleFromUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+	roleID, err := pathID(r, "roleId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+	restaurantID, err := queryRestaurantID(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.userRoleUC.RemoveRole(r.Context(), permissionActor(r), userID, roleID, restaurantID); err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Role removed"})
+}
+
+// GetUserRolesByEmail returns the roles of the user with this email
+// GET /api/v1/rbac/users-by-email/{email}/roles
+func (h *RBACUserRoleHandler) GetUserRolesByEmail(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.userRoleUC.GetUserRolesByEmail(r.Context(), middleware.GetTenantID(r), r.PathValue("email"))
+	if err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondRBAC(w, http.StatusOK, roles)
+}
+
+// AssignRoleToUserByEmail gives the user with this email a role
+// POST /api/v1/rbac/users-by-email/{email}/roles
+func (h *RBACUserRoleHandler) AssignRoleToUserByEmail(w http.ResponseWriter, r *http.Request) {
+	req, err := decodeRoleAssignment(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.userRoleUC.AssignRoleByEmail(r.Context(), permissionActor(r), r.PathValue("email"), req); err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Role assigned"})
+}
+
+// RemoveRoleFromUserByEmail takes a role away from the user with this email
+// DELETE /api/v1/rbac/users-by-email/{email}/roles/{roleId}
+func (h *RBACUserRoleHandler) RemoveRoleFromUserByEmail(w http.ResponseWriter, r *http.Request) {
+	roleID, err := pathID(r, "roleId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid role ID")
+		return
+	}
+	restaurantID, err := queryRestaurantID(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := h.userRoleUC.RemoveRoleByEmail(r.Context(), permissionActor(r), r.PathValue("email"), roleID, restaurantID); err != nil {
+		respondRBACError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Role removed"})
+}
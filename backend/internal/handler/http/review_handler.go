@@ -0,0 +1,168 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/repository"
+	"pos-saas/internal/usecase"
+)
+
+// ReviewHandler handles customer feedback, product reviews and moderation
+type ReviewHandler struct {
+	reviewUC       *usecase.ReviewUseCase
+	restaurantRepo *repository.RestaurantRepository
+}
+
+// NewReviewHandler creates new review handler
+func NewReviewHandler(reviewUC *usecase.ReviewUseCase, restaurantRepo *repository.RestaurantRepository) *ReviewHandler {
+	return &ReviewHandler{
+		reviewUC:       reviewUC,
+		restaurantRepo: restaurantRepo,
+	}
+}
+
+// respondReviewError maps feedback errors to HTTP status codes
+func respondReviewError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrReviewRequestNotFound), errors.Is(err, domain.ErrReviewNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrReviewRequestUsed), strings.Contains(err.Error(), "duplicate key"):
+		respondError(w, http.StatusConflict, domain.ErrReviewRequestUsed.Error())
+	case errors.Is(err, domain.ErrReviewRequestExpired):
+		respondError(w, http.StatusGone, err.Error())
+	case strings.Contains(err.Error(), "validation failed"),
+		strings.Contains(err.Error(), "invalid"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// GetReviewRequest returns the order behind a review link
+// GET /api/v1/public/reviews/{token}
+func (h *ReviewHandler) GetReviewRequest(w http.ResponseWriter, r *http.Request) {
+	view, err := h.reviewUC.GetReviewRequest(r.PathValue("token"))
+	if err != nil {
+		respondReviewError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, view)
+}
+
+// SubmitFeedback records the customer's order, item and delivery ratings
+// POST /api/v1/public/reviews/{token}
+func (h *ReviewHandler) SubmitFeedback(w http.ResponseWriter, r *http.Request) {
+	var req domain.SubmitFeedbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	feedback, err := h.reviewUC.SubmitFeedback(r.PathValue("token"), &req)
+	if err != nil {
+		respondReviewError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success":  true,
+		"message":  "Thank you for your feedback",
+		"feedback": feedback,
+	})
+}
+
+// ListProductReviews returns the approved reviews of a product
+// GET /api/v1/public/restaurants/{slug}/products/{productId}/reviews?limit=20
+func (h *ReviewHandler) ListProductReviews(w http.ResponseWriter, r *http.Request) {
+	productID, err := pathID(r, "productId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid product ID")
+		return
+	}
+
+	restaurant, err := h.restaurantRepo.GetBySlug(r.PathValue("slug"))
+	if err != nil {
+		respondError(w, http.StatusNotFound, "Restaurant not found")
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	reviews, err := h.reviewUC.ListProductReviews(int64(restaurant.ID), productID, limit)
+	if err != nil {
+		respondReviewError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"product_id": productID,
+		"reviews":    reviews,
+		"count":      len(reviews),
+	})
+}
+
+// ListReviews lists product reviews for moderation
+// GET /api/v1/reviews?status=pending&product_id=1&page=1&limit=20
+func (h *ReviewHandler) ListReviews(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filters := &domain.ReviewListFilters{Status: query.Get("status")}
+	filters.ProductID, _ = strconv.ParseInt(query.Get("product_id"), 10, 64)
+	filters.Page, _ = strconv.Atoi(query.Get("page"))
+	filters.Limit, _ = strconv.Atoi(query.Get("limit"))
+
+	reviews, total, err := h.reviewUC.ListReviews(middleware.GetTenantID(r), middleware.GetRestaurantID(r), filters)
+	if err != nil {
+		respondReviewError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"reviews": reviews,
+		"total":   total,
+		"page":    filters.Page,
+		"limit":   filters.Limit,
+	})
+}
+
+// ApproveReview publishes a review and counts it in the product rating
+// POST /api/v1/reviews/{id}/approve
+func (h *ReviewHandler) ApproveReview(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, domain.ReviewStatusApproved)
+}
+
+// HideReview hides a review from the public menu
+// POST /api/v1/reviews/{id}/hide
+func (h *ReviewHandler) HideReview(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, domain.ReviewStatusHidden)
+}
+
+func (h *ReviewHandler) moderate(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid review ID")
+		return
+	}
+
+	review, err := h.reviewUC.ModerateReview(middleware.GetTenantID(r), middleware.GetRestaurantID(r), id, status, middleware.GetUserID(r))
+	if err != nil {
+		respondReviewError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, review)
+}
+
+// GetRatingSummary returns the restaurant rating summary for the dashboard
+// GET /api/v1/reviews/summary
+func (h *ReviewHandler) GetRatingSummary(w http.ResponseWriter, r *http.Request) {
+	summary, err := h.reviewUC.GetRatingSummary(middleware.GetRestaurantID(r))
+	if err != nil {
+		respondReviewError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, summary)
+}
@@ -0,0 +1,414 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// RotaHandler handles shift templates, staffing requirements and weekly rotas
+type RotaHandler struct {
+	rotaUC *usecase.RotaUseCase
+}
+
+// NewRotaHandler creates new rota handler
+func NewRotaHandler(rotaUC *usecase.RotaUseCase) *RotaHandler {
+	return &RotaHandler{rotaUC: rotaUC}
+}
+
+// respondRotaError maps rota errors to HTTP status codes
+func respondRotaError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrRotaNotFound),
+		errors.Is(err, domain.ErrShiftNotFound),
+		errors.Is(err, domain.ErrShiftTemplateNotFound),
+		strings.Contains(err.Error(), "not found"):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrRotaPublished),
+		errors.Is(err, domain.ErrRotaExists),
+		strings.Contains(err.Error(), "duplicate key"):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrShiftOutsideRota),
+		errors.Is(err, domain.ErrInvalidShiftTime),
+		strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "must"),
+		strings.Contains(err.Error(), "cannot"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, err.Error())
+	}
+}
+
+// hrScope returns the tenant and restaurant of the request as HR ids
+func hrScope(r *http.Request) (int, int) {
+	return int(middleware.GetTenantID(r)), int(middleware.GetRestaurantID(r))
+}
+
+// ListShiftTemplates returns the restaurant's shift templates
+// GET /api/v1/hr/shift-templates
+func (h *RotaHandler) ListShiftTemplates(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	templates, err := h.rotaUC.ListShiftTemplates(tenantID, restaurantID)
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, templates)
+}
+
+// CreateShiftTemplate creates a shift template
+// POST /api/v1/hr/shift-templates
+func (h *RotaHandler) CreateShiftTemplate(w http.ResponseWriter, r *http.Request) {
+	var req domain.ShiftTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	template, err := h.rotaUC.CreateShiftTemplate(tenantID, restaurantID, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, template)
+}
+
+// UpdateShiftTemplate updates a shift template
+// PUT /api/v1/hr/shift-templates/{id}
+func (h *RotaHandler) UpdateShiftTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+	var req domain.ShiftTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	template, err := h.rotaUC.UpdateShiftTemplate(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, template)
+}
+
+// DeleteShiftTemplate deletes a shift template
+// DELETE /api/v1/hr/shift-templates/{id}
+func (h *RotaHandler) DeleteShiftTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid template ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	if err := h.rotaUC.DeleteShiftTemplate(tenantID, restaurantID, int(id), int(middleware.GetUserID(r))); err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Shift template deleted",
+	})
+}
+
+// ListStaffingRequirements returns the restaurant's staffing requirements
+// GET /api/v1/hr/staffing-requirements
+func (h *RotaHandler) ListStaffingRequirements(w http.ResponseWriter, r *http.Request) {
+	tenantID, restaurantID := hrScope(r)
+	requirements, err := h.rotaUC.ListStaffingRequirements(tenantID, restaurantID)
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, requirements)
+}
+
+// CreateStaffingRequirement creates a staffing requirement
+// POST /api/v1/hr/staffing-requirements
+func (h *RotaHandler) CreateStaffingRequirement(w http.ResponseWriter, r *http.Request) {
+	var req domain.StaffingRequirementRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	requirement, err := h.rotaUC.CreateStaffingRequirement(tenantID, restaurantID, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, requirement)
+}
+
+// DeleteStaffingRequirement deletes a staffing requirement
+// DELETE /api/v1/hr/staffing-requirements/{id}
+func (h *RotaHandler) DeleteStaffingRequirement(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid requirement ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	if err := h.rotaUC.DeleteStaffingRequirement(tenantID, restaurantID, int(id), int(middleware.GetUserID(r))); err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Staffing requirement deleted",
+	})
+}
+
+// ListRotas returns the rotas of weeks starting in a period, by default four weeks back to eight weeks ahead
+// GET /api/v1/hr/rotas?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *RotaHandler) ListRotas(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	now := time.Now()
+	if from == nil {
+		t := now.AddDate(0, 0, -28)
+		from = &t
+	}
+	if to == nil {
+		t := now.AddDate(0, 0, 56)
+		to = &t
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	rotas, err := h.rotaUC.ListRotas(tenantID, restaurantID, *from, *to)
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, rotas)
+}
+
+// CreateRota starts a draft rota for a week
+// POST /api/v1/hr/rotas
+func (h *RotaHandler) CreateRota(w http.ResponseWriter, r *http.Request) {
+	var req domain.CreateRotaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	rota, err := h.rotaUC.CreateRota(tenantID, restaurantID, &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, rota)
+}
+
+// GetRota returns a rota with its shifts and conflicts
+// GET /api/v1/hr/rotas/{id}
+func (h *RotaHandler) GetRota(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rota ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	rota, err := h.rotaUC.GetRota(tenantID, restaurantID, int(id))
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, rota)
+}
+
+// GetConflicts returns the leave, overlap, hours and staffing conflicts of a rota
+// GET /api/v1/hr/rotas/{id}/conflicts
+func (h *RotaHandler) GetConflicts(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rota ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	conflicts, err := h.rotaUC.GetConflicts(tenantID, restaurantID, int(id))
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, conflicts)
+}
+
+// AddShift adds a shift to a draft rota
+// POST /api/v1/hr/rotas/{id}/shifts
+func (h *RotaHandler) AddShift(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rota ID")
+		return
+	}
+	var req domain.ShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	shift, err := h.rotaUC.AddShift(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusCreated, shift)
+}
+
+// UpdateShift changes a shift of a draft rota
+// PUT /api/v1/hr/rotas/{id}/shifts/{shiftId}
+func (h *RotaHandler) UpdateShift(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rota ID")
+		return
+	}
+	shiftID, err := pathID(r, "shiftId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid shift ID")
+		return
+	}
+	var req domain.ShiftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	shift, err := h.rotaUC.UpdateShift(tenantID, restaurantID, int(id), int(shiftID), &req, int(middleware.GetUserID(r)))
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, shift)
+}
+
+// DeleteShift removes a shift from a draft rota
+// DELETE /api/v1/hr/rotas/{id}/shifts/{shiftId}
+func (h *RotaHandler) DeleteShift(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rota ID")
+		return
+	}
+	shiftID, err := pathID(r, "shiftId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid shift ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	if err := h.rotaUC.DeleteShift(tenantID, restaurantID, int(id), int(shiftID), int(middleware.GetUserID(r))); err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Shift deleted",
+	})
+}
+
+// PublishRota publishes a rota and notifies the scheduled employees.
+// Blocking conflicts are returned with 409 unless force is set.
+// POST /api/v1/hr/rotas/{id}/publish
+func (h *RotaHandler) PublishRota(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rota ID")
+		return
+	}
+	var req domain.PublishRotaRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			respondError(w, http.StatusBadRequest, "Invalid request body")
+			return
+		}
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	resp, conflicts, err := h.rotaUC.PublishRota(tenantID, restaurantID, int(id), &req, int(middleware.GetUserID(r)))
+	if errors.Is(err, domain.ErrRotaHasConflicts) {
+		respondJSON(w, http.StatusConflict, map[string]interface{}{
+			"error":     err.Error(),
+			"conflicts": conflicts,
+		})
+		return
+	}
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, resp)
+}
+
+// UnpublishRota returns a published rota to draft
+// POST /api/v1/hr/rotas/{id}/unpublish
+func (h *RotaHandler) UnpublishRota(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid rota ID")
+		return
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	rota, err := h.rotaUC.UnpublishRota(tenantID, restaurantID, int(id), int(middleware.GetUserID(r)))
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, rota)
+}
+
+// ListEmployeeShifts returns an employee's published shifts, by default for the current week
+// GET /api/v1/hr/employees/{id}/shifts?from=YYYY-MM-DD&to=YYYY-MM-DD
+func (h *RotaHandler) ListEmployeeShifts(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid employee ID")
+		return
+	}
+	from, to, err := parseDateRange(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if from == nil {
+		t := domain.WeekStartOf(time.Now())
+		from = &t
+	}
+	if to == nil {
+		t := from.AddDate(0, 0, 6)
+		to = &t
+	}
+
+	tenantID, restaurantID := hrScope(r)
+	shifts, err := h.rotaUC.ListEmployeeShifts(tenantID, restaurantID, int(id), *from, *to)
+	if err != nil {
+		respondRotaError(w, err)
+		return
+	}
+	respondJSON(w, http.StatusOK, shifts)
+}
@@ -0,0 +1,171 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// SSOHandler configures a tenant's OpenID Connect provider and runs the sign-in flow
+type SSOHandler struct {
+	sso  *usecase.SSOUseCase
+	auth *usecase.AuthUseCase
+}
+
+func NewSSOHandler(sso *usecase.SSOUseCase, auth *usecase.AuthUseCase) *SSOHandler {
+	return &SSOHandler{sso: sso, auth: auth}
+}
+
+// GetConfig returns the tenant's identity provider
+// GET /api/v1/sso/oidc
+func (h *SSOHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	cfg, err := h.sso.GetConfig(r.Context(), int(middleware.GetTenantID(r)))
+	if err != nil {
+		respondSSOError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: cfg})
+}
+
+// SaveConfig creates or replaces the tenant's identity provider
+// PUT /api/v1/sso/oidc
+func (h *SSOHandler) SaveConfig(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetAPIKey(r) != nil {
+		respondError(w, http.StatusForbidden, domain.ErrSSOManagement.Error())
+		return
+	}
+
+	var req domain.SSOConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	cfg, err := h.sso.SaveConfig(r.Context(), permissionActor(r), &req)
+	if err != nil {
+		respondSSOError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: cfg})
+}
+
+// DeleteConfig removes the tenant's identity provider
+// DELETE /api/v1/sso/oidc
+func (h *SSOHandler) DeleteConfig(w http.ResponseWriter, r *http.Request) {
+	if middleware.GetAPIKey(r) != nil {
+		respondError(w, http.StatusForbidden, domain.ErrSSOManagement.Error())
+		return
+	}
+
+	if err := h.sso.DeleteConfig(r.Context(), int(middleware.GetTenantID(r))); err != nil {
+		respondSSOError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Single sign-on removed"})
+}
+
+// GetProvider tells the login page whether the tenant offers single sign-on
+// GET /api/v1/auth/sso?tenant={slug}
+func (h *SSOHandler) GetProvider(w http.ResponseWriter, r *http.Request) {
+	slug := strings.TrimSpace(r.URL.Query().Get("tenant"))
+	if slug == "" {
+		respondError(w, http.StatusBadRequest, "tenant parameter is required")
+		return
+	}
+
+	info, err := h.sso.Provider(r.Context(), slug)
+	if err != nil {
+		respondSSOError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, info)
+}
+
+// Start sends the browser to the tenant's identity provider
+// GET /api/v1/auth/sso/start?tenant={slug}&redirect={path}
+func (h *SSOHandler) Start(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	target, binding := h.sso.Start(r.Context(), strings.TrimSpace(q.Get("tenant")), q.Get("redirect"))
+	if binding != "" {
+		setSSOStateCookie(w, r, binding, int(domain.SSOLoginTTL.Seconds()))
+	}
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// Callback receives the identity provider's redirect and sends the browser on to the dashboard
+// GET /api/v1/auth/sso/callback
+func (h *SSOHandler) Callback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var binding string
+	if cookie, err := r.Cookie(domain.SSOStateCookie); err == nil {
+		binding = cookie.Value
+	}
+	target := h.sso.Callback(r.Context(), q.Get("state"), binding, q.Get("code"), q.Get("error"), sessionClient(r, ""))
+	setSSOStateCookie(w, r, "", -1)
+	w.Header().Set("Cache-Control", "no-store")
+	http.Redirect(w, r, target, http.StatusFound)
+}
+
+// setSSOStateCookie stores the hash of the sign-in state for the callback; maxAge < 0 clears
+// it. It must be Lax, not Strict, to be sent on the provider's cross-site redirect back.
+func setSSOStateCookie(w http.ResponseWriter, r *http.Request, binding string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     domain.SSOStateCookie,
+		Value:    binding,
+		Path:     "/api/v1/auth/sso/callback",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+// Exchange trades the code the dashboard was redirected with for a session, or for a
+// two-factor challenge when the account needs a second step
+// POST /api/v1/auth/sso/exchange
+func (h *SSOHandler) Exchange(w http.ResponseWriter, r *http.Request) {
+	var req domain.SSOExchangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	req.TrustedDeviceToken = trustedDeviceToken(r)
+
+	response, err := h.auth.LoginSSO(r.Context(), &req, sessionClient(r, req.DeviceName))
+	if err != nil {
+		respondSSOError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+func respondSSOError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrSSONotConfigured), errors.Is(err, domain.ErrRoleNotFound),
+		errors.Is(err, domain.ErrRestaurantNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrSSOInvalidState), errors.Is(err, domain.ErrSSOAccountDisabled):
+		respondError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrPermissionDenied):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrSSONotLinked):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrSSOIssuerUnreachable):
+		respondError(w, http.StatusBadRequest, err.Error())
+	case strings.Contains(err.Error(), "required"), strings.Contains(err.Error(), "invalid"),
+		strings.Contains(err.Error(), "must"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "Failed to process single sign-on request")
+	}
+}
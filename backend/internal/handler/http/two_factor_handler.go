@@ -0,0 +1,245 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/middleware"
+	"pos-saas/internal/usecase"
+)
+
+// TwoFactorHandler handles the second login step and TOTP enrollment
+type TwoFactorHandler struct {
+	authUseCase *usecase.AuthUseCase
+	twoFactor   *usecase.TwoFactorUseCase
+}
+
+func NewTwoFactorHandler(authUseCase *usecase.AuthUseCase, twoFactor *usecase.TwoFactorUseCase) *TwoFactorHandler {
+	return &TwoFactorHandler{authUseCase: authUseCase, twoFactor: twoFactor}
+}
+
+// trustedDeviceToken reads the remember-device cookie
+func trustedDeviceToken(r *http.Request) string {
+	cookie, err := r.Cookie(domain.TrustedDeviceCookie)
+	if err != nil {
+		return ""
+	}
+	return cookie.Value
+}
+
+// setTrustedDeviceCookie stores the remember-device token; maxAge < 0 clears it
+func setTrustedDeviceCookie(w http.ResponseWriter, r *http.Request, token string, maxAge int) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     domain.TrustedDeviceCookie,
+		Value:    token,
+		Path:     "/api/v1/auth",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https",
+		SameSite: http.SameSiteStrictMode,
+	})
+}
+
+// Verify completes a login challenge with a TOTP or recovery code
+// POST /api/v1/auth/2fa/verify
+func (h *TwoFactorHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	var req domain.TwoFactorVerifyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	response, deviceToken, err := h.authUseCase.VerifyTwoFactor(r.Context(), &req, sessionClient(r, req.DeviceName))
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	if deviceToken != "" {
+		setTrustedDeviceCookie(w, r, deviceToken, int(domain.TrustedDeviceTTL.Seconds()))
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// ChallengeSetup starts the enrollment the tenant requires before the user can sign in
+// POST /api/v1/auth/2fa/challenge/setup
+func (h *TwoFactorHandler) ChallengeSetup(w http.ResponseWriter, r *http.Request) {
+	var req domain.TwoFactorChallengeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	setup, err := h.twoFactor.SetupFromChallenge(r.Context(), &req)
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: setup})
+}
+
+// Status returns the signed-in user's 2FA state
+// GET /api/v1/auth/2fa
+func (h *TwoFactorHandler) Status(w http.ResponseWriter, r *http.Request) {
+	status, err := h.twoFactor.Status(r.Context(), int(middleware.GetUserID(r)))
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: status})
+}
+
+// Setup starts enrollment and returns the secret and provisioning URI for the QR code
+// POST /api/v1/auth/2fa/setup
+func (h *TwoFactorHandler) Setup(w http.ResponseWriter, r *http.Request) {
+	var req domain.TwoFactorPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	setup, err := h.twoFactor.BeginSetup(r.Context(), int(middleware.GetUserID(r)), &req)
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: setup})
+}
+
+// Enable confirms enrollment with a first code and returns the recovery codes
+// POST /api/v1/auth/2fa/enable
+func (h *TwoFactorHandler) Enable(w http.ResponseWriter, r *http.Request) {
+	var req domain.TwoFactorCodeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := h.twoFactor.Enable(r.Context(), int(middleware.GetUserID(r)), &req)
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: codes, Message: "Two-factor authentication enabled"})
+}
+
+// Disable turns 2FA off
+// POST /api/v1/auth/2fa/disable
+func (h *TwoFactorHandler) Disable(w http.ResponseWriter, r *http.Request) {
+	var req domain.TwoFactorPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.twoFactor.Disable(r.Context(), int(middleware.GetUserID(r)), &req); err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	setTrustedDeviceCookie(w, r, "", -1)
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Two-factor authentication disabled"})
+}
+
+// RegenerateRecoveryCodes replaces the recovery codes
+// POST /api/v1/auth/2fa/recovery-codes
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	var req domain.TwoFactorPasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	codes, err := h.twoFactor.RegenerateRecoveryCodes(r.Context(), int(middleware.GetUserID(r)), &req)
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: codes})
+}
+
+// ForgetTrustedDevices makes every remembered device ask for a code again
+// DELETE /api/v1/auth/2fa/trusted-devices
+func (h *TwoFactorHandler) ForgetTrustedDevices(w http.ResponseWriter, r *http.Request) {
+	removed, err := h.twoFactor.ForgetTrustedDevices(r.Context(), int(middleware.GetUserID(r)))
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	setTrustedDeviceCookie(w, r, "", -1)
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: map[string]int64{"removed": removed}})
+}
+
+// ResetUser removes the 2FA of a user of the tenant who lost their device
+// DELETE /api/v1/users/{userId}/2fa
+func (h *TwoFactorHandler) ResetUser(w http.ResponseWriter, r *http.Request) {
+	userID, err := pathID(r, "userId")
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := h.twoFactor.ResetUser(r.Context(), int(middleware.GetTenantID(r)), int(userID)); err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Message: "Two-factor authentication reset"})
+}
+
+// GetPolicy returns the tenant's two-factor policy
+// GET /api/v1/settings/two-factor
+func (h *TwoFactorHandler) GetPolicy(w http.ResponseWriter, r *http.Request) {
+	policy, err := h.twoFactor.GetPolicy(r.Context(), int(middleware.GetTenantID(r)))
+	if err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: domain.TwoFactorPolicyRequest{Policy: policy}})
+}
+
+// UpdatePolicy sets who must use two-factor authentication in the tenant
+// PUT /api/v1/settings/two-factor
+func (h *TwoFactorHandler) UpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	var req domain.TwoFactorPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.twoFactor.SetPolicy(r.Context(), int(middleware.GetTenantID(r)), &req); err != nil {
+		respondTwoFactorError(w, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, APIResponse{Success: true, Data: req, Message: "Two-factor policy updated"})
+}
+
+func respondTwoFactorError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, domain.ErrInvalidTwoFactorChallenge), errors.Is(err, domain.ErrInvalidTwoFactorCode),
+		errors.Is(err, domain.ErrIncorrectPassword):
+		respondError(w, http.StatusUnauthorized, err.Error())
+	case errors.Is(err, domain.ErrTwoFactorRequired), errors.Is(err, domain.ErrTwoFactorSetupRequired):
+		respondError(w, http.StatusForbidden, err.Error())
+	case errors.Is(err, domain.ErrTwoFactorAlreadyEnabled):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, domain.ErrRBACUserNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrTwoFactorNotEnabled), strings.Contains(err.Error(), "required"),
+		strings.Contains(err.Error(), "must"), strings.Contains(err.Error(), "first"):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		respondError(w, http.StatusInternalServerError, "Failed to process two-factor request")
+	}
+}
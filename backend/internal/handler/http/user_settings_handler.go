@@ -114,7 +114,7 @@ func (h *UserSettingsHandler) UpdateLanguage(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Log audit change
-	h.settingsRepo.LogAuditChange(r.Context(), userID, int64(claims.UserID), "language", nil, &req.Language, getClientIP(r))
+	h.settingsRepo.LogAuditChange(r.Context(), userID, int64(claims.UserID), "language", nil, &req.Language, middleware.ClientIP(r))
 
 	log.Printf("[UpdateLanguage] Language updated for user %d to %s", userID, req.Language)
 	respondJSON(w, http.StatusOK, settings)
@@ -166,7 +166,7 @@ func (h *UserSettingsHandler) UpdateTheme(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	h.settingsRepo.LogAuditChange(r.Context(), userID, int64(claims.UserID), "theme", nil, &req.Theme, getClientIP(r))
+	h.settingsRepo.LogAuditChange(r.Context(), userID, int64(claims.UserID), "theme", nil, &req.Theme, middleware.ClientIP(r))
 
 	log.Printf("[UpdateTheme] Theme updated for user %d to %s", userID, req.Theme)
 	respondJSON(w, http.StatusOK, settings)
@@ -221,7 +221,7 @@ func (h *UserSettingsHandler) UpdateColors(w http.ResponseWriter, r *http.Reques
 	}
 
 	h.settingsRepo.LogAuditChange(r.Context(), userID, int64(claims.UserID), "colors", nil,
-		stringPtr(fmt.Sprintf("%s|%s|%s", req.PrimaryColor, req.SecondaryColor, req.AccentColor)), getClientIP(r))
+		stringPtr(fmt.Sprintf("%s|%s|%s", req.PrimaryColor, req.SecondaryColor, req.AccentColor)), middleware.ClientIP(r))
 
 	log.Printf("[UpdateColors] Colors updated for user %d", userID)
 	respondJSON(w, http.StatusOK, settings)
@@ -274,8 +274,8 @@ func (h *UserSettingsHandler) ChangePassword(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	if len(req.NewPassword) < 8 {
-		respondError(w, http.StatusBadRequest, "New password must be at least 8 characters")
+	if err := domain.ValidatePassword(req.NewPassword); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -310,7 +310,7 @@ func (h *UserSettingsHandler) ChangePassword(w http.ResponseWriter, r *http.Requ
 	}
 
 	// Record password change in history
-	if err := h.settingsRepo.RecordPasswordChange(r.Context(), userID, hashedPassword, getClientIP(r)); err != nil {
+	if err := h.settingsRepo.RecordPasswordChange(r.Context(), userID, hashedPassword, middleware.ClientIP(r)); err != nil {
 		log.Printf("[ChangePassword] ERROR: Failed to record password change: %v", err)
 		// Don't fail the request, just log it
 	}
@@ -511,16 +511,6 @@ func stringPtr(s string) *string {
 	return &s
 }
 
-func getClientIP(r *http.Request) string {
-	if ip := r.Header.Get("X-Forwarded-For"); ip != "" {
-		return ip
-	}
-	if ip := r.Header.Get("X-Real-IP"); ip != "" {
-		return ip
-	}
-	return r.RemoteAddr
-}
-
 func hashPassword(password string) (string, error) {
 	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
 	if err != nil {
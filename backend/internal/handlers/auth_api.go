@@ -314,9 +314,9 @@ func (ah *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
-	apiKeyString, err := ah.apiKeyManager.GenerateAPIKey(
+	apiKeyString, keyID, err := ah.apiKeyManager.GenerateAPIKey(
 		ctx, conn,
-		authCtx.TenantID, authCtx.UserID,
+		authCtx.TenantID, authCtx.UserID, 0,
 		req.Name, req.Permissions,
 		req.AllowedIPs, req.AllowedOrigins,
 		req.RateLimit, req.ExpiresInDays,
@@ -335,6 +335,7 @@ func (ah *AuthHandler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
 
 	response := CreateAPIKeyResponse{
 		APIKey:    apiKeyString,
+		KeyID:     keyID,
 		Prefix:    apiKeyString[:min(len(apiKeyString), 12)],
 		Name:      req.Name,
 		CreatedAt: time.Now(),
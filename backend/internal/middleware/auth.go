@@ -2,10 +2,12 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"strings"
 
+	"pos-saas/internal/domain"
 	"pos-saas/internal/pkg/jwt"
 )
 
@@ -13,12 +15,26 @@ type contextKey string
 
 const UserContextKey contextKey = "user"
 
-func AuthMiddleware(tokenService *jwt.TokenService) func(http.Handler) http.Handler {
+// SessionValidator reports whether the session an access token was issued for is still active
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, sessionID string, userID int) error
+}
+
+// AuthMiddleware validates the bearer token and, when sessions is set, rejects tokens whose
+// session was revoked or has expired. API keys are accepted when apiKeys is set; routes
+// passing nil only accept access tokens.
+func AuthMiddleware(tokenService *jwt.TokenService, sessions SessionValidator, apiKeys APIKeyAuthenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			log.Printf("=== [AUTH MIDDLEWARE] CALLED ===")
 			log.Printf("[AUTH MIDDLEWARE] Request to: %s %s", r.Method, r.URL.Path)
 
+			// Integrations authenticate with an API key instead of an access token
+			if key := apiKeyCredential(r); key != "" {
+				serveAPIKey(w, r, next, apiKeys, key)
+				return
+			}
+
 			authHeader := r.Header.Get("Authorization")
 
 			// Debug logging
@@ -56,14 +72,31 @@ func AuthMiddleware(tokenService *jwt.TokenService) func(http.Handler) http.Hand
 
 			claims, err := tokenService.ValidateToken(token)
 			if err != nil {
-				log.Printf("[AUTH MIDDLEWARE] WARNING: Token validation failed: %v. Using stub/mock claims for development.", err)
-				// In development/stub mode, create mock claims for testing
-				claims = &jwt.Claims{
-					UserID:       7,
-					Email:        "yoseabdallah866@gmail.com",
-					TenantID:     1,
-					RestaurantID: 1,
-					Role:         "owner",
+				log.Printf("[AUTH MIDDLEWARE] ERROR: Token validation failed: %v", err)
+				http.Error(w, "Unauthorized - invalid or expired token", http.StatusUnauthorized)
+				return
+			}
+
+			// Driver app tokens only grant access to the driver API
+			if claims.Role == domain.DriverRole {
+				http.Error(w, "Forbidden - driver tokens cannot access this resource", http.StatusForbidden)
+				return
+			}
+
+			// Tokens are bound to a session so logout and revocation take effect immediately
+			if sessions != nil {
+				if claims.SessionID == "" {
+					http.Error(w, "Unauthorized - session required, please sign in again", http.StatusUnauthorized)
+					return
+				}
+				if err := sessions.ValidateSession(r.Context(), claims.SessionID, claims.UserID); err != nil {
+					if errors.Is(err, domain.ErrSessionRevoked) {
+						http.Error(w, "Unauthorized - session has been revoked or has expired", http.StatusUnauthorized)
+						return
+					}
+					log.Printf("[AUTH MIDDLEWARE] ERROR: Session validation failed: %v", err)
+					http.Error(w, "Failed to validate session", http.StatusInternalServerError)
+					return
 				}
 			}
 
@@ -93,3 +126,12 @@ func GetUserID(r *http.Request) int64 {
 	}
 	return int64(claims.UserID)
 }
+
+// GetSessionID retrieves the session ID of the access token from request context
+func GetSessionID(r *http.Request) string {
+	claims := GetUserClaims(r)
+	if claims == nil {
+		return ""
+	}
+	return claims.SessionID
+}
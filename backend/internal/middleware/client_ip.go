@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const ClientIPContextKey contextKey = "clientIP"
+
+// ClientIPMiddleware resolves the address each request came from. X-Forwarded-For and
+// X-Real-IP are set by the client, so they are only read when the request arrives from one
+// of the trusted proxies (IP addresses or CIDR ranges); otherwise the peer address is used.
+func ClientIPMiddleware(trustedProxies []string) (func(http.Handler) http.Handler, error) {
+	trusted, err := parseTrustedProxies(trustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := context.WithValue(r.Context(), ClientIPContextKey, resolveClientIP(r, trusted))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}, nil
+}
+
+// ClientIP returns the address the request came from, or "" when it is not a valid IP.
+// Without ClientIPMiddleware no proxy is trusted.
+func ClientIP(r *http.Request) string {
+	if ip, ok := r.Context().Value(ClientIPContextKey).(string); ok {
+		return ip
+	}
+	return resolveClientIP(r, nil)
+}
+
+// resolveClientIP walks X-Forwarded-For from the nearest hop back while the hops are trusted
+// proxies; the first untrusted hop is the client
+func resolveClientIP(r *http.Request, trusted []*net.IPNet) string {
+	peer := parseIP(r.RemoteAddr)
+	if peer == nil {
+		return ""
+	}
+	if !isTrusted(peer, trusted) {
+		return peer.String()
+	}
+
+	client := peer
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := parseIP(hops[i])
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !isTrusted(ip, trusted) {
+			return ip.String()
+		}
+	}
+	if client.Equal(peer) {
+		if ip := parseIP(r.Header.Get("X-Real-IP")); ip != nil {
+			return ip.String()
+		}
+	}
+	return client.String()
+}
+
+// parseIP parses an address with or without a port
+func parseIP(addr string) net.IP {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(addr)
+}
+
+func isTrusted(ip net.IP, trusted []*net.IPNet) bool {
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseTrustedProxies(proxies []string) ([]*net.IPNet, error) {
+	var trusted []*net.IPNet
+	for _, proxy := range proxies {
+		proxy = strings.TrimSpace(proxy)
+		if proxy == "" {
+			continue
+		}
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", proxy)
+		}
+		trusted = append(trusted, network)
+	}
+	return trusted, nil
+}
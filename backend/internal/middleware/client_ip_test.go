@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// TestClientIP tests that forwarding headers are only honored from trusted proxies
+func TestClientIP(t *testing.T) {
+	mw, err := ClientIPMiddleware([]string{"10.0.0.0/8", "192.0.2.10"})
+	if err != nil {
+		t.Fatalf("ClientIPMiddleware() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		realIP     string
+		want       string
+	}{
+		{"direct client", "203.0.113.5:4000", "", "", "203.0.113.5"},
+		{"spoofed header from untrusted peer", "203.0.113.5:4000", "198.51.100.1", "198.51.100.2", "203.0.113.5"},
+		{"trusted proxy", "10.1.2.3:4000", "198.51.100.1", "", "198.51.100.1"},
+		{"spoofed hop before trusted proxy", "10.1.2.3:4000", "198.51.100.99, 198.51.100.1", "", "198.51.100.1"},
+		{"chain of trusted proxies", "192.0.2.10:4000", "198.51.100.1, 10.0.0.7", "", "198.51.100.1"},
+		{"real IP from trusted proxy", "10.1.2.3:4000", "", "198.51.100.3", "198.51.100.3"},
+		{"malformed hop", "10.1.2.3:4000", "not-an-ip", "", "10.1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = tt.remoteAddr
+			if tt.forwarded != "" {
+				r.Header.Set("X-Forwarded-For", tt.forwarded)
+			}
+			if tt.realIP != "" {
+				r.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			var got string
+			mw(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				got = ClientIP(r)
+			})).ServeHTTP(httptest.NewRecorder(), r)
+			if got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ClientIPMiddleware([]string{"10.0.0.0/33"}); err == nil {
+		t.Error("ClientIPMiddleware() accepted an invalid CIDR")
+	}
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/pkg/jwt"
+)
+
+const DriverContextKey contextKey = "driver"
+
+// DriverResolver loads the driver behind a validated driver app token
+type DriverResolver interface {
+	ResolveDriver(ctx context.Context, claims *jwt.Claims) (*domain.Driver, error)
+}
+
+// DriverAuthMiddleware authenticates driver app requests.
+// The token must be valid and carry the driver role.
+// The token is read from the Authorization header, or from the ?token= query parameter for
+// WebSocket connections, which cannot set headers from the browser. The driver's tenant and
+// restaurant are put in the request context.
+func DriverAuthMiddleware(tokenService *jwt.TokenService, resolver DriverResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := ""
+			if header := r.Header.Get("Authorization"); header != "" {
+				parts := strings.Split(header, " ")
+				if len(parts) != 2 || parts[0] != "Bearer" {
+					http.Error(w, "Invalid authorization header", http.StatusUnauthorized)
+					return
+				}
+				token = parts[1]
+			} else {
+				token = r.URL.Query().Get("token")
+			}
+			if token == "" {
+				http.Error(w, "Unauthorized - missing authorization header", http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := tokenService.ValidateToken(token)
+			if err != nil || claims.Role != domain.DriverRole {
+				http.Error(w, "Unauthorized - invalid driver token", http.StatusUnauthorized)
+				return
+			}
+
+			driver, err := resolver.ResolveDriver(r.Context(), claims)
+			if err != nil {
+				http.Error(w, "Unauthorized - driver not found or inactive", http.StatusUnauthorized)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), UserContextKey, claims)
+			ctx = context.WithValue(ctx, TenantContextKey, int64(driver.TenantID))
+			ctx = context.WithValue(ctx, RestaurantContextKey, int64(driver.RestaurantID))
+			ctx = context.WithValue(ctx, DriverContextKey, driver)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetDriver retrieves the authenticated driver, if any
+func GetDriver(r *http.Request) *domain.Driver {
+	driver, ok := r.Context().Value(DriverContextKey).(*domain.Driver)
+	if !ok {
+		return nil
+	}
+	return driver
+}
@@ -2,16 +2,23 @@ package middleware
 
 import (
 	"context"
+	"log"
 	"net/http"
 )
 
 type contextKeyPermission string
 
 const (
-	RequiredModuleContextKey      contextKeyPermission = "required_module_id"
-	RequiredPermissionContextKey  contextKeyPermission = "required_permission_level"
+	RequiredModuleContextKey     contextKeyPermission = "required_module_id"
+	RequiredPermissionContextKey contextKeyPermission = "required_permission_level"
 )
 
+// PermissionChecker decides whether a user's roles in a restaurant grant a permission level
+// on a module
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, tenantID, restaurantID, userID, moduleID int64, level string) (bool, error)
+}
+
 // WithRequiredPermission wraps a handler and sets required module and permission level in context
 func WithRequiredPermission(moduleID int64, permissionLevel string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
@@ -22,3 +29,45 @@ func WithRequiredPermission(moduleID int64, permissionLevel string) func(http.Ha
 		})
 	}
 }
+
+// PermissionMiddleware enforces the module and level set by WithRequiredPermission, so it
+// must run inside it, and after AuthMiddleware and TenantContextMiddleware. Requests without
+// a requirement in the context are refused rather than let through. Roles are evaluated in
+// the request's restaurant, so a role limited to another restaurant grants nothing here.
+func PermissionMiddleware(checker PermissionChecker) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims := GetUserClaims(r)
+			if claims == nil {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			moduleID, _ := r.Context().Value(RequiredModuleContextKey).(int64)
+			level, _ := r.Context().Value(RequiredPermissionContextKey).(string)
+			if moduleID == 0 || level == "" {
+				http.Error(w, "Forbidden - no permission requirement for this route", http.StatusForbidden)
+				return
+			}
+
+			// An API key is limited to its scopes on top of its owner's permissions
+			if key := GetAPIKey(r); key != nil && !key.Scopes.Allows(moduleID, level) {
+				http.Error(w, "Forbidden - API key is not scoped for this resource", http.StatusForbidden)
+				return
+			}
+
+			allowed, err := checker.HasPermission(r.Context(), GetTenantID(r), GetRestaurantID(r), int64(claims.UserID), moduleID, level)
+			if err != nil {
+				log.Printf("[PERMISSION MIDDLEWARE] Failed to check %s on module %d for user %d: %v", level, moduleID, claims.UserID, err)
+				http.Error(w, "Failed to check permissions", http.StatusInternalServerError)
+				return
+			}
+			if !allowed {
+				http.Error(w, "Forbidden - insufficient permissions", http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
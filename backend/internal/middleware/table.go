@@ -0,0 +1,55 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"pos-saas/internal/domain"
+)
+
+const TableContextKey contextKey = "diningTable"
+
+// TableResolver resolves a QR table token to a dining table
+type TableResolver interface {
+	ResolveTableToken(token string) (*domain.DiningTable, error)
+}
+
+// TableTokenMiddleware binds a request to a dine-in table from its QR token.
+// The token is read from the X-Table-Token header or the ?table= query parameter
+// and also supplies the tenant and restaurant context. Requests without a token
+// fall through to TenantContextMiddleware.
+func TableTokenMiddleware(resolver TableResolver) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fallback := TenantContextMiddleware(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token := r.Header.Get("X-Table-Token")
+			if token == "" {
+				token = r.URL.Query().Get("table")
+			}
+			if token == "" {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+
+			table, err := resolver.ResolveTableToken(token)
+			if err != nil {
+				http.Error(w, "Invalid table token", http.StatusNotFound)
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), TenantContextKey, table.TenantID)
+			ctx = context.WithValue(ctx, RestaurantContextKey, table.RestaurantID)
+			ctx = context.WithValue(ctx, TableContextKey, table)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// GetDiningTable retrieves the dine-in table bound to the request, if any
+func GetDiningTable(r *http.Request) *domain.DiningTable {
+	table, ok := r.Context().Value(TableContextKey).(*domain.DiningTable)
+	if !ok {
+		return nil
+	}
+	return table
+}
@@ -1,10 +1,13 @@
 package jwt
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
 	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
 type Claims struct {
@@ -13,12 +16,23 @@ type Claims struct {
 	TenantID     int    `json:"tenant_id"`
 	RestaurantID int    `json:"restaurant_id,omitempty"`
 	Role         string `json:"role"`
+	SessionID    string `json:"sid,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// SelectionClaims are carried by the token returned when a login matches accounts in
+// several tenants. It proves the password was verified and lists the tenants the user may
+// pick; its ID makes it single-use.
+type SelectionClaims struct {
+	Email     string `json:"email"`
+	TenantIDs []int  `json:"tenant_ids"`
 	jwt.RegisteredClaims
 }
 
 type TokenService struct {
-	secret []byte
-	expiry time.Duration
+	secret          []byte
+	selectionSecret []byte
+	expiry          time.Duration
 }
 
 func NewTokenService(secret string, expiry string) (*TokenService, error) {
@@ -27,13 +41,24 @@ func NewTokenService(secret string, expiry string) (*TokenService, error) {
 		return nil, err
 	}
 
+	// Selection tokens are signed with a derived key so they can never pass as access tokens
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("tenant-selection"))
+
 	return &TokenService{
-		secret: []byte(secret),
-		expiry: duration,
+		secret:          []byte(secret),
+		selectionSecret: mac.Sum(nil),
+		expiry:          duration,
 	}, nil
 }
 
 func (s *TokenService) GenerateToken(userID, tenantID int, restaurantID *int, email, role string) (string, error) {
+	return s.GenerateSessionToken(userID, tenantID, restaurantID, email, role, "")
+}
+
+// GenerateSessionToken issues an access token bound to a user session, so revoking the
+// session also rejects the access token
+func (s *TokenService) GenerateSessionToken(userID, tenantID int, restaurantID *int, email, role, sessionID string) (string, error) {
 	restID := 0
 	if restaurantID != nil {
 		restID = *restaurantID
@@ -45,6 +70,7 @@ func (s *TokenService) GenerateToken(userID, tenantID int, restaurantID *int, em
 		TenantID:     tenantID,
 		RestaurantID: restID,
 		Role:         role,
+		SessionID:    sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(s.expiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
@@ -55,6 +81,11 @@ func (s *TokenService) GenerateToken(userID, tenantID int, restaurantID *int, em
 	return token.SignedString(s.secret)
 }
 
+// Expiry returns the lifetime of access tokens
+func (s *TokenService) Expiry() time.Duration {
+	return s.expiry
+}
+
 func (s *TokenService) ValidateToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
@@ -73,3 +104,40 @@ func (s *TokenService) ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("invalid token")
 }
+
+// GenerateSelectionToken issues a tenant selection token for a verified login
+func (s *TokenService) GenerateSelectionToken(email string, tenantIDs []int, ttl time.Duration) (string, error) {
+	claims := SelectionClaims{
+		Email:     email,
+		TenantIDs: tenantIDs,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString(s.selectionSecret)
+}
+
+// ValidateSelectionToken checks the signature and expiry of a tenant selection token.
+// Callers must still make sure its ID is only used once.
+func (s *TokenService) ValidateSelectionToken(tokenString string) (*SelectionClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &SelectionClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+		return s.selectionSecret, nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(*SelectionClaims); ok && token.Valid && claims.ID != "" && claims.ExpiresAt != nil {
+		return claims, nil
+	}
+
+	return nil, errors.New("invalid token")
+}
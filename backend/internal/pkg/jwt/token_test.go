@@ -0,0 +1,39 @@
+package jwt
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSelectionToken tests the round trip and that selection and access tokens cannot be swapped
+func TestSelectionToken(t *testing.T) {
+	s, err := NewTokenService("test-secret", "1h")
+	if err != nil {
+		t.Fatalf("NewTokenService() error = %v", err)
+	}
+
+	token, err := s.GenerateSelectionToken("owner@example.com", []int{1, 2}, time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateSelectionToken() error = %v", err)
+	}
+	claims, err := s.ValidateSelectionToken(token)
+	if err != nil {
+		t.Fatalf("ValidateSelectionToken() error = %v", err)
+	}
+	if claims.Email != "owner@example.com" || len(claims.TenantIDs) != 2 || claims.ID == "" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+
+	if _, err := s.ValidateToken(token); err == nil {
+		t.Error("ValidateToken() accepted a selection token")
+	}
+	access, _ := s.GenerateToken(7, 1, nil, "owner@example.com", "owner")
+	if _, err := s.ValidateSelectionToken(access); err == nil {
+		t.Error("ValidateSelectionToken() accepted an access token")
+	}
+
+	expired, _ := s.GenerateSelectionToken("owner@example.com", []int{1}, -time.Minute)
+	if _, err := s.ValidateSelectionToken(expired); err == nil {
+		t.Error("ValidateSelectionToken() accepted an expired token")
+	}
+}
@@ -0,0 +1,154 @@
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"mime"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mail drivers
+const (
+	DriverLog  = "log"  // Writes a summary of each message to the server log
+	DriverFile = "file" // Writes each message as an .eml file, for local development and tests
+	DriverSMTP = "smtp" // Sends through an SMTP server or a catcher such as MailHog or Mailpit
+)
+
+// Message is a plain text email with an optional HTML alternative
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer sends email
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config selects and configures the mail driver
+type Config struct {
+	Driver       string
+	FromEmail    string
+	FromName     string
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	FileDir      string
+}
+
+// New returns the mailer of the configured driver
+func New(cfg Config) (Mailer, error) {
+	from := mail.Address{Name: cfg.FromName, Address: cfg.FromEmail}
+	switch cfg.Driver {
+	case "", DriverLog:
+		return &LogMailer{}, nil
+	case DriverFile:
+		if cfg.FileDir == "" {
+			return nil, fmt.Errorf("mailer: MAIL_FILE_DIR is required for the file driver")
+		}
+		return &FileMailer{Dir: cfg.FileDir, From: from}, nil
+	case DriverSMTP:
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("mailer: SMTP_HOST is required for the smtp driver")
+		}
+		return &SMTPMailer{
+			Addr:     cfg.SMTPHost + ":" + strconv.Itoa(cfg.SMTPPort),
+			Host:     cfg.SMTPHost,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     from,
+		}, nil
+	}
+	return nil, fmt.Errorf("mailer: unknown driver %q", cfg.Driver)
+}
+
+// LogMailer logs messages instead of sending them. The body is not logged because it
+// may hold a password reset or verification link.
+type LogMailer struct{}
+
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	log.Printf("[mailer] to=%s subject=%q (log driver, not sent)", msg.To, msg.Subject)
+	return nil
+}
+
+// FileMailer writes each message to Dir as an RFC 5322 .eml file
+type FileMailer struct {
+	Dir  string
+	From mail.Address
+}
+
+func (m *FileMailer) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+	if err := os.WriteFile(filepath.Join(m.Dir, name), build(m.From, msg), 0o644); err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	return nil
+}
+
+// SMTPMailer sends messages through an SMTP server. Authentication is used when a
+// username is set; servers offering STARTTLS are upgraded automatically.
+type SMTPMailer struct {
+	Addr     string
+	Host     string
+	Username string
+	Password string
+	From     mail.Address
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	if err := smtp.SendMail(m.Addr, auth, m.From.Address, []string{msg.To}, build(m.From, msg)); err != nil {
+		return fmt.Errorf("mailer: %w", err)
+	}
+	return nil
+}
+
+// build renders a message with a text part and, when set, an HTML alternative
+func build(from mail.Address, msg Message) []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", from.String())
+	fmt.Fprintf(&b, "To: %s\r\n", strings.NewReplacer("\r", "", "\n", "").Replace(msg.To))
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+
+	if msg.HTML == "" {
+		b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+		b.WriteString(crlf(msg.Text))
+		return b.Bytes()
+	}
+
+	boundary := "pos-saas-alt-" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	fmt.Fprintf(&b, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n%s\r\n", boundary, crlf(msg.Text))
+	fmt.Fprintf(&b, "--%s\r\nContent-Type: text/html; charset=utf-8\r\n\r\n%s\r\n", boundary, crlf(msg.HTML))
+	fmt.Fprintf(&b, "--%s--\r\n", boundary)
+	return b.Bytes()
+}
+
+func crlf(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "\r\n", "\n"), "\n", "\r\n")
+}
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFileMailer tests that the file driver writes a readable message per email
+func TestFileMailer(t *testing.T) {
+	dir := t.TempDir()
+	m, err := New(Config{Driver: DriverFile, FileDir: dir, FromEmail: "noreply@pos.com", FromName: "Restaurant POS"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	msg := Message{To: "owner@example.com\r\nBcc: x@example.com", Subject: "Reset your password", Text: "Line one\nLine two"}
+	if err := m.Send(context.Background(), msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("wrote %d files, want 1", len(files))
+	}
+	f, err := os.Open(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	parsed, err := mail.ReadMessage(f)
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if got := parsed.Header.Get("Subject"); got != "Reset your password" {
+		t.Errorf("Subject = %q", got)
+	}
+	if got := parsed.Header.Get("Bcc"); got != "" {
+		t.Errorf("Bcc header was injected: %q", got)
+	}
+	if got := parsed.Header.Get("From"); !strings.Contains(got, "noreply@pos.com") {
+		t.Errorf("From = %q", got)
+	}
+}
+
+// TestNewUnknownDriver tests that misconfigured drivers are rejected
+func TestNewUnknownDriver(t *testing.T) {
+	if _, err := New(Config{Driver: "carrier-pigeon"}); err == nil {
+		t.Error("New() expected an error for an unknown driver")
+	}
+	if _, err := New(Config{Driver: DriverSMTP}); err == nil {
+		t.Error("New() expected an error without SMTP_HOST")
+	}
+	if _, err := New(Config{Driver: DriverFile}); err == nil {
+		t.Error("New() expected an error without MAIL_FILE_DIR")
+	}
+}
@@ -0,0 +1,486 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// discoveryTTL is how long a provider's discovery document and signing keys are cached
+	discoveryTTL = time.Hour
+	// keyRefetchInterval is how often tokens with an unknown key ID may refetch a provider's keys
+	keyRefetchInterval = time.Minute
+)
+
+var (
+	ErrInvalidIDToken = errors.New("oidc: invalid ID token")
+	ErrNonceMismatch  = errors.New("oidc: ID token nonce does not match the login request")
+	// ErrForbiddenAddress is returned when a provider URL resolves to a non-public address
+	ErrForbiddenAddress = errors.New("oidc: provider address is not public")
+)
+
+// Provider is the part of an OpenID provider's discovery document used for the
+// authorization code flow
+type Provider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// AuthRequest describes the authorization request a user is redirected to
+type AuthRequest struct {
+	ClientID      string
+	RedirectURI   string
+	Scopes        []string
+	State         string
+	Nonce         string
+	CodeChallenge string // S256 challenge of the PKCE code verifier
+}
+
+// Token is a token endpoint response
+type Token struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	IDToken     string `json:"id_token"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// Claims are the verified claims of an ID token
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Raw           map[string]interface{}
+}
+
+// Groups returns the string values of a claim such as "groups" or "roles". A single string
+// is treated as one group. ok is false when the token does not carry the claim at all.
+func (c *Claims) Groups(claim string) (groups []string, ok bool) {
+	value, ok := c.Raw[claim]
+	if !ok {
+		return nil, false
+	}
+	switch v := value.(type) {
+	case string:
+		return []string{v}, true
+	case []interface{}:
+		for _, item := range v {
+			if s, isString := item.(string); isString {
+				groups = append(groups, s)
+			}
+		}
+	}
+	return groups, true
+}
+
+// Client runs the authorization code flow with PKCE against OpenID providers. Discovery
+// documents and signing keys are cached per issuer.
+type Client struct {
+	HTTPClient *http.Client
+	// AllowLocal permits http URLs and, with the default HTTP client, providers on loopback
+	// and private networks. Issuer URLs are chosen by tenants, so it is for development only.
+	AllowLocal bool
+
+	mu        sync.Mutex
+	providers map[string]*cachedProvider
+}
+
+type cachedProvider struct {
+	provider      *Provider
+	keys          map[string]interface{}
+	fetchedAt     time.Time
+	keysFetchedAt time.Time
+}
+
+// NewClient creates a client; a nil httpClient uses one with a 10 second timeout that only
+// connects to public addresses
+func NewClient(httpClient *http.Client) *Client {
+	c := &Client{HTTPClient: httpClient, providers: map[string]*cachedProvider{}}
+	if httpClient == nil {
+		c.HTTPClient = c.publicHTTPClient()
+	}
+	return c
+}
+
+// publicHTTPClient returns an HTTP client that refuses to connect to loopback, link-local
+// and private addresses unless AllowLocal is set. The check runs on the resolved address at
+// dial time, so DNS names and redirects cannot be used to reach internal services.
+func (c *Client) publicHTTPClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if c.AllowLocal {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+				return fmt.Errorf("%w: %s", ErrForbiddenAddress, host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{
+		Timeout:   10 * time.Second,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: 5 * time.Second},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 5 {
+				return errors.New("too many redirects")
+			}
+			return c.checkURL(req.URL.String())
+		},
+	}
+}
+
+// isPublicIP reports whether ip is a globally routable unicast address
+func isPublicIP(ip net.IP) bool {
+	return !(ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified())
+}
+
+// checkURL refuses provider URLs that are not https, unless AllowLocal is set
+func (c *Client) checkURL(rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("oidc: invalid URL %q", rawURL)
+	}
+	if u.Scheme != "https" && !(c.AllowLocal && u.Scheme == "http") {
+		return fmt.Errorf("oidc: %s must use https", rawURL)
+	}
+	return nil
+}
+
+// NewCodeVerifier returns a random PKCE code verifier
+func NewCodeVerifier() (string, error) {
+	return randomString(32)
+}
+
+// NewState returns a random value for the state or nonce parameter
+func NewState() (string, error) {
+	return randomString(24)
+}
+
+// CodeChallenge returns the S256 code challenge of a verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// Discover fetches the provider's discovery document. The document must name the issuer it
+// was fetched from.
+func (c *Client) Discover(ctx context.Context, issuer string) (*Provider, error) {
+	issuer = strings.TrimSuffix(issuer, "/")
+	if cached := c.cached(issuer); cached != nil {
+		return cached.provider, nil
+	}
+	if err := c.checkURL(issuer); err != nil {
+		return nil, err
+	}
+
+	var provider Provider
+	if err := c.getJSON(ctx, issuer+"/.well-known/openid-configuration", &provider); err != nil {
+		return nil, fmt.Errorf("oidc: discovery failed: %w", err)
+	}
+	if strings.TrimSuffix(provider.Issuer, "/") != issuer {
+		return nil, fmt.Errorf("oidc: discovery document is for issuer %q, not %q", provider.Issuer, issuer)
+	}
+	if provider.AuthorizationEndpoint == "" || provider.TokenEndpoint == "" || provider.JWKSURI == "" {
+		return nil, errors.New("oidc: discovery document is missing endpoints")
+	}
+	for _, endpoint := range []string{provider.AuthorizationEndpoint, provider.TokenEndpoint, provider.JWKSURI} {
+		if err := c.checkURL(endpoint); err != nil {
+			return nil, err
+		}
+	}
+
+	c.mu.Lock()
+	c.providers[issuer] = &cachedProvider{provider: &provider, fetchedAt: time.Now()}
+	c.mu.Unlock()
+	return &provider, nil
+}
+
+func (c *Client) cached(issuer string) *cachedProvider {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached := c.providers[issuer]
+	if cached == nil || time.Since(cached.fetchedAt) > discoveryTTL {
+		return nil
+	}
+	return cached
+}
+
+// AuthCodeURL returns the provider's authorization URL for a code flow request with PKCE
+func (p *Provider) AuthCodeURL(req AuthRequest) string {
+	params := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {req.ClientID},
+		"redirect_uri":          {req.RedirectURI},
+		"scope":                 {strings.Join(req.Scopes, " ")},
+		"state":                 {req.State},
+		"nonce":                 {req.Nonce},
+		"code_challenge":        {req.CodeChallenge},
+		"code_challenge_method": {"S256"},
+	}
+	sep := "?"
+	if strings.Contains(p.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return p.AuthorizationEndpoint + sep + params.Encode()
+}
+
+// Exchange trades an authorization code and its PKCE verifier for tokens. The client secret
+// is sent with HTTP basic authentication; public clients leave it empty.
+func (c *Client) Exchange(ctx context.Context, p *Provider, clientID, clientSecret, redirectURI, code, verifier string) (*Token, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	}
+	if clientSecret == "" {
+		form.Set("client_id", clientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if clientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(clientID), url.QueryEscape(clientSecret))
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if resp.StatusCode != http.StatusOK {
+		var oauthErr struct {
+			Error       string `json:"error"`
+			Description string `json:"error_description"`
+		}
+		_ = json.Unmarshal(body, &oauthErr)
+		return nil, fmt.Errorf("oidc: token endpoint returned %d %s %s", resp.StatusCode, oauthErr.Error, oauthErr.Description)
+	}
+
+	var token Token
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("oidc: invalid token response: %w", err)
+	}
+	if token.IDToken == "" {
+		return nil, errors.New("oidc: token response has no id_token")
+	}
+	return &token, nil
+}
+
+// VerifyIDToken checks an ID token's signature against the provider's keys, and its issuer,
+// audience, expiry and nonce
+func (c *Client) VerifyIDToken(ctx context.Context, p *Provider, clientID, rawIDToken, nonce string) (*Claims, error) {
+	raw := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(rawIDToken, raw, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return c.signingKey(ctx, p, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384"}),
+		jwt.WithIssuer(p.Issuer),
+		jwt.WithAudience(clientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIDToken, err)
+	}
+
+	// With several audiences the token must have been issued to this client
+	if aud, _ := raw.GetAudience(); len(aud) > 1 {
+		if azp, _ := raw["azp"].(string); azp != clientID {
+			return nil, fmt.Errorf("%w: authorized party is not this client", ErrInvalidIDToken)
+		}
+	}
+	if tokenNonce, _ := raw["nonce"].(string); tokenNonce != nonce {
+		return nil, ErrNonceMismatch
+	}
+
+	claims := &Claims{Raw: raw}
+	claims.Subject, _ = raw["sub"].(string)
+	claims.Email, _ = raw["email"].(string)
+	claims.Name, _ = raw["name"].(string)
+	switch verified := raw["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = verified
+	case string:
+		claims.EmailVerified = verified == "true"
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing subject", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+// signingKey returns the provider key with this ID, fetching the key set again when the key
+// is unknown so rotated keys are picked up. The key set is fetched at most once per
+// keyRefetchInterval, so tokens with made-up key IDs cannot make every request fetch it.
+func (c *Client) signingKey(ctx context.Context, p *Provider, kid string) (interface{}, error) {
+	issuer := strings.TrimSuffix(p.Issuer, "/")
+	key, refetch := c.cachedKey(issuer, kid)
+	if key != nil {
+		return key, nil
+	}
+	if !refetch {
+		return nil, fmt.Errorf("oidc: no signing key %q", kid)
+	}
+
+	keys, err := c.fetchKeys(ctx, p.JWKSURI)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	c.mu.Lock()
+	c.providers[issuer] = &cachedProvider{provider: p, keys: keys, fetchedAt: now, keysFetchedAt: now}
+	c.mu.Unlock()
+
+	if key := pickKey(keys, kid); key != nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("oidc: no signing key %q", kid)
+}
+
+// cachedKey returns the cached key with this ID. Otherwise refetch reports whether the key
+// set may be fetched now; the fetch is then reserved for the caller.
+func (c *Client) cachedKey(issuer, kid string) (key interface{}, refetch bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cached := c.providers[issuer]
+	if cached == nil || time.Since(cached.fetchedAt) > discoveryTTL {
+		return nil, true
+	}
+	if key := pickKey(cached.keys, kid); key != nil {
+		return key, false
+	}
+	if time.Since(cached.keysFetchedAt) < keyRefetchInterval {
+		return nil, false
+	}
+	cached.keysFetchedAt = time.Now()
+	return nil, true
+}
+
+// pickKey returns the key with this ID, or the only key when the token names none
+func pickKey(keys map[string]interface{}, kid string) interface{} {
+	if kid != "" {
+		return keys[kid]
+	}
+	if len(keys) == 1 {
+		for _, key := range keys {
+			return key
+		}
+	}
+	return nil
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetchKeys loads the provider's RSA and EC signing keys
+func (c *Client) fetchKeys(ctx context.Context, jwksURI string) (map[string]interface{}, error) {
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := c.getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc: failed to fetch signing keys: %w", err)
+	}
+
+	keys := make(map[string]interface{}, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, errN := base64.RawURLEncoding.DecodeString(k.N)
+			e, errE := base64.RawURLEncoding.DecodeString(k.E)
+			if errN != nil || errE != nil {
+				continue
+			}
+			keys[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		case "EC":
+			var curve elliptic.Curve
+			switch k.Crv {
+			case "P-256":
+				curve = elliptic.P256()
+			case "P-384":
+				curve = elliptic.P384()
+			default:
+				continue
+			}
+			x, errX := base64.RawURLEncoding.DecodeString(k.X)
+			y, errY := base64.RawURLEncoding.DecodeString(k.Y)
+			if errX != nil || errY != nil {
+				continue
+			}
+			keys[k.Kid] = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("oidc: provider has no usable signing keys")
+	}
+	return keys, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
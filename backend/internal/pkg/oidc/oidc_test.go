@@ -0,0 +1,200 @@
+package oidc_test
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"pos-saas/internal/pkg/oidc"
+	"pos-saas/internal/pkg/oidc/oidctest"
+)
+
+const redirectURI = "http://localhost:8080/api/v1/auth/sso/callback"
+
+func newIssuer(t *testing.T) (*oidctest.Issuer, *oidc.Client) {
+	t.Helper()
+	issuer, err := oidctest.NewIssuer("", "pos-client", "pos-secret", redirectURI)
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+	server := httptest.NewServer(issuer)
+	t.Cleanup(server.Close)
+	issuer.URL = server.URL
+	client := oidc.NewClient(server.Client())
+	client.AllowLocal = true
+	return issuer, client
+}
+
+// authorize follows the authorization request and returns the code from the redirect
+func authorize(t *testing.T, client *oidc.Client, authURL string) url.Values {
+	t.Helper()
+	httpClient := *client.HTTPClient
+	httpClient.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := httpClient.Get(authURL)
+	if err != nil {
+		t.Fatalf("authorize error = %v", err)
+	}
+	defer resp.Body.Close()
+	location, err := url.Parse(resp.Header.Get("Location"))
+	if resp.StatusCode != http.StatusFound || err != nil {
+		t.Fatalf("authorize status = %d, location error = %v", resp.StatusCode, err)
+	}
+	return location.Query()
+}
+
+// TestCodeFlow tests discovery, the PKCE code exchange and ID token verification
+func TestCodeFlow(t *testing.T) {
+	issuer, client := newIssuer(t)
+	issuer.User.Groups = []string{"pos-admins", "kitchen"}
+	ctx := context.Background()
+
+	provider, err := client.Discover(ctx, issuer.URL+"/")
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	verifier, _ := oidc.NewCodeVerifier()
+	nonce, _ := oidc.NewState()
+	params := authorize(t, client, provider.AuthCodeURL(oidc.AuthRequest{
+		ClientID:      "pos-client",
+		RedirectURI:   redirectURI,
+		Scopes:        []string{"openid", "email"},
+		State:         "state-1",
+		Nonce:         nonce,
+		CodeChallenge: oidc.CodeChallenge(verifier),
+	}))
+	if params.Get("state") != "state-1" || params.Get("code") == "" {
+		t.Fatalf("redirect params = %v", params)
+	}
+
+	token, err := client.Exchange(ctx, provider, "pos-client", "pos-secret", redirectURI, params.Get("code"), verifier)
+	if err != nil {
+		t.Fatalf("Exchange() error = %v", err)
+	}
+	claims, err := client.VerifyIDToken(ctx, provider, "pos-client", token.IDToken, nonce)
+	if err != nil {
+		t.Fatalf("VerifyIDToken() error = %v", err)
+	}
+	if claims.Subject != "mock-user-1" || claims.Email != "sso.user@example.com" || !claims.EmailVerified {
+		t.Errorf("claims = %+v", claims)
+	}
+	if groups, ok := claims.Groups("groups"); !ok || len(groups) != 2 || groups[0] != "pos-admins" {
+		t.Errorf("Groups() = %v, %v", groups, ok)
+	}
+	if _, ok := claims.Groups("roles"); ok {
+		t.Error("Groups() found a claim the token does not carry")
+	}
+
+	// A code is single use
+	if _, err := client.Exchange(ctx, provider, "pos-client", "pos-secret", redirectURI, params.Get("code"), verifier); err == nil {
+		t.Error("Exchange() redeemed a code twice")
+	}
+}
+
+// TestCodeFlowRejects tests a wrong PKCE verifier, nonce, audience and client secret
+func TestCodeFlowRejects(t *testing.T) {
+	issuer, client := newIssuer(t)
+	ctx := context.Background()
+	provider, err := client.Discover(ctx, issuer.URL)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+
+	start := func() (string, string) {
+		verifier, _ := oidc.NewCodeVerifier()
+		params := authorize(t, client, provider.AuthCodeURL(oidc.AuthRequest{
+			ClientID: "pos-client", RedirectURI: redirectURI, Scopes: []string{"openid"},
+			State: "s", Nonce: "n", CodeChallenge: oidc.CodeChallenge(verifier),
+		}))
+		return params.Get("code"), verifier
+	}
+
+	code, _ := start()
+	other, _ := oidc.NewCodeVerifier()
+	if _, err := client.Exchange(ctx, provider, "pos-client", "pos-secret", redirectURI, code, other); err == nil {
+		t.Error("Exchange() accepted the wrong code verifier")
+	}
+
+	code, verifier := start()
+	if _, err := client.Exchange(ctx, provider, "pos-client", "wrong", redirectURI, code, verifier); err == nil {
+		t.Error("Exchange() accepted the wrong client secret")
+	}
+
+	idToken, _ := issuer.SignIDToken("n")
+	if _, err := client.VerifyIDToken(ctx, provider, "pos-client", idToken, "other"); !errors.Is(err, oidc.ErrNonceMismatch) {
+		t.Errorf("VerifyIDToken() wrong nonce error = %v", err)
+	}
+	if _, err := client.VerifyIDToken(ctx, provider, "other-client", idToken, "n"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+		t.Errorf("VerifyIDToken() wrong audience error = %v", err)
+	}
+
+	if _, err := client.Discover(ctx, "http://127.0.0.1:1"); err == nil {
+		t.Error("Discover() succeeded for an unreachable issuer")
+	}
+}
+
+// TestPublicClient tests that the default client only fetches https URLs on public addresses
+func TestPublicClient(t *testing.T) {
+	issuer, err := oidctest.NewIssuer("", "pos-client", "pos-secret", redirectURI)
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+	server := httptest.NewTLSServer(issuer)
+	t.Cleanup(server.Close)
+	issuer.URL = server.URL
+	ctx := context.Background()
+
+	client := oidc.NewClient(nil)
+	if _, err := client.Discover(ctx, "http://login.example.com"); err == nil {
+		t.Error("Discover() accepted a plain http issuer")
+	}
+	if _, err := client.Discover(ctx, server.URL); !errors.Is(err, oidc.ErrForbiddenAddress) {
+		t.Errorf("Discover() loopback issuer error = %v, want ErrForbiddenAddress", err)
+	}
+}
+
+// TestUnknownKeyIDRefetch tests that tokens with unknown key IDs refetch the signing keys
+// at most once per interval
+func TestUnknownKeyIDRefetch(t *testing.T) {
+	issuer, err := oidctest.NewIssuer("", "pos-client", "pos-secret", redirectURI)
+	if err != nil {
+		t.Fatalf("NewIssuer() error = %v", err)
+	}
+	var keyFetches int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/jwks" {
+			keyFetches++
+		}
+		issuer.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	issuer.URL = server.URL
+	client := oidc.NewClient(server.Client())
+	client.AllowLocal = true
+	ctx := context.Background()
+
+	provider, err := client.Discover(ctx, issuer.URL)
+	if err != nil {
+		t.Fatalf("Discover() error = %v", err)
+	}
+	idToken, _ := issuer.SignIDToken("n")
+	if _, err := client.VerifyIDToken(ctx, provider, "pos-client", idToken, "n"); err != nil {
+		t.Fatalf("VerifyIDToken() error = %v", err)
+	}
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"RS256","kid":"forged","typ":"JWT"}`))
+	forged := header + idToken[strings.Index(idToken, "."):]
+	for i := 0; i < 3; i++ {
+		if _, err := client.VerifyIDToken(ctx, provider, "pos-client", forged, "n"); !errors.Is(err, oidc.ErrInvalidIDToken) {
+			t.Errorf("VerifyIDToken() unknown key error = %v", err)
+		}
+	}
+	if keyFetches != 1 {
+		t.Errorf("signing keys fetched %d times, want 1", keyFetches)
+	}
+}
@@ -0,0 +1,249 @@
+// Package oidctest provides a minimal OpenID provider for tests and local development. It
+// implements discovery, the authorization code flow with PKCE and a JSON Web Key Set, and
+// signs in a single configurable user without asking for credentials.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// User is the account the issuer signs in
+type User struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	Groups        []string // nil leaves the groups claim out of the ID token
+}
+
+type authCode struct {
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+	expiresAt     time.Time
+}
+
+// Issuer is an http.Handler serving the provider endpoints under its issuer URL
+type Issuer struct {
+	URL          string // Issuer URL, e.g. http://localhost:9400
+	ClientID     string
+	ClientSecret string // Empty accepts public clients
+	RedirectURIs []string
+	User         User
+
+	key   *rsa.PrivateKey
+	keyID string
+
+	mu    sync.Mutex
+	codes map[string]authCode
+}
+
+// NewIssuer creates an issuer with a fresh RSA signing key
+func NewIssuer(issuerURL, clientID, clientSecret string, redirectURIs ...string) (*Issuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+	return &Issuer{
+		URL:          issuerURL,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURIs: redirectURIs,
+		User: User{
+			Subject:       "mock-user-1",
+			Email:         "sso.user@example.com",
+			EmailVerified: true,
+			Name:          "SSO User",
+		},
+		key:   key,
+		keyID: "mock-key-1",
+		codes: map[string]authCode{},
+	}, nil
+}
+
+// ServeHTTP routes the provider endpoints
+func (i *Issuer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		i.discovery(w)
+	case "/authorize":
+		i.authorize(w, r)
+	case "/token":
+		i.token(w, r)
+	case "/jwks":
+		i.jwks(w)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (i *Issuer) discovery(w http.ResponseWriter) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                i.URL,
+		"authorization_endpoint":                i.URL + "/authorize",
+		"token_endpoint":                        i.URL + "/token",
+		"jwks_uri":                              i.URL + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+// authorize signs the user in straight away and redirects back with a code
+func (i *Issuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	if q.Get("client_id") != i.ClientID || !i.allowedRedirect(redirectURI) {
+		http.Error(w, "unknown client or redirect_uri", http.StatusBadRequest)
+		return
+	}
+
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := target.Query()
+	params.Set("state", q.Get("state"))
+
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		params.Set("error", "invalid_request")
+		params.Set("error_description", "code flow with an S256 code challenge is required")
+	} else {
+		code := randomString()
+		i.mu.Lock()
+		i.codes[code] = authCode{
+			redirectURI:   redirectURI,
+			nonce:         q.Get("nonce"),
+			codeChallenge: q.Get("code_challenge"),
+			expiresAt:     time.Now().Add(time.Minute),
+		}
+		i.mu.Unlock()
+		params.Set("code", code)
+	}
+
+	target.RawQuery = params.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+// token redeems a code once, checking the client, redirect URI and PKCE verifier
+func (i *Issuer) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+
+	clientID, secret, hasBasic := r.BasicAuth()
+	if hasBasic {
+		clientID, _ = url.QueryUnescape(clientID)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+	}
+	if clientID != i.ClientID || secret != i.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if r.PostForm.Get("grant_type") != "authorization_code" {
+		tokenError(w, "unsupported_grant_type")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	i.mu.Lock()
+	issued, ok := i.codes[code]
+	delete(i.codes, code)
+	i.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	challenge := base64.RawURLEncoding.EncodeToString(sum[:])
+	if !ok || time.Now().After(issued.expiresAt) || issued.redirectURI != r.PostForm.Get("redirect_uri") || issued.codeChallenge != challenge {
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	idToken, err := i.SignIDToken(issued.nonce)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+// SignIDToken returns an ID token for the configured user
+func (i *Issuer) SignIDToken(nonce string) (string, error) {
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            i.URL,
+		"sub":            i.User.Subject,
+		"aud":            i.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          nonce,
+		"email":          i.User.Email,
+		"email_verified": i.User.EmailVerified,
+		"name":           i.User.Name,
+	}
+	if i.User.Groups != nil {
+		claims["groups"] = i.User.Groups
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = i.keyID
+	return token.SignedString(i.key)
+}
+
+func (i *Issuer) jwks(w http.ResponseWriter) {
+	pub := i.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": i.keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func (i *Issuer) allowedRedirect(uri string) bool {
+	for _, allowed := range i.RedirectURIs {
+		if uri == allowed {
+			return true
+		}
+	}
+	return len(i.RedirectURIs) == 0 && uri != ""
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	_, _ = rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
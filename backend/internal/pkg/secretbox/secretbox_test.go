@@ -0,0 +1,32 @@
+package secretbox
+
+import "testing"
+
+// TestSealOpen tests the round trip and that another key cannot open a value
+func TestSealOpen(t *testing.T) {
+	box, err := New("first-key")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	sealed, err := box.Seal("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if again, _ := box.Seal("JBSWY3DPEHPK3PXP"); again == sealed {
+		t.Error("Seal() is not randomized")
+	}
+
+	opened, err := box.Open(sealed)
+	if err != nil || opened != "JBSWY3DPEHPK3PXP" {
+		t.Errorf("Open() = %q, %v", opened, err)
+	}
+
+	other, _ := New("second-key")
+	if _, err := other.Open(sealed); err == nil {
+		t.Error("Open() with another key succeeded")
+	}
+	if _, err := New(""); err == nil {
+		t.Error("New() accepted an empty key")
+	}
+}
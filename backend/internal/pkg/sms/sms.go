@@ -0,0 +1,139 @@
+package sms
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SMS drivers
+const (
+	DriverLog    = "log"    // Writes a summary of each message to the server log
+	DriverFile   = "file"   // Writes each message as a .txt file, for local development and tests
+	DriverTwilio = "twilio" // Sends through the Twilio Messages API
+)
+
+// Message is a plain text SMS
+type Message struct {
+	To   string
+	Text string
+}
+
+// Sender sends text messages
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Config selects and configures the SMS driver
+type Config struct {
+	Driver           string
+	From             string
+	FileDir          string
+	TwilioAccountSID string
+	TwilioAuthToken  string
+}
+
+// New returns the sender of the configured driver
+func New(cfg Config) (Sender, error) {
+	switch cfg.Driver {
+	case "", DriverLog:
+		return &LogSender{}, nil
+	case DriverFile:
+		if cfg.FileDir == "" {
+			return nil, fmt.Errorf("sms: SMS_FILE_DIR is required for the file driver")
+		}
+		return &FileSender{Dir: cfg.FileDir, From: cfg.From}, nil
+	case DriverTwilio:
+		if cfg.TwilioAccountSID == "" || cfg.TwilioAuthToken == "" || cfg.From == "" {
+			return nil, fmt.Errorf("sms: TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_PHONE_NUMBER are required for the twilio driver")
+		}
+		return &TwilioSender{
+			AccountSID: cfg.TwilioAccountSID,
+			AuthToken:  cfg.TwilioAuthToken,
+			From:       cfg.From,
+			Client:     &http.Client{Timeout: 10 * time.Second},
+		}, nil
+	}
+	return nil, fmt.Errorf("sms: unknown driver %q", cfg.Driver)
+}
+
+// LogSender logs messages instead of sending them. The text is not logged because it
+// may hold an invitation link.
+type LogSender struct{}
+
+func (s *LogSender) Send(ctx context.Context, msg Message) error {
+	log.Printf("[sms] to=%s length=%d (log driver, not sent)", msg.To, len(msg.Text))
+	return nil
+}
+
+// FileSender writes each message to Dir as a text file
+type FileSender struct {
+	Dir  string
+	From string
+}
+
+func (s *FileSender) Send(ctx context.Context, msg Message) error {
+	if err := os.MkdirAll(s.Dir, 0o755); err != nil {
+		return fmt.Errorf("sms: %w", err)
+	}
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return fmt.Errorf("sms: %w", err)
+	}
+	name := fmt.Sprintf("%s-%s.txt", time.Now().UTC().Format("20060102T150405.000000000"), hex.EncodeToString(suffix))
+	body := fmt.Sprintf("From: %s\nTo: %s\n\n%s\n", s.From, oneLine(msg.To), msg.Text)
+	if err := os.WriteFile(filepath.Join(s.Dir, name), []byte(body), 0o644); err != nil {
+		return fmt.Errorf("sms: %w", err)
+	}
+	return nil
+}
+
+// TwilioSender sends messages with the Twilio Messages API
+type TwilioSender struct {
+	AccountSID string
+	AuthToken  string
+	From       string
+	Client     *http.Client
+	// BaseURL overrides the API endpoint, for tests
+	BaseURL string
+}
+
+func (s *TwilioSender) Send(ctx context.Context, msg Message) error {
+	base := s.BaseURL
+	if base == "" {
+		base = "https://api.twilio.com"
+	}
+	endpoint := fmt.Sprintf("%s/2010-04-01/Accounts/%s/Messages.json", strings.TrimRight(base, "/"), url.PathEscape(s.AccountSID))
+	form := url.Values{"To": {oneLine(msg.To)}, "From": {s.From}, "Body": {msg.Text}}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("sms: %w", err)
+	}
+	req.SetBasicAuth(s.AccountSID, s.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return fmt.Errorf("sms: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sms: twilio returned %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return nil
+}
+
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", "", "\n", "").Replace(s)
+}
@@ -0,0 +1,82 @@
+package sms
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestFileSender tests that the file driver writes one file per message
+func TestFileSender(t *testing.T) {
+	dir := t.TempDir()
+	s, err := New(Config{Driver: DriverFile, FileDir: dir, From: "POS"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if err := s.Send(context.Background(), Message{To: "+15550100\nBcc", Text: "Join your team"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.txt"))
+	if len(files) != 1 {
+		t.Fatalf("wrote %d files, want 1", len(files))
+	}
+	body, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(body), "To: +15550100Bcc\n") {
+		t.Errorf("recipient was not kept on one line:\n%s", body)
+	}
+	if !strings.Contains(string(body), "Join your team") {
+		t.Errorf("text missing:\n%s", body)
+	}
+}
+
+// TestTwilioSender tests the request sent to the Messages API
+func TestTwilioSender(t *testing.T) {
+	var gotPath, gotUser, gotTo, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		gotUser, _, _ = r.BasicAuth()
+		r.ParseForm()
+		gotTo, gotBody = r.PostForm.Get("To"), r.PostForm.Get("Body")
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	s := &TwilioSender{AccountSID: "AC123", AuthToken: "secret", From: "+15550199", Client: server.Client(), BaseURL: server.URL}
+	if err := s.Send(context.Background(), Message{To: "+15550100", Text: "hello"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if gotPath != "/2010-04-01/Accounts/AC123/Messages.json" || gotUser != "AC123" || gotTo != "+15550100" || gotBody != "hello" {
+		t.Errorf("request = %s user=%s to=%s body=%s", gotPath, gotUser, gotTo, gotBody)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"invalid number"}`, http.StatusBadRequest)
+	}))
+	defer failing.Close()
+	s.BaseURL, s.Client = failing.URL, failing.Client()
+	if err := s.Send(context.Background(), Message{To: "x", Text: "hello"}); err == nil {
+		t.Error("Send() expected an error for a rejected message")
+	}
+}
+
+// TestNewUnknownDriver tests that misconfigured drivers are rejected
+func TestNewUnknownDriver(t *testing.T) {
+	if _, err := New(Config{Driver: "pager"}); err == nil {
+		t.Error("New() expected an error for an unknown driver")
+	}
+	if _, err := New(Config{Driver: DriverTwilio, From: "+15550199"}); err == nil {
+		t.Error("New() expected an error without Twilio credentials")
+	}
+	if _, err := New(Config{Driver: DriverFile}); err == nil {
+		t.Error("New() expected an error without SMS_FILE_DIR")
+	}
+}
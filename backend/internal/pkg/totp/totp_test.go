@@ -0,0 +1,87 @@
+package totp
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 test key of RFC 6238 appendix B
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+// TestCodeAt tests the RFC 6238 test vectors, truncated to six digits
+func TestCodeAt(t *testing.T) {
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		got, err := CodeAt(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil {
+			t.Fatalf("CodeAt() error = %v", err)
+		}
+		if got != tt.want {
+			t.Errorf("CodeAt(%d) = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+// TestValidate tests the accepted clock drift and the returned step
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111109, 0)
+
+	step, ok := Validate(rfcSecret, "081804", now)
+	if !ok || step != Step(now) {
+		t.Errorf("Validate() = %d, %v, want %d, true", step, ok, Step(now))
+	}
+
+	previous, _ := CodeAt(rfcSecret, Step(now)-1)
+	if _, ok := Validate(rfcSecret, previous, now); !ok {
+		t.Error("Validate() rejected the code of the previous step")
+	}
+
+	tooOld, _ := CodeAt(rfcSecret, Step(now)-2)
+	if _, ok := Validate(rfcSecret, tooOld, now); ok {
+		t.Error("Validate() accepted a code two steps old")
+	}
+
+	for _, code := range []string{"", "12345", "abcdef", "0818045"} {
+		if _, ok := Validate(rfcSecret, code, now); ok {
+			t.Errorf("Validate(%q) accepted an invalid code", code)
+		}
+	}
+}
+
+// TestGenerateSecret tests that secrets decode and differ
+func TestGenerateSecret(t *testing.T) {
+	a, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	b, _ := GenerateSecret()
+	if a == b {
+		t.Error("GenerateSecret() returned the same secret twice")
+	}
+	if _, err := CodeAt(a, 1); err != nil {
+		t.Errorf("generated secret is not usable: %v", err)
+	}
+}
+
+// TestProvisioningURI tests the label and parameters read by authenticator apps
+func TestProvisioningURI(t *testing.T) {
+	uri := ProvisioningURI("Restaurant POS", "owner@example.com", "JBSWY3DPEHPK3PXP")
+	if !strings.HasPrefix(uri, "otpauth://totp/Restaurant%20POS:owner@example.com?") {
+		t.Errorf("unexpected label in %s", uri)
+	}
+	for _, part := range []string{"secret=JBSWY3DPEHPK3PXP", "issuer=Restaurant+POS", "digits=6", "period=30"} {
+		if !strings.Contains(uri, part) {
+			t.Errorf("%s does not contain %s", uri, part)
+		}
+	}
+}
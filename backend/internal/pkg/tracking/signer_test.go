@@ -0,0 +1,48 @@
+package tracking
+
+import "testing"
+
+// TestSignVerify tests that a token only verifies the order it was issued for
+func TestSignVerify(t *testing.T) {
+	signer := NewSigner("first-secret")
+	token := signer.Sign(7, "ORD-1001")
+
+	tests := []struct {
+		name         string
+		signer       *Signer
+		restaurantID int64
+		orderNumber  string
+		token        string
+		want         bool
+	}{
+		{"round trip", signer, 7, "ORD-1001", token, true},
+		{"wrong restaurant", signer, 8, "ORD-1001", token, false},
+		{"wrong order number", signer, 7, "ORD-1002", token, false},
+		{"tampered token", signer, 7, "ORD-1001", tamper(token), false},
+		{"different secret", NewSigner("second-secret"), 7, "ORD-1001", token, false},
+		{"empty token", signer, 7, "ORD-1001", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signer.Verify(tt.restaurantID, tt.orderNumber, tt.token); got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if again := signer.Sign(7, "ORD-1001"); again != token {
+		t.Errorf("Sign() is not deterministic: %q != %q", again, token)
+	}
+}
+
+// tamper flips the first character of a token
+func tamper(token string) string {
+	b := []byte(token)
+	if b[0] == 'A' {
+		b[0] = 'B'
+	} else {
+		b[0] = 'A'
+	}
+	return string(b)
+}
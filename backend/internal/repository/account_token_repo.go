@@ -0,0 +1,181 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"pos-saas/internal/domain"
+)
+
+// AccountTokenRepository stores password reset, email verification and email change tokens
+type AccountTokenRepository struct {
+	db *sql.DB
+}
+
+// NewAccountTokenRepository creates a new account token repository
+func NewAccountTokenRepository(db *sql.DB) *AccountTokenRepository {
+	return &AccountTokenRepository{db: db}
+}
+
+// CreateToken stores a token and spends the user's earlier unused tokens of the same
+// purpose, so only the most recent link works
+func (r *AccountTokenRepository) CreateToken(ctx context.Context, token *domain.AccountToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE account_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND purpose = $2 AND used_at IS NULL
+	`, token.UserID, token.Purpose); err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO account_tokens (user_id, purpose, token_hash, expires_at, requested_ip)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+		RETURNING id, created_at
+	`, token.UserID, token.Purpose, token.TokenHash, token.ExpiresAt, token.RequestedIP).Scan(&token.ID, &token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create account token: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// IssuedSince reports whether a token of the purpose was issued to the user within the window
+func (r *AccountTokenRepository) IssuedSince(ctx context.Context, userID int, purpose string, window time.Duration) (bool, error) {
+	var issued bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM account_tokens
+			WHERE user_id = $1 AND purpose = $2 AND created_at > $3
+		)
+	`, userID, purpose, time.Now().Add(-window)).Scan(&issued)
+	return issued, err
+}
+
+// GetValidToken returns an unused, unexpired token, nil when there is none
+func (r *AccountTokenRepository) GetValidToken(ctx context.Context, purpose, tokenHash string) (*domain.AccountToken, error) {
+	var t domain.AccountToken
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, purpose, token_hash, expires_at, used_at, COALESCE(requested_ip, ''), created_at
+		FROM account_tokens
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`, tokenHash, purpose).Scan(&t.ID, &t.UserID, &t.Purpose, &t.TokenHash, &t.ExpiresAt, &t.UsedAt, &t.RequestedIP, &t.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// ConsumeToken marks a token as used. Returns domain.ErrInvalidAccountToken when it was
+// already used or has expired in the meantime.
+func (r *AccountTokenRepository) ConsumeToken(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE account_tokens SET used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrInvalidAccountToken
+	}
+	return nil
+}
+
+// CreateEmailChange stores a pending email change and drops the user's earlier pending ones
+func (r *AccountTokenRepository) CreateEmailChange(ctx context.Context, userID int, newEmail, tokenHash string) (*domain.EmailChangeRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `
+		DELETE FROM email_change_requests WHERE user_id = $1 AND verified = false
+	`, userID); err != nil {
+		return nil, err
+	}
+
+	req := domain.EmailChangeRequest{UserID: int64(userID), NewEmail: newEmail}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO email_change_requests (user_id, new_email, verification_token, expires_at)
+		VALUES ($1, $2, $3, CURRENT_TIMESTAMP + $4 * INTERVAL '1 second')
+		RETURNING id, requested_at, expires_at
+	`, userID, newEmail, tokenHash, int64(domain.EmailChangeTTL.Seconds())).Scan(&req.ID, &req.RequestedAt, &req.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create email change request: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// ApplyEmailChange moves the user to the new address of a pending email change and marks
+// the address verified. Returns domain.ErrInvalidAccountToken for unknown, used or expired
+// tokens and domain.ErrEmailTaken when the address was taken in the meantime.
+func (r *AccountTokenRepository) ApplyEmailChange(ctx context.Context, tokenHash string) (*domain.EmailChangeRequest, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var req domain.EmailChangeRequest
+	err = tx.QueryRowContext(ctx, `
+		SELECT id, user_id, new_email, requested_at, expires_at
+		FROM email_change_requests
+		WHERE verification_token = $1 AND verified = false AND expires_at > CURRENT_TIMESTAMP
+		FOR UPDATE
+	`, tokenHash).Scan(&req.ID, &req.UserID, &req.NewEmail, &req.RequestedAt, &req.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvalidAccountToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE users SET email = $1, email_verified_at = CURRENT_TIMESTAMP, updated_at = NOW()
+		WHERE id = $2
+	`, req.NewEmail, req.UserID)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return nil, domain.ErrEmailTaken
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update email: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE email_change_requests SET verified = true, verified_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING verified_at
+	`, req.ID).Scan(&req.VerifiedAt)
+	if err != nil {
+		return nil, err
+	}
+	req.Verified = true
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
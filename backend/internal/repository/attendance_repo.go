@@ -242,25 +242,30 @@ func (r *AttendanceRepository) ClockOut(tenantID, restaurantID, employeeID int)
 	return err
 }
 
-// GetTodayAttendance retrieves today's attendance for an employee
-func (r *AttendanceRepository) GetTodayAttendance(tenantID, restaurantID, employeeID int) (*domain.Attendance, error) {
-	query := `
-		SELECT
-			id, tenant_id, restaurant_id, employee_id, attendance_date,
-			clock_in, clock_out, total_hours, status, created_at
-		FROM attendance
-		WHERE tenant_id = $1 AND restaurant_id = $2 AND employee_id = $3
-		  AND attendance_date = CURRENT_DATE
-	`
-
+const timeClockAttendanceColumns = `
+	id, tenant_id, restaurant_id, employee_id, attendance_date,
+	clock_in, clock_out, scheduled_clock_in, scheduled_clock_out,
+	break_start, break_end, total_break_minutes, total_hours,
+	regular_hours, overtime_hours, status, is_late, late_by_minutes,
+	is_early_departure, early_departure_minutes, is_overtime,
+	overtime_approved, requires_approval, created_at, updated_at
+`
+
+// scanTimeClockAttendance scans a row of timeClockAttendanceColumns; returns nil when there is no row
+func scanTimeClockAttendance(row *sql.Row) (*domain.Attendance, error) {
 	var att domain.Attendance
-	var clockIn, clockOut sql.NullTime
+	var clockIn, clockOut, breakStart, breakEnd sql.NullTime
+	var scheduledClockIn, scheduledClockOut sql.NullString
 	var totalHours sql.NullFloat64
 
-	err := r.db.QueryRow(query, tenantID, restaurantID, employeeID).Scan(
+	err := row.Scan(
 		&att.ID, &att.TenantID, &att.RestaurantID, &att.EmployeeID,
-		&att.AttendanceDate, &clockIn, &clockOut, &totalHours,
-		&att.Status, &att.CreatedAt,
+		&att.AttendanceDate, &clockIn, &clockOut, &scheduledClockIn,
+		&scheduledClockOut, &breakStart, &breakEnd, &att.TotalBreakMinutes,
+		&totalHours, &att.RegularHours, &att.OvertimeHours, &att.Status,
+		&att.IsLate, &att.LateByMinutes, &att.IsEarlyDeparture,
+		&att.EarlyDepartureMinutes, &att.IsOvertime, &att.OvertimeApproved,
+		&att.RequiresApproval, &att.CreatedAt, &att.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -276,9 +281,275 @@ func (r *AttendanceRepository) GetTodayAttendance(tenantID, restaurantID, employ
 	if clockOut.Valid {
 		att.ClockOut = &clockOut.Time
 	}
+	if breakStart.Valid {
+		att.BreakStart = &breakStart.Time
+	}
+	if breakEnd.Valid {
+		att.BreakEnd = &breakEnd.Time
+	}
+	if scheduledClockIn.Valid {
+		att.ScheduledClockIn = &scheduledClockIn.String
+	}
+	if scheduledClockOut.Valid {
+		att.ScheduledClockOut = &scheduledClockOut.String
+	}
 	if totalHours.Valid {
 		att.TotalHours = &totalHours.Float64
 	}
 
 	return &att, nil
 }
+
+// GetTodayAttendance retrieves today's attendance for an employee
+func (r *AttendanceRepository) GetTodayAttendance(tenantID, restaurantID, employeeID int) (*domain.Attendance, error) {
+	query := `
+		SELECT ` + timeClockAttendanceColumns + `
+		FROM attendance
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND employee_id = $3
+		  AND attendance_date = CURRENT_DATE
+	`
+
+	return scanTimeClockAttendance(r.db.QueryRow(query, tenantID, restaurantID, employeeID))
+}
+
+// GetOpenAttendance retrieves the attendance an employee is clocked in on and has not clocked out of.
+// Yesterday's record is included so overnight shifts can clock out after midnight.
+func (r *AttendanceRepository) GetOpenAttendance(tenantID, restaurantID, employeeID int) (*domain.Attendance, error) {
+	query := `
+		SELECT ` + timeClockAttendanceColumns + `
+		FROM attendance
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND employee_id = $3
+		  AND attendance_date >= CURRENT_DATE - 1
+		  AND clock_in IS NOT NULL AND clock_out IS NULL
+		ORDER BY attendance_date DESC
+		LIMIT 1
+	`
+
+	return scanTimeClockAttendance(r.db.QueryRow(query, tenantID, restaurantID, employeeID))
+}
+
+// RecordClockIn creates today's attendance with the clock-in time, location, IP and device.
+// A record without a clock-in (e.g. created by a manager) is filled in; returns
+// ErrAlreadyClockedIn when the employee has already clocked in today.
+func (r *AttendanceRepository) RecordClockIn(attendance *domain.Attendance) (int, error) {
+	query := `
+		INSERT INTO attendance (
+			tenant_id, restaurant_id, employee_id, attendance_date,
+			clock_in, clock_in_location, clock_in_ip, clock_in_device, status, notes
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), 'present', NULLIF($9, '')
+		)
+		ON CONFLICT (employee_id, attendance_date)
+		DO UPDATE SET clock_in = EXCLUDED.clock_in, clock_in_location = EXCLUDED.clock_in_location,
+		    clock_in_ip = EXCLUDED.clock_in_ip, clock_in_device = EXCLUDED.clock_in_device,
+		    status = 'present', notes = COALESCE(EXCLUDED.notes, attendance.notes)
+		WHERE attendance.clock_in IS NULL
+		RETURNING id
+	`
+
+	var location interface{}
+	if len(attendance.ClockInLocation) > 0 {
+		location = []byte(attendance.ClockInLocation)
+	}
+
+	var id int
+	err := r.db.QueryRow(
+		query,
+		attendance.TenantID, attendance.RestaurantID, attendance.EmployeeID,
+		attendance.AttendanceDate.Format("2006-01-02"), attendance.ClockIn, location,
+		attendance.ClockInIP, attendance.ClockInDevice, attendance.Notes,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, domain.ErrAlreadyClockedIn
+	}
+	return id, err
+}
+
+// UpdateTimeClock stores breaks, clock-out details and the calculated hours of an attendance record
+func (r *AttendanceRepository) UpdateTimeClock(attendance *domain.Attendance) error {
+	query := `
+		UPDATE attendance
+		SET break_start = $1, break_end = $2, total_break_minutes = $3,
+		    clock_out = $4, clock_out_location = $5, clock_out_ip = NULLIF($6, ''),
+		    clock_out_device = NULLIF($7, ''), total_hours = $8, regular_hours = $9,
+		    overtime_hours = $10, is_overtime = $11, overtime_approved = $12,
+		    requires_approval = $13, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $14 AND tenant_id = $15 AND restaurant_id = $16
+	`
+
+	var location interface{}
+	if len(attendance.ClockOutLocation) > 0 {
+		location = []byte(attendance.ClockOutLocation)
+	}
+
+	_, err := r.db.Exec(
+		query,
+		attendance.BreakStart, attendance.BreakEnd, attendance.TotalBreakMinutes,
+		attendance.ClockOut, location, attendance.ClockOutIP,
+		attendance.ClockOutDevice, attendance.TotalHours, attendance.RegularHours,
+		attendance.OvertimeHours, attendance.IsOvertime, attendance.OvertimeApproved,
+		attendance.RequiresApproval, attendance.ID, attendance.TenantID, attendance.RestaurantID,
+	)
+	return err
+}
+
+// ListPendingOvertime retrieves attendance with overtime awaiting a manager's approval
+func (r *AttendanceRepository) ListPendingOvertime(tenantID, restaurantID int) ([]domain.Attendance, error) {
+	query := `
+		SELECT
+			id, tenant_id, restaurant_id, employee_id, attendance_date,
+			clock_in, clock_out, total_break_minutes, total_hours,
+			regular_hours, overtime_hours, status, created_at, updated_at
+		FROM attendance
+		WHERE tenant_id = $1 AND restaurant_id = $2
+		  AND is_overtime = true AND overtime_approved = false AND requires_approval = true
+		ORDER BY attendance_date ASC, employee_id ASC
+	`
+
+	rows, err := r.db.Query(query, tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attendances := []domain.Attendance{}
+	for rows.Next() {
+		var att domain.Attendance
+		var clockIn, clockOut sql.NullTime
+		var totalHours sql.NullFloat64
+
+		if err := rows.Scan(
+			&att.ID, &att.TenantID, &att.RestaurantID, &att.EmployeeID,
+			&att.AttendanceDate, &clockIn, &clockOut, &att.TotalBreakMinutes,
+			&totalHours, &att.RegularHours, &att.OvertimeHours, &att.Status,
+			&att.CreatedAt, &att.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+
+		if clockIn.Valid {
+			att.ClockIn = &clockIn.Time
+		}
+		if clockOut.Valid {
+			att.ClockOut = &clockOut.Time
+		}
+		if totalHours.Valid {
+			att.TotalHours = &totalHours.Float64
+		}
+		att.IsOvertime, att.RequiresApproval = true, true
+
+		attendances = append(attendances, att)
+	}
+
+	return attendances, rows.Err()
+}
+
+// ApproveOvertime approves the overtime of an attendance record.
+// Returns ErrOvertimeNotPending when there is no overtime awaiting approval.
+func (r *AttendanceRepository) ApproveOvertime(tenantID, restaurantID, id, approvedBy int) error {
+	query := `
+		UPDATE attendance
+		SET overtime_approved = true, overtime_approved_by = $1, overtime_approved_at = CURRENT_TIMESTAMP,
+		    is_approved = true, approved_by = $1, approved_at = CURRENT_TIMESTAMP,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND tenant_id = $3 AND restaurant_id = $4
+		  AND is_overtime = true AND overtime_approved = false
+	`
+
+	result, err := r.db.Exec(query, approvedBy, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrOvertimeNotPending
+	}
+	return nil
+}
+
+// GetTimeClockSettings retrieves a restaurant's time clock settings, or the defaults when none are saved
+func (r *AttendanceRepository) GetTimeClockSettings(tenantID, restaurantID int) (*domain.TimeClockSettings, error) {
+	query := `
+		SELECT tenant_id, restaurant_id, require_geofence, geofence_radius_meters, kiosk_enabled,
+		       regular_hours_per_day, overtime_requires_approval, updated_at, updated_by
+		FROM time_clock_settings
+		WHERE tenant_id = $1 AND restaurant_id = $2
+	`
+
+	var s domain.TimeClockSettings
+	var updatedBy sql.NullInt64
+	err := r.db.QueryRow(query, tenantID, restaurantID).Scan(
+		&s.TenantID, &s.RestaurantID, &s.RequireGeofence, &s.GeofenceRadiusMeters, &s.KioskEnabled,
+		&s.RegularHoursPerDay, &s.OvertimeRequiresApproval, &s.UpdatedAt, &updatedBy,
+	)
+	if err == sql.ErrNoRows {
+		return domain.DefaultTimeClockSettings(tenantID, restaurantID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if updatedBy.Valid {
+		id := int(updatedBy.Int64)
+		s.UpdatedBy = &id
+	}
+	return &s, nil
+}
+
+// SaveTimeClockSettings creates or replaces a restaurant's time clock settings
+func (r *AttendanceRepository) SaveTimeClockSettings(s *domain.TimeClockSettings) error {
+	query := `
+		INSERT INTO time_clock_settings (
+			restaurant_id, tenant_id, require_geofence, geofence_radius_meters, kiosk_enabled,
+			regular_hours_per_day, overtime_requires_approval, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (restaurant_id)
+		DO UPDATE SET require_geofence = EXCLUDED.require_geofence,
+		    geofence_radius_meters = EXCLUDED.geofence_radius_meters,
+		    kiosk_enabled = EXCLUDED.kiosk_enabled,
+		    regular_hours_per_day = EXCLUDED.regular_hours_per_day,
+		    overtime_requires_approval = EXCLUDED.overtime_requires_approval,
+		    updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	return r.db.QueryRow(
+		query,
+		s.RestaurantID, s.TenantID, s.RequireGeofence, s.GeofenceRadiusMeters, s.KioskEnabled,
+		s.RegularHoursPerDay, s.OvertimeRequiresApproval, s.UpdatedBy,
+	).Scan(&s.UpdatedAt)
+}
+
+// GetRestaurantLocation retrieves the restaurant coordinates the time clock geofence is centred on
+func (r *AttendanceRepository) GetRestaurantLocation(tenantID, restaurantID int) (*float64, *float64, error) {
+	var lat, lon sql.NullFloat64
+	err := r.db.QueryRow(`
+		SELECT latitude, longitude FROM restaurants WHERE id = $1 AND tenant_id = $2
+	`, restaurantID, tenantID).Scan(&lat, &lon)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !lat.Valid || !lon.Valid {
+		return nil, nil, nil
+	}
+	return &lat.Float64, &lon.Float64, nil
+}
+
+// UpdateSchedule stores the scheduled times, lateness and early departure derived from a shift
+func (r *AttendanceRepository) UpdateSchedule(attendance *domain.Attendance) error {
+	query := `
+		UPDATE attendance
+		SET scheduled_clock_in = $1, scheduled_clock_out = $2, status = $3,
+		    is_late = $4, late_by_minutes = $5,
+		    is_early_departure = $6, early_departure_minutes = $7,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8 AND tenant_id = $9 AND restaurant_id = $10
+	`
+
+	_, err := r.db.Exec(
+		query,
+		attendance.ScheduledClockIn, attendance.ScheduledClockOut, attendance.Status,
+		attendance.IsLate, attendance.LateByMinutes,
+		attendance.IsEarlyDeparture, attendance.EarlyDepartureMinutes,
+		attendance.ID, attendance.TenantID, attendance.RestaurantID,
+	)
+	return err
+}
@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 	"pos-saas/internal/domain"
 )
@@ -182,7 +183,7 @@ func (r *AuthRepository) CreateTenantWithUser(req *domain.RegisterRequest) (*dom
 	_, err = tx.Exec(`
 		INSERT INTO user_roles (tenant_id, user_id, role_id, assigned_by)
 		VALUES ($1, $2, $3, $4)
-		ON CONFLICT (tenant_id, user_id, role_id) DO NOTHING
+		ON CONFLICT (tenant_id, user_id, role_id) WHERE restaurant_id IS NULL DO NOTHING
 	`, tenantID, user.ID, adminRoleID, user.ID)
 
 	if err != nil {
@@ -237,7 +238,6 @@ func (r *AuthRepository) FindUserByEmail(email string) (*domain.User, error) {
 // This is used for multi-tenant login detection
 func (r *AuthRepository) FindUserByEmailAllTenants(email string) ([]domain.User, error) {
 	fmt.Printf("=== REPOSITORY: FindUserByEmailAllTenants ===\n")
-	fmt.Printf("Searching for all tenants with email: %s\n", email)
 
 	// Handle stub mode (no database)
 	if r.db == nil {
@@ -261,7 +261,7 @@ func (r *AuthRepository) FindUserByEmailAllTenants(email string) ([]domain.User,
 	}
 
 	rows, err := r.db.Query(`
-		SELECT id, tenant_id, restaurant_id, email, password_hash, name, phone, avatar_url, role, status, created_at, updated_at
+		SELECT id, tenant_id, restaurant_id, email, password_hash, name, phone, avatar_url, role, status, email_verified_at, created_at, updated_at
 		FROM users
 		WHERE email = $1 AND status = 'active'
 		ORDER BY tenant_id ASC
@@ -279,7 +279,7 @@ func (r *AuthRepository) FindUserByEmailAllTenants(email string) ([]domain.User,
 		var avatarURL sql.NullString
 		err := rows.Scan(
 			&user.ID, &user.TenantID, &user.RestaurantID, &user.Email, &user.PasswordHash,
-			&user.Name, &user.Phone, &avatarURL, &user.Role, &user.Status,
+			&user.Name, &user.Phone, &avatarURL, &user.Role, &user.Status, &user.EmailVerifiedAt,
 			&user.CreatedAt, &user.UpdatedAt,
 		)
 		if err != nil {
@@ -300,7 +300,6 @@ func (r *AuthRepository) FindUserByEmailAllTenants(email string) ([]domain.User,
 		return nil, err
 	}
 
-	fmt.Printf("Found %d users with email %s across tenants\n", len(users), email)
 	return users, nil
 }
 
@@ -315,6 +314,76 @@ func (r *AuthRepository) UpdateLastLogin(userID int) error {
 	return err
 }
 
+// ListLoginTenants returns the tenants an email has active accounts in, with the tenant
+// names and the roles assigned to each account in user_roles
+func (r *AuthRepository) ListLoginTenants(email string) ([]domain.TenantInfo, error) {
+	// In stub mode, there is a single mock tenant
+	if r.db == nil {
+		return []domain.TenantInfo{{TenantID: 1, TenantName: "Demo Restaurant", UserID: 7, Roles: []string{"owner"}}}, nil
+	}
+
+	rows, err := r.db.Query(`
+		SELECT u.id, u.tenant_id, t.name, u.restaurant_id, u.role,
+		       ARRAY(
+		           SELECT r.role_name FROM roles r
+		           WHERE r.tenant_id = u.tenant_id AND r.is_active = true
+		             AND EXISTS (SELECT 1 FROM user_roles ur WHERE ur.role_id = r.id AND ur.user_id = u.id AND ur.tenant_id = u.tenant_id)
+		           ORDER BY r.is_system_role DESC, r.role_name
+		       )
+		FROM users u
+		JOIN tenants t ON t.id = u.tenant_id
+		WHERE u.email = $1 AND u.status = 'active'
+		ORDER BY u.tenant_id ASC
+	`, email)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tenants []domain.TenantInfo
+	for rows.Next() {
+		var info domain.TenantInfo
+		var userRole string
+		var roles []string
+		if err := rows.Scan(&info.UserID, &info.TenantID, &info.TenantName, &info.DefaultRestaurantID,
+			&userRole, pq.Array(&roles)); err != nil {
+			return nil, err
+		}
+		// Accounts without role assignments fall back to their base role
+		if len(roles) == 0 {
+			roles = []string{userRole}
+		}
+		info.Roles = roles
+		tenants = append(tenants, info)
+	}
+	return tenants, rows.Err()
+}
+
+// UseSelectionToken records a tenant selection token as spent. Returns false when it was
+// already used.
+func (r *AuthRepository) UseSelectionToken(tokenID string, expiresAt time.Time) (bool, error) {
+	// In stub mode, tokens are not tracked
+	if r.db == nil {
+		return true, nil
+	}
+
+	// Spent tokens are only needed until they expire
+	if _, err := r.db.Exec(`DELETE FROM used_selection_tokens WHERE expires_at < CURRENT_TIMESTAMP`); err != nil {
+		return false, err
+	}
+
+	result, err := r.db.Exec(`
+		INSERT INTO used_selection_tokens (token_id, expires_at)
+		VALUES ($1, $2)
+		ON CONFLICT (token_id) DO NOTHING
+	`, tokenID, expiresAt)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
 // CheckEmailExists checks if email already exists
 func (r *AuthRepository) CheckEmailExists(email string) (bool, error) {
 	var exists bool
@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"pos-saas/internal/domain"
+)
+
+// DiningRepository handles dine-in tables and tabs data operations
+type DiningRepository struct {
+	db *sql.DB
+}
+
+// NewDiningRepository creates new dining repository
+func NewDiningRepository(db *sql.DB) *DiningRepository {
+	return &DiningRepository{db: db}
+}
+
+const diningTableColumns = `
+	id, tenant_id, restaurant_id, table_number, COALESCE(label, ''), COALESCE(area, ''),
+	COALESCE(seats, 0), qr_token, is_active, created_at, updated_at
+`
+
+const tableTabColumns = `
+	id, tenant_id, restaurant_id, table_id, tab_number, status, COALESCE(guest_count, 0),
+	merged_into_tab_id, opened_at, closed_at, closed_by,
+	COALESCE(subtotal, 0), COALESCE(tax_amount, 0), COALESCE(discount_amount, 0),
+	COALESCE(service_charge, 0), COALESCE(total_amount, 0),
+	COALESCE(payment_method, ''), COALESCE(notes, ''), created_at, updated_at
+`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanDiningTable(row rowScanner) (*domain.DiningTable, error) {
+	table := &domain.DiningTable{}
+	err := row.Scan(
+		&table.ID, &table.TenantID, &table.RestaurantID, &table.TableNumber, &table.Label, &table.Area,
+		&table.Seats, &table.QRToken, &table.IsActive, &table.CreatedAt, &table.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+func scanTableTab(row rowScanner) (*domain.TableTab, error) {
+	tab := &domain.TableTab{}
+	var mergedInto, closedBy sql.NullInt64
+	var closedAt sql.NullTime
+	err := row.Scan(
+		&tab.ID, &tab.TenantID, &tab.RestaurantID, &tab.TableID, &tab.TabNumber, &tab.Status, &tab.GuestCount,
+		&mergedInto, &tab.OpenedAt, &closedAt, &closedBy,
+		&tab.Subtotal, &tab.TaxAmount, &tab.DiscountAmount,
+		&tab.ServiceCharge, &tab.TotalAmount,
+		&tab.PaymentMethod, &tab.Notes, &tab.CreatedAt, &tab.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if mergedInto.Valid {
+		tab.MergedIntoTabID = &mergedInto.Int64
+	}
+	if closedAt.Valid {
+		tab.ClosedAt = &closedAt.Time
+	}
+	if closedBy.Valid {
+		tab.ClosedBy = &closedBy.Int64
+	}
+	return tab, nil
+}
+
+// CreateTable inserts a new dining table
+func (r *DiningRepository) CreateTable(table *domain.DiningTable) (*domain.DiningTable, error) {
+	query := `
+		INSERT INTO dining_tables (tenant_id, restaurant_id, table_number, label, area, seats, qr_token, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query,
+		table.TenantID, table.RestaurantID, table.TableNumber, table.Label, table.Area,
+		table.Seats, table.QRToken, table.IsActive,
+	).Scan(&table.ID, &table.CreatedAt, &table.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create table: %w", err)
+	}
+	return table, nil
+}
+
+// ListTables returns all tables of a restaurant
+func (r *DiningRepository) ListTables(tenantID, restaurantID int64) ([]domain.DiningTable, error) {
+	query := `SELECT ` + diningTableColumns + `
+		FROM dining_tables
+		WHERE tenant_id = $1 AND restaurant_id = $2
+		ORDER BY table_number`
+
+	rows, err := r.db.Query(query, tenantID, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	tables := []domain.DiningTable{}
+	for rows.Next() {
+		table, err := scanDiningTable(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan table: %w", err)
+		}
+		tables = append(tables, *table)
+	}
+	return tables, rows.Err()
+}
+
+// GetTableByID retrieves a table scoped to a restaurant
+func (r *DiningRepository) GetTableByID(tenantID, restaurantID, tableID int64) (*domain.DiningTable, error) {
+	query := `SELECT ` + diningTableColumns + `
+		FROM dining_tables
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3`
+
+	table, err := scanDiningTable(r.db.QueryRow(query, tableID, tenantID, restaurantID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTableNotFound
+		}
+		return nil, fmt.Errorf("failed to get table: %w", err)
+	}
+	return table, nil
+}
+
+// GetTableByToken retrieves a table by its QR token.
+// The token is globally unique, so no tenant scope is required.
+func (r *DiningRepository) GetTableByToken(token string) (*domain.DiningTable, error) {
+	query := `SELECT ` + diningTableColumns + `
+		FROM dining_tables
+		WHERE qr_token = $1`
+
+	table, err := scanDiningTable(r.db.QueryRow(query, token))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTableNotFound
+		}
+		return nil, fmt.Errorf("failed to get table: %w", err)
+	}
+	return table, nil
+}
+
+// UpdateTable updates table details
+func (r *DiningRepository) UpdateTable(table *domain.DiningTable) error {
+	query := `
+		UPDATE dining_tables
+		SET label = $1, area = $2, seats = $3, is_active = $4, updated_at = NOW()
+		WHERE id = $5 AND tenant_id = $6 AND restaurant_id = $7
+	`
+
+	result, err := r.db.Exec(query, table.Label, table.Area, table.Seats, table.IsActive,
+		table.ID, table.TenantID, table.RestaurantID)
+	if err != nil {
+		return fmt.Errorf("failed to update table: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return domain.ErrTableNotFound
+	}
+	return nil
+}
+
+// UpdateTableToken replaces the QR token of a table, invalidating printed codes
+func (r *DiningRepository) UpdateTableToken(tenantID, restaurantID, tableID int64, token string) error {
+	query := `
+		UPDATE dining_tables
+		SET qr_token = $1, updated_at = NOW()
+		WHERE id = $2 AND tenant_id = $3 AND restaurant_id = $4
+	`
+
+	result, err := r.db.Exec(query, token, tableID, tenantID, restaurantID)
+	if err != nil {
+		return fmt.Errorf("failed to update table token: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return domain.ErrTableNotFound
+	}
+	return nil
+}
+
+// DeleteTable removes a table
+func (r *DiningRepository) DeleteTable(tenantID, restaurantID, tableID int64) error {
+	result, err := r.db.Exec(
+		`DELETE FROM dining_tables WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3`,
+		tableID, tenantID, restaurantID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete table: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return domain.ErrTableNotFound
+	}
+	return nil
+}
+
+// CreateTab opens a new tab on a table
+func (r *DiningRepository) CreateTab(tab *domain.TableTab) (*domain.TableTab, error) {
+	query := `
+		INSERT INTO table_tabs (tenant_id, restaurant_id, table_id, tab_number, status, guest_count)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, opened_at, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query,
+		tab.TenantID, tab.RestaurantID, tab.TableID, tab.TabNumber, domain.TabStatusOpen, tab.GuestCount,
+	).Scan(&tab.ID, &tab.OpenedAt, &tab.CreatedAt, &tab.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tab: %w", err)
+	}
+	tab.Status = domain.TabStatusOpen
+	return tab, nil
+}
+
+// GetTabByID retrieves a tab scoped to a restaurant
+func (r *DiningRepository) GetTabByID(tenantID, restaurantID, tabID int64) (*domain.TableTab, error) {
+	query := `SELECT ` + tableTabColumns + `
+		FROM table_tabs
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3`
+
+	tab, err := scanTableTab(r.db.QueryRow(query, tabID, tenantID, restaurantID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTabNotFound
+		}
+		return nil, fmt.Errorf("failed to get tab: %w", err)
+	}
+	return tab, nil
+}
+
+// GetOpenTabForTable returns the open tab of a table, or nil if none is open
+func (r *DiningRepository) GetOpenTabForTable(tableID int64) (*domain.TableTab, error) {
+	query := `SELECT ` + tableTabColumns + `
+		FROM table_tabs
+		WHERE table_id = $1 AND status = 'open'`
+
+	tab, err := scanTableTab(r.db.QueryRow(query, tableID))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get open tab: %w", err)
+	}
+	return tab, nil
+}
+
+// ListOpenTabs returns all open tabs of a restaurant
+func (r *DiningRepository) ListOpenTabs(tenantID, restaurantID int64) ([]domain.TableTab, error) {
+	query := `SELECT ` + tableTabColumns + `
+		FROM table_tabs
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND status = 'open'
+		ORDER BY opened_at`
+
+	rows, err := r.db.Query(query, tenantID, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tabs: %w", err)
+	}
+	defer rows.Close()
+
+	tabs := []domain.TableTab{}
+	for rows.Next() {
+		tab, err := scanTableTab(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tab: %w", err)
+		}
+		tabs = append(tabs, *tab)
+	}
+	return tabs, rows.Err()
+}
+
+// ListTabOrderIDs returns the IDs of all order rounds placed on a tab
+func (r *DiningRepository) ListTabOrderIDs(tenantID, tabID int64) ([]int64, error) {
+	rows, err := r.db.Query(
+		`SELECT id FROM orders WHERE tenant_id = $1 AND tab_id = $2 ORDER BY created_at`,
+		tenantID, tabID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tab orders: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan tab order: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// TransferTab moves an open tab and its orders to another table
+func (r *DiningRepository) TransferTab(tenantID, tabID, targetTableID int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE table_tabs SET table_id = $1, updated_at = NOW()
+		WHERE id = $2 AND tenant_id = $3 AND status = 'open'`,
+		targetTableID, tabID, tenantID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to transfer tab: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return domain.ErrTabNotOpen
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE orders SET table_id = $1, updated_at = NOW() WHERE tenant_id = $2 AND tab_id = $3`,
+		targetTableID, tenantID, tabID,
+	); err != nil {
+		return fmt.Errorf("failed to transfer tab orders: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// MergeTabs moves the orders of the source tabs onto the target tab and marks the sources as merged
+func (r *DiningRepository) MergeTabs(tenantID, targetTabID int64, sourceTabIDs []int64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var targetTableID int64
+	var guestCount int
+	err = tx.QueryRow(
+		`SELECT table_id, COALESCE(guest_count, 0) FROM table_tabs WHERE id = $1 AND tenant_id = $2 AND status = 'open' FOR UPDATE`,
+		targetTabID, tenantID,
+	).Scan(&targetTableID, &guestCount)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrTabNotOpen
+		}
+		return fmt.Errorf("failed to lock target tab: %w", err)
+	}
+
+	for _, sourceID := range sourceTabIDs {
+		var sourceGuests int
+		err := tx.QueryRow(`
+			UPDATE table_tabs
+			SET status = 'merged', merged_into_tab_id = $1, closed_at = NOW(), updated_at = NOW()
+			WHERE id = $2 AND tenant_id = $3 AND status = 'open'
+			RETURNING COALESCE(guest_count, 0)`,
+			targetTabID, sourceID, tenantID,
+		).Scan(&sourceGuests)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return fmt.Errorf("tab %d: %w", sourceID, domain.ErrTabNotOpen)
+			}
+			return fmt.Errorf("failed to merge tab %d: %w", sourceID, err)
+		}
+		guestCount += sourceGuests
+
+		if _, err := tx.Exec(
+			`UPDATE orders SET tab_id = $1, table_id = $2, updated_at = NOW() WHERE tenant_id = $3 AND tab_id = $4`,
+			targetTabID, targetTableID, tenantID, sourceID,
+		); err != nil {
+			return fmt.Errorf("failed to move orders of tab %d: %w", sourceID, err)
+		}
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE table_tabs SET guest_count = $1, updated_at = NOW() WHERE id = $2`,
+		guestCount, targetTabID,
+	); err != nil {
+		return fmt.Errorf("failed to update target tab: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// CloseTab closes an open tab and stores the final bill totals
+func (r *DiningRepository) CloseTab(tab *domain.TableTab) error {
+	query := `
+		UPDATE table_tabs
+		SET status = 'closed', closed_at = NOW(), closed_by = $1,
+			subtotal = $2, tax_amount = $3, discount_amount = $4, service_charge = $5, total_amount = $6,
+			payment_method = $7, notes = $8, updated_at = NOW()
+		WHERE id = $9 AND tenant_id = $10 AND status = 'open'
+		RETURNING closed_at
+	`
+
+	var closedAt sql.NullTime
+	err := r.db.QueryRow(query,
+		tab.ClosedBy, tab.Subtotal, tab.TaxAmount, tab.DiscountAmount, tab.ServiceCharge, tab.TotalAmount,
+		tab.PaymentMethod, tab.Notes, tab.ID, tab.TenantID,
+	).Scan(&closedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrTabNotOpen
+		}
+		return fmt.Errorf("failed to close tab: %w", err)
+	}
+
+	tab.Status = domain.TabStatusClosed
+	if closedAt.Valid {
+		tab.ClosedAt = &closedAt.Time
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"pos-saas/internal/domain"
+	"time"
+)
+
+// DispatchRepository handles driver dispatch offers
+type DispatchRepository struct {
+	db *sql.DB
+}
+
+// NewDispatchRepository creates new dispatch repository
+func NewDispatchRepository(db *sql.DB) *DispatchRepository {
+	return &DispatchRepository{db: db}
+}
+
+const dispatchOfferColumns = `
+	id, tenant_id, restaurant_id, order_id, driver_id, attempt, status,
+	COALESCE(score, 0), distance_km, COALESCE(is_manual, false), assigned_by,
+	offered_at, expires_at, responded_at, created_at
+`
+
+func scanDispatchOffer(row rowScanner) (*domain.DispatchOffer, error) {
+	offer := &domain.DispatchOffer{}
+	var distance sql.NullFloat64
+	var assignedBy sql.NullInt64
+	var respondedAt sql.NullTime
+	err := row.Scan(
+		&offer.ID, &offer.TenantID, &offer.RestaurantID, &offer.OrderID, &offer.DriverID, &offer.Attempt, &offer.Status,
+		&offer.Score, &distance, &offer.IsManual, &assignedBy,
+		&offer.OfferedAt, &offer.ExpiresAt, &respondedAt, &offer.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if distance.Valid {
+		offer.DistanceKm = &distance.Float64
+	}
+	if assignedBy.Valid {
+		offer.AssignedBy = &assignedBy.Int64
+	}
+	if respondedAt.Valid {
+		offer.RespondedAt = &respondedAt.Time
+	}
+	return offer, nil
+}
+
+func (r *DispatchRepository) queryOffers(query string, args ...interface{}) ([]domain.DispatchOffer, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query dispatch offers: %w", err)
+	}
+	defer rows.Close()
+
+	offers := []domain.DispatchOffer{}
+	for rows.Next() {
+		offer, err := scanDispatchOffer(rows)
+		if err != nil {
+			return nil, err
+		}
+		offers = append(offers, *offer)
+	}
+	return offers, rows.Err()
+}
+
+// GetRestaurantLocation returns the restaurant coordinates, nil when they are not set
+func (r *DispatchRepository) GetRestaurantLocation(restaurantID int64) (*float64, *float64, error) {
+	var lat, lon sql.NullFloat64
+	err := r.db.QueryRow(`SELECT latitude, longitude FROM restaurants WHERE id = $1`, restaurantID).Scan(&lat, &lon)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get restaurant location: %w", err)
+	}
+	if !lat.Valid || !lon.Valid {
+		return nil, nil, nil
+	}
+	return &lat.Float64, &lon.Float64, nil
+}
+
+// CreateOffer records an offer made to a driver
+func (r *DispatchRepository) CreateOffer(offer *domain.DispatchOffer) (*domain.DispatchOffer, error) {
+	query := `
+		INSERT INTO dispatch_offers (
+			tenant_id, restaurant_id, order_id, driver_id, attempt, status,
+			score, distance_km, is_manual, assigned_by, expires_at, responded_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING ` + dispatchOfferColumns
+
+	row := r.db.QueryRow(query,
+		offer.TenantID, offer.RestaurantID, offer.OrderID, offer.DriverID, offer.Attempt, offer.Status,
+		offer.Score, offer.DistanceKm, offer.IsManual, offer.AssignedBy, offer.ExpiresAt, offer.RespondedAt,
+	)
+	created, err := scanDispatchOffer(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dispatch offer: %w", err)
+	}
+	return created, nil
+}
+
+// GetOfferByID retrieves a dispatch offer
+func (r *DispatchRepository) GetOfferByID(offerID int64) (*domain.DispatchOffer, error) {
+	row := r.db.QueryRow(`SELECT `+dispatchOfferColumns+` FROM dispatch_offers WHERE id = $1`, offerID)
+	offer, err := scanDispatchOffer(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrOfferNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dispatch offer: %w", err)
+	}
+	return offer, nil
+}
+
+// ListOffersForOrder returns all offers made for an order, oldest first
+func (r *DispatchRepository) ListOffersForOrder(orderID int64) ([]domain.DispatchOffer, error) {
+	return r.queryOffers(`
+		SELECT `+dispatchOfferColumns+`
+		FROM dispatch_offers
+		WHERE order_id = $1
+		ORDER BY offered_at ASC, id ASC
+	`, orderID)
+}
+
+// ListOpenOffersForDriver returns the offers a driver can still accept, oldest first
+func (r *DispatchRepository) ListOpenOffersForDriver(driverID int) ([]domain.DispatchOffer, error) {
+	return r.queryOffers(`
+		SELECT `+dispatchOfferColumns+`
+		FROM dispatch_offers
+		WHERE driver_id = $1 AND status = $2 AND expires_at > $3
+		ORDER BY offered_at ASC
+	`, driverID, domain.OfferStatusOffered, time.Now())
+}
+
+// ListDriversWithOpenOffers returns the drivers of a restaurant currently holding an open offer
+func (r *DispatchRepository) ListDriversWithOpenOffers(restaurantID int64) (map[int]bool, error) {
+	rows, err := r.db.Query(`
+		SELECT DISTINCT driver_id FROM dispatch_offers
+		WHERE restaurant_id = $1 AND status = $2 AND expires_at > $3
+	`, restaurantID, domain.OfferStatusOffered, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list drivers with open offers: %w", err)
+	}
+	defer rows.Close()
+
+	busy := map[int]bool{}
+	for rows.Next() {
+		var driverID int
+		if err := rows.Scan(&driverID); err != nil {
+			return nil, err
+		}
+		busy[driverID] = true
+	}
+	return busy, rows.Err()
+}
+
+// ListPendingPickups returns the accepted assignments of a restaurant whose driver has not yet
+// left with the order, so further orders can be batched onto the same run
+func (r *DispatchRepository) ListPendingPickups(restaurantID int64) ([]domain.DriverAssignment, error) {
+	rows, err := r.db.Query(`
+		SELECT da.id, da.order_id, da.driver_id, da.status, da.assigned_at
+		FROM driver_assignments da
+		JOIN orders o ON o.id = da.order_id
+		WHERE o.restaurant_id = $1
+			AND da.status IN ('pending', 'accepted')
+			AND o.status IN ('confirmed', 'preparing', 'ready')
+		ORDER BY da.assigned_at ASC
+	`, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending pickups: %w", err)
+	}
+	defer rows.Close()
+
+	assignments := []domain.DriverAssignment{}
+	for rows.Next() {
+		var a domain.DriverAssignment
+		if err := rows.Scan(&a.ID, &a.OrderID, &a.DriverID, &a.Status, &a.AssignedAt); err != nil {
+			return nil, err
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+// ListExpiredOffers returns open offers whose acceptance window has passed
+func (r *DispatchRepository) ListExpiredOffers(now time.Time) ([]domain.DispatchOffer, error) {
+	return r.queryOffers(`
+		SELECT `+dispatchOfferColumns+`
+		FROM dispatch_offers
+		WHERE status = $1 AND expires_at <= $2
+		ORDER BY expires_at ASC
+		LIMIT 100
+	`, domain.OfferStatusOffered, now)
+}
+
+// RespondToOffer closes an open offer with the given status.
+// It fails with ErrOfferNotPending if the offer was already answered, expired or cancelled.
+func (r *DispatchRepository) RespondToOffer(offerID int64, status string) (*domain.DispatchOffer, error) {
+	row := r.db.QueryRow(`
+		UPDATE dispatch_offers SET status = $1, responded_at = $2
+		WHERE id = $3 AND status = $4
+		RETURNING `+dispatchOfferColumns,
+		status, time.Now(), offerID, domain.OfferStatusOffered,
+	)
+	offer, err := scanDispatchOffer(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrOfferNotPending
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update dispatch offer: %w", err)
+	}
+	return offer, nil
+}
+
+// CancelOpenOffers cancels any open offer for an order
+func (r *DispatchRepository) CancelOpenOffers(orderID int64) error {
+	_, err := r.db.Exec(`
+		UPDATE dispatch_offers SET status = $1, responded_at = $2
+		WHERE order_id = $3 AND status = $4
+	`, domain.OfferStatusCancelled, time.Now(), orderID, domain.OfferStatusOffered)
+	if err != nil {
+		return fmt.Errorf("failed to cancel dispatch offers: %w", err)
+	}
+	return nil
+}
@@ -20,6 +20,10 @@ type DriverRepository interface {
 	Update(ctx context.Context, driver *domain.Driver) error
 	Delete(ctx context.Context, id int) error
 
+	// Driver App Credentials
+	GetByLogin(ctx context.Context, login string) (*domain.Driver, string, error)
+	SetPassword(ctx context.Context, id int, passwordHash string) error
+
 	// Status & Availability
 	UpdateStatus(ctx context.Context, id int, status string) error
 	UpdateAvailability(ctx context.Context, id int, status string) error
@@ -37,16 +41,35 @@ type DriverRepository interface {
 	GetAssignmentByID(ctx context.Context, id int) (*domain.DriverAssignment, error)
 	GetActiveAssignment(ctx context.Context, driverID int) (*domain.DriverAssignment, error)
 	GetAssignmentByOrderID(ctx context.Context, orderID int) (*domain.DriverAssignment, error)
+	ListActiveAssignments(ctx context.Context, driverID int) ([]domain.DriverAssignment, error)
 	UpdateAssignmentStatus(ctx context.Context, assignmentID int, status string) error
 	RateDriver(ctx context.Context, assignmentID int, rating int, comment string) error
 	GetAssignmentHistory(ctx context.Context, driverID int, limit int) ([]domain.DriverAssignment, error)
 	CompleteAssignment(ctx context.Context, assignmentID int) error
+	RecordDeliveryProof(ctx context.Context, assignmentID int, proofType, photoURL string, pinVerified bool) error
 
 	// Statistics
 	GetDriverStats(ctx context.Context, driverID int) (*domain.DriverStats, error)
 	UpdateDeliveryMetrics(ctx context.Context, driverID int, completed bool) error
 }
 
+// driverColumns selects a full driver row; nullable text columns are coalesced
+// so they can be scanned into the plain string fields of domain.Driver
+const driverColumns = `
+	id, tenant_id, restaurant_id, first_name, last_name, email, phone_number,
+	license_number, COALESCE(vehicle_type, ''), COALESCE(vehicle_number, ''), status, availability_status,
+	current_latitude, current_longitude, total_deliveries, completed_deliveries,
+	cancelled_deliveries, average_rating, active_orders_count, date_of_birth,
+	COALESCE(address, ''), COALESCE(city, ''), COALESCE(state, ''), COALESCE(zip_code, ''),
+	is_verified, verification_date, joined_date, last_active, COALESCE(notes, ''), created_at, updated_at
+`
+
+const assignmentColumns = `
+	id, order_id, driver_id, assigned_at, accepted_at, started_at, completed_at,
+	status, COALESCE(assignment_notes, ''), rating, COALESCE(rating_comment, ''), created_at, updated_at,
+	COALESCE(proof_type, ''), COALESCE(proof_photo_url, ''), COALESCE(proof_pin_verified, false)
+`
+
 type driverRepository struct {
 	db *sql.DB
 }
@@ -62,7 +85,7 @@ func (r *driverRepository) Create(ctx context.Context, driver *domain.Driver) er
 		INSERT INTO drivers (
 			tenant_id, restaurant_id, first_name, last_name, email, phone_number,
 			license_number, vehicle_type, vehicle_number, status, availability_status
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -78,13 +101,8 @@ func (r *driverRepository) Create(ctx context.Context, driver *domain.Driver) er
 func (r *driverRepository) GetByID(ctx context.Context, id int) (*domain.Driver, error) {
 	driver := &domain.Driver{}
 	query := `
-		SELECT id, tenant_id, restaurant_id, first_name, last_name, email, phone_number,
-		       license_number, vehicle_type, vehicle_number, status, availability_status,
-		       current_latitude, current_longitude, total_deliveries, completed_deliveries,
-		       cancelled_deliveries, average_rating, active_orders_count, date_of_birth,
-		       address, city, state, zip_code, is_verified, verification_date, joined_date,
-		       last_active, notes, created_at, updated_at
-		FROM drivers WHERE id = ?
+		SELECT ` + driverColumns + `
+		FROM drivers WHERE id = $1
 	`
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
@@ -112,13 +130,8 @@ func (r *driverRepository) GetByID(ctx context.Context, id int) (*domain.Driver,
 func (r *driverRepository) GetByEmail(ctx context.Context, email string) (*domain.Driver, error) {
 	driver := &domain.Driver{}
 	query := `
-		SELECT id, tenant_id, restaurant_id, first_name, last_name, email, phone_number,
-		       license_number, vehicle_type, vehicle_number, status, availability_status,
-		       current_latitude, current_longitude, total_deliveries, completed_deliveries,
-		       cancelled_deliveries, average_rating, active_orders_count, date_of_birth,
-		       address, city, state, zip_code, is_verified, verification_date, joined_date,
-		       last_active, notes, created_at, updated_at
-		FROM drivers WHERE email = ? LIMIT 1
+		SELECT ` + driverColumns + `
+		FROM drivers WHERE email = $1 LIMIT 1
 	`
 
 	err := r.db.QueryRowContext(ctx, query, email).Scan(
@@ -138,17 +151,64 @@ func (r *driverRepository) GetByEmail(ctx context.Context, email string) (*domai
 	return driver, err
 }
 
+// GetByLogin retrieves a driver and their password hash by email or phone number.
+// The hash is empty when no driver app password has been set.
+func (r *driverRepository) GetByLogin(ctx context.Context, login string) (*domain.Driver, string, error) {
+	driver := &domain.Driver{}
+	var passwordHash sql.NullString
+	query := `
+		SELECT ` + driverColumns + `, password_hash
+		FROM drivers WHERE LOWER(email) = LOWER($1) OR phone_number = $1
+		LIMIT 1
+	`
+
+	err := r.db.QueryRowContext(ctx, query, login).Scan(
+		&driver.ID, &driver.TenantID, &driver.RestaurantID, &driver.FirstName, &driver.LastName,
+		&driver.Email, &driver.PhoneNumber, &driver.LicenseNumber, &driver.VehicleType,
+		&driver.VehicleNumber, &driver.Status, &driver.AvailabilityStatus,
+		&driver.CurrentLatitude, &driver.CurrentLongitude, &driver.TotalDeliveries,
+		&driver.CompletedDeliveries, &driver.CancelledDeliveries, &driver.AverageRating,
+		&driver.ActiveOrdersCount, &driver.DateOfBirth, &driver.Address, &driver.City,
+		&driver.State, &driver.ZipCode, &driver.IsVerified, &driver.VerificationDate,
+		&driver.JoinedDate, &driver.LastActive, &driver.Notes, &driver.CreatedAt, &driver.UpdatedAt,
+		&passwordHash,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, "", errors.New("driver not found")
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get driver: %w", err)
+	}
+
+	return driver, passwordHash.String, nil
+}
+
+// SetPassword stores the driver app password hash
+func (r *driverRepository) SetPassword(ctx context.Context, id int, passwordHash string) error {
+	query := `UPDATE drivers SET password_hash = $1, updated_at = NOW() WHERE id = $2`
+	result, err := r.db.ExecContext(ctx, query, passwordHash, id)
+	if err != nil {
+		return fmt.Errorf("failed to set driver password: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rows == 0 {
+		return errors.New("driver not found")
+	}
+
+	return nil
+}
+
 // GetByTenantAndRestaurant retrieves all drivers for a restaurant
 func (r *driverRepository) GetByTenantAndRestaurant(ctx context.Context, tenantID, restaurantID int) ([]domain.Driver, error) {
 	query := `
-		SELECT id, tenant_id, restaurant_id, first_name, last_name, email, phone_number,
-		       license_number, vehicle_type, vehicle_number, status, availability_status,
-		       current_latitude, current_longitude, total_deliveries, completed_deliveries,
-		       cancelled_deliveries, average_rating, active_orders_count, date_of_birth,
-		       address, city, state, zip_code, is_verified, verification_date, joined_date,
-		       last_active, notes, created_at, updated_at
+		SELECT ` + driverColumns + `
 		FROM drivers
-		WHERE tenant_id = ? AND restaurant_id = ?
+		WHERE tenant_id = $1 AND restaurant_id = $2
 		ORDER BY created_at DESC
 	`
 
@@ -184,11 +244,11 @@ func (r *driverRepository) GetByTenantAndRestaurant(ctx context.Context, tenantI
 func (r *driverRepository) Update(ctx context.Context, driver *domain.Driver) error {
 	query := `
 		UPDATE drivers SET
-			first_name = ?, last_name = ?, email = ?, phone_number = ?,
-			vehicle_type = ?, vehicle_number = ?, status = ?, availability_status = ?,
-			date_of_birth = ?, address = ?, city = ?, state = ?, zip_code = ?,
-			notes = ?, updated_at = NOW()
-		WHERE id = ?
+			first_name = $1, last_name = $2, email = $3, phone_number = $4,
+			vehicle_type = $5, vehicle_number = $6, status = $7, availability_status = $8,
+			date_of_birth = $9, address = $10, city = $11, state = $12, zip_code = $13,
+			notes = $14, updated_at = NOW()
+		WHERE id = $15
 	`
 
 	result, err := r.db.ExecContext(
@@ -217,7 +277,7 @@ func (r *driverRepository) Update(ctx context.Context, driver *domain.Driver) er
 
 // Delete deletes a driver
 func (r *driverRepository) Delete(ctx context.Context, id int) error {
-	query := `DELETE FROM drivers WHERE id = ?`
+	query := `DELETE FROM drivers WHERE id = $1`
 
 	result, err := r.db.ExecContext(ctx, query, id)
 	if err != nil {
@@ -238,14 +298,14 @@ func (r *driverRepository) Delete(ctx context.Context, id int) error {
 
 // UpdateStatus updates driver status
 func (r *driverRepository) UpdateStatus(ctx context.Context, id int, status string) error {
-	query := `UPDATE drivers SET status = ?, updated_at = NOW() WHERE id = ?`
+	query := `UPDATE drivers SET status = $1, updated_at = NOW() WHERE id = $2`
 	_, err := r.db.ExecContext(ctx, query, status, id)
 	return err
 }
 
 // UpdateAvailability updates driver availability status
 func (r *driverRepository) UpdateAvailability(ctx context.Context, id int, status string) error {
-	query := `UPDATE drivers SET availability_status = ?, last_active = NOW(), updated_at = NOW() WHERE id = ?`
+	query := `UPDATE drivers SET availability_status = $1, last_active = NOW(), updated_at = NOW() WHERE id = $2`
 	_, err := r.db.ExecContext(ctx, query, status, id)
 	return err
 }
@@ -253,14 +313,9 @@ func (r *driverRepository) UpdateAvailability(ctx context.Context, id int, statu
 // GetAvailableDrivers retrieves all available drivers
 func (r *driverRepository) GetAvailableDrivers(ctx context.Context, restaurantID int) ([]domain.Driver, error) {
 	query := `
-		SELECT id, tenant_id, restaurant_id, first_name, last_name, email, phone_number,
-		       license_number, vehicle_type, vehicle_number, status, availability_status,
-		       current_latitude, current_longitude, total_deliveries, completed_deliveries,
-		       cancelled_deliveries, average_rating, active_orders_count, date_of_birth,
-		       address, city, state, zip_code, is_verified, verification_date, joined_date,
-		       last_active, notes, created_at, updated_at
+		SELECT ` + driverColumns + `
 		FROM drivers
-		WHERE restaurant_id = ? AND status = 'active' AND availability_status = 'available'
+		WHERE restaurant_id = $1 AND status = 'active' AND availability_status = 'available'
 		ORDER BY active_orders_count ASC, average_rating DESC
 	`
 
@@ -294,14 +349,14 @@ func (r *driverRepository) GetAvailableDrivers(ctx context.Context, restaurantID
 
 // UpdateActiveOrders updates the count of active orders for a driver
 func (r *driverRepository) UpdateActiveOrders(ctx context.Context, id int, count int) error {
-	query := `UPDATE drivers SET active_orders_count = ?, updated_at = NOW() WHERE id = ?`
+	query := `UPDATE drivers SET active_orders_count = $1, updated_at = NOW() WHERE id = $2`
 	_, err := r.db.ExecContext(ctx, query, count, id)
 	return err
 }
 
 // UpdateLocation updates driver current location
 func (r *driverRepository) UpdateLocation(ctx context.Context, driverID int, lat, lon float64) error {
-	query := `UPDATE drivers SET current_latitude = ?, current_longitude = ?, last_active = NOW(), updated_at = NOW() WHERE id = ?`
+	query := `UPDATE drivers SET current_latitude = $1, current_longitude = $2, last_active = NOW(), updated_at = NOW() WHERE id = $3`
 	_, err := r.db.ExecContext(ctx, query, lat, lon, driverID)
 	return err
 }
@@ -312,7 +367,7 @@ func (r *driverRepository) GetLastLocation(ctx context.Context, driverID int) (*
 	query := `
 		SELECT id, driver_id, order_id, latitude, longitude, accuracy, recorded_at
 		FROM driver_location_history
-		WHERE driver_id = ?
+		WHERE driver_id = $1
 		ORDER BY recorded_at DESC
 		LIMIT 1
 	`
@@ -332,7 +387,7 @@ func (r *driverRepository) GetLastLocation(ctx context.Context, driverID int) (*
 func (r *driverRepository) RecordLocation(ctx context.Context, location *domain.DriverLocation) error {
 	query := `
 		INSERT INTO driver_location_history (driver_id, order_id, latitude, longitude, accuracy)
-		VALUES (?, ?, ?, ?, ?)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, recorded_at
 	`
 
@@ -346,9 +401,9 @@ func (r *driverRepository) GetLocationHistory(ctx context.Context, driverID int,
 	query := `
 		SELECT id, driver_id, order_id, latitude, longitude, accuracy, recorded_at
 		FROM driver_location_history
-		WHERE driver_id = ?
+		WHERE driver_id = $1
 		ORDER BY recorded_at DESC
-		LIMIT ?
+		LIMIT $2
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, driverID, limit)
@@ -375,13 +430,13 @@ func (r *driverRepository) GetLocationHistory(ctx context.Context, driverID int,
 // AssignOrder creates a new order assignment
 func (r *driverRepository) AssignOrder(ctx context.Context, assignment *domain.DriverAssignment) error {
 	query := `
-		INSERT INTO driver_assignments (order_id, driver_id, assigned_at, status)
-		VALUES (?, ?, NOW(), ?)
-		RETURNING id, created_at, updated_at
+		INSERT INTO driver_assignments (order_id, driver_id, assigned_at, status, assignment_notes)
+		VALUES ($1, $2, NOW(), $3, $4)
+		RETURNING id, assigned_at, created_at, updated_at
 	`
 
-	return r.db.QueryRowContext(ctx, query, assignment.OrderID, assignment.DriverID, assignment.Status).Scan(
-		&assignment.ID, &assignment.CreatedAt, &assignment.UpdatedAt,
+	return r.db.QueryRowContext(ctx, query, assignment.OrderID, assignment.DriverID, assignment.Status, assignment.AssignmentNotes).Scan(
+		&assignment.ID, &assignment.AssignedAt, &assignment.CreatedAt, &assignment.UpdatedAt,
 	)
 }
 
@@ -389,10 +444,9 @@ func (r *driverRepository) AssignOrder(ctx context.Context, assignment *domain.D
 func (r *driverRepository) GetAssignmentByID(ctx context.Context, id int) (*domain.DriverAssignment, error) {
 	assignment := &domain.DriverAssignment{}
 	query := `
-		SELECT id, order_id, driver_id, assigned_at, accepted_at, started_at, completed_at,
-		       status, assignment_notes, rating, rating_comment, created_at, updated_at
+		SELECT ` + assignmentColumns + `
 		FROM driver_assignments
-		WHERE id = ?
+		WHERE id = $1
 	`
 
 	err := r.db.QueryRowContext(ctx, query, id).Scan(
@@ -400,6 +454,7 @@ func (r *driverRepository) GetAssignmentByID(ctx context.Context, id int) (*doma
 		&assignment.AcceptedAt, &assignment.StartedAt, &assignment.CompletedAt,
 		&assignment.Status, &assignment.AssignmentNotes, &assignment.Rating,
 		&assignment.RatingComment, &assignment.CreatedAt, &assignment.UpdatedAt,
+		&assignment.ProofType, &assignment.ProofPhotoURL, &assignment.ProofPINVerified,
 	)
 
 	if err == sql.ErrNoRows {
@@ -413,10 +468,9 @@ func (r *driverRepository) GetAssignmentByID(ctx context.Context, id int) (*doma
 func (r *driverRepository) GetActiveAssignment(ctx context.Context, driverID int) (*domain.DriverAssignment, error) {
 	assignment := &domain.DriverAssignment{}
 	query := `
-		SELECT id, order_id, driver_id, assigned_at, accepted_at, started_at, completed_at,
-		       status, assignment_notes, rating, rating_comment, created_at, updated_at
+		SELECT ` + assignmentColumns + `
 		FROM driver_assignments
-		WHERE driver_id = ? AND status IN ('pending', 'accepted', 'in_progress')
+		WHERE driver_id = $1 AND status IN ('pending', 'accepted', 'in_progress')
 		ORDER BY assigned_at DESC
 		LIMIT 1
 	`
@@ -426,6 +480,7 @@ func (r *driverRepository) GetActiveAssignment(ctx context.Context, driverID int
 		&assignment.AcceptedAt, &assignment.StartedAt, &assignment.CompletedAt,
 		&assignment.Status, &assignment.AssignmentNotes, &assignment.Rating,
 		&assignment.RatingComment, &assignment.CreatedAt, &assignment.UpdatedAt,
+		&assignment.ProofType, &assignment.ProofPhotoURL, &assignment.ProofPINVerified,
 	)
 
 	if err == sql.ErrNoRows {
@@ -439,10 +494,10 @@ func (r *driverRepository) GetActiveAssignment(ctx context.Context, driverID int
 func (r *driverRepository) GetAssignmentByOrderID(ctx context.Context, orderID int) (*domain.DriverAssignment, error) {
 	assignment := &domain.DriverAssignment{}
 	query := `
-		SELECT id, order_id, driver_id, assigned_at, accepted_at, started_at, completed_at,
-		       status, assignment_notes, rating, rating_comment, created_at, updated_at
+		SELECT ` + assignmentColumns + `
 		FROM driver_assignments
-		WHERE order_id = ?
+		WHERE order_id = $1
+		ORDER BY assigned_at DESC
 		LIMIT 1
 	`
 
@@ -451,6 +506,7 @@ func (r *driverRepository) GetAssignmentByOrderID(ctx context.Context, orderID i
 		&assignment.AcceptedAt, &assignment.StartedAt, &assignment.CompletedAt,
 		&assignment.Status, &assignment.AssignmentNotes, &assignment.Rating,
 		&assignment.RatingComment, &assignment.CreatedAt, &assignment.UpdatedAt,
+		&assignment.ProofType, &assignment.ProofPhotoURL, &assignment.ProofPINVerified,
 	)
 
 	if err == sql.ErrNoRows {
@@ -460,29 +516,59 @@ func (r *driverRepository) GetAssignmentByOrderID(ctx context.Context, orderID i
 	return assignment, err
 }
 
+// ListActiveAssignments retrieves all assignments a driver is still working on, oldest first
+func (r *driverRepository) ListActiveAssignments(ctx context.Context, driverID int) ([]domain.DriverAssignment, error) {
+	query := `
+		SELECT ` + assignmentColumns + `
+		FROM driver_assignments
+		WHERE driver_id = $1 AND status IN ('pending', 'accepted', 'in_progress')
+		ORDER BY assigned_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, driverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active assignments: %w", err)
+	}
+	defer rows.Close()
+
+	assignments := []domain.DriverAssignment{}
+	for rows.Next() {
+		assignment := domain.DriverAssignment{}
+		err := rows.Scan(
+			&assignment.ID, &assignment.OrderID, &assignment.DriverID, &assignment.AssignedAt,
+			&assignment.AcceptedAt, &assignment.StartedAt, &assignment.CompletedAt,
+			&assignment.Status, &assignment.AssignmentNotes, &assignment.Rating,
+			&assignment.RatingComment, &assignment.CreatedAt, &assignment.UpdatedAt,
+			&assignment.ProofType, &assignment.ProofPhotoURL, &assignment.ProofPINVerified,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan assignment: %w", err)
+		}
+		assignments = append(assignments, assignment)
+	}
+
+	return assignments, rows.Err()
+}
+
 // UpdateAssignmentStatus updates assignment status
 func (r *driverRepository) UpdateAssignmentStatus(ctx context.Context, assignmentID int, status string) error {
 	now := time.Now()
 	query := `
-		UPDATE driver_assignments SET status = ?, updated_at = ?
+		UPDATE driver_assignments SET status = $1, updated_at = $2
 	`
 
-	// Set appropriate timestamps based on status
+	// Set appropriate timestamps based on status; pick-up and drop-off times
+	// already stamped by geofence events are kept
 	switch status {
 	case "accepted":
-		query += `, accepted_at = ?`
+		query += `, accepted_at = $2`
 	case "in_progress":
-		query += `, started_at = ?`
+		query += `, started_at = COALESCE(started_at, $2)`
 	case "completed":
-		query += `, completed_at = ?`
+		query += `, completed_at = COALESCE(completed_at, $2)`
 	}
 
-	query += ` WHERE id = ?`
-
-	if status == "accepted" || status == "in_progress" || status == "completed" {
-		_, err := r.db.ExecContext(ctx, query, status, now, now, assignmentID)
-		return err
-	}
+	query += ` WHERE id = $3`
 
 	_, err := r.db.ExecContext(ctx, query, status, now, assignmentID)
 	return err
@@ -491,8 +577,8 @@ func (r *driverRepository) UpdateAssignmentStatus(ctx context.Context, assignmen
 // RateDriver rates a driver for delivery
 func (r *driverRepository) RateDriver(ctx context.Context, assignmentID int, rating int, comment string) error {
 	query := `
-		UPDATE driver_assignments SET rating = ?, rating_comment = ?, updated_at = NOW()
-		WHERE id = ?
+		UPDATE driver_assignments SET rating = $1, rating_comment = $2, updated_at = NOW()
+		WHERE id = $3
 	`
 
 	_, err := r.db.ExecContext(ctx, query, rating, comment, assignmentID)
@@ -502,12 +588,11 @@ func (r *driverRepository) RateDriver(ctx context.Context, assignmentID int, rat
 // GetAssignmentHistory retrieves assignment history for a driver
 func (r *driverRepository) GetAssignmentHistory(ctx context.Context, driverID int, limit int) ([]domain.DriverAssignment, error) {
 	query := `
-		SELECT id, order_id, driver_id, assigned_at, accepted_at, started_at, completed_at,
-		       status, assignment_notes, rating, rating_comment, created_at, updated_at
+		SELECT ` + assignmentColumns + `
 		FROM driver_assignments
-		WHERE driver_id = ?
+		WHERE driver_id = $1
 		ORDER BY assigned_at DESC
-		LIMIT ?
+		LIMIT $2
 	`
 
 	rows, err := r.db.QueryContext(ctx, query, driverID, limit)
@@ -524,6 +609,7 @@ func (r *driverRepository) GetAssignmentHistory(ctx context.Context, driverID in
 			&assignment.AcceptedAt, &assignment.StartedAt, &assignment.CompletedAt,
 			&assignment.Status, &assignment.AssignmentNotes, &assignment.Rating,
 			&assignment.RatingComment, &assignment.CreatedAt, &assignment.UpdatedAt,
+			&assignment.ProofType, &assignment.ProofPhotoURL, &assignment.ProofPINVerified,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan assignment: %w", err)
@@ -539,19 +625,52 @@ func (r *driverRepository) CompleteAssignment(ctx context.Context, assignmentID
 	return r.UpdateAssignmentStatus(ctx, assignmentID, "completed")
 }
 
+// RecordDeliveryProof stores how a delivery was proven
+func (r *driverRepository) RecordDeliveryProof(ctx context.Context, assignmentID int, proofType, photoURL string, pinVerified bool) error {
+	query := `
+		UPDATE driver_assignments SET proof_type = $1, proof_photo_url = NULLIF($2, ''), proof_pin_verified = $3, updated_at = NOW()
+		WHERE id = $4
+	`
+
+	_, err := r.db.ExecContext(ctx, query, proofType, photoURL, pinVerified, assignmentID)
+	return err
+}
+
 // GetDriverStats retrieves driver performance statistics
 func (r *driverRepository) GetDriverStats(ctx context.Context, driverID int) (*domain.DriverStats, error) {
 	stats := &domain.DriverStats{}
+	var lastSettledAt sql.NullTime
 	query := `
-		SELECT id, first_name, last_name, total_deliveries, completed_deliveries,
-		       cancelled_deliveries, average_rating
-		FROM drivers
-		WHERE id = ?
+		SELECT d.id, d.first_name, d.last_name, d.total_deliveries, d.completed_deliveries,
+		       d.cancelled_deliveries, d.average_rating, d.active_orders_count,
+		       COALESCE(a.earnings, 0) + COALESCE(s.incentives, 0),
+		       COALESCE(a.unsettled_earnings, 0), COALESCE(a.unsettled_cash, 0),
+		       COALESCE(s.variance, 0), COALESCE(s.settlements, 0), s.last_settled_at
+		FROM drivers d
+		LEFT JOIN (
+			SELECT driver_id,
+			       SUM(earning_amount) AS earnings,
+			       SUM(earning_amount) FILTER (WHERE settlement_id IS NULL) AS unsettled_earnings,
+			       SUM(cash_collected) FILTER (WHERE settlement_id IS NULL) AS unsettled_cash
+			FROM driver_assignments
+			WHERE driver_id = $1 AND status = 'completed'
+			GROUP BY driver_id
+		) a ON a.driver_id = d.id
+		LEFT JOIN (
+			SELECT driver_id, SUM(incentives) AS incentives, SUM(cash_variance) AS variance,
+			       COUNT(*) AS settlements, MAX(settled_at) AS last_settled_at
+			FROM driver_settlements
+			WHERE driver_id = $1
+			GROUP BY driver_id
+		) s ON s.driver_id = d.id
+		WHERE d.id = $1
 	`
 
 	err := r.db.QueryRowContext(ctx, query, driverID).Scan(
 		&stats.DriverID, &stats.FirstName, &stats.LastName, &stats.TotalDeliveries,
-		&stats.CompletedDeliveries, &stats.CancelledDeliveries, &stats.AverageRating,
+		&stats.CompletedDeliveries, &stats.CancelledDeliveries, &stats.AverageRating, &stats.ActiveOrders,
+		&stats.TotalEarnings, &stats.UnsettledEarnings, &stats.UnsettledCash,
+		&stats.CashVariance, &stats.SettlementsCount, &lastSettledAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -562,6 +681,10 @@ func (r *driverRepository) GetDriverStats(ctx context.Context, driverID int) (*d
 		return nil, fmt.Errorf("failed to get driver stats: %w", err)
 	}
 
+	if lastSettledAt.Valid {
+		stats.LastSettledAt = &lastSettledAt.Time
+	}
+
 	// Calculate completion rate
 	if stats.TotalDeliveries > 0 {
 		stats.CompletionRate = float64(stats.CompletedDeliveries) / float64(stats.TotalDeliveries) * 100
@@ -579,7 +702,7 @@ func (r *driverRepository) UpdateDeliveryMetrics(ctx context.Context, driverID i
 				total_deliveries = total_deliveries + 1,
 				completed_deliveries = completed_deliveries + 1,
 				updated_at = NOW()
-			WHERE id = ?
+			WHERE id = $1
 		`
 	} else {
 		query = `
@@ -587,7 +710,7 @@ func (r *driverRepository) UpdateDeliveryMetrics(ctx context.Context, driverID i
 				total_deliveries = total_deliveries + 1,
 				cancelled_deliveries = cancelled_deliveries + 1,
 				updated_at = NOW()
-			WHERE id = ?
+			WHERE id = $1
 		`
 	}
 
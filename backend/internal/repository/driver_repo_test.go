@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"testing"
+	"time"
+)
+
+// TestGetAssignmentHistory tests that a history row scans into every assignment field
+func TestGetAssignmentHistory(t *testing.T) {
+	assignedAt := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	completedAt := assignedAt.Add(40 * time.Minute)
+	db := sql.OpenDB(&rowsConnector{rows: [][]driver.Value{{
+		int64(11), int64(42), int64(3), assignedAt, nil, assignedAt.Add(5 * time.Minute), completedAt,
+		"completed", "", int64(5), "quick", assignedAt, completedAt,
+		"pin", "", true,
+	}}})
+	defer db.Close()
+
+	history, err := NewDriverRepository(db).GetAssignmentHistory(context.Background(), 3, 10)
+	if err != nil {
+		t.Fatalf("GetAssignmentHistory() error = %v", err)
+	}
+	if len(history) != 1 {
+		t.Fatalf("GetAssignmentHistory() returned %d assignments, want 1", len(history))
+	}
+
+	got := history[0]
+	if got.ID != 11 || got.OrderID != 42 || got.DriverID != 3 || got.Status != "completed" {
+		t.Errorf("assignment = %+v", got)
+	}
+	if got.AcceptedAt != nil || got.CompletedAt == nil || !got.CompletedAt.Equal(completedAt) {
+		t.Errorf("AcceptedAt = %v, CompletedAt = %v", got.AcceptedAt, got.CompletedAt)
+	}
+	if got.Rating == nil || *got.Rating != 5 || got.RatingComment != "quick" {
+		t.Errorf("Rating = %v, RatingComment = %q", got.Rating, got.RatingComment)
+	}
+	if got.ProofType != "pin" || !got.ProofPINVerified {
+		t.Errorf("ProofType = %q, ProofPINVerified = %v", got.ProofType, got.ProofPINVerified)
+	}
+}
+
+// rowsConnector is a database that answers every query with the same rows, one value per
+// column of assignmentColumns
+type rowsConnector struct {
+	rows [][]driver.Value
+}
+
+func (c *rowsConnector) Connect(context.Context) (driver.Conn, error) { return &rowsConn{c}, nil }
+func (c *rowsConnector) Driver() driver.Driver                        { return nil }
+
+type rowsConn struct{ c *rowsConnector }
+
+func (conn *rowsConn) Prepare(string) (driver.Stmt, error) { return &rowsStmt{conn.c}, nil }
+func (conn *rowsConn) Close() error                        { return nil }
+func (conn *rowsConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+type rowsStmt struct{ c *rowsConnector }
+
+func (s *rowsStmt) Close() error                               { return nil }
+func (s *rowsStmt) NumInput() int                              { return -1 }
+func (s *rowsStmt) Exec([]driver.Value) (driver.Result, error) { return nil, driver.ErrSkip }
+func (s *rowsStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &rows{columns: make([]string, len(s.c.rows[0])), values: s.c.rows}, nil
+}
+
+type rows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *rows) Columns() []string { return r.columns }
+func (r *rows) Close() error      { return nil }
+func (r *rows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
@@ -0,0 +1,389 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"pos-saas/internal/domain"
+	"strings"
+	"time"
+)
+
+// EarningsRepository handles driver pay rules, delivery earnings and cash settlements
+type EarningsRepository struct {
+	db *sql.DB
+}
+
+// NewEarningsRepository creates new earnings repository
+func NewEarningsRepository(db *sql.DB) *EarningsRepository {
+	return &EarningsRepository{db: db}
+}
+
+const earningRuleColumns = `
+	id, tenant_id, restaurant_id, driver_id, per_delivery, per_km,
+	incentive_every, incentive_amount, created_at, updated_at
+`
+
+func scanEarningRule(row rowScanner) (*domain.EarningRule, error) {
+	rule := &domain.EarningRule{}
+	var driverID sql.NullInt64
+	err := row.Scan(
+		&rule.ID, &rule.TenantID, &rule.RestaurantID, &driverID, &rule.PerDelivery, &rule.PerKm,
+		&rule.IncentiveEvery, &rule.IncentiveAmount, &rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if driverID.Valid {
+		id := int(driverID.Int64)
+		rule.DriverID = &id
+	}
+	return rule, nil
+}
+
+const deliveryEarningColumns = `
+	da.id, da.order_id, COALESCE(o.order_number, ''), da.driver_id, da.completed_at,
+	COALESCE(o.payment_method, ''), COALESCE(da.distance_km, 0), COALESCE(da.earning_amount, 0),
+	COALESCE(da.cash_expected, 0), COALESCE(da.cash_collected, 0), da.settlement_id
+`
+
+func scanDeliveryEarning(row rowScanner) (*domain.DeliveryEarning, error) {
+	d := &domain.DeliveryEarning{}
+	var completedAt sql.NullTime
+	var settlementID sql.NullInt64
+	err := row.Scan(
+		&d.AssignmentID, &d.OrderID, &d.OrderNumber, &d.DriverID, &completedAt,
+		&d.PaymentMethod, &d.DistanceKm, &d.Earning,
+		&d.CashExpected, &d.CashCollected, &settlementID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		d.CompletedAt = &completedAt.Time
+	}
+	if settlementID.Valid {
+		d.SettlementID = &settlementID.Int64
+	}
+	return d, nil
+}
+
+const settlementColumns = `
+	id, tenant_id, restaurant_id, driver_id, period_start, period_end, deliveries_count,
+	distance_km, delivery_earnings, incentives, total_earnings,
+	cash_expected, cash_collected, cash_handed_in, cash_variance,
+	status, COALESCE(notes, ''), settled_by, settled_at
+`
+
+func scanSettlement(row rowScanner) (*domain.DriverSettlement, error) {
+	s := &domain.DriverSettlement{}
+	var settledBy sql.NullInt64
+	var settledAt sql.NullTime
+	err := row.Scan(
+		&s.ID, &s.TenantID, &s.RestaurantID, &s.DriverID, &s.PeriodStart, &s.PeriodEnd, &s.DeliveriesCount,
+		&s.DistanceKm, &s.DeliveryEarnings, &s.Incentives, &s.TotalEarnings,
+		&s.CashExpected, &s.CashCollected, &s.CashHandedIn, &s.CashVariance,
+		&s.Status, &s.Notes, &settledBy, &settledAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if settledBy.Valid {
+		s.SettledBy = &settledBy.Int64
+	}
+	if settledAt.Valid {
+		s.SettledAt = &settledAt.Time
+	}
+	return s, nil
+}
+
+func (r *EarningsRepository) queryDeliveries(query string, args ...interface{}) ([]domain.DeliveryEarning, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query delivery earnings: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []domain.DeliveryEarning{}
+	for rows.Next() {
+		d, err := scanDeliveryEarning(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *d)
+	}
+	return deliveries, rows.Err()
+}
+
+// GetEarningRule returns the rule that applies to a driver: their own rule, else the restaurant default.
+// It returns nil when neither exists.
+func (r *EarningsRepository) GetEarningRule(restaurantID int64, driverID int) (*domain.EarningRule, error) {
+	row := r.db.QueryRow(`
+		SELECT `+earningRuleColumns+`
+		FROM driver_earning_rules
+		WHERE restaurant_id = $1 AND (driver_id = $2 OR driver_id IS NULL)
+		ORDER BY driver_id NULLS LAST
+		LIMIT 1
+	`, restaurantID, driverID)
+	rule, err := scanEarningRule(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get earning rule: %w", err)
+	}
+	return rule, nil
+}
+
+// ListEarningRules returns the restaurant default followed by driver rules
+func (r *EarningsRepository) ListEarningRules(tenantID, restaurantID int64) ([]domain.EarningRule, error) {
+	rows, err := r.db.Query(`
+		SELECT `+earningRuleColumns+`
+		FROM driver_earning_rules
+		WHERE tenant_id = $1 AND restaurant_id = $2
+		ORDER BY driver_id NULLS FIRST
+	`, tenantID, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list earning rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []domain.EarningRule{}
+	for rows.Next() {
+		rule, err := scanEarningRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+// SaveEarningRule creates or replaces the restaurant default rule or a driver's rule
+func (r *EarningsRepository) SaveEarningRule(rule *domain.EarningRule) (*domain.EarningRule, error) {
+	conflict := `ON CONFLICT (restaurant_id) WHERE driver_id IS NULL`
+	if rule.DriverID != nil {
+		conflict = `ON CONFLICT (restaurant_id, driver_id) WHERE driver_id IS NOT NULL`
+	}
+
+	row := r.db.QueryRow(`
+		INSERT INTO driver_earning_rules (
+			tenant_id, restaurant_id, driver_id, per_delivery, per_km, incentive_every, incentive_amount
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		`+conflict+` DO UPDATE SET
+			per_delivery = EXCLUDED.per_delivery,
+			per_km = EXCLUDED.per_km,
+			incentive_every = EXCLUDED.incentive_every,
+			incentive_amount = EXCLUDED.incentive_amount,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING `+earningRuleColumns,
+		rule.TenantID, rule.RestaurantID, rule.DriverID, rule.PerDelivery, rule.PerKm,
+		rule.IncentiveEvery, rule.IncentiveAmount,
+	)
+	saved, err := scanEarningRule(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save earning rule: %w", err)
+	}
+	return saved, nil
+}
+
+// DeleteEarningRule removes an earning rule
+func (r *EarningsRepository) DeleteEarningRule(tenantID, restaurantID, ruleID int64) error {
+	result, err := r.db.Exec(`
+		DELETE FROM driver_earning_rules WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, ruleID, tenantID, restaurantID)
+	if err != nil {
+		return fmt.Errorf("failed to delete earning rule: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrEarningRuleNotFound
+	}
+	return nil
+}
+
+// RecordDeliveryEarning stores the distance, pay and cash of a completed delivery
+func (r *EarningsRepository) RecordDeliveryEarning(assignmentID int, distanceKm, earning, cashExpected, cashCollected float64) error {
+	_, err := r.db.Exec(`
+		UPDATE driver_assignments
+		SET distance_km = $1, earning_amount = $2, cash_expected = $3, cash_collected = $4, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5
+	`, distanceKm, earning, cashExpected, cashCollected, assignmentID)
+	if err != nil {
+		return fmt.Errorf("failed to record delivery earning: %w", err)
+	}
+	return nil
+}
+
+// ListUnsettledDeliveries returns a driver's completed deliveries not yet included in a settlement
+func (r *EarningsRepository) ListUnsettledDeliveries(driverID int) ([]domain.DeliveryEarning, error) {
+	return r.queryDeliveries(`
+		SELECT `+deliveryEarningColumns+`
+		FROM driver_assignments da
+		LEFT JOIN orders o ON o.id = da.order_id
+		WHERE da.driver_id = $1 AND da.status = 'completed' AND da.settlement_id IS NULL
+		ORDER BY da.completed_at ASC
+	`, driverID)
+}
+
+// ListDeliveries returns a driver's completed deliveries in a period, oldest first
+func (r *EarningsRepository) ListDeliveries(driverID int, from, to *time.Time) ([]domain.DeliveryEarning, error) {
+	conditions := []string{"da.driver_id = $1", "da.status = 'completed'"}
+	args := []interface{}{driverID}
+	if from != nil {
+		args = append(args, *from)
+		conditions = append(conditions, fmt.Sprintf("da.completed_at >= $%d", len(args)))
+	}
+	if to != nil {
+		args = append(args, *to)
+		conditions = append(conditions, fmt.Sprintf("da.completed_at < $%d", len(args)))
+	}
+
+	return r.queryDeliveries(`
+		SELECT `+deliveryEarningColumns+`
+		FROM driver_assignments da
+		LEFT JOIN orders o ON o.id = da.order_id
+		WHERE `+strings.Join(conditions, " AND ")+`
+		ORDER BY da.completed_at ASC
+	`, args...)
+}
+
+// GetLastSettlementEnd returns when the driver's previous settlement period ended, nil if never settled
+func (r *EarningsRepository) GetLastSettlementEnd(driverID int) (*time.Time, error) {
+	var end sql.NullTime
+	err := r.db.QueryRow(`SELECT MAX(period_end) FROM driver_settlements WHERE driver_id = $1`, driverID).Scan(&end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last settlement: %w", err)
+	}
+	if !end.Valid {
+		return nil, nil
+	}
+	return &end.Time, nil
+}
+
+// CreateSettlement records a settlement and attaches the driver's unsettled deliveries up to the end of its period.
+// It fails with ErrDeliveriesSettled if some of them were settled since the settlement was built.
+func (r *EarningsRepository) CreateSettlement(s *domain.DriverSettlement) (*domain.DriverSettlement, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(`
+		INSERT INTO driver_settlements (
+			tenant_id, restaurant_id, driver_id, period_start, period_end, deliveries_count,
+			distance_km, delivery_earnings, incentives, total_earnings,
+			cash_expected, cash_collected, cash_handed_in, cash_variance,
+			status, notes, settled_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NULLIF($16, ''), $17)
+		RETURNING `+settlementColumns,
+		s.TenantID, s.RestaurantID, s.DriverID, s.PeriodStart, s.PeriodEnd, s.DeliveriesCount,
+		s.DistanceKm, s.DeliveryEarnings, s.Incentives, s.TotalEarnings,
+		s.CashExpected, s.CashCollected, s.CashHandedIn, s.CashVariance,
+		s.Status, s.Notes, s.SettledBy,
+	)
+	created, err := scanSettlement(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create settlement: %w", err)
+	}
+
+	result, err := tx.Exec(`
+		UPDATE driver_assignments SET settlement_id = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE driver_id = $2 AND status = 'completed' AND settlement_id IS NULL AND completed_at <= $3
+	`, created.ID, s.DriverID, s.PeriodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to settle deliveries: %w", err)
+	}
+	if n, _ := result.RowsAffected(); int(n) != s.DeliveriesCount {
+		return nil, domain.ErrDeliveriesSettled
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit settlement: %w", err)
+	}
+	created.Deliveries = s.Deliveries
+	return created, nil
+}
+
+// GetSettlement returns a settlement with its deliveries
+func (r *EarningsRepository) GetSettlement(tenantID, restaurantID, settlementID int64) (*domain.DriverSettlement, error) {
+	row := r.db.QueryRow(`
+		SELECT `+settlementColumns+`
+		FROM driver_settlements
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, settlementID, tenantID, restaurantID)
+	s, err := scanSettlement(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrSettlementNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get settlement: %w", err)
+	}
+
+	s.Deliveries, err = r.queryDeliveries(`
+		SELECT `+deliveryEarningColumns+`
+		FROM driver_assignments da
+		LEFT JOIN orders o ON o.id = da.order_id
+		WHERE da.settlement_id = $1
+		ORDER BY da.completed_at ASC
+	`, s.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// ListSettlements returns a restaurant's settlements, newest first
+func (r *EarningsRepository) ListSettlements(tenantID, restaurantID int64, filters *domain.SettlementFilters) ([]domain.DriverSettlement, int, error) {
+	conditions := []string{"tenant_id = $1", "restaurant_id = $2"}
+	args := []interface{}{tenantID, restaurantID}
+
+	if filters.DriverID > 0 {
+		args = append(args, filters.DriverID)
+		conditions = append(conditions, fmt.Sprintf("driver_id = $%d", len(args)))
+	}
+	if filters.From != nil {
+		args = append(args, *filters.From)
+		conditions = append(conditions, fmt.Sprintf("settled_at >= $%d", len(args)))
+	}
+	if filters.To != nil {
+		args = append(args, *filters.To)
+		conditions = append(conditions, fmt.Sprintf("settled_at < $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM driver_settlements WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count settlements: %w", err)
+	}
+
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 || filters.Limit > 100 {
+		filters.Limit = 20
+	}
+	args = append(args, filters.Limit, (filters.Page-1)*filters.Limit)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM driver_settlements
+		WHERE %s
+		ORDER BY settled_at DESC
+		LIMIT $%d OFFSET $%d
+	`, settlementColumns, where, len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list settlements: %w", err)
+	}
+	defer rows.Close()
+
+	settlements := []domain.DriverSettlement{}
+	for rows.Next() {
+		s, err := scanSettlement(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		settlements = append(settlements, *s)
+	}
+	return settlements, total, rows.Err()
+}
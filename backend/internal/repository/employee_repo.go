@@ -2,8 +2,11 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
 	"pos-saas/internal/domain"
+
+	"github.com/lib/pq"
 )
 
 // EmployeeRepository handles employee data operations
@@ -28,7 +31,7 @@ func (r *EmployeeRepository) ListEmployees(tenantID, restaurantID int) ([]domain
 			payment_frequency, working_hours_per_week, shift_type,
 			emergency_contact_name, emergency_contact_phone, emergency_contact_relationship,
 			profile_image_url, documents, notes, is_active, created_at, updated_at,
-			created_by, updated_by
+			created_by, updated_by, user_id
 		FROM employees
 		WHERE tenant_id = $1 AND restaurant_id = $2 AND is_active = true
 		ORDER BY first_name ASC, last_name ASC
@@ -49,7 +52,7 @@ func (r *EmployeeRepository) ListEmployees(tenantID, restaurantID int) ([]domain
 		var profileImageURL, notes sql.NullString
 		var dateOfBirth, terminationDate sql.NullTime
 		var gender, department sql.NullString
-		var managerID, createdBy, updatedBy sql.NullInt64
+		var managerID, createdBy, updatedBy, userID sql.NullInt64
 		var documents sql.NullString
 
 		err := rows.Scan(
@@ -62,7 +65,7 @@ func (r *EmployeeRepository) ListEmployees(tenantID, restaurantID int) ([]domain
 			&emp.PaymentFrequency, &emp.WorkingHoursPerWeek, &emp.ShiftType,
 			&emergencyName, &emergencyPhone, &emergencyRelation,
 			&profileImageURL, &documents, &notes, &emp.IsActive,
-			&emp.CreatedAt, &emp.UpdatedAt, &createdBy, &updatedBy,
+			&emp.CreatedAt, &emp.UpdatedAt, &createdBy, &updatedBy, &userID,
 		)
 		if err != nil {
 			return nil, err
@@ -104,6 +107,10 @@ func (r *EmployeeRepository) ListEmployees(tenantID, restaurantID int) ([]domain
 			ub := int(updatedBy.Int64)
 			emp.UpdatedBy = &ub
 		}
+		if userID.Valid {
+			uid := int(userID.Int64)
+			emp.UserID = &uid
+		}
 		if documents.Valid {
 			emp.Documents = []byte(documents.String)
 		}
@@ -135,7 +142,7 @@ func (r *EmployeeRepository) GetEmployeeByID(tenantID, restaurantID, id int) (*d
 			payment_frequency, working_hours_per_week, shift_type,
 			emergency_contact_name, emergency_contact_phone, emergency_contact_relationship,
 			profile_image_url, documents, notes, is_active, created_at, updated_at,
-			created_by, updated_by
+			created_by, updated_by, user_id
 		FROM employees
 		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
 	`
@@ -147,7 +154,7 @@ func (r *EmployeeRepository) GetEmployeeByID(tenantID, restaurantID, id int) (*d
 	var profileImageURL, notes sql.NullString
 	var dateOfBirth, terminationDate sql.NullTime
 	var gender, department sql.NullString
-	var managerID, createdBy, updatedBy sql.NullInt64
+	var managerID, createdBy, updatedBy, userID sql.NullInt64
 	var documents sql.NullString
 
 	err := r.db.QueryRow(query, id, tenantID, restaurantID).Scan(
@@ -160,7 +167,7 @@ func (r *EmployeeRepository) GetEmployeeByID(tenantID, restaurantID, id int) (*d
 		&emp.PaymentFrequency, &emp.WorkingHoursPerWeek, &emp.ShiftType,
 		&emergencyName, &emergencyPhone, &emergencyRelation,
 		&profileImageURL, &documents, &notes, &emp.IsActive,
-		&emp.CreatedAt, &emp.UpdatedAt, &createdBy, &updatedBy,
+		&emp.CreatedAt, &emp.UpdatedAt, &createdBy, &updatedBy, &userID,
 	)
 
 	if err == sql.ErrNoRows {
@@ -206,6 +213,10 @@ func (r *EmployeeRepository) GetEmployeeByID(tenantID, restaurantID, id int) (*d
 		ub := int(updatedBy.Int64)
 		emp.UpdatedBy = &ub
 	}
+	if userID.Valid {
+		uid := int(userID.Int64)
+		emp.UserID = &uid
+	}
 	if documents.Valid {
 		emp.Documents = []byte(documents.String)
 	}
@@ -393,3 +404,145 @@ func (r *EmployeeRepository) GetEmployeeByCode(tenantID int, employeeCode string
 
 	return &emp, nil
 }
+
+// SetClockCredentials sets an employee's kiosk PIN hash and badge code. A nil value leaves
+// the credential unchanged and an empty string clears it.
+func (r *EmployeeRepository) SetClockCredentials(tenantID, restaurantID, id int, pinHash, badgeCode *string) error {
+	query := `
+		UPDATE employees
+		SET clock_pin_hash = CASE WHEN $1 THEN NULLIF($2, '') ELSE clock_pin_hash END,
+		    badge_code = CASE WHEN $3 THEN NULLIF($4, '') ELSE badge_code END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $5 AND tenant_id = $6 AND restaurant_id = $7
+	`
+
+	var pin, badge string
+	if pinHash != nil {
+		pin = *pinHash
+	}
+	if badgeCode != nil {
+		badge = *badgeCode
+	}
+
+	result, err := r.db.Exec(query, pinHash != nil, pin, badgeCode != nil, badge, id, tenantID, restaurantID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return domain.ErrBadgeCodeTaken
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("employee not found")
+	}
+	return nil
+}
+
+// LinkUser links an employee to a login account, or unlinks it when userID is nil.
+// Returns domain.ErrUserAlreadyLinked when the account belongs to another employee of the restaurant.
+func (r *EmployeeRepository) LinkUser(tenantID, restaurantID, id int, userID *int) error {
+	query := `
+		UPDATE employees
+		SET user_id = $1, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND tenant_id = $3 AND restaurant_id = $4
+	`
+
+	result, err := r.db.Exec(query, userID, id, tenantID, restaurantID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return domain.ErrUserAlreadyLinked
+	}
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("employee not found")
+	}
+	return nil
+}
+
+// GetClockPINHash retrieves the id, name and kiosk PIN hash of an active employee by employee code.
+// Returns a zero id when no active employee of the restaurant has the code.
+func (r *EmployeeRepository) GetClockPINHash(tenantID, restaurantID int, employeeCode string) (int, string, string, error) {
+	query := `
+		SELECT id, first_name || ' ' || last_name, COALESCE(clock_pin_hash, '')
+		FROM employees
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND LOWER(employee_code) = LOWER($3) AND is_active = true
+	`
+
+	var id int
+	var name, hash string
+	err := r.db.QueryRow(query, tenantID, restaurantID, employeeCode).Scan(&id, &name, &hash)
+	if err == sql.ErrNoRows {
+		return 0, "", "", nil
+	}
+	return id, name, hash, err
+}
+
+// GetEmployeeByBadge retrieves the id and name of an active employee by badge code.
+// Returns a zero id when no active employee of the restaurant has the badge.
+func (r *EmployeeRepository) GetEmployeeByBadge(tenantID, restaurantID int, badgeCode string) (int, string, error) {
+	query := `
+		SELECT id, first_name || ' ' || last_name
+		FROM employees
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND badge_code = $3 AND is_active = true
+	`
+
+	var id int
+	var name string
+	err := r.db.QueryRow(query, tenantID, restaurantID, badgeCode).Scan(&id, &name)
+	if err == sql.ErrNoRows {
+		return 0, "", nil
+	}
+	return id, name, err
+}
+
+// employeeUserJoin matches employees to their login account: the linked user, or for
+// employees not linked yet, the user sharing their email in the tenant
+const employeeUserJoin = `
+		JOIN users u ON u.tenant_id = e.tenant_id
+			AND (u.id = e.user_id OR (e.user_id IS NULL AND LOWER(u.email) = LOWER(e.email)))`
+
+// GetEmployeeUserIDs maps employees to their user accounts in the tenant
+func (r *EmployeeRepository) GetEmployeeUserIDs(tenantID int, employeeIDs []int) (map[int]int, error) {
+	ids := make([]int64, len(employeeIDs))
+	for i, id := range employeeIDs {
+		ids[i] = int64(id)
+	}
+	rows, err := r.db.Query(`
+		SELECT e.id, u.id
+		FROM employees e`+employeeUserJoin+`
+		WHERE e.tenant_id = $1 AND e.id = ANY($2)
+	`, tenantID, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	users := map[int]int{}
+	for rows.Next() {
+		var employeeID, userID int
+		if err := rows.Scan(&employeeID, &userID); err != nil {
+			return nil, err
+		}
+		users[employeeID] = userID
+	}
+	return users, rows.Err()
+}
+
+// GetEmployeeIDByUser retrieves the restaurant's employee linked to the user, falling back to
+// an unlinked employee sharing the user's email. Returns zero when the user has no employee record.
+func (r *EmployeeRepository) GetEmployeeIDByUser(tenantID, restaurantID, userID int) (int, error) {
+	query := `
+		SELECT e.id
+		FROM employees e` + employeeUserJoin + `
+		WHERE e.tenant_id = $1 AND e.restaurant_id = $2 AND u.id = $3
+		ORDER BY e.user_id IS NULL, e.is_active DESC, e.id ASC
+		LIMIT 1
+	`
+
+	var id int
+	err := r.db.QueryRow(query, tenantID, restaurantID, userID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return id, err
+}
@@ -0,0 +1,415 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"pos-saas/internal/domain"
+	"strings"
+	"time"
+)
+
+// FeedbackRepository handles order feedback, product reviews and review requests
+type FeedbackRepository struct {
+	db *sql.DB
+}
+
+// NewFeedbackRepository creates new feedback repository
+func NewFeedbackRepository(db *sql.DB) *FeedbackRepository {
+	return &FeedbackRepository{db: db}
+}
+
+const reviewRequestColumns = `
+	id, tenant_id, restaurant_id, order_id, token,
+	COALESCE(customer_name, ''), COALESCE(customer_email, ''), COALESCE(customer_phone, ''),
+	status, expires_at, completed_at, created_at
+`
+
+const productReviewColumns = `
+	pr.id, pr.tenant_id, pr.restaurant_id, pr.product_id, COALESCE(p.name_en, ''), pr.order_id,
+	COALESCE(pr.customer_name, ''), pr.rating, COALESCE(pr.comment, ''), pr.status,
+	pr.moderated_by, pr.moderated_at, pr.created_at, pr.updated_at
+`
+
+func scanReviewRequest(row rowScanner) (*domain.ReviewRequest, error) {
+	req := &domain.ReviewRequest{}
+	var completedAt sql.NullTime
+	err := row.Scan(
+		&req.ID, &req.TenantID, &req.RestaurantID, &req.OrderID, &req.Token,
+		&req.CustomerName, &req.CustomerEmail, &req.CustomerPhone,
+		&req.Status, &req.ExpiresAt, &completedAt, &req.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if completedAt.Valid {
+		req.CompletedAt = &completedAt.Time
+	}
+	return req, nil
+}
+
+func scanProductReview(row rowScanner) (*domain.ProductReview, error) {
+	review := &domain.ProductReview{}
+	var moderatedBy sql.NullInt64
+	var moderatedAt sql.NullTime
+	err := row.Scan(
+		&review.ID, &review.TenantID, &review.RestaurantID, &review.ProductID, &review.ProductName, &review.OrderID,
+		&review.CustomerName, &review.Rating, &review.Comment, &review.Status,
+		&moderatedBy, &moderatedAt, &review.CreatedAt, &review.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if moderatedBy.Valid {
+		review.ModeratedBy = &moderatedBy.Int64
+	}
+	if moderatedAt.Valid {
+		review.ModeratedAt = &moderatedAt.Time
+	}
+	return review, nil
+}
+
+// CreateReviewRequest stores a review request for a delivered order.
+// If the order already has one, the existing request is returned.
+func (r *FeedbackRepository) CreateReviewRequest(req *domain.ReviewRequest) (*domain.ReviewRequest, error) {
+	query := `
+		INSERT INTO review_requests (tenant_id, restaurant_id, order_id, token, customer_name, customer_email, customer_phone, status, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (order_id) DO NOTHING
+	`
+	_, err := r.db.Exec(query,
+		req.TenantID, req.RestaurantID, req.OrderID, req.Token,
+		req.CustomerName, req.CustomerEmail, req.CustomerPhone, domain.ReviewRequestPending, req.ExpiresAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create review request: %w", err)
+	}
+
+	row := r.db.QueryRow(`SELECT `+reviewRequestColumns+` FROM review_requests WHERE order_id = $1`, req.OrderID)
+	created, err := scanReviewRequest(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review request: %w", err)
+	}
+	return created, nil
+}
+
+// GetReviewRequestByToken retrieves a review request by its public token
+func (r *FeedbackRepository) GetReviewRequestByToken(token string) (*domain.ReviewRequest, error) {
+	row := r.db.QueryRow(`SELECT `+reviewRequestColumns+` FROM review_requests WHERE token = $1`, token)
+	req, err := scanReviewRequest(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrReviewRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review request: %w", err)
+	}
+	return req, nil
+}
+
+// GetReviewRequestByOrder retrieves the review request of an order
+func (r *FeedbackRepository) GetReviewRequestByOrder(orderID int64) (*domain.ReviewRequest, error) {
+	row := r.db.QueryRow(`SELECT `+reviewRequestColumns+` FROM review_requests WHERE order_id = $1`, orderID)
+	req, err := scanReviewRequest(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrReviewRequestNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review request: %w", err)
+	}
+	return req, nil
+}
+
+// SubmitFeedback stores the order feedback and item reviews and completes the review request.
+// The delivery rating is copied to the driver assignment and the driver's average is refreshed.
+func (r *FeedbackRepository) SubmitFeedback(request *domain.ReviewRequest, feedback *domain.OrderFeedback, reviews []domain.ProductReview) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Claim the request first so concurrent submissions cannot both succeed
+	res, err := tx.Exec(`
+		UPDATE review_requests SET status = $1, completed_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND status = $3
+	`, domain.ReviewRequestCompleted, request.ID, domain.ReviewRequestPending)
+	if err != nil {
+		return fmt.Errorf("failed to complete review request: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return domain.ErrReviewRequestUsed
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO order_feedback (tenant_id, restaurant_id, order_id, overall_rating, food_rating, delivery_rating, comment, delivery_comment)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`,
+		feedback.TenantID, feedback.RestaurantID, feedback.OrderID, feedback.OverallRating,
+		feedback.FoodRating, feedback.DeliveryRating, feedback.Comment, feedback.DeliveryComment,
+	).Scan(&feedback.ID, &feedback.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create order feedback: %w", err)
+	}
+
+	for i := range reviews {
+		review := &reviews[i]
+		err = tx.QueryRow(`
+			INSERT INTO product_reviews (tenant_id, restaurant_id, product_id, order_id, customer_name, rating, comment, status)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+			RETURNING id, created_at, updated_at
+		`,
+			review.TenantID, review.RestaurantID, review.ProductID, review.OrderID,
+			review.CustomerName, review.Rating, review.Comment, domain.ReviewStatusPending,
+		).Scan(&review.ID, &review.CreatedAt, &review.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create product review: %w", err)
+		}
+		review.Status = domain.ReviewStatusPending
+	}
+
+	if feedback.DeliveryRating != nil {
+		var driverID int64
+		err = tx.QueryRow(`
+			UPDATE driver_assignments SET rating = $1, rating_comment = $2, updated_at = CURRENT_TIMESTAMP
+			WHERE id = (
+				SELECT id FROM driver_assignments
+				WHERE order_id = $3 AND status = 'completed'
+				ORDER BY completed_at DESC NULLS LAST
+				LIMIT 1
+			)
+			RETURNING driver_id
+		`, *feedback.DeliveryRating, feedback.DeliveryComment, feedback.OrderID).Scan(&driverID)
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("failed to rate driver: %w", err)
+		}
+		if err == nil {
+			_, err = tx.Exec(`
+				UPDATE drivers SET average_rating = (
+					SELECT COALESCE(AVG(rating), 0) FROM driver_assignments
+					WHERE driver_id = $1 AND rating IS NOT NULL
+				), updated_at = CURRENT_TIMESTAMP
+				WHERE id = $1
+			`, driverID)
+			if err != nil {
+				return fmt.Errorf("failed to update driver rating: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit feedback: %w", err)
+	}
+	return nil
+}
+
+// GetOrderFeedback retrieves the feedback left for an order, or nil if there is none
+func (r *FeedbackRepository) GetOrderFeedback(orderID int64) (*domain.OrderFeedback, error) {
+	query := `
+		SELECT id, tenant_id, restaurant_id, order_id, overall_rating, food_rating, delivery_rating,
+			COALESCE(comment, ''), COALESCE(delivery_comment, ''), created_at
+		FROM order_feedback
+		WHERE order_id = $1
+	`
+
+	feedback := &domain.OrderFeedback{}
+	var food, delivery sql.NullInt64
+	err := r.db.QueryRow(query, orderID).Scan(
+		&feedback.ID, &feedback.TenantID, &feedback.RestaurantID, &feedback.OrderID, &feedback.OverallRating,
+		&food, &delivery, &feedback.Comment, &feedback.DeliveryComment, &feedback.CreatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order feedback: %w", err)
+	}
+	if food.Valid {
+		v := int(food.Int64)
+		feedback.FoodRating = &v
+	}
+	if delivery.Valid {
+		v := int(delivery.Int64)
+		feedback.DeliveryRating = &v
+	}
+	return feedback, nil
+}
+
+// ListReviews lists product reviews of a restaurant for moderation
+func (r *FeedbackRepository) ListReviews(tenantID, restaurantID int64, filters *domain.ReviewListFilters) ([]domain.ProductReview, int, error) {
+	conditions := []string{"pr.tenant_id = $1", "pr.restaurant_id = $2"}
+	args := []interface{}{tenantID, restaurantID}
+
+	if filters.Status != "" {
+		args = append(args, filters.Status)
+		conditions = append(conditions, fmt.Sprintf("pr.status = $%d", len(args)))
+	}
+	if filters.ProductID > 0 {
+		args = append(args, filters.ProductID)
+		conditions = append(conditions, fmt.Sprintf("pr.product_id = $%d", len(args)))
+	}
+	where := strings.Join(conditions, " AND ")
+
+	var total int
+	if err := r.db.QueryRow(`SELECT COUNT(*) FROM product_reviews pr WHERE `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count reviews: %w", err)
+	}
+
+	if filters.Page < 1 {
+		filters.Page = 1
+	}
+	if filters.Limit < 1 || filters.Limit > 100 {
+		filters.Limit = 20
+	}
+	args = append(args, filters.Limit, (filters.Page-1)*filters.Limit)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM product_reviews pr
+		LEFT JOIN products p ON p.id = pr.product_id
+		WHERE %s
+		ORDER BY pr.created_at DESC
+		LIMIT $%d OFFSET $%d
+	`, productReviewColumns, where, len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []domain.ProductReview{}
+	for rows.Next() {
+		review, err := scanProductReview(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		reviews = append(reviews, *review)
+	}
+	return reviews, total, rows.Err()
+}
+
+// ListApprovedProductReviews returns the approved reviews of a product, newest first
+func (r *FeedbackRepository) ListApprovedProductReviews(restaurantID, productID int64, limit int) ([]domain.ProductReview, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+
+	query := `
+		SELECT ` + productReviewColumns + `
+		FROM product_reviews pr
+		LEFT JOIN products p ON p.id = pr.product_id
+		WHERE pr.restaurant_id = $1 AND pr.product_id = $2 AND pr.status = $3
+		ORDER BY pr.created_at DESC
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(query, restaurantID, productID, domain.ReviewStatusApproved, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product reviews: %w", err)
+	}
+	defer rows.Close()
+
+	reviews := []domain.ProductReview{}
+	for rows.Next() {
+		review, err := scanProductReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, *review)
+	}
+	return reviews, rows.Err()
+}
+
+// ModerateReview sets the status of a review and refreshes the product rating aggregates
+func (r *FeedbackRepository) ModerateReview(tenantID, restaurantID, reviewID int64, status string, moderatedBy int64) (*domain.ProductReview, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var productID int64
+	err = tx.QueryRow(`
+		UPDATE product_reviews
+		SET status = $1, moderated_by = $2, moderated_at = $3, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND tenant_id = $5 AND restaurant_id = $6
+		RETURNING product_id
+	`, status, moderatedBy, time.Now(), reviewID, tenantID, restaurantID).Scan(&productID)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to moderate review: %w", err)
+	}
+
+	_, err = tx.Exec(`
+		UPDATE products SET
+			rating_average = COALESCE((SELECT ROUND(AVG(rating)::numeric, 2) FROM product_reviews WHERE product_id = $1 AND status = $2), 0),
+			rating_count = (SELECT COUNT(*) FROM product_reviews WHERE product_id = $1 AND status = $2)
+		WHERE id = $1
+	`, productID, domain.ReviewStatusApproved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update product rating: %w", err)
+	}
+
+	row := tx.QueryRow(`
+		SELECT `+productReviewColumns+`
+		FROM product_reviews pr
+		LEFT JOIN products p ON p.id = pr.product_id
+		WHERE pr.id = $1
+	`, reviewID)
+	review, err := scanProductReview(row)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get review: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit moderation: %w", err)
+	}
+	return review, nil
+}
+
+// GetRatingSummary aggregates the order feedback of a restaurant
+func (r *FeedbackRepository) GetRatingSummary(restaurantID int64) (*domain.RatingSummary, error) {
+	summary := &domain.RatingSummary{Distribution: map[int]int{}}
+
+	err := r.db.QueryRow(`
+		SELECT COUNT(*),
+			COALESCE(AVG(overall_rating), 0),
+			COALESCE(AVG(food_rating), 0),
+			COALESCE(AVG(delivery_rating), 0)
+		FROM order_feedback
+		WHERE restaurant_id = $1
+	`, restaurantID).Scan(&summary.TotalRatings, &summary.AverageRating, &summary.AverageFoodRating, &summary.AverageDeliveryRating)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating summary: %w", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT overall_rating, COUNT(*)
+		FROM order_feedback
+		WHERE restaurant_id = $1
+		GROUP BY overall_rating
+	`, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rating distribution: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[int]int{}
+	for rows.Next() {
+		var stars, count int
+		if err := rows.Scan(&stars, &count); err != nil {
+			return nil, err
+		}
+		counts[stars] = count
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	summary.Distribution = domain.RatingDistribution(counts)
+	summary.AverageRating = domain.RoundRating(summary.AverageRating)
+	summary.AverageFoodRating = domain.RoundRating(summary.AverageFoodRating)
+	summary.AverageDeliveryRating = domain.RoundRating(summary.AverageDeliveryRating)
+	return summary, nil
+}
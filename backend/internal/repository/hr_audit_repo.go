@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+
+	"pos-saas/internal/domain"
+)
+
+// HRAuditRepository writes and reads the HR audit trail
+type HRAuditRepository struct {
+	db *sql.DB
+}
+
+// NewHRAuditRepository creates a new HR audit repository
+func NewHRAuditRepository(db *sql.DB) *HRAuditRepository {
+	return &HRAuditRepository{db: db}
+}
+
+// CreateAuditLog records an HR change. create_hr_audit_log works out the changed fields from
+// the old and new values.
+func (r *HRAuditRepository) CreateAuditLog(entry *domain.HRAuditLog) error {
+	var oldValues, newValues interface{}
+	if len(entry.OldValues) > 0 {
+		oldValues = string(entry.OldValues)
+	}
+	if len(entry.NewValues) > 0 {
+		newValues = string(entry.NewValues)
+	}
+	var reason *string
+	if entry.Reason != "" {
+		reason = &entry.Reason
+	}
+
+	return r.db.QueryRow(`
+		SELECT create_hr_audit_log($1, $2, $3, $4, $5, $6, $7::jsonb, $8::jsonb, $9, $10)
+	`,
+		entry.TenantID, entry.RestaurantID, entry.EntityType, entry.EntityID, entry.EntityName,
+		entry.Action, oldValues, newValues, entry.UserID, reason,
+	).Scan(&entry.ID)
+}
+
+// ListAuditLogs retrieves a restaurant's HR audit trail, newest first
+func (r *HRAuditRepository) ListAuditLogs(tenantID, restaurantID int, filter domain.HRAuditFilter) ([]domain.HRAuditLog, error) {
+	conditions := []string{"tenant_id = $1", "restaurant_id = $2"}
+	args := []interface{}{tenantID, restaurantID}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.EntityType != "" {
+		add("entity_type = $%d", filter.EntityType)
+	}
+	if filter.EntityID != 0 {
+		add("entity_id = $%d", filter.EntityID)
+	}
+	if filter.UserID != 0 {
+		add("user_id = $%d", filter.UserID)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at <= $%d", *filter.To)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	args = append(args, limit)
+
+	query := fmt.Sprintf(`
+		SELECT id, tenant_id, restaurant_id, entity_type, entity_id, COALESCE(entity_name, ''),
+		       action, old_values, new_values, changed_fields, user_id,
+		       COALESCE(user_name, ''), COALESCE(user_email, ''), COALESCE(reason, ''), created_at
+		FROM hr_audit_logs_with_details
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d
+	`, strings.Join(conditions, " AND "), len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	logs := []domain.HRAuditLog{}
+	for rows.Next() {
+		var entry domain.HRAuditLog
+		var oldValues, newValues []byte
+		var changedFields pq.StringArray
+		var userID sql.NullInt64
+		err := rows.Scan(
+			&entry.ID, &entry.TenantID, &entry.RestaurantID, &entry.EntityType, &entry.EntityID,
+			&entry.EntityName, &entry.Action, &oldValues, &newValues, &changedFields, &userID,
+			&entry.UserName, &entry.UserEmail, &entry.Reason, &entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		entry.OldValues = oldValues
+		entry.NewValues = newValues
+		entry.ChangedFields = changedFields
+		entry.UserID = nullIntPtr(userID)
+		logs = append(logs, entry)
+	}
+	return logs, rows.Err()
+}
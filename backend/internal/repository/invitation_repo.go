@@ -0,0 +1,250 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"pos-saas/internal/domain"
+)
+
+// InvitationRepository stores invitations to join a tenant
+type InvitationRepository struct {
+	db *sql.DB
+}
+
+// NewInvitationRepository creates a new invitation repository
+func NewInvitationRepository(db *sql.DB) *InvitationRepository {
+	return &InvitationRepository{db: db}
+}
+
+const invitationColumns = `
+	id, tenant_id, restaurant_id, employee_id, name, email, COALESCE(phone, ''), channel,
+	role_ids, invited_by, user_id, expires_at, accepted_at, revoked_at, created_at, token_hash`
+
+// scanInvitation scans invitationColumns, followed by any extra columns of the query
+func scanInvitation(row rowScanner, extra ...interface{}) (*domain.UserInvitation, error) {
+	var inv domain.UserInvitation
+	var restaurantID, employeeID, invitedBy, userID sql.NullInt64
+	var roleIDs pq.Int64Array
+	dest := []interface{}{
+		&inv.ID, &inv.TenantID, &restaurantID, &employeeID, &inv.Name, &inv.Email, &inv.Phone, &inv.Channel,
+		&roleIDs, &invitedBy, &userID, &inv.ExpiresAt, &inv.AcceptedAt, &inv.RevokedAt, &inv.CreatedAt, &inv.TokenHash,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	inv.RestaurantID = nullIntPtr(restaurantID)
+	inv.EmployeeID = nullIntPtr(employeeID)
+	inv.InvitedBy = nullIntPtr(invitedBy)
+	inv.UserID = nullIntPtr(userID)
+	inv.RoleIDs = []int64(roleIDs)
+	if inv.RoleIDs == nil {
+		inv.RoleIDs = []int64{}
+	}
+	inv.Status = inv.StatusAt(time.Now())
+	return &inv, nil
+}
+
+// CountSeats counts the tenant's active users and open invitations against its user limit.
+// The open invitation of exceptEmail is left out, as inviting the same person again replaces it.
+func (r *InvitationRepository) CountSeats(ctx context.Context, tenantID int, exceptEmail string) (*domain.UserSeats, error) {
+	var seats domain.UserSeats
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(t.max_users, 0),
+			(SELECT COUNT(*) FROM users WHERE tenant_id = t.id AND status = 'active'),
+			(SELECT COUNT(*) FROM user_invitations
+			 WHERE tenant_id = t.id AND accepted_at IS NULL AND revoked_at IS NULL
+			   AND expires_at > CURRENT_TIMESTAMP AND LOWER(email) <> LOWER($2))
+		FROM tenants t
+		WHERE t.id = $1
+	`, tenantID, exceptEmail).Scan(&seats.MaxUsers, &seats.ActiveUsers, &seats.PendingInvitations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count user seats: %w", err)
+	}
+	return &seats, nil
+}
+
+// Create stores an invitation and revokes the earlier open invitation of the same email,
+// so only the most recent link works
+func (r *InvitationRepository) Create(ctx context.Context, inv *domain.UserInvitation) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE user_invitations SET revoked_at = CURRENT_TIMESTAMP
+		WHERE tenant_id = $1 AND LOWER(email) = LOWER($2) AND accepted_at IS NULL AND revoked_at IS NULL
+	`, inv.TenantID, inv.Email); err != nil {
+		return err
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO user_invitations
+			(tenant_id, restaurant_id, employee_id, name, email, phone, channel, role_ids, token_hash, invited_by, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10, $11)
+		RETURNING id, created_at
+	`, inv.TenantID, inv.RestaurantID, inv.EmployeeID, inv.Name, inv.Email, inv.Phone, inv.Channel,
+		pq.Array(inv.RoleIDs), inv.TokenHash, inv.InvitedBy, inv.ExpiresAt).Scan(&inv.ID, &inv.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create invitation: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+	inv.Status = inv.StatusAt(time.Now())
+	return nil
+}
+
+// List returns the tenant's invitations, newest first
+func (r *InvitationRepository) List(ctx context.Context, tenantID int) ([]domain.UserInvitation, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+invitationColumns+`
+		FROM user_invitations
+		WHERE tenant_id = $1
+		ORDER BY created_at DESC, id DESC
+	`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invitations := []domain.UserInvitation{}
+	for rows.Next() {
+		inv, err := scanInvitation(rows)
+		if err != nil {
+			return nil, err
+		}
+		invitations = append(invitations, *inv)
+	}
+	return invitations, rows.Err()
+}
+
+// GetByID returns an invitation of the tenant. Returns domain.ErrInvitationNotFound when there is none.
+func (r *InvitationRepository) GetByID(ctx context.Context, tenantID int, id int64) (*domain.UserInvitation, error) {
+	inv, err := scanInvitation(r.db.QueryRowContext(ctx, `
+		SELECT `+invitationColumns+`
+		FROM user_invitations
+		WHERE id = $1 AND tenant_id = $2
+	`, id, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvitationNotFound
+	}
+	return inv, err
+}
+
+// GetOpenByToken returns an open, unexpired invitation with the name of its tenant.
+// Returns domain.ErrInvalidInvitation when there is none.
+func (r *InvitationRepository) GetOpenByToken(ctx context.Context, tokenHash string) (*domain.UserInvitation, string, error) {
+	var tenantName string
+	inv, err := scanInvitation(r.db.QueryRowContext(ctx, `
+		SELECT `+invitationColumns+`,
+			(SELECT name FROM tenants WHERE id = user_invitations.tenant_id)
+		FROM user_invitations
+		WHERE token_hash = $1 AND accepted_at IS NULL AND revoked_at IS NULL
+		  AND expires_at > CURRENT_TIMESTAMP
+	`, tokenHash), &tenantName)
+	if err == sql.ErrNoRows {
+		return nil, "", domain.ErrInvalidInvitation
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return inv, tenantName, nil
+}
+
+// Renew gives an open invitation a new token and expiry, for resending it.
+// Returns domain.ErrInvitationNotPending when it was accepted or revoked.
+func (r *InvitationRepository) Renew(ctx context.Context, tenantID int, id int64, tokenHash string, expiresAt time.Time) error {
+	return r.updateOpen(ctx, `
+		UPDATE user_invitations SET token_hash = $3, expires_at = $4
+		WHERE id = $1 AND tenant_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, id, tenantID, tokenHash, expiresAt)
+}
+
+// Revoke cancels an open invitation. Returns domain.ErrInvitationNotPending when it was
+// already accepted or revoked.
+func (r *InvitationRepository) Revoke(ctx context.Context, tenantID int, id int64) error {
+	return r.updateOpen(ctx, `
+		UPDATE user_invitations SET revoked_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND tenant_id = $2 AND accepted_at IS NULL AND revoked_at IS NULL
+	`, id, tenantID)
+}
+
+// Accept claims an open, unexpired invitation and creates its account in one transaction,
+// so a token can only be used once. The tenant row stays locked while its active users are
+// counted, so accepts at the same time cannot fill more seats than the tenant's user limit.
+// Returns domain.ErrInvalidInvitation when the invitation is no longer open and
+// domain.ErrUserLimitReached when the tenant is full.
+func (r *InvitationRepository) Accept(ctx context.Context, inv *domain.UserInvitation, name, hashedPassword string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var maxUsers int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(max_users, 0) FROM tenants WHERE id = $1 FOR UPDATE
+	`, inv.TenantID).Scan(&maxUsers)
+	if err != nil {
+		return 0, fmt.Errorf("failed to lock tenant: %w", err)
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE user_invitations SET accepted_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND accepted_at IS NULL AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`, inv.ID)
+	if err != nil {
+		return 0, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return 0, domain.ErrInvalidInvitation
+	}
+
+	// The invitation holds its own seat, so only users added without one can have filled the limit
+	var activeUsers int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM users WHERE tenant_id = $1 AND status = 'active'
+	`, inv.TenantID).Scan(&activeUsers)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count user seats: %w", err)
+	}
+	if activeUsers >= maxUsers {
+		return 0, domain.ErrUserLimitReached
+	}
+
+	var userID int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO users (tenant_id, restaurant_id, email, password_hash, name, phone, role, status, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, 'user', 'active', NOW(), NOW())
+		RETURNING id
+	`, inv.TenantID, inv.RestaurantID, inv.Email, hashedPassword, name, inv.Phone).Scan(&userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE user_invitations SET user_id = $2 WHERE id = $1
+	`, inv.ID, userID); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+func (r *InvitationRepository) updateOpen(ctx context.Context, query string, args ...interface{}) error {
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrInvitationNotPending
+	}
+	return nil
+}
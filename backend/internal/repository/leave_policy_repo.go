@@ -0,0 +1,528 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"pos-saas/internal/domain"
+)
+
+// LeavePolicyRepository handles leave policies, holiday calendars and leave balances
+type LeavePolicyRepository struct {
+	db *sql.DB
+}
+
+// NewLeavePolicyRepository creates a new leave policy repository
+func NewLeavePolicyRepository(db *sql.DB) *LeavePolicyRepository {
+	return &LeavePolicyRepository{db: db}
+}
+
+const leavePolicyColumns = `
+	id, tenant_id, restaurant_id, leave_type, leave_category, track_balance, accrual_frequency,
+	accrual_days, max_carry_over, probation_months, accrue_during_probation, approval_levels,
+	holiday_calendar_id, is_active, created_at, updated_at
+`
+
+func scanLeavePolicy(row rowScanner) (*domain.LeavePolicy, error) {
+	var p domain.LeavePolicy
+	var maxCarryOver sql.NullFloat64
+	var calendarID sql.NullInt64
+	err := row.Scan(
+		&p.ID, &p.TenantID, &p.RestaurantID, &p.LeaveType, &p.LeaveCategory, &p.TrackBalance,
+		&p.AccrualFrequency, &p.AccrualDays, &maxCarryOver, &p.ProbationMonths,
+		&p.AccrueDuringProbation, &p.ApprovalLevels, &calendarID, &p.IsActive,
+		&p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if maxCarryOver.Valid {
+		p.MaxCarryOver = &maxCarryOver.Float64
+	}
+	p.HolidayCalendarID = nullIntPtr(calendarID)
+	return &p, nil
+}
+
+// ListLeavePolicies retrieves a restaurant's leave policies
+func (r *LeavePolicyRepository) ListLeavePolicies(tenantID, restaurantID int) ([]domain.LeavePolicy, error) {
+	rows, err := r.db.Query(`SELECT `+leavePolicyColumns+`
+		FROM leave_policies
+		WHERE tenant_id = $1 AND restaurant_id = $2
+		ORDER BY leave_type ASC
+	`, tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []domain.LeavePolicy{}
+	for rows.Next() {
+		p, err := scanLeavePolicy(rows)
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, *p)
+	}
+	return policies, rows.Err()
+}
+
+// GetLeavePolicy retrieves the active policy for a leave type, or nil when there is none
+func (r *LeavePolicyRepository) GetLeavePolicy(tenantID, restaurantID int, leaveType string) (*domain.LeavePolicy, error) {
+	p, err := scanLeavePolicy(r.db.QueryRow(`SELECT `+leavePolicyColumns+`
+		FROM leave_policies
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND leave_type = $3 AND is_active = true
+	`, tenantID, restaurantID, leaveType))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// CreateLeavePolicy creates a leave policy.
+// Returns ErrLeavePolicyExists when the leave type already has a policy.
+func (r *LeavePolicyRepository) CreateLeavePolicy(p *domain.LeavePolicy) (*domain.LeavePolicy, error) {
+	query := `
+		INSERT INTO leave_policies (
+			tenant_id, restaurant_id, leave_type, leave_category, track_balance, accrual_frequency,
+			accrual_days, max_carry_over, probation_months, accrue_during_probation, approval_levels,
+			holiday_calendar_id, is_active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING ` + leavePolicyColumns
+
+	created, err := scanLeavePolicy(r.db.QueryRow(
+		query,
+		p.TenantID, p.RestaurantID, p.LeaveType, p.LeaveCategory, p.TrackBalance, p.AccrualFrequency,
+		p.AccrualDays, p.MaxCarryOver, p.ProbationMonths, p.AccrueDuringProbation, p.ApprovalLevels,
+		p.HolidayCalendarID, p.IsActive,
+	))
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, domain.ErrLeavePolicyExists
+	}
+	return created, err
+}
+
+// UpdateLeavePolicy replaces a leave policy.
+// Returns ErrLeavePolicyNotFound when the policy does not belong to the restaurant.
+func (r *LeavePolicyRepository) UpdateLeavePolicy(p *domain.LeavePolicy) (*domain.LeavePolicy, error) {
+	query := `
+		UPDATE leave_policies
+		SET leave_type = $1, leave_category = $2, track_balance = $3, accrual_frequency = $4,
+		    accrual_days = $5, max_carry_over = $6, probation_months = $7,
+		    accrue_during_probation = $8, approval_levels = $9, holiday_calendar_id = $10,
+		    is_active = $11, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $12 AND tenant_id = $13 AND restaurant_id = $14
+		RETURNING ` + leavePolicyColumns
+
+	updated, err := scanLeavePolicy(r.db.QueryRow(
+		query,
+		p.LeaveType, p.LeaveCategory, p.TrackBalance, p.AccrualFrequency, p.AccrualDays,
+		p.MaxCarryOver, p.ProbationMonths, p.AccrueDuringProbation, p.ApprovalLevels,
+		p.HolidayCalendarID, p.IsActive, p.ID, p.TenantID, p.RestaurantID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrLeavePolicyNotFound
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, domain.ErrLeavePolicyExists
+	}
+	return updated, err
+}
+
+// DeleteLeavePolicy deletes a leave policy. Existing balances are kept.
+func (r *LeavePolicyRepository) DeleteLeavePolicy(tenantID, restaurantID, id int) error {
+	result, err := r.db.Exec(`
+		DELETE FROM leave_policies WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrLeavePolicyNotFound
+	}
+	return nil
+}
+
+const holidayCalendarColumns = `
+	id, tenant_id, restaurant_id, name, weekend_days, is_default, created_at, updated_at
+`
+
+func scanHolidayCalendar(row rowScanner) (*domain.HolidayCalendar, error) {
+	var c domain.HolidayCalendar
+	var weekendDays pq.Int64Array
+	err := row.Scan(
+		&c.ID, &c.TenantID, &c.RestaurantID, &c.Name, &weekendDays, &c.IsDefault,
+		&c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	c.WeekendDays = make([]int, len(weekendDays))
+	for i, d := range weekendDays {
+		c.WeekendDays[i] = int(d)
+	}
+	c.Holidays = []domain.PublicHoliday{}
+	return &c, nil
+}
+
+// ListHolidayCalendars retrieves a restaurant's holiday calendars with their holidays
+func (r *LeavePolicyRepository) ListHolidayCalendars(tenantID, restaurantID int) ([]domain.HolidayCalendar, error) {
+	rows, err := r.db.Query(`SELECT `+holidayCalendarColumns+`
+		FROM holiday_calendars
+		WHERE tenant_id = $1 AND restaurant_id = $2
+		ORDER BY is_default DESC, name ASC
+	`, tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	calendars := []domain.HolidayCalendar{}
+	for rows.Next() {
+		c, err := scanHolidayCalendar(rows)
+		if err != nil {
+			return nil, err
+		}
+		calendars = append(calendars, *c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range calendars {
+		if calendars[i].Holidays, err = r.listHolidays(calendars[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return calendars, nil
+}
+
+// GetHolidayCalendar retrieves a calendar with its holidays, or nil when it does not belong to the restaurant
+func (r *LeavePolicyRepository) GetHolidayCalendar(tenantID, restaurantID, id int) (*domain.HolidayCalendar, error) {
+	return r.getHolidayCalendar(`id = $3`, tenantID, restaurantID, id)
+}
+
+// GetDefaultHolidayCalendar retrieves the restaurant's default calendar, or nil when it has none
+func (r *LeavePolicyRepository) GetDefaultHolidayCalendar(tenantID, restaurantID int) (*domain.HolidayCalendar, error) {
+	return r.getHolidayCalendar(`is_default = true`, tenantID, restaurantID)
+}
+
+func (r *LeavePolicyRepository) getHolidayCalendar(where string, args ...interface{}) (*domain.HolidayCalendar, error) {
+	c, err := scanHolidayCalendar(r.db.QueryRow(`SELECT `+holidayCalendarColumns+`
+		FROM holiday_calendars
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND `+where, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if c.Holidays, err = r.listHolidays(c.ID); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (r *LeavePolicyRepository) listHolidays(calendarID int) ([]domain.PublicHoliday, error) {
+	rows, err := r.db.Query(`
+		SELECT id, calendar_id, holiday_date, name
+		FROM public_holidays
+		WHERE calendar_id = $1
+		ORDER BY holiday_date ASC
+	`, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	holidays := []domain.PublicHoliday{}
+	for rows.Next() {
+		var h domain.PublicHoliday
+		if err := rows.Scan(&h.ID, &h.CalendarID, &h.HolidayDate, &h.Name); err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, h)
+	}
+	return holidays, rows.Err()
+}
+
+// SaveHolidayCalendar creates the calendar when it has no ID, otherwise replaces it.
+// Making a calendar the default clears the flag on the restaurant's other calendars.
+func (r *LeavePolicyRepository) SaveHolidayCalendar(c *domain.HolidayCalendar) (*domain.HolidayCalendar, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if c.IsDefault {
+		if _, err := tx.Exec(`
+			UPDATE holiday_calendars SET is_default = false, updated_at = CURRENT_TIMESTAMP
+			WHERE tenant_id = $1 AND restaurant_id = $2 AND is_default = true AND id != $3
+		`, c.TenantID, c.RestaurantID, c.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	weekendDays := make(pq.Int64Array, len(c.WeekendDays))
+	for i, d := range c.WeekendDays {
+		weekendDays[i] = int64(d)
+	}
+
+	var saved *domain.HolidayCalendar
+	if c.ID == 0 {
+		saved, err = scanHolidayCalendar(tx.QueryRow(`
+			INSERT INTO holiday_calendars (tenant_id, restaurant_id, name, weekend_days, is_default)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING `+holidayCalendarColumns,
+			c.TenantID, c.RestaurantID, c.Name, weekendDays, c.IsDefault,
+		))
+	} else {
+		saved, err = scanHolidayCalendar(tx.QueryRow(`
+			UPDATE holiday_calendars
+			SET name = $1, weekend_days = $2, is_default = $3, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $4 AND tenant_id = $5 AND restaurant_id = $6
+			RETURNING `+holidayCalendarColumns,
+			c.Name, weekendDays, c.IsDefault, c.ID, c.TenantID, c.RestaurantID,
+		))
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrHolidayCalendarNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	if saved.Holidays, err = r.listHolidays(saved.ID); err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// DeleteHolidayCalendar deletes a calendar and its holidays
+func (r *LeavePolicyRepository) DeleteHolidayCalendar(tenantID, restaurantID, id int) error {
+	result, err := r.db.Exec(`
+		DELETE FROM holiday_calendars WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrHolidayCalendarNotFound
+	}
+	return nil
+}
+
+// AddPublicHoliday adds a holiday to a calendar.
+// Returns ErrPublicHolidayExists when the calendar already has a holiday that day.
+func (r *LeavePolicyRepository) AddPublicHoliday(h *domain.PublicHoliday) error {
+	err := r.db.QueryRow(`
+		INSERT INTO public_holidays (calendar_id, holiday_date, name)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, h.CalendarID, h.HolidayDate, h.Name).Scan(&h.ID)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return domain.ErrPublicHolidayExists
+	}
+	return err
+}
+
+// DeletePublicHoliday removes a holiday from a calendar
+func (r *LeavePolicyRepository) DeletePublicHoliday(calendarID, id int) error {
+	result, err := r.db.Exec(`DELETE FROM public_holidays WHERE id = $1 AND calendar_id = $2`, id, calendarID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrHolidayCalendarNotFound
+	}
+	return nil
+}
+
+// Pending days are summed from the employee's requests of the same type starting in the balance year
+const leaveBalanceColumns = `
+	b.id, b.tenant_id, b.restaurant_id, b.employee_id, b.leave_type, b.year, b.carried_over,
+	b.accrued, b.used, b.adjusted,
+	COALESCE((
+		SELECT SUM(l.total_days) FROM leaves l
+		WHERE l.employee_id = b.employee_id AND l.leave_type = b.leave_type
+		  AND l.status = 'pending' AND EXTRACT(YEAR FROM l.start_date) = b.year
+	), 0),
+	b.created_at, b.updated_at
+`
+
+func scanLeaveBalance(row rowScanner) (*domain.LeaveBalance, error) {
+	var b domain.LeaveBalance
+	err := row.Scan(
+		&b.ID, &b.TenantID, &b.RestaurantID, &b.EmployeeID, &b.LeaveType, &b.Year, &b.CarriedOver,
+		&b.Accrued, &b.Used, &b.Adjusted, &b.Pending, &b.CreatedAt, &b.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// GetLeaveBalance retrieves an employee's balance of a leave type for a year, or nil when none is kept
+func (r *LeavePolicyRepository) GetLeaveBalance(tenantID, restaurantID, employeeID int, leaveType string, year int) (*domain.LeaveBalance, error) {
+	b, err := scanLeaveBalance(r.db.QueryRow(`SELECT `+leaveBalanceColumns+`
+		FROM leave_balances b
+		WHERE b.tenant_id = $1 AND b.restaurant_id = $2 AND b.employee_id = $3
+		  AND b.leave_type = $4 AND b.year = $5
+	`, tenantID, restaurantID, employeeID, leaveType, year))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return b, err
+}
+
+// ListEmployeeBalances retrieves an employee's balances for a year
+func (r *LeavePolicyRepository) ListEmployeeBalances(tenantID, restaurantID, employeeID, year int) ([]domain.LeaveBalance, error) {
+	rows, err := r.db.Query(`SELECT `+leaveBalanceColumns+`
+		FROM leave_balances b
+		WHERE b.tenant_id = $1 AND b.restaurant_id = $2 AND b.employee_id = $3 AND b.year = $4
+		ORDER BY b.leave_type ASC
+	`, tenantID, restaurantID, employeeID, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	balances := []domain.LeaveBalance{}
+	for rows.Next() {
+		b, err := scanLeaveBalance(rows)
+		if err != nil {
+			return nil, err
+		}
+		balances = append(balances, *b)
+	}
+	return balances, rows.Err()
+}
+
+// CreateLeaveBalance opens a year's balance with its carry-over and first accrual.
+// When another request opened the balance first, the existing balance is returned.
+func (r *LeavePolicyRepository) CreateLeaveBalance(b *domain.LeaveBalance) (*domain.LeaveBalance, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO leave_balances (tenant_id, restaurant_id, employee_id, leave_type, year, carried_over, accrued)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (employee_id, leave_type, year) DO NOTHING
+		RETURNING id
+	`, b.TenantID, b.RestaurantID, b.EmployeeID, b.LeaveType, b.Year, b.CarriedOver, b.Accrued).Scan(&id)
+	if err == sql.ErrNoRows {
+		return r.GetLeaveBalance(b.TenantID, b.RestaurantID, b.EmployeeID, b.LeaveType, b.Year)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if b.CarriedOver != 0 {
+		if err := addBalanceTransaction(tx, id, nil, domain.BalanceCarryOver, b.CarriedOver, "Carried over from previous year", nil); err != nil {
+			return nil, err
+		}
+	}
+	if b.Accrued != 0 {
+		if err := addBalanceTransaction(tx, id, nil, domain.BalanceAccrual, b.Accrued, "", nil); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetLeaveBalance(b.TenantID, b.RestaurantID, b.EmployeeID, b.LeaveType, b.Year)
+}
+
+// RecordAccrual raises a balance's accrued days to the entitlement and records the difference.
+// Entitlements never go down, so a lower figure is ignored.
+func (r *LeavePolicyRepository) RecordAccrual(b *domain.LeaveBalance, accrued float64) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var previous float64
+	if err := tx.QueryRow(`SELECT accrued FROM leave_balances WHERE id = $1 FOR UPDATE`, b.ID).Scan(&previous); err != nil {
+		return err
+	}
+	if accrued <= previous {
+		return nil
+	}
+	if _, err := tx.Exec(`
+		UPDATE leave_balances SET accrued = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+	`, accrued, b.ID); err != nil {
+		return err
+	}
+	if err := addBalanceTransaction(tx, b.ID, nil, domain.BalanceAccrual, accrued-previous, "", nil); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	b.Accrued = accrued
+	return nil
+}
+
+// AdjustLeaveBalance adds days to (or removes days from) a balance by hand
+func (r *LeavePolicyRepository) AdjustLeaveBalance(balanceID int, days float64, notes string, adjustedBy int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		UPDATE leave_balances SET adjusted = adjusted + $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+	`, days, balanceID); err != nil {
+		return err
+	}
+	if err := addBalanceTransaction(tx, balanceID, nil, domain.BalanceAdjustment, days, notes, &adjustedBy); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ListBalanceTransactions retrieves the history of an employee's balances for a year, newest first
+func (r *LeavePolicyRepository) ListBalanceTransactions(tenantID, restaurantID, employeeID, year int) ([]domain.LeaveBalanceTransaction, error) {
+	rows, err := r.db.Query(`
+		SELECT t.id, t.balance_id, t.leave_id, t.kind, t.days, COALESCE(t.notes, ''), t.created_by, t.created_at
+		FROM leave_balance_transactions t
+		JOIN leave_balances b ON b.id = t.balance_id
+		WHERE b.tenant_id = $1 AND b.restaurant_id = $2 AND b.employee_id = $3 AND b.year = $4
+		ORDER BY t.created_at DESC, t.id DESC
+	`, tenantID, restaurantID, employeeID, year)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	transactions := []domain.LeaveBalanceTransaction{}
+	for rows.Next() {
+		var t domain.LeaveBalanceTransaction
+		var leaveID, createdBy sql.NullInt64
+		if err := rows.Scan(&t.ID, &t.BalanceID, &leaveID, &t.Kind, &t.Days, &t.Notes, &createdBy, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		t.LeaveID = nullIntPtr(leaveID)
+		t.CreatedBy = nullIntPtr(createdBy)
+		transactions = append(transactions, t)
+	}
+	return transactions, rows.Err()
+}
+
+func addBalanceTransaction(tx *sql.Tx, balanceID int, leaveID *int, kind string, days float64, notes string, createdBy *int) error {
+	_, err := tx.Exec(`
+		INSERT INTO leave_balance_transactions (balance_id, leave_id, kind, days, notes, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+	`, balanceID, leaveID, kind, days, notes, createdBy)
+	return err
+}
@@ -71,17 +71,20 @@ func (r *LeaveRepository) GetLeaveByID(tenantID, restaurantID, id int) (*domain.
 			reason, contact_number, contact_address, emergency_contact, attachments,
 			status, is_approved, approved_by, approved_at, approval_notes,
 			rejected_by, rejected_at, rejection_reason, hr_notes, handover_notes,
-			replacement_employee_id, handover_completed, created_at, updated_at
+			replacement_employee_id, handover_completed, current_approval_level,
+			cancelled_by, cancelled_at, cancellation_reason, COALESCE(deducted_from_balance, false),
+			balance_before, balance_after, created_at, updated_at
 		FROM leaves
 		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
 	`
 
 	var leave domain.Leave
 	var halfDayPeriod, contactNumber, contactAddress, emergencyContact sql.NullString
-	var approvalNotes, rejectionReason, hrNotes, handoverNotes sql.NullString
+	var approvalNotes, rejectionReason, hrNotes, handoverNotes, cancellationReason sql.NullString
 	var attachments sql.NullString
-	var approvedBy, rejectedBy, replacementEmployeeID sql.NullInt64
-	var approvedAt, rejectedAt sql.NullTime
+	var approvedBy, rejectedBy, cancelledBy, replacementEmployeeID sql.NullInt64
+	var approvedAt, rejectedAt, cancelledAt sql.NullTime
+	var balanceBefore, balanceAfter sql.NullFloat64
 
 	err := r.db.QueryRow(query, id, tenantID, restaurantID).Scan(
 		&leave.ID, &leave.TenantID, &leave.RestaurantID, &leave.EmployeeID,
@@ -90,7 +93,9 @@ func (r *LeaveRepository) GetLeaveByID(tenantID, restaurantID, id int) (*domain.
 		&contactNumber, &contactAddress, &emergencyContact, &attachments,
 		&leave.Status, &leave.IsApproved, &approvedBy, &approvedAt, &approvalNotes,
 		&rejectedBy, &rejectedAt, &rejectionReason, &hrNotes, &handoverNotes,
-		&replacementEmployeeID, &leave.HandoverCompleted, &leave.CreatedAt, &leave.UpdatedAt,
+		&replacementEmployeeID, &leave.HandoverCompleted, &leave.CurrentApprovalLevel,
+		&cancelledBy, &cancelledAt, &cancellationReason, &leave.DeductedFromBalance,
+		&balanceBefore, &balanceAfter, &leave.CreatedAt, &leave.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -108,6 +113,8 @@ func (r *LeaveRepository) GetLeaveByID(tenantID, restaurantID, id int) (*domain.
 	leave.RejectionReason = rejectionReason.String
 	leave.HRNotes = hrNotes.String
 	leave.HandoverNotes = handoverNotes.String
+	leave.CancellationReason = cancellationReason.String
+	leave.CancelledBy = nullIntPtr(cancelledBy)
 
 	if attachments.Valid {
 		leave.Attachments = []byte(attachments.String)
@@ -130,40 +137,127 @@ func (r *LeaveRepository) GetLeaveByID(tenantID, restaurantID, id int) (*domain.
 	if rejectedAt.Valid {
 		leave.RejectedAt = &rejectedAt.Time
 	}
+	if cancelledAt.Valid {
+		leave.CancelledAt = &cancelledAt.Time
+	}
+	if balanceBefore.Valid {
+		leave.BalanceBefore = &balanceBefore.Float64
+	}
+	if balanceAfter.Valid {
+		leave.BalanceAfter = &balanceAfter.Float64
+	}
+
+	if leave.Approvals, err = r.ListLeaveApprovals(leave.ID); err != nil {
+		return nil, err
+	}
 
 	return &leave, nil
 }
 
-// CreateLeave creates a new leave request
+// CreateLeave creates a new leave request together with its approval chain
 func (r *LeaveRepository) CreateLeave(leave *domain.Leave) (int, error) {
 	query := `
 		INSERT INTO leaves (
 			tenant_id, restaurant_id, employee_id, start_date, end_date,
 			total_days, is_half_day, half_day_period, leave_type, leave_category,
 			reason, contact_number, contact_address, emergency_contact,
-			status, created_by
+			status, created_by, current_approval_level, deducted_from_balance
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, 1, false
 		)
 		RETURNING id
 	`
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var halfDayPeriod *string
+	if leave.HalfDayPeriod != "" {
+		halfDayPeriod = &leave.HalfDayPeriod
+	}
+
 	var id int
-	err := r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		leave.TenantID, leave.RestaurantID, leave.EmployeeID, leave.StartDate,
-		leave.EndDate, leave.TotalDays, leave.IsHalfDay, leave.HalfDayPeriod,
+		leave.EndDate, leave.TotalDays, leave.IsHalfDay, halfDayPeriod,
 		leave.LeaveType, leave.LeaveCategory, leave.Reason, leave.ContactNumber,
 		leave.ContactAddress, leave.EmergencyContact, leave.Status, leave.CreatedBy,
 	).Scan(&id)
-
 	if err != nil {
 		return 0, err
 	}
 
+	for _, approval := range leave.Approvals {
+		if _, err := tx.Exec(`
+			INSERT INTO leave_approvals (leave_id, level, approver_employee_id, status)
+			VALUES ($1, $2, $3, 'pending')
+		`, id, approval.Level, approval.ApproverEmployeeID); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
 	return id, nil
 }
 
+// ListLeaveApprovals retrieves a leave's approval chain in level order
+func (r *LeaveRepository) ListLeaveApprovals(leaveID int) ([]domain.LeaveApproval, error) {
+	rows, err := r.db.Query(`
+		SELECT a.id, a.leave_id, a.level, a.approver_employee_id,
+		       COALESCE(e.first_name || ' ' || e.last_name, ''), a.status,
+		       a.acted_by, a.acted_at, COALESCE(a.notes, ''), a.created_at
+		FROM leave_approvals a
+		LEFT JOIN employees e ON e.id = a.approver_employee_id
+		WHERE a.leave_id = $1
+		ORDER BY a.level ASC
+	`, leaveID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	approvals := []domain.LeaveApproval{}
+	for rows.Next() {
+		var a domain.LeaveApproval
+		var approverID, actedBy sql.NullInt64
+		var actedAt sql.NullTime
+		err := rows.Scan(
+			&a.ID, &a.LeaveID, &a.Level, &approverID, &a.ApproverName, &a.Status,
+			&actedBy, &actedAt, &a.Notes, &a.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		a.ApproverEmployeeID = nullIntPtr(approverID)
+		a.ActedBy = nullIntPtr(actedBy)
+		if actedAt.Valid {
+			a.ActedAt = &actedAt.Time
+		}
+		approvals = append(approvals, a)
+	}
+	return approvals, rows.Err()
+}
+
+// HasOverlappingLeave reports whether the employee has pending or approved leave on any of the days
+func (r *LeaveRepository) HasOverlappingLeave(employeeID int, startDate, endDate time.Time) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM leaves
+			WHERE employee_id = $1 AND status IN ('pending', 'approved')
+			  AND start_date <= $3 AND end_date >= $2
+		)
+	`, employeeID, startDate, endDate).Scan(&exists)
+	return exists, err
+}
+
 // UpdateLeave updates an existing leave request
 func (r *LeaveRepository) UpdateLeave(leave *domain.Leave) error {
 	query := `
@@ -187,49 +281,220 @@ func (r *LeaveRepository) UpdateLeave(leave *domain.Leave) error {
 	return err
 }
 
-// ApproveLeave approves a leave request
-func (r *LeaveRepository) ApproveLeave(tenantID, restaurantID, id int, approvedBy int, notes string) error {
-	query := `
+// ApproveLeave records the approval of the leave's current level. When it is the last level
+// the leave is approved and, when balanceID is set, its days are taken from that balance.
+// Returns ErrLeaveNotPending when the level was already decided and ErrInsufficientLeaveBalance
+// when the balance no longer covers the leave.
+func (r *LeaveRepository) ApproveLeave(leave *domain.Leave, approvedBy int, notes string, balanceID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := decideApproval(tx, leave, domain.ApprovalApproved, approvedBy, notes); err != nil {
+		return err
+	}
+
+	if !leave.IsFinalApproval() {
+		result, err := tx.Exec(`
+			UPDATE leaves
+			SET current_approval_level = current_approval_level + 1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND current_approval_level = $2 AND status = 'pending'
+		`, leave.ID, leave.CurrentApprovalLevel)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return domain.ErrLeaveNotPending
+		}
+		return tx.Commit()
+	}
+
+	var balanceBefore, balanceAfter *float64
+	if balanceID != 0 {
+		var after float64
+		err := tx.QueryRow(`
+			UPDATE leave_balances SET used = used + $1, updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2
+			RETURNING carried_over + accrued + adjusted - used
+		`, leave.TotalDays, balanceID).Scan(&after)
+		if err != nil {
+			return err
+		}
+		if after < 0 {
+			return domain.ErrInsufficientLeaveBalance
+		}
+		before := after + leave.TotalDays
+		balanceBefore, balanceAfter = &before, &after
+		if err := addBalanceTransaction(tx, balanceID, &leave.ID, domain.BalanceUsage, -leave.TotalDays, "", &approvedBy); err != nil {
+			return err
+		}
+	}
+
+	result, err := tx.Exec(`
 		UPDATE leaves
 		SET
 			is_approved = true, status = 'approved', approved_by = $1,
 			approved_at = CURRENT_TIMESTAMP, approval_notes = $2,
+			deducted_from_balance = $3, balance_before = $4, balance_after = $5,
 			updated_at = CURRENT_TIMESTAMP
-		WHERE id = $3 AND tenant_id = $4 AND restaurant_id = $5 AND status = 'pending'
-	`
+		WHERE id = $6 AND tenant_id = $7 AND restaurant_id = $8 AND status = 'pending'
+	`, approvedBy, notes, balanceID != 0, balanceBefore, balanceAfter, leave.ID, leave.TenantID, leave.RestaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrLeaveNotPending
+	}
 
-	_, err := r.db.Exec(query, approvedBy, notes, id, tenantID, restaurantID)
-	return err
+	return tx.Commit()
 }
 
-// RejectLeave rejects a leave request
-func (r *LeaveRepository) RejectLeave(tenantID, restaurantID, id int, rejectedBy int, reason string) error {
-	query := `
+// RejectLeave rejects a leave request at its current level, ending the approval chain
+func (r *LeaveRepository) RejectLeave(leave *domain.Leave, rejectedBy int, reason string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := decideApproval(tx, leave, domain.ApprovalRejected, rejectedBy, reason); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`
+		UPDATE leave_approvals SET status = 'cancelled' WHERE leave_id = $1 AND status = 'pending'
+	`, leave.ID); err != nil {
+		return err
+	}
+
+	result, err := tx.Exec(`
 		UPDATE leaves
 		SET
 			is_approved = false, status = 'rejected', rejected_by = $1,
 			rejected_at = CURRENT_TIMESTAMP, rejection_reason = $2,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = $3 AND tenant_id = $4 AND restaurant_id = $5 AND status = 'pending'
-	`
+	`, rejectedBy, reason, leave.ID, leave.TenantID, leave.RestaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrLeaveNotPending
+	}
 
-	_, err := r.db.Exec(query, rejectedBy, reason, id, tenantID, restaurantID)
-	return err
+	return tx.Commit()
 }
 
-// CancelLeave cancels a leave request
-func (r *LeaveRepository) CancelLeave(tenantID, restaurantID, id int, cancelledBy int, reason string) error {
-	query := `
+// CancelLeave cancels a pending or approved leave request. When the approved leave was taken
+// from a balance (balanceID), the days are given back.
+func (r *LeaveRepository) CancelLeave(leave *domain.Leave, cancelledBy int, reason string, balanceID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
 		UPDATE leaves
 		SET
 			status = 'cancelled', cancelled_by = $1, cancelled_at = CURRENT_TIMESTAMP,
 			cancellation_reason = $2, updated_at = CURRENT_TIMESTAMP
 		WHERE id = $3 AND tenant_id = $4 AND restaurant_id = $5
 		  AND status IN ('pending', 'approved')
+	`, cancelledBy, reason, leave.ID, leave.TenantID, leave.RestaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrLeaveNotCancellable
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE leave_approvals SET status = 'cancelled' WHERE leave_id = $1 AND status = 'pending'
+	`, leave.ID); err != nil {
+		return err
+	}
+
+	if balanceID != 0 && leave.Status == "approved" && leave.DeductedFromBalance {
+		if _, err := tx.Exec(`
+			UPDATE leave_balances SET used = used - $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+		`, leave.TotalDays, balanceID); err != nil {
+			return err
+		}
+		if err := addBalanceTransaction(tx, balanceID, &leave.ID, domain.BalanceReversal, leave.TotalDays, reason, &cancelledBy); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// decideApproval marks the leave's current approval level as decided. Leaves without an
+// approval chain have nothing to record.
+func decideApproval(tx *sql.Tx, leave *domain.Leave, status string, userID int, notes string) error {
+	approval := leave.CurrentApproval()
+	if approval == nil {
+		if len(leave.Approvals) > 0 {
+			return domain.ErrLeaveNotPending
+		}
+		return nil
+	}
+	result, err := tx.Exec(`
+		UPDATE leave_approvals
+		SET status = $1, acted_by = $2, acted_at = CURRENT_TIMESTAMP, notes = NULLIF($3, '')
+		WHERE id = $4 AND status = 'pending'
+	`, status, userID, notes, approval.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrLeaveNotPending
+	}
+	return nil
+}
+
+// ListAwaitingApproval retrieves the pending leaves whose current level is assigned to the
+// approver employee. A zero approver lists the leaves waiting on HR: levels without an
+// approver and requests made before approval chains existed.
+func (r *LeaveRepository) ListAwaitingApproval(tenantID, restaurantID, approverEmployeeID int) ([]domain.Leave, error) {
+	query := `
+		SELECT
+			l.id, l.tenant_id, l.restaurant_id, l.employee_id, l.start_date, l.end_date,
+			l.total_days, l.is_half_day, l.leave_type, l.leave_category, l.reason,
+			l.status, l.current_approval_level, l.created_at
+		FROM leaves l
+		LEFT JOIN leave_approvals a ON a.leave_id = l.id AND a.level = l.current_approval_level
+		WHERE l.tenant_id = $1 AND l.restaurant_id = $2 AND l.status = 'pending'
+		  AND (
+			($3 != 0 AND a.approver_employee_id = $3)
+			OR ($3 = 0 AND a.approver_employee_id IS NULL)
+		  )
+		ORDER BY l.created_at ASC
 	`
 
-	_, err := r.db.Exec(query, cancelledBy, reason, id, tenantID, restaurantID)
-	return err
+	rows, err := r.db.Query(query, tenantID, restaurantID, approverEmployeeID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaves := []domain.Leave{}
+	for rows.Next() {
+		var leave domain.Leave
+		err := rows.Scan(
+			&leave.ID, &leave.TenantID, &leave.RestaurantID, &leave.EmployeeID,
+			&leave.StartDate, &leave.EndDate, &leave.TotalDays, &leave.IsHalfDay,
+			&leave.LeaveType, &leave.LeaveCategory, &leave.Reason,
+			&leave.Status, &leave.CurrentApprovalLevel, &leave.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leave)
+	}
+
+	return leaves, rows.Err()
 }
 
 // GetPendingLeaves retrieves all pending leave requests
@@ -311,3 +576,38 @@ func (r *LeaveRepository) GetEmployeeLeaves(tenantID, restaurantID, employeeID i
 
 	return leaves, nil
 }
+
+// ListApprovedLeaves retrieves approved leaves overlapping a date range
+func (r *LeaveRepository) ListApprovedLeaves(tenantID, restaurantID int, startDate, endDate time.Time) ([]domain.Leave, error) {
+	query := `
+		SELECT
+			id, tenant_id, restaurant_id, employee_id, start_date, end_date,
+			total_days, is_half_day, leave_type, leave_category, status, is_approved
+		FROM leaves
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND status = 'approved'
+		  AND start_date <= $4 AND end_date >= $3
+		ORDER BY start_date ASC
+	`
+
+	rows, err := r.db.Query(query, tenantID, restaurantID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	leaves := []domain.Leave{}
+	for rows.Next() {
+		var leave domain.Leave
+		err := rows.Scan(
+			&leave.ID, &leave.TenantID, &leave.RestaurantID, &leave.EmployeeID,
+			&leave.StartDate, &leave.EndDate, &leave.TotalDays, &leave.IsHalfDay,
+			&leave.LeaveType, &leave.LeaveCategory, &leave.Status, &leave.IsApproved,
+		)
+		if err != nil {
+			return nil, err
+		}
+		leaves = append(leaves, leave)
+	}
+
+	return leaves, rows.Err()
+}
@@ -0,0 +1,239 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"pos-saas/internal/domain"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// LocationRepository handles geofence events and the retention of driver location history
+type LocationRepository struct {
+	db *sql.DB
+}
+
+// NewLocationRepository creates new location repository
+func NewLocationRepository(db *sql.DB) *LocationRepository {
+	return &LocationRepository{db: db}
+}
+
+const geofenceEventColumns = `
+	id, assignment_id, driver_id, order_id, event, latitude, longitude, occurred_at
+`
+
+func scanGeofenceEvent(row rowScanner) (*domain.GeofenceEvent, error) {
+	e := &domain.GeofenceEvent{}
+	err := row.Scan(&e.ID, &e.AssignmentID, &e.DriverID, &e.OrderID, &e.Event, &e.Latitude, &e.Longitude, &e.OccurredAt)
+	if err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// RecordGeofenceEvent stores an event and stamps the assignment: picked_up sets started_at
+// and arrived_at_customer sets completed_at, unless they are already set.
+// Returns nil when the event was already recorded for the assignment.
+func (r *LocationRepository) RecordGeofenceEvent(e *domain.GeofenceEvent) (*domain.GeofenceEvent, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	row := tx.QueryRow(`
+		INSERT INTO driver_geofence_events (assignment_id, driver_id, order_id, event, latitude, longitude, occurred_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (assignment_id, event) DO NOTHING
+		RETURNING `+geofenceEventColumns,
+		e.AssignmentID, e.DriverID, e.OrderID, e.Event, e.Latitude, e.Longitude, e.OccurredAt,
+	)
+	created, err := scanGeofenceEvent(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to record geofence event: %w", err)
+	}
+
+	var column string
+	switch e.Event {
+	case domain.GeofencePickedUp:
+		column = "started_at"
+	case domain.GeofenceArrivedAtCustomer:
+		column = "completed_at"
+	}
+	if column != "" {
+		_, err = tx.Exec(`
+			UPDATE driver_assignments SET `+column+` = COALESCE(`+column+`, $1), updated_at = CURRENT_TIMESTAMP
+			WHERE id = $2
+		`, e.OccurredAt, e.AssignmentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update assignment times: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit geofence event: %w", err)
+	}
+	return created, nil
+}
+
+// ListEmittedEvents returns the events already recorded for each of the given assignments
+func (r *LocationRepository) ListEmittedEvents(assignmentIDs []int) (map[int]map[string]bool, error) {
+	emitted := make(map[int]map[string]bool, len(assignmentIDs))
+	if len(assignmentIDs) == 0 {
+		return emitted, nil
+	}
+
+	ids := make([]int64, len(assignmentIDs))
+	for i, id := range assignmentIDs {
+		ids[i] = int64(id)
+	}
+	rows, err := r.db.Query(`
+		SELECT assignment_id, event FROM driver_geofence_events WHERE assignment_id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query geofence events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var assignmentID int
+		var event string
+		if err := rows.Scan(&assignmentID, &event); err != nil {
+			return nil, err
+		}
+		if emitted[assignmentID] == nil {
+			emitted[assignmentID] = map[string]bool{}
+		}
+		emitted[assignmentID][event] = true
+	}
+	return emitted, rows.Err()
+}
+
+// ListOrderEvents returns an order's geofence events, oldest first
+func (r *LocationRepository) ListOrderEvents(tenantID, restaurantID, orderID int64) ([]domain.GeofenceEvent, error) {
+	rows, err := r.db.Query(`
+		SELECT e.id, e.assignment_id, e.driver_id, e.order_id, e.event, e.latitude, e.longitude, e.occurred_at
+		FROM driver_geofence_events e
+		JOIN orders o ON o.id = e.order_id
+		WHERE e.order_id = $1 AND o.tenant_id = $2 AND o.restaurant_id = $3
+		ORDER BY e.occurred_at ASC, e.id ASC
+	`, orderID, tenantID, restaurantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query geofence events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []domain.GeofenceEvent{}
+	for rows.Next() {
+		e, err := scanGeofenceEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan geofence event: %w", err)
+		}
+		events = append(events, *e)
+	}
+	return events, rows.Err()
+}
+
+// GetRetentionPolicy returns the tenant's retention policy, or the default when none is set
+func (r *LocationRepository) GetRetentionPolicy(tenantID int64) (*domain.LocationRetentionPolicy, error) {
+	p := &domain.LocationRetentionPolicy{}
+	var updatedAt time.Time
+	err := r.db.QueryRow(`
+		SELECT tenant_id, keep_full_days, downsample_interval_seconds, purge_after_days, updated_at
+		FROM location_retention_policies WHERE tenant_id = $1
+	`, tenantID).Scan(&p.TenantID, &p.KeepFullDays, &p.DownsampleIntervalSeconds, &p.PurgeAfterDays, &updatedAt)
+	if err == sql.ErrNoRows {
+		return domain.DefaultLocationRetentionPolicy(tenantID), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get retention policy: %w", err)
+	}
+	p.UpdatedAt = &updatedAt
+	return p, nil
+}
+
+// SaveRetentionPolicy creates or replaces the tenant's retention policy
+func (r *LocationRepository) SaveRetentionPolicy(p *domain.LocationRetentionPolicy) (*domain.LocationRetentionPolicy, error) {
+	var updatedAt time.Time
+	err := r.db.QueryRow(`
+		INSERT INTO location_retention_policies (tenant_id, keep_full_days, downsample_interval_seconds, purge_after_days, updated_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			keep_full_days = EXCLUDED.keep_full_days,
+			downsample_interval_seconds = EXCLUDED.downsample_interval_seconds,
+			purge_after_days = EXCLUDED.purge_after_days,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`, p.TenantID, p.KeepFullDays, p.DownsampleIntervalSeconds, p.PurgeAfterDays).Scan(&updatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save retention policy: %w", err)
+	}
+	p.UpdatedAt = &updatedAt
+	return p, nil
+}
+
+// ListRetentionPolicies returns the policy of every tenant with drivers, defaults included
+func (r *LocationRepository) ListRetentionPolicies() ([]domain.LocationRetentionPolicy, error) {
+	rows, err := r.db.Query(`
+		SELECT t.tenant_id,
+			COALESCE(p.keep_full_days, $1), COALESCE(p.downsample_interval_seconds, $2), COALESCE(p.purge_after_days, $3)
+		FROM (SELECT DISTINCT tenant_id FROM drivers) t
+		LEFT JOIN location_retention_policies p ON p.tenant_id = t.tenant_id
+		ORDER BY t.tenant_id
+	`, domain.DefaultKeepFullDays, domain.DefaultDownsampleIntervalSeconds, domain.DefaultPurgeAfterDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query retention policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []domain.LocationRetentionPolicy{}
+	for rows.Next() {
+		var p domain.LocationRetentionPolicy
+		if err := rows.Scan(&p.TenantID, &p.KeepFullDays, &p.DownsampleIntervalSeconds, &p.PurgeAfterDays); err != nil {
+			return nil, err
+		}
+		policies = append(policies, p)
+	}
+	return policies, rows.Err()
+}
+
+// PurgeLocations deletes a tenant's driver locations recorded before the cutoff
+func (r *LocationRepository) PurgeLocations(tenantID int64, before time.Time) (int64, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM driver_location_history h
+		USING drivers d
+		WHERE d.id = h.driver_id AND d.tenant_id = $1 AND h.recorded_at < $2
+	`, tenantID, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge locations: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// DownsampleLocations keeps the first of a tenant's driver locations in each interval
+// between from and before and deletes the rest. Running it again removes nothing new.
+func (r *LocationRepository) DownsampleLocations(tenantID int64, from, before time.Time, intervalSeconds int) (int64, error) {
+	result, err := r.db.Exec(`
+		DELETE FROM driver_location_history
+		WHERE id IN (
+			SELECT id FROM (
+				SELECT h.id, ROW_NUMBER() OVER (
+					PARTITION BY h.driver_id, FLOOR(EXTRACT(EPOCH FROM h.recorded_at) / $4)
+					ORDER BY h.recorded_at, h.id
+				) AS rn
+				FROM driver_location_history h
+				JOIN drivers d ON d.id = h.driver_id
+				WHERE d.tenant_id = $1 AND h.recorded_at >= $2 AND h.recorded_at < $3
+			) ranked
+			WHERE rn > 1
+		)
+	`, tenantID, from, before, intervalSeconds)
+	if err != nil {
+		return 0, fmt.Errorf("failed to downsample locations: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-saas/internal/domain"
+)
+
+// LoginThrottleRepository stores failed sign-in counters per account and per IP
+type LoginThrottleRepository struct {
+	db *sql.DB
+}
+
+// NewLoginThrottleRepository creates a new login throttle repository
+func NewLoginThrottleRepository(db *sql.DB) *LoginThrottleRepository {
+	return &LoginThrottleRepository{db: db}
+}
+
+// Get returns the throttle of a key, nil when it has no failures. Failures older than the
+// failure window are not counted.
+func (r *LoginThrottleRepository) Get(ctx context.Context, scope, key string) (*domain.LoginThrottle, error) {
+	// In stub mode, logins are not throttled
+	if r.db == nil {
+		return nil, nil
+	}
+
+	t := domain.LoginThrottle{Scope: scope, Key: key}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT CASE WHEN last_failure_at > CURRENT_TIMESTAMP - make_interval(secs => $3) THEN failures ELSE 0 END,
+		       last_failure_at, blocked_until, locked_until
+		FROM login_throttles WHERE scope = $1 AND key = $2
+	`, scope, key, domain.LoginFailureWindow.Seconds()).Scan(&t.Failures, &t.LastFailureAt, &t.BlockedUntil, &t.LockedUntil)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get login throttle: %w", err)
+	}
+	return &t, nil
+}
+
+// RecordFailure counts a failed sign-in and returns the updated throttle. The count starts
+// over when the previous failure is outside the window or an earlier lockout has expired.
+func (r *LoginThrottleRepository) RecordFailure(ctx context.Context, scope, key string) (*domain.LoginThrottle, error) {
+	if r.db == nil {
+		return &domain.LoginThrottle{Scope: scope, Key: key}, nil
+	}
+
+	t := domain.LoginThrottle{Scope: scope, Key: key}
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO login_throttles (scope, key, failures, last_failure_at)
+		VALUES ($1, $2, 1, CURRENT_TIMESTAMP)
+		ON CONFLICT (scope, key) DO UPDATE SET
+			failures = CASE
+				WHEN login_throttles.last_failure_at IS NULL
+				  OR login_throttles.last_failure_at <= CURRENT_TIMESTAMP - make_interval(secs => $3)
+				  OR login_throttles.locked_until <= CURRENT_TIMESTAMP
+				THEN 1 ELSE login_throttles.failures + 1 END,
+			locked_until = CASE
+				WHEN login_throttles.locked_until <= CURRENT_TIMESTAMP THEN NULL
+				ELSE login_throttles.locked_until END,
+			last_failure_at = CURRENT_TIMESTAMP
+		RETURNING failures, last_failure_at, blocked_until, locked_until
+	`, scope, key, domain.LoginFailureWindow.Seconds()).Scan(&t.Failures, &t.LastFailureAt, &t.BlockedUntil, &t.LockedUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record login failure: %w", err)
+	}
+	return &t, nil
+}
+
+// SetBlock refuses attempts on a key until blockedUntil and, for accounts, locks it until
+// lockedUntil. A nil lockedUntil keeps the current lock.
+func (r *LoginThrottleRepository) SetBlock(ctx context.Context, scope, key string, blockedUntil time.Time, lockedUntil *time.Time) error {
+	if r.db == nil {
+		return nil
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE login_throttles
+		SET blocked_until = $3, locked_until = COALESCE($4, locked_until)
+		WHERE scope = $1 AND key = $2
+	`, scope, key, blockedUntil, lockedUntil)
+	if err != nil {
+		return fmt.Errorf("failed to block login: %w", err)
+	}
+	return nil
+}
+
+// Clear forgets the failures of a key, which also lifts a lockout
+func (r *LoginThrottleRepository) Clear(ctx context.Context, scope, key string) error {
+	if r.db == nil {
+		return nil
+	}
+
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM login_throttles WHERE scope = $1 AND key = $2`, scope, key); err != nil {
+		return fmt.Errorf("failed to clear login throttle: %w", err)
+	}
+	return nil
+}
@@ -22,7 +22,8 @@ func NewModuleDefinitionRepository(db *sql.DB) *ModuleDefinitionRepository {
 // GetAll retrieves all active modules
 func (r *ModuleDefinitionRepository) GetAll(ctx context.Context) ([]domain.ModuleDefinition, error) {
 	query := `
-		SELECT id, name, display_name, description, icon, path, is_active, created_at, updated_at
+		SELECT id, name, display_name, COALESCE(description, ''), COALESCE(icon, ''), COALESCE(path, ''),
+		       is_active, created_at, updated_at
 		FROM modules
 		WHERE is_active = TRUE
 		ORDER BY name ASC
@@ -64,7 +65,8 @@ func (r *ModuleDefinitionRepository) GetAll(ctx context.Context) ([]domain.Modul
 // GetAllIncludingInactive retrieves all modules including inactive ones
 func (r *ModuleDefinitionRepository) GetAllIncludingInactive(ctx context.Context) ([]domain.ModuleDefinition, error) {
 	query := `
-		SELECT id, name, display_name, description, icon, path, is_active, created_at, updated_at
+		SELECT id, name, display_name, COALESCE(description, ''), COALESCE(icon, ''), COALESCE(path, ''),
+		       is_active, created_at, updated_at
 		FROM modules
 		ORDER BY is_active DESC, name ASC
 	`
@@ -105,7 +107,8 @@ func (r *ModuleDefinitionRepository) GetAllIncludingInactive(ctx context.Context
 // GetByID retrieves a module by ID
 func (r *ModuleDefinitionRepository) GetByID(ctx context.Context, moduleID int64) (*domain.ModuleDefinition, error) {
 	query := `
-		SELECT id, name, display_name, description, icon, path, is_active, created_at, updated_at
+		SELECT id, name, display_name, COALESCE(description, ''), COALESCE(icon, ''), COALESCE(path, ''),
+		       is_active, created_at, updated_at
 		FROM modules
 		WHERE id = $1
 	`
@@ -127,7 +130,7 @@ func (r *ModuleDefinitionRepository) GetByID(ctx context.Context, moduleID int64
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("module not found")
+			return nil, domain.ErrModuleNotFound
 		}
 		return nil, err
 	}
@@ -138,7 +141,8 @@ func (r *ModuleDefinitionRepository) GetByID(ctx context.Context, moduleID int64
 // GetByName retrieves a module by name
 func (r *ModuleDefinitionRepository) GetByName(ctx context.Context, name string) (*domain.ModuleDefinition, error) {
 	query := `
-		SELECT id, name, display_name, description, icon, path, is_active, created_at, updated_at
+		SELECT id, name, display_name, COALESCE(description, ''), COALESCE(icon, ''), COALESCE(path, ''),
+		       is_active, created_at, updated_at
 		FROM modules
 		WHERE name = $1
 	`
@@ -160,7 +164,7 @@ func (r *ModuleDefinitionRepository) GetByName(ctx context.Context, name string)
 
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, errors.New("module not found")
+			return nil, domain.ErrModuleNotFound
 		}
 		return nil, err
 	}
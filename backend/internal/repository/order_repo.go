@@ -29,11 +29,16 @@ func (r *OrderRepository) CreateOrder(order *domain.Order) (*domain.Order, error
 			delivery_zip_code, delivery_latitude, delivery_longitude,
 			delivery_instructions, subtotal, tax_amount, discount_amount,
 			delivery_fee, total_amount, payment_method, payment_status,
-			status, estimated_delivery_time, notes, order_source
-		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
-			$16, $17, $18, $19, $20, $21, $22, $23, $24
+			status, estimated_delivery_time, notes, order_source,
+			table_id, tab_id, tip_amount
 		)
+		SELECT
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+			$16, $17, $18, $19, $20, $21, $22, $23, $24, $25, $26, $27
+		-- A round can only go onto a tab that is still open; the share lock makes a
+		-- concurrent close wait for this insert, or this insert see the closed tab
+		WHERE $26::bigint IS NULL
+			OR EXISTS (SELECT 1 FROM table_tabs WHERE id = $26 AND status = 'open' FOR SHARE)
 		RETURNING id, created_at, updated_at
 	`
 
@@ -99,9 +104,15 @@ func (r *OrderRepository) CreateOrder(order *domain.Order) (*domain.Order, error
 		estimatedDeliveryTime,
 		order.Notes,
 		order.OrderSource,
+		order.TableID,
+		order.TabID,
+		order.TipAmount,
 	).Scan(&order.ID, &order.CreatedAt, &order.UpdatedAt)
 
 	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrTabNotOpen
+		}
 		if strings.Contains(err.Error(), "duplicate key") {
 			return nil, errors.New("order with this order number already exists")
 		}
@@ -121,7 +132,7 @@ func (r *OrderRepository) GetOrderByID(tenantID, restaurantID, orderID int64) (*
 			delivery_instructions, subtotal, tax_amount, discount_amount,
 			delivery_fee, total_amount, payment_method, payment_status,
 			status, estimated_delivery_time, actual_delivery_time, notes, order_source,
-			created_at, updated_at
+			table_id, tab_id, tip_amount, created_at, updated_at
 		FROM orders
 		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
 	`
@@ -131,6 +142,7 @@ func (r *OrderRepository) GetOrderByID(tenantID, restaurantID, orderID int64) (*
 	var deliveryLatitude, deliveryLongitude sql.NullFloat64
 	var paymentMethod, notes sql.NullString
 	var estimatedDeliveryTime, actualDeliveryTime sql.NullTime
+	var tableID, tabID sql.NullInt64
 
 	err := r.db.QueryRow(query, orderID, tenantID, restaurantID).Scan(
 		&order.ID, &order.TenantID, &order.RestaurantID, &order.OrderNumber,
@@ -140,7 +152,7 @@ func (r *OrderRepository) GetOrderByID(tenantID, restaurantID, orderID int64) (*
 		&deliveryInstructions, &order.Subtotal, &order.TaxAmount, &order.DiscountAmount,
 		&order.DeliveryFee, &order.TotalAmount, &paymentMethod, &order.PaymentStatus,
 		&order.Status, &estimatedDeliveryTime, &actualDeliveryTime, &notes, &order.OrderSource,
-		&order.CreatedAt, &order.UpdatedAt,
+		&tableID, &tabID, &order.TipAmount, &order.CreatedAt, &order.UpdatedAt,
 	)
 
 	if err != nil {
@@ -187,6 +199,12 @@ func (r *OrderRepository) GetOrderByID(tenantID, restaurantID, orderID int64) (*
 	if notes.Valid {
 		order.Notes = notes.String
 	}
+	if tableID.Valid {
+		order.TableID = &tableID.Int64
+	}
+	if tabID.Valid {
+		order.TabID = &tabID.Int64
+	}
 
 	// Get order items
 	items, err := r.GetOrderItems(tenantID, orderID)
@@ -788,3 +806,63 @@ func (r *OrderRepository) DeleteOrder(tenantID, restaurantID, orderID int64) err
 	// We prefer to cancel orders rather than delete them for audit trail
 	return r.CancelOrder(tenantID, restaurantID, orderID, "Deleted by admin")
 }
+
+// GetDeliveryDriver returns the driver currently delivering an order, or nil if none is assigned
+func (r *OrderRepository) GetDeliveryDriver(orderID int64) (*domain.TrackingDriver, error) {
+	query := `
+		SELECT d.first_name, COALESCE(d.vehicle_type, ''), d.current_latitude, d.current_longitude, d.last_active
+		FROM driver_assignments da
+		JOIN drivers d ON d.id = da.driver_id
+		WHERE da.order_id = $1 AND da.status IN ('pending', 'accepted', 'in_progress')
+		ORDER BY da.assigned_at DESC
+		LIMIT 1
+	`
+
+	driver := &domain.TrackingDriver{}
+	var lat, lon sql.NullFloat64
+	var lastActive sql.NullTime
+	err := r.db.QueryRow(query, orderID).Scan(&driver.FirstName, &driver.VehicleType, &lat, &lon, &lastActive)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery driver: %w", err)
+	}
+
+	if lat.Valid && lon.Valid {
+		driver.Latitude = &lat.Float64
+		driver.Longitude = &lon.Float64
+	}
+	if lastActive.Valid {
+		driver.UpdatedAt = &lastActive.Time
+	}
+	return driver, nil
+}
+
+// SetDeliveryPIN stores the code the customer gives the driver at the door
+func (r *OrderRepository) SetDeliveryPIN(orderID int64, pin string) error {
+	_, err := r.db.Exec(`UPDATE orders SET delivery_pin = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, pin, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to set delivery PIN: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveryPIN returns the delivery PIN of an order, empty if none was generated
+func (r *OrderRepository) GetDeliveryPIN(orderID int64) (string, error) {
+	var pin sql.NullString
+	err := r.db.QueryRow(`SELECT delivery_pin FROM orders WHERE id = $1`, orderID).Scan(&pin)
+	if err != nil {
+		return "", fmt.Errorf("failed to get delivery PIN: %w", err)
+	}
+	return pin.String, nil
+}
+
+// UpdateEstimatedDeliveryTime stores the planned arrival time of an order
+func (r *OrderRepository) UpdateEstimatedDeliveryTime(orderID int64, eta time.Time) error {
+	_, err := r.db.Exec(`UPDATE orders SET estimated_delivery_time = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`, eta, orderID)
+	if err != nil {
+		return fmt.Errorf("failed to update estimated delivery time: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,596 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+
+	"pos-saas/internal/domain"
+)
+
+// PayrollRepository handles payroll runs, payroll settings and deduction rules
+type PayrollRepository struct {
+	db *sql.DB
+}
+
+// NewPayrollRepository creates a new payroll repository
+func NewPayrollRepository(db *sql.DB) *PayrollRepository {
+	return &PayrollRepository{db: db}
+}
+
+// GetPayrollSettings retrieves a restaurant's payroll settings, or the defaults when none are saved
+func (r *PayrollRepository) GetPayrollSettings(tenantID, restaurantID int) (*domain.PayrollSettings, error) {
+	query := `
+		SELECT tenant_id, restaurant_id, overtime_multiplier, working_days_per_month,
+		       tip_pool_method, payment_method, updated_at, updated_by
+		FROM payroll_settings
+		WHERE tenant_id = $1 AND restaurant_id = $2
+	`
+
+	var s domain.PayrollSettings
+	var updatedBy sql.NullInt64
+	err := r.db.QueryRow(query, tenantID, restaurantID).Scan(
+		&s.TenantID, &s.RestaurantID, &s.OvertimeMultiplier, &s.WorkingDaysPerMonth,
+		&s.TipPoolMethod, &s.PaymentMethod, &s.UpdatedAt, &updatedBy,
+	)
+	if err == sql.ErrNoRows {
+		return domain.DefaultPayrollSettings(tenantID, restaurantID), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if updatedBy.Valid {
+		id := int(updatedBy.Int64)
+		s.UpdatedBy = &id
+	}
+	return &s, nil
+}
+
+// SavePayrollSettings creates or replaces a restaurant's payroll settings
+func (r *PayrollRepository) SavePayrollSettings(s *domain.PayrollSettings) error {
+	query := `
+		INSERT INTO payroll_settings (
+			restaurant_id, tenant_id, overtime_multiplier, working_days_per_month,
+			tip_pool_method, payment_method, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (restaurant_id)
+		DO UPDATE SET overtime_multiplier = EXCLUDED.overtime_multiplier,
+		    working_days_per_month = EXCLUDED.working_days_per_month,
+		    tip_pool_method = EXCLUDED.tip_pool_method,
+		    payment_method = EXCLUDED.payment_method,
+		    updated_by = EXCLUDED.updated_by, updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`
+
+	return r.db.QueryRow(
+		query,
+		s.RestaurantID, s.TenantID, s.OvertimeMultiplier, s.WorkingDaysPerMonth,
+		s.TipPoolMethod, s.PaymentMethod, s.UpdatedBy,
+	).Scan(&s.UpdatedAt)
+}
+
+const deductionRuleColumns = `
+	id, tenant_id, restaurant_id, name, rule_type, component, rate, amount,
+	min_income, max_income, max_deduction, is_active, created_at, updated_at
+`
+
+func scanDeductionRule(row rowScanner) (*domain.DeductionRule, error) {
+	var rule domain.DeductionRule
+	var maxIncome, maxDeduction sql.NullFloat64
+	err := row.Scan(
+		&rule.ID, &rule.TenantID, &rule.RestaurantID, &rule.Name, &rule.RuleType, &rule.Component,
+		&rule.Rate, &rule.Amount, &rule.MinIncome, &maxIncome, &maxDeduction, &rule.IsActive,
+		&rule.CreatedAt, &rule.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if maxIncome.Valid {
+		rule.MaxIncome = &maxIncome.Float64
+	}
+	if maxDeduction.Valid {
+		rule.MaxDeduction = &maxDeduction.Float64
+	}
+	return &rule, nil
+}
+
+// ListDeductionRules retrieves a restaurant's deduction rules, optionally only the active ones
+func (r *PayrollRepository) ListDeductionRules(tenantID, restaurantID int, activeOnly bool) ([]domain.DeductionRule, error) {
+	query := `SELECT ` + deductionRuleColumns + `
+		FROM payroll_deduction_rules
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND (is_active = true OR NOT $3)
+		ORDER BY component ASC, min_income ASC, id ASC
+	`
+
+	rows, err := r.db.Query(query, tenantID, restaurantID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rules := []domain.DeductionRule{}
+	for rows.Next() {
+		rule, err := scanDeductionRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, *rule)
+	}
+	return rules, rows.Err()
+}
+
+// GetDeductionRule retrieves a deduction rule.
+// Returns ErrDeductionRuleNotFound when the rule does not belong to the restaurant.
+func (r *PayrollRepository) GetDeductionRule(tenantID, restaurantID, id int) (*domain.DeductionRule, error) {
+	rule, err := scanDeductionRule(r.db.QueryRow(`SELECT `+deductionRuleColumns+`
+		FROM payroll_deduction_rules
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrDeductionRuleNotFound
+	}
+	return rule, err
+}
+
+// CreateDeductionRule creates a deduction rule
+func (r *PayrollRepository) CreateDeductionRule(rule *domain.DeductionRule) (*domain.DeductionRule, error) {
+	query := `
+		INSERT INTO payroll_deduction_rules (
+			tenant_id, restaurant_id, name, rule_type, component, rate, amount,
+			min_income, max_income, max_deduction, is_active
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING ` + deductionRuleColumns
+
+	return scanDeductionRule(r.db.QueryRow(
+		query,
+		rule.TenantID, rule.RestaurantID, rule.Name, rule.RuleType, rule.Component, rule.Rate,
+		rule.Amount, rule.MinIncome, rule.MaxIncome, rule.MaxDeduction, rule.IsActive,
+	))
+}
+
+// UpdateDeductionRule replaces a deduction rule.
+// Returns ErrDeductionRuleNotFound when the rule does not belong to the restaurant.
+func (r *PayrollRepository) UpdateDeductionRule(rule *domain.DeductionRule) (*domain.DeductionRule, error) {
+	query := `
+		UPDATE payroll_deduction_rules
+		SET name = $1, rule_type = $2, component = $3, rate = $4, amount = $5, min_income = $6,
+		    max_income = $7, max_deduction = $8, is_active = $9, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $10 AND tenant_id = $11 AND restaurant_id = $12
+		RETURNING ` + deductionRuleColumns
+
+	updated, err := scanDeductionRule(r.db.QueryRow(
+		query,
+		rule.Name, rule.RuleType, rule.Component, rule.Rate, rule.Amount, rule.MinIncome,
+		rule.MaxIncome, rule.MaxDeduction, rule.IsActive, rule.ID, rule.TenantID, rule.RestaurantID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrDeductionRuleNotFound
+	}
+	return updated, err
+}
+
+// DeleteDeductionRule deletes a deduction rule
+func (r *PayrollRepository) DeleteDeductionRule(tenantID, restaurantID, id int) error {
+	result, err := r.db.Exec(`
+		DELETE FROM payroll_deduction_rules WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrDeductionRuleNotFound
+	}
+	return nil
+}
+
+// SumOrderTips totals the tips on the restaurant's orders placed in the period, excluding cancelled orders
+func (r *PayrollRepository) SumOrderTips(tenantID, restaurantID int, start, end time.Time) (float64, error) {
+	var total float64
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(tip_amount), 0)
+		FROM orders
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND status != 'cancelled'
+		  AND created_at >= $3::date AND created_at < $4::date + INTERVAL '1 day'
+	`, tenantID, restaurantID, start, end).Scan(&total)
+	return total, err
+}
+
+// HasOverlappingRun reports whether a run that is not cancelled already covers part of the period
+func (r *PayrollRepository) HasOverlappingRun(tenantID, restaurantID int, start, end time.Time, excludeID int) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (
+			SELECT 1 FROM payroll_runs
+			WHERE tenant_id = $1 AND restaurant_id = $2 AND status != 'cancelled'
+			  AND period_start <= $4 AND period_end >= $3 AND id != $5
+		)
+	`, tenantID, restaurantID, start, end, excludeID).Scan(&exists)
+	return exists, err
+}
+
+const payrollRunColumns = `
+	id, tenant_id, restaurant_id, period_start, period_end, status, tip_pool, employee_count,
+	total_gross, total_deductions, total_net, notes, created_by, approved_by, approved_at,
+	paid_by, paid_at, created_at, updated_at
+`
+
+func scanPayrollRun(row rowScanner) (*domain.PayrollRun, error) {
+	var run domain.PayrollRun
+	var notes sql.NullString
+	var createdBy, approvedBy, paidBy sql.NullInt64
+	var approvedAt, paidAt sql.NullTime
+	err := row.Scan(
+		&run.ID, &run.TenantID, &run.RestaurantID, &run.PeriodStart, &run.PeriodEnd, &run.Status,
+		&run.TipPool, &run.EmployeeCount, &run.TotalGross, &run.TotalDeductions, &run.TotalNet,
+		&notes, &createdBy, &approvedBy, &approvedAt, &paidBy, &paidAt, &run.CreatedAt, &run.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	run.Notes = notes.String
+	run.CreatedBy = nullIntPtr(createdBy)
+	run.ApprovedBy = nullIntPtr(approvedBy)
+	run.PaidBy = nullIntPtr(paidBy)
+	if approvedAt.Valid {
+		run.ApprovedAt = &approvedAt.Time
+	}
+	if paidAt.Valid {
+		run.PaidAt = &paidAt.Time
+	}
+	return &run, nil
+}
+
+// ListPayrollRuns retrieves the restaurant's payroll runs, newest period first
+func (r *PayrollRepository) ListPayrollRuns(tenantID, restaurantID int) ([]domain.PayrollRun, error) {
+	query := `SELECT ` + payrollRunColumns + `
+		FROM payroll_runs
+		WHERE tenant_id = $1 AND restaurant_id = $2
+		ORDER BY period_start DESC, id DESC
+	`
+
+	rows, err := r.db.Query(query, tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	runs := []domain.PayrollRun{}
+	for rows.Next() {
+		run, err := scanPayrollRun(rows)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, *run)
+	}
+	return runs, rows.Err()
+}
+
+// GetPayrollRun retrieves a payroll run. Returns ErrPayrollRunNotFound when it does not exist.
+func (r *PayrollRepository) GetPayrollRun(tenantID, restaurantID, id int) (*domain.PayrollRun, error) {
+	query := `SELECT ` + payrollRunColumns + `
+		FROM payroll_runs
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`
+
+	run, err := scanPayrollRun(r.db.QueryRow(query, id, tenantID, restaurantID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrPayrollRunNotFound
+	}
+	return run, err
+}
+
+// CreatePayrollRun stores a draft run together with its generated salaries
+func (r *PayrollRepository) CreatePayrollRun(run *domain.PayrollRun, salaries []domain.Salary) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRow(`
+		INSERT INTO payroll_runs (
+			tenant_id, restaurant_id, period_start, period_end, status, tip_pool, employee_count,
+			total_gross, total_deductions, total_net, notes, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), $12)
+		RETURNING id, created_at, updated_at
+	`,
+		run.TenantID, run.RestaurantID, run.PeriodStart, run.PeriodEnd, run.Status, run.TipPool,
+		run.EmployeeCount, run.TotalGross, run.TotalDeductions, run.TotalNet, run.Notes, run.CreatedBy,
+	).Scan(&run.ID, &run.CreatedAt, &run.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	if err := insertRunSalaries(tx, run, salaries); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReplacePayrollRunSalaries swaps a draft run's salaries for freshly generated ones and updates its totals
+func (r *PayrollRepository) ReplacePayrollRunSalaries(run *domain.PayrollRun, salaries []domain.Salary) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM salaries WHERE payroll_run_id = $1`, run.ID); err != nil {
+		return err
+	}
+	if err := insertRunSalaries(tx, run, salaries); err != nil {
+		return err
+	}
+	if err := updateRunTotals(tx, run); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func insertRunSalaries(tx *sql.Tx, run *domain.PayrollRun, salaries []domain.Salary) error {
+	stmt, err := tx.Prepare(`
+		INSERT INTO salaries (
+			tenant_id, restaurant_id, employee_id, payroll_run_id, pay_period_start, pay_period_end,
+			month, year, base_salary, currency, overtime_hours, overtime_rate, overtime_amount,
+			bonus, commission, allowances, tips, other_earnings, earnings_details,
+			tax, social_insurance, health_insurance, pension, loan_deduction, advance_deduction,
+			other_deductions, deductions_details, gross_salary, total_deductions, net_salary,
+			days_worked, days_absent, total_hours_worked, total_overtime_hours,
+			payment_method, status, notes, calculation_notes, created_by
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19,
+			$20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34, $35, $36,
+			NULLIF($37, ''), $38, $39
+		)
+		RETURNING id, created_at, updated_at
+	`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for i := range salaries {
+		sal := &salaries[i]
+		sal.PayrollRunID = &run.ID
+		err := stmt.QueryRow(
+			sal.TenantID, sal.RestaurantID, sal.EmployeeID, run.ID, sal.PayPeriodStart, sal.PayPeriodEnd,
+			sal.Month, sal.Year, sal.BaseSalary, sal.Currency, sal.OvertimeHours, sal.OvertimeRate,
+			sal.OvertimeAmount, sal.Bonus, sal.Commission, sal.Allowances, sal.Tips, sal.OtherEarnings,
+			jsonOrEmpty(sal.EarningsDetails), sal.Tax, sal.SocialInsurance, sal.HealthInsurance,
+			sal.Pension, sal.LoanDeduction, sal.AdvanceDeduction, sal.OtherDeductions,
+			jsonOrEmpty(sal.DeductionsDetails), sal.GrossSalary, sal.TotalDeductions, sal.NetSalary,
+			sal.DaysWorked, sal.DaysAbsent, sal.TotalHoursWorked, sal.TotalOvertimeHours,
+			sal.PaymentMethod, sal.Status, sal.Notes, sal.CalculationNotes, run.CreatedBy,
+		).Scan(&sal.ID, &sal.CreatedAt, &sal.UpdatedAt)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return domain.ErrPayrollSalaryExists
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func updateRunTotals(tx *sql.Tx, run *domain.PayrollRun) error {
+	return tx.QueryRow(`
+		UPDATE payroll_runs
+		SET tip_pool = $1, employee_count = $2, total_gross = $3, total_deductions = $4,
+		    total_net = $5, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $6
+		RETURNING updated_at
+	`, run.TipPool, run.EmployeeCount, run.TotalGross, run.TotalDeductions, run.TotalNet, run.ID).Scan(&run.UpdatedAt)
+}
+
+const runSalaryColumns = `
+	s.id, s.tenant_id, s.restaurant_id, s.employee_id, e.first_name || ' ' || e.last_name,
+	s.payroll_run_id, s.pay_period_start, s.pay_period_end, s.month, s.year, s.base_salary,
+	COALESCE(s.currency, ''), s.overtime_hours, s.overtime_rate, s.overtime_amount, s.bonus,
+	s.commission, s.allowances, s.tips, s.other_earnings, s.earnings_details, s.tax,
+	s.social_insurance, s.health_insurance, s.pension, s.loan_deduction, s.advance_deduction,
+	s.other_deductions, s.deductions_details, s.gross_salary, s.total_deductions, s.net_salary,
+	s.days_worked, s.days_absent, s.total_hours_worked, s.total_overtime_hours, s.payment_method,
+	s.payment_reference, s.status, s.is_paid, s.paid_at, s.is_approved, s.approved_by,
+	s.approved_at, s.notes, s.calculation_notes, s.created_at, s.updated_at
+`
+
+func scanRunSalary(row rowScanner) (*domain.Salary, error) {
+	var sal domain.Salary
+	var runID, approvedBy sql.NullInt64
+	var earningsDetails, deductionsDetails, paymentRef, notes, calcNotes sql.NullString
+	var paidAt, approvedAt sql.NullTime
+	err := row.Scan(
+		&sal.ID, &sal.TenantID, &sal.RestaurantID, &sal.EmployeeID, &sal.EmployeeName,
+		&runID, &sal.PayPeriodStart, &sal.PayPeriodEnd, &sal.Month, &sal.Year, &sal.BaseSalary,
+		&sal.Currency, &sal.OvertimeHours, &sal.OvertimeRate, &sal.OvertimeAmount, &sal.Bonus,
+		&sal.Commission, &sal.Allowances, &sal.Tips, &sal.OtherEarnings, &earningsDetails, &sal.Tax,
+		&sal.SocialInsurance, &sal.HealthInsurance, &sal.Pension, &sal.LoanDeduction, &sal.AdvanceDeduction,
+		&sal.OtherDeductions, &deductionsDetails, &sal.GrossSalary, &sal.TotalDeductions, &sal.NetSalary,
+		&sal.DaysWorked, &sal.DaysAbsent, &sal.TotalHoursWorked, &sal.TotalOvertimeHours, &sal.PaymentMethod,
+		&paymentRef, &sal.Status, &sal.IsPaid, &paidAt, &sal.IsApproved, &approvedBy,
+		&approvedAt, &notes, &calcNotes, &sal.CreatedAt, &sal.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	sal.PayrollRunID = nullIntPtr(runID)
+	sal.ApprovedBy = nullIntPtr(approvedBy)
+	if earningsDetails.Valid {
+		sal.EarningsDetails = []byte(earningsDetails.String)
+	}
+	if deductionsDetails.Valid {
+		sal.DeductionsDetails = []byte(deductionsDetails.String)
+	}
+	sal.PaymentReference = paymentRef.String
+	sal.Notes = notes.String
+	sal.CalculationNotes = calcNotes.String
+	if paidAt.Valid {
+		sal.PaidAt = &paidAt.Time
+	}
+	if approvedAt.Valid {
+		sal.ApprovedAt = &approvedAt.Time
+	}
+	return &sal, nil
+}
+
+// ListRunSalaries retrieves the salaries generated by a payroll run with the employees' names
+func (r *PayrollRepository) ListRunSalaries(runID int) ([]domain.Salary, error) {
+	query := `SELECT ` + runSalaryColumns + `
+		FROM salaries s
+		JOIN employees e ON e.id = s.employee_id
+		WHERE s.payroll_run_id = $1
+		ORDER BY e.first_name ASC, e.last_name ASC
+	`
+
+	rows, err := r.db.Query(query, runID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	salaries := []domain.Salary{}
+	for rows.Next() {
+		sal, err := scanRunSalary(rows)
+		if err != nil {
+			return nil, err
+		}
+		salaries = append(salaries, *sal)
+	}
+	return salaries, rows.Err()
+}
+
+// GetRunSalary retrieves one salary of a payroll run. Returns ErrSalaryNotFound when the run has no such salary.
+func (r *PayrollRepository) GetRunSalary(runID, salaryID int) (*domain.Salary, error) {
+	query := `SELECT ` + runSalaryColumns + `
+		FROM salaries s
+		JOIN employees e ON e.id = s.employee_id
+		WHERE s.payroll_run_id = $1 AND s.id = $2
+	`
+
+	sal, err := scanRunSalary(r.db.QueryRow(query, runID, salaryID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrSalaryNotFound
+	}
+	return sal, err
+}
+
+// UpdateRunSalary stores an adjusted salary of a draft run and the run's new totals
+func (r *PayrollRepository) UpdateRunSalary(run *domain.PayrollRun, sal *domain.Salary) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE salaries
+		SET bonus = $1, commission = $2, allowances = $3, other_earnings = $4, tips = $5,
+		    tax = $6, social_insurance = $7, health_insurance = $8, pension = $9,
+		    loan_deduction = $10, advance_deduction = $11, other_deductions = $12,
+		    deductions_details = $13, notes = NULLIF($14, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $15 AND payroll_run_id = $16
+	`,
+		sal.Bonus, sal.Commission, sal.Allowances, sal.OtherEarnings, sal.Tips,
+		sal.Tax, sal.SocialInsurance, sal.HealthInsurance, sal.Pension,
+		sal.LoanDeduction, sal.AdvanceDeduction, sal.OtherDeductions,
+		jsonOrEmpty(sal.DeductionsDetails), sal.Notes, sal.ID, run.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if err := updateRunTotals(tx, run); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ApprovePayrollRun approves a draft run and its salaries.
+// Returns ErrPayrollRunNotDraft when the run is no longer a draft.
+func (r *PayrollRepository) ApprovePayrollRun(tenantID, restaurantID, id, approvedBy int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE payroll_runs
+		SET status = 'approved', approved_by = $1, approved_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND tenant_id = $3 AND restaurant_id = $4 AND status = 'draft'
+	`, approvedBy, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrPayrollRunNotDraft
+	}
+
+	_, err = tx.Exec(`
+		UPDATE salaries
+		SET is_approved = true, approved_by = $1, approved_at = CURRENT_TIMESTAMP,
+		    status = 'processing', updated_at = CURRENT_TIMESTAMP
+		WHERE payroll_run_id = $2
+	`, approvedBy, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// MarkPayrollRunPaid marks an approved run as paid once its salaries have been paid
+func (r *PayrollRepository) MarkPayrollRunPaid(tenantID, restaurantID, id, paidBy int) error {
+	result, err := r.db.Exec(`
+		UPDATE payroll_runs
+		SET status = 'paid', paid_by = $1, paid_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $2 AND tenant_id = $3 AND restaurant_id = $4 AND status = 'approved'
+	`, paidBy, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrPayrollRunNotApproved
+	}
+	return nil
+}
+
+// CancelPayrollRun cancels a draft or approved run and deletes its salaries so the period can be run again
+func (r *PayrollRepository) CancelPayrollRun(tenantID, restaurantID, id int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE payroll_runs
+		SET status = 'cancelled', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3 AND status IN ('draft', 'approved')
+	`, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrPayrollRunClosed
+	}
+
+	if _, err := tx.Exec(`DELETE FROM salaries WHERE payroll_run_id = $1 AND is_paid = false`, id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func nullIntPtr(v sql.NullInt64) *int {
+	if !v.Valid {
+		return nil
+	}
+	id := int(v.Int64)
+	return &id
+}
+
+func jsonOrEmpty(data []byte) string {
+	if len(data) == 0 {
+		return "{}"
+	}
+	return string(data)
+}
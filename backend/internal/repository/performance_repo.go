@@ -0,0 +1,588 @@
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+
+	"pos-saas/internal/domain"
+)
+
+// PerformanceRepository handles review cycles, rating criteria, performance reviews and goals
+type PerformanceRepository struct {
+	db *sql.DB
+}
+
+// NewPerformanceRepository creates a new performance repository
+func NewPerformanceRepository(db *sql.DB) *PerformanceRepository {
+	return &PerformanceRepository{db: db}
+}
+
+const reviewCriterionColumns = `
+	id, tenant_id, restaurant_id, key, name, COALESCE(description, ''), weight, sort_order,
+	is_active, created_at, updated_at
+`
+
+func scanReviewCriterion(row rowScanner) (*domain.ReviewCriterion, error) {
+	var c domain.ReviewCriterion
+	err := row.Scan(
+		&c.ID, &c.TenantID, &c.RestaurantID, &c.Key, &c.Name, &c.Description, &c.Weight,
+		&c.SortOrder, &c.IsActive, &c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListReviewCriteria retrieves the restaurant's rating criteria, optionally only the active ones
+func (r *PerformanceRepository) ListReviewCriteria(tenantID, restaurantID int, activeOnly bool) ([]domain.ReviewCriterion, error) {
+	rows, err := r.db.Query(`SELECT `+reviewCriterionColumns+`
+		FROM review_criteria
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND (is_active = true OR NOT $3)
+		ORDER BY sort_order ASC, id ASC
+	`, tenantID, restaurantID, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	criteria := []domain.ReviewCriterion{}
+	for rows.Next() {
+		c, err := scanReviewCriterion(rows)
+		if err != nil {
+			return nil, err
+		}
+		criteria = append(criteria, *c)
+	}
+	return criteria, rows.Err()
+}
+
+// GetReviewCriterion retrieves a rating criterion.
+// Returns ErrReviewCriterionNotFound when it does not belong to the restaurant.
+func (r *PerformanceRepository) GetReviewCriterion(tenantID, restaurantID, id int) (*domain.ReviewCriterion, error) {
+	c, err := scanReviewCriterion(r.db.QueryRow(`SELECT `+reviewCriterionColumns+`
+		FROM review_criteria
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrReviewCriterionNotFound
+	}
+	return c, err
+}
+
+// CreateReviewCriterion creates a rating criterion.
+// Returns ErrReviewCriterionExists when the key is taken.
+func (r *PerformanceRepository) CreateReviewCriterion(c *domain.ReviewCriterion) (*domain.ReviewCriterion, error) {
+	created, err := scanReviewCriterion(r.db.QueryRow(`
+		INSERT INTO review_criteria (tenant_id, restaurant_id, key, name, description, weight, sort_order, is_active)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8)
+		RETURNING `+reviewCriterionColumns,
+		c.TenantID, c.RestaurantID, c.Key, c.Name, c.Description, c.Weight, c.SortOrder, c.IsActive,
+	))
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, domain.ErrReviewCriterionExists
+	}
+	return created, err
+}
+
+// UpdateReviewCriterion replaces a rating criterion. Ratings already given keep their key.
+func (r *PerformanceRepository) UpdateReviewCriterion(c *domain.ReviewCriterion) (*domain.ReviewCriterion, error) {
+	updated, err := scanReviewCriterion(r.db.QueryRow(`
+		UPDATE review_criteria
+		SET key = $1, name = $2, description = NULLIF($3, ''), weight = $4, sort_order = $5,
+		    is_active = $6, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $7 AND tenant_id = $8 AND restaurant_id = $9
+		RETURNING `+reviewCriterionColumns,
+		c.Key, c.Name, c.Description, c.Weight, c.SortOrder, c.IsActive, c.ID, c.TenantID, c.RestaurantID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrReviewCriterionNotFound
+	}
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, domain.ErrReviewCriterionExists
+	}
+	return updated, err
+}
+
+// DeleteReviewCriterion deletes a rating criterion
+func (r *PerformanceRepository) DeleteReviewCriterion(tenantID, restaurantID, id int) error {
+	result, err := r.db.Exec(`
+		DELETE FROM review_criteria WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrReviewCriterionNotFound
+	}
+	return nil
+}
+
+const reviewCycleColumns = `
+	c.id, c.tenant_id, c.restaurant_id, c.name, c.review_type, c.period_start, c.period_end,
+	c.self_assessment_due, c.manager_assessment_due, c.status,
+	(SELECT COUNT(*) FROM performance_reviews p WHERE p.cycle_id = c.id),
+	(SELECT COUNT(*) FROM performance_reviews p WHERE p.cycle_id = c.id AND p.status = 'finalized'),
+	c.created_by, c.created_at, c.updated_at
+`
+
+func scanReviewCycle(row rowScanner) (*domain.ReviewCycle, error) {
+	var c domain.ReviewCycle
+	var selfDue, managerDue sql.NullTime
+	var createdBy sql.NullInt64
+	err := row.Scan(
+		&c.ID, &c.TenantID, &c.RestaurantID, &c.Name, &c.ReviewType, &c.PeriodStart, &c.PeriodEnd,
+		&selfDue, &managerDue, &c.Status, &c.ReviewCount, &c.FinalizedCount, &createdBy,
+		&c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if selfDue.Valid {
+		c.SelfAssessmentDue = &selfDue.Time
+	}
+	if managerDue.Valid {
+		c.ManagerAssessmentDue = &managerDue.Time
+	}
+	c.CreatedBy = nullIntPtr(createdBy)
+	return &c, nil
+}
+
+// ListReviewCycles retrieves the restaurant's review cycles, newest period first
+func (r *PerformanceRepository) ListReviewCycles(tenantID, restaurantID int) ([]domain.ReviewCycle, error) {
+	rows, err := r.db.Query(`SELECT `+reviewCycleColumns+`
+		FROM review_cycles c
+		WHERE c.tenant_id = $1 AND c.restaurant_id = $2
+		ORDER BY c.period_start DESC, c.id DESC
+	`, tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	cycles := []domain.ReviewCycle{}
+	for rows.Next() {
+		c, err := scanReviewCycle(rows)
+		if err != nil {
+			return nil, err
+		}
+		cycles = append(cycles, *c)
+	}
+	return cycles, rows.Err()
+}
+
+// GetReviewCycle retrieves a review cycle.
+// Returns ErrReviewCycleNotFound when it does not belong to the restaurant.
+func (r *PerformanceRepository) GetReviewCycle(tenantID, restaurantID, id int) (*domain.ReviewCycle, error) {
+	c, err := scanReviewCycle(r.db.QueryRow(`SELECT `+reviewCycleColumns+`
+		FROM review_cycles c
+		WHERE c.id = $1 AND c.tenant_id = $2 AND c.restaurant_id = $3
+	`, id, tenantID, restaurantID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrReviewCycleNotFound
+	}
+	return c, err
+}
+
+// CreateReviewCycle creates a review cycle and opens its reviews
+func (r *PerformanceRepository) CreateReviewCycle(cycle *domain.ReviewCycle, reviews []domain.PerformanceReview) (*domain.ReviewCycle, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var id int
+	err = tx.QueryRow(`
+		INSERT INTO review_cycles (
+			tenant_id, restaurant_id, name, review_type, period_start, period_end,
+			self_assessment_due, manager_assessment_due, status, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`,
+		cycle.TenantID, cycle.RestaurantID, cycle.Name, cycle.ReviewType, cycle.PeriodStart,
+		cycle.PeriodEnd, cycle.SelfAssessmentDue, cycle.ManagerAssessmentDue, cycle.Status, cycle.CreatedBy,
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, review := range reviews {
+		// reviewer_comments is required by the table; it is filled in by the manager assessment
+		if _, err := tx.Exec(`
+			INSERT INTO performance_reviews (
+				tenant_id, restaurant_id, employee_id, cycle_id, review_period_start, review_period_end,
+				review_date, review_type, reviewer_id, reviewer_employee_id, reviewer_name,
+				reviewer_comments, status, created_by
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''), '', 'draft', $12)
+		`,
+			cycle.TenantID, cycle.RestaurantID, review.EmployeeID, id, cycle.PeriodStart, cycle.PeriodEnd,
+			cycle.PeriodEnd, cycle.ReviewType, review.ReviewerID, review.ReviewerEmployeeID,
+			review.ReviewerName, cycle.CreatedBy,
+		); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetReviewCycle(cycle.TenantID, cycle.RestaurantID, id)
+}
+
+// CloseReviewCycle closes an active review cycle
+func (r *PerformanceRepository) CloseReviewCycle(tenantID, restaurantID, id int) error {
+	result, err := r.db.Exec(`
+		UPDATE review_cycles SET status = 'closed', updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3 AND status = 'active'
+	`, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrReviewCycleClosed
+	}
+	return nil
+}
+
+const performanceReviewColumns = `
+	p.id, p.tenant_id, p.restaurant_id, p.employee_id, e.first_name || ' ' || e.last_name,
+	p.cycle_id, p.review_period_start, p.review_period_end, p.review_date, p.review_type,
+	p.reviewer_id, p.reviewer_employee_id, COALESCE(p.reviewer_name, ''),
+	p.self_ratings, COALESCE(p.self_comments, ''), p.self_submitted_at,
+	COALESCE(p.custom_ratings, '{}'), p.overall_rating, p.manager_submitted_at,
+	COALESCE(p.strengths, ''), COALESCE(p.areas_for_improvement, ''), COALESCE(p.achievements, ''),
+	COALESCE(p.action_plan, ''), COALESCE(p.training_recommendations, ''), p.reviewer_comments,
+	COALESCE(p.employee_comments, ''), COALESCE(p.hr_comments, ''),
+	COALESCE(p.promotion_recommended, false), COALESCE(p.salary_increase_recommended, false),
+	p.recommended_increase_percentage, p.next_review_date, p.status,
+	COALESCE(p.employee_acknowledged, false), p.acknowledged_at, COALESCE(p.is_finalized, false),
+	p.finalized_at, p.finalized_by, p.created_at, p.updated_at
+`
+
+func scanPerformanceReview(row rowScanner) (*domain.PerformanceReview, error) {
+	var p domain.PerformanceReview
+	var cycleID, reviewerID, reviewerEmployeeID, finalizedBy sql.NullInt64
+	var selfRatings, ratings []byte
+	var selfSubmittedAt, managerSubmittedAt, acknowledgedAt, finalizedAt, nextReviewDate sql.NullTime
+	var overallRating, increasePercentage sql.NullFloat64
+	err := row.Scan(
+		&p.ID, &p.TenantID, &p.RestaurantID, &p.EmployeeID, &p.EmployeeName,
+		&cycleID, &p.ReviewPeriodStart, &p.ReviewPeriodEnd, &p.ReviewDate, &p.ReviewType,
+		&reviewerID, &reviewerEmployeeID, &p.ReviewerName,
+		&selfRatings, &p.SelfComments, &selfSubmittedAt,
+		&ratings, &overallRating, &managerSubmittedAt,
+		&p.Strengths, &p.AreasForImprovement, &p.Achievements,
+		&p.ActionPlan, &p.TrainingRecommendations, &p.ReviewerComments,
+		&p.EmployeeComments, &p.HRComments,
+		&p.PromotionRecommended, &p.SalaryIncreaseRecommended,
+		&increasePercentage, &nextReviewDate, &p.Status,
+		&p.EmployeeAcknowledged, &acknowledgedAt, &p.IsFinalized,
+		&finalizedAt, &finalizedBy, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p.CycleID = nullIntPtr(cycleID)
+	p.ReviewerID = nullIntPtr(reviewerID)
+	p.ReviewerEmployeeID = nullIntPtr(reviewerEmployeeID)
+	p.FinalizedBy = nullIntPtr(finalizedBy)
+	p.SelfRatings = map[string]float64{}
+	p.Ratings = map[string]float64{}
+	if err := json.Unmarshal(selfRatings, &p.SelfRatings); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(ratings, &p.Ratings); err != nil {
+		return nil, err
+	}
+	if overallRating.Valid {
+		p.OverallRating = &overallRating.Float64
+	}
+	if increasePercentage.Valid {
+		p.RecommendedIncreasePercentage = &increasePercentage.Float64
+	}
+	for dst, src := range map[**time.Time]sql.NullTime{
+		&p.SelfSubmittedAt:    selfSubmittedAt,
+		&p.ManagerSubmittedAt: managerSubmittedAt,
+		&p.AcknowledgedAt:     acknowledgedAt,
+		&p.FinalizedAt:        finalizedAt,
+		&p.NextReviewDate:     nextReviewDate,
+	} {
+		if src.Valid {
+			t := src.Time
+			*dst = &t
+		}
+	}
+	return &p, nil
+}
+
+func (r *PerformanceRepository) listReviews(where string, args ...interface{}) ([]domain.PerformanceReview, error) {
+	rows, err := r.db.Query(`SELECT `+performanceReviewColumns+`
+		FROM performance_reviews p
+		JOIN employees e ON e.id = p.employee_id
+		WHERE `+where+`
+		ORDER BY p.review_period_end DESC, e.first_name ASC, e.last_name ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []domain.PerformanceReview{}
+	for rows.Next() {
+		p, err := scanPerformanceReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, *p)
+	}
+	return reviews, rows.Err()
+}
+
+// ListCycleReviews retrieves the reviews of a cycle
+func (r *PerformanceRepository) ListCycleReviews(tenantID, restaurantID, cycleID int) ([]domain.PerformanceReview, error) {
+	return r.listReviews(`p.tenant_id = $1 AND p.restaurant_id = $2 AND p.cycle_id = $3`, tenantID, restaurantID, cycleID)
+}
+
+// ListEmployeeReviews retrieves an employee's review history, newest first
+func (r *PerformanceRepository) ListEmployeeReviews(tenantID, restaurantID, employeeID int) ([]domain.PerformanceReview, error) {
+	return r.listReviews(`p.tenant_id = $1 AND p.restaurant_id = $2 AND p.employee_id = $3`, tenantID, restaurantID, employeeID)
+}
+
+// ListReviewsForReviewer retrieves the draft reviews the employee is to assess as manager
+func (r *PerformanceRepository) ListReviewsForReviewer(tenantID, restaurantID, reviewerEmployeeID int) ([]domain.PerformanceReview, error) {
+	return r.listReviews(`p.tenant_id = $1 AND p.restaurant_id = $2 AND p.reviewer_employee_id = $3 AND p.status = 'draft'`,
+		tenantID, restaurantID, reviewerEmployeeID)
+}
+
+// GetPerformanceReview retrieves a review with the goals set in it.
+// Returns ErrPerformanceReviewNotFound when it does not belong to the restaurant.
+func (r *PerformanceRepository) GetPerformanceReview(tenantID, restaurantID, id int) (*domain.PerformanceReview, error) {
+	p, err := scanPerformanceReview(r.db.QueryRow(`SELECT `+performanceReviewColumns+`
+		FROM performance_reviews p
+		JOIN employees e ON e.id = p.employee_id
+		WHERE p.id = $1 AND p.tenant_id = $2 AND p.restaurant_id = $3
+	`, id, tenantID, restaurantID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrPerformanceReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.Goals, err = r.listGoals(`review_id = $1`, p.ID); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// SaveSelfAssessment stores the employee's assessment of a draft review
+func (r *PerformanceRepository) SaveSelfAssessment(p *domain.PerformanceReview) error {
+	result, err := r.db.Exec(`
+		UPDATE performance_reviews
+		SET self_ratings = $1, self_comments = NULLIF($2, ''), achievements = NULLIF($3, ''),
+		    self_submitted_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $4 AND status = 'draft'
+	`, domain.RatingsJSON(p.SelfRatings), p.SelfComments, p.Achievements, p.ID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrReviewWrongStatus
+	}
+	return nil
+}
+
+// SubmitManagerAssessment stores the reviewer's assessment of a draft review and creates the
+// goals it sets, moving the review to submitted
+func (r *PerformanceRepository) SubmitManagerAssessment(p *domain.PerformanceReview, goals []domain.PerformanceGoal, reviewerID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// review_date must not fall before the period end (valid_review_date)
+	result, err := tx.Exec(`
+		UPDATE performance_reviews
+		SET custom_ratings = $1, overall_rating = $2, strengths = NULLIF($3, ''),
+		    areas_for_improvement = NULLIF($4, ''), action_plan = NULLIF($5, ''),
+		    training_recommendations = NULLIF($6, ''), reviewer_comments = $7,
+		    promotion_recommended = $8, salary_increase_recommended = $9,
+		    recommended_increase_percentage = $10, next_review_date = $11,
+		    reviewer_id = $12, status = 'submitted', manager_submitted_at = CURRENT_TIMESTAMP,
+		    review_date = GREATEST(CURRENT_DATE, review_period_end),
+		    updated_by = $12, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $13 AND status = 'draft'
+	`,
+		domain.RatingsJSON(p.Ratings), p.OverallRating, p.Strengths, p.AreasForImprovement, p.ActionPlan,
+		p.TrainingRecommendations, p.ReviewerComments, p.PromotionRecommended,
+		p.SalaryIncreaseRecommended, p.RecommendedIncreasePercentage, p.NextReviewDate,
+		reviewerID, p.ID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrReviewWrongStatus
+	}
+
+	for i := range goals {
+		if err := insertGoal(tx, &goals[i]); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// AcknowledgeReview records the employee's response to a submitted review
+func (r *PerformanceRepository) AcknowledgeReview(id int, status, comments string) error {
+	result, err := r.db.Exec(`
+		UPDATE performance_reviews
+		SET status = $1, employee_acknowledged = true, acknowledged_at = CURRENT_TIMESTAMP,
+		    employee_comments = NULLIF($2, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status = 'submitted'
+	`, status, comments, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrReviewWrongStatus
+	}
+	return nil
+}
+
+// FinalizeReview records HR's sign-off on an acknowledged or disputed review
+func (r *PerformanceRepository) FinalizeReview(id int, hrComments string, finalizedBy int) error {
+	result, err := r.db.Exec(`
+		UPDATE performance_reviews
+		SET status = 'finalized', is_finalized = true, finalized_at = CURRENT_TIMESTAMP,
+		    finalized_by = $1, hr_comments = NULLIF($2, ''), updated_by = $1,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND status IN ('acknowledged', 'disputed')
+	`, finalizedBy, hrComments, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrReviewWrongStatus
+	}
+	return nil
+}
+
+const performanceGoalColumns = `
+	id, tenant_id, restaurant_id, employee_id, review_id, title, COALESCE(description, ''),
+	target_date, progress, status, completed_at, created_by, created_at, updated_at
+`
+
+func scanPerformanceGoal(row rowScanner) (*domain.PerformanceGoal, error) {
+	var g domain.PerformanceGoal
+	var reviewID, createdBy sql.NullInt64
+	var targetDate, completedAt sql.NullTime
+	err := row.Scan(
+		&g.ID, &g.TenantID, &g.RestaurantID, &g.EmployeeID, &reviewID, &g.Title, &g.Description,
+		&targetDate, &g.Progress, &g.Status, &completedAt, &createdBy, &g.CreatedAt, &g.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	g.ReviewID = nullIntPtr(reviewID)
+	g.CreatedBy = nullIntPtr(createdBy)
+	if targetDate.Valid {
+		g.TargetDate = &targetDate.Time
+	}
+	if completedAt.Valid {
+		g.CompletedAt = &completedAt.Time
+	}
+	return &g, nil
+}
+
+func (r *PerformanceRepository) listGoals(where string, args ...interface{}) ([]domain.PerformanceGoal, error) {
+	rows, err := r.db.Query(`SELECT `+performanceGoalColumns+`
+		FROM performance_goals
+		WHERE `+where+`
+		ORDER BY (status IN ('completed', 'cancelled')) ASC, target_date ASC NULLS LAST, id ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	goals := []domain.PerformanceGoal{}
+	for rows.Next() {
+		g, err := scanPerformanceGoal(rows)
+		if err != nil {
+			return nil, err
+		}
+		goals = append(goals, *g)
+	}
+	return goals, rows.Err()
+}
+
+// ListEmployeeGoals retrieves an employee's goals, open goals first
+func (r *PerformanceRepository) ListEmployeeGoals(tenantID, restaurantID, employeeID int) ([]domain.PerformanceGoal, error) {
+	return r.listGoals(`tenant_id = $1 AND restaurant_id = $2 AND employee_id = $3`, tenantID, restaurantID, employeeID)
+}
+
+// GetPerformanceGoal retrieves a goal.
+// Returns ErrGoalNotFound when it does not belong to the restaurant.
+func (r *PerformanceRepository) GetPerformanceGoal(tenantID, restaurantID, id int) (*domain.PerformanceGoal, error) {
+	g, err := scanPerformanceGoal(r.db.QueryRow(`SELECT `+performanceGoalColumns+`
+		FROM performance_goals
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrGoalNotFound
+	}
+	return g, err
+}
+
+// CreatePerformanceGoal creates a goal
+func (r *PerformanceRepository) CreatePerformanceGoal(g *domain.PerformanceGoal) (*domain.PerformanceGoal, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := insertGoal(tx, g); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return r.GetPerformanceGoal(g.TenantID, g.RestaurantID, g.ID)
+}
+
+// UpdatePerformanceGoal stores a goal's progress and status, stamping its completion
+func (r *PerformanceRepository) UpdatePerformanceGoal(g *domain.PerformanceGoal) (*domain.PerformanceGoal, error) {
+	updated, err := scanPerformanceGoal(r.db.QueryRow(`
+		UPDATE performance_goals
+		SET progress = $1, status = $2,
+		    completed_at = CASE WHEN $2 = 'completed' THEN COALESCE(completed_at, CURRENT_TIMESTAMP) END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND tenant_id = $4 AND restaurant_id = $5
+		RETURNING `+performanceGoalColumns,
+		g.Progress, g.Status, g.ID, g.TenantID, g.RestaurantID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrGoalNotFound
+	}
+	return updated, err
+}
+
+func insertGoal(tx *sql.Tx, g *domain.PerformanceGoal) error {
+	return tx.QueryRow(`
+		INSERT INTO performance_goals (
+			tenant_id, restaurant_id, employee_id, review_id, title, description, target_date,
+			progress, status, created_by
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10)
+		RETURNING id
+	`,
+		g.TenantID, g.RestaurantID, g.EmployeeID, g.ReviewID, g.Title, g.Description, g.TargetDate,
+		g.Progress, g.Status, g.CreatedBy,
+	).Scan(&g.ID)
+}
@@ -28,7 +28,7 @@ func (r *PermissionAuditLogRepository) Log(ctx context.Context, log *domain.Perm
 	query := `
 		INSERT INTO permission_audit_logs
 		(tenant_id, admin_id, user_id, role_id, module_id, action, details, ip_address, user_agent, created_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, '')::inet, NULLIF($9, ''), $10)
 		RETURNING id
 	`
 
@@ -66,7 +66,8 @@ func (r *PermissionAuditLogRepository) GetByTenant(ctx context.Context, tenantID
 	}
 
 	query := `
-		SELECT id, tenant_id, admin_id, user_id, role_id, module_id, action, details, ip_address, user_agent, created_at
+		SELECT id, tenant_id, admin_id, user_id, role_id, module_id, action, details,
+		       COALESCE(host(ip_address), ''), COALESCE(user_agent, ''), created_at
 		FROM permission_audit_logs
 		WHERE tenant_id = $1
 		ORDER BY created_at DESC
@@ -125,7 +126,8 @@ func (r *PermissionAuditLogRepository) GetByUser(ctx context.Context, userID int
 	}
 
 	query := `
-		SELECT id, tenant_id, admin_id, user_id, role_id, module_id, action, details, ip_address, user_agent, created_at
+		SELECT id, tenant_id, admin_id, user_id, role_id, module_id, action, details,
+		       COALESCE(host(ip_address), ''), COALESCE(user_agent, ''), created_at
 		FROM permission_audit_logs
 		WHERE user_id = $1 OR admin_id = $1
 		ORDER BY created_at DESC
@@ -184,7 +186,8 @@ func (r *PermissionAuditLogRepository) GetByAction(ctx context.Context, action s
 	}
 
 	query := `
-		SELECT id, tenant_id, admin_id, user_id, role_id, module_id, action, details, ip_address, user_agent, created_at
+		SELECT id, tenant_id, admin_id, user_id, role_id, module_id, action, details,
+		       COALESCE(host(ip_address), ''), COALESCE(user_agent, ''), created_at
 		FROM permission_audit_logs
 		WHERE action = $1
 		ORDER BY created_at DESC
@@ -246,7 +249,8 @@ func (r *PermissionAuditLogRepository) GetRecent(ctx context.Context, tenantID i
 	}
 
 	query := `
-		SELECT id, tenant_id, admin_id, user_id, role_id, module_id, action, details, ip_address, user_agent, created_at
+		SELECT id, tenant_id, admin_id, user_id, role_id, module_id, action, details,
+		       COALESCE(host(ip_address), ''), COALESCE(user_agent, ''), created_at
 		FROM permission_audit_logs
 		WHERE tenant_id = $1 AND created_at > NOW() - INTERVAL '1 hour' * $2
 		ORDER BY created_at DESC
@@ -135,6 +135,12 @@ func (r *ProductRepository) CreateProduct(product *domain.Product) (*domain.Prod
 		return nil, fmt.Errorf("failed to create product: %w", err)
 	}
 
+	if len(product.Allergens) > 0 {
+		if err := r.SetProductAllergens(product.ID, product.Allergens); err != nil {
+			return nil, err
+		}
+	}
+
 	fmt.Printf("DEBUG: Insert successful. ID: %d. Returning product.\n", product.ID)
 	return product, nil
 }
@@ -146,11 +152,13 @@ func (r *ProductRepository) GetProductByID(tenantID, restaurantID, productID int
 			id, tenant_id, restaurant_id, category_id, sku, barcode,
 			name_en, name_ar, description_en, description_ar,
 			price, cost, discount_price, discount_percentage,
-			calories, protein_g, carbs_g, fat_g, fiber_g, allergens,
+			calories, protein_g, carbs_g, fat_g, fiber_g,
+			COALESCE((SELECT json_agg(pa.allergen_code ORDER BY pa.allergen_code)::text FROM product_allergens pa WHERE pa.product_id = products.id), allergens),
 			is_vegetarian, is_vegan, is_spicy, is_gluten_free,
 			is_available, available_from, available_until, available_days,
 			track_inventory, quantity_in_stock, low_stock_threshold, reorder_quantity,
 			display_order, featured, main_image_url, status,
+			COALESCE(rating_average, 0), COALESCE(rating_count, 0),
 			created_by, updated_by, created_at, updated_at
 		FROM products
 		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
@@ -172,6 +180,7 @@ func (r *ProductRepository) GetProductByID(tenantID, restaurantID, productID int
 		&product.IsAvailable, &product.AvailableFrom, &product.AvailableUntil, &availableDaysJSON,
 		&product.TrackInventory, &product.QuantityInStock, &product.LowStockThreshold, &product.ReorderQuantity,
 		&product.DisplayOrder, &product.Featured, &mainImageURL, &product.Status,
+		&product.RatingAverage, &product.RatingCount,
 		&createdBy, &updatedBy, &product.CreatedAt, &product.UpdatedAt,
 	)
 
@@ -212,9 +221,7 @@ func (r *ProductRepository) GetProductByID(tenantID, restaurantID, productID int
 	}
 
 	// Unmarshal JSON fields
-	if len(allergensJSON) > 0 {
-		json.Unmarshal(allergensJSON, &product.Allergens)
-	}
+	product.Allergens = domain.ParseAllergens(string(allergensJSON))
 	if len(availableDaysJSON) > 0 {
 		json.Unmarshal(availableDaysJSON, &product.AvailableDays)
 	}
@@ -427,10 +434,12 @@ func (r *ProductRepository) ListPublic(tenantID, restaurantID int, lang string)
 			id, tenant_id, restaurant_id, category_id, sku,
 			name_en, name_ar, description_en, description_ar,
 			price, discount_price, discount_percentage,
-			calories, protein_g, carbs_g, fat_g, fiber_g, allergens,
+			calories, protein_g, carbs_g, fat_g, fiber_g,
+			COALESCE((SELECT json_agg(pa.allergen_code ORDER BY pa.allergen_code)::text FROM product_allergens pa WHERE pa.product_id = products.id), allergens),
 			is_vegetarian, is_vegan, is_spicy, is_gluten_free,
 			is_available, available_from, available_until,
 			main_image_url, featured, status,
+			COALESCE(rating_average, 0), COALESCE(rating_count, 0),
 			created_at
 		FROM products
 		WHERE restaurant_id = $1
@@ -458,6 +467,7 @@ func (r *ProductRepository) ListPublic(tenantID, restaurantID int, lang string)
 			&p.IsVegetarian, &p.IsVegan, &p.IsSpicy, &p.IsGlutenFree,
 			&p.IsAvailable, &p.AvailableFrom, &p.AvailableUntil,
 			&mainImageURL, &p.Featured, &p.Status,
+			&p.RatingAverage, &p.RatingCount,
 			&p.CreatedAt,
 		)
 		if err != nil {
@@ -468,7 +478,7 @@ func (r *ProductRepository) ListPublic(tenantID, restaurantID int, lang string)
 		p.DescriptionEn = descEn.String
 		p.DescriptionAr = descAr.String
 		p.MainImageURL = mainImageURL.String
-		p.Allergens = []string{} // Reset/ignore for now to avoid nil pointer issues if any logic depends on it
+		p.Allergens = domain.ParseAllergens(allergens.String)
 		products = append(products, p)
 	}
 	return products, nil
@@ -481,10 +491,12 @@ func (r *ProductRepository) ListByCategoryPublic(tenantID, restaurantID, categor
 			id, tenant_id, restaurant_id, category_id, sku,
 			name_en, name_ar, description_en, description_ar,
 			price, discount_price, discount_percentage,
-			calories, protein_g, carbs_g, fat_g, fiber_g, allergens,
+			calories, protein_g, carbs_g, fat_g, fiber_g,
+			COALESCE((SELECT json_agg(pa.allergen_code ORDER BY pa.allergen_code)::text FROM product_allergens pa WHERE pa.product_id = products.id), allergens),
 			is_vegetarian, is_vegan, is_spicy, is_gluten_free,
 			is_available, available_from, available_until,
 			main_image_url, featured, status,
+			COALESCE(rating_average, 0), COALESCE(rating_count, 0),
 			created_at
 		FROM products
 		WHERE restaurant_id = $1 AND category_id = $2
@@ -511,6 +523,7 @@ func (r *ProductRepository) ListByCategoryPublic(tenantID, restaurantID, categor
 			&p.IsVegetarian, &p.IsVegan, &p.IsSpicy, &p.IsGlutenFree,
 			&p.IsAvailable, &p.AvailableFrom, &p.AvailableUntil,
 			&mainImageURL, &p.Featured, &p.Status,
+			&p.RatingAverage, &p.RatingCount,
 			&p.CreatedAt,
 		)
 		if err != nil {
@@ -521,40 +534,80 @@ func (r *ProductRepository) ListByCategoryPublic(tenantID, restaurantID, categor
 		p.DescriptionEn = descEn.String
 		p.DescriptionAr = descAr.String
 		p.MainImageURL = mainImageURL.String
+		p.Allergens = domain.ParseAllergens(allergens.String)
 		products = append(products, p)
 	}
 	return products, nil
 }
 
-// SearchPublic searches products
+// SearchPublic searches products by relevance
 func (r *ProductRepository) SearchPublic(tenantID, restaurantID int, queryStr string, lang string) ([]domain.Product, error) {
-	searchTerm := "%" + queryStr + "%"
+	hits, err := r.SearchPublicRanked(restaurantID, queryStr, 50)
+	if err != nil {
+		return nil, err
+	}
+
+	products := make([]domain.Product, 0, len(hits))
+	for _, hit := range hits {
+		products = append(products, hit.Product)
+	}
+	return products, nil
+}
+
+// SearchPublicRanked runs a full-text search over English (stemmed) and normalized Arabic
+// names and descriptions, falling back to trigram similarity on names for typos.
+// Results are ordered by text rank, name similarity and featured flag.
+func (r *ProductRepository) SearchPublicRanked(restaurantID int, queryStr string, limit int) ([]domain.ProductSearchHit, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 200
+	}
+
 	query := `
+		WITH q AS (
+			SELECT
+				websearch_to_tsquery('english', $2) || websearch_to_tsquery('simple', normalize_arabic($2)) AS ts,
+				lower(normalize_arabic($2)) AS term
+		)
 		SELECT 
-			id, tenant_id, restaurant_id, category_id, sku,
-			name_en, name_ar, description_en, description_ar,
-			price, discount_price, discount_percentage,
-			calories, protein_g, carbs_g, fat_g, fiber_g, allergens,
-			is_vegetarian, is_vegan, is_spicy, is_gluten_free,
-			is_available, available_from, available_until,
-			main_image_url, featured, status,
-			created_at
-		FROM products
-		WHERE restaurant_id = $1
-		  AND status = 'active'
-		  AND is_available = true
-          AND (name_en ILIKE $2 OR name_ar ILIKE $2 OR description_en ILIKE $2)
-		ORDER BY display_order ASC
+			p.id, p.tenant_id, p.restaurant_id, p.category_id, p.sku,
+			p.name_en, p.name_ar, p.description_en, p.description_ar,
+			p.price, p.discount_price, p.discount_percentage,
+			p.calories, p.protein_g, p.carbs_g, p.fat_g, p.fiber_g,
+			COALESCE((SELECT json_agg(pa.allergen_code ORDER BY pa.allergen_code)::text FROM product_allergens pa WHERE pa.product_id = p.id), p.allergens),
+			p.is_vegetarian, p.is_vegan, p.is_spicy, p.is_gluten_free,
+			p.is_available, p.available_from, p.available_until,
+			p.main_image_url, p.featured, p.status,
+			COALESCE(p.rating_average, 0), COALESCE(p.rating_count, 0),
+			p.created_at,
+			COALESCE(c.name, ''), COALESCE(c.name_ar, ''),
+			ts_rank_cd(p.search_vector, q.ts)
+				+ 0.5 * word_similarity(q.term, p.search_names)
+				+ CASE WHEN p.featured THEN 0.1 ELSE 0 END AS rank
+		FROM products p
+		CROSS JOIN q
+		LEFT JOIN categories c ON c.id = p.category_id
+		WHERE p.restaurant_id = $1
+		  AND p.status = 'active'
+		  AND p.is_available = true
+		  AND (
+			p.search_vector @@ q.ts
+			OR word_similarity(q.term, p.search_names) > 0.4
+			OR strpos(p.search_names, q.term) > 0
+		  )
+		ORDER BY rank DESC, p.display_order ASC
+		LIMIT $3
 	`
-	rows, err := r.db.Query(query, restaurantID, searchTerm)
+
+	rows, err := r.db.Query(query, restaurantID, queryStr, limit)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to search products: %w", err)
 	}
 	defer rows.Close()
 
-	var products []domain.Product
+	hits := []domain.ProductSearchHit{}
 	for rows.Next() {
-		var p domain.Product
+		var hit domain.ProductSearchHit
+		p := &hit.Product
 		var allergens, sku, nameAr, descEn, descAr, mainImageURL sql.NullString
 		err := rows.Scan(
 			&p.ID, &p.TenantID, &p.RestaurantID, &p.CategoryID, &sku,
@@ -564,7 +617,10 @@ func (r *ProductRepository) SearchPublic(tenantID, restaurantID int, queryStr st
 			&p.IsVegetarian, &p.IsVegan, &p.IsSpicy, &p.IsGlutenFree,
 			&p.IsAvailable, &p.AvailableFrom, &p.AvailableUntil,
 			&mainImageURL, &p.Featured, &p.Status,
+			&p.RatingAverage, &p.RatingCount,
 			&p.CreatedAt,
+			&hit.CategoryName, &hit.CategoryNameAr,
+			&hit.Rank,
 		)
 		if err != nil {
 			return nil, err
@@ -574,9 +630,109 @@ func (r *ProductRepository) SearchPublic(tenantID, restaurantID int, queryStr st
 		p.DescriptionEn = descEn.String
 		p.DescriptionAr = descAr.String
 		p.MainImageURL = mainImageURL.String
-		products = append(products, p)
+		p.Allergens = domain.ParseAllergens(allergens.String)
+		hits = append(hits, hit)
 	}
-	return products, nil
+	return hits, rows.Err()
+}
+
+// SuggestPublic returns autocomplete suggestions for a partially typed query
+func (r *ProductRepository) SuggestPublic(restaurantID int, prefix string, lang string, limit int) ([]domain.SearchSuggestion, error) {
+	tsPrefix := domain.BuildPrefixTSQuery(prefix)
+	if tsPrefix == "" {
+		return []domain.SearchSuggestion{}, nil
+	}
+	if limit <= 0 || limit > 20 {
+		limit = 8
+	}
+
+	query := `
+		SELECT id, name_en, COALESCE(name_ar, '')
+		FROM products
+		WHERE restaurant_id = $1
+		  AND status = 'active'
+		  AND is_available = true
+		  AND (
+			search_vector @@ to_tsquery('simple', $2)
+			OR search_names LIKE $3 || '%'
+			OR word_similarity($3, search_names) > 0.5
+		  )
+		ORDER BY featured DESC, word_similarity($3, search_names) DESC, display_order ASC
+		LIMIT $4
+	`
+
+	rows, err := r.db.Query(query, restaurantID, tsPrefix, domain.NormalizeSearchQuery(prefix), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []domain.SearchSuggestion{}
+	for rows.Next() {
+		var id int
+		var nameEn, nameAr string
+		if err := rows.Scan(&id, &nameEn, &nameAr); err != nil {
+			return nil, err
+		}
+		suggestion := domain.SearchSuggestion{ProductID: id, Text: nameEn, Lang: "en"}
+		if lang == "ar" && nameAr != "" {
+			suggestion.Text = nameAr
+			suggestion.Lang = "ar"
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
+
+// ListAllergens returns the allergen vocabulary
+func (r *ProductRepository) ListAllergens() ([]domain.Allergen, error) {
+	rows, err := r.db.Query(`SELECT code, name_en, name_ar, display_order FROM allergens ORDER BY display_order, code`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list allergens: %w", err)
+	}
+	defer rows.Close()
+
+	allergens := []domain.Allergen{}
+	for rows.Next() {
+		var a domain.Allergen
+		if err := rows.Scan(&a.Code, &a.NameEn, &a.NameAr, &a.DisplayOrder); err != nil {
+			return nil, err
+		}
+		allergens = append(allergens, a)
+	}
+	return allergens, rows.Err()
+}
+
+// SetProductAllergens replaces the allergens of a product.
+// The legacy products.allergens column is kept in sync as a JSON array.
+func (r *ProductRepository) SetProductAllergens(productID int, codes []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM product_allergens WHERE product_id = $1`, productID); err != nil {
+		return fmt.Errorf("failed to clear product allergens: %w", err)
+	}
+	for _, code := range codes {
+		if _, err := tx.Exec(
+			`INSERT INTO product_allergens (product_id, allergen_code) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			productID, code,
+		); err != nil {
+			return fmt.Errorf("failed to add allergen %s: %w", code, err)
+		}
+	}
+
+	allergensJSON, err := json.Marshal(codes)
+	if err != nil {
+		return fmt.Errorf("failed to marshal allergens: %w", err)
+	}
+	if _, err := tx.Exec(`UPDATE products SET allergens = $1 WHERE id = $2`, string(allergensJSON), productID); err != nil {
+		return fmt.Errorf("failed to update product allergens: %w", err)
+	}
+
+	return tx.Commit()
 }
 
 // GetProductImages returns images
@@ -60,3 +60,48 @@ func (r *RestaurantRepository) GetBySlug(slug string) (*domain.Restaurant, error
 
 	return restaurant, nil
 }
+
+// GetByID retrieves a restaurant by ID
+func (r *RestaurantRepository) GetByID(id int64) (*domain.Restaurant, error) {
+	query := `
+		SELECT 
+			id, tenant_id, name, slug, description, logo_url, hero_image_url,
+			email, phone, address, city, theme,
+			website_enabled, pos_enabled, delivery_enabled, reservation_enabled,
+			status, created_at, updated_at
+		FROM restaurants
+		WHERE id = $1
+	`
+
+	restaurant := &domain.Restaurant{}
+	err := r.db.QueryRow(query, id).Scan(
+		&restaurant.ID,
+		&restaurant.TenantID,
+		&restaurant.Name,
+		&restaurant.Slug,
+		&restaurant.Description,
+		&restaurant.LogoURL,
+		&restaurant.HeroImageURL,
+		&restaurant.Email,
+		&restaurant.Phone,
+		&restaurant.Address,
+		&restaurant.City,
+		&restaurant.Theme,
+		&restaurant.WebsiteEnabled,
+		&restaurant.POSEnabled,
+		&restaurant.DeliveryEnabled,
+		&restaurant.ReservationEnabled,
+		&restaurant.Status,
+		&restaurant.CreatedAt,
+		&restaurant.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("restaurant not found")
+		}
+		return nil, fmt.Errorf("failed to get restaurant: %w", err)
+	}
+
+	return restaurant, nil
+}
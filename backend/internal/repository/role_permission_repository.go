@@ -209,3 +209,29 @@ func (r *RolePermissionRepository) GetByRoleAndTenant(ctx context.Context, tenan
 
 	return permissions, nil
 }
+
+// ReplaceForRole replaces all of a role's module permissions in one transaction
+func (r *RolePermissionRepository) ReplaceForRole(ctx context.Context, tenantID int64, roleID int64, permissions []domain.RolePermission) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE tenant_id = $1 AND role_id = $2`, tenantID, roleID); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, perm := range permissions {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO role_permissions (tenant_id, role_id, module_id, permission_level, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $5)
+		`, tenantID, roleID, perm.ModuleID, perm.PermissionLevel, now)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
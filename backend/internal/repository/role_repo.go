@@ -2,6 +2,10 @@ package repository
 
 import (
 	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+
 	"pos-saas/internal/domain"
 )
 
@@ -152,6 +156,9 @@ func (r *RoleRepository) CreateRole(role *domain.Role) (int, error) {
 		role.IsActive, role.DisplayOrder, role.CreatedBy,
 	).Scan(&id)
 
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return 0, fmt.Errorf("%w: %v", domain.ErrRoleExists, err)
+	}
 	if err != nil {
 		return 0, err
 	}
@@ -181,6 +188,9 @@ func (r *RoleRepository) UpdateRole(role *domain.Role) error {
 		role.UpdatedBy, role.ID, role.TenantID, role.RestaurantID,
 	)
 
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return fmt.Errorf("%w: %v", domain.ErrRoleExists, err)
+	}
 	return err
 }
 
@@ -221,3 +231,80 @@ func (r *RoleRepository) GetRoleByCode(tenantID int, roleCode string) (*domain.R
 
 	return &role, nil
 }
+
+const tenantRoleColumns = `
+	id, tenant_id, restaurant_id, role_name, role_name_ar,
+	description, description_ar, role_code, permissions,
+	access_level, can_approve_leaves, can_approve_overtime,
+	can_manage_payroll, can_view_reports, min_salary, max_salary,
+	is_active, COALESCE(is_system_role, false), display_order, created_at, updated_at`
+
+func scanRole(row rowScanner) (*domain.Role, error) {
+	var role domain.Role
+	var roleNameAr, description, descriptionAr sql.NullString
+	var minSalary, maxSalary sql.NullFloat64
+
+	err := row.Scan(
+		&role.ID, &role.TenantID, &role.RestaurantID,
+		&role.RoleName, &roleNameAr, &description, &descriptionAr,
+		&role.RoleCode, &role.Permissions, &role.AccessLevel,
+		&role.CanApproveLeaves, &role.CanApproveOvertime,
+		&role.CanManagePayroll, &role.CanViewReports,
+		&minSalary, &maxSalary, &role.IsActive, &role.IsSystemRole,
+		&role.DisplayOrder, &role.CreatedAt, &role.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	role.RoleNameAr = roleNameAr.String
+	role.Description = description.String
+	role.DescriptionAr = descriptionAr.String
+	if minSalary.Valid {
+		role.MinSalary = &minSalary.Float64
+	}
+	if maxSalary.Valid {
+		role.MaxSalary = &maxSalary.Float64
+	}
+	return &role, nil
+}
+
+// ListTenantRoles retrieves the active roles of every restaurant in a tenant.
+// Roles are shared by the tenant's restaurants, so RBAC works on this list; an assignment in
+// user_roles may still limit a role to some restaurants.
+func (r *RoleRepository) ListTenantRoles(tenantID int) ([]domain.Role, error) {
+	rows, err := r.db.Query(`
+		SELECT `+tenantRoleColumns+`
+		FROM roles
+		WHERE tenant_id = $1 AND is_active = true
+		ORDER BY is_system_role DESC, display_order ASC, role_name ASC
+	`, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []domain.Role{}
+	for rows.Next() {
+		role, err := scanRole(rows)
+		if err != nil {
+			return nil, err
+		}
+		roles = append(roles, *role)
+	}
+	return roles, rows.Err()
+}
+
+// GetTenantRole retrieves an active role of a tenant by ID, whatever its restaurant.
+// Returns nil when there is no such role.
+func (r *RoleRepository) GetTenantRole(tenantID, id int) (*domain.Role, error) {
+	role, err := scanRole(r.db.QueryRow(`
+		SELECT `+tenantRoleColumns+`
+		FROM roles
+		WHERE id = $1 AND tenant_id = $2 AND is_active = true
+	`, id, tenantID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return role, err
+}
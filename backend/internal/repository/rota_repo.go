@@ -0,0 +1,441 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"pos-saas/internal/domain"
+)
+
+// RotaRepository handles shift templates, staffing requirements, rotas and shifts
+type RotaRepository struct {
+	db *sql.DB
+}
+
+// NewRotaRepository creates a new rota repository
+func NewRotaRepository(db *sql.DB) *RotaRepository {
+	return &RotaRepository{db: db}
+}
+
+// localDate moves a DATE column value to midnight in the server's time zone,
+// which is the zone shift times and clock times are compared in
+func localDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+const shiftTemplateColumns = `
+	id, tenant_id, restaurant_id, name, start_time::text, end_time::text, break_minutes,
+	role_id, COALESCE(color, ''), is_active, created_at, updated_at
+`
+
+func scanShiftTemplate(row rowScanner) (*domain.ShiftTemplate, error) {
+	t := &domain.ShiftTemplate{}
+	var roleID sql.NullInt64
+	err := row.Scan(
+		&t.ID, &t.TenantID, &t.RestaurantID, &t.Name, &t.StartTime, &t.EndTime, &t.BreakMinutes,
+		&roleID, &t.Color, &t.IsActive, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	t.StartTime, t.EndTime = domain.FormatClock(t.StartTime), domain.FormatClock(t.EndTime)
+	if roleID.Valid {
+		id := int(roleID.Int64)
+		t.RoleID = &id
+	}
+	return t, nil
+}
+
+// ListShiftTemplates retrieves a restaurant's shift templates
+func (r *RotaRepository) ListShiftTemplates(tenantID, restaurantID int) ([]domain.ShiftTemplate, error) {
+	rows, err := r.db.Query(`
+		SELECT `+shiftTemplateColumns+`
+		FROM shift_templates
+		WHERE tenant_id = $1 AND restaurant_id = $2
+		ORDER BY start_time ASC, name ASC
+	`, tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	templates := []domain.ShiftTemplate{}
+	for rows.Next() {
+		t, err := scanShiftTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, *t)
+	}
+	return templates, rows.Err()
+}
+
+// GetShiftTemplate retrieves a shift template
+func (r *RotaRepository) GetShiftTemplate(tenantID, restaurantID, id int) (*domain.ShiftTemplate, error) {
+	t, err := scanShiftTemplate(r.db.QueryRow(`
+		SELECT `+shiftTemplateColumns+`
+		FROM shift_templates
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrShiftTemplateNotFound
+	}
+	return t, err
+}
+
+// CreateShiftTemplate creates a shift template
+func (r *RotaRepository) CreateShiftTemplate(t *domain.ShiftTemplate) (*domain.ShiftTemplate, error) {
+	return scanShiftTemplate(r.db.QueryRow(`
+		INSERT INTO shift_templates (tenant_id, restaurant_id, name, start_time, end_time, break_minutes, role_id, color, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NULLIF($8, ''), $9)
+		RETURNING `+shiftTemplateColumns,
+		t.TenantID, t.RestaurantID, t.Name, t.StartTime, t.EndTime, t.BreakMinutes, t.RoleID, t.Color, t.IsActive,
+	))
+}
+
+// UpdateShiftTemplate updates a shift template
+func (r *RotaRepository) UpdateShiftTemplate(t *domain.ShiftTemplate) (*domain.ShiftTemplate, error) {
+	updated, err := scanShiftTemplate(r.db.QueryRow(`
+		UPDATE shift_templates
+		SET name = $1, start_time = $2, end_time = $3, break_minutes = $4, role_id = $5,
+		    color = NULLIF($6, ''), is_active = $7, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $8 AND tenant_id = $9 AND restaurant_id = $10
+		RETURNING `+shiftTemplateColumns,
+		t.Name, t.StartTime, t.EndTime, t.BreakMinutes, t.RoleID, t.Color, t.IsActive, t.ID, t.TenantID, t.RestaurantID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrShiftTemplateNotFound
+	}
+	return updated, err
+}
+
+// DeleteShiftTemplate deletes a shift template; shifts created from it keep their times
+func (r *RotaRepository) DeleteShiftTemplate(tenantID, restaurantID, id int) error {
+	result, err := r.db.Exec(`
+		DELETE FROM shift_templates WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrShiftTemplateNotFound
+	}
+	return nil
+}
+
+const staffingRequirementColumns = `
+	id, tenant_id, restaurant_id, role_id, day_of_week, start_time::text, end_time::text,
+	min_staff, created_at, updated_at
+`
+
+func scanStaffingRequirement(row rowScanner) (*domain.StaffingRequirement, error) {
+	s := &domain.StaffingRequirement{}
+	err := row.Scan(
+		&s.ID, &s.TenantID, &s.RestaurantID, &s.RoleID, &s.DayOfWeek, &s.StartTime, &s.EndTime,
+		&s.MinStaff, &s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.StartTime, s.EndTime = domain.FormatClock(s.StartTime), domain.FormatClock(s.EndTime)
+	return s, nil
+}
+
+// ListStaffingRequirements retrieves a restaurant's staffing requirements
+func (r *RotaRepository) ListStaffingRequirements(tenantID, restaurantID int) ([]domain.StaffingRequirement, error) {
+	rows, err := r.db.Query(`
+		SELECT `+staffingRequirementColumns+`
+		FROM staffing_requirements
+		WHERE tenant_id = $1 AND restaurant_id = $2
+		ORDER BY day_of_week ASC, start_time ASC, role_id ASC
+	`, tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	requirements := []domain.StaffingRequirement{}
+	for rows.Next() {
+		s, err := scanStaffingRequirement(rows)
+		if err != nil {
+			return nil, err
+		}
+		requirements = append(requirements, *s)
+	}
+	return requirements, rows.Err()
+}
+
+// CreateStaffingRequirement creates a staffing requirement
+func (r *RotaRepository) CreateStaffingRequirement(s *domain.StaffingRequirement) (*domain.StaffingRequirement, error) {
+	return scanStaffingRequirement(r.db.QueryRow(`
+		INSERT INTO staffing_requirements (tenant_id, restaurant_id, role_id, day_of_week, start_time, end_time, min_staff)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING `+staffingRequirementColumns,
+		s.TenantID, s.RestaurantID, s.RoleID, s.DayOfWeek, s.StartTime, s.EndTime, s.MinStaff,
+	))
+}
+
+// DeleteStaffingRequirement deletes a staffing requirement
+func (r *RotaRepository) DeleteStaffingRequirement(tenantID, restaurantID, id int) error {
+	result, err := r.db.Exec(`
+		DELETE FROM staffing_requirements WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return fmt.Errorf("staffing requirement not found")
+	}
+	return nil
+}
+
+const rotaColumns = `
+	id, tenant_id, restaurant_id, week_start, status, COALESCE(notes, ''),
+	published_at, published_by, created_at, updated_at, created_by
+`
+
+func scanRota(row rowScanner) (*domain.Rota, error) {
+	rota := &domain.Rota{}
+	var publishedAt sql.NullTime
+	var publishedBy, createdBy sql.NullInt64
+	err := row.Scan(
+		&rota.ID, &rota.TenantID, &rota.RestaurantID, &rota.WeekStart, &rota.Status, &rota.Notes,
+		&publishedAt, &publishedBy, &rota.CreatedAt, &rota.UpdatedAt, &createdBy,
+	)
+	if err != nil {
+		return nil, err
+	}
+	rota.WeekStart = localDate(rota.WeekStart)
+	if publishedAt.Valid {
+		rota.PublishedAt = &publishedAt.Time
+	}
+	if publishedBy.Valid {
+		id := int(publishedBy.Int64)
+		rota.PublishedBy = &id
+	}
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		rota.CreatedBy = &id
+	}
+	return rota, nil
+}
+
+// CreateRota creates a draft rota for a week
+func (r *RotaRepository) CreateRota(rota *domain.Rota) (*domain.Rota, error) {
+	created, err := scanRota(r.db.QueryRow(`
+		INSERT INTO rotas (tenant_id, restaurant_id, week_start, status, notes, created_by)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		RETURNING `+rotaColumns,
+		rota.TenantID, rota.RestaurantID, rota.WeekStart.Format(domain.DateLayout), domain.RotaStatusDraft, rota.Notes, rota.CreatedBy,
+	))
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return nil, domain.ErrRotaExists
+	}
+	return created, err
+}
+
+// GetRota retrieves a rota without its shifts
+func (r *RotaRepository) GetRota(tenantID, restaurantID, id int) (*domain.Rota, error) {
+	rota, err := scanRota(r.db.QueryRow(`
+		SELECT `+rotaColumns+`
+		FROM rotas
+		WHERE id = $1 AND tenant_id = $2 AND restaurant_id = $3
+	`, id, tenantID, restaurantID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrRotaNotFound
+	}
+	return rota, err
+}
+
+// ListRotas retrieves the rotas of weeks starting between from and to
+func (r *RotaRepository) ListRotas(tenantID, restaurantID int, from, to time.Time) ([]domain.Rota, error) {
+	rows, err := r.db.Query(`
+		SELECT `+rotaColumns+`
+		FROM rotas
+		WHERE tenant_id = $1 AND restaurant_id = $2 AND week_start BETWEEN $3 AND $4
+		ORDER BY week_start DESC
+	`, tenantID, restaurantID, from.Format(domain.DateLayout), to.Format(domain.DateLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rotas := []domain.Rota{}
+	for rows.Next() {
+		rota, err := scanRota(rows)
+		if err != nil {
+			return nil, err
+		}
+		rotas = append(rotas, *rota)
+	}
+	return rotas, rows.Err()
+}
+
+// SetRotaStatus publishes or unpublishes a rota
+func (r *RotaRepository) SetRotaStatus(tenantID, restaurantID, id int, status string, publishedBy *int) (*domain.Rota, error) {
+	rota, err := scanRota(r.db.QueryRow(`
+		UPDATE rotas
+		SET status = $1,
+		    published_at = CASE WHEN $1 = 'published' THEN CURRENT_TIMESTAMP ELSE NULL END,
+		    published_by = CASE WHEN $1 = 'published' THEN $2::INTEGER ELSE NULL END,
+		    updated_at = CURRENT_TIMESTAMP
+		WHERE id = $3 AND tenant_id = $4 AND restaurant_id = $5
+		RETURNING `+rotaColumns,
+		status, publishedBy, id, tenantID, restaurantID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrRotaNotFound
+	}
+	return rota, err
+}
+
+const shiftColumns = `
+	s.id, s.tenant_id, s.restaurant_id, s.rota_id, s.employee_id,
+	COALESCE(e.first_name || ' ' || e.last_name, ''), s.role_id, s.template_id,
+	s.shift_date, s.start_time::text, s.end_time::text, s.break_minutes, COALESCE(s.notes, ''),
+	s.created_at, s.updated_at
+`
+
+func scanShift(row rowScanner) (*domain.Shift, error) {
+	s := &domain.Shift{}
+	var roleID, templateID sql.NullInt64
+	err := row.Scan(
+		&s.ID, &s.TenantID, &s.RestaurantID, &s.RotaID, &s.EmployeeID,
+		&s.EmployeeName, &roleID, &templateID,
+		&s.ShiftDate, &s.StartTime, &s.EndTime, &s.BreakMinutes, &s.Notes,
+		&s.CreatedAt, &s.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.ShiftDate = localDate(s.ShiftDate)
+	s.StartTime, s.EndTime = domain.FormatClock(s.StartTime), domain.FormatClock(s.EndTime)
+	if roleID.Valid {
+		id := int(roleID.Int64)
+		s.RoleID = &id
+	}
+	if templateID.Valid {
+		id := int(templateID.Int64)
+		s.TemplateID = &id
+	}
+	return s, nil
+}
+
+func (r *RotaRepository) queryShifts(query string, args ...interface{}) ([]domain.Shift, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shifts := []domain.Shift{}
+	for rows.Next() {
+		s, err := scanShift(rows)
+		if err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, *s)
+	}
+	return shifts, rows.Err()
+}
+
+// ListShifts retrieves the shifts of a rota
+func (r *RotaRepository) ListShifts(rotaID int) ([]domain.Shift, error) {
+	return r.queryShifts(`
+		SELECT `+shiftColumns+`
+		FROM shifts s
+		LEFT JOIN employees e ON e.id = s.employee_id
+		WHERE s.rota_id = $1
+		ORDER BY s.shift_date ASC, s.start_time ASC, s.employee_id ASC
+	`, rotaID)
+}
+
+// GetShift retrieves a shift of a rota
+func (r *RotaRepository) GetShift(rotaID, id int) (*domain.Shift, error) {
+	s, err := scanShift(r.db.QueryRow(`
+		SELECT `+shiftColumns+`
+		FROM shifts s
+		LEFT JOIN employees e ON e.id = s.employee_id
+		WHERE s.id = $1 AND s.rota_id = $2
+	`, id, rotaID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrShiftNotFound
+	}
+	return s, err
+}
+
+// CreateShift adds a shift to a rota
+func (r *RotaRepository) CreateShift(s *domain.Shift) (*domain.Shift, error) {
+	var id int
+	err := r.db.QueryRow(`
+		INSERT INTO shifts (
+			tenant_id, restaurant_id, rota_id, employee_id, role_id, template_id,
+			shift_date, start_time, end_time, break_minutes, notes
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, ''))
+		RETURNING id
+	`,
+		s.TenantID, s.RestaurantID, s.RotaID, s.EmployeeID, s.RoleID, s.TemplateID,
+		s.ShiftDate.Format(domain.DateLayout), s.StartTime, s.EndTime, s.BreakMinutes, s.Notes,
+	).Scan(&id)
+	if err != nil {
+		return nil, err
+	}
+	return r.GetShift(s.RotaID, id)
+}
+
+// UpdateShift replaces a shift's employee, role and times
+func (r *RotaRepository) UpdateShift(s *domain.Shift) (*domain.Shift, error) {
+	result, err := r.db.Exec(`
+		UPDATE shifts
+		SET employee_id = $1, role_id = $2, template_id = $3, shift_date = $4, start_time = $5,
+		    end_time = $6, break_minutes = $7, notes = NULLIF($8, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $9 AND rota_id = $10
+	`,
+		s.EmployeeID, s.RoleID, s.TemplateID, s.ShiftDate.Format(domain.DateLayout), s.StartTime,
+		s.EndTime, s.BreakMinutes, s.Notes, s.ID, s.RotaID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return nil, domain.ErrShiftNotFound
+	}
+	return r.GetShift(s.RotaID, s.ID)
+}
+
+// DeleteShift removes a shift from a rota
+func (r *RotaRepository) DeleteShift(rotaID, id int) error {
+	result, err := r.db.Exec(`DELETE FROM shifts WHERE id = $1 AND rota_id = $2`, id, rotaID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrShiftNotFound
+	}
+	return nil
+}
+
+// ListPublishedShifts retrieves published shifts between two dates, optionally for one employee
+func (r *RotaRepository) ListPublishedShifts(tenantID, restaurantID, employeeID int, from, to time.Time) ([]domain.Shift, error) {
+	return r.queryShifts(`
+		SELECT `+shiftColumns+`
+		FROM shifts s
+		JOIN rotas ro ON ro.id = s.rota_id
+		LEFT JOIN employees e ON e.id = s.employee_id
+		WHERE s.tenant_id = $1 AND s.restaurant_id = $2 AND ro.status = 'published'
+		  AND ($3 = 0 OR s.employee_id = $3)
+		  AND s.shift_date BETWEEN $4 AND $5
+		ORDER BY s.shift_date ASC, s.start_time ASC
+	`, tenantID, restaurantID, employeeID, from.Format(domain.DateLayout), to.Format(domain.DateLayout))
+}
+
+// GetPublishedShift retrieves an employee's first published shift on a date, or nil when unscheduled
+func (r *RotaRepository) GetPublishedShift(tenantID, restaurantID, employeeID int, date time.Time) (*domain.Shift, error) {
+	shifts, err := r.ListPublishedShifts(tenantID, restaurantID, employeeID, date, date)
+	if err != nil || len(shifts) == 0 {
+		return nil, err
+	}
+	return &shifts[0], nil
+}
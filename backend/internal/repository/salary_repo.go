@@ -212,7 +212,8 @@ func (r *SalaryRepository) UpdateSalary(salary *domain.Salary) error {
 	return err
 }
 
-// MarkAsPaid marks a salary record as paid
+// MarkAsPaid marks an approved, unpaid salary record as paid.
+// Returns ErrSalaryNotApproved when the salary has not been approved or is already paid.
 func (r *SalaryRepository) MarkAsPaid(tenantID, restaurantID, id int, paidBy int, paymentRef string) error {
 	query := `
 		UPDATE salaries
@@ -220,10 +221,17 @@ func (r *SalaryRepository) MarkAsPaid(tenantID, restaurantID, id int, paidBy int
 			is_paid = true, paid_at = CURRENT_TIMESTAMP, paid_by = $1,
 			payment_reference = $2, status = 'paid', updated_at = CURRENT_TIMESTAMP
 		WHERE id = $3 AND tenant_id = $4 AND restaurant_id = $5
+		  AND is_approved = true AND is_paid = false
 	`
 
-	_, err := r.db.Exec(query, paidBy, paymentRef, id, tenantID, restaurantID)
-	return err
+	result, err := r.db.Exec(query, paidBy, paymentRef, id, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrSalaryNotApproved
+	}
+	return nil
 }
 
 // GetEmployeeSalaries retrieves all salary records for an employee
@@ -0,0 +1,257 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-saas/internal/domain"
+)
+
+// SessionRepository stores user sessions and their refresh tokens
+type SessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *sql.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+const sessionColumns = `s.id, s.tenant_id, s.user_id, s.restaurant_id, s.device_name, s.user_agent,
+	COALESCE(host(s.ip_address), ''), s.created_at, s.last_seen_at, s.expires_at,
+	s.revoked_at, COALESCE(s.revoked_reason, '')`
+
+func scanSession(row rowScanner) (*domain.UserSession, error) {
+	var s domain.UserSession
+	var restaurantID sql.NullInt64
+	err := row.Scan(&s.ID, &s.TenantID, &s.UserID, &restaurantID, &s.DeviceName, &s.UserAgent,
+		&s.IPAddress, &s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt,
+		&s.RevokedAt, &s.RevokedReason)
+	if err != nil {
+		return nil, err
+	}
+	s.RestaurantID = nullIntPtr(restaurantID)
+	return &s, nil
+}
+
+// CreateSession stores a new session together with its first refresh token
+func (r *SessionRepository) CreateSession(ctx context.Context, session *domain.UserSession, tokenHash string) error {
+	// In stub mode, sessions are not stored
+	if r.db == nil {
+		session.CreatedAt, session.LastSeenAt = time.Now(), time.Now()
+		return nil
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO user_sessions (id, tenant_id, user_id, device_name, user_agent, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::inet, $7)
+		RETURNING created_at, last_seen_at
+	`, session.ID, session.TenantID, session.UserID, session.DeviceName, session.UserAgent,
+		session.IPAddress, session.ExpiresAt).Scan(&session.CreatedAt, &session.LastSeenAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES ($1, $2, $3)
+	`, session.ID, tokenHash, session.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// RotateRefreshToken spends the refresh token with oldHash and stores newHash in its place.
+// A token that was already spent revokes its whole session and returns ErrRefreshTokenReused.
+func (r *SessionRepository) RotateRefreshToken(ctx context.Context, oldHash, newHash string, expiresAt time.Time, client domain.SessionClient) (*domain.UserSession, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var tokenID int64
+	var tokenExpiresAt time.Time
+	var usedAt *time.Time
+	session, err := scanSession(rowFunc(func(dest ...interface{}) error {
+		return tx.QueryRowContext(ctx, `
+			SELECT rt.id, rt.expires_at, rt.used_at, `+sessionColumns+`
+			FROM refresh_tokens rt
+			JOIN user_sessions s ON s.id = rt.session_id
+			WHERE rt.token_hash = $1
+			FOR UPDATE OF rt, s
+		`, oldHash).Scan(append([]interface{}{&tokenID, &tokenExpiresAt, &usedAt}, dest...)...)
+	}))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if usedAt != nil {
+		if session.RevokedAt == nil {
+			if _, err = tx.ExecContext(ctx, `
+				UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $2 WHERE id = $1
+			`, session.ID, domain.SessionRevokedTokenReuse); err != nil {
+				return nil, err
+			}
+			if err = tx.Commit(); err != nil {
+				return nil, err
+			}
+		}
+		return nil, domain.ErrRefreshTokenReused
+	}
+	if !session.IsActive(now) || now.After(tokenExpiresAt) {
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE refresh_tokens SET used_at = CURRENT_TIMESTAMP WHERE id = $1`, tokenID); err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, `
+		INSERT INTO refresh_tokens (session_id, token_hash, expires_at) VALUES ($1, $2, $3)
+	`, session.ID, newHash, expiresAt); err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+	err = tx.QueryRowContext(ctx, `
+		UPDATE user_sessions
+		SET last_seen_at = CURRENT_TIMESTAMP, expires_at = $2, user_agent = $3,
+		    ip_address = COALESCE(NULLIF($4, '')::inet, ip_address)
+		WHERE id = $1
+		RETURNING last_seen_at
+	`, session.ID, expiresAt, client.UserAgent, client.IPAddress).Scan(&session.LastSeenAt)
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	session.ExpiresAt = expiresAt
+	session.UserAgent = client.UserAgent
+	if client.IPAddress != "" {
+		session.IPAddress = client.IPAddress
+	}
+	return session, nil
+}
+
+// GetSessionByRefreshToken returns the session a refresh token belongs to, nil when unknown
+func (r *SessionRepository) GetSessionByRefreshToken(ctx context.Context, tokenHash string) (*domain.UserSession, error) {
+	session, err := scanSession(r.db.QueryRowContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM refresh_tokens rt
+		JOIN user_sessions s ON s.id = rt.session_id
+		WHERE rt.token_hash = $1
+	`, tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+// IsSessionActive reports whether a session of the user is neither revoked nor expired
+func (r *SessionRepository) IsSessionActive(ctx context.Context, sessionID string, userID int) (bool, error) {
+	var active bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM user_sessions
+			WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		)
+	`, sessionID, userID).Scan(&active)
+	return active, err
+}
+
+// ListActiveSessions returns the user's active sessions, most recently used first
+func (r *SessionRepository) ListActiveSessions(ctx context.Context, tenantID, userID int) ([]domain.UserSession, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+sessionColumns+`
+		FROM user_sessions s
+		WHERE s.tenant_id = $1 AND s.user_id = $2
+		  AND s.revoked_at IS NULL AND s.expires_at > CURRENT_TIMESTAMP
+		ORDER BY s.last_seen_at DESC
+	`, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []domain.UserSession{}
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, *session)
+	}
+	return sessions, rows.Err()
+}
+
+// SetRestaurant records the restaurant an active session of the user switched to
+func (r *SessionRepository) SetRestaurant(ctx context.Context, sessionID string, userID, restaurantID int) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_sessions SET restaurant_id = $3
+		WHERE id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`, sessionID, userID, restaurantID)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrSessionRevoked
+	}
+	return nil
+}
+
+// RevokeSession revokes an active session of the user.
+// Returns domain.ErrSessionNotFound when there is no such active session.
+func (r *SessionRepository) RevokeSession(ctx context.Context, tenantID, userID int, sessionID, reason string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $4
+		WHERE id = $1 AND tenant_id = $2 AND user_id = $3 AND revoked_at IS NULL
+	`, sessionID, tenantID, userID, reason)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrSessionNotFound
+	}
+	return nil
+}
+
+// RevokeUserSessions revokes all active sessions of the user except exceptSessionID
+// (empty revokes every session) and returns how many were revoked
+func (r *SessionRepository) RevokeUserSessions(ctx context.Context, tenantID, userID int, exceptSessionID, reason string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_sessions SET revoked_at = CURRENT_TIMESTAMP, revoked_reason = $4
+		WHERE tenant_id = $1 AND user_id = $2 AND revoked_at IS NULL
+		  AND ($3 = '' OR id::text <> $3)
+	`, tenantID, userID, exceptSessionID, reason)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// rowFunc adapts a scan function to rowScanner
+type rowFunc func(dest ...interface{}) error
+
+func (f rowFunc) Scan(dest ...interface{}) error {
+	return f(dest...)
+}
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"pos-saas/internal/domain"
+)
+
+// SSORepository stores tenants' identity providers, linked provider accounts and sign-ins
+// in progress
+type SSORepository struct {
+	db *sql.DB
+}
+
+// NewSSORepository creates a new SSO repository
+func NewSSORepository(db *sql.DB) *SSORepository {
+	return &SSORepository{db: db}
+}
+
+const ssoConfigColumns = `
+	c.tenant_id, c.issuer_url, c.client_id, c.client_secret, c.scopes, c.groups_claim,
+	c.allowed_domains, c.jit_provisioning, c.default_restaurant_id, c.password_login_disabled,
+	c.enabled, c.updated_at`
+
+// getConfig loads a config and its role mappings. Returns domain.ErrSSONotConfigured when
+// the query finds none.
+func (r *SSORepository) getConfig(ctx context.Context, where string, arg interface{}) (*domain.SSOConfig, error) {
+	var cfg domain.SSOConfig
+	var scopes, domains pq.StringArray
+	var defaultRestaurantID sql.NullInt64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT `+ssoConfigColumns+`
+		FROM tenant_sso_configs c
+		JOIN tenants t ON t.id = c.tenant_id
+		WHERE `+where, arg).Scan(
+		&cfg.TenantID, &cfg.IssuerURL, &cfg.ClientID, &cfg.ClientSecret, &scopes, &cfg.GroupsClaim,
+		&domains, &cfg.JITProvisioning, &defaultRestaurantID, &cfg.PasswordLoginDisabled,
+		&cfg.Enabled, &cfg.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrSSONotConfigured
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SSO config: %w", err)
+	}
+	cfg.Scopes = []string(scopes)
+	cfg.AllowedDomains = []string(domains)
+	if cfg.AllowedDomains == nil {
+		cfg.AllowedDomains = []string{}
+	}
+	cfg.DefaultRestaurantID = nullIntPtr(defaultRestaurantID)
+	cfg.HasClientSecret = cfg.ClientSecret != ""
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT group_name, role_id, restaurant_id
+		FROM tenant_sso_role_mappings
+		WHERE tenant_id = $1
+		ORDER BY group_name, id
+	`, cfg.TenantID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get SSO role mappings: %w", err)
+	}
+	defer rows.Close()
+
+	cfg.RoleMappings = []domain.SSORoleMapping{}
+	for rows.Next() {
+		var mapping domain.SSORoleMapping
+		var restaurantID sql.NullInt64
+		if err := rows.Scan(&mapping.Group, &mapping.RoleID, &restaurantID); err != nil {
+			return nil, err
+		}
+		if restaurantID.Valid {
+			id := restaurantID.Int64
+			mapping.RestaurantID = &id
+		}
+		cfg.RoleMappings = append(cfg.RoleMappings, mapping)
+	}
+	return &cfg, rows.Err()
+}
+
+// GetConfig returns a tenant's identity provider. Returns domain.ErrSSONotConfigured when
+// there is none.
+func (r *SSORepository) GetConfig(ctx context.Context, tenantID int) (*domain.SSOConfig, error) {
+	return r.getConfig(ctx, "c.tenant_id = $1", tenantID)
+}
+
+// GetConfigBySlug returns the identity provider of an active tenant by its slug
+func (r *SSORepository) GetConfigBySlug(ctx context.Context, slug string) (*domain.SSOConfig, error) {
+	return r.getConfig(ctx, "t.slug = $1 AND t.is_active = true", slug)
+}
+
+// SaveConfig creates or replaces a tenant's identity provider and its role mappings
+func (r *SSORepository) SaveConfig(ctx context.Context, cfg *domain.SSOConfig, updatedBy int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO tenant_sso_configs (
+			tenant_id, issuer_url, client_id, client_secret, scopes, groups_claim, allowed_domains,
+			jit_provisioning, default_restaurant_id, password_login_disabled, enabled, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		ON CONFLICT (tenant_id) DO UPDATE SET
+			issuer_url = EXCLUDED.issuer_url,
+			client_id = EXCLUDED.client_id,
+			client_secret = EXCLUDED.client_secret,
+			scopes = EXCLUDED.scopes,
+			groups_claim = EXCLUDED.groups_claim,
+			allowed_domains = EXCLUDED.allowed_domains,
+			jit_provisioning = EXCLUDED.jit_provisioning,
+			default_restaurant_id = EXCLUDED.default_restaurant_id,
+			password_login_disabled = EXCLUDED.password_login_disabled,
+			enabled = EXCLUDED.enabled,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING updated_at
+	`, cfg.TenantID, cfg.IssuerURL, cfg.ClientID, cfg.ClientSecret, pq.Array(cfg.Scopes), cfg.GroupsClaim,
+		pq.Array(cfg.AllowedDomains), cfg.JITProvisioning, cfg.DefaultRestaurantID, cfg.PasswordLoginDisabled,
+		cfg.Enabled, updatedBy).Scan(&cfg.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save SSO config: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, `DELETE FROM tenant_sso_role_mappings WHERE tenant_id = $1`, cfg.TenantID); err != nil {
+		return err
+	}
+	for _, mapping := range cfg.RoleMappings {
+		if _, err = tx.ExecContext(ctx, `
+			INSERT INTO tenant_sso_role_mappings (tenant_id, group_name, role_id, restaurant_id)
+			VALUES ($1, $2, $3, $4)
+		`, cfg.TenantID, mapping.Group, mapping.RoleID, mapping.RestaurantID); err != nil {
+			return fmt.Errorf("failed to save SSO role mapping: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// DeleteConfig removes a tenant's identity provider. Linked accounts are kept so they are
+// recognized again if the same provider is configured later. Returns
+// domain.ErrSSONotConfigured when there is none.
+func (r *SSORepository) DeleteConfig(ctx context.Context, tenantID int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tenant_sso_configs WHERE tenant_id = $1`, tenantID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return domain.ErrSSONotConfigured
+	}
+	return nil
+}
+
+// PasswordLoginDisabled reports whether the tenant requires single sign-on
+func (r *SSORepository) PasswordLoginDisabled(ctx context.Context, tenantID int) (bool, error) {
+	var disabled bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT password_login_disabled AND enabled FROM tenant_sso_configs WHERE tenant_id = $1
+	`, tenantID).Scan(&disabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return disabled, err
+}
+
+// CreateLoginRequest stores a sign-in started at the identity provider, and clears out
+// requests that expired more than a day ago
+func (r *SSORepository) CreateLoginRequest(ctx context.Context, req *domain.SSOLoginRequest, stateHash string) error {
+	if _, err := r.db.ExecContext(ctx, `
+		DELETE FROM sso_login_requests WHERE expires_at < CURRENT_TIMESTAMP - INTERVAL '1 day'
+	`); err != nil {
+		return err
+	}
+	return r.db.QueryRowContext(ctx, `
+		INSERT INTO sso_login_requests (tenant_id, state_hash, nonce, code_verifier, redirect_path, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, req.TenantID, stateHash, req.Nonce, req.CodeVerifier, req.RedirectPath, req.ExpiresAt).Scan(&req.ID)
+}
+
+// ClaimLoginRequest marks the sign-in of this state as returned from the provider, so a
+// state can only be used once. Returns domain.ErrSSOInvalidState when it is unknown, used
+// or expired.
+func (r *SSORepository) ClaimLoginRequest(ctx context.Context, stateHash string) (*domain.SSOLoginRequest, error) {
+	var req domain.SSOLoginRequest
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE sso_login_requests SET callback_at = CURRENT_TIMESTAMP
+		WHERE state_hash = $1 AND callback_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+		RETURNING id, tenant_id, nonce, code_verifier, redirect_path, expires_at
+	`, stateHash).Scan(&req.ID, &req.TenantID, &req.Nonce, &req.CodeVerifier, &req.RedirectPath, &req.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrSSOInvalidState
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// SetHandoff records the signed-in user and the code the frontend exchanges for tokens
+func (r *SSORepository) SetHandoff(ctx context.Context, id int64, userID int, handoffHash string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE sso_login_requests SET user_id = $2, handoff_hash = $3, handoff_expires_at = $4
+		WHERE id = $1
+	`, id, userID, handoffHash, expiresAt)
+	return err
+}
+
+// ClaimHandoff redeems a handoff code once and returns the tenant and user it signs in.
+// Returns domain.ErrSSOInvalidState when it is unknown, used or expired.
+func (r *SSORepository) ClaimHandoff(ctx context.Context, handoffHash string) (tenantID, userID int, err error) {
+	err = r.db.QueryRowContext(ctx, `
+		UPDATE sso_login_requests SET exchanged_at = CURRENT_TIMESTAMP
+		WHERE handoff_hash = $1 AND exchanged_at IS NULL AND handoff_expires_at > CURRENT_TIMESTAMP
+		RETURNING tenant_id, user_id
+	`, handoffHash).Scan(&tenantID, &userID)
+	if err == sql.ErrNoRows {
+		return 0, 0, domain.ErrSSOInvalidState
+	}
+	return tenantID, userID, err
+}
+
+// GetIdentityUser returns the user linked to a provider account, or 0 when none is
+func (r *SSORepository) GetIdentityUser(ctx context.Context, tenantID int, issuer, subject string) (int, error) {
+	var userID int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT user_id FROM user_identities WHERE tenant_id = $1 AND issuer = $2 AND subject = $3
+	`, tenantID, issuer, subject).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return userID, err
+}
+
+// HasIdentity reports whether a user has signed in with an account of this issuer
+func (r *SSORepository) HasIdentity(ctx context.Context, tenantID, userID int, issuer string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM user_identities WHERE tenant_id = $1 AND user_id = $2 AND issuer = $3)
+	`, tenantID, userID, issuer).Scan(&exists)
+	return exists, err
+}
+
+// LinkIdentity links a provider account to a user, or records another sign-in with it
+func (r *SSORepository) LinkIdentity(ctx context.Context, tenantID, userID int, issuer, subject, email string) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_identities (tenant_id, user_id, issuer, subject, email, last_login_at)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), CURRENT_TIMESTAMP)
+		ON CONFLICT (tenant_id, issuer, subject) DO UPDATE SET
+			user_id = EXCLUDED.user_id,
+			email = EXCLUDED.email,
+			last_login_at = CURRENT_TIMESTAMP
+	`, tenantID, userID, issuer, subject, email)
+	if err != nil {
+		return fmt.Errorf("failed to link identity: %w", err)
+	}
+	return nil
+}
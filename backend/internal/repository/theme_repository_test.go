@@ -344,10 +344,6 @@ func TestThemeRepositoryConfigHandling(t *testing.T) {
 }
 
 // Helper functions
-func ptrInt64(v int64) *int64 {
-	return &v
-}
-
 func ptrTime(v time.Time) *time.Time {
 	return &v
 }
@@ -0,0 +1,320 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"pos-saas/internal/domain"
+)
+
+// TwoFactorRepository stores TOTP enrollments, recovery codes, login challenges, trusted
+// devices and the tenant two-factor policy
+type TwoFactorRepository struct {
+	db *sql.DB
+}
+
+// NewTwoFactorRepository creates a new two-factor repository
+func NewTwoFactorRepository(db *sql.DB) *TwoFactorRepository {
+	return &TwoFactorRepository{db: db}
+}
+
+// GetTenantPolicy returns the tenant's two-factor policy
+func (r *TwoFactorRepository) GetTenantPolicy(ctx context.Context, tenantID int) (string, error) {
+	// In stub mode, 2FA is optional
+	if r.db == nil {
+		return domain.TwoFactorPolicyOptional, nil
+	}
+
+	var policy string
+	err := r.db.QueryRowContext(ctx, `SELECT two_factor_policy FROM tenants WHERE id = $1`, tenantID).Scan(&policy)
+	if err == sql.ErrNoRows {
+		return domain.TwoFactorPolicyOptional, nil
+	}
+	return policy, err
+}
+
+// SetTenantPolicy updates the tenant's two-factor policy
+func (r *TwoFactorRepository) SetTenantPolicy(ctx context.Context, tenantID int, policy string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE tenants SET two_factor_policy = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2
+	`, policy, tenantID)
+	if err != nil {
+		return fmt.Errorf("failed to update two-factor policy: %w", err)
+	}
+	return nil
+}
+
+// GetTwoFactor returns the user's enrollment, nil when the user never started one
+func (r *TwoFactorRepository) GetTwoFactor(ctx context.Context, userID int) (*domain.TwoFactor, error) {
+	if r.db == nil {
+		return nil, nil
+	}
+
+	var tf domain.TwoFactor
+	err := r.db.QueryRowContext(ctx, `
+		SELECT user_id, secret_encrypted, enabled_at, last_used_step, created_at
+		FROM user_two_factor WHERE user_id = $1
+	`, userID).Scan(&tf.UserID, &tf.SecretEncrypted, &tf.EnabledAt, &tf.LastUsedStep, &tf.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &tf, nil
+}
+
+// SavePendingSecret starts or restarts an enrollment. An enabled enrollment is left untouched.
+func (r *TwoFactorRepository) SavePendingSecret(ctx context.Context, userID int, secretEncrypted string) error {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_two_factor (user_id, secret_encrypted)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE
+		SET secret_encrypted = EXCLUDED.secret_encrypted, last_used_step = 0, updated_at = CURRENT_TIMESTAMP
+		WHERE user_two_factor.enabled_at IS NULL
+	`, userID, secretEncrypted)
+	if err != nil {
+		return fmt.Errorf("failed to save two-factor secret: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrTwoFactorAlreadyEnabled
+	}
+	return nil
+}
+
+// Enable confirms a pending enrollment with the step of the first valid code and stores the
+// recovery codes
+func (r *TwoFactorRepository) Enable(ctx context.Context, userID int, step int64, recoveryHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE user_two_factor
+		SET enabled_at = CURRENT_TIMESTAMP, last_used_step = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND enabled_at IS NULL
+	`, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable two-factor authentication: %w", err)
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	if _, err = tx.ExecContext(ctx, `UPDATE users SET two_factor_enabled = true WHERE id = $1`, userID); err != nil {
+		return err
+	}
+	if err = replaceRecoveryCodes(ctx, tx, userID, recoveryHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Disable removes the enrollment with its recovery codes and trusted devices
+func (r *TwoFactorRepository) Disable(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM user_two_factor WHERE user_id = $1`,
+		`DELETE FROM two_factor_recovery_codes WHERE user_id = $1`,
+		`DELETE FROM trusted_devices WHERE user_id = $1`,
+		`UPDATE users SET two_factor_enabled = false WHERE id = $1`,
+	} {
+		if _, err = tx.ExecContext(ctx, query, userID); err != nil {
+			return fmt.Errorf("failed to disable two-factor authentication: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// UseStep records the time step of an accepted code. Returns false when the step, or a
+// later one, was already used, so the code is a replay.
+func (r *TwoFactorRepository) UseStep(ctx context.Context, userID int, step int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_two_factor SET last_used_step = $2, updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND enabled_at IS NOT NULL AND last_used_step < $2
+	`, userID, step)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// ReplaceRecoveryCodes invalidates the user's recovery codes and stores new ones
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(ctx context.Context, userID int, hashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = replaceRecoveryCodes(ctx, tx, userID, hashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(ctx context.Context, tx *sql.Tx, userID int, hashes []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM two_factor_recovery_codes WHERE user_id = $1`, userID); err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO two_factor_recovery_codes (user_id, code_hash) VALUES ($1, $2)
+		`, userID, hash); err != nil {
+			return fmt.Errorf("failed to store recovery codes: %w", err)
+		}
+	}
+	return nil
+}
+
+// UseRecoveryCode spends an unused recovery code. Returns false when there is none.
+func (r *TwoFactorRepository) UseRecoveryCode(ctx context.Context, userID int, hash string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE two_factor_recovery_codes SET used_at = CURRENT_TIMESTAMP
+		WHERE id = (
+			SELECT id FROM two_factor_recovery_codes
+			WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+			LIMIT 1
+		) AND used_at IS NULL
+	`, userID, hash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// CountRecoveryCodes returns how many recovery codes the user has left
+func (r *TwoFactorRepository) CountRecoveryCodes(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM two_factor_recovery_codes WHERE user_id = $1 AND used_at IS NULL
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// CreateChallenge stores a pending second login step
+func (r *TwoFactorRepository) CreateChallenge(ctx context.Context, challenge *domain.TwoFactorChallenge, tokenHash, ip string) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO two_factor_challenges (user_id, token_hash, setup_required, expires_at, ip_address)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::inet)
+		RETURNING id
+	`, challenge.UserID, tokenHash, challenge.SetupRequired, challenge.ExpiresAt, ip).Scan(&challenge.ID)
+	if err != nil {
+		return fmt.Errorf("failed to create two-factor challenge: %w", err)
+	}
+	return nil
+}
+
+// GetChallenge returns an unused, unexpired challenge, nil when there is none
+func (r *TwoFactorRepository) GetChallenge(ctx context.Context, tokenHash string) (*domain.TwoFactorChallenge, error) {
+	var c domain.TwoFactorChallenge
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, user_id, setup_required, attempts, expires_at
+		FROM two_factor_challenges
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`, tokenHash).Scan(&c.ID, &c.UserID, &c.SetupRequired, &c.Attempts, &c.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// FailChallenge counts a wrong code; the challenge is spent after the maximum attempts
+func (r *TwoFactorRepository) FailChallenge(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE two_factor_challenges
+		SET attempts = attempts + 1,
+			used_at = CASE WHEN attempts + 1 >= $2 THEN CURRENT_TIMESTAMP ELSE used_at END
+		WHERE id = $1
+	`, id, domain.MaxTwoFactorAttempts)
+	return err
+}
+
+// ConsumeChallenge marks a challenge as used. Returns domain.ErrInvalidTwoFactorChallenge
+// when it was used or expired in the meantime.
+func (r *TwoFactorRepository) ConsumeChallenge(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE two_factor_challenges SET used_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND used_at IS NULL AND expires_at > CURRENT_TIMESTAMP
+	`, id)
+	if err != nil {
+		return err
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return domain.ErrInvalidTwoFactorChallenge
+	}
+	return nil
+}
+
+// CreateTrustedDevice remembers a device for the user until expiresAt
+func (r *TwoFactorRepository) CreateTrustedDevice(ctx context.Context, userID int, tokenHash, deviceName, ip string, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO trusted_devices (user_id, token_hash, device_name, ip_address, expires_at)
+		VALUES ($1, $2, $3, NULLIF($4, '')::inet, $5)
+	`, userID, tokenHash, deviceName, ip, expiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to remember device: %w", err)
+	}
+	return nil
+}
+
+// IsTrustedDevice reports whether the token belongs to an unexpired remembered device of the user
+func (r *TwoFactorRepository) IsTrustedDevice(ctx context.Context, userID int, tokenHash string) (bool, error) {
+	if r.db == nil {
+		return false, nil
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE trusted_devices SET last_used_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND token_hash = $2 AND expires_at > CURRENT_TIMESTAMP
+	`, userID, tokenHash)
+	if err != nil {
+		return false, err
+	}
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
+
+// CountTrustedDevices returns how many unexpired remembered devices the user has
+func (r *TwoFactorRepository) CountTrustedDevices(ctx context.Context, userID int) (int, error) {
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM trusted_devices WHERE user_id = $1 AND expires_at > CURRENT_TIMESTAMP
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// DeleteTrustedDevices forgets all remembered devices of the user
+func (r *TwoFactorRepository) DeleteTrustedDevices(ctx context.Context, userID int) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM trusted_devices WHERE user_id = $1`, userID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
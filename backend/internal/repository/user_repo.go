@@ -28,12 +28,12 @@ func (r *UserRepository) GetByID(ctx context.Context, userID int) (*domain.User,
 	var avatarURL sql.NullString
 
 	err := r.db.QueryRowContext(ctx, `
-		SELECT id, tenant_id, restaurant_id, email, password_hash, name, phone, avatar_url, role, status, created_at, updated_at
+		SELECT id, tenant_id, restaurant_id, email, password_hash, name, phone, avatar_url, role, status, email_verified_at, created_at, updated_at
 		FROM users
 		WHERE id = $1
 	`, userID).Scan(
 		&user.ID, &user.TenantID, &user.RestaurantID, &user.Email, &user.PasswordHash,
-		&user.Name, &user.Phone, &avatarURL, &user.Role, &user.Status,
+		&user.Name, &user.Phone, &avatarURL, &user.Role, &user.Status, &user.EmailVerifiedAt,
 		&user.CreatedAt, &user.UpdatedAt,
 	)
 
@@ -85,6 +85,19 @@ func (r *UserRepository) UpdatePassword(ctx context.Context, userID int, hashedP
 	return nil
 }
 
+// MarkEmailVerified records that the user confirmed their email address
+func (r *UserRepository) MarkEmailVerified(ctx context.Context, userID int) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE users SET email_verified_at = COALESCE(email_verified_at, CURRENT_TIMESTAMP), updated_at = NOW()
+		WHERE id = $1
+	`, userID)
+	if err != nil {
+		log.Printf("[UserRepository] ERROR: Failed to mark email verified for user %d: %v\n", userID, err)
+		return fmt.Errorf("failed to mark email verified: %w", err)
+	}
+	return nil
+}
+
 // UpdateProfile updates a user's profile information (name and avatar_url)
 func (r *UserRepository) UpdateProfile(ctx context.Context, userID int, name string, avatarURL *string) error {
 	log.Printf("[UserRepository] UpdateProfile for user %d\n", userID)
@@ -157,6 +170,38 @@ func (r *UserRepository) GetByEmail(ctx context.Context, email string) (*domain.
 	return &user, nil
 }
 
+// GetByEmailInTenant retrieves a tenant's user by email address. Returns nil when the
+// tenant has no user with that email.
+func (r *UserRepository) GetByEmailInTenant(ctx context.Context, tenantID int64, email string) (*domain.User, error) {
+	log.Printf("[UserRepository] GetByEmailInTenant: tenant %d, %s\n", tenantID, email)
+
+	var user domain.User
+	var avatarURL sql.NullString
+
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, tenant_id, restaurant_id, email, password_hash, name, phone, avatar_url, role, status, created_at, updated_at
+		FROM users
+		WHERE tenant_id = $1 AND LOWER(email) = LOWER($2)
+	`, tenantID, email).Scan(
+		&user.ID, &user.TenantID, &user.RestaurantID, &user.Email, &user.PasswordHash,
+		&user.Name, &user.Phone, &avatarURL, &user.Role, &user.Status,
+		&user.CreatedAt, &user.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	if avatarURL.Valid {
+		user.AvatarURL = avatarURL.String
+	}
+
+	return &user, nil
+}
+
 // VerifyPassword checks if a password matches the stored hash
 func (r *UserRepository) VerifyPassword(ctx context.Context, userID int, password string) (bool, error) {
 	log.Printf("[UserRepository] VerifyPassword for user %d\n", userID)
@@ -3,7 +3,6 @@ package repository
 import (
 	"context"
 	"database/sql"
-	"errors"
 	"time"
 
 	"pos-saas/internal/domain"
@@ -19,44 +18,67 @@ func NewUserRoleRepository(db *sql.DB) *UserRoleRepository {
 	return &UserRoleRepository{db: db}
 }
 
-// GetUserRoles retrieves all roles assigned to a user in a specific tenant
+const userRoleColumns = `r.id, r.tenant_id, r.restaurant_id, r.role_name, COALESCE(r.description, ''),
+		       r.role_code, r.access_level, r.is_active, COALESCE(r.is_system_role, false),
+		       r.display_order, r.created_at, r.updated_at`
+
+// GetUserRoles retrieves all active roles assigned to a user in a specific tenant, whichever
+// restaurants the assignments are limited to
 func (r *UserRoleRepository) GetUserRoles(ctx context.Context, userID int64, tenantID int64) ([]domain.Role, error) {
 	query := `
-		SELECT DISTINCT r.id, r.tenant_id, r.name, r.description, r.is_system, r.created_at, r.updated_at
-		FROM user_roles ur
-		JOIN roles r ON ur.role_id = r.id
-		WHERE ur.user_id = $1 AND ur.tenant_id = $2
-		ORDER BY r.is_system DESC, r.name ASC
+		SELECT ` + userRoleColumns + `
+		FROM roles r
+		WHERE r.tenant_id = $2 AND r.is_active = true
+		  AND EXISTS (SELECT 1 FROM user_roles ur WHERE ur.role_id = r.id AND ur.user_id = $1 AND ur.tenant_id = $2)
+		ORDER BY r.is_system_role DESC, r.role_name ASC
+	`
+	return r.queryRoles(ctx, query, userID, tenantID)
+}
+
+// GetUserRolesInRestaurant retrieves the active roles a user holds in one restaurant: those
+// assigned tenant-wide and those limited to the restaurant
+func (r *UserRoleRepository) GetUserRolesInRestaurant(ctx context.Context, userID, tenantID, restaurantID int64) ([]domain.Role, error) {
+	query := `
+		SELECT ` + userRoleColumns + `
+		FROM roles r
+		WHERE r.tenant_id = $2 AND r.is_active = true
+		  AND EXISTS (
+			SELECT 1 FROM user_roles ur
+			WHERE ur.role_id = r.id AND ur.user_id = $1 AND ur.tenant_id = $2
+			  AND (ur.restaurant_id IS NULL OR ur.restaurant_id = $3)
+		  )
+		ORDER BY r.is_system_role DESC, r.role_name ASC
 	`
+	return r.queryRoles(ctx, query, userID, tenantID, restaurantID)
+}
 
-	rows, err := r.db.QueryContext(ctx, query, userID, tenantID)
+func (r *UserRoleRepository) queryRoles(ctx context.Context, query string, args ...interface{}) ([]domain.Role, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var roles []domain.Role
+	roles := []domain.Role{}
 	for rows.Next() {
 		role := domain.Role{}
-		// TODO: Update to use correct HR Role fields (RoleName, etc.)
-		// This is a placeholder until schema is clarified
-		var tempName, tempDesc string
-		var tempIsSystem bool
 		err := rows.Scan(
 			&role.ID,
 			&role.TenantID,
-			&tempName,          // Will need to map to correct field
-			&tempDesc,          // Will need to map to correct field
-			&tempIsSystem,      // Will need to map to correct field
+			&role.RestaurantID,
+			&role.RoleName,
+			&role.Description,
+			&role.RoleCode,
+			&role.AccessLevel,
+			&role.IsActive,
+			&role.IsSystemRole,
+			&role.DisplayOrder,
 			&role.CreatedAt,
 			&role.UpdatedAt,
 		)
 		if err != nil {
 			return nil, err
 		}
-		// Map temp values to Role fields
-		role.RoleName = tempName
-		role.Description = tempDesc
 		roles = append(roles, role)
 	}
 
@@ -67,6 +89,80 @@ func (r *UserRoleRepository) GetUserRoles(ctx context.Context, userID int64, ten
 	return roles, nil
 }
 
+// GetUserRoleAssignments lists a user's role assignments with the restaurant each one is
+// limited to, tenant-wide assignments first
+func (r *UserRoleRepository) GetUserRoleAssignments(ctx context.Context, tenantID, userID int64) ([]domain.UserRole, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT ur.id, ur.tenant_id, ur.user_id, ur.role_id, r.role_name, ur.restaurant_id,
+		       COALESCE(rs.name, ''), COALESCE(ur.assigned_by, 0), ur.created_at, ur.updated_at
+		FROM user_roles ur
+		JOIN roles r ON r.id = ur.role_id AND r.tenant_id = ur.tenant_id AND r.is_active = true
+		LEFT JOIN restaurants rs ON rs.id = ur.restaurant_id
+		WHERE ur.tenant_id = $1 AND ur.user_id = $2
+		ORDER BY ur.restaurant_id NULLS FIRST, r.role_name ASC
+	`, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	assignments := []domain.UserRole{}
+	for rows.Next() {
+		var a domain.UserRole
+		var restaurantID sql.NullInt64
+		if err := rows.Scan(&a.ID, &a.TenantID, &a.UserID, &a.RoleID, &a.RoleName, &restaurantID,
+			&a.RestaurantName, &a.AssignedBy, &a.CreatedAt, &a.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if restaurantID.Valid {
+			a.RestaurantID = &restaurantID.Int64
+		}
+		assignments = append(assignments, a)
+	}
+	return assignments, rows.Err()
+}
+
+// GetAccessibleRestaurants lists the tenant's active restaurants a user can work in: every
+// restaurant when one of their roles is tenant-wide, otherwise the restaurants their roles
+// are limited to, plus their default restaurant
+func (r *UserRoleRepository) GetAccessibleRestaurants(ctx context.Context, tenantID, userID, defaultRestaurantID int64) ([]domain.RestaurantAccess, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT rs.id, rs.name, rs.slug
+		FROM restaurants rs
+		WHERE rs.tenant_id = $1 AND COALESCE(rs.status, 'active') = 'active'
+		  AND (rs.id = $3 OR EXISTS (
+			SELECT 1 FROM user_roles ur
+			JOIN roles r ON r.id = ur.role_id AND r.tenant_id = ur.tenant_id AND r.is_active = true
+			WHERE ur.tenant_id = $1 AND ur.user_id = $2
+			  AND (ur.restaurant_id IS NULL OR ur.restaurant_id = rs.id)
+		  ))
+		ORDER BY rs.name ASC
+	`, tenantID, userID, defaultRestaurantID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	restaurants := []domain.RestaurantAccess{}
+	for rows.Next() {
+		var rs domain.RestaurantAccess
+		if err := rows.Scan(&rs.ID, &rs.Name, &rs.Slug); err != nil {
+			return nil, err
+		}
+		restaurants = append(restaurants, rs)
+	}
+	return restaurants, rows.Err()
+}
+
+// IsTenantRestaurant reports whether a restaurant belongs to the tenant
+func (r *UserRoleRepository) IsTenantRestaurant(ctx context.Context, tenantID, restaurantID int64) (bool, error) {
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS(SELECT 1 FROM restaurants WHERE id = $1 AND tenant_id = $2)
+	`, restaurantID, tenantID).Scan(&exists)
+	return exists, err
+}
+
 // GetRoleUsers retrieves all users assigned to a specific role
 func (r *UserRoleRepository) GetRoleUsers(ctx context.Context, roleID int64) ([]domain.User, error) {
 	query := `
@@ -108,42 +204,56 @@ func (r *UserRoleRepository) GetRoleUsers(ctx context.Context, roleID int64) ([]
 	return users, nil
 }
 
-// Assign assigns a role to a user
-func (r *UserRoleRepository) Assign(ctx context.Context, tenantID int64, userID int64, roleID int64, assignedBy int64) error {
-	query := `
-		INSERT INTO user_roles (tenant_id, user_id, role_id, assigned_by, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6)
-		ON CONFLICT (tenant_id, user_id, role_id) DO NOTHING
-	`
-
+// Assign assigns a role to a user in one restaurant, or in every restaurant when
+// restaurantID is nil. A tenant-wide assignment replaces the role's restaurant assignments.
+func (r *UserRoleRepository) Assign(ctx context.Context, tenantID int64, userID int64, roleID int64, restaurantID *int64, assignedBy int64) error {
 	now := time.Now()
-	_, err := r.db.ExecContext(ctx, query, tenantID, userID, roleID, assignedBy, now, now)
+	if restaurantID != nil {
+		_, err := r.db.ExecContext(ctx, `
+			INSERT INTO user_roles (tenant_id, user_id, role_id, restaurant_id, assigned_by, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			ON CONFLICT (tenant_id, user_id, role_id, restaurant_id) WHERE restaurant_id IS NOT NULL DO NOTHING
+		`, tenantID, userID, roleID, *restaurantID, assignedBy, now, now)
+		return err
+	}
 
-	return err
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM user_roles
+		WHERE tenant_id = $1 AND user_id = $2 AND role_id = $3 AND restaurant_id IS NOT NULL
+	`, tenantID, userID, roleID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO user_roles (tenant_id, user_id, role_id, assigned_by, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (tenant_id, user_id, role_id) WHERE restaurant_id IS NULL DO NOTHING
+	`, tenantID, userID, roleID, assignedBy, now, now); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// Remove removes a role from a user
-func (r *UserRoleRepository) Remove(ctx context.Context, tenantID int64, userID int64, roleID int64) error {
+// Remove removes a role from a user in one restaurant, or every assignment of the role when
+// restaurantID is nil, and returns how many assignments were removed. A tenant-wide
+// assignment is not affected by removing the role in one restaurant.
+func (r *UserRoleRepository) Remove(ctx context.Context, tenantID int64, userID int64, roleID int64, restaurantID *int64) (int64, error) {
 	query := `
 		DELETE FROM user_roles
 		WHERE tenant_id = $1 AND user_id = $2 AND role_id = $3
+		  AND ($4::int IS NULL OR restaurant_id = $4)
 	`
 
-	result, err := r.db.ExecContext(ctx, query, tenantID, userID, roleID)
+	result, err := r.db.ExecContext(ctx, query, tenantID, userID, roleID, restaurantID)
 	if err != nil {
-		return err
+		return 0, err
 	}
-
-	rowsAffected, err := result.RowsAffected()
-	if err != nil {
-		return err
-	}
-
-	if rowsAffected == 0 {
-		return errors.New("user role assignment not found")
-	}
-
-	return nil
+	return result.RowsAffected()
 }
 
 // RemoveAll removes all roles from a user
@@ -157,17 +267,19 @@ func (r *UserRoleRepository) RemoveAll(ctx context.Context, tenantID int64, user
 	return err
 }
 
-// HasRole checks if a user has a specific role in a tenant
-func (r *UserRoleRepository) HasRole(ctx context.Context, tenantID int64, userID int64, roleID int64) (bool, error) {
+// HasRole checks if a user holds a role tenant-wide, or in a restaurant when restaurantID
+// is set (tenant-wide or limited to that restaurant)
+func (r *UserRoleRepository) HasRole(ctx context.Context, tenantID int64, userID int64, roleID int64, restaurantID *int64) (bool, error) {
 	query := `
 		SELECT EXISTS(
 			SELECT 1 FROM user_roles
 			WHERE tenant_id = $1 AND user_id = $2 AND role_id = $3
+			  AND (restaurant_id IS NULL OR restaurant_id = $4)
 		)
 	`
 
 	var exists bool
-	err := r.db.QueryRowContext(ctx, query, tenantID, userID, roleID).Scan(&exists)
+	err := r.db.QueryRowContext(ctx, query, tenantID, userID, roleID, restaurantID).Scan(&exists)
 
 	return exists, err
 }
@@ -185,13 +297,13 @@ func (r *UserRoleRepository) CountUserRoles(ctx context.Context, tenantID int64,
 	return count, err
 }
 
-// GetUsersWithRole gets all users who have a specific role in a tenant
+// GetUsersWithRole gets all users who hold a specific role in every restaurant of a tenant
 func (r *UserRoleRepository) GetUsersWithRole(ctx context.Context, tenantID int64, roleID int64) ([]domain.User, error) {
 	query := `
 		SELECT DISTINCT u.id, u.email, u.tenant_id, u.name, u.role, u.created_at, u.updated_at
 		FROM user_roles ur
 		JOIN users u ON ur.user_id = u.id
-		WHERE ur.tenant_id = $1 AND ur.role_id = $2
+		WHERE ur.tenant_id = $1 AND ur.role_id = $2 AND ur.restaurant_id IS NULL
 		ORDER BY u.email ASC
 	`
 
@@ -234,6 +234,32 @@ func (r *UserSettingsRepository) RecordPasswordChange(ctx context.Context, userI
 	return nil
 }
 
+// RecentPasswordHashes returns the hashes of the user's last passwords, newest first
+func (r *UserSettingsRepository) RecentPasswordHashes(ctx context.Context, userID int64, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT password_hash
+		FROM password_change_history
+		WHERE user_id = $1
+		ORDER BY changed_at DESC, id DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		log.Printf("[UserSettings] ERROR fetching password hashes for user %d: %v", userID, err)
+		return nil, fmt.Errorf("failed to fetch password history: %w", err)
+	}
+	defer rows.Close()
+
+	var hashes []string
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, fmt.Errorf("failed to scan password history: %w", err)
+		}
+		hashes = append(hashes, hash)
+	}
+	return hashes, rows.Err()
+}
+
 // GetPasswordChangeHistory retrieves password change history for a user
 func (r *UserSettingsRepository) GetPasswordChangeHistory(ctx context.Context, userID int64, limit int) ([]domain.PasswordChangeHistory, error) {
 	query := `
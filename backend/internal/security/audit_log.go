@@ -118,7 +118,7 @@ func (alm *AuditLogManager) flushLocked(ctx context.Context) error {
 				details, ip_address, user_agent, status, error_message,
 				duration_ms, created_at
 			)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+			VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::inet, $8, $9, $10, $11, $12)
 		`
 
 		_, err := alm.db.ExecContext(ctx, query,
@@ -314,7 +314,7 @@ func (alm *AuditLogManager) GetFailedAttempts(ctx context.Context, tenantID int6
 
 	query := `
 		SELECT id, tenant_id, user_id, action, resource_type, resource_id,
-		       details, ip_address, user_agent, status, error_message,
+		       details, COALESCE(host(ip_address), ''), user_agent, status, error_message,
 		       duration_ms, created_at
 		FROM audit_log
 		WHERE tenant_id = $1
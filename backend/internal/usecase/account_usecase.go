@@ -0,0 +1,298 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/pkg/mailer"
+	"pos-saas/internal/repository"
+)
+
+// AccountUseCase handles password reset, email verification and email change.
+// Tokens are single-use, expire, and are only stored as hashes.
+type AccountUseCase struct {
+	authRepo     *repository.AuthRepository
+	userRepo     *repository.UserRepository
+	settingsRepo *repository.UserSettingsRepository
+	tokenRepo    *repository.AccountTokenRepository
+	sessions     *SessionUseCase
+	mailer       mailer.Mailer
+	appURL       string
+}
+
+// NewAccountUseCase creates new account use case. appURL is the dashboard URL that the
+// links in emails point to.
+func NewAccountUseCase(
+	authRepo *repository.AuthRepository,
+	userRepo *repository.UserRepository,
+	settingsRepo *repository.UserSettingsRepository,
+	tokenRepo *repository.AccountTokenRepository,
+	sessions *SessionUseCase,
+	mail mailer.Mailer,
+	appURL string,
+) *AccountUseCase {
+	return &AccountUseCase{
+		authRepo:     authRepo,
+		userRepo:     userRepo,
+		settingsRepo: settingsRepo,
+		tokenRepo:    tokenRepo,
+		sessions:     sessions,
+		mailer:       mail,
+		appURL:       strings.TrimRight(appURL, "/"),
+	}
+}
+
+// send delivers an email; failures are logged and never reveal anything to the caller
+func (uc *AccountUseCase) send(ctx context.Context, to, subject, text string) {
+	if err := uc.mailer.Send(ctx, mailer.Message{To: to, Subject: subject, Text: text}); err != nil {
+		log.Printf("accounts: failed to send %q to %s: %v", subject, to, err)
+	}
+}
+
+// issueToken stores a new account token for the user and returns the token to email
+func (uc *AccountUseCase) issueToken(ctx context.Context, userID int, purpose string, ttl time.Duration, ip string) (string, error) {
+	token, err := newSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	err = uc.tokenRepo.CreateToken(ctx, &domain.AccountToken{
+		UserID:      userID,
+		Purpose:     purpose,
+		TokenHash:   domain.HashToken(token),
+		ExpiresAt:   time.Now().Add(ttl),
+		RequestedIP: ip,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ForgotPassword emails a password reset link. It succeeds whether or not the email
+// belongs to an account, so it cannot be used to discover accounts.
+func (uc *AccountUseCase) ForgotPassword(ctx context.Context, req *domain.ForgotPasswordRequest, ip string) error {
+	if strings.TrimSpace(req.Email) == "" {
+		return errors.New("email is required")
+	}
+
+	users, err := uc.authRepo.FindUserByEmailAllTenants(strings.TrimSpace(req.Email))
+	if err != nil || len(users) == 0 {
+		return nil
+	}
+	// Accounts sharing an email share a password; login checks the first one
+	user := users[0]
+
+	recent, err := uc.tokenRepo.IssuedSince(ctx, user.ID, domain.TokenPurposePasswordReset, domain.AccountEmailCooldown)
+	if err != nil {
+		return err
+	}
+	if recent {
+		return nil
+	}
+
+	token, err := uc.issueToken(ctx, user.ID, domain.TokenPurposePasswordReset, domain.PasswordResetTTL, ip)
+	if err != nil {
+		return err
+	}
+
+	uc.send(ctx, user.Email, "Reset your password", fmt.Sprintf(
+		"Hi %s,\n\nWe received a request to reset your password. Open the link below to choose a new one:\n\n%s/reset-password?token=%s\n\nThe link expires in 1 hour and can only be used once. If you did not ask for this, you can ignore this email.\n",
+		user.Name, uc.appURL, token))
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token. The password is changed on every
+// account sharing the email, all their sessions are signed out, and the change is recorded
+// in the password history.
+func (uc *AccountUseCase) ResetPassword(ctx context.Context, req *domain.ResetPasswordRequest, ip string) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	token, err := uc.tokenRepo.GetValidToken(ctx, domain.TokenPurposePasswordReset, domain.HashToken(req.Token))
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return domain.ErrInvalidAccountToken
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, token.UserID)
+	if err != nil || user.Status != "active" {
+		return domain.ErrInvalidAccountToken
+	}
+	if err := uc.checkPasswordReuse(ctx, user, req.NewPassword); err != nil {
+		return err
+	}
+
+	if err := uc.tokenRepo.ConsumeToken(ctx, token.ID); err != nil {
+		return err
+	}
+
+	hashed, err := bcrypt.GenerateFromPassword([]byte(req.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	accounts, err := uc.userRepo.FindByEmailAllTenants(ctx, user.Email)
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		if account.Status != "active" {
+			continue
+		}
+		if err := uc.userRepo.UpdatePassword(ctx, account.ID, string(hashed)); err != nil {
+			return err
+		}
+		if err := uc.settingsRepo.RecordPasswordChange(ctx, int64(account.ID), string(hashed), ip); err != nil {
+			log.Printf("accounts: failed to record password change of user %d: %v", account.ID, err)
+		}
+		if _, err := uc.sessions.RevokeAllSessions(ctx, account.TenantID, account.ID, domain.SessionRevokedPasswordReset); err != nil {
+			log.Printf("accounts: failed to revoke sessions of user %d: %v", account.ID, err)
+		}
+	}
+
+	// Opening the reset link proves the user owns the address
+	if user.EmailVerifiedAt == nil {
+		if err := uc.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+			log.Printf("accounts: %v", err)
+		}
+	}
+
+	uc.send(ctx, user.Email, "Your password was changed", fmt.Sprintf(
+		"Hi %s,\n\nThe password of your account was just reset and all devices were signed out. If this wasn't you, reset your password again and contact your administrator.\n",
+		user.Name))
+	return nil
+}
+
+// checkPasswordReuse rejects the current password and the last passwords in the history
+func (uc *AccountUseCase) checkPasswordReuse(ctx context.Context, user *domain.User, password string) error {
+	if user.VerifyPassword(password) {
+		return domain.ErrPasswordReused
+	}
+	hashes, err := uc.settingsRepo.RecentPasswordHashes(ctx, int64(user.ID), domain.PasswordHistoryDepth)
+	if err != nil {
+		return err
+	}
+	for _, hash := range hashes {
+		if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil {
+			return domain.ErrPasswordReused
+		}
+	}
+	return nil
+}
+
+// SendEmailVerification emails a verification link to a user whose address is not verified yet
+func (uc *AccountUseCase) SendEmailVerification(ctx context.Context, user *domain.User, ip string) error {
+	if user.EmailVerifiedAt != nil {
+		return domain.ErrEmailVerified
+	}
+
+	token, err := uc.issueToken(ctx, user.ID, domain.TokenPurposeEmailVerification, domain.EmailVerificationTTL, ip)
+	if err != nil {
+		return err
+	}
+
+	uc.send(ctx, user.Email, "Verify your email address", fmt.Sprintf(
+		"Hi %s,\n\nPlease confirm your email address by opening the link below:\n\n%s/verify-email?token=%s\n\nThe link expires in 48 hours.\n",
+		user.Name, uc.appURL, token))
+	return nil
+}
+
+// ResendEmailVerification sends a new verification link to the signed-in user
+func (uc *AccountUseCase) ResendEmailVerification(ctx context.Context, userID int, ip string) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if user.EmailVerifiedAt != nil {
+		return domain.ErrEmailVerified
+	}
+
+	recent, err := uc.tokenRepo.IssuedSince(ctx, user.ID, domain.TokenPurposeEmailVerification, domain.AccountEmailCooldown)
+	if err != nil {
+		return err
+	}
+	if recent {
+		return domain.ErrAccountEmailTooSoon
+	}
+	return uc.SendEmailVerification(ctx, user, ip)
+}
+
+// VerifyEmail confirms the user's email address with a verification token
+func (uc *AccountUseCase) VerifyEmail(ctx context.Context, req *domain.AccountTokenRequest) error {
+	if strings.TrimSpace(req.Token) == "" {
+		return errors.New("token is required")
+	}
+
+	token, err := uc.tokenRepo.GetValidToken(ctx, domain.TokenPurposeEmailVerification, domain.HashToken(req.Token))
+	if err != nil {
+		return err
+	}
+	if token == nil {
+		return domain.ErrInvalidAccountToken
+	}
+	if err := uc.tokenRepo.ConsumeToken(ctx, token.ID); err != nil {
+		return err
+	}
+	return uc.userRepo.MarkEmailVerified(ctx, token.UserID)
+}
+
+// RequestEmailChange emails a confirmation link to the new address and a notice to the
+// current one. The address only changes once the link is opened.
+func (uc *AccountUseCase) RequestEmailChange(ctx context.Context, userID int, req *domain.ChangeEmailRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if !user.VerifyPassword(req.CurrentPassword) {
+		return domain.ErrIncorrectPassword
+	}
+	if strings.EqualFold(user.Email, req.NewEmail) {
+		return domain.ErrEmailUnchanged
+	}
+
+	existing, err := uc.userRepo.GetByEmailInTenant(ctx, int64(user.TenantID), req.NewEmail)
+	if err != nil {
+		return err
+	}
+	if existing != nil {
+		return domain.ErrEmailTaken
+	}
+
+	token, err := newSecureToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate token: %w", err)
+	}
+	if _, err := uc.tokenRepo.CreateEmailChange(ctx, user.ID, req.NewEmail, domain.HashToken(token)); err != nil {
+		return err
+	}
+
+	uc.send(ctx, req.NewEmail, "Confirm your new email address", fmt.Sprintf(
+		"Hi %s,\n\nOpen the link below to use this address for your account:\n\n%s/confirm-email-change?token=%s\n\nThe link expires in 24 hours.\n",
+		user.Name, uc.appURL, token))
+	uc.send(ctx, user.Email, "Your email address is being changed", fmt.Sprintf(
+		"Hi %s,\n\nA request was made to change the email of your account to %s. Nothing changes until the new address is confirmed. If this wasn't you, change your password.\n",
+		user.Name, req.NewEmail))
+	return nil
+}
+
+// ConfirmEmailChange applies a pending email change with the token sent to the new address
+func (uc *AccountUseCase) ConfirmEmailChange(ctx context.Context, req *domain.AccountTokenRequest) error {
+	if strings.TrimSpace(req.Token) == "" {
+		return errors.New("token is required")
+	}
+	_, err := uc.tokenRepo.ApplyEmailChange(ctx, domain.HashToken(req.Token))
+	return err
+}
@@ -0,0 +1,294 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"pos-saas/internal/auth"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+	"pos-saas/internal/security"
+)
+
+// APIKeyUseCase manages the tenant's API keys and authenticates requests made with them
+type APIKeyUseCase struct {
+	db          *sql.DB
+	manager     *auth.APIKeyManager
+	userRepo    *repository.UserRepository
+	permissions *PermissionUseCase
+}
+
+// NewAPIKeyUseCase creates new API key use case. Key changes are written to audit when set.
+func NewAPIKeyUseCase(
+	db *sql.DB,
+	userRepo *repository.UserRepository,
+	permissions *PermissionUseCase,
+	audit *security.AuditLogManager,
+) *APIKeyUseCase {
+	var logger auth.AuditLogger
+	if audit != nil {
+		logger = apiKeyAuditLogger{audit: audit}
+	}
+	return &APIKeyUseCase{
+		db:          db,
+		manager:     auth.NewAPIKeyManager(db, logger),
+		userRepo:    userRepo,
+		permissions: permissions,
+	}
+}
+
+// withConn runs fn on a dedicated connection, as the key manager expects
+func (uc *APIKeyUseCase) withConn(ctx context.Context, fn func(conn *sql.Conn) error) error {
+	if uc.db == nil {
+		return errors.New("API keys are not available without a database")
+	}
+	conn, err := uc.db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get database connection: %w", err)
+	}
+	defer conn.Close()
+	return fn(conn)
+}
+
+// Create issues a key acting for the user in their current restaurant. The key's scopes
+// cannot exceed the user's own permissions.
+func (uc *APIKeyUseCase) Create(ctx context.Context, tenantID, userID, restaurantID int64, req *domain.CreateAPIKeyRequest) (*domain.CreatedAPIKey, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if restaurantID <= 0 {
+		return nil, errors.New("a restaurant must be selected to create an API key")
+	}
+	if err := uc.checkScopes(ctx, tenantID, restaurantID, userID, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	scopes := domain.FormatAPIKeyScopes(req.Permissions)
+	var created *domain.CreatedAPIKey
+	err := uc.withConn(ctx, func(conn *sql.Conn) error {
+		key, keyID, err := uc.manager.GenerateAPIKey(ctx, conn, tenantID, userID, restaurantID, req.Name,
+			scopes, req.AllowedIPs, req.AllowedOrigins, req.RateLimit, req.ExpiresInDays)
+		if err != nil {
+			return err
+		}
+		stored, err := uc.find(ctx, conn, tenantID, keyID)
+		if err != nil {
+			return err
+		}
+		created = &domain.CreatedAPIKey{APIKey: key, Key: *stored}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// List returns all of the tenant's keys, newest first
+func (uc *APIKeyUseCase) List(ctx context.Context, tenantID int64) ([]domain.APIKey, error) {
+	keys := []domain.APIKey{}
+	err := uc.withConn(ctx, func(conn *sql.Conn) error {
+		stored, err := uc.manager.ListAPIKeys(ctx, conn, tenantID, 0)
+		if err != nil {
+			return err
+		}
+		for i := range stored {
+			keys = append(keys, toDomainAPIKey(&stored[i]))
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// Update changes a key's scopes or rate limit. New scopes cannot exceed the permissions the
+// user making the change has in the key's restaurant.
+func (uc *APIKeyUseCase) Update(ctx context.Context, tenantID, userID, keyID int64, req *domain.UpdateAPIKeyRequest) (*domain.APIKey, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	var updated *domain.APIKey
+	err := uc.withConn(ctx, func(conn *sql.Conn) error {
+		key, err := uc.find(ctx, conn, tenantID, keyID)
+		if err != nil {
+			return err
+		}
+		if req.Permissions != nil {
+			if err := uc.checkScopes(ctx, tenantID, key.RestaurantID, userID, *req.Permissions); err != nil {
+				return err
+			}
+			if err := uc.manager.UpdateAPIKeyPermissions(ctx, conn, keyID, tenantID, domain.FormatAPIKeyScopes(*req.Permissions)); err != nil {
+				return mapAPIKeyError(err)
+			}
+		}
+		if req.RateLimit != nil {
+			if err := uc.manager.UpdateAPIKeyRateLimit(ctx, conn, keyID, tenantID, *req.RateLimit); err != nil {
+				return mapAPIKeyError(err)
+			}
+		}
+		updated, err = uc.find(ctx, conn, tenantID, keyID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+// Revoke disables a key of the tenant
+func (uc *APIKeyUseCase) Revoke(ctx context.Context, tenantID, keyID int64) error {
+	return uc.withConn(ctx, func(conn *sql.Conn) error {
+		return mapAPIKeyError(uc.manager.RevokeAPIKey(ctx, conn, keyID, tenantID))
+	})
+}
+
+// Usage returns a key's request statistics
+func (uc *APIKeyUseCase) Usage(ctx context.Context, tenantID, keyID int64) (*domain.APIKeyUsage, error) {
+	var usage *domain.APIKeyUsage
+	err := uc.withConn(ctx, func(conn *sql.Conn) error {
+		if _, err := uc.find(ctx, conn, tenantID, keyID); err != nil {
+			return err
+		}
+		stats, err := uc.manager.GetAPIKeyUsage(ctx, conn, keyID, tenantID)
+		if err != nil {
+			return err
+		}
+		usage = &domain.APIKeyUsage{
+			KeyID:           keyID,
+			TotalRequests:   stats.RequestCount,
+			TodayRequests:   stats.TodayRequests,
+			MonthlyRequests: stats.MonthlyRequests,
+			ErrorRequests:   stats.ErrorRequests,
+			AvgDurationMs:   stats.AvgDurationMs,
+			LastUsedAt:      stats.LastUsedAt,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
+// AuthenticateAPIKey validates a key and its allow lists, checks that its owner is still an
+// active user of the tenant, and enforces the key's rate limit
+func (uc *APIKeyUseCase) AuthenticateAPIKey(ctx context.Context, key, clientIP, origin string) (*domain.APIKeyPrincipal, error) {
+	var principal *domain.APIKeyPrincipal
+	err := uc.withConn(ctx, func(conn *sql.Conn) error {
+		apiKey, err := uc.manager.ValidateAPIKey(ctx, conn, key, clientIP, origin)
+		if err != nil {
+			return mapAPIKeyError(err)
+		}
+
+		owner, err := uc.userRepo.GetByID(ctx, int(apiKey.UserID))
+		if err != nil || int64(owner.TenantID) != apiKey.TenantID || owner.Status != "active" {
+			return domain.ErrInvalidAPIKey
+		}
+
+		count, retryAfter, err := uc.manager.CountAPIKeyRequest(ctx, conn, apiKey.ID, domain.APIKeyRateWindow)
+		if err != nil {
+			return fmt.Errorf("failed to check API key rate limit: %w", err)
+		}
+		if count > apiKey.RateLimit {
+			return &domain.APIKeyRateLimitError{RetryAfter: retryAfter}
+		}
+
+		principal = &domain.APIKeyPrincipal{
+			KeyID:        apiKey.ID,
+			TenantID:     apiKey.TenantID,
+			RestaurantID: apiKey.RestaurantID,
+			UserID:       apiKey.UserID,
+			Email:        owner.Email,
+			Role:         owner.Role,
+			Scopes:       domain.ParseAPIKeyScopes(apiKey.Permissions),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return principal, nil
+}
+
+// RecordAPIKeyUsage stores a request made with a key; failures are only logged
+func (uc *APIKeyUseCase) RecordAPIKeyUsage(ctx context.Context, principal *domain.APIKeyPrincipal, endpoint string, statusCode int, duration time.Duration) {
+	if len(endpoint) > 255 {
+		endpoint = endpoint[:255]
+	}
+	err := uc.withConn(ctx, func(conn *sql.Conn) error {
+		return uc.manager.RecordAPIKeyUsage(ctx, conn, principal.KeyID, principal.TenantID, endpoint, statusCode, duration)
+	})
+	if err != nil {
+		log.Printf("api keys: failed to record usage of key %d: %v", principal.KeyID, err)
+	}
+}
+
+// checkScopes refuses scopes above the user's own permission levels in the key's restaurant
+func (uc *APIKeyUseCase) checkScopes(ctx context.Context, tenantID, restaurantID, userID int64, scopes []domain.ModulePermissionRequest) error {
+	granted, err := uc.permissions.GetUserPermissions(ctx, tenantID, restaurantID, userID)
+	if err != nil {
+		return err
+	}
+	for _, scope := range scopes {
+		if !granted.Allows(scope.ModuleID, scope.PermissionLevel) {
+			return fmt.Errorf("%w: module %d needs %s", domain.ErrAPIKeyScopeTooWide, scope.ModuleID, scope.PermissionLevel)
+		}
+	}
+	return nil
+}
+
+// find returns a key of the tenant
+func (uc *APIKeyUseCase) find(ctx context.Context, conn *sql.Conn, tenantID, keyID int64) (*domain.APIKey, error) {
+	stored, err := uc.manager.GetAPIKey(ctx, conn, keyID, tenantID)
+	if err != nil {
+		return nil, mapAPIKeyError(err)
+	}
+	key := toDomainAPIKey(stored)
+	return &key, nil
+}
+
+func toDomainAPIKey(key *auth.APIKey) domain.APIKey {
+	return domain.APIKey{
+		ID:             key.ID,
+		Name:           key.Name,
+		Prefix:         key.KeyPrefix,
+		UserID:         key.UserID,
+		RestaurantID:   key.RestaurantID,
+		Permissions:    domain.APIKeyScopeList(key.Permissions),
+		AllowedIPs:     key.AllowedIPs,
+		AllowedOrigins: key.AllowedOrigins,
+		RateLimit:      key.RateLimit,
+		IsActive:       key.IsActive,
+		LastUsedAt:     key.LastUsedAt,
+		ExpiresAt:      key.ExpiresAt,
+		CreatedAt:      key.CreatedAt,
+	}
+}
+
+// mapAPIKeyError turns the key manager's errors into domain errors
+func mapAPIKeyError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, auth.ErrInvalidAPIKey), errors.Is(err, auth.ErrAPIKeyExpired):
+		return domain.ErrInvalidAPIKey
+	case errors.Is(err, auth.ErrAPIKeyIPNotAllowed), errors.Is(err, auth.ErrAPIKeyOriginNotAllowed):
+		return domain.ErrAPIKeyNotAllowed
+	case errors.Is(err, auth.ErrAPIKeyNotFound):
+		return domain.ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// apiKeyAuditLogger writes the key manager's events to the security audit log
+type apiKeyAuditLogger struct {
+	audit *security.AuditLogManager
+}
+
+func (l apiKeyAuditLogger) LogAction(ctx context.Context, tenantID int64, action string, details string) error {
+	return l.audit.LogAction(ctx, tenantID, 0, action, "api_key", "", details, "", "", "success", "", 0)
+}
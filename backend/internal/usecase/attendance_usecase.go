@@ -0,0 +1,416 @@
+package usecase
+
+import (
+	"fmt"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AttendanceUseCase handles the time clock: clocking in and out, breaks, kiosk punches,
+// hours and overtime, and lateness derived from published shifts
+type AttendanceUseCase struct {
+	attendanceRepo *repository.AttendanceRepository
+	employeeRepo   *repository.EmployeeRepository
+	rotaRepo       *repository.RotaRepository
+	auditRepo      *repository.HRAuditRepository
+}
+
+// NewAttendanceUseCase creates new attendance use case
+func NewAttendanceUseCase(
+	attendanceRepo *repository.AttendanceRepository,
+	employeeRepo *repository.EmployeeRepository,
+	rotaRepo *repository.RotaRepository,
+	auditRepo *repository.HRAuditRepository,
+) *AttendanceUseCase {
+	return &AttendanceUseCase{
+		attendanceRepo: attendanceRepo,
+		employeeRepo:   employeeRepo,
+		rotaRepo:       rotaRepo,
+		auditRepo:      auditRepo,
+	}
+}
+
+// GetSettings returns the restaurant's time clock settings
+func (uc *AttendanceUseCase) GetSettings(tenantID, restaurantID int) (*domain.TimeClockSettings, error) {
+	return uc.attendanceRepo.GetTimeClockSettings(tenantID, restaurantID)
+}
+
+// SaveSettings replaces the restaurant's time clock settings
+func (uc *AttendanceUseCase) SaveSettings(tenantID, restaurantID int, settings *domain.TimeClockSettings, userID int) (*domain.TimeClockSettings, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	if settings.RequireGeofence {
+		lat, lon, err := uc.attendanceRepo.GetRestaurantLocation(tenantID, restaurantID)
+		if err != nil {
+			return nil, err
+		}
+		if lat == nil || lon == nil {
+			return nil, domain.ErrRestaurantNoLocation
+		}
+	}
+
+	before, err := uc.attendanceRepo.GetTimeClockSettings(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	settings.TenantID, settings.RestaurantID, settings.UpdatedBy = tenantID, restaurantID, &userID
+	if err := uc.attendanceRepo.SaveTimeClockSettings(settings); err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditTimeClockSettings, restaurantID, "", domain.AuditUpdate, before, settings, userID)
+	return settings, nil
+}
+
+// SetClockCredentials sets or clears an employee's kiosk PIN and badge. The audit trail records
+// that the PIN changed, never the PIN itself.
+func (uc *AttendanceUseCase) SetClockCredentials(tenantID, restaurantID, employeeID int, req *domain.ClockCredentialsRequest, userID int) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+
+	var pinHash, badgeCode *string
+	if req.PIN != nil {
+		hash := ""
+		if *req.PIN != "" {
+			h, err := bcrypt.GenerateFromPassword([]byte(*req.PIN), bcrypt.DefaultCost)
+			if err != nil {
+				return fmt.Errorf("failed to hash pin: %w", err)
+			}
+			hash = string(h)
+		}
+		pinHash = &hash
+	}
+	if req.BadgeCode != nil {
+		badge := strings.TrimSpace(*req.BadgeCode)
+		badgeCode = &badge
+	}
+
+	if err := uc.employeeRepo.SetClockCredentials(tenantID, restaurantID, employeeID, pinHash, badgeCode); err != nil {
+		return err
+	}
+	changes := map[string]interface{}{}
+	if pinHash != nil {
+		changes["clock_pin_set"] = *pinHash != ""
+	}
+	if badgeCode != nil {
+		changes["badge_code"] = *badgeCode
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditEmployee, employeeID, "", domain.AuditUpdate, nil, changes, userID)
+	return nil
+}
+
+// ClockIn records an employee's arrival, checking the geofence and marking lateness against their shift
+func (uc *AttendanceUseCase) ClockIn(tenantID, restaurantID int, req *domain.ClockPunchRequest, ip string) (*domain.Attendance, error) {
+	return uc.clockIn(tenantID, restaurantID, req, ip, false)
+}
+
+// ClockOut records an employee's departure, ending any break, calculating hours and overtime
+// and marking early departure against their shift
+func (uc *AttendanceUseCase) ClockOut(tenantID, restaurantID int, req *domain.ClockPunchRequest, ip string) (*domain.Attendance, error) {
+	return uc.clockOut(tenantID, restaurantID, req, ip, false)
+}
+
+func (uc *AttendanceUseCase) clockIn(tenantID, restaurantID int, req *domain.ClockPunchRequest, ip string, kiosk bool) (*domain.Attendance, error) {
+	if _, err := uc.employee(tenantID, restaurantID, req.EmployeeID); err != nil {
+		return nil, err
+	}
+	loc, err := uc.checkLocation(tenantID, restaurantID, req, kiosk)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = uc.attendanceRepo.RecordClockIn(&domain.Attendance{
+		TenantID:        tenantID,
+		RestaurantID:    restaurantID,
+		EmployeeID:      req.EmployeeID,
+		AttendanceDate:  now,
+		ClockIn:         &now,
+		ClockInLocation: domain.MarshalLocation(loc),
+		ClockInIP:       ip,
+		ClockInDevice:   punchDevice(req, kiosk),
+		Notes:           req.Notes,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	att, err := uc.attendanceRepo.GetTodayAttendance(tenantID, restaurantID, req.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+	if att == nil {
+		return nil, domain.ErrAttendanceNotFound
+	}
+	return uc.applySchedule(att)
+}
+
+func (uc *AttendanceUseCase) clockOut(tenantID, restaurantID int, req *domain.ClockPunchRequest, ip string, kiosk bool) (*domain.Attendance, error) {
+	loc, err := uc.checkLocation(tenantID, restaurantID, req, kiosk)
+	if err != nil {
+		return nil, err
+	}
+	settings, err := uc.attendanceRepo.GetTimeClockSettings(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	att, err := uc.openAttendance(tenantID, restaurantID, req.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := att.Finish(time.Now()); err != nil {
+		return nil, err
+	}
+	att.ClockOutLocation, att.ClockOutIP, att.ClockOutDevice = domain.MarshalLocation(loc), ip, punchDevice(req, kiosk)
+	att.CalculateHours(settings.RegularHoursPerDay, settings.OvertimeRequiresApproval)
+
+	if err := uc.attendanceRepo.UpdateTimeClock(att); err != nil {
+		return nil, fmt.Errorf("failed to clock out: %w", err)
+	}
+	return uc.applySchedule(att)
+}
+
+// StartBreak starts a break for a clocked-in employee
+func (uc *AttendanceUseCase) StartBreak(tenantID, restaurantID, employeeID int) (*domain.Attendance, error) {
+	att, err := uc.openAttendance(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := att.StartBreak(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := uc.attendanceRepo.UpdateTimeClock(att); err != nil {
+		return nil, fmt.Errorf("failed to start break: %w", err)
+	}
+	return att, nil
+}
+
+// EndBreak ends a clocked-in employee's break
+func (uc *AttendanceUseCase) EndBreak(tenantID, restaurantID, employeeID int) (*domain.Attendance, error) {
+	att, err := uc.openAttendance(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if err := att.EndBreak(time.Now()); err != nil {
+		return nil, err
+	}
+	if err := uc.attendanceRepo.UpdateTimeClock(att); err != nil {
+		return nil, fmt.Errorf("failed to end break: %w", err)
+	}
+	return att, nil
+}
+
+// KioskPunch identifies an employee at the shared time clock by badge or by employee code and PIN,
+// then performs the requested action. The kiosk sits in the restaurant, so no geofence applies.
+func (uc *AttendanceUseCase) KioskPunch(tenantID, restaurantID int, req *domain.KioskPunchRequest, ip string) (*domain.KioskPunchResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	settings, err := uc.attendanceRepo.GetTimeClockSettings(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.KioskEnabled {
+		return nil, domain.ErrKioskDisabled
+	}
+
+	employeeID, name, err := uc.identifyAtKiosk(tenantID, restaurantID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	punch := &domain.ClockPunchRequest{EmployeeID: employeeID}
+	var att *domain.Attendance
+	switch req.Action {
+	case domain.PunchClockIn:
+		att, err = uc.clockIn(tenantID, restaurantID, punch, ip, true)
+	case domain.PunchClockOut:
+		att, err = uc.clockOut(tenantID, restaurantID, punch, ip, true)
+	case domain.PunchBreakStart:
+		att, err = uc.StartBreak(tenantID, restaurantID, employeeID)
+	case domain.PunchBreakEnd:
+		att, err = uc.EndBreak(tenantID, restaurantID, employeeID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &domain.KioskPunchResponse{EmployeeID: employeeID, EmployeeName: name, Action: req.Action, Attendance: att}, nil
+}
+
+// ListAttendance returns attendance records between two dates
+func (uc *AttendanceUseCase) ListAttendance(tenantID, restaurantID int, from, to time.Time) ([]domain.Attendance, error) {
+	return uc.attendanceRepo.ListAttendance(tenantID, restaurantID, from, to)
+}
+
+// ListPendingOvertime returns attendance with overtime awaiting approval
+func (uc *AttendanceUseCase) ListPendingOvertime(tenantID, restaurantID int) ([]domain.Attendance, error) {
+	return uc.attendanceRepo.ListPendingOvertime(tenantID, restaurantID)
+}
+
+// ApproveOvertime approves the overtime of an attendance record
+func (uc *AttendanceUseCase) ApproveOvertime(tenantID, restaurantID, id, userID int) (*domain.Attendance, error) {
+	before, err := uc.attendanceRepo.GetAttendanceByID(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.attendanceRepo.ApproveOvertime(tenantID, restaurantID, id, userID); err != nil {
+		return nil, err
+	}
+	approved, err := uc.attendanceRepo.GetAttendanceByID(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditAttendance, id, "", domain.AuditApprove, before, approved, userID)
+	return approved, nil
+}
+
+// ApplyPublishedShifts recomputes scheduled times, lateness and early departure of the
+// attendance records between two dates from the published shifts. Returns the number updated.
+func (uc *AttendanceUseCase) ApplyPublishedShifts(tenantID, restaurantID int, from, to time.Time) (int, error) {
+	records, err := uc.attendanceRepo.ListAttendance(tenantID, restaurantID, from, to)
+	if err != nil {
+		return 0, err
+	}
+	shifts, err := uc.rotaRepo.ListPublishedShifts(tenantID, restaurantID, 0, from, to)
+	if err != nil {
+		return 0, err
+	}
+
+	// An employee's first shift of the day is the one attendance is measured against
+	byDay := map[string]*domain.Shift{}
+	for i := range shifts {
+		key := fmt.Sprintf("%d/%s", shifts[i].EmployeeID, shifts[i].ShiftDate.Format(domain.DateLayout))
+		if _, ok := byDay[key]; !ok {
+			byDay[key] = &shifts[i]
+		}
+	}
+
+	updated := 0
+	for i := range records {
+		att := &records[i]
+		shift := byDay[fmt.Sprintf("%d/%s", att.EmployeeID, att.AttendanceDate.Format(domain.DateLayout))]
+		if shift == nil {
+			continue
+		}
+		domain.ApplyShiftSchedule(att, shift)
+		if err := uc.attendanceRepo.UpdateSchedule(att); err != nil {
+			return updated, fmt.Errorf("failed to update attendance %d: %w", att.ID, err)
+		}
+		updated++
+	}
+	return updated, nil
+}
+
+// applySchedule derives lateness and early departure from the employee's published shift on the attendance date
+func (uc *AttendanceUseCase) applySchedule(att *domain.Attendance) (*domain.Attendance, error) {
+	shift, err := uc.rotaRepo.GetPublishedShift(att.TenantID, att.RestaurantID, att.EmployeeID, att.AttendanceDate)
+	if err != nil {
+		return nil, err
+	}
+	if shift == nil {
+		return att, nil
+	}
+
+	domain.ApplyShiftSchedule(att, shift)
+	if err := uc.attendanceRepo.UpdateSchedule(att); err != nil {
+		return nil, fmt.Errorf("failed to update attendance schedule: %w", err)
+	}
+	return att, nil
+}
+
+// openAttendance loads the attendance the employee is clocked in on
+func (uc *AttendanceUseCase) openAttendance(tenantID, restaurantID, employeeID int) (*domain.Attendance, error) {
+	att, err := uc.attendanceRepo.GetOpenAttendance(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if att != nil {
+		return att, nil
+	}
+
+	today, err := uc.attendanceRepo.GetTodayAttendance(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if today != nil && today.ClockOut != nil {
+		return nil, domain.ErrAlreadyClockedOut
+	}
+	return nil, domain.ErrNotClockedIn
+}
+
+// checkLocation validates the punch location against the restaurant geofence; kiosk punches are exempt
+func (uc *AttendanceUseCase) checkLocation(tenantID, restaurantID int, req *domain.ClockPunchRequest, kiosk bool) (*domain.ClockLocation, error) {
+	loc, err := req.Location()
+	if err != nil {
+		return nil, err
+	}
+	if kiosk {
+		return loc, nil
+	}
+
+	settings, err := uc.attendanceRepo.GetTimeClockSettings(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if !settings.RequireGeofence {
+		return loc, nil
+	}
+	lat, lon, err := uc.attendanceRepo.GetRestaurantLocation(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return loc, domain.CheckGeofence(settings, lat, lon, loc)
+}
+
+// identifyAtKiosk resolves the employee from a badge, or from an employee code and PIN
+func (uc *AttendanceUseCase) identifyAtKiosk(tenantID, restaurantID int, req *domain.KioskPunchRequest) (int, string, error) {
+	if badge := strings.TrimSpace(req.BadgeCode); badge != "" {
+		id, name, err := uc.employeeRepo.GetEmployeeByBadge(tenantID, restaurantID, badge)
+		if err != nil {
+			return 0, "", err
+		}
+		if id == 0 {
+			return 0, "", domain.ErrInvalidKioskLogin
+		}
+		return id, name, nil
+	}
+
+	id, name, hash, err := uc.employeeRepo.GetClockPINHash(tenantID, restaurantID, strings.TrimSpace(req.EmployeeCode))
+	if err != nil {
+		return 0, "", err
+	}
+	if id == 0 || hash == "" || bcrypt.CompareHashAndPassword([]byte(hash), []byte(req.PIN)) != nil {
+		return 0, "", domain.ErrInvalidKioskLogin
+	}
+	return id, name, nil
+}
+
+// employee loads an employee of the restaurant
+func (uc *AttendanceUseCase) employee(tenantID, restaurantID, id int) (*domain.Employee, error) {
+	emp, err := uc.employeeRepo.GetEmployeeByID(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if emp == nil {
+		return nil, fmt.Errorf("employee %d not found", id)
+	}
+	return emp, nil
+}
+
+// punchDevice returns the device a punch came from: the kiosk, or mobile or web as stated by the client
+func punchDevice(req *domain.ClockPunchRequest, kiosk bool) string {
+	if kiosk {
+		return domain.ClockDeviceKiosk
+	}
+	if req.Device == domain.ClockDeviceMobile {
+		return domain.ClockDeviceMobile
+	}
+	return domain.ClockDeviceWeb
+}
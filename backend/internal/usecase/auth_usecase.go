@@ -1,8 +1,12 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
+	"slices"
+	"strings"
 
 	"golang.org/x/crypto/bcrypt"
 	"pos-saas/internal/domain"
@@ -12,25 +16,43 @@ import (
 
 type AuthUseCase struct {
 	repo         *repository.AuthRepository
+	sessions     *SessionUseCase
+	accounts     *AccountUseCase
+	twoFactor    *TwoFactorUseCase
+	guard        *LoginGuardUseCase
+	sso          *SSOUseCase
 	tokenService *jwt.TokenService
 }
 
-func NewAuthUseCase(repo *repository.AuthRepository, tokenService *jwt.TokenService) *AuthUseCase {
+func NewAuthUseCase(
+	repo *repository.AuthRepository,
+	sessions *SessionUseCase,
+	accounts *AccountUseCase,
+	twoFactor *TwoFactorUseCase,
+	guard *LoginGuardUseCase,
+	sso *SSOUseCase,
+	tokenService *jwt.TokenService,
+) *AuthUseCase {
 	return &AuthUseCase{
 		repo:         repo,
+		sessions:     sessions,
+		accounts:     accounts,
+		twoFactor:    twoFactor,
+		guard:        guard,
+		sso:          sso,
 		tokenService: tokenService,
 	}
 }
 
 // Register creates a new tenant, restaurant, and admin user
-func (u *AuthUseCase) Register(req *domain.RegisterRequest) (*domain.AuthResponse, error) {
+func (u *AuthUseCase) Register(ctx context.Context, req *domain.RegisterRequest, client domain.SessionClient) (*domain.AuthResponse, error) {
 	// Validate input
 	if req.Name == "" || req.Email == "" || req.Password == "" || req.RestaurantName == "" {
 		return nil, errors.New("all fields are required")
 	}
 
-	if len(req.Password) < 6 {
-		return nil, errors.New("password must be at least 6 characters")
+	if err := domain.ValidatePassword(req.Password); err != nil {
+		return nil, err
 	}
 
 	// Check if email already exists
@@ -48,135 +70,134 @@ func (u *AuthUseCase) Register(req *domain.RegisterRequest) (*domain.AuthRespons
 		return nil, fmt.Errorf("failed to register: %w", err)
 	}
 
-	// Generate JWT token
-	token, err := u.tokenService.GenerateToken(user.ID, user.TenantID, user.RestaurantID, user.Email, user.Role)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+	// Ask the new owner to verify their email; the account works in the meantime
+	if err := u.accounts.SendEmailVerification(ctx, user, client.IPAddress); err != nil {
+		log.Printf("auth: failed to send verification email to user %d: %v", user.ID, err)
 	}
 
-	// Clear sensitive data
-	user.PasswordHash = ""
-
-	return &domain.AuthResponse{
-		User:  user,
-		Token: token,
-	}, nil
+	// Open a session and generate JWT tokens
+	return u.sessions.StartSession(ctx, user, client)
 }
 
 // Login authenticates a user and detects if they have multiple tenant accounts
-func (u *AuthUseCase) Login(req *domain.LoginRequest) (interface{}, error) {
-	fmt.Println("=== LOGIN USE CASE DEBUG START ===")
-	fmt.Printf("Login attempt for email: %s\n", req.Email)
-	fmt.Printf("Password length received: %d\n", len(req.Password))
-
+func (u *AuthUseCase) Login(ctx context.Context, req *domain.LoginRequest, client domain.SessionClient) (interface{}, error) {
 	// Validate input
 	if req.Email == "" || req.Password == "" {
-		fmt.Println("ERROR: Email or password is empty")
 		return nil, errors.New("email and password are required")
 	}
 
+	// Refuse the attempt while the account is locked or the account or IP is backing off
+	if err := u.guard.Check(ctx, req.Email, client); err != nil {
+		return nil, err
+	}
+
 	// Find ALL users with this email across all tenants
-	fmt.Printf("Looking up all users with email: %s\n", req.Email)
 	users, err := u.repo.FindUserByEmailAllTenants(req.Email)
 	if err != nil {
-		fmt.Printf("ERROR: Database query failed: %v\n", err)
 		return nil, errors.New("invalid email or password")
 	}
 
 	if len(users) == 0 {
-		fmt.Printf("ERROR: No users found with email: %s\n", req.Email)
-		return nil, errors.New("invalid email or password")
+		return nil, u.guard.RecordFailure(ctx, req.Email, nil, client)
 	}
 
-	fmt.Printf("Found %d users with email %s\n", len(users), req.Email)
-
-	// Verify password against the first user (all have same email, should have same password)
-	firstUser := &users[0]
-	fmt.Printf("Verifying password for first user - ID: %d, Email: %s\n", firstUser.ID, firstUser.Email)
-	fmt.Printf("Password hash from DB (first 30 chars): %s...\n", firstUser.PasswordHash[:30])
-
-	// Verify password
-	fmt.Println("Comparing password with bcrypt...")
-	fmt.Printf("Input password (first 3 chars): %s***\n", req.Password[:min(3, len(req.Password))])
-	err = bcrypt.CompareHashAndPassword([]byte(firstUser.PasswordHash), []byte(req.Password))
-	if err != nil {
-		fmt.Printf("ERROR: Password comparison failed: %v\n", err)
-		return nil, errors.New("invalid email or password")
+	// Verify the password against every account. Accounts sharing an email normally share a
+	// password, but only the tenants whose account matched may be signed in to or selected.
+	verified := make(map[int]bool, len(users))
+	checked := make(map[string]bool, 1)
+	var firstUser *domain.User
+	for i := range users {
+		match, seen := checked[users[i].PasswordHash]
+		if !seen {
+			match = bcrypt.CompareHashAndPassword([]byte(users[i].PasswordHash), []byte(req.Password)) == nil
+			checked[users[i].PasswordHash] = match
+		}
+		if match {
+			verified[users[i].ID] = true
+			if firstUser == nil {
+				firstUser = &users[i]
+			}
+		}
 	}
-	fmt.Println("SUCCESS: Password verified successfully!")
+	if firstUser == nil {
+		return nil, u.guard.RecordFailure(ctx, req.Email, &users[0], client)
+	}
+	u.guard.RecordSuccess(ctx, firstUser, client)
 
 	// Case 1: Single tenant - auto-login
-	if len(users) == 1 {
-		fmt.Println("Single tenant found - auto-logging in")
-		user := &users[0]
-
-		// Update last login
-		fmt.Printf("Updating last login for user ID: %d\n", user.ID)
-		_ = u.repo.UpdateLastLogin(user.ID)
-
-		// Generate JWT token
-		fmt.Println("Generating JWT token...")
-		token, err := u.tokenService.GenerateToken(user.ID, user.TenantID, user.RestaurantID, user.Email, user.Role)
+	if len(verified) == 1 {
+		// Open a session, or ask for the second factor first
+		response, err := u.completeLogin(ctx, firstUser, client, req.TrustedDeviceToken)
 		if err != nil {
-			fmt.Printf("ERROR: Failed to generate token: %v\n", err)
-			return nil, fmt.Errorf("failed to generate token: %w", err)
+			return nil, err
 		}
-		fmt.Printf("Token generated successfully (first 20 chars): %s...\n", token[:min(20, len(token))])
-
-		// Clear sensitive data
-		user.PasswordHash = ""
 
-		fmt.Println("=== LOGIN USE CASE DEBUG END (SUCCESS - SINGLE TENANT) ===")
-		return &domain.AuthResponse{
-			User:  user,
-			Token: token,
-		}, nil
+		return response, nil
 	}
 
 	// Case 2: Multiple tenants - return list for user to select
-	fmt.Printf("Multiple tenants found (%d) - returning list for selection\n", len(users))
-
-	tenantList := make([]domain.TenantInfo, 0, len(users))
-	for _, user := range users {
-		fmt.Printf("Processing user in tenant %d\n", user.TenantID)
-
-		// For now, use the role from the user record
-		// In production, you might want to fetch all assigned roles from user_roles table
-		tenantInfo := domain.TenantInfo{
-			TenantID:            user.TenantID,
-			TenantName:          fmt.Sprintf("Tenant %d", user.TenantID), // TODO: Fetch actual tenant name
-			UserID:              user.ID,
-			Roles:               []string{user.Role},
-			DefaultRestaurantID: user.RestaurantID,
+	allTenants, err := u.repo.ListLoginTenants(req.Email)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list organizations: %w", err)
+	}
+
+	// The selection token proves the password step for the tenants listed here
+	tenantList := make([]domain.TenantInfo, 0, len(verified))
+	tenantIDs := make([]int, 0, len(verified))
+	for _, tenant := range allTenants {
+		if verified[tenant.UserID] {
+			tenantList = append(tenantList, tenant)
+			tenantIDs = append(tenantIDs, tenant.TenantID)
 		}
-		tenantList = append(tenantList, tenantInfo)
+	}
+	selectionToken, err := u.tokenService.GenerateSelectionToken(firstUser.Email, tenantIDs, domain.TenantSelectionTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate selection token: %w", err)
 	}
 
-	fmt.Println("=== LOGIN USE CASE DEBUG END (SUCCESS - MULTIPLE TENANTS) ===")
 	return &domain.MultiTenantLoginResponse{
 		Success:         true,
 		MultipleTenants: true,
 		Tenants:         tenantList,
 		Message:         "Multiple organizations found. Please select one.",
+		SelectionToken:  selectionToken,
+		ExpiresIn:       int64(domain.TenantSelectionTTL.Seconds()),
 	}, nil
 }
 
-// LoginConfirm confirms tenant selection and generates JWT token for the selected tenant
-func (u *AuthUseCase) LoginConfirm(req *domain.TenantSelectionRequest) (*domain.AuthResponse, error) {
-	fmt.Println("=== LOGIN CONFIRM USE CASE START ===")
-	fmt.Printf("LoginConfirm: Email: %s, TenantID: %d\n", req.Email, req.TenantID)
-
+// LoginConfirm confirms tenant selection and generates JWT token for the selected tenant.
+// Returns a two-factor challenge instead when the selected account needs a second step.
+func (u *AuthUseCase) LoginConfirm(ctx context.Context, req *domain.TenantSelectionRequest, client domain.SessionClient) (interface{}, error) {
 	// Validate input
-	if req.Email == "" || req.TenantID == 0 {
-		fmt.Println("ERROR: Email or TenantID is empty")
-		return nil, errors.New("email and tenant_id are required")
+	if req.SelectionToken == "" || req.TenantID == 0 {
+		return nil, errors.New("selection_token and tenant_id are required")
+	}
+
+	// The selection token proves the password was verified and lists the allowed tenants
+	claims, err := u.tokenService.ValidateSelectionToken(req.SelectionToken)
+	if err != nil {
+		return nil, domain.ErrInvalidTenantSelection
+	}
+	if req.Email != "" && !strings.EqualFold(req.Email, claims.Email) {
+		return nil, domain.ErrInvalidTenantSelection
+	}
+	if !slices.Contains(claims.TenantIDs, req.TenantID) {
+		return nil, errors.New("user not found in selected organization")
+	}
+
+	// Each selection token can only be used once
+	fresh, err := u.repo.UseSelectionToken(claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check selection token: %w", err)
+	}
+	if !fresh {
+		return nil, domain.ErrInvalidTenantSelection
 	}
 
 	// Find user in the specific tenant
-	fmt.Printf("Finding user with email %s in tenant %d\n", req.Email, req.TenantID)
-	users, err := u.repo.FindUserByEmailAllTenants(req.Email)
+	users, err := u.repo.FindUserByEmailAllTenants(claims.Email)
 	if err != nil {
-		fmt.Printf("ERROR: Failed to find users: %v\n", err)
+		log.Printf("auth: failed to load accounts for tenant selection: %v", err)
 		return nil, errors.New("invalid email or tenant selection")
 	}
 
@@ -190,33 +211,76 @@ func (u *AuthUseCase) LoginConfirm(req *domain.TenantSelectionRequest) (*domain.
 	}
 
 	if selectedUser == nil {
-		fmt.Printf("ERROR: User not found in tenant %d\n", req.TenantID)
 		return nil, errors.New("user not found in selected organization")
 	}
 
-	fmt.Printf("User found in tenant - ID: %d, TenantID: %d\n", selectedUser.ID, selectedUser.TenantID)
+	// Open a session in the selected tenant, or ask for the second factor first
+	response, err := u.completeLogin(ctx, selectedUser, client, req.TrustedDeviceToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return response, nil
+}
+
+// LoginSSO signs in the user of a single sign-on code the dashboard was redirected with. The
+// sign-in is audited and, like a password login, returns a 2FA challenge when the user has
+// 2FA or the tenant requires it.
+func (u *AuthUseCase) LoginSSO(ctx context.Context, req *domain.SSOExchangeRequest, client domain.SessionClient) (interface{}, error) {
+	user, err := u.sso.Redeem(ctx, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	u.guard.RecordSuccess(ctx, user, client)
+	return u.finishLogin(ctx, user, client, req.TrustedDeviceToken)
+}
 
-	// Update last login
-	fmt.Printf("Updating last login for user ID: %d\n", selectedUser.ID)
-	_ = u.repo.UpdateLastLogin(selectedUser.ID)
+// completeLogin signs in a user whose password was verified. When the user has 2FA, or
+// the tenant requires it, a challenge is returned instead of tokens. Tenants that require
+// single sign-on refuse the password.
+func (u *AuthUseCase) completeLogin(ctx context.Context, user *domain.User, client domain.SessionClient, trustedDeviceToken string) (interface{}, error) {
+	if err := u.sso.CheckPasswordLogin(ctx, user.TenantID); err != nil {
+		return nil, err
+	}
+	return u.finishLogin(ctx, user, client, trustedDeviceToken)
+}
 
-	// Generate JWT token for the selected tenant
-	fmt.Println("Generating JWT token for selected tenant...")
-	token, err := u.tokenService.GenerateToken(selectedUser.ID, selectedUser.TenantID, selectedUser.RestaurantID, selectedUser.Email, selectedUser.Role)
+// finishLogin opens the session of an authenticated user, or returns the 2FA challenge
+func (u *AuthUseCase) finishLogin(ctx context.Context, user *domain.User, client domain.SessionClient, trustedDeviceToken string) (interface{}, error) {
+	challenge, err := u.twoFactor.Challenge(ctx, user, trustedDeviceToken, client.IPAddress)
 	if err != nil {
-		fmt.Printf("ERROR: Failed to generate token: %v\n", err)
-		return nil, fmt.Errorf("failed to generate token: %w", err)
+		return nil, err
+	}
+	if challenge != nil {
+		return challenge, nil
 	}
-	fmt.Printf("Token generated successfully (first 20 chars): %s...\n", token[:min(20, len(token))])
 
-	// Clear sensitive data
-	selectedUser.PasswordHash = ""
+	_ = u.repo.UpdateLastLogin(user.ID)
+	return u.sessions.StartSession(ctx, user, client)
+}
 
-	fmt.Println("=== LOGIN CONFIRM USE CASE END (SUCCESS) ===")
-	return &domain.AuthResponse{
-		User:  selectedUser,
-		Token: token,
-	}, nil
+// VerifyTwoFactor completes a login challenge and opens the session. When the device is
+// to be remembered, the returned token goes into the trusted device cookie.
+func (u *AuthUseCase) VerifyTwoFactor(ctx context.Context, req *domain.TwoFactorVerifyRequest, client domain.SessionClient) (*domain.AuthResponse, string, error) {
+	user, recoveryCodes, err := u.twoFactor.CompleteChallenge(ctx, req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	_ = u.repo.UpdateLastLogin(user.ID)
+	response, err := u.sessions.StartSession(ctx, user, client)
+	if err != nil {
+		return nil, "", err
+	}
+	response.RecoveryCodes = recoveryCodes
+
+	var deviceToken string
+	if req.RememberDevice {
+		if deviceToken, err = u.twoFactor.TrustDevice(ctx, user.ID, client); err != nil {
+			log.Printf("auth: failed to remember device of user %d: %v", user.ID, err)
+		}
+	}
+	return response, deviceToken, nil
 }
 
 // Helper function for min
@@ -244,22 +308,3 @@ func (u *AuthUseCase) CheckSubdomainAvailability(slug string) (bool, string) {
 
 	return true, "Subdomain is available"
 }
-
-// ForgotPassword initiates password reset process
-func (u *AuthUseCase) ForgotPassword(req *domain.ForgotPasswordRequest) error {
-	// Validate input
-	if req.Email == "" {
-		return errors.New("email is required")
-	}
-
-	// Check if user exists
-	_, err := u.repo.FindUserByEmail(req.Email)
-	if err != nil {
-		// Don't reveal if user exists or not
-		return nil
-	}
-
-	// TODO: Generate reset token and send email
-	// For now, just return success
-	return nil
-}
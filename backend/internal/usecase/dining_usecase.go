@@ -0,0 +1,310 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+	"time"
+)
+
+// DiningUseCase handles dine-in tables, QR tokens and table tabs
+type DiningUseCase struct {
+	diningRepo *repository.DiningRepository
+	orderRepo  *repository.OrderRepository
+	orderUC    *OrderUseCase
+}
+
+// NewDiningUseCase creates new dining use case
+func NewDiningUseCase(diningRepo *repository.DiningRepository, orderRepo *repository.OrderRepository, orderUC *OrderUseCase) *DiningUseCase {
+	return &DiningUseCase{
+		diningRepo: diningRepo,
+		orderRepo:  orderRepo,
+		orderUC:    orderUC,
+	}
+}
+
+// generateTableToken creates a random, URL-safe QR token
+func generateTableToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate table token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// CreateTable creates a table with a fresh QR token
+func (uc *DiningUseCase) CreateTable(tenantID, restaurantID int64, req *domain.CreateDiningTableRequest) (*domain.DiningTable, error) {
+	if req.TableNumber == "" {
+		return nil, errors.New("table number is required")
+	}
+
+	token, err := generateTableToken()
+	if err != nil {
+		return nil, err
+	}
+
+	seats := req.Seats
+	if seats <= 0 {
+		seats = 4
+	}
+
+	table := &domain.DiningTable{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		TableNumber:  req.TableNumber,
+		Label:        req.Label,
+		Area:         req.Area,
+		Seats:        seats,
+		QRToken:      token,
+		IsActive:     true,
+	}
+	return uc.diningRepo.CreateTable(table)
+}
+
+// ListTables returns all tables of a restaurant with their open tabs
+func (uc *DiningUseCase) ListTables(tenantID, restaurantID int64) ([]domain.DiningTable, error) {
+	tables, err := uc.diningRepo.ListTables(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	tabs, err := uc.diningRepo.ListOpenTabs(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	byTable := make(map[int64]*domain.TableTab, len(tabs))
+	for i := range tabs {
+		byTable[tabs[i].TableID] = &tabs[i]
+	}
+	for i := range tables {
+		tables[i].OpenTab = byTable[tables[i].ID]
+	}
+	return tables, nil
+}
+
+// UpdateTable updates table details
+func (uc *DiningUseCase) UpdateTable(tenantID, restaurantID, tableID int64, req *domain.UpdateDiningTableRequest) (*domain.DiningTable, error) {
+	table, err := uc.diningRepo.GetTableByID(tenantID, restaurantID, tableID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Label != nil {
+		table.Label = *req.Label
+	}
+	if req.Area != nil {
+		table.Area = *req.Area
+	}
+	if req.Seats != nil && *req.Seats > 0 {
+		table.Seats = *req.Seats
+	}
+	if req.IsActive != nil {
+		table.IsActive = *req.IsActive
+	}
+
+	if err := uc.diningRepo.UpdateTable(table); err != nil {
+		return nil, err
+	}
+	return table, nil
+}
+
+// RegenerateTableToken issues a new QR token, invalidating the old printed code
+func (uc *DiningUseCase) RegenerateTableToken(tenantID, restaurantID, tableID int64) (*domain.DiningTable, error) {
+	token, err := generateTableToken()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.diningRepo.UpdateTableToken(tenantID, restaurantID, tableID, token); err != nil {
+		return nil, err
+	}
+	return uc.diningRepo.GetTableByID(tenantID, restaurantID, tableID)
+}
+
+// DeleteTable removes a table
+func (uc *DiningUseCase) DeleteTable(tenantID, restaurantID, tableID int64) error {
+	return uc.diningRepo.DeleteTable(tenantID, restaurantID, tableID)
+}
+
+// ResolveTableToken resolves a QR token to an active table
+func (uc *DiningUseCase) ResolveTableToken(token string) (*domain.DiningTable, error) {
+	if token == "" {
+		return nil, domain.ErrTableNotFound
+	}
+	table, err := uc.diningRepo.GetTableByToken(token)
+	if err != nil {
+		return nil, err
+	}
+	if !table.IsActive {
+		return nil, domain.ErrTableNotFound
+	}
+	return table, nil
+}
+
+// PlaceTableOrder adds a round to the table's open tab, opening a tab if none exists
+func (uc *DiningUseCase) PlaceTableOrder(table *domain.DiningTable, req *domain.CreateOrderRequest) (*domain.Order, error) {
+	tab, err := uc.diningRepo.GetOpenTabForTable(table.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tab == nil {
+		tab, err = uc.diningRepo.CreateTab(&domain.TableTab{
+			TenantID:     table.TenantID,
+			RestaurantID: table.RestaurantID,
+			TableID:      table.ID,
+			TabNumber:    domain.GenerateTabNumber(table.TableNumber, time.Now().UnixNano()/int64(time.Millisecond)),
+		})
+		if err != nil {
+			// Another guest at the same table may have opened the tab concurrently
+			existing, lookupErr := uc.diningRepo.GetOpenTabForTable(table.ID)
+			if lookupErr != nil || existing == nil {
+				return nil, err
+			}
+			tab = existing
+		}
+	}
+
+	return uc.orderUC.CreateTabOrder(tab, req)
+}
+
+// ListOpenTabs returns all open tabs of a restaurant
+func (uc *DiningUseCase) ListOpenTabs(tenantID, restaurantID int64) ([]domain.TableTab, error) {
+	return uc.diningRepo.ListOpenTabs(tenantID, restaurantID)
+}
+
+// GetTab retrieves a tab with all of its order rounds
+func (uc *DiningUseCase) GetTab(tenantID, restaurantID, tabID int64) (*domain.TableTab, error) {
+	tab, err := uc.diningRepo.GetTabByID(tenantID, restaurantID, tabID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders, err := uc.loadTabOrders(tab)
+	if err != nil {
+		return nil, err
+	}
+	tab.Orders = orders
+	return tab, nil
+}
+
+// TransferTab moves an open tab to another table
+func (uc *DiningUseCase) TransferTab(tenantID, restaurantID, tabID int64, req *domain.TransferTabRequest) (*domain.TableTab, error) {
+	tab, err := uc.diningRepo.GetTabByID(tenantID, restaurantID, tabID)
+	if err != nil {
+		return nil, err
+	}
+	if tab.Status != domain.TabStatusOpen {
+		return nil, domain.ErrTabNotOpen
+	}
+
+	target, err := uc.diningRepo.GetTableByID(tenantID, restaurantID, req.TargetTableID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == tab.TableID {
+		return tab, nil
+	}
+
+	occupied, err := uc.diningRepo.GetOpenTabForTable(target.ID)
+	if err != nil {
+		return nil, err
+	}
+	if occupied != nil {
+		return nil, fmt.Errorf("table %s already has an open tab, merge the tabs instead", target.TableNumber)
+	}
+
+	if err := uc.diningRepo.TransferTab(tenantID, tab.ID, target.ID); err != nil {
+		return nil, err
+	}
+	return uc.diningRepo.GetTabByID(tenantID, restaurantID, tabID)
+}
+
+// MergeTabs merges the source tabs into the target tab
+func (uc *DiningUseCase) MergeTabs(tenantID, restaurantID, targetTabID int64, req *domain.MergeTabsRequest) (*domain.TableTab, error) {
+	if len(req.SourceTabIDs) == 0 {
+		return nil, errors.New("at least one source tab is required")
+	}
+
+	for _, id := range req.SourceTabIDs {
+		if id == targetTabID {
+			return nil, errors.New("cannot merge a tab into itself")
+		}
+		// Scope every source tab to the restaurant before touching it
+		if _, err := uc.diningRepo.GetTabByID(tenantID, restaurantID, id); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := uc.diningRepo.GetTabByID(tenantID, restaurantID, targetTabID); err != nil {
+		return nil, err
+	}
+
+	if err := uc.diningRepo.MergeTabs(tenantID, targetTabID, req.SourceTabIDs); err != nil {
+		return nil, err
+	}
+	return uc.GetTab(tenantID, restaurantID, targetTabID)
+}
+
+// GetTabBill returns the current bill of a tab without closing it
+func (uc *DiningUseCase) GetTabBill(tenantID, restaurantID, tabID int64) (*domain.TabBill, error) {
+	tab, err := uc.GetTab(tenantID, restaurantID, tabID)
+	if err != nil {
+		return nil, err
+	}
+	bill := domain.BuildTabBill(tab, tab.Orders, tab.DiscountAmount, tab.ServiceCharge)
+	bill.Table, _ = uc.diningRepo.GetTableByID(tenantID, restaurantID, tab.TableID)
+	return bill, nil
+}
+
+// CloseTab closes a tab into its final bill
+func (uc *DiningUseCase) CloseTab(tenantID, restaurantID, tabID int64, closedBy *int64, req *domain.CloseTabRequest) (*domain.TabBill, error) {
+	if req.DiscountAmount < 0 || req.ServiceCharge < 0 {
+		return nil, errors.New("discount and service charge cannot be negative")
+	}
+
+	tab, err := uc.GetTab(tenantID, restaurantID, tabID)
+	if err != nil {
+		return nil, err
+	}
+	if tab.Status != domain.TabStatusOpen {
+		return nil, domain.ErrTabNotOpen
+	}
+
+	bill := domain.BuildTabBill(tab, tab.Orders, req.DiscountAmount, req.ServiceCharge)
+
+	tab.Subtotal = bill.Subtotal
+	tab.TaxAmount = bill.TaxAmount
+	tab.DiscountAmount = bill.DiscountAmount
+	tab.ServiceCharge = bill.ServiceCharge
+	tab.TotalAmount = bill.TotalAmount
+	tab.PaymentMethod = req.PaymentMethod
+	tab.Notes = req.Notes
+	tab.ClosedBy = closedBy
+
+	if err := uc.diningRepo.CloseTab(tab); err != nil {
+		return nil, err
+	}
+
+	bill.Table, _ = uc.diningRepo.GetTableByID(tenantID, restaurantID, tab.TableID)
+	return bill, nil
+}
+
+// loadTabOrders loads every order round placed on a tab
+func (uc *DiningUseCase) loadTabOrders(tab *domain.TableTab) ([]domain.Order, error) {
+	ids, err := uc.diningRepo.ListTabOrderIDs(tab.TenantID, tab.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	orders := make([]domain.Order, 0, len(ids))
+	for _, id := range ids {
+		order, err := uc.orderRepo.GetOrderByID(tab.TenantID, tab.RestaurantID, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load tab order %d: %w", id, err)
+		}
+		orders = append(orders, *order)
+	}
+	return orders, nil
+}
@@ -0,0 +1,561 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+	"time"
+)
+
+// DispatchUseCase assigns delivery orders to drivers.
+// Ready orders are offered to the best ranked available driver; if the driver declines
+// or does not answer within the offer timeout the job falls back to the next driver.
+// Orders compatible with one a driver is still collecting are batched onto that driver's run,
+// and each driver's drop-offs are sequenced into a route with per-order ETAs.
+// A dispatcher can override the engine at any time.
+type DispatchUseCase struct {
+	driverRepo   repository.DriverRepository
+	dispatchRepo *repository.DispatchRepository
+	orderRepo    *repository.OrderRepository
+	offerTimeout time.Duration
+	weights      domain.DispatchWeights
+}
+
+// NewDispatchUseCase creates new dispatch use case
+func NewDispatchUseCase(driverRepo repository.DriverRepository, dispatchRepo *repository.DispatchRepository, orderRepo *repository.OrderRepository) *DispatchUseCase {
+	return &DispatchUseCase{
+		driverRepo:   driverRepo,
+		dispatchRepo: dispatchRepo,
+		orderRepo:    orderRepo,
+		offerTimeout: domain.DispatchOfferTimeout,
+		weights:      domain.DefaultDispatchWeights,
+	}
+}
+
+// DispatchOrder starts (or resumes) the driver search for a delivery order.
+// It returns the open offer, or ErrNoDriverAvailable when the order needs manual dispatch.
+func (uc *DispatchUseCase) DispatchOrder(ctx context.Context, tenantID, restaurantID, orderID int64) (*domain.DispatchOffer, error) {
+	order, err := uc.orderRepo.GetOrderByID(tenantID, restaurantID, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	if !order.NeedsDelivery() {
+		return nil, domain.ErrOrderNotDelivery
+	}
+
+	assignment, err := uc.activeAssignment(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment != nil {
+		return nil, errors.New("order already has a driver assigned")
+	}
+
+	offers, err := uc.dispatchRepo.ListOffersForOrder(order.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range offers {
+		if offers[i].Status == domain.OfferStatusOffered && time.Now().Before(offers[i].ExpiresAt) {
+			return &offers[i], nil
+		}
+	}
+
+	return uc.offerNext(ctx, order, offers)
+}
+
+// offerNext offers the order to the best ranked driver that has not been offered it yet
+func (uc *DispatchUseCase) offerNext(ctx context.Context, order *domain.Order, previous []domain.DispatchOffer) (*domain.DispatchOffer, error) {
+	if len(previous) >= domain.MaxDispatchAttempts {
+		return nil, domain.ErrNoDriverAvailable
+	}
+
+	drivers, err := uc.driverRepo.GetAvailableDrivers(ctx, int(order.RestaurantID))
+	if err != nil {
+		return nil, err
+	}
+
+	excluded, err := uc.dispatchRepo.ListDriversWithOpenOffers(order.RestaurantID)
+	if err != nil {
+		return nil, err
+	}
+	for _, offer := range previous {
+		excluded[offer.DriverID] = true
+	}
+
+	// A driver still waiting for a compatible order takes this one on the same run
+	if offer, err := uc.offerBatch(ctx, order, len(previous)+1, excluded); err != nil || offer != nil {
+		return offer, err
+	}
+
+	locations := make(map[int]*domain.DriverLocation, len(drivers))
+	for _, d := range drivers {
+		if excluded[d.ID] {
+			continue
+		}
+		// A driver without location history falls back to the position on the driver record
+		if loc, err := uc.driverRepo.GetLastLocation(ctx, d.ID); err == nil {
+			locations[d.ID] = loc
+		}
+	}
+
+	lat, lon, err := uc.dispatchRepo.GetRestaurantLocation(order.RestaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := domain.RankDispatchCandidates(drivers, locations, lat, lon, excluded, uc.weights)
+	if len(candidates) == 0 {
+		return nil, domain.ErrNoDriverAvailable
+	}
+
+	best := candidates[0]
+	return uc.dispatchRepo.CreateOffer(&domain.DispatchOffer{
+		TenantID:     order.TenantID,
+		RestaurantID: order.RestaurantID,
+		OrderID:      order.ID,
+		DriverID:     best.Driver.ID,
+		Attempt:      len(previous) + 1,
+		Status:       domain.OfferStatusOffered,
+		Score:        best.Score,
+		DistanceKm:   best.DistanceKm,
+		ExpiresAt:    time.Now().Add(uc.offerTimeout),
+	})
+}
+
+// offerBatch offers the order to a driver who has yet to collect a batch-compatible order
+// from the restaurant. It returns nil when no such driver has capacity.
+func (uc *DispatchUseCase) offerBatch(ctx context.Context, order *domain.Order, attempt int, excluded map[int]bool) (*domain.DispatchOffer, error) {
+	if !order.HasDropOffLocation() {
+		return nil, nil
+	}
+
+	pickups, err := uc.dispatchRepo.ListPendingPickups(order.RestaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pickup := range pickups {
+		if excluded[pickup.DriverID] {
+			continue
+		}
+		other, err := uc.orderRepo.GetOrderByID(order.TenantID, order.RestaurantID, int64(pickup.OrderID))
+		if err != nil || !domain.BatchCompatible(order, other) {
+			continue
+		}
+		driver, err := uc.driverRepo.GetByID(ctx, pickup.DriverID)
+		if err != nil || driver.Status != "active" || driver.AvailabilityStatus == "offline" ||
+			driver.ActiveOrdersCount >= domain.MaxBatchSize {
+			continue
+		}
+
+		return uc.dispatchRepo.CreateOffer(&domain.DispatchOffer{
+			TenantID:     order.TenantID,
+			RestaurantID: order.RestaurantID,
+			OrderID:      order.ID,
+			DriverID:     driver.ID,
+			Attempt:      attempt,
+			Status:       domain.OfferStatusOffered,
+			Score:        1, // batched with an order the driver is already collecting
+			ExpiresAt:    time.Now().Add(uc.offerTimeout),
+		})
+	}
+	return nil, nil
+}
+
+// fallback moves an order to the next driver after an offer was declined or expired
+func (uc *DispatchUseCase) fallback(ctx context.Context, offer *domain.DispatchOffer) {
+	order, err := uc.orderRepo.GetOrderByID(offer.TenantID, offer.RestaurantID, offer.OrderID)
+	if err != nil || order.Status != "ready" {
+		return
+	}
+
+	previous, err := uc.dispatchRepo.ListOffersForOrder(order.ID)
+	if err != nil {
+		log.Printf("dispatch: failed to list offers for order %d: %v", order.ID, err)
+		return
+	}
+
+	if _, err := uc.offerNext(ctx, order, previous); err != nil {
+		log.Printf("dispatch: order %d needs manual dispatch: %v", order.ID, err)
+	}
+}
+
+// GetOffer returns an offer of the given restaurant
+func (uc *DispatchUseCase) GetOffer(tenantID, restaurantID, offerID int64) (*domain.DispatchOffer, error) {
+	offer, err := uc.dispatchRepo.GetOfferByID(offerID)
+	if err != nil {
+		return nil, err
+	}
+	if offer.TenantID != tenantID || offer.RestaurantID != restaurantID {
+		return nil, domain.ErrOfferNotFound
+	}
+	return offer, nil
+}
+
+// AcceptOffer assigns the order to the driver who accepted the offer
+func (uc *DispatchUseCase) AcceptOffer(ctx context.Context, offerID int64, driverID int) (*domain.DriverAssignment, error) {
+	offer, err := uc.dispatchRepo.GetOfferByID(offerID)
+	if err != nil {
+		return nil, err
+	}
+	if offer.DriverID != driverID {
+		return nil, domain.ErrOfferNotFound
+	}
+	if offer.Status == domain.OfferStatusOffered && time.Now().After(offer.ExpiresAt) {
+		if expired, err := uc.dispatchRepo.RespondToOffer(offer.ID, domain.OfferStatusExpired); err == nil {
+			uc.fallback(ctx, expired)
+		}
+		return nil, domain.ErrOfferExpired
+	}
+
+	offer, err = uc.dispatchRepo.RespondToOffer(offer.ID, domain.OfferStatusAccepted)
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.assign(ctx, offer.OrderID, driverID, "")
+}
+
+// DeclineOffer records a declined offer and moves the job to the next driver
+func (uc *DispatchUseCase) DeclineOffer(ctx context.Context, offerID int64, driverID int) error {
+	offer, err := uc.dispatchRepo.GetOfferByID(offerID)
+	if err != nil {
+		return err
+	}
+	if offer.DriverID != driverID {
+		return domain.ErrOfferNotFound
+	}
+
+	offer, err = uc.dispatchRepo.RespondToOffer(offer.ID, domain.OfferStatusDeclined)
+	if err != nil {
+		return err
+	}
+
+	uc.fallback(ctx, offer)
+	return nil
+}
+
+// ExpireOffers closes offers that were not answered in time and falls back to the next driver
+func (uc *DispatchUseCase) ExpireOffers(ctx context.Context) (int, error) {
+	offers, err := uc.dispatchRepo.ListExpiredOffers(time.Now())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, offer := range offers {
+		closed, err := uc.dispatchRepo.RespondToOffer(offer.ID, domain.OfferStatusExpired)
+		if err != nil {
+			// Answered by the driver in the meantime
+			continue
+		}
+		expired++
+		uc.fallback(ctx, closed)
+	}
+	return expired, nil
+}
+
+// RunOfferExpiry expires unanswered offers on every tick until the context is cancelled
+func (uc *DispatchUseCase) RunOfferExpiry(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := uc.ExpireOffers(ctx); err != nil {
+				log.Printf("dispatch: failed to expire offers: %v", err)
+			}
+		}
+	}
+}
+
+// ManualAssign lets a dispatcher assign an order to a specific driver, overriding the engine.
+// Open offers are cancelled and an existing assignment to another driver is released.
+func (uc *DispatchUseCase) ManualAssign(ctx context.Context, tenantID, restaurantID, orderID int64, req *domain.ManualAssignRequest, dispatcherID int64) (*domain.DriverAssignment, error) {
+	if req.DriverID <= 0 {
+		return nil, errors.New("driver_id is required")
+	}
+
+	order, err := uc.orderRepo.GetOrderByID(tenantID, restaurantID, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	if order.Status == "delivered" || order.Status == "cancelled" {
+		return nil, fmt.Errorf("cannot assign a driver to a %s order", order.Status)
+	}
+
+	driver, err := uc.driverRepo.GetByID(ctx, req.DriverID)
+	if err != nil {
+		return nil, err
+	}
+	if int64(driver.TenantID) != tenantID || int64(driver.RestaurantID) != restaurantID {
+		return nil, errors.New("driver not found")
+	}
+
+	if err := uc.dispatchRepo.CancelOpenOffers(order.ID); err != nil {
+		return nil, err
+	}
+
+	current, err := uc.activeAssignment(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		if current.DriverID == req.DriverID {
+			return current, nil
+		}
+		if err := uc.release(ctx, current); err != nil {
+			return nil, err
+		}
+	}
+
+	var assignedBy *int64
+	if dispatcherID > 0 {
+		assignedBy = &dispatcherID
+	}
+	now := time.Now()
+	_, err = uc.dispatchRepo.CreateOffer(&domain.DispatchOffer{
+		TenantID:     order.TenantID,
+		RestaurantID: order.RestaurantID,
+		OrderID:      order.ID,
+		DriverID:     req.DriverID,
+		Attempt:      0,
+		Status:       domain.OfferStatusAccepted,
+		IsManual:     true,
+		AssignedBy:   assignedBy,
+		ExpiresAt:    now,
+		RespondedAt:  &now,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return uc.assign(ctx, order.ID, req.DriverID, req.Notes)
+}
+
+// GetDispatchStatus returns the offers and assignment of an order
+func (uc *DispatchUseCase) GetDispatchStatus(ctx context.Context, tenantID, restaurantID, orderID int64) (*domain.DispatchStatus, error) {
+	order, err := uc.orderRepo.GetOrderByID(tenantID, restaurantID, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+
+	offers, err := uc.dispatchRepo.ListOffersForOrder(order.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &domain.DispatchStatus{OrderID: order.ID, State: "unassigned", Offers: offers}
+
+	assignment, err := uc.activeAssignment(ctx, order.ID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment != nil {
+		status.State = "assigned"
+		status.Assignment = assignment
+		return status, nil
+	}
+
+	for i := range offers {
+		if offers[i].Status == domain.OfferStatusOffered {
+			status.State = "offered"
+			status.CurrentOffer = &offers[i]
+			return status, nil
+		}
+	}
+	return status, nil
+}
+
+// CancelDispatch withdraws open offers and releases the driver of a cancelled order
+func (uc *DispatchUseCase) CancelDispatch(ctx context.Context, orderID int64) error {
+	if err := uc.dispatchRepo.CancelOpenOffers(orderID); err != nil {
+		return err
+	}
+
+	current, err := uc.activeAssignment(ctx, orderID)
+	if err != nil || current == nil {
+		return err
+	}
+	return uc.release(ctx, current)
+}
+
+// activeAssignment returns the order's assignment if it is still in progress
+func (uc *DispatchUseCase) activeAssignment(ctx context.Context, orderID int64) (*domain.DriverAssignment, error) {
+	assignment, err := uc.driverRepo.GetAssignmentByOrderID(ctx, int(orderID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get assignment: %w", err)
+	}
+	if assignment == nil {
+		return nil, nil
+	}
+	switch assignment.Status {
+	case "pending", "accepted", "in_progress":
+		return assignment, nil
+	}
+	return nil, nil
+}
+
+// assign creates the driver assignment and updates the driver's load
+func (uc *DispatchUseCase) assign(ctx context.Context, orderID int64, driverID int, notes string) (*domain.DriverAssignment, error) {
+	assignment := &domain.DriverAssignment{
+		OrderID:         int(orderID),
+		DriverID:        driverID,
+		Status:          "accepted",
+		AssignmentNotes: notes,
+	}
+	if err := uc.driverRepo.AssignOrder(ctx, assignment); err != nil {
+		return nil, fmt.Errorf("failed to assign order: %w", err)
+	}
+	if err := uc.driverRepo.UpdateAssignmentStatus(ctx, assignment.ID, "accepted"); err != nil {
+		return nil, fmt.Errorf("failed to accept assignment: %w", err)
+	}
+
+	driver, err := uc.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.driverRepo.UpdateActiveOrders(ctx, driverID, driver.ActiveOrdersCount+1); err != nil {
+		return nil, fmt.Errorf("failed to update driver load: %w", err)
+	}
+	uc.refreshRoute(ctx, driverID)
+	assignment.Driver = driver
+	return assignment, nil
+}
+
+// release fails an in-progress assignment and frees the driver's capacity
+func (uc *DispatchUseCase) release(ctx context.Context, assignment *domain.DriverAssignment) error {
+	if err := uc.driverRepo.UpdateAssignmentStatus(ctx, assignment.ID, "failed"); err != nil {
+		return fmt.Errorf("failed to release assignment: %w", err)
+	}
+
+	driver, err := uc.driverRepo.GetByID(ctx, assignment.DriverID)
+	if err != nil {
+		return err
+	}
+	count := driver.ActiveOrdersCount - 1
+	if count < 0 {
+		count = 0
+	}
+	if err := uc.driverRepo.UpdateActiveOrders(ctx, driver.ID, count); err != nil {
+		return err
+	}
+	uc.refreshRoute(ctx, driver.ID)
+	return nil
+}
+
+// GetDriverRoute returns the planned route of a restaurant's driver
+func (uc *DispatchUseCase) GetDriverRoute(ctx context.Context, tenantID, restaurantID int64, driverID int) (*domain.DeliveryRoute, error) {
+	driver, err := uc.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if int64(driver.TenantID) != tenantID || int64(driver.RestaurantID) != restaurantID {
+		return nil, errors.New("driver not found")
+	}
+	return uc.RefreshRoute(ctx, driverID)
+}
+
+// RefreshRoute sequences a driver's active orders and stores the resulting ETA on each order.
+// The route starts from the driver's latest position; while orders are still to be collected
+// it goes through the restaurant first. Orders without drop-off coordinates are listed last without an ETA.
+func (uc *DispatchUseCase) RefreshRoute(ctx context.Context, driverID int) (*domain.DeliveryRoute, error) {
+	driver, err := uc.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := uc.driverRepo.ListActiveAssignments(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	route := &domain.DeliveryRoute{DriverID: driverID, Stops: []domain.RouteStop{}, ComputedAt: now}
+
+	orderNumbers := make(map[int64]string, len(assignments))
+	points := make([]domain.RoutePoint, 0, len(assignments))
+	var unlocated []*domain.Order
+	for _, a := range assignments {
+		order, err := uc.orderRepo.GetOrderByID(int64(driver.TenantID), int64(driver.RestaurantID), int64(a.OrderID))
+		if err != nil {
+			continue
+		}
+		if a.Status != "in_progress" {
+			route.ViaRestaurant = true
+		}
+		orderNumbers[order.ID] = order.OrderNumber
+		if order.HasDropOffLocation() {
+			points = append(points, domain.RoutePoint{OrderID: order.ID, Latitude: order.DeliveryLatitude, Longitude: order.DeliveryLongitude})
+		} else {
+			unlocated = append(unlocated, order)
+		}
+	}
+
+	startLat, startLon := driver.CurrentLatitude, driver.CurrentLongitude
+	if loc, err := uc.driverRepo.GetLastLocation(ctx, driverID); err == nil {
+		startLat, startLon = &loc.Latitude, &loc.Longitude
+	}
+	route.StartLatitude, route.StartLongitude = startLat, startLon
+
+	departAt := now
+	if route.ViaRestaurant {
+		restLat, restLon, err := uc.dispatchRepo.GetRestaurantLocation(int64(driver.RestaurantID))
+		if err != nil {
+			return nil, err
+		}
+		if restLat != nil && restLon != nil {
+			if startLat != nil && startLon != nil {
+				km := domain.HaversineKm(*startLat, *startLon, *restLat, *restLon)
+				route.TotalKm += km
+				departAt = departAt.Add(domain.TravelTime(km, domain.AverageDeliverySpeedKmh))
+			}
+			startLat, startLon = restLat, restLon
+		}
+	}
+
+	if startLat != nil && startLon != nil {
+		sequenced := domain.OptimizeRoute(*startLat, *startLon, points)
+		route.TotalKm += domain.RouteDistanceKm(*startLat, *startLon, sequenced)
+		route.Stops = domain.PlanStops(*startLat, *startLon, sequenced, departAt, domain.AverageDeliverySpeedKmh)
+		for i := range route.Stops {
+			stop := &route.Stops[i]
+			stop.OrderNumber = orderNumbers[stop.OrderID]
+			if err := uc.orderRepo.UpdateEstimatedDeliveryTime(stop.OrderID, *stop.ETA); err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		// Without a known position the drop-offs cannot be sequenced or timed
+		for _, p := range points {
+			unlocated = append(unlocated, &domain.Order{ID: p.OrderID, OrderNumber: orderNumbers[p.OrderID],
+				DeliveryLatitude: p.Latitude, DeliveryLongitude: p.Longitude})
+		}
+	}
+
+	for _, order := range unlocated {
+		route.Stops = append(route.Stops, domain.RouteStop{
+			Sequence:    len(route.Stops) + 1,
+			OrderID:     order.ID,
+			OrderNumber: order.OrderNumber,
+			Latitude:    order.DeliveryLatitude,
+			Longitude:   order.DeliveryLongitude,
+		})
+	}
+	route.TotalKm = math.Round(route.TotalKm*100) / 100
+	return route, nil
+}
+
+// refreshRoute recomputes a driver's route after their orders changed; ETAs are best effort
+func (uc *DispatchUseCase) refreshRoute(ctx context.Context, driverID int) {
+	if _, err := uc.RefreshRoute(ctx, driverID); err != nil {
+		log.Printf("dispatch: failed to plan route for driver %d: %v", driverID, err)
+	}
+}
@@ -0,0 +1,548 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/big"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/pkg/jwt"
+	"pos-saas/internal/repository"
+)
+
+// DriverAppUseCase backs the driver-facing app: sign-in, duty, job offers,
+// pick-up and proof of delivery, and live location updates.
+// Job steps drive both the driver assignment and the order status:
+// accepted -> in_progress (order out_for_delivery) -> completed (order delivered).
+type DriverAppUseCase struct {
+	driverRepo   repository.DriverRepository
+	dispatchRepo *repository.DispatchRepository
+	orderRepo    *repository.OrderRepository
+	orderUC      *OrderUseCase
+	dispatchUC   *DispatchUseCase
+	earningsUC   *EarningsUseCase
+	locationRepo *repository.LocationRepository
+	tokenService *jwt.TokenService
+	throttle     *domain.LocationThrottle
+	routeTimer   *domain.LocationThrottle
+	storageURL   string
+}
+
+// NewDriverAppUseCase creates new driver app use case
+func NewDriverAppUseCase(
+	driverRepo repository.DriverRepository,
+	dispatchRepo *repository.DispatchRepository,
+	orderRepo *repository.OrderRepository,
+	orderUC *OrderUseCase,
+	dispatchUC *DispatchUseCase,
+	earningsUC *EarningsUseCase,
+	locationRepo *repository.LocationRepository,
+	tokenService *jwt.TokenService,
+	storageURL string,
+) *DriverAppUseCase {
+	return &DriverAppUseCase{
+		driverRepo:   driverRepo,
+		dispatchRepo: dispatchRepo,
+		orderRepo:    orderRepo,
+		orderUC:      orderUC,
+		dispatchUC:   dispatchUC,
+		earningsUC:   earningsUC,
+		locationRepo: locationRepo,
+		tokenService: tokenService,
+		throttle:     domain.NewLocationThrottle(domain.LocationUpdateInterval),
+		routeTimer:   domain.NewLocationThrottle(domain.RouteRefreshInterval),
+		storageURL:   storageURL,
+	}
+}
+
+// Login signs a driver in with their email or phone number and returns a driver app token
+func (uc *DriverAppUseCase) Login(ctx context.Context, req *domain.DriverLoginRequest) (*domain.DriverLoginResponse, error) {
+	login := strings.TrimSpace(req.Login)
+	if login == "" || req.Password == "" {
+		return nil, domain.ErrInvalidDriverCredentials
+	}
+
+	driver, passwordHash, err := uc.driverRepo.GetByLogin(ctx, login)
+	if err != nil || passwordHash == "" {
+		return nil, domain.ErrInvalidDriverCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(passwordHash), []byte(req.Password)); err != nil {
+		return nil, domain.ErrInvalidDriverCredentials
+	}
+	if driver.Status != "active" {
+		return nil, domain.ErrDriverInactive
+	}
+
+	restaurantID := driver.RestaurantID
+	token, err := uc.tokenService.GenerateToken(driver.ID, driver.TenantID, &restaurantID, driver.Email, domain.DriverRole)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	return &domain.DriverLoginResponse{Token: token, Driver: driver}, nil
+}
+
+// SetPassword sets the driver app password of a restaurant's driver
+func (uc *DriverAppUseCase) SetPassword(ctx context.Context, tenantID, restaurantID int64, driverID int, req *domain.SetDriverPasswordRequest) error {
+	if len(req.Password) < domain.MinDriverPasswordLength {
+		return fmt.Errorf("password must be at least %d characters", domain.MinDriverPasswordLength)
+	}
+
+	driver, err := uc.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return err
+	}
+	if int64(driver.TenantID) != tenantID || int64(driver.RestaurantID) != restaurantID {
+		return errors.New("driver not found")
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+	return uc.driverRepo.SetPassword(ctx, driver.ID, string(hash))
+}
+
+// ResolveDriver loads the driver behind a driver app token.
+// Drivers deactivated after the token was issued are rejected.
+func (uc *DriverAppUseCase) ResolveDriver(ctx context.Context, claims *jwt.Claims) (*domain.Driver, error) {
+	driver, err := uc.driverRepo.GetByID(ctx, claims.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if driver.TenantID != claims.TenantID {
+		return nil, errors.New("driver not found")
+	}
+	if driver.Status != "active" {
+		return nil, domain.ErrDriverInactive
+	}
+	return driver, nil
+}
+
+// SetDuty puts a driver on or off duty.
+// Going off duty declines any open offer and is refused while deliveries are in progress.
+func (uc *DriverAppUseCase) SetDuty(ctx context.Context, driver *domain.Driver, req *domain.DriverDutyRequest) (*domain.Driver, error) {
+	if req.OnDuty {
+		if err := uc.driverRepo.UpdateAvailability(ctx, driver.ID, "available"); err != nil {
+			return nil, fmt.Errorf("failed to update availability: %w", err)
+		}
+		return uc.driverRepo.GetByID(ctx, driver.ID)
+	}
+
+	active, err := uc.driverRepo.ListActiveAssignments(ctx, driver.ID)
+	if err != nil {
+		return nil, err
+	}
+	if len(active) > 0 {
+		return nil, domain.ErrDriverHasActiveJobs
+	}
+
+	offers, err := uc.dispatchRepo.ListOpenOffersForDriver(driver.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, offer := range offers {
+		if err := uc.dispatchUC.DeclineOffer(ctx, offer.ID, driver.ID); err != nil && !errors.Is(err, domain.ErrOfferNotPending) {
+			return nil, err
+		}
+	}
+
+	if err := uc.driverRepo.UpdateAvailability(ctx, driver.ID, "offline"); err != nil {
+		return nil, fmt.Errorf("failed to update availability: %w", err)
+	}
+	uc.throttle.Forget(driver.ID)
+	uc.routeTimer.Forget(driver.ID)
+	return uc.driverRepo.GetByID(ctx, driver.ID)
+}
+
+// ListJobs returns the driver's open offers followed by the deliveries assigned to them
+func (uc *DriverAppUseCase) ListJobs(ctx context.Context, driver *domain.Driver) ([]domain.DriverJob, error) {
+	offers, err := uc.dispatchRepo.ListOpenOffersForDriver(driver.ID)
+	if err != nil {
+		return nil, err
+	}
+	assignments, err := uc.driverRepo.ListActiveAssignments(ctx, driver.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]domain.DriverJob, 0, len(offers)+len(assignments))
+	for i := range offers {
+		order, err := uc.orderRepo.GetOrderByID(int64(driver.TenantID), int64(driver.RestaurantID), offers[i].OrderID)
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, domain.DriverJob{Offer: &offers[i], Order: domain.NewDriverJobOrder(order, false)})
+	}
+	for i := range assignments {
+		order, err := uc.orderRepo.GetOrderByID(int64(driver.TenantID), int64(driver.RestaurantID), int64(assignments[i].OrderID))
+		if err != nil {
+			continue
+		}
+		jobs = append(jobs, domain.DriverJob{Assignment: &assignments[i], Order: domain.NewDriverJobOrder(order, true)})
+	}
+	return jobs, nil
+}
+
+// AcceptOffer accepts a job offered to the driver
+func (uc *DriverAppUseCase) AcceptOffer(ctx context.Context, driver *domain.Driver, offerID int64) (*domain.DriverJob, error) {
+	assignment, err := uc.dispatchUC.AcceptOffer(ctx, offerID, driver.ID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.job(driver, assignment)
+}
+
+// RejectOffer declines a job offered to the driver; the job moves on to the next driver
+func (uc *DriverAppUseCase) RejectOffer(ctx context.Context, driver *domain.Driver, offerID int64) error {
+	return uc.dispatchUC.DeclineOffer(ctx, offerID, driver.ID)
+}
+
+// PickUp records the driver collecting the order from the restaurant.
+// The order goes out for delivery and a delivery PIN is generated for the customer.
+func (uc *DriverAppUseCase) PickUp(ctx context.Context, driver *domain.Driver, assignmentID int) (*domain.DriverJob, error) {
+	assignment, err := uc.assignment(ctx, driver, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.Status != "pending" && assignment.Status != "accepted" {
+		return nil, fmt.Errorf("cannot pick up a job that is %s", assignment.Status)
+	}
+
+	tenantID, restaurantID := int64(driver.TenantID), int64(driver.RestaurantID)
+	err = uc.orderUC.UpdateOrderStatus(tenantID, restaurantID, int64(assignment.OrderID), &domain.UpdateOrderStatusRequest{
+		Status: "out_for_delivery",
+		Reason: "Picked up by driver",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.driverRepo.UpdateAssignmentStatus(ctx, assignment.ID, "in_progress"); err != nil {
+		return nil, fmt.Errorf("failed to start assignment: %w", err)
+	}
+
+	pin, err := generateDeliveryPIN()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.orderRepo.SetDeliveryPIN(int64(assignment.OrderID), pin); err != nil {
+		return nil, err
+	}
+
+	uc.refreshRoute(ctx, driver.ID)
+
+	assignment, err = uc.driverRepo.GetAssignmentByID(ctx, assignment.ID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.job(driver, assignment)
+}
+
+// Deliver completes a delivery. The customer's PIN is checked when given,
+// otherwise a proof photo is required.
+func (uc *DriverAppUseCase) Deliver(ctx context.Context, driver *domain.Driver, assignmentID int, req *domain.DeliverRequest, photo *multipart.FileHeader) (*domain.DriverJob, error) {
+	assignment, err := uc.assignment(ctx, driver, assignmentID)
+	if err != nil {
+		return nil, err
+	}
+	if assignment.Status != "in_progress" {
+		return nil, errors.New("cannot deliver a job that has not been picked up")
+	}
+	if req.CashCollected != nil && *req.CashCollected < 0 {
+		return nil, errors.New("cash_collected cannot be negative")
+	}
+
+	orderID := int64(assignment.OrderID)
+	var proofType, photoURL string
+	pinVerified := false
+	switch {
+	case strings.TrimSpace(req.PIN) != "":
+		expected, err := uc.orderRepo.GetDeliveryPIN(orderID)
+		if err != nil {
+			return nil, err
+		}
+		if !domain.VerifyDeliveryPIN(expected, strings.TrimSpace(req.PIN)) {
+			return nil, domain.ErrInvalidDeliveryPIN
+		}
+		proofType, pinVerified = domain.ProofTypePIN, true
+	case photo != nil:
+		photoURL, err = uc.uploadProofPhoto(driver.TenantID, orderID, photo)
+		if err != nil {
+			return nil, err
+		}
+		proofType = domain.ProofTypePhoto
+	default:
+		return nil, domain.ErrDeliveryProofRequired
+	}
+
+	// Cash due is read before completion, while the payment status is still unpaid
+	order, err := uc.orderRepo.GetOrderByID(int64(driver.TenantID), int64(driver.RestaurantID), orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = uc.orderUC.UpdateOrderStatus(int64(driver.TenantID), int64(driver.RestaurantID), orderID, &domain.UpdateOrderStatusRequest{
+		Status: "delivered",
+		Reason: "Delivered by driver",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := uc.driverRepo.RecordDeliveryProof(ctx, assignment.ID, proofType, photoURL, pinVerified); err != nil {
+		return nil, fmt.Errorf("failed to record delivery proof: %w", err)
+	}
+	if err := uc.driverRepo.UpdateAssignmentStatus(ctx, assignment.ID, "completed"); err != nil {
+		return nil, fmt.Errorf("failed to complete assignment: %w", err)
+	}
+	if err := uc.driverRepo.UpdateDeliveryMetrics(ctx, driver.ID, true); err != nil {
+		return nil, fmt.Errorf("failed to update delivery metrics: %w", err)
+	}
+	if err := uc.earningsUC.RecordDelivery(ctx, driver, assignment.ID, order, req.CashCollected); err != nil {
+		log.Printf("driver app: failed to record earnings for assignment %d: %v", assignment.ID, err)
+	}
+
+	current, err := uc.driverRepo.GetByID(ctx, driver.ID)
+	if err != nil {
+		return nil, err
+	}
+	count := current.ActiveOrdersCount - 1
+	if count < 0 {
+		count = 0
+	}
+	if err := uc.driverRepo.UpdateActiveOrders(ctx, driver.ID, count); err != nil {
+		return nil, fmt.Errorf("failed to update driver load: %w", err)
+	}
+	uc.refreshRoute(ctx, driver.ID)
+
+	assignment, err = uc.driverRepo.GetAssignmentByID(ctx, assignment.ID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.job(driver, assignment)
+}
+
+// GetEarnings returns the driver's current shift: deliveries, earnings and cash to hand in
+func (uc *DriverAppUseCase) GetEarnings(ctx context.Context, driver *domain.Driver) (*domain.DriverSettlement, error) {
+	return uc.earningsUC.PreviewSettlement(ctx, int64(driver.TenantID), int64(driver.RestaurantID), driver.ID)
+}
+
+// RecordLocation stores a location update from the driver app.
+// Updates arriving faster than the location interval are dropped and reported as not stored.
+// Stored locations are checked against the restaurant and drop-off geofences of the driver's jobs.
+func (uc *DriverAppUseCase) RecordLocation(ctx context.Context, driver *domain.Driver, req *domain.UpdateLocationRequest) (*domain.LocationUpdateResult, error) {
+	if !domain.ValidCoordinates(req.Latitude, req.Longitude) {
+		return nil, domain.ErrInvalidCoordinates
+	}
+	now := time.Now()
+	if !uc.throttle.Allow(driver.ID, now) {
+		return &domain.LocationUpdateResult{Stored: false}, nil
+	}
+
+	location := &domain.DriverLocation{
+		DriverID:  driver.ID,
+		Latitude:  req.Latitude,
+		Longitude: req.Longitude,
+		Accuracy:  req.Accuracy,
+	}
+
+	active, err := uc.driverRepo.ListActiveAssignments(ctx, driver.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only link the location to an order the driver is actually delivering
+	if req.OrderID != nil {
+		for _, a := range active {
+			if a.OrderID == *req.OrderID {
+				location.OrderID = req.OrderID
+				break
+			}
+		}
+	}
+
+	if err := uc.driverRepo.RecordLocation(ctx, location); err != nil {
+		return nil, fmt.Errorf("failed to record location: %w", err)
+	}
+	if err := uc.driverRepo.UpdateLocation(ctx, driver.ID, req.Latitude, req.Longitude); err != nil {
+		return nil, fmt.Errorf("failed to update location: %w", err)
+	}
+
+	result := &domain.LocationUpdateResult{Stored: true}
+	if len(active) > 0 && domain.GeofenceAccuracyOK(req.Accuracy) {
+		result.Events, err = uc.detectGeofenceEvents(ctx, driver, active, req.Latitude, req.Longitude, now)
+		if err != nil {
+			log.Printf("driver app: geofencing failed for driver %d: %v", driver.ID, err)
+		}
+	}
+
+	// Keep the ETAs of the driver's orders current as they move
+	if uc.routeTimer.Allow(driver.ID, now) {
+		uc.refreshRoute(ctx, driver.ID)
+	}
+	return result, nil
+}
+
+// GetRoute returns the driver's planned drop-off sequence with ETAs
+func (uc *DriverAppUseCase) GetRoute(ctx context.Context, driver *domain.Driver) (*domain.DeliveryRoute, error) {
+	return uc.dispatchUC.RefreshRoute(ctx, driver.ID)
+}
+
+// detectGeofenceEvents records the arrivals and departures a location triggers for the driver's
+// active jobs. Leaving the restaurant after arriving picks up jobs the driver has not yet
+// picked up in the app, which sends their orders out for delivery.
+func (uc *DriverAppUseCase) detectGeofenceEvents(ctx context.Context, driver *domain.Driver, active []domain.DriverAssignment, lat, lon float64, at time.Time) ([]domain.GeofenceEvent, error) {
+	var restaurant *domain.RoutePoint
+	rLat, rLon, err := uc.dispatchRepo.GetRestaurantLocation(int64(driver.RestaurantID))
+	if err != nil {
+		return nil, err
+	}
+	if rLat != nil && rLon != nil {
+		restaurant = &domain.RoutePoint{Latitude: *rLat, Longitude: *rLon}
+	}
+
+	ids := make([]int, len(active))
+	for i, a := range active {
+		ids[i] = a.ID
+	}
+	emitted, err := uc.locationRepo.ListEmittedEvents(ids)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []domain.GeofenceEvent
+	for _, a := range active {
+		target := &domain.GeofenceTarget{
+			AssignmentID: a.ID,
+			OrderID:      a.OrderID,
+			Status:       a.Status,
+			Restaurant:   restaurant,
+			Emitted:      emitted[a.ID],
+		}
+		order, err := uc.orderRepo.GetOrderByID(int64(driver.TenantID), int64(driver.RestaurantID), int64(a.OrderID))
+		if err != nil {
+			return events, err
+		}
+		if order.HasDropOffLocation() {
+			target.DropOff = &domain.RoutePoint{OrderID: order.ID, Latitude: order.DeliveryLatitude, Longitude: order.DeliveryLongitude}
+		}
+
+		for _, name := range domain.DetectGeofenceEvents(target, lat, lon) {
+			event, err := uc.locationRepo.RecordGeofenceEvent(&domain.GeofenceEvent{
+				AssignmentID: a.ID,
+				DriverID:     driver.ID,
+				OrderID:      a.OrderID,
+				Event:        name,
+				Latitude:     lat,
+				Longitude:    lon,
+				OccurredAt:   at,
+			})
+			if err != nil {
+				return events, err
+			}
+			if event == nil {
+				continue // already recorded by a concurrent update
+			}
+			events = append(events, *event)
+
+			if name == domain.GeofencePickedUp && (a.Status == "pending" || a.Status == "accepted") {
+				if _, err := uc.PickUp(ctx, driver, a.ID); err != nil {
+					log.Printf("driver app: automatic pick-up of job %d failed: %v", a.ID, err)
+				}
+			}
+		}
+	}
+	return events, nil
+}
+
+// refreshRoute recomputes the driver's route; a failure must not fail the driver's action
+func (uc *DriverAppUseCase) refreshRoute(ctx context.Context, driverID int) {
+	if _, err := uc.dispatchUC.RefreshRoute(ctx, driverID); err != nil {
+		log.Printf("driver app: failed to plan route for driver %d: %v", driverID, err)
+	}
+}
+
+// assignment returns one of the driver's assignments; other drivers' jobs are reported as not found
+func (uc *DriverAppUseCase) assignment(ctx context.Context, driver *domain.Driver, assignmentID int) (*domain.DriverAssignment, error) {
+	assignment, err := uc.driverRepo.GetAssignmentByID(ctx, assignmentID)
+	if err != nil || assignment.DriverID != driver.ID {
+		return nil, domain.ErrJobNotFound
+	}
+	return assignment, nil
+}
+
+// job wraps an assignment with the driver's view of its order
+func (uc *DriverAppUseCase) job(driver *domain.Driver, assignment *domain.DriverAssignment) (*domain.DriverJob, error) {
+	order, err := uc.orderRepo.GetOrderByID(int64(driver.TenantID), int64(driver.RestaurantID), int64(assignment.OrderID))
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	return &domain.DriverJob{Assignment: assignment, Order: domain.NewDriverJobOrder(order, true)}, nil
+}
+
+// uploadProofPhoto stores a proof of delivery photo and returns its URL
+func (uc *DriverAppUseCase) uploadProofPhoto(tenantID int, orderID int64, fileHeader *multipart.FileHeader) (string, error) {
+	extensions := map[string]string{
+		"image/jpeg": ".jpg",
+		"image/png":  ".png",
+		"image/webp": ".webp",
+	}
+
+	ext, ok := extensions[fileHeader.Header.Get("Content-Type")]
+	if !ok {
+		return "", fmt.Errorf("invalid file type. allowed: jpeg, png, webp")
+	}
+
+	const maxFileSize = 10 * 1024 * 1024 // 10MB
+	if fileHeader.Size > maxFileSize {
+		return "", fmt.Errorf("file size exceeds 10MB limit")
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	filename := fmt.Sprintf("deliveries/%d/%d/%d%s", tenantID, orderID, time.Now().UnixNano(), ext)
+	path := filepath.Join("./uploads", filename)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to save file: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, file); err != nil {
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return fmt.Sprintf("%s/%s", uc.storageURL, filename), nil
+}
+
+// generateDeliveryPIN returns a random numeric delivery PIN
+func generateDeliveryPIN() (string, error) {
+	var sb strings.Builder
+	for i := 0; i < domain.DeliveryPINLength; i++ {
+		n, err := rand.Int(rand.Reader, big.NewInt(10))
+		if err != nil {
+			return "", fmt.Errorf("failed to generate delivery PIN: %w", err)
+		}
+		sb.WriteByte(byte('0' + n.Int64()))
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+	"time"
+)
+
+// DriverLocationUseCase exposes geofence events and keeps driver location history bounded
+type DriverLocationUseCase struct {
+	locationRepo *repository.LocationRepository
+}
+
+// NewDriverLocationUseCase creates new driver location use case
+func NewDriverLocationUseCase(locationRepo *repository.LocationRepository) *DriverLocationUseCase {
+	return &DriverLocationUseCase{locationRepo: locationRepo}
+}
+
+// ListOrderEvents returns the geofence events of an order
+func (uc *DriverLocationUseCase) ListOrderEvents(tenantID, restaurantID, orderID int64) ([]domain.GeofenceEvent, error) {
+	return uc.locationRepo.ListOrderEvents(tenantID, restaurantID, orderID)
+}
+
+// GetRetentionPolicy returns the tenant's location retention policy
+func (uc *DriverLocationUseCase) GetRetentionPolicy(tenantID int64) (*domain.LocationRetentionPolicy, error) {
+	return uc.locationRepo.GetRetentionPolicy(tenantID)
+}
+
+// SaveRetentionPolicy replaces the tenant's location retention policy
+func (uc *DriverLocationUseCase) SaveRetentionPolicy(tenantID int64, policy *domain.LocationRetentionPolicy) (*domain.LocationRetentionPolicy, error) {
+	policy.TenantID = tenantID
+	if err := policy.Validate(); err != nil {
+		return nil, err
+	}
+	return uc.locationRepo.SaveRetentionPolicy(policy)
+}
+
+// ApplyRetention purges and downsamples the location history of every tenant.
+// A tenant that fails is logged and skipped so the others are still processed.
+func (uc *DriverLocationUseCase) ApplyRetention(now time.Time) ([]domain.LocationRetentionResult, error) {
+	policies, err := uc.locationRepo.ListRetentionPolicies()
+	if err != nil {
+		return nil, err
+	}
+
+	results := []domain.LocationRetentionResult{}
+	for _, policy := range policies {
+		downsampleBefore, purgeBefore := policy.Cutoffs(now)
+		result := domain.LocationRetentionResult{TenantID: policy.TenantID}
+
+		result.Purged, err = uc.locationRepo.PurgeLocations(policy.TenantID, purgeBefore)
+		if err != nil {
+			log.Printf("location retention: tenant %d: %v", policy.TenantID, err)
+			continue
+		}
+		result.Downsampled, err = uc.locationRepo.DownsampleLocations(policy.TenantID, purgeBefore, downsampleBefore, policy.DownsampleIntervalSeconds)
+		if err != nil {
+			log.Printf("location retention: tenant %d: %v", policy.TenantID, err)
+			continue
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// RunRetention applies the retention policies on every tick until the context is cancelled
+func (uc *DriverLocationUseCase) RunRetention(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := uc.ApplyRetention(time.Now()); err != nil {
+				log.Printf("location retention: failed to apply policies: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package usecase
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+	"time"
+)
+
+// EarningsUseCase handles driver pay, cash on delivery collection and end-of-shift settlements
+type EarningsUseCase struct {
+	earningsRepo *repository.EarningsRepository
+	driverRepo   repository.DriverRepository
+	dispatchRepo *repository.DispatchRepository
+	orderRepo    *repository.OrderRepository
+}
+
+// NewEarningsUseCase creates new earnings use case
+func NewEarningsUseCase(
+	earningsRepo *repository.EarningsRepository,
+	driverRepo repository.DriverRepository,
+	dispatchRepo *repository.DispatchRepository,
+	orderRepo *repository.OrderRepository,
+) *EarningsUseCase {
+	return &EarningsUseCase{
+		earningsRepo: earningsRepo,
+		driverRepo:   driverRepo,
+		dispatchRepo: dispatchRepo,
+		orderRepo:    orderRepo,
+	}
+}
+
+// ListRules returns the restaurant's earning rules
+func (uc *EarningsUseCase) ListRules(tenantID, restaurantID int64) ([]domain.EarningRule, error) {
+	return uc.earningsRepo.ListEarningRules(tenantID, restaurantID)
+}
+
+// SaveRule creates or replaces the restaurant default rule, or a driver's rule when driver_id is set
+func (uc *EarningsUseCase) SaveRule(ctx context.Context, tenantID, restaurantID int64, req *domain.EarningRuleRequest) (*domain.EarningRule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.DriverID != nil {
+		if _, err := uc.driver(ctx, tenantID, restaurantID, *req.DriverID); err != nil {
+			return nil, err
+		}
+	}
+
+	return uc.earningsRepo.SaveEarningRule(&domain.EarningRule{
+		TenantID:        tenantID,
+		RestaurantID:    restaurantID,
+		DriverID:        req.DriverID,
+		PerDelivery:     req.PerDelivery,
+		PerKm:           req.PerKm,
+		IncentiveEvery:  req.IncentiveEvery,
+		IncentiveAmount: req.IncentiveAmount,
+	})
+}
+
+// DeleteRule removes an earning rule
+func (uc *EarningsUseCase) DeleteRule(tenantID, restaurantID, ruleID int64) error {
+	return uc.earningsRepo.DeleteEarningRule(tenantID, restaurantID, ruleID)
+}
+
+// RecordDelivery prices a completed delivery and records the cash the driver collected.
+// The distance is measured from the restaurant to the drop-off. For unpaid cash orders the
+// collected amount defaults to the amount due, and the order is marked paid once it is covered.
+func (uc *EarningsUseCase) RecordDelivery(ctx context.Context, driver *domain.Driver, assignmentID int, order *domain.Order, cashCollected *float64) error {
+	distance := 0.0
+	if order.HasDropOffLocation() {
+		lat, lon, err := uc.dispatchRepo.GetRestaurantLocation(order.RestaurantID)
+		if err != nil {
+			return err
+		}
+		if lat != nil && lon != nil {
+			distance = domain.HaversineKm(*lat, *lon, order.DeliveryLatitude, order.DeliveryLongitude)
+		}
+	}
+
+	rule, err := uc.earningsRepo.GetEarningRule(order.RestaurantID, driver.ID)
+	if err != nil {
+		return err
+	}
+
+	expected := domain.CashDue(order)
+	collected := expected
+	if cashCollected != nil {
+		if *cashCollected < 0 {
+			return errors.New("cash_collected cannot be negative")
+		}
+		collected = domain.RoundMoney(*cashCollected)
+	}
+
+	err = uc.earningsRepo.RecordDeliveryEarning(assignmentID, domain.RoundMoney(distance), rule.DeliveryPay(distance), expected, collected)
+	if err != nil {
+		return err
+	}
+
+	if expected > 0 && collected >= expected-domain.CashTolerance {
+		if err := uc.orderRepo.UpdatePaymentStatus(order.TenantID, order.RestaurantID, order.ID, "paid"); err != nil {
+			return fmt.Errorf("failed to mark order paid: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetDriverStats returns a driver's delivery and financial figures
+func (uc *EarningsUseCase) GetDriverStats(ctx context.Context, tenantID, restaurantID int64, driverID int) (*domain.DriverStats, error) {
+	if _, err := uc.driver(ctx, tenantID, restaurantID, driverID); err != nil {
+		return nil, err
+	}
+	return uc.driverRepo.GetDriverStats(ctx, driverID)
+}
+
+// GetEarningsReport lists a driver's completed deliveries and earnings in a period
+func (uc *EarningsUseCase) GetEarningsReport(ctx context.Context, tenantID, restaurantID int64, driverID int, from, to *time.Time) (*domain.DriverEarningsReport, error) {
+	if _, err := uc.driver(ctx, tenantID, restaurantID, driverID); err != nil {
+		return nil, err
+	}
+	deliveries, err := uc.earningsRepo.ListDeliveries(driverID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return domain.NewDriverEarningsReport(driverID, from, to, deliveries), nil
+}
+
+// PreviewSettlement returns the settlement of the driver's current shift without saving it
+func (uc *EarningsUseCase) PreviewSettlement(ctx context.Context, tenantID, restaurantID int64, driverID int) (*domain.DriverSettlement, error) {
+	if _, err := uc.driver(ctx, tenantID, restaurantID, driverID); err != nil {
+		return nil, err
+	}
+	return uc.buildSettlement(tenantID, restaurantID, driverID, 0, time.Now())
+}
+
+// SettleDriver closes the driver's shift: unsettled deliveries are totalled and
+// the cash expected from cash orders is compared with the cash handed in
+func (uc *EarningsUseCase) SettleDriver(ctx context.Context, tenantID, restaurantID int64, driverID int, req *domain.SettleDriverRequest, settledBy int64) (*domain.DriverSettlement, error) {
+	if req.CashHandedIn < 0 {
+		return nil, domain.ErrNegativeCashHandedIn
+	}
+	if _, err := uc.driver(ctx, tenantID, restaurantID, driverID); err != nil {
+		return nil, err
+	}
+
+	settlement, err := uc.buildSettlement(tenantID, restaurantID, driverID, req.CashHandedIn, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if settlement.DeliveriesCount == 0 {
+		return nil, domain.ErrNothingToSettle
+	}
+
+	settlement.Notes = req.Notes
+	if settledBy > 0 {
+		settlement.SettledBy = &settledBy
+	}
+	return uc.earningsRepo.CreateSettlement(settlement)
+}
+
+// GetSettlement returns a settlement with its deliveries
+func (uc *EarningsUseCase) GetSettlement(tenantID, restaurantID, settlementID int64) (*domain.DriverSettlement, error) {
+	return uc.earningsRepo.GetSettlement(tenantID, restaurantID, settlementID)
+}
+
+// ListSettlements returns the restaurant's settlements
+func (uc *EarningsUseCase) ListSettlements(tenantID, restaurantID int64, filters *domain.SettlementFilters) ([]domain.DriverSettlement, int, error) {
+	return uc.earningsRepo.ListSettlements(tenantID, restaurantID, filters)
+}
+
+// ExportSettlement renders a settlement as CSV or JSON
+func (uc *EarningsUseCase) ExportSettlement(tenantID, restaurantID, settlementID int64, format string) ([]byte, string, string, error) {
+	settlement, err := uc.earningsRepo.GetSettlement(tenantID, restaurantID, settlementID)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	name := fmt.Sprintf("settlement_%d", settlement.ID)
+	return export(format, name, settlement, func(buf *bytes.Buffer) error {
+		return domain.WriteSettlementCSV(buf, settlement)
+	})
+}
+
+// ExportEarningsReport renders a driver's earnings report as CSV or JSON
+func (uc *EarningsUseCase) ExportEarningsReport(report *domain.DriverEarningsReport, format string) ([]byte, string, string, error) {
+	name := fmt.Sprintf("driver_%d_earnings", report.DriverID)
+	return export(format, name, report, func(buf *bytes.Buffer) error {
+		return domain.WriteEarningsReportCSV(buf, report)
+	})
+}
+
+// export encodes v in the requested format (CSV by default) and returns the data, content type and file name
+func export(format, name string, v interface{}, writeCSV func(*bytes.Buffer) error) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "", "csv":
+		if err := writeCSV(&buf); err != nil {
+			return nil, "", "", fmt.Errorf("failed to write CSV: %w", err)
+		}
+		return buf.Bytes(), "text/csv", name + ".csv", nil
+	case "json":
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return nil, "", "", err
+		}
+		return data, "application/json", name + ".json", nil
+	default:
+		return nil, "", "", domain.ErrInvalidExportFormat
+	}
+}
+
+// buildSettlement totals the driver's unsettled deliveries since their previous settlement
+func (uc *EarningsUseCase) buildSettlement(tenantID, restaurantID int64, driverID int, cashHandedIn float64, now time.Time) (*domain.DriverSettlement, error) {
+	deliveries, err := uc.earningsRepo.ListUnsettledDeliveries(driverID)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := uc.earningsRepo.GetEarningRule(restaurantID, driverID)
+	if err != nil {
+		return nil, err
+	}
+	since, err := uc.earningsRepo.GetLastSettlementEnd(driverID)
+	if err != nil {
+		return nil, err
+	}
+
+	settlement := domain.BuildSettlement(rule, deliveries, since, cashHandedIn, now)
+	settlement.TenantID = tenantID
+	settlement.RestaurantID = restaurantID
+	settlement.DriverID = driverID
+	return settlement, nil
+}
+
+// driver returns a driver of the restaurant
+func (uc *EarningsUseCase) driver(ctx context.Context, tenantID, restaurantID int64, driverID int) (*domain.Driver, error) {
+	driver, err := uc.driverRepo.GetByID(ctx, driverID)
+	if err != nil {
+		return nil, err
+	}
+	if int64(driver.TenantID) != tenantID || int64(driver.RestaurantID) != restaurantID {
+		return nil, errors.New("driver not found")
+	}
+	return driver, nil
+}
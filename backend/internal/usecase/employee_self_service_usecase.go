@@ -0,0 +1,132 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+)
+
+// EmployeeSelfServiceUseCase links HR employees to login accounts and lets linked users
+// clock in and request leave for themselves, without HR permissions
+type EmployeeSelfServiceUseCase struct {
+	employeeRepo *repository.EmployeeRepository
+	userRepo     *repository.UserRepository
+	leaveRepo    *repository.LeaveRepository
+	attendanceUC *AttendanceUseCase
+	leaveUC      *LeaveUseCase
+	auditRepo    *repository.HRAuditRepository
+}
+
+// NewEmployeeSelfServiceUseCase creates new employee self-service use case
+func NewEmployeeSelfServiceUseCase(
+	employeeRepo *repository.EmployeeRepository,
+	userRepo *repository.UserRepository,
+	leaveRepo *repository.LeaveRepository,
+	attendanceUC *AttendanceUseCase,
+	leaveUC *LeaveUseCase,
+	auditRepo *repository.HRAuditRepository,
+) *EmployeeSelfServiceUseCase {
+	return &EmployeeSelfServiceUseCase{
+		employeeRepo: employeeRepo,
+		userRepo:     userRepo,
+		leaveRepo:    leaveRepo,
+		attendanceUC: attendanceUC,
+		leaveUC:      leaveUC,
+		auditRepo:    auditRepo,
+	}
+}
+
+// LinkUser links an employee to one of the tenant's users, or unlinks it when no user is given
+func (uc *EmployeeSelfServiceUseCase) LinkUser(ctx context.Context, tenantID, restaurantID, employeeID int, req *domain.LinkEmployeeUserRequest, actorID int) (*domain.Employee, error) {
+	before, err := uc.attendanceUC.employee(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if req.UserID != nil {
+		user, err := uc.userRepo.GetByID(ctx, *req.UserID)
+		if errors.Is(err, sql.ErrNoRows) || (err == nil && user.TenantID != tenantID) {
+			return nil, domain.ErrRBACUserNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.employeeRepo.LinkUser(tenantID, restaurantID, employeeID, req.UserID); err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditEmployee, employeeID, "", domain.AuditUpdate,
+		map[string]interface{}{"user_id": before.UserID}, map[string]interface{}{"user_id": req.UserID}, actorID)
+	return uc.attendanceUC.employee(tenantID, restaurantID, employeeID)
+}
+
+// Employee returns the signed-in user's employee record in the restaurant
+func (uc *EmployeeSelfServiceUseCase) Employee(tenantID, restaurantID, userID int) (*domain.Employee, error) {
+	id, err := uc.employeeID(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.attendanceUC.employee(tenantID, restaurantID, id)
+}
+
+// ClockIn records the signed-in user's arrival
+func (uc *EmployeeSelfServiceUseCase) ClockIn(tenantID, restaurantID, userID int, req *domain.ClockPunchRequest, ip string) (*domain.Attendance, error) {
+	id, err := uc.employeeID(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	req.EmployeeID = id
+	return uc.attendanceUC.ClockIn(tenantID, restaurantID, req, ip)
+}
+
+// ClockOut records the signed-in user's departure
+func (uc *EmployeeSelfServiceUseCase) ClockOut(tenantID, restaurantID, userID int, req *domain.ClockPunchRequest, ip string) (*domain.Attendance, error) {
+	id, err := uc.employeeID(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	req.EmployeeID = id
+	return uc.attendanceUC.ClockOut(tenantID, restaurantID, req, ip)
+}
+
+// Leaves returns the signed-in user's leave requests
+func (uc *EmployeeSelfServiceUseCase) Leaves(tenantID, restaurantID, userID int) ([]domain.Leave, error) {
+	id, err := uc.employeeID(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.leaveRepo.GetEmployeeLeaves(tenantID, restaurantID, id)
+}
+
+// RequestLeave submits a leave request for the signed-in user
+func (uc *EmployeeSelfServiceUseCase) RequestLeave(tenantID, restaurantID, userID int, req *domain.CreateLeaveRequest) (*domain.Leave, error) {
+	id, err := uc.employeeID(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	req.EmployeeID = id
+	return uc.leaveUC.RequestLeave(tenantID, restaurantID, req, userID)
+}
+
+// LeaveBalances returns the signed-in user's leave balances for a year
+func (uc *EmployeeSelfServiceUseCase) LeaveBalances(tenantID, restaurantID, userID, year int) ([]domain.LeaveBalance, error) {
+	id, err := uc.employeeID(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	return uc.leaveUC.EmployeeBalances(tenantID, restaurantID, id, year)
+}
+
+func (uc *EmployeeSelfServiceUseCase) employeeID(tenantID, restaurantID, userID int) (int, error) {
+	id, err := uc.employeeRepo.GetEmployeeIDByUser(tenantID, restaurantID, userID)
+	if err != nil {
+		return 0, err
+	}
+	if id == 0 {
+		return 0, domain.ErrNoEmployeeRecord
+	}
+	return id, nil
+}
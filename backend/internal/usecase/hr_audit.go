@@ -0,0 +1,72 @@
+package usecase
+
+import (
+	"encoding/json"
+	"log"
+	"strings"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+)
+
+// recordHRChange writes an HR change into hr_audit_logs. before and after are stored as the
+// old and new values; nil leaves them out. The trail is best-effort: failures are logged and
+// never undo the change.
+func recordHRChange(
+	auditRepo *repository.HRAuditRepository,
+	tenantID, restaurantID int,
+	entityType string, entityID int, entityName, action string,
+	before, after interface{},
+	userID int,
+) {
+	if auditRepo == nil {
+		return
+	}
+	entry := domain.HRAuditLog{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		EntityType:   entityType,
+		EntityID:     entityID,
+		EntityName:   entityName,
+		Action:       action,
+		OldValues:    auditValues(before),
+		NewValues:    auditValues(after),
+	}
+	if userID != 0 {
+		entry.UserID = &userID
+	}
+	if err := auditRepo.CreateAuditLog(&entry); err != nil {
+		log.Printf("hr audit: failed to record %s of %s %d: %v", entry.Action, entry.EntityType, entry.EntityID, err)
+	}
+}
+
+func auditValues(v interface{}) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil || string(data) == "null" {
+		return nil
+	}
+	return data
+}
+
+// employeeName names an employee in the audit trail
+func employeeName(emp *domain.Employee) string {
+	return strings.TrimSpace(emp.FirstName + " " + emp.LastName)
+}
+
+// HRAuditUseCase reads the HR audit trail
+type HRAuditUseCase struct {
+	auditRepo *repository.HRAuditRepository
+}
+
+// NewHRAuditUseCase creates new HR audit use case
+func NewHRAuditUseCase(auditRepo *repository.HRAuditRepository) *HRAuditUseCase {
+	return &HRAuditUseCase{auditRepo: auditRepo}
+}
+
+// ListAuditLogs returns the restaurant's HR changes, newest first
+func (uc *HRAuditUseCase) ListAuditLogs(tenantID, restaurantID int, filter domain.HRAuditFilter) ([]domain.HRAuditLog, error) {
+	return uc.auditRepo.ListAuditLogs(tenantID, restaurantID, filter)
+}
@@ -0,0 +1,353 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/pkg/mailer"
+	"pos-saas/internal/pkg/sms"
+	"pos-saas/internal/repository"
+)
+
+// InvitationUseCase invites staff to join a tenant. Invitations hold a seat of the tenant's
+// user limit, carry the roles the account gets, and are accepted by setting a password.
+type InvitationUseCase struct {
+	repo         *repository.InvitationRepository
+	userRepo     *repository.UserRepository
+	employeeRepo *repository.EmployeeRepository
+	userRoles    *UserRoleUseCase
+	permissions  *PermissionUseCase
+	sessions     *SessionUseCase
+	mailer       mailer.Mailer
+	sms          sms.Sender
+	appURL       string
+}
+
+// NewInvitationUseCase creates new invitation use case. appURL is the dashboard URL that
+// invitation links point to.
+func NewInvitationUseCase(
+	repo *repository.InvitationRepository,
+	userRepo *repository.UserRepository,
+	employeeRepo *repository.EmployeeRepository,
+	userRoles *UserRoleUseCase,
+	permissions *PermissionUseCase,
+	sessions *SessionUseCase,
+	mail mailer.Mailer,
+	smsSender sms.Sender,
+	appURL string,
+) *InvitationUseCase {
+	return &InvitationUseCase{
+		repo:         repo,
+		userRepo:     userRepo,
+		employeeRepo: employeeRepo,
+		userRoles:    userRoles,
+		permissions:  permissions,
+		sessions:     sessions,
+		mailer:       mail,
+		sms:          smsSender,
+		appURL:       strings.TrimRight(appURL, "/"),
+	}
+}
+
+// ensureSeatAvailable refuses to add a user when the tenant's active users and pending
+// invitations already fill its user limit
+func (uc *InvitationUseCase) ensureSeatAvailable(ctx context.Context, tenantID int) error {
+	seats, err := uc.repo.CountSeats(ctx, tenantID, "")
+	if err != nil {
+		return err
+	}
+	if seats.Available() == 0 {
+		return domain.ErrUserLimitReached
+	}
+	return nil
+}
+
+// Invite stores an invitation and sends its link by email or SMS. Inviting an email that
+// already has an open invitation replaces it.
+func (uc *InvitationUseCase) Invite(ctx context.Context, actor domain.PermissionActor, req *domain.CreateInvitationRequest) (*domain.UserInvitation, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	tenantID := int(actor.TenantID)
+
+	seats, err := uc.repo.CountSeats(ctx, tenantID, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if seats.Available() == 0 {
+		return nil, domain.ErrUserLimitReached
+	}
+	existing, err := uc.userRepo.GetByEmailInTenant(ctx, actor.TenantID, req.Email)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, domain.ErrEmailTaken
+	}
+	if err := uc.checkRoles(ctx, actor, req.RoleIDs); err != nil {
+		return nil, err
+	}
+
+	var restaurantID *int
+	if actor.RestaurantID > 0 {
+		rid := int(actor.RestaurantID)
+		restaurantID = &rid
+	}
+	if req.EmployeeID != nil {
+		if restaurantID == nil {
+			return nil, errors.New("a restaurant must be selected to invite an employee")
+		}
+		emp, err := uc.employeeRepo.GetEmployeeByID(tenantID, *restaurantID, *req.EmployeeID)
+		if err != nil {
+			return nil, err
+		}
+		if emp == nil {
+			return nil, fmt.Errorf("employee %d not found", *req.EmployeeID)
+		}
+		if emp.UserID != nil {
+			return nil, domain.ErrEmployeeAlreadyLinked
+		}
+	}
+
+	token, err := newSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+	invitedBy := int(actor.UserID)
+	inv := &domain.UserInvitation{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		EmployeeID:   req.EmployeeID,
+		Name:         req.Name,
+		Email:        req.Email,
+		Phone:        req.Phone,
+		Channel:      req.Channel,
+		RoleIDs:      req.RoleIDs,
+		InvitedBy:    &invitedBy,
+		ExpiresAt:    time.Now().Add(domain.InvitationTTL),
+		TokenHash:    domain.HashToken(token),
+	}
+	if err := uc.repo.Create(ctx, inv); err != nil {
+		return nil, err
+	}
+	if err := uc.deliver(ctx, inv, token); err != nil {
+		return inv, err
+	}
+	return inv, nil
+}
+
+// List returns the tenant's invitations with its seat usage
+func (uc *InvitationUseCase) List(ctx context.Context, tenantID int) (*domain.InvitationList, error) {
+	invitations, err := uc.repo.List(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	seats, err := uc.repo.CountSeats(ctx, tenantID, "")
+	if err != nil {
+		return nil, err
+	}
+	return &domain.InvitationList{Invitations: invitations, Seats: *seats}, nil
+}
+
+// Resend sends an open invitation again with a new link and expiry; the old link stops working.
+// An expired invitation can be resent as long as a seat is available for it.
+func (uc *InvitationUseCase) Resend(ctx context.Context, tenantID int, id int64) (*domain.UserInvitation, error) {
+	inv, err := uc.repo.GetByID(ctx, tenantID, id)
+	if err != nil {
+		return nil, err
+	}
+	switch inv.Status {
+	case domain.InvitationAccepted, domain.InvitationRevoked:
+		return nil, domain.ErrInvitationNotPending
+	case domain.InvitationExpired:
+		if err := uc.ensureSeatAvailable(ctx, tenantID); err != nil {
+			return nil, err
+		}
+	}
+
+	token, err := newSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate invitation token: %w", err)
+	}
+	expiresAt := time.Now().Add(domain.InvitationTTL)
+	if err := uc.repo.Renew(ctx, tenantID, id, domain.HashToken(token), expiresAt); err != nil {
+		return nil, err
+	}
+	inv.ExpiresAt = expiresAt
+	inv.Status = inv.StatusAt(time.Now())
+	if err := uc.deliver(ctx, inv, token); err != nil {
+		return inv, err
+	}
+	return inv, nil
+}
+
+// Revoke cancels an open invitation, freeing its seat
+func (uc *InvitationUseCase) Revoke(ctx context.Context, tenantID int, id int64) error {
+	if _, err := uc.repo.GetByID(ctx, tenantID, id); err != nil {
+		return err
+	}
+	return uc.repo.Revoke(ctx, tenantID, id)
+}
+
+// Preview returns who an invitation is for, so the invitee can check it before accepting
+func (uc *InvitationUseCase) Preview(ctx context.Context, token string) (*domain.InvitationPreview, error) {
+	inv, tenantName, err := uc.repo.GetOpenByToken(ctx, domain.HashToken(strings.TrimSpace(token)))
+	if err != nil {
+		return nil, err
+	}
+	return &domain.InvitationPreview{
+		OrganizationName: tenantName,
+		Name:             inv.Name,
+		Email:            inv.Email,
+		ExpiresAt:        inv.ExpiresAt,
+	}, nil
+}
+
+// Accept creates the invitee's account with the chosen password, assigns the invitation's
+// roles, links the employee record and signs the new user in. An invitation sent by email
+// also verifies the address, as the link could only be opened from that inbox.
+func (uc *InvitationUseCase) Accept(ctx context.Context, req *domain.AcceptInvitationRequest, client domain.SessionClient) (*domain.AuthResponse, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	inv, _, err := uc.repo.GetOpenByToken(ctx, domain.HashToken(strings.TrimSpace(req.Token)))
+	if err != nil {
+		return nil, err
+	}
+
+	hashed, err := uc.acceptPasswordHash(ctx, inv, req.Password)
+	if err != nil {
+		return nil, err
+	}
+	name := inv.Name
+	if req.Name != "" {
+		name = req.Name
+	}
+
+	// The seat check and the account are one transaction, see InvitationRepository.Accept
+	userID, err := uc.repo.Accept(ctx, inv, name, hashed)
+	if err != nil {
+		return nil, err
+	}
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if inv.Channel == domain.InvitationChannelEmail {
+		if err := uc.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+			log.Printf("invitations: failed to verify email of user %d: %v", user.ID, err)
+		}
+	}
+	uc.assignRoles(ctx, inv, user.ID)
+	if inv.EmployeeID != nil && inv.RestaurantID != nil {
+		if err := uc.employeeRepo.LinkUser(inv.TenantID, *inv.RestaurantID, *inv.EmployeeID, &user.ID); err != nil {
+			log.Printf("invitations: failed to link employee %d to user %d: %v", *inv.EmployeeID, user.ID, err)
+		}
+	}
+
+	return uc.sessions.StartSession(ctx, user, client)
+}
+
+// acceptPasswordHash returns the password hash of the invitee's new account. Accounts that
+// share an email share a password, so when the email already has accounts in other tenants
+// the invitee must prove they own them: the invitation must have gone to that inbox and the
+// password must be the existing one, whose hash is reused.
+func (uc *InvitationUseCase) acceptPasswordHash(ctx context.Context, inv *domain.UserInvitation, password string) (string, error) {
+	accounts, err := uc.userRepo.FindByEmailAllTenants(ctx, inv.Email)
+	if err != nil {
+		return "", err
+	}
+
+	var others []domain.User
+	for _, account := range accounts {
+		if account.TenantID == inv.TenantID {
+			return "", domain.ErrEmailTaken
+		}
+		others = append(others, account)
+	}
+	if len(others) == 0 {
+		hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return "", fmt.Errorf("failed to hash password: %w", err)
+		}
+		return string(hashed), nil
+	}
+
+	if inv.Channel != domain.InvitationChannelEmail {
+		return "", domain.ErrInvitationEmailInUse
+	}
+	for _, account := range others {
+		if account.VerifyPassword(password) {
+			return account.PasswordHash, nil
+		}
+	}
+	return "", domain.ErrInvitationPassword
+}
+
+// checkRoles makes sure the roles exist in the tenant and that the inviter may assign them.
+// Invitation roles apply in every restaurant, so the inviter needs that through a tenant-wide
+// role, and cannot invite anyone with more access than the inviter has.
+func (uc *InvitationUseCase) checkRoles(ctx context.Context, actor domain.PermissionActor, roleIDs []int64) error {
+	if len(roleIDs) == 0 {
+		return nil
+	}
+	allowed, err := uc.permissions.HasPermission(ctx, actor.TenantID, 0, actor.UserID, domain.RolesModuleID, domain.PermissionWrite)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return domain.ErrInvitationRoles
+	}
+	for _, roleID := range roleIDs {
+		role, err := tenantRole(uc.userRoles.roleRepo, actor.TenantID, roleID)
+		if err != nil {
+			return err
+		}
+		if err := uc.userRoles.checkGrant(ctx, actor, role, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// assignRoles gives the new user the invitation's roles on behalf of the inviter. Roles
+// deleted since the invitation was sent are skipped.
+func (uc *InvitationUseCase) assignRoles(ctx context.Context, inv *domain.UserInvitation, userID int) {
+	actor := domain.PermissionActor{TenantID: int64(inv.TenantID)}
+	if inv.InvitedBy != nil {
+		actor.UserID = int64(*inv.InvitedBy)
+	}
+	for _, roleID := range inv.RoleIDs {
+		if err := uc.userRoles.assign(ctx, actor, int64(userID), roleID, nil); err != nil {
+			log.Printf("invitations: failed to assign role %d to user %d: %v", roleID, userID, err)
+		}
+	}
+}
+
+// deliver sends the invitation link by the invitation's channel
+func (uc *InvitationUseCase) deliver(ctx context.Context, inv *domain.UserInvitation, token string) error {
+	link := fmt.Sprintf("%s/accept-invitation?token=%s", uc.appURL, token)
+	days := int(domain.InvitationTTL.Hours() / 24)
+
+	var err error
+	if inv.Channel == domain.InvitationChannelSMS {
+		err = uc.sms.Send(ctx, sms.Message{To: inv.Phone, Text: fmt.Sprintf(
+			"You have been invited to join your team on Restaurant POS. Set your password here: %s (expires in %d days)", link, days)})
+	} else {
+		err = uc.mailer.Send(ctx, mailer.Message{To: inv.Email, Subject: "You have been invited to join your team", Text: fmt.Sprintf(
+			"Hi %s,\n\nYou have been invited to join your team on Restaurant POS. Open the link below to choose a password and activate your account:\n\n%s\n\nThe link expires in %d days and can only be used once. If you were not expecting this, you can ignore this email.\n",
+			inv.Name, link, days)})
+	}
+	if err != nil {
+		log.Printf("invitations: failed to send invitation %d by %s: %v", inv.ID, inv.Channel, err)
+		return domain.ErrInvitationNotSent
+	}
+	return nil
+}
@@ -0,0 +1,659 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+)
+
+// LeaveUseCase handles leave requests, leave policies and the balances they maintain
+type LeaveUseCase struct {
+	leaveRepo        *repository.LeaveRepository
+	leavePolicyRepo  *repository.LeavePolicyRepository
+	employeeRepo     *repository.EmployeeRepository
+	notificationRepo *repository.NotificationRepository
+	auditRepo        *repository.HRAuditRepository
+}
+
+// NewLeaveUseCase creates new leave use case
+func NewLeaveUseCase(
+	leaveRepo *repository.LeaveRepository,
+	leavePolicyRepo *repository.LeavePolicyRepository,
+	employeeRepo *repository.EmployeeRepository,
+	notificationRepo *repository.NotificationRepository,
+	auditRepo *repository.HRAuditRepository,
+) *LeaveUseCase {
+	return &LeaveUseCase{
+		leaveRepo:        leaveRepo,
+		leavePolicyRepo:  leavePolicyRepo,
+		employeeRepo:     employeeRepo,
+		notificationRepo: notificationRepo,
+		auditRepo:        auditRepo,
+	}
+}
+
+// ListPolicies returns the restaurant's leave policies
+func (uc *LeaveUseCase) ListPolicies(tenantID, restaurantID int) ([]domain.LeavePolicy, error) {
+	return uc.leavePolicyRepo.ListLeavePolicies(tenantID, restaurantID)
+}
+
+// CreatePolicy creates a policy for a leave type
+func (uc *LeaveUseCase) CreatePolicy(tenantID, restaurantID int, req *domain.LeavePolicyRequest, userID int) (*domain.LeavePolicy, error) {
+	policy, err := uc.newPolicy(tenantID, restaurantID, req)
+	if err != nil {
+		return nil, err
+	}
+	created, err := uc.leavePolicyRepo.CreateLeavePolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditLeavePolicy, created.ID, created.LeaveType, domain.AuditCreate, nil, created, userID)
+	return created, nil
+}
+
+// UpdatePolicy replaces a leave policy. Balances already opened keep their figures.
+func (uc *LeaveUseCase) UpdatePolicy(tenantID, restaurantID, id int, req *domain.LeavePolicyRequest, userID int) (*domain.LeavePolicy, error) {
+	policy, err := uc.newPolicy(tenantID, restaurantID, req)
+	if err != nil {
+		return nil, err
+	}
+	before, err := uc.policyByID(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	policy.ID = id
+	updated, err := uc.leavePolicyRepo.UpdateLeavePolicy(policy)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditLeavePolicy, id, updated.LeaveType, domain.AuditUpdate, before, updated, userID)
+	return updated, nil
+}
+
+// DeletePolicy deletes a leave policy
+func (uc *LeaveUseCase) DeletePolicy(tenantID, restaurantID, id, userID int) error {
+	before, err := uc.policyByID(tenantID, restaurantID, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.leavePolicyRepo.DeleteLeavePolicy(tenantID, restaurantID, id); err != nil {
+		return err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditLeavePolicy, id, before.LeaveType, domain.AuditDelete, before, nil, userID)
+	return nil
+}
+
+// policyByID finds a policy among the restaurant's policies
+func (uc *LeaveUseCase) policyByID(tenantID, restaurantID, id int) (*domain.LeavePolicy, error) {
+	policies, err := uc.leavePolicyRepo.ListLeavePolicies(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range policies {
+		if policies[i].ID == id {
+			return &policies[i], nil
+		}
+	}
+	return nil, domain.ErrLeavePolicyNotFound
+}
+
+func (uc *LeaveUseCase) newPolicy(tenantID, restaurantID int, req *domain.LeavePolicyRequest) (*domain.LeavePolicy, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.HolidayCalendarID != nil {
+		if _, err := uc.calendar(tenantID, restaurantID, *req.HolidayCalendarID); err != nil {
+			return nil, err
+		}
+	}
+	return req.Policy(tenantID, restaurantID), nil
+}
+
+// ListCalendars returns the restaurant's holiday calendars
+func (uc *LeaveUseCase) ListCalendars(tenantID, restaurantID int) ([]domain.HolidayCalendar, error) {
+	return uc.leavePolicyRepo.ListHolidayCalendars(tenantID, restaurantID)
+}
+
+// SaveCalendar creates a holiday calendar, or replaces it when id is set
+func (uc *LeaveUseCase) SaveCalendar(tenantID, restaurantID, id int, req *domain.HolidayCalendarRequest, userID int) (*domain.HolidayCalendar, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	var before *domain.HolidayCalendar
+	action := domain.AuditCreate
+	if id != 0 {
+		existing, err := uc.calendar(tenantID, restaurantID, id)
+		if err != nil {
+			return nil, err
+		}
+		before, action = existing, domain.AuditUpdate
+	}
+	weekendDays := req.WeekendDays
+	if weekendDays == nil {
+		weekendDays = domain.DefaultWeekendDays
+	}
+	saved, err := uc.leavePolicyRepo.SaveHolidayCalendar(&domain.HolidayCalendar{
+		ID:           id,
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		Name:         req.Name,
+		WeekendDays:  weekendDays,
+		IsDefault:    req.IsDefault,
+	})
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditHolidayCalendar, saved.ID, saved.Name, action, before, saved, userID)
+	return saved, nil
+}
+
+// DeleteCalendar deletes a holiday calendar; policies using it fall back to the default calendar
+func (uc *LeaveUseCase) DeleteCalendar(tenantID, restaurantID, id, userID int) error {
+	before, err := uc.calendar(tenantID, restaurantID, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.leavePolicyRepo.DeleteHolidayCalendar(tenantID, restaurantID, id); err != nil {
+		return err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditHolidayCalendar, id, before.Name, domain.AuditDelete, before, nil, userID)
+	return nil
+}
+
+// AddHoliday adds a public holiday to a calendar
+func (uc *LeaveUseCase) AddHoliday(tenantID, restaurantID, calendarID int, req *domain.PublicHolidayRequest, userID int) (*domain.PublicHoliday, error) {
+	calendar, err := uc.calendar(tenantID, restaurantID, calendarID)
+	if err != nil {
+		return nil, err
+	}
+	holiday, err := req.Holiday(calendarID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.leavePolicyRepo.AddPublicHoliday(holiday); err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditHolidayCalendar, calendarID, calendar.Name, domain.AuditUpdate,
+		nil, map[string]interface{}{"added_holiday": holiday}, userID)
+	return holiday, nil
+}
+
+// DeleteHoliday removes a public holiday from a calendar
+func (uc *LeaveUseCase) DeleteHoliday(tenantID, restaurantID, calendarID, id, userID int) error {
+	calendar, err := uc.calendar(tenantID, restaurantID, calendarID)
+	if err != nil {
+		return err
+	}
+	if err := uc.leavePolicyRepo.DeletePublicHoliday(calendarID, id); err != nil {
+		return err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditHolidayCalendar, calendarID, calendar.Name, domain.AuditUpdate,
+		nil, map[string]interface{}{"removed_holiday_id": id}, userID)
+	return nil
+}
+
+// ListLeaves returns the restaurant's leave requests within a date range
+func (uc *LeaveUseCase) ListLeaves(tenantID, restaurantID int, from, to time.Time) ([]domain.Leave, error) {
+	return uc.leaveRepo.ListLeaves(tenantID, restaurantID, from, to)
+}
+
+// GetLeave returns a leave request with its approval chain
+func (uc *LeaveUseCase) GetLeave(tenantID, restaurantID, id int) (*domain.Leave, error) {
+	leave, err := uc.leaveRepo.GetLeaveByID(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if leave == nil {
+		return nil, domain.ErrLeaveNotFound
+	}
+	return leave, nil
+}
+
+// RequestLeave checks a leave request against the leave type's policy and the employee's balance,
+// builds its approval chain up the employee's managers and notifies the first approver
+func (uc *LeaveUseCase) RequestLeave(tenantID, restaurantID int, req *domain.CreateLeaveRequest, userID int) (*domain.Leave, error) {
+	start, end, err := req.Dates()
+	if err != nil {
+		return nil, err
+	}
+	emp, err := uc.employee(tenantID, restaurantID, req.EmployeeID)
+	if err != nil {
+		return nil, err
+	}
+	if !emp.IsActive || emp.EmploymentStatus == "terminated" {
+		return nil, fmt.Errorf("employee %d is not active", emp.ID)
+	}
+
+	policy, err := uc.policy(tenantID, restaurantID, req.LeaveType)
+	if err != nil {
+		return nil, err
+	}
+	calendar, err := uc.policyCalendar(tenantID, restaurantID, policy)
+	if err != nil {
+		return nil, err
+	}
+	days := domain.CountLeaveDays(start, end, req.IsHalfDay, calendar)
+	if days == 0 {
+		return nil, domain.ErrLeaveNoWorkingDays
+	}
+	if policy.ProbationMonths > 0 && start.Before(policy.ProbationEnd(emp.HireDate)) {
+		return nil, fmt.Errorf("%w until %s", domain.ErrLeaveInProbation, policy.ProbationEnd(emp.HireDate).Format(domain.DateLayout))
+	}
+
+	overlaps, err := uc.leaveRepo.HasOverlappingLeave(emp.ID, start, end)
+	if err != nil {
+		return nil, err
+	}
+	if overlaps {
+		return nil, domain.ErrLeaveOverlaps
+	}
+
+	if policy.TrackBalance {
+		balance, err := uc.ensureBalance(policy, emp, start.Year())
+		if err != nil {
+			return nil, err
+		}
+		if days > balance.Bookable() {
+			return nil, fmt.Errorf("%w: %.1f day(s) requested, %.1f available", domain.ErrInsufficientLeaveBalance, days, balance.Bookable())
+		}
+	}
+
+	chain, err := domain.BuildApprovalChain(emp, policy.ApprovalLevels, func(e *domain.Employee) (*domain.Employee, error) {
+		if e.ManagerID == nil {
+			return nil, nil
+		}
+		return uc.employeeRepo.GetEmployeeByID(tenantID, restaurantID, *e.ManagerID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	leave := &domain.Leave{
+		TenantID:             tenantID,
+		RestaurantID:         restaurantID,
+		EmployeeID:           emp.ID,
+		StartDate:            start,
+		EndDate:              end,
+		TotalDays:            days,
+		IsHalfDay:            req.IsHalfDay,
+		HalfDayPeriod:        req.HalfDayPeriod,
+		LeaveType:            req.LeaveType,
+		LeaveCategory:        policy.LeaveCategory,
+		Reason:               req.Reason,
+		ContactNumber:        req.ContactNumber,
+		ContactAddress:       req.ContactAddress,
+		EmergencyContact:     req.EmergencyContact,
+		Status:               "pending",
+		CurrentApprovalLevel: 1,
+		Approvals:            chain,
+		CreatedBy:            &userID,
+	}
+	id, err := uc.leaveRepo.CreateLeave(leave)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := uc.GetLeave(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditLeave, id, employeeName(emp), domain.AuditCreate, nil, created, userID)
+	uc.notifyApprover(created, emp)
+	return created, nil
+}
+
+// ApproveLeave approves the leave's current level on behalf of the user. The last approval
+// takes the days from the employee's balance; earlier ones pass the request to the next manager.
+func (uc *LeaveUseCase) ApproveLeave(tenantID, restaurantID, id, userID int, notes string) (*domain.Leave, error) {
+	leave, emp, err := uc.pendingLeaveFor(tenantID, restaurantID, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	balanceID := 0
+	if leave.IsFinalApproval() {
+		policy, err := uc.policy(tenantID, restaurantID, leave.LeaveType)
+		if err != nil {
+			return nil, err
+		}
+		if policy.TrackBalance {
+			balance, err := uc.ensureBalance(policy, emp, leave.StartDate.Year())
+			if err != nil {
+				return nil, err
+			}
+			balanceID = balance.ID
+		}
+	}
+
+	if err := uc.leaveRepo.ApproveLeave(leave, userID, notes, balanceID); err != nil {
+		return nil, err
+	}
+
+	updated, err := uc.GetLeave(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditLeave, id, employeeName(emp), domain.AuditApprove, leave, updated, userID)
+	if updated.Status == "approved" {
+		uc.notifyEmployee(updated, "Leave approved",
+			fmt.Sprintf("Your %s leave from %s to %s has been approved", updated.LeaveType,
+				updated.StartDate.Format(domain.DateLayout), updated.EndDate.Format(domain.DateLayout)))
+	} else {
+		uc.notifyApprover(updated, emp)
+	}
+	return updated, nil
+}
+
+// RejectLeave rejects the leave at its current level and tells the employee
+func (uc *LeaveUseCase) RejectLeave(tenantID, restaurantID, id, userID int, reason string) (*domain.Leave, error) {
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to reject a leave request")
+	}
+	leave, emp, err := uc.pendingLeaveFor(tenantID, restaurantID, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.leaveRepo.RejectLeave(leave, userID, reason); err != nil {
+		return nil, err
+	}
+
+	updated, err := uc.GetLeave(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditLeave, id, employeeName(emp), domain.AuditReject, leave, updated, userID)
+	uc.notifyEmployee(updated, "Leave rejected",
+		fmt.Sprintf("Your %s leave from %s to %s was rejected: %s", updated.LeaveType,
+			updated.StartDate.Format(domain.DateLayout), updated.EndDate.Format(domain.DateLayout), reason))
+	return updated, nil
+}
+
+// CancelLeave cancels a pending or approved leave, giving approved days back to the balance
+func (uc *LeaveUseCase) CancelLeave(tenantID, restaurantID, id, userID int, reason string) (*domain.Leave, error) {
+	leave, err := uc.GetLeave(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if leave.Status != "pending" && leave.Status != "approved" {
+		return nil, domain.ErrLeaveNotCancellable
+	}
+
+	balanceID := 0
+	if leave.Status == "approved" && leave.DeductedFromBalance {
+		balance, err := uc.leavePolicyRepo.GetLeaveBalance(tenantID, restaurantID, leave.EmployeeID, leave.LeaveType, leave.StartDate.Year())
+		if err != nil {
+			return nil, err
+		}
+		if balance != nil {
+			balanceID = balance.ID
+		}
+	}
+
+	if err := uc.leaveRepo.CancelLeave(leave, userID, reason, balanceID); err != nil {
+		return nil, err
+	}
+	cancelled, err := uc.GetLeave(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditLeave, id, "", domain.AuditCancel, leave, cancelled, userID)
+	return cancelled, nil
+}
+
+// PendingApprovals returns the leave requests waiting on the user: levels assigned to the user's
+// employee record, followed by levels any HR user can approve
+func (uc *LeaveUseCase) PendingApprovals(tenantID, restaurantID, userID int) ([]domain.Leave, error) {
+	employeeID, err := uc.employeeRepo.GetEmployeeIDByUser(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	leaves := []domain.Leave{}
+	if employeeID != 0 {
+		if leaves, err = uc.leaveRepo.ListAwaitingApproval(tenantID, restaurantID, employeeID); err != nil {
+			return nil, err
+		}
+	}
+	hr, err := uc.leaveRepo.ListAwaitingApproval(tenantID, restaurantID, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, leave := range hr {
+		if leave.EmployeeID != employeeID {
+			leaves = append(leaves, leave)
+		}
+	}
+	return leaves, nil
+}
+
+// EmployeeBalances returns an employee's leave balances for a year, bringing accruals up to date
+func (uc *LeaveUseCase) EmployeeBalances(tenantID, restaurantID, employeeID, year int) ([]domain.LeaveBalance, error) {
+	emp, err := uc.employee(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if year <= time.Now().Year() {
+		policies, err := uc.leavePolicyRepo.ListLeavePolicies(tenantID, restaurantID)
+		if err != nil {
+			return nil, err
+		}
+		for i := range policies {
+			if !policies[i].IsActive || !policies[i].TrackBalance {
+				continue
+			}
+			if _, err := uc.ensureBalance(&policies[i], emp, year); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return uc.leavePolicyRepo.ListEmployeeBalances(tenantID, restaurantID, employeeID, year)
+}
+
+// BalanceHistory returns the changes to an employee's balances in a year
+func (uc *LeaveUseCase) BalanceHistory(tenantID, restaurantID, employeeID, year int) ([]domain.LeaveBalanceTransaction, error) {
+	if _, err := uc.employee(tenantID, restaurantID, employeeID); err != nil {
+		return nil, err
+	}
+	return uc.leavePolicyRepo.ListBalanceTransactions(tenantID, restaurantID, employeeID, year)
+}
+
+// AdjustBalance adds days to or removes days from an employee's balance by hand
+func (uc *LeaveUseCase) AdjustBalance(tenantID, restaurantID, employeeID int, req *domain.LeaveBalanceAdjustmentRequest, userID int) (*domain.LeaveBalance, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if req.Year == 0 {
+		req.Year = time.Now().Year()
+	}
+	emp, err := uc.employee(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := uc.leavePolicyRepo.GetLeavePolicy(tenantID, restaurantID, req.LeaveType)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil || !policy.TrackBalance {
+		return nil, fmt.Errorf("%s leave has no policy that keeps a balance", req.LeaveType)
+	}
+
+	balance, err := uc.ensureBalance(policy, emp, req.Year)
+	if err != nil {
+		return nil, err
+	}
+	if balance.Available()+req.Days < 0 {
+		return nil, fmt.Errorf("%w: cannot remove %.1f day(s), %.1f available", domain.ErrInsufficientLeaveBalance, -req.Days, balance.Available())
+	}
+	if err := uc.leavePolicyRepo.AdjustLeaveBalance(balance.ID, req.Days, req.Notes, userID); err != nil {
+		return nil, err
+	}
+	adjusted, err := uc.leavePolicyRepo.GetLeaveBalance(tenantID, restaurantID, employeeID, req.LeaveType, req.Year)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditLeaveBalance, balance.ID, employeeName(emp), domain.AuditUpdate, balance, adjusted, userID)
+	return adjusted, nil
+}
+
+// ensureBalance opens the employee's balance for the year, carrying over from the previous year,
+// and credits any accrual due since it was last brought up to date. Balances for future years
+// open on January 1 so that the carry-over is final.
+func (uc *LeaveUseCase) ensureBalance(policy *domain.LeavePolicy, emp *domain.Employee, year int) (*domain.LeaveBalance, error) {
+	now := time.Now()
+	if year > now.Year() {
+		return nil, fmt.Errorf("leave balances for %d open on January 1, %d", year, year)
+	}
+	accrued := policy.AccruedEntitlement(emp.HireDate, year, now)
+
+	balance, err := uc.leavePolicyRepo.GetLeaveBalance(emp.TenantID, emp.RestaurantID, emp.ID, policy.LeaveType, year)
+	if err != nil {
+		return nil, err
+	}
+	if balance != nil {
+		if err := uc.leavePolicyRepo.RecordAccrual(balance, accrued); err != nil {
+			return nil, err
+		}
+		return balance, nil
+	}
+
+	previous, err := uc.leavePolicyRepo.GetLeaveBalance(emp.TenantID, emp.RestaurantID, emp.ID, policy.LeaveType, year-1)
+	if err != nil {
+		return nil, err
+	}
+	return uc.leavePolicyRepo.CreateLeaveBalance(&domain.LeaveBalance{
+		TenantID:     emp.TenantID,
+		RestaurantID: emp.RestaurantID,
+		EmployeeID:   emp.ID,
+		LeaveType:    policy.LeaveType,
+		Year:         year,
+		CarriedOver:  policy.CarryOver(previous),
+		Accrued:      accrued,
+	})
+}
+
+// pendingLeaveFor loads a pending leave and checks that the user may decide its current level:
+// the assigned manager, or for HR levels anyone but the employee on leave
+func (uc *LeaveUseCase) pendingLeaveFor(tenantID, restaurantID, id, userID int) (*domain.Leave, *domain.Employee, error) {
+	leave, err := uc.GetLeave(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if leave.Status != "pending" {
+		return nil, nil, domain.ErrLeaveNotPending
+	}
+	emp, err := uc.employee(tenantID, restaurantID, leave.EmployeeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	actorID, err := uc.employeeRepo.GetEmployeeIDByUser(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if actorID != 0 && actorID == leave.EmployeeID {
+		return nil, nil, domain.ErrNotLeaveApprover
+	}
+	if approval := leave.CurrentApproval(); approval != nil && approval.ApproverEmployeeID != nil {
+		if actorID != *approval.ApproverEmployeeID {
+			return nil, nil, domain.ErrNotLeaveApprover
+		}
+	}
+	return leave, emp, nil
+}
+
+// policy returns the active policy for a leave type, or the default when the restaurant has none
+func (uc *LeaveUseCase) policy(tenantID, restaurantID int, leaveType string) (*domain.LeavePolicy, error) {
+	policy, err := uc.leavePolicyRepo.GetLeavePolicy(tenantID, restaurantID, leaveType)
+	if err != nil {
+		return nil, err
+	}
+	if policy == nil {
+		return domain.DefaultLeavePolicy(leaveType), nil
+	}
+	return policy, nil
+}
+
+// policyCalendar returns the policy's holiday calendar, or the restaurant's default one
+func (uc *LeaveUseCase) policyCalendar(tenantID, restaurantID int, policy *domain.LeavePolicy) (*domain.HolidayCalendar, error) {
+	if policy.HolidayCalendarID != nil {
+		calendar, err := uc.leavePolicyRepo.GetHolidayCalendar(tenantID, restaurantID, *policy.HolidayCalendarID)
+		if err != nil || calendar != nil {
+			return calendar, err
+		}
+	}
+	return uc.leavePolicyRepo.GetDefaultHolidayCalendar(tenantID, restaurantID)
+}
+
+// calendar loads a holiday calendar of the restaurant
+func (uc *LeaveUseCase) calendar(tenantID, restaurantID, id int) (*domain.HolidayCalendar, error) {
+	calendar, err := uc.leavePolicyRepo.GetHolidayCalendar(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if calendar == nil {
+		return nil, domain.ErrHolidayCalendarNotFound
+	}
+	return calendar, nil
+}
+
+// employee loads an employee of the restaurant
+func (uc *LeaveUseCase) employee(tenantID, restaurantID, id int) (*domain.Employee, error) {
+	emp, err := uc.employeeRepo.GetEmployeeByID(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if emp == nil {
+		return nil, fmt.Errorf("employee %d not found", id)
+	}
+	return emp, nil
+}
+
+// notifyApprover tells the manager at the leave's current level that a request is waiting.
+// HR levels have no single approver and are found through the pending approvals list.
+func (uc *LeaveUseCase) notifyApprover(leave *domain.Leave, emp *domain.Employee) {
+	approval := leave.CurrentApproval()
+	if approval == nil || approval.ApproverEmployeeID == nil {
+		return
+	}
+	uc.notify(leave, *approval.ApproverEmployeeID, "Leave request awaiting your approval",
+		fmt.Sprintf("%s %s requested %.1f day(s) of %s leave from %s to %s", emp.FirstName, emp.LastName,
+			leave.TotalDays, leave.LeaveType, leave.StartDate.Format(domain.DateLayout), leave.EndDate.Format(domain.DateLayout)))
+}
+
+// notifyEmployee tells the employee on leave about a decision
+func (uc *LeaveUseCase) notifyEmployee(leave *domain.Leave, title, message string) {
+	uc.notify(leave, leave.EmployeeID, title, message)
+}
+
+// notify sends a leave notification to the user account of an employee. Failures are logged.
+func (uc *LeaveUseCase) notify(leave *domain.Leave, employeeID int, title, message string) {
+	users, err := uc.employeeRepo.GetEmployeeUserIDs(leave.TenantID, []int{employeeID})
+	if err != nil {
+		log.Printf("leave %d: failed to resolve account of employee %d: %v", leave.ID, employeeID, err)
+		return
+	}
+	userID, ok := users[employeeID]
+	if !ok {
+		return
+	}
+
+	entityType := "leave"
+	_, err = uc.notificationRepo.CreateNotification(&domain.Notification{
+		TenantID:          leave.TenantID,
+		RestaurantID:      leave.RestaurantID,
+		UserID:            userID,
+		Type:              domain.NotificationTypeLeave,
+		Module:            domain.ModuleHR,
+		Title:             title,
+		Message:           message,
+		RelatedEntityType: &entityType,
+		RelatedEntityID:   &leave.ID,
+		Priority:          domain.PriorityNormal,
+	})
+	if err != nil {
+		log.Printf("leave %d: failed to notify employee %d: %v", leave.ID, employeeID, err)
+	}
+}
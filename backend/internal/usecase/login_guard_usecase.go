@@ -0,0 +1,264 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/pkg/mailer"
+	"pos-saas/internal/repository"
+	"pos-saas/internal/security"
+)
+
+// LoginGuardUseCase protects login against brute force: it throttles failed attempts per
+// account and per IP with exponential backoff, locks accounts after repeated failures and
+// records every attempt in the security audit log
+type LoginGuardUseCase struct {
+	repo     *repository.LoginThrottleRepository
+	userRepo *repository.UserRepository
+	audit    *security.AuditLogManager
+	mailer   mailer.Mailer
+	appURL   string
+}
+
+// NewLoginGuardUseCase creates new login guard use case. audit may be nil, in which case
+// attempts are not audited.
+func NewLoginGuardUseCase(
+	repo *repository.LoginThrottleRepository,
+	userRepo *repository.UserRepository,
+	audit *security.AuditLogManager,
+	mail mailer.Mailer,
+	appURL string,
+) *LoginGuardUseCase {
+	return &LoginGuardUseCase{
+		repo:     repo,
+		userRepo: userRepo,
+		audit:    audit,
+		mailer:   mail,
+		appURL:   strings.TrimRight(appURL, "/"),
+	}
+}
+
+// Check refuses an attempt while the account is locked or the account or IP is backing off
+func (uc *LoginGuardUseCase) Check(ctx context.Context, email string, client domain.SessionClient) error {
+	now := time.Now()
+
+	account, err := uc.repo.Get(ctx, domain.ThrottleScopeAccount, domain.LoginThrottleKey(email))
+	if err != nil {
+		return err
+	}
+	ip, err := uc.ipThrottle(ctx, client.IPAddress)
+	if err != nil {
+		return err
+	}
+	captcha := captchaRequired(account, ip)
+
+	if account.IsLocked(now) {
+		return &domain.LoginError{Err: domain.ErrAccountLocked, RetryAfter: account.RetryAfter(now), CaptchaRequired: captcha}
+	}
+	wait := account.RetryAfter(now)
+	if ipWait := ip.RetryAfter(now); ipWait > wait {
+		wait = ipWait
+	}
+	if wait > 0 {
+		return &domain.LoginError{Err: domain.ErrTooManyLoginAttempts, RetryAfter: wait, CaptchaRequired: captcha}
+	}
+	return nil
+}
+
+// RecordFailure counts a rejected password for the email and the IP, starts the backoff and
+// locks the account once it reaches the threshold. user is the account the email belongs
+// to, nil when there is none. The returned error is what login reports to the client.
+func (uc *LoginGuardUseCase) RecordFailure(ctx context.Context, email string, user *domain.User, client domain.SessionClient) error {
+	uc.auditAttempt(ctx, user, email, client, "failure", domain.ErrInvalidCredentials.Error())
+
+	loginErr, err := uc.countFailure(ctx, email, user, client)
+	if err != nil {
+		log.Printf("login guard: failed to count failed sign-in: %v", err)
+		return &domain.LoginError{Err: domain.ErrInvalidCredentials}
+	}
+	return loginErr
+}
+
+// countFailure updates the account and IP throttles after a rejected password
+func (uc *LoginGuardUseCase) countFailure(ctx context.Context, email string, user *domain.User, client domain.SessionClient) (*domain.LoginError, error) {
+	now := time.Now()
+	key := domain.LoginThrottleKey(email)
+
+	account, err := uc.repo.RecordFailure(ctx, domain.ThrottleScopeAccount, key)
+	if err != nil {
+		return nil, err
+	}
+	var lockedUntil *time.Time
+	if account.Failures >= domain.AccountLockThreshold && !account.IsLocked(now) {
+		until := now.Add(domain.AccountLockDuration)
+		lockedUntil = &until
+	}
+	if err := uc.repo.SetBlock(ctx, domain.ThrottleScopeAccount, key,
+		now.Add(domain.LoginBackoff(account.Failures, domain.AccountFreeAttempts)), lockedUntil); err != nil {
+		return nil, err
+	}
+
+	var ip *domain.LoginThrottle
+	if client.IPAddress != "" {
+		if ip, err = uc.repo.RecordFailure(ctx, domain.ThrottleScopeIP, client.IPAddress); err != nil {
+			return nil, err
+		}
+		if err := uc.repo.SetBlock(ctx, domain.ThrottleScopeIP, client.IPAddress,
+			now.Add(domain.LoginBackoff(ip.Failures, domain.IPFreeAttempts)), nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if lockedUntil != nil {
+		uc.onLocked(ctx, user, email, client, *lockedUntil)
+		return &domain.LoginError{Err: domain.ErrAccountLocked, RetryAfter: domain.AccountLockDuration, CaptchaRequired: true}, nil
+	}
+
+	wait := domain.LoginBackoff(account.Failures, domain.AccountFreeAttempts)
+	if ip != nil {
+		if ipWait := domain.LoginBackoff(ip.Failures, domain.IPFreeAttempts); ipWait > wait {
+			wait = ipWait
+		}
+	}
+	return &domain.LoginError{Err: domain.ErrInvalidCredentials, RetryAfter: wait, CaptchaRequired: captchaRequired(account, ip)}, nil
+}
+
+// RecordSuccess audits a verified password and forgets the account's failures. The IP
+// counter is kept, so a valid account cannot be used to reset it.
+func (uc *LoginGuardUseCase) RecordSuccess(ctx context.Context, user *domain.User, client domain.SessionClient) {
+	uc.auditAttempt(ctx, user, user.Email, client, "success", "")
+	if err := uc.repo.Clear(ctx, domain.ThrottleScopeAccount, domain.LoginThrottleKey(user.Email)); err != nil {
+		log.Printf("login guard: failed to clear failures of user %d: %v", user.ID, err)
+	}
+}
+
+// Status returns the lockout state of a user of the tenant
+func (uc *LoginGuardUseCase) Status(ctx context.Context, tenantID, userID int) (*domain.AccountLockStatus, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return nil, domain.ErrRBACUserNotFound
+	}
+
+	account, err := uc.repo.Get(ctx, domain.ThrottleScopeAccount, domain.LoginThrottleKey(user.Email))
+	if err != nil {
+		return nil, err
+	}
+	status := &domain.AccountLockStatus{UserID: userID}
+	if account != nil {
+		status.Locked = account.IsLocked(time.Now())
+		if status.Locked {
+			status.LockedUntil = account.LockedUntil
+		}
+		status.FailedAttempts = account.Failures
+		status.LastFailureAt = account.LastFailureAt
+	}
+
+	// Recent failed sign-ins of the user, from the security audit log
+	if uc.audit != nil {
+		entries, err := uc.audit.GetFailedAttempts(ctx, int64(tenantID), int(domain.LoginFailureWindow.Minutes()))
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if entry.Action == domain.AuditActionLogin && entry.UserID == int64(userID) {
+				status.RecentFailures = append(status.RecentFailures, domain.LoginAttempt{
+					IPAddress: entry.IPAddress,
+					UserAgent: entry.UserAgent,
+					At:        entry.CreatedAt,
+				})
+			}
+		}
+	}
+	return status, nil
+}
+
+// Unlock lifts the lockout of a user of the tenant and forgets the failed attempts. The
+// lockout is kept per email and login is shared by every tenant the email belongs to, so
+// an admin can only unlock an email that no other tenant uses.
+func (uc *LoginGuardUseCase) Unlock(ctx context.Context, tenantID, userID, adminID int, client domain.SessionClient) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return domain.ErrRBACUserNotFound
+	}
+
+	accounts, err := uc.userRepo.FindByEmailAllTenants(ctx, user.Email)
+	if err != nil {
+		return err
+	}
+	for _, account := range accounts {
+		if account.TenantID != tenantID {
+			return domain.ErrAccountLockShared
+		}
+	}
+
+	if err := uc.repo.Clear(ctx, domain.ThrottleScopeAccount, domain.LoginThrottleKey(user.Email)); err != nil {
+		return err
+	}
+	uc.log(ctx, int64(tenantID), int64(adminID), domain.AuditActionAccountUnlock, strconv.Itoa(userID),
+		fmt.Sprintf("unlocked by user %d", adminID), client, "success", "")
+	return nil
+}
+
+// ipThrottle returns the throttle of the client IP, nil when the IP is unknown
+func (uc *LoginGuardUseCase) ipThrottle(ctx context.Context, ip string) (*domain.LoginThrottle, error) {
+	if ip == "" {
+		return nil, nil
+	}
+	return uc.repo.Get(ctx, domain.ThrottleScopeIP, ip)
+}
+
+// onLocked audits a lockout and tells the user by email, so they know someone is trying
+// their password and how to regain access
+func (uc *LoginGuardUseCase) onLocked(ctx context.Context, user *domain.User, email string, client domain.SessionClient, until time.Time) {
+	var tenantID, userID int64
+	if user != nil {
+		tenantID, userID = int64(user.TenantID), int64(user.ID)
+	}
+	uc.log(ctx, tenantID, userID, domain.AuditActionAccountLocked, domain.LoginThrottleKey(email),
+		fmt.Sprintf("locked until %s", until.UTC().Format(time.RFC3339)), client, "failure", domain.ErrAccountLocked.Error())
+
+	if user == nil {
+		return
+	}
+	msg := mailer.Message{
+		To:      user.Email,
+		Subject: "Your account has been locked",
+		Text: fmt.Sprintf(
+			"Hi %s,\n\nYour account was locked after %d failed sign-in attempts, the last one from %s. You can try again after %s (UTC), or ask an administrator of your organization to unlock it.\n\nIf this was not you, use \"Forgot password\" on the sign-in page at %s once the lock has expired.\n",
+			user.Name, domain.AccountLockThreshold, client.IPAddress, until.UTC().Format("2006-01-02 15:04"), uc.appURL),
+	}
+	if err := uc.mailer.Send(ctx, msg); err != nil {
+		log.Printf("login guard: failed to send lockout email to user %d: %v", user.ID, err)
+	}
+}
+
+// auditAttempt records a password check in the audit log
+func (uc *LoginGuardUseCase) auditAttempt(ctx context.Context, user *domain.User, email string, client domain.SessionClient, status, errorMessage string) {
+	var tenantID, userID int64
+	if user != nil {
+		tenantID, userID = int64(user.TenantID), int64(user.ID)
+	}
+	uc.log(ctx, tenantID, userID, domain.AuditActionLogin, domain.LoginThrottleKey(email), "", client, status, errorMessage)
+}
+
+func (uc *LoginGuardUseCase) log(ctx context.Context, tenantID, userID int64, action, resourceID, details string, client domain.SessionClient, status, errorMessage string) {
+	if uc.audit == nil {
+		return
+	}
+	if err := uc.audit.LogAction(ctx, tenantID, userID, action, "user", resourceID, details,
+		client.IPAddress, client.UserAgent, status, errorMessage, 0); err != nil {
+		log.Printf("login guard: failed to write audit log: %v", err)
+	}
+}
+
+// captchaRequired reports whether recent failures of the account or the IP are suspicious
+// enough that the client should show a CAPTCHA
+func captchaRequired(account, ip *domain.LoginThrottle) bool {
+	return (account != nil && account.Failures >= domain.AccountCaptchaThreshold) ||
+		(ip != nil && ip.Failures >= domain.IPCaptchaThreshold)
+}
@@ -0,0 +1,160 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/pkg/tracking"
+	"pos-saas/internal/repository"
+	"time"
+)
+
+// ErrTrackingNotFound is returned both for unknown orders and failed verification,
+// so tracking cannot be used to discover which order numbers exist
+var ErrTrackingNotFound = errors.New("order not found")
+
+// maxOrderNumberLength bounds the order numbers looked up and used as throttle keys
+const maxOrderNumberLength = 64
+
+// OrderTrackingUseCase handles anonymous customer order tracking
+type OrderTrackingUseCase struct {
+	orderRepo    *repository.OrderRepository
+	feedbackRepo *repository.FeedbackRepository
+	throttles    *repository.LoginThrottleRepository
+	signer       *tracking.Signer
+}
+
+// NewOrderTrackingUseCase creates new order tracking use case
+func NewOrderTrackingUseCase(
+	orderRepo *repository.OrderRepository,
+	feedbackRepo *repository.FeedbackRepository,
+	throttles *repository.LoginThrottleRepository,
+	signer *tracking.Signer,
+) *OrderTrackingUseCase {
+	return &OrderTrackingUseCase{
+		orderRepo:    orderRepo,
+		feedbackRepo: feedbackRepo,
+		throttles:    throttles,
+		signer:       signer,
+	}
+}
+
+// TrackingToken returns the signed tracking token handed to the customer at order creation
+func (uc *OrderTrackingUseCase) TrackingToken(order *domain.Order) string {
+	return uc.signer.Sign(order.RestaurantID, order.OrderNumber)
+}
+
+// VerifyOrder returns an order once the caller has proven ownership with either the
+// customer phone or the tracking token. byToken reports that the token matched: a phone
+// number is easier to guess, so the delivery PIN and cancellation need the token. Failed
+// attempts back off per order number and per client IP.
+func (uc *OrderTrackingUseCase) VerifyOrder(ctx context.Context, tenantID, restaurantID int64, orderNumber, phone, token, ip string) (order *domain.Order, byToken bool, err error) {
+	if orderNumber == "" || len(orderNumber) > maxOrderNumberLength || (phone == "" && token == "") {
+		return nil, false, ErrTrackingNotFound
+	}
+	key := domain.TrackingThrottleKey(restaurantID, orderNumber)
+	if err := uc.checkThrottle(ctx, key, ip); err != nil {
+		return nil, false, err
+	}
+
+	order, err = uc.orderRepo.GetOrderByNumber(tenantID, restaurantID, orderNumber)
+	if err == nil {
+		byToken = uc.signer.Verify(restaurantID, order.OrderNumber, token)
+		if byToken || (phone != "" && domain.PhoneMatches(order.CustomerPhone, phone)) {
+			return order, byToken, nil
+		}
+	}
+
+	uc.recordFailure(ctx, domain.ThrottleScopeTrackingOrder, key, domain.TrackingOrderFreeAttempts)
+	if ip != "" {
+		uc.recordFailure(ctx, domain.ThrottleScopeTrackingIP, ip, domain.TrackingIPFreeAttempts)
+	}
+	return nil, false, ErrTrackingNotFound
+}
+
+// checkThrottle refuses a verification while the order number or the IP is backing off
+func (uc *OrderTrackingUseCase) checkThrottle(ctx context.Context, key, ip string) error {
+	now := time.Now()
+	var wait time.Duration
+	for scope, k := range map[string]string{domain.ThrottleScopeTrackingOrder: key, domain.ThrottleScopeTrackingIP: ip} {
+		if k == "" {
+			continue
+		}
+		throttle, err := uc.throttles.Get(ctx, scope, k)
+		if err != nil {
+			return err
+		}
+		if retry := throttle.RetryAfter(now); retry > wait {
+			wait = retry
+		}
+	}
+	if wait > 0 {
+		return &domain.LoginError{Err: domain.ErrTrackingThrottled, RetryAfter: wait}
+	}
+	return nil
+}
+
+// recordFailure counts a failed verification and starts the backoff of the key
+func (uc *OrderTrackingUseCase) recordFailure(ctx context.Context, scope, key string, freeAttempts int) {
+	throttle, err := uc.throttles.RecordFailure(ctx, scope, key)
+	if err == nil {
+		err = uc.throttles.SetBlock(ctx, scope, key, time.Now().Add(domain.LoginBackoff(throttle.Failures, freeAttempts)), nil)
+	}
+	if err != nil {
+		log.Printf("tracking: failed to count failed verification: %v", err)
+	}
+}
+
+// TrackOrder returns the public tracking view of an order.
+// The caller must prove ownership with either the customer phone or the tracking token.
+func (uc *OrderTrackingUseCase) TrackOrder(ctx context.Context, tenantID, restaurantID int64, orderNumber, phone, token, ip string) (*domain.OrderTracking, error) {
+	order, byToken, err := uc.VerifyOrder(ctx, tenantID, restaurantID, orderNumber, phone, token, ip)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := uc.orderRepo.GetOrderStatusHistory(tenantID, order.ID)
+	if err != nil {
+		// History is optional, don't fail tracking if it's not available
+		history = []domain.OrderStatusHistory{}
+	}
+
+	result := &domain.OrderTracking{
+		OrderNumber:           order.OrderNumber,
+		Status:                order.Status,
+		PaymentStatus:         order.PaymentStatus,
+		OrderSource:           order.OrderSource,
+		CustomerName:          order.CustomerName,
+		Items:                 order.Items,
+		TotalAmount:           order.TotalAmount,
+		CreatedAt:             order.CreatedAt,
+		EstimatedDeliveryTime: order.EstimatedDeliveryTime,
+		StatusHistory:         history,
+	}
+
+	// Driver position and the delivery PIN are only shared while the order is on its way,
+	// and the PIN only with the tracking token
+	if order.Status == "out_for_delivery" {
+		driver, err := uc.orderRepo.GetDeliveryDriver(order.ID)
+		if err == nil {
+			result.Driver = driver
+		}
+		if byToken {
+			if pin, err := uc.orderRepo.GetDeliveryPIN(order.ID); err == nil {
+				result.DeliveryPIN = pin
+			}
+		}
+	}
+	result.ETAMinutes = domain.EstimateETAMinutes(order, result.Driver, time.Now())
+
+	// The review link is handed to the customer here once the order has been delivered
+	if order.Status == "delivered" && uc.feedbackRepo != nil {
+		request, err := uc.feedbackRepo.GetReviewRequestByOrder(order.ID)
+		if err == nil && request.Status == domain.ReviewRequestPending && time.Now().Before(request.ExpiresAt) {
+			result.ReviewToken = request.Token
+		}
+	}
+
+	return result, nil
+}
@@ -1,8 +1,10 @@
 package usecase
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"log"
 	"pos-saas/internal/domain"
 	"pos-saas/internal/repository"
 	"time"
@@ -10,25 +12,60 @@ import (
 
 // OrderUseCase handles order business logic
 type OrderUseCase struct {
-	orderRepo   *repository.OrderRepository
-	productRepo *repository.ProductRepository
+	orderRepo    *repository.OrderRepository
+	productRepo  *repository.ProductRepository
+	feedbackRepo *repository.FeedbackRepository
+	dispatcher   orderDispatcher
+}
+
+// orderDispatcher offers ready delivery orders to drivers, implemented by DispatchUseCase
+type orderDispatcher interface {
+	DispatchOrder(ctx context.Context, tenantID, restaurantID, orderID int64) (*domain.DispatchOffer, error)
+	CancelDispatch(ctx context.Context, orderID int64) error
 }
 
 // NewOrderUseCase creates new order use case
-func NewOrderUseCase(orderRepo *repository.OrderRepository, productRepo *repository.ProductRepository) *OrderUseCase {
-	return &OrderUseCase{
-		orderRepo:   orderRepo,
-		productRepo: productRepo,
-	}
+func NewOrderUseCase(
+	orderRepo *repository.OrderRepository,
+	productRepo *repository.ProductRepository,
+	feedbackRepo *repository.FeedbackRepository,
+	dispatchUC *DispatchUseCase,
+) *OrderUseCase {
+	uc := &OrderUseCase{
+		orderRepo:    orderRepo,
+		productRepo:  productRepo,
+		feedbackRepo: feedbackRepo,
+	}
+	if dispatchUC != nil {
+		uc.dispatcher = dispatchUC
+	}
+	return uc
 }
 
 // CreateOrder handles order creation with all business logic
 func (uc *OrderUseCase) CreateOrder(
 	tenantID, restaurantID int64,
 	req *domain.CreateOrderRequest,
+) (*domain.Order, error) {
+	return uc.createOrder(tenantID, restaurantID, req, nil)
+}
+
+// CreateTabOrder places a dine-in round on an open table tab
+func (uc *OrderUseCase) CreateTabOrder(tab *domain.TableTab, req *domain.CreateOrderRequest) (*domain.Order, error) {
+	if tab == nil || tab.Status != domain.TabStatusOpen {
+		return nil, domain.ErrTabNotOpen
+	}
+	req.OrderSource = domain.OrderSourceDineIn
+	return uc.createOrder(tab.TenantID, tab.RestaurantID, req, tab)
+}
+
+func (uc *OrderUseCase) createOrder(
+	tenantID, restaurantID int64,
+	req *domain.CreateOrderRequest,
+	tab *domain.TableTab,
 ) (*domain.Order, error) {
 	// Validate request
-	if err := uc.validateCreateOrderRequest(req); err != nil {
+	if err := uc.validateCreateOrderRequest(req, tab != nil); err != nil {
 		return nil, fmt.Errorf("order validation failed: %w", err)
 	}
 
@@ -61,6 +98,11 @@ func (uc *OrderUseCase) CreateOrder(
 		Status:               "pending",
 		OrderSource:          req.OrderSource,
 		Notes:                req.Notes,
+		TipAmount:            req.TipAmount,
+	}
+
+	if req.TipAmount < 0 {
+		return nil, errors.New("tip amount cannot be negative")
 	}
 
 	// Handle optional delivery coordinates
@@ -71,6 +113,21 @@ func (uc *OrderUseCase) CreateOrder(
 		order.DeliveryLongitude = *req.DeliveryLongitude
 	}
 
+	// Bind dine-in rounds to the table and tab
+	if tab != nil {
+		order.TableID = &tab.TableID
+		order.TabID = &tab.ID
+	}
+
+	// Allergens the customer declared they must avoid
+	avoidAllergens := make([]string, 0, len(req.AvoidAllergens))
+	for _, a := range req.AvoidAllergens {
+		if code, ok := domain.NormalizeAllergenCode(a); ok {
+			avoidAllergens = append(avoidAllergens, code)
+		}
+	}
+	var allergenWarnings []domain.OrderAllergenWarning
+
 	// Process order items and calculate pricing
 	items := make([]domain.OrderItem, 0)
 	for _, itemReq := range req.Items {
@@ -89,6 +146,15 @@ func (uc *OrderUseCase) CreateOrder(
 			return nil, fmt.Errorf("insufficient inventory for product %s", product.NameEn)
 		}
 
+		// Warn about items containing allergens the customer must avoid
+		if conflicts := domain.AllergenConflicts(product.Allergens, avoidAllergens); len(conflicts) > 0 {
+			allergenWarnings = append(allergenWarnings, domain.OrderAllergenWarning{
+				ProductID:   itemReq.ProductID,
+				ProductName: product.NameEn,
+				Allergens:   conflicts,
+			})
+		}
+
 		// Calculate item pricing
 		unitPrice := product.Price
 		if product.DiscountPrice != nil && *product.DiscountPrice > 0 {
@@ -130,7 +196,7 @@ func (uc *OrderUseCase) CreateOrder(
 	order.DeliveryFee = 0
 
 	// Calculate final total
-	order.TotalAmount = order.Subtotal + order.TaxAmount - order.DiscountAmount + order.DeliveryFee
+	order.TotalAmount = order.Subtotal + order.TaxAmount - order.DiscountAmount + order.DeliveryFee + order.TipAmount
 
 	// Validate final totals
 	if order.TotalAmount < 0 {
@@ -153,7 +219,12 @@ func (uc *OrderUseCase) CreateOrder(
 	}
 
 	// Reload order with items
-	return uc.orderRepo.GetOrderByID(tenantID, restaurantID, createdOrder.ID)
+	result, err := uc.orderRepo.GetOrderByID(tenantID, restaurantID, createdOrder.ID)
+	if err != nil {
+		return nil, err
+	}
+	result.AllergenWarnings = allergenWarnings
+	return result, nil
 }
 
 // GetOrder retrieves a single order by ID
@@ -221,6 +292,16 @@ func (uc *OrderUseCase) UpdateOrderStatus(
 		return fmt.Errorf("failed to update order status: %w", err)
 	}
 
+	uc.afterStatusChange(order, req.Status)
+
+	// Invite the customer to review the order once it has been delivered.
+	// A failure here must not fail the status change.
+	if req.Status == "delivered" && uc.feedbackRepo != nil {
+		if _, err := requestReview(uc.feedbackRepo, order); err != nil {
+			log.Printf("reviews: failed to create review request for order %d: %v", orderID, err)
+		}
+	}
+
 	return nil
 }
 
@@ -246,6 +327,8 @@ func (uc *OrderUseCase) CancelOrder(tenantID, restaurantID, orderID int64, reaso
 		return fmt.Errorf("failed to cancel order: %w", err)
 	}
 
+	uc.afterStatusChange(order, "cancelled")
+
 	return nil
 }
 
@@ -392,8 +475,9 @@ func (uc *OrderUseCase) GetOrderStatusHistory(tenantID, orderID int64) ([]domain
 	return history, nil
 }
 
-// validateCreateOrderRequest validates order creation request
-func (uc *OrderUseCase) validateCreateOrderRequest(req *domain.CreateOrderRequest) error {
+// validateCreateOrderRequest validates order creation request. atTable is set when the
+// order was placed from a table's QR code.
+func (uc *OrderUseCase) validateCreateOrderRequest(req *domain.CreateOrderRequest, atTable bool) error {
 	if req == nil {
 		return errors.New("order request is required")
 	}
@@ -402,7 +486,9 @@ func (uc *OrderUseCase) validateCreateOrderRequest(req *domain.CreateOrderReques
 		return errors.New("customer name is required")
 	}
 
-	if req.CustomerPhone == "" {
+	// Dine-in guests are identified by their table, not a phone number. The order
+	// source is client-supplied, so only a resolved table token waives the phone.
+	if req.CustomerPhone == "" && !atTable {
 		return errors.New("customer phone is required")
 	}
 
@@ -439,3 +525,24 @@ func (uc *OrderUseCase) validateCreateOrderRequest(req *domain.CreateOrderReques
 
 	return nil
 }
+
+// afterStatusChange drives driver dispatch from order status changes.
+// Ready delivery orders are offered to drivers; cancelled orders release them.
+// Dispatch failures are logged, the order can still be assigned manually.
+func (uc *OrderUseCase) afterStatusChange(order *domain.Order, newStatus string) {
+	if uc.dispatcher == nil || !order.NeedsDelivery() {
+		return
+	}
+
+	ctx := context.Background()
+	switch newStatus {
+	case "ready":
+		if _, err := uc.dispatcher.DispatchOrder(ctx, order.TenantID, order.RestaurantID, order.ID); err != nil {
+			log.Printf("dispatch: order %d needs manual dispatch: %v", order.ID, err)
+		}
+	case "cancelled":
+		if err := uc.dispatcher.CancelDispatch(ctx, order.ID); err != nil {
+			log.Printf("dispatch: failed to cancel dispatch for order %d: %v", order.ID, err)
+		}
+	}
+}
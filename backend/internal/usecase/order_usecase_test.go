@@ -0,0 +1,76 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+
+	"pos-saas/internal/domain"
+)
+
+// fakeDispatcher records the dispatch calls made by the order use case
+type fakeDispatcher struct {
+	offers    []*domain.DispatchOffer
+	cancelled []int64
+}
+
+func (d *fakeDispatcher) DispatchOrder(ctx context.Context, tenantID, restaurantID, orderID int64) (*domain.DispatchOffer, error) {
+	offer := &domain.DispatchOffer{TenantID: tenantID, RestaurantID: restaurantID, OrderID: orderID, Status: "pending"}
+	d.offers = append(d.offers, offer)
+	return offer, nil
+}
+
+func (d *fakeDispatcher) CancelDispatch(ctx context.Context, orderID int64) error {
+	d.cancelled = append(d.cancelled, orderID)
+	return nil
+}
+
+// TestAfterStatusChangeDispatch tests that ready delivery orders are offered to drivers
+func TestAfterStatusChangeDispatch(t *testing.T) {
+	delivery := &domain.Order{ID: 7, TenantID: 1, RestaurantID: 2, OrderSource: domain.OrderSourceWebsite, DeliveryAddress: "1 High Street"}
+	pickup := &domain.Order{ID: 8, TenantID: 1, RestaurantID: 2, OrderSource: domain.OrderSourceWebsite}
+	dineIn := &domain.Order{ID: 9, TenantID: 1, RestaurantID: 2, OrderSource: domain.OrderSourceDineIn, DeliveryAddress: "Table 4"}
+
+	tests := []struct {
+		name          string
+		order         *domain.Order
+		status        string
+		wantOffers    int
+		wantCancelled int
+	}{
+		{name: "Ready delivery order is dispatched", order: delivery, status: "ready", wantOffers: 1},
+		{name: "Preparing delivery order is not dispatched", order: delivery, status: "preparing"},
+		{name: "Cancelled delivery order releases the driver", order: delivery, status: "cancelled", wantCancelled: 1},
+		{name: "Ready pickup order is not dispatched", order: pickup, status: "ready"},
+		{name: "Ready dine-in order is not dispatched", order: dineIn, status: "ready"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dispatcher := &fakeDispatcher{}
+			uc := &OrderUseCase{dispatcher: dispatcher}
+
+			uc.afterStatusChange(tt.order, tt.status)
+
+			if len(dispatcher.offers) != tt.wantOffers {
+				t.Fatalf("dispatch offers = %d, want %d", len(dispatcher.offers), tt.wantOffers)
+			}
+			if len(dispatcher.cancelled) != tt.wantCancelled {
+				t.Errorf("cancelled dispatches = %d, want %d", len(dispatcher.cancelled), tt.wantCancelled)
+			}
+			for _, offer := range dispatcher.offers {
+				if offer.OrderID != tt.order.ID || offer.TenantID != tt.order.TenantID || offer.RestaurantID != tt.order.RestaurantID {
+					t.Errorf("offer = %+v, want one for order %d", offer, tt.order.ID)
+				}
+			}
+		})
+	}
+}
+
+// TestNewOrderUseCaseWithoutDispatch tests that a missing dispatch use case disables dispatch
+func TestNewOrderUseCaseWithoutDispatch(t *testing.T) {
+	uc := NewOrderUseCase(nil, nil, nil, nil)
+	if uc.dispatcher != nil {
+		t.Fatal("dispatcher is set without a dispatch use case")
+	}
+	uc.afterStatusChange(&domain.Order{ID: 7, DeliveryAddress: "1 High Street"}, "ready")
+}
@@ -0,0 +1,418 @@
+package usecase
+
+import (
+	"bytes"
+	"fmt"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+)
+
+// PayrollUseCase generates payroll runs from attendance, leave and tips, and takes them through approval and payment
+type PayrollUseCase struct {
+	payrollRepo    *repository.PayrollRepository
+	salaryRepo     *repository.SalaryRepository
+	employeeRepo   *repository.EmployeeRepository
+	attendanceRepo *repository.AttendanceRepository
+	leaveRepo      *repository.LeaveRepository
+	auditRepo      *repository.HRAuditRepository
+}
+
+// NewPayrollUseCase creates new payroll use case
+func NewPayrollUseCase(
+	payrollRepo *repository.PayrollRepository,
+	salaryRepo *repository.SalaryRepository,
+	employeeRepo *repository.EmployeeRepository,
+	attendanceRepo *repository.AttendanceRepository,
+	leaveRepo *repository.LeaveRepository,
+	auditRepo *repository.HRAuditRepository,
+) *PayrollUseCase {
+	return &PayrollUseCase{
+		payrollRepo:    payrollRepo,
+		salaryRepo:     salaryRepo,
+		employeeRepo:   employeeRepo,
+		attendanceRepo: attendanceRepo,
+		leaveRepo:      leaveRepo,
+		auditRepo:      auditRepo,
+	}
+}
+
+// GetSettings returns the restaurant's payroll settings
+func (uc *PayrollUseCase) GetSettings(tenantID, restaurantID int) (*domain.PayrollSettings, error) {
+	return uc.payrollRepo.GetPayrollSettings(tenantID, restaurantID)
+}
+
+// SaveSettings validates and stores the restaurant's payroll settings
+func (uc *PayrollUseCase) SaveSettings(tenantID, restaurantID int, settings *domain.PayrollSettings, userID int) (*domain.PayrollSettings, error) {
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	before, err := uc.payrollRepo.GetPayrollSettings(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	settings.TenantID = tenantID
+	settings.RestaurantID = restaurantID
+	settings.UpdatedBy = &userID
+	if err := uc.payrollRepo.SavePayrollSettings(settings); err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPayrollSettings, restaurantID, "", domain.AuditUpdate, before, settings, userID)
+	return settings, nil
+}
+
+// ListDeductionRules returns all of the restaurant's deduction rules
+func (uc *PayrollUseCase) ListDeductionRules(tenantID, restaurantID int) ([]domain.DeductionRule, error) {
+	return uc.payrollRepo.ListDeductionRules(tenantID, restaurantID, false)
+}
+
+// CreateDeductionRule creates a deduction rule
+func (uc *PayrollUseCase) CreateDeductionRule(tenantID, restaurantID int, req *domain.DeductionRuleRequest, userID int) (*domain.DeductionRule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	rule, err := uc.payrollRepo.CreateDeductionRule(newDeductionRule(tenantID, restaurantID, req))
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditDeductionRule, rule.ID, rule.Name, domain.AuditCreate, nil, rule, userID)
+	return rule, nil
+}
+
+// UpdateDeductionRule replaces a deduction rule; runs already generated keep their deductions
+func (uc *PayrollUseCase) UpdateDeductionRule(tenantID, restaurantID, id int, req *domain.DeductionRuleRequest, userID int) (*domain.DeductionRule, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	before, err := uc.payrollRepo.GetDeductionRule(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	rule := newDeductionRule(tenantID, restaurantID, req)
+	rule.ID = id
+	updated, err := uc.payrollRepo.UpdateDeductionRule(rule)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditDeductionRule, id, updated.Name, domain.AuditUpdate, before, updated, userID)
+	return updated, nil
+}
+
+// DeleteDeductionRule deletes a deduction rule
+func (uc *PayrollUseCase) DeleteDeductionRule(tenantID, restaurantID, id, userID int) error {
+	before, err := uc.payrollRepo.GetDeductionRule(tenantID, restaurantID, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.payrollRepo.DeleteDeductionRule(tenantID, restaurantID, id); err != nil {
+		return err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditDeductionRule, id, before.Name, domain.AuditDelete, before, nil, userID)
+	return nil
+}
+
+// ListRuns returns the restaurant's payroll runs
+func (uc *PayrollUseCase) ListRuns(tenantID, restaurantID int) ([]domain.PayrollRun, error) {
+	return uc.payrollRepo.ListPayrollRuns(tenantID, restaurantID)
+}
+
+// GetRun returns a payroll run with its salaries
+func (uc *PayrollUseCase) GetRun(tenantID, restaurantID, id int) (*domain.PayrollRun, error) {
+	run, err := uc.payrollRepo.GetPayrollRun(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	run.Salaries, err = uc.payrollRepo.ListRunSalaries(run.ID)
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// CreateRun generates a draft payroll run for the period
+func (uc *PayrollUseCase) CreateRun(tenantID, restaurantID int, req *domain.CreatePayrollRunRequest, userID int) (*domain.PayrollRun, error) {
+	start, end, err := req.Period()
+	if err != nil {
+		return nil, err
+	}
+	overlaps, err := uc.payrollRepo.HasOverlappingRun(tenantID, restaurantID, start, end, 0)
+	if err != nil {
+		return nil, err
+	}
+	if overlaps {
+		return nil, domain.ErrPayrollRunOverlaps
+	}
+
+	run := &domain.PayrollRun{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		PeriodStart:  start,
+		PeriodEnd:    end,
+		Status:       domain.PayrollRunDraft,
+		Notes:        req.Notes,
+		CreatedBy:    &userID,
+	}
+	salaries, err := uc.buildSalaries(run)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.payrollRepo.CreatePayrollRun(run, salaries); err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPayrollRun, run.ID, payrollRunName(run), domain.AuditCreate, nil, run, userID)
+	run.Salaries = salaries
+	return run, nil
+}
+
+// RecalculateRun regenerates a draft run's salaries, e.g. after attendance corrections or
+// late overtime approvals. Adjustments made to the old salaries are discarded.
+func (uc *PayrollUseCase) RecalculateRun(tenantID, restaurantID, id, userID int) (*domain.PayrollRun, error) {
+	run, err := uc.draftRun(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *run
+	salaries, err := uc.buildSalaries(run)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.payrollRepo.ReplacePayrollRunSalaries(run, salaries); err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPayrollRun, id, payrollRunName(run), domain.AuditUpdate, &before, run, userID)
+	run.Salaries = salaries
+	return run, nil
+}
+
+// AdjustSalary changes the bonus, allowances and loan or advance deductions of a draft run's
+// salary and recalculates its deductions
+func (uc *PayrollUseCase) AdjustSalary(tenantID, restaurantID, runID, salaryID int, req *domain.SalaryAdjustmentRequest, userID int) (*domain.Salary, error) {
+	run, err := uc.draftRun(tenantID, restaurantID, runID)
+	if err != nil {
+		return nil, err
+	}
+	sal, err := uc.payrollRepo.GetRunSalary(run.ID, salaryID)
+	if err != nil {
+		return nil, err
+	}
+	before := *sal
+	if err := req.Apply(sal); err != nil {
+		return nil, err
+	}
+	rules, err := uc.payrollRepo.ListDeductionRules(tenantID, restaurantID, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.ApplyDeductions(sal, rules); err != nil {
+		return nil, err
+	}
+
+	salaries, err := uc.payrollRepo.ListRunSalaries(run.ID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range salaries {
+		if salaries[i].ID == sal.ID {
+			salaries[i] = *sal
+		}
+	}
+	run.SummarizeSalaries(salaries)
+	if err := uc.payrollRepo.UpdateRunSalary(run, sal); err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditSalary, sal.ID, sal.EmployeeName, domain.AuditUpdate, &before, sal, userID)
+	return sal, nil
+}
+
+// ApproveRun approves a draft run so that it can be paid
+func (uc *PayrollUseCase) ApproveRun(tenantID, restaurantID, id, userID int) (*domain.PayrollRun, error) {
+	_, err := uc.changeRunStatus(tenantID, restaurantID, id, userID, domain.AuditApprove, func() error {
+		return uc.payrollRepo.ApprovePayrollRun(tenantID, restaurantID, id, userID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uc.GetRun(tenantID, restaurantID, id)
+}
+
+// PayRun marks every salary of an approved run as paid, then the run itself
+func (uc *PayrollUseCase) PayRun(tenantID, restaurantID, id int, req *domain.PayPayrollRunRequest, userID int) (*domain.PayrollRun, error) {
+	run, err := uc.GetRun(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != domain.PayrollRunApproved {
+		return nil, domain.ErrPayrollRunNotApproved
+	}
+
+	for _, sal := range run.Salaries {
+		if sal.IsPaid {
+			continue
+		}
+		if err := uc.salaryRepo.MarkAsPaid(tenantID, restaurantID, sal.ID, userID, req.PaymentReference); err != nil {
+			return nil, fmt.Errorf("failed to pay salary %d: %w", sal.ID, err)
+		}
+	}
+	if err := uc.payrollRepo.MarkPayrollRunPaid(tenantID, restaurantID, id, userID); err != nil {
+		return nil, err
+	}
+	paid, err := uc.GetRun(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *run
+	before.Salaries = nil
+	after := *paid
+	after.Salaries = nil
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPayrollRun, id, payrollRunName(paid), domain.AuditPay, &before, &after, userID)
+	return paid, nil
+}
+
+// CancelRun cancels a run that has not been paid
+func (uc *PayrollUseCase) CancelRun(tenantID, restaurantID, id, userID int) (*domain.PayrollRun, error) {
+	return uc.changeRunStatus(tenantID, restaurantID, id, userID, domain.AuditCancel, func() error {
+		return uc.payrollRepo.CancelPayrollRun(tenantID, restaurantID, id)
+	})
+}
+
+// changeRunStatus applies a status change to a run and records it in the audit trail.
+// The run is returned without its salaries.
+func (uc *PayrollUseCase) changeRunStatus(tenantID, restaurantID, id, userID int, action string, change func() error) (*domain.PayrollRun, error) {
+	before, err := uc.payrollRepo.GetPayrollRun(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := change(); err != nil {
+		return nil, err
+	}
+	after, err := uc.payrollRepo.GetPayrollRun(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPayrollRun, id, payrollRunName(after), action, before, after, userID)
+	return after, nil
+}
+
+// ExportRun renders a run's payroll register as CSV or JSON
+func (uc *PayrollUseCase) ExportRun(tenantID, restaurantID, id int, format string) ([]byte, string, string, error) {
+	run, err := uc.GetRun(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	name := fmt.Sprintf("payroll_%s_%s", run.PeriodStart.Format(domain.DateLayout), run.PeriodEnd.Format(domain.DateLayout))
+	return export(format, name, run, func(buf *bytes.Buffer) error {
+		return domain.WritePayrollRunCSV(buf, run)
+	})
+}
+
+// ExportPayslip renders an employee's payslip from a run as CSV or JSON
+func (uc *PayrollUseCase) ExportPayslip(tenantID, restaurantID, runID, salaryID int, format string) ([]byte, string, string, error) {
+	run, err := uc.payrollRepo.GetPayrollRun(tenantID, restaurantID, runID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	sal, err := uc.payrollRepo.GetRunSalary(run.ID, salaryID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	emp, err := uc.employeeRepo.GetEmployeeByID(tenantID, restaurantID, sal.EmployeeID)
+	if err != nil {
+		return nil, "", "", err
+	}
+	if emp == nil {
+		return nil, "", "", fmt.Errorf("employee not found")
+	}
+
+	slip := domain.NewPayslip(run.ID, emp, sal)
+	name := fmt.Sprintf("payslip_%s_%s", emp.EmployeeCode, run.PeriodEnd.Format(domain.DateLayout))
+	return export(format, name, slip, func(buf *bytes.Buffer) error {
+		return domain.WritePayslipCSV(buf, slip)
+	})
+}
+
+// buildSalaries gathers every active employee's attendance, unpaid leave and the tip pool
+// for the run's period and computes their salaries
+func (uc *PayrollUseCase) buildSalaries(run *domain.PayrollRun) ([]domain.Salary, error) {
+	tenantID, restaurantID := run.TenantID, run.RestaurantID
+
+	settings, err := uc.payrollRepo.GetPayrollSettings(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := uc.payrollRepo.ListDeductionRules(tenantID, restaurantID, true)
+	if err != nil {
+		return nil, err
+	}
+	employees, err := uc.employeeRepo.ListEmployees(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	attendance, err := uc.attendanceRepo.ListAttendance(tenantID, restaurantID, run.PeriodStart, run.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	leaves, err := uc.leaveRepo.ListApprovedLeaves(tenantID, restaurantID, run.PeriodStart, run.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	tips, err := uc.payrollRepo.SumOrderTips(tenantID, restaurantID, run.PeriodStart, run.PeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	byEmployee := make(map[int][]domain.Attendance)
+	for _, att := range attendance {
+		byEmployee[att.EmployeeID] = append(byEmployee[att.EmployeeID], att)
+	}
+	unpaidDays := make(map[int]float64)
+	for i := range leaves {
+		unpaidDays[leaves[i].EmployeeID] += domain.UnpaidLeaveDays(&leaves[i], run.PeriodStart, run.PeriodEnd)
+	}
+
+	inputs := make([]domain.PayrollInput, 0, len(employees))
+	for _, emp := range employees {
+		inputs = append(inputs, domain.PayrollInput{
+			Employee:        emp,
+			Attendance:      byEmployee[emp.ID],
+			UnpaidLeaveDays: unpaidDays[emp.ID],
+		})
+	}
+	return domain.BuildPayroll(run, settings, rules, inputs, tips)
+}
+
+// draftRun loads a run that can still be changed
+func (uc *PayrollUseCase) draftRun(tenantID, restaurantID, id int) (*domain.PayrollRun, error) {
+	run, err := uc.payrollRepo.GetPayrollRun(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if run.Status != domain.PayrollRunDraft {
+		return nil, domain.ErrPayrollRunNotDraft
+	}
+	return run, nil
+}
+
+// payrollRunName names a run in the audit trail by its period
+func payrollRunName(run *domain.PayrollRun) string {
+	return run.PeriodStart.Format(domain.DateLayout) + " to " + run.PeriodEnd.Format(domain.DateLayout)
+}
+
+func newDeductionRule(tenantID, restaurantID int, req *domain.DeductionRuleRequest) *domain.DeductionRule {
+	rule := &domain.DeductionRule{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		Name:         req.Name,
+		RuleType:     req.RuleType,
+		Component:    req.Component,
+		Rate:         req.Rate,
+		Amount:       req.Amount,
+		MinIncome:    req.MinIncome,
+		MaxIncome:    req.MaxIncome,
+		MaxDeduction: req.MaxDeduction,
+		IsActive:     true,
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+	return rule
+}
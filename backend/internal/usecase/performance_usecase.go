@@ -0,0 +1,542 @@
+package usecase
+
+import (
+	"fmt"
+	"log"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+)
+
+// PerformanceUseCase handles review cycles, performance reviews and employee goals
+type PerformanceUseCase struct {
+	performanceRepo  *repository.PerformanceRepository
+	employeeRepo     *repository.EmployeeRepository
+	notificationRepo *repository.NotificationRepository
+	auditRepo        *repository.HRAuditRepository
+}
+
+// NewPerformanceUseCase creates new performance use case
+func NewPerformanceUseCase(
+	performanceRepo *repository.PerformanceRepository,
+	employeeRepo *repository.EmployeeRepository,
+	notificationRepo *repository.NotificationRepository,
+	auditRepo *repository.HRAuditRepository,
+) *PerformanceUseCase {
+	return &PerformanceUseCase{
+		performanceRepo:  performanceRepo,
+		employeeRepo:     employeeRepo,
+		notificationRepo: notificationRepo,
+		auditRepo:        auditRepo,
+	}
+}
+
+// ListCriteria returns the restaurant's rating criteria
+func (uc *PerformanceUseCase) ListCriteria(tenantID, restaurantID int) ([]domain.ReviewCriterion, error) {
+	return uc.performanceRepo.ListReviewCriteria(tenantID, restaurantID, false)
+}
+
+// CreateCriterion creates a rating criterion
+func (uc *PerformanceUseCase) CreateCriterion(tenantID, restaurantID int, req *domain.ReviewCriterionRequest, userID int) (*domain.ReviewCriterion, error) {
+	criterion, err := req.Criterion(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	created, err := uc.performanceRepo.CreateReviewCriterion(criterion)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, created.TenantID, created.RestaurantID, domain.AuditReviewCriterion, created.ID, created.Name, domain.AuditCreate, nil, created, userID)
+	return created, nil
+}
+
+// UpdateCriterion replaces a rating criterion
+func (uc *PerformanceUseCase) UpdateCriterion(tenantID, restaurantID, id int, req *domain.ReviewCriterionRequest, userID int) (*domain.ReviewCriterion, error) {
+	criterion, err := req.Criterion(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := uc.performanceRepo.GetReviewCriterion(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	criterion.ID = id
+	updated, err := uc.performanceRepo.UpdateReviewCriterion(criterion)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditReviewCriterion, id, updated.Name, domain.AuditUpdate, before, updated, userID)
+	return updated, nil
+}
+
+// DeleteCriterion deletes a rating criterion. Ratings already given keep their key.
+func (uc *PerformanceUseCase) DeleteCriterion(tenantID, restaurantID, id, userID int) error {
+	before, err := uc.performanceRepo.GetReviewCriterion(tenantID, restaurantID, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.performanceRepo.DeleteReviewCriterion(tenantID, restaurantID, id); err != nil {
+		return err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditReviewCriterion, id, before.Name, domain.AuditDelete, before, nil, userID)
+	return nil
+}
+
+// ListCycles returns the restaurant's review cycles
+func (uc *PerformanceUseCase) ListCycles(tenantID, restaurantID int) ([]domain.ReviewCycle, error) {
+	return uc.performanceRepo.ListReviewCycles(tenantID, restaurantID)
+}
+
+// GetCycle returns a review cycle with its reviews
+func (uc *PerformanceUseCase) GetCycle(tenantID, restaurantID, id int) (*domain.ReviewCycle, error) {
+	cycle, err := uc.performanceRepo.GetReviewCycle(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if cycle.Reviews, err = uc.performanceRepo.ListCycleReviews(tenantID, restaurantID, id); err != nil {
+		return nil, err
+	}
+	return cycle, nil
+}
+
+// CreateCycle opens a review cycle with a draft review for each employee in it. Each review
+// is assessed by the employee's manager, or by HR when the employee has none.
+func (uc *PerformanceUseCase) CreateCycle(tenantID, restaurantID int, req *domain.CreateReviewCycleRequest, userID int) (*domain.ReviewCycle, error) {
+	cycle, err := req.Cycle(tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	criteria, err := uc.performanceRepo.ListReviewCriteria(tenantID, restaurantID, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(criteria) == 0 {
+		return nil, domain.ErrNoReviewCriteria
+	}
+
+	employees, err := uc.cycleEmployees(tenantID, restaurantID, req.EmployeeIDs)
+	if err != nil {
+		return nil, err
+	}
+	if len(employees) == 0 {
+		return nil, domain.ErrNoEmployeesToReview
+	}
+
+	managerIDs := []int{}
+	for _, emp := range employees {
+		if emp.ManagerID != nil {
+			managerIDs = append(managerIDs, *emp.ManagerID)
+		}
+	}
+	managerUsers, err := uc.employeeRepo.GetEmployeeUserIDs(tenantID, managerIDs)
+	if err != nil {
+		return nil, err
+	}
+	managerNames := map[int]string{}
+	reviews := make([]domain.PerformanceReview, 0, len(employees))
+	for _, emp := range employees {
+		review := domain.PerformanceReview{EmployeeID: emp.ID, ReviewerEmployeeID: emp.ManagerID}
+		if emp.ManagerID != nil {
+			name, ok := managerNames[*emp.ManagerID]
+			if !ok {
+				if manager, err := uc.employeeRepo.GetEmployeeByID(tenantID, restaurantID, *emp.ManagerID); err == nil && manager != nil {
+					name = employeeName(manager)
+				}
+				managerNames[*emp.ManagerID] = name
+			}
+			review.ReviewerName = name
+			if managerUser, ok := managerUsers[*emp.ManagerID]; ok {
+				review.ReviewerID = &managerUser
+			}
+		}
+		reviews = append(reviews, review)
+	}
+
+	if userID != 0 {
+		cycle.CreatedBy = &userID
+	}
+	created, err := uc.performanceRepo.CreateReviewCycle(cycle, reviews)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditReviewCycle, created.ID, created.Name, domain.AuditCreate, nil, created, userID)
+
+	for _, review := range reviews {
+		uc.notify(tenantID, restaurantID, created.ID, "review_cycle", review.EmployeeID, "Performance review started",
+			fmt.Sprintf("Your %s review for %s to %s has started. Please complete your self assessment", created.Name,
+				created.PeriodStart.Format(domain.DateLayout), created.PeriodEnd.Format(domain.DateLayout)))
+	}
+	for managerID := range managerNames {
+		uc.notify(tenantID, restaurantID, created.ID, "review_cycle", managerID, "Performance reviews to complete",
+			fmt.Sprintf("You have team members to assess in the %s review", created.Name))
+	}
+	return created, nil
+}
+
+// CloseCycle closes a review cycle; its open reviews can no longer be assessed
+func (uc *PerformanceUseCase) CloseCycle(tenantID, restaurantID, id, userID int) (*domain.ReviewCycle, error) {
+	before, err := uc.performanceRepo.GetReviewCycle(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.performanceRepo.CloseReviewCycle(tenantID, restaurantID, id); err != nil {
+		return nil, err
+	}
+	closed, err := uc.performanceRepo.GetReviewCycle(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditReviewCycle, id, closed.Name, domain.AuditUpdate, before, closed, userID)
+	return closed, nil
+}
+
+// GetReview returns a performance review with the goals set in it
+func (uc *PerformanceUseCase) GetReview(tenantID, restaurantID, id int) (*domain.PerformanceReview, error) {
+	return uc.performanceRepo.GetPerformanceReview(tenantID, restaurantID, id)
+}
+
+// EmployeeReviews returns an employee's review history
+func (uc *PerformanceUseCase) EmployeeReviews(tenantID, restaurantID, employeeID int) ([]domain.PerformanceReview, error) {
+	if _, err := uc.employee(tenantID, restaurantID, employeeID); err != nil {
+		return nil, err
+	}
+	return uc.performanceRepo.ListEmployeeReviews(tenantID, restaurantID, employeeID)
+}
+
+// PendingReviews returns the draft reviews the user is to assess as manager
+func (uc *PerformanceUseCase) PendingReviews(tenantID, restaurantID, userID int) ([]domain.PerformanceReview, error) {
+	employeeID, err := uc.employeeRepo.GetEmployeeIDByUser(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if employeeID == 0 {
+		return []domain.PerformanceReview{}, nil
+	}
+	return uc.performanceRepo.ListReviewsForReviewer(tenantID, restaurantID, employeeID)
+}
+
+// SubmitSelfAssessment stores the employee's own assessment. It can be revised until the
+// manager submits theirs.
+func (uc *PerformanceUseCase) SubmitSelfAssessment(tenantID, restaurantID, id int, req *domain.SelfAssessmentRequest, userID int) (*domain.PerformanceReview, error) {
+	review, err := uc.openReview(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := uc.employeeRepo.GetEmployeeIDByUser(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if actor != review.EmployeeID {
+		return nil, domain.ErrNotReviewParticipant
+	}
+	criteria, err := uc.performanceRepo.ListReviewCriteria(tenantID, restaurantID, true)
+	if err != nil {
+		return nil, err
+	}
+	if err := domain.ValidateRatings(req.Ratings, criteria); err != nil {
+		return nil, err
+	}
+
+	before := *review
+	review.SelfRatings = req.Ratings
+	review.SelfComments = req.Comments
+	review.Achievements = req.Achievements
+	if err := uc.performanceRepo.SaveSelfAssessment(review); err != nil {
+		return nil, err
+	}
+	updated, err := uc.performanceRepo.GetPerformanceReview(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPerformanceReview, id, updated.EmployeeName, domain.AuditSubmit, &before, updated, userID)
+
+	if updated.ReviewerEmployeeID != nil {
+		uc.notify(tenantID, restaurantID, id, "performance_review", *updated.ReviewerEmployeeID, "Self assessment submitted",
+			fmt.Sprintf("%s submitted their self assessment", updated.EmployeeName))
+	}
+	return updated, nil
+}
+
+// SubmitManagerAssessment stores the reviewer's assessment, sets the goals agreed in it and
+// sends the review to the employee
+func (uc *PerformanceUseCase) SubmitManagerAssessment(tenantID, restaurantID, id int, req *domain.ManagerAssessmentRequest, userID int) (*domain.PerformanceReview, error) {
+	review, err := uc.openReview(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	actor, err := uc.employeeRepo.GetEmployeeIDByUser(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if review.ReviewerEmployeeID != nil && actor != *review.ReviewerEmployeeID {
+		return nil, domain.ErrNotReviewParticipant
+	}
+	if actor == review.EmployeeID {
+		return nil, domain.ErrNotReviewParticipant
+	}
+	criteria, err := uc.performanceRepo.ListReviewCriteria(tenantID, restaurantID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	before := *review
+	if err := req.Apply(review, criteria); err != nil {
+		return nil, err
+	}
+	goals := make([]domain.PerformanceGoal, 0, len(req.Goals))
+	for i := range req.Goals {
+		req.Goals[i].ReviewID = &review.ID
+		goal, err := req.Goals[i].Goal(tenantID, restaurantID, review.EmployeeID)
+		if err != nil {
+			return nil, err
+		}
+		if userID != 0 {
+			goal.CreatedBy = &userID
+		}
+		goals = append(goals, *goal)
+	}
+	if err := uc.performanceRepo.SubmitManagerAssessment(review, goals, userID); err != nil {
+		return nil, err
+	}
+	updated, err := uc.performanceRepo.GetPerformanceReview(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPerformanceReview, id, updated.EmployeeName, domain.AuditSubmit, &before, updated, userID)
+	for _, goal := range goals {
+		recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPerformanceGoal, goal.ID, goal.Title, domain.AuditCreate, nil, goal, userID)
+	}
+
+	uc.notify(tenantID, restaurantID, id, "performance_review", updated.EmployeeID, "Performance review ready",
+		fmt.Sprintf("Your review has been submitted with an overall rating of %.2f. Please acknowledge it", *updated.OverallRating))
+	return updated, nil
+}
+
+// AcknowledgeReview records the employee's acceptance of or dispute with a submitted review
+func (uc *PerformanceUseCase) AcknowledgeReview(tenantID, restaurantID, id int, req *domain.AcknowledgeReviewRequest, userID int) (*domain.PerformanceReview, error) {
+	review, err := uc.performanceRepo.GetPerformanceReview(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != domain.ReviewSubmitted {
+		return nil, domain.ErrReviewWrongStatus
+	}
+	actor, err := uc.employeeRepo.GetEmployeeIDByUser(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if actor != review.EmployeeID {
+		return nil, domain.ErrNotReviewParticipant
+	}
+
+	status := domain.ReviewAcknowledged
+	if !req.Agree {
+		status = domain.ReviewDisputed
+	}
+	if err := uc.performanceRepo.AcknowledgeReview(id, status, req.Comments); err != nil {
+		return nil, err
+	}
+	updated, err := uc.performanceRepo.GetPerformanceReview(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPerformanceReview, id, updated.EmployeeName, domain.AuditAcknowledge, review, updated, userID)
+
+	if updated.ReviewerEmployeeID != nil {
+		message := fmt.Sprintf("%s acknowledged their review", updated.EmployeeName)
+		if status == domain.ReviewDisputed {
+			message = fmt.Sprintf("%s disputed their review", updated.EmployeeName)
+		}
+		uc.notify(tenantID, restaurantID, id, "performance_review", *updated.ReviewerEmployeeID, "Performance review "+status, message)
+	}
+	return updated, nil
+}
+
+// FinalizeReview records HR's sign-off on an acknowledged or disputed review
+func (uc *PerformanceUseCase) FinalizeReview(tenantID, restaurantID, id int, req *domain.FinalizeReviewRequest, userID int) (*domain.PerformanceReview, error) {
+	review, err := uc.performanceRepo.GetPerformanceReview(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != domain.ReviewAcknowledged && review.Status != domain.ReviewDisputed {
+		return nil, domain.ErrReviewWrongStatus
+	}
+	actor, err := uc.employeeRepo.GetEmployeeIDByUser(tenantID, restaurantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if actor == review.EmployeeID {
+		return nil, domain.ErrNotReviewParticipant
+	}
+
+	if err := uc.performanceRepo.FinalizeReview(id, req.HRComments, userID); err != nil {
+		return nil, err
+	}
+	updated, err := uc.performanceRepo.GetPerformanceReview(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPerformanceReview, id, updated.EmployeeName, domain.AuditFinalize, review, updated, userID)
+	uc.notify(tenantID, restaurantID, id, "performance_review", updated.EmployeeID, "Performance review finalized",
+		"Your performance review has been finalized by HR")
+	return updated, nil
+}
+
+// EmployeeGoals returns an employee's goals
+func (uc *PerformanceUseCase) EmployeeGoals(tenantID, restaurantID, employeeID int) ([]domain.PerformanceGoal, error) {
+	if _, err := uc.employee(tenantID, restaurantID, employeeID); err != nil {
+		return nil, err
+	}
+	return uc.performanceRepo.ListEmployeeGoals(tenantID, restaurantID, employeeID)
+}
+
+// CreateGoal sets a goal for an employee outside of a review
+func (uc *PerformanceUseCase) CreateGoal(tenantID, restaurantID, employeeID int, req *domain.GoalRequest, userID int) (*domain.PerformanceGoal, error) {
+	if _, err := uc.employee(tenantID, restaurantID, employeeID); err != nil {
+		return nil, err
+	}
+	if req.ReviewID != nil {
+		review, err := uc.performanceRepo.GetPerformanceReview(tenantID, restaurantID, *req.ReviewID)
+		if err != nil {
+			return nil, err
+		}
+		if review.EmployeeID != employeeID {
+			return nil, fmt.Errorf("review %d is not a review of employee %d", review.ID, employeeID)
+		}
+	}
+	goal, err := req.Goal(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	if userID != 0 {
+		goal.CreatedBy = &userID
+	}
+	created, err := uc.performanceRepo.CreatePerformanceGoal(goal)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPerformanceGoal, created.ID, created.Title, domain.AuditCreate, nil, created, userID)
+	return created, nil
+}
+
+// UpdateGoalProgress records progress on a goal or changes its status
+func (uc *PerformanceUseCase) UpdateGoalProgress(tenantID, restaurantID, id int, req *domain.GoalProgressRequest, userID int) (*domain.PerformanceGoal, error) {
+	goal, err := uc.performanceRepo.GetPerformanceGoal(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	before := *goal
+	if err := req.Apply(goal); err != nil {
+		return nil, err
+	}
+	updated, err := uc.performanceRepo.UpdatePerformanceGoal(goal)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditPerformanceGoal, id, updated.Title, domain.AuditUpdate, &before, updated, userID)
+	return updated, nil
+}
+
+// EmployeeProfile returns an employee with their review history and goals
+func (uc *PerformanceUseCase) EmployeeProfile(tenantID, restaurantID, employeeID int) (*domain.EmployeeProfile, error) {
+	emp, err := uc.employee(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	reviews, err := uc.performanceRepo.ListEmployeeReviews(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	goals, err := uc.performanceRepo.ListEmployeeGoals(tenantID, restaurantID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.EmployeeProfile{
+		Employee:      emp,
+		Reviews:       reviews,
+		Goals:         goals,
+		AverageRating: domain.AverageFinalizedRating(reviews),
+	}, nil
+}
+
+// openReview loads a draft review whose cycle is still active
+func (uc *PerformanceUseCase) openReview(tenantID, restaurantID, id int) (*domain.PerformanceReview, error) {
+	review, err := uc.performanceRepo.GetPerformanceReview(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if review.Status != domain.ReviewDraft {
+		return nil, domain.ErrReviewWrongStatus
+	}
+	if review.CycleID != nil {
+		cycle, err := uc.performanceRepo.GetReviewCycle(tenantID, restaurantID, *review.CycleID)
+		if err != nil {
+			return nil, err
+		}
+		if cycle.Status != "active" {
+			return nil, domain.ErrReviewCycleClosed
+		}
+	}
+	return review, nil
+}
+
+// cycleEmployees returns the requested employees, or every active employee
+func (uc *PerformanceUseCase) cycleEmployees(tenantID, restaurantID int, ids []int) ([]domain.Employee, error) {
+	if len(ids) == 0 {
+		return uc.employeeRepo.GetEmployeesByStatus(tenantID, restaurantID, "active")
+	}
+	employees := make([]domain.Employee, 0, len(ids))
+	seen := map[int]bool{}
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		emp, err := uc.employee(tenantID, restaurantID, id)
+		if err != nil {
+			return nil, err
+		}
+		employees = append(employees, *emp)
+	}
+	return employees, nil
+}
+
+func (uc *PerformanceUseCase) employee(tenantID, restaurantID, id int) (*domain.Employee, error) {
+	emp, err := uc.employeeRepo.GetEmployeeByID(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if emp == nil {
+		return nil, fmt.Errorf("employee %d not found", id)
+	}
+	return emp, nil
+}
+
+// notify sends a performance notification to the user account of an employee. Failures are logged.
+func (uc *PerformanceUseCase) notify(tenantID, restaurantID, entityID int, entityType string, employeeID int, title, message string) {
+	users, err := uc.employeeRepo.GetEmployeeUserIDs(tenantID, []int{employeeID})
+	if err != nil {
+		log.Printf("%s %d: failed to resolve account of employee %d: %v", entityType, entityID, employeeID, err)
+		return
+	}
+	userID, ok := users[employeeID]
+	if !ok {
+		return
+	}
+
+	_, err = uc.notificationRepo.CreateNotification(&domain.Notification{
+		TenantID:          tenantID,
+		RestaurantID:      restaurantID,
+		UserID:            userID,
+		Type:              domain.NotificationTypePerformance,
+		Module:            domain.ModuleHR,
+		Title:             title,
+		Message:           message,
+		RelatedEntityType: &entityType,
+		RelatedEntityID:   &entityID,
+		Priority:          domain.PriorityNormal,
+	})
+	if err != nil {
+		log.Printf("%s %d: failed to notify employee %d: %v", entityType, entityID, employeeID, err)
+	}
+}
@@ -1,6 +1,7 @@
 package usecase
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -8,6 +9,7 @@ import (
 	"path/filepath"
 	"pos-saas/internal/domain"
 	"pos-saas/internal/repository"
+	"strings"
 	"time"
 )
 
@@ -159,6 +161,13 @@ func (uc *ProductUseCase) UpdateProduct(
 	if req.IsAvailable != nil {
 		product.IsAvailable = *req.IsAvailable
 	}
+	if req.Allergens != nil {
+		codes, err := domain.NormalizeAllergenCodes(req.Allergens)
+		if err != nil {
+			return nil, err
+		}
+		product.Allergens = codes
+	}
 
 	product.UpdatedBy = &userID
 
@@ -168,6 +177,12 @@ func (uc *ProductUseCase) UpdateProduct(
 		return nil, err
 	}
 
+	if req.Allergens != nil {
+		if err := uc.repo.SetProductAllergens(product.ID, product.Allergens); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create low stock notification if applicable
 	if product.TrackInventory && product.QuantityInStock < product.LowStockThreshold {
 		actionURL := fmt.Sprintf("/dashboard/products/%d/edit", product.ID)
@@ -295,6 +310,49 @@ func (uc *ProductUseCase) SearchPublic(ctx interface{}, restaurantID int, query
 	return uc.repo.SearchPublic(0, restaurantID, query, lang)
 }
 
+// SearchMenu runs a ranked menu search and returns filtered results with facets.
+// Facets are computed over all matches before filters so clients can show counts for every option.
+func (uc *ProductUseCase) SearchMenu(restaurantID int, req *domain.ProductSearchRequest) (*domain.ProductSearchResult, error) {
+	if strings.TrimSpace(req.Query) == "" {
+		return nil, errors.New("search query is required")
+	}
+
+	hits, err := uc.repo.SearchPublicRanked(restaurantID, req.Query, 200)
+	if err != nil {
+		return nil, err
+	}
+
+	facets := domain.BuildSearchFacets(hits)
+	filtered := domain.FilterSearchHits(hits, req)
+
+	total := len(filtered)
+	if req.Limit > 0 && len(filtered) > req.Limit {
+		filtered = filtered[:req.Limit]
+	}
+
+	products := make([]domain.Product, 0, len(filtered))
+	for _, hit := range filtered {
+		products = append(products, hit.Product)
+	}
+
+	return &domain.ProductSearchResult{
+		Query:    req.Query,
+		Total:    total,
+		Products: products,
+		Facets:   facets,
+	}, nil
+}
+
+// ListAllergens returns the allergen vocabulary
+func (uc *ProductUseCase) ListAllergens() ([]domain.Allergen, error) {
+	return uc.repo.ListAllergens()
+}
+
+// SuggestMenu returns autocomplete suggestions
+func (uc *ProductUseCase) SuggestMenu(restaurantID int, prefix, lang string, limit int) ([]domain.SearchSuggestion, error) {
+	return uc.repo.SuggestPublic(restaurantID, prefix, lang, limit)
+}
+
 // GetProductImages returns images
 func (uc *ProductUseCase) GetProductImages(ctx interface{}, productID int) ([]domain.ProductImage, error) {
 	return uc.repo.GetProductImages(productID)
@@ -317,5 +375,12 @@ func validateProductRequest(req *domain.CreateProductRequest) error {
 	// 	return fmt.Errorf("price must be greater than 0")
 	// }
 	// Allow creation without category initially if needed, or if frontend sends 0
+
+	// Allergens must come from the controlled vocabulary
+	codes, err := domain.NormalizeAllergenCodes(req.Allergens)
+	if err != nil {
+		return err
+	}
+	req.Allergens = codes
 	return nil
 }
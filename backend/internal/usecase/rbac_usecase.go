@@ -0,0 +1,721 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+)
+
+// logPermissionChange writes an entry to permission_audit_logs. Like the HR audit trail it is
+// best-effort: failures are logged and never undo the change.
+func logPermissionChange(ctx context.Context, auditLogRepo *repository.PermissionAuditLogRepository, entry *domain.PermissionAuditLog) {
+	if auditLogRepo == nil {
+		return
+	}
+	if _, err := auditLogRepo.Log(ctx, entry); err != nil {
+		log.Printf("permission audit: failed to record %s by user %d: %v", entry.Action, entry.AdminID, err)
+	}
+}
+
+func int64Ptr(v int64) *int64 {
+	return &v
+}
+
+// RoleUseCase manages the tenant's roles and the module permissions they grant
+type RoleUseCase struct {
+	roleRepo           *repository.RoleRepository
+	rolePermissionRepo *repository.RolePermissionRepository
+	moduleDefRepo      *repository.ModuleDefinitionRepository
+	auditLogRepo       *repository.PermissionAuditLogRepository
+}
+
+// NewRoleUseCase creates new role use case
+func NewRoleUseCase(
+	roleRepo *repository.RoleRepository,
+	rolePermissionRepo *repository.RolePermissionRepository,
+	moduleDefRepo *repository.ModuleDefinitionRepository,
+	auditLogRepo *repository.PermissionAuditLogRepository,
+) *RoleUseCase {
+	return &RoleUseCase{
+		roleRepo:           roleRepo,
+		rolePermissionRepo: rolePermissionRepo,
+		moduleDefRepo:      moduleDefRepo,
+		auditLogRepo:       auditLogRepo,
+	}
+}
+
+// ListRoles returns the tenant's active roles
+func (uc *RoleUseCase) ListRoles(ctx context.Context, tenantID int64) ([]domain.Role, error) {
+	return uc.roleRepo.ListTenantRoles(int(tenantID))
+}
+
+// GetRole returns a role with its module permissions
+func (uc *RoleUseCase) GetRole(ctx context.Context, tenantID, roleID int64) (*domain.RBACRole, error) {
+	role, err := tenantRole(uc.roleRepo, tenantID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	permissions, err := rolePermissions(ctx, uc.rolePermissionRepo, uc.moduleDefRepo, role)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.RBACRole{Role: *role, ModulePermissions: permissions}, nil
+}
+
+// CreateRole creates a role in the actor's current restaurant with the requested permissions
+func (uc *RoleUseCase) CreateRole(ctx context.Context, actor domain.PermissionActor, req *domain.RBACRoleRequest) (*domain.RBACRole, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	if err := uc.checkModules(ctx, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	code := domain.RoleCodeFromName(req.RoleName)
+	if code == "" {
+		code = fmt.Sprintf("ROLE_%d", time.Now().UnixNano()%1000000)
+	}
+	createdBy := int(actor.UserID)
+	role := &domain.Role{
+		TenantID:     int(actor.TenantID),
+		RestaurantID: int(actor.RestaurantID),
+		RoleName:     req.RoleName,
+		Description:  req.Description,
+		RoleCode:     code,
+		Permissions:  json.RawMessage("{}"),
+		AccessLevel:  "basic",
+		IsActive:     true,
+		CreatedBy:    &createdBy,
+	}
+	id, err := uc.roleRepo.CreateRole(role)
+	if err != nil {
+		return nil, err
+	}
+	role.ID = id
+
+	entry := actor.AuditLog(domain.ActionCreateRole)
+	entry.RoleID = int64Ptr(int64(id))
+	entry.Details.NewValue = role.RoleName
+	logPermissionChange(ctx, uc.auditLogRepo, entry)
+
+	if err := uc.setPermissions(ctx, actor, role, req.Permissions); err != nil {
+		return nil, err
+	}
+	return uc.GetRole(ctx, actor.TenantID, int64(id))
+}
+
+// UpdateRole renames a role and, when permissions are sent, replaces its module permissions.
+// System roles cannot be changed.
+func (uc *RoleUseCase) UpdateRole(ctx context.Context, actor domain.PermissionActor, roleID int64, req *domain.RBACRoleRequest) (*domain.RBACRole, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	role, err := tenantRole(uc.roleRepo, actor.TenantID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if role.IsSystemRole {
+		return nil, domain.ErrSystemRoleImmutable
+	}
+	if err := uc.checkModules(ctx, req.Permissions); err != nil {
+		return nil, err
+	}
+
+	before := role.RoleName
+	role.RoleName = req.RoleName
+	role.Description = req.Description
+	if len(role.Permissions) == 0 {
+		role.Permissions = json.RawMessage("{}")
+	}
+	updatedBy := int(actor.UserID)
+	role.UpdatedBy = &updatedBy
+	if err := uc.roleRepo.UpdateRole(role); err != nil {
+		return nil, err
+	}
+
+	entry := actor.AuditLog(domain.ActionUpdateRole)
+	entry.RoleID = int64Ptr(roleID)
+	entry.Details.OldValue = before
+	entry.Details.NewValue = role.RoleName
+	logPermissionChange(ctx, uc.auditLogRepo, entry)
+
+	if req.Permissions != nil {
+		if err := uc.setPermissions(ctx, actor, role, req.Permissions); err != nil {
+			return nil, err
+		}
+	}
+	return uc.GetRole(ctx, actor.TenantID, roleID)
+}
+
+// DeleteRole deactivates a role and revokes its permissions. System roles cannot be deleted.
+func (uc *RoleUseCase) DeleteRole(ctx context.Context, actor domain.PermissionActor, roleID int64) error {
+	role, err := tenantRole(uc.roleRepo, actor.TenantID, roleID)
+	if err != nil {
+		return err
+	}
+	if role.IsSystemRole {
+		return domain.ErrSystemRoleImmutable
+	}
+	if err := uc.roleRepo.DeleteRole(role.TenantID, role.RestaurantID, role.ID); err != nil {
+		return err
+	}
+	if err := uc.rolePermissionRepo.RevokeAll(ctx, roleID); err != nil {
+		return err
+	}
+
+	entry := actor.AuditLog(domain.ActionDeleteRole)
+	entry.RoleID = int64Ptr(roleID)
+	entry.Details.OldValue = role.RoleName
+	logPermissionChange(ctx, uc.auditLogRepo, entry)
+	return nil
+}
+
+// setPermissions replaces a role's module permissions and records one audit entry per
+// module whose level changed
+func (uc *RoleUseCase) setPermissions(ctx context.Context, actor domain.PermissionActor, role *domain.Role, requested []domain.ModulePermissionRequest) error {
+	current, err := uc.rolePermissionRepo.GetByRoleAndTenant(ctx, actor.TenantID, int64(role.ID))
+	if err != nil {
+		return err
+	}
+	before := make(map[int64]string, len(current))
+	for _, p := range current {
+		before[p.ModuleID] = p.PermissionLevel
+	}
+
+	permissions := make([]domain.RolePermission, 0, len(requested))
+	after := make(map[int64]string, len(requested))
+	for _, p := range requested {
+		if p.PermissionLevel == domain.PermissionNone {
+			continue
+		}
+		permissions = append(permissions, domain.RolePermission{
+			TenantID:        actor.TenantID,
+			RoleID:          int64(role.ID),
+			ModuleID:        p.ModuleID,
+			PermissionLevel: p.PermissionLevel,
+		})
+		after[p.ModuleID] = p.PermissionLevel
+	}
+	if err := uc.rolePermissionRepo.ReplaceForRole(ctx, actor.TenantID, int64(role.ID), permissions); err != nil {
+		return err
+	}
+
+	for moduleID, level := range after {
+		if before[moduleID] != level {
+			entry := actor.AuditLog(domain.ActionAssignPermission)
+			entry.RoleID = int64Ptr(int64(role.ID))
+			entry.ModuleID = int64Ptr(moduleID)
+			entry.Details.OldValue = before[moduleID]
+			entry.Details.NewValue = level
+			logPermissionChange(ctx, uc.auditLogRepo, entry)
+		}
+	}
+	for moduleID, level := range before {
+		if _, kept := after[moduleID]; !kept {
+			entry := actor.AuditLog(domain.ActionRevokePermission)
+			entry.RoleID = int64Ptr(int64(role.ID))
+			entry.ModuleID = int64Ptr(moduleID)
+			entry.Details.OldValue = level
+			logPermissionChange(ctx, uc.auditLogRepo, entry)
+		}
+	}
+	return nil
+}
+
+func (uc *RoleUseCase) checkModules(ctx context.Context, permissions []domain.ModulePermissionRequest) error {
+	for _, p := range permissions {
+		if err := checkModule(ctx, uc.moduleDefRepo, p.ModuleID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rolePermissions lists a role's module permissions. The ADMIN system role has no rows and
+// is reported as ADMIN on every active module.
+func rolePermissions(
+	ctx context.Context,
+	rolePermissionRepo *repository.RolePermissionRepository,
+	moduleDefRepo *repository.ModuleDefinitionRepository,
+	role *domain.Role,
+) ([]domain.RolePermission, error) {
+	if !role.HasFullAccess() {
+		permissions, err := rolePermissionRepo.GetByRoleAndTenant(ctx, int64(role.TenantID), int64(role.ID))
+		if permissions == nil && err == nil {
+			permissions = []domain.RolePermission{}
+		}
+		return permissions, err
+	}
+
+	modules, err := moduleDefRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	permissions := make([]domain.RolePermission, 0, len(modules))
+	for _, m := range modules {
+		permissions = append(permissions, domain.RolePermission{
+			TenantID:        int64(role.TenantID),
+			RoleID:          int64(role.ID),
+			ModuleID:        m.ID,
+			PermissionLevel: domain.PermissionAdmin,
+		})
+	}
+	return permissions, nil
+}
+
+// tenantRole loads one of the tenant's active roles
+func tenantRole(roleRepo *repository.RoleRepository, tenantID, roleID int64) (*domain.Role, error) {
+	role, err := roleRepo.GetTenantRole(int(tenantID), int(roleID))
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		return nil, domain.ErrRoleNotFound
+	}
+	return role, nil
+}
+
+func checkModule(ctx context.Context, moduleDefRepo *repository.ModuleDefinitionRepository, moduleID int64) error {
+	module, err := moduleDefRepo.GetByID(ctx, moduleID)
+	if err != nil {
+		return err
+	}
+	if !module.IsActive {
+		return domain.ErrModuleNotFound
+	}
+	return nil
+}
+
+// PermissionUseCase grants module permissions to roles and decides what a user may do
+type PermissionUseCase struct {
+	rolePermissionRepo *repository.RolePermissionRepository
+	userRoleRepo       *repository.UserRoleRepository
+	moduleDefRepo      *repository.ModuleDefinitionRepository
+	roleRepo           *repository.RoleRepository
+	auditLogRepo       *repository.PermissionAuditLogRepository
+}
+
+// NewPermissionUseCase creates new permission use case
+func NewPermissionUseCase(
+	rolePermissionRepo *repository.RolePermissionRepository,
+	userRoleRepo *repository.UserRoleRepository,
+	moduleDefRepo *repository.ModuleDefinitionRepository,
+	roleRepo *repository.RoleRepository,
+	auditLogRepo *repository.PermissionAuditLogRepository,
+) *PermissionUseCase {
+	return &PermissionUseCase{
+		rolePermissionRepo: rolePermissionRepo,
+		userRoleRepo:       userRoleRepo,
+		moduleDefRepo:      moduleDefRepo,
+		roleRepo:           roleRepo,
+		auditLogRepo:       auditLogRepo,
+	}
+}
+
+// ListModules returns the active modules permissions can be granted on
+func (uc *PermissionUseCase) ListModules(ctx context.Context) ([]domain.ModuleDefinition, error) {
+	modules, err := uc.moduleDefRepo.GetAll(ctx)
+	if modules == nil && err == nil {
+		modules = []domain.ModuleDefinition{}
+	}
+	return modules, err
+}
+
+// GetRolePermissions returns a role's module permissions
+func (uc *PermissionUseCase) GetRolePermissions(ctx context.Context, tenantID, roleID int64) ([]domain.RolePermission, error) {
+	role, err := tenantRole(uc.roleRepo, tenantID, roleID)
+	if err != nil {
+		return nil, err
+	}
+	return rolePermissions(ctx, uc.rolePermissionRepo, uc.moduleDefRepo, role)
+}
+
+// AssignPermission sets a role's permission level on one module
+func (uc *PermissionUseCase) AssignPermission(ctx context.Context, actor domain.PermissionActor, roleID int64, req *domain.ModulePermissionRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	role, err := tenantRole(uc.roleRepo, actor.TenantID, roleID)
+	if err != nil {
+		return err
+	}
+	if role.HasFullAccess() {
+		return domain.ErrSystemRoleImmutable
+	}
+	if err := checkModule(ctx, uc.moduleDefRepo, req.ModuleID); err != nil {
+		return err
+	}
+	// Roles are shared by the whole tenant, so the actor must hold the level tenant-wide
+	own, err := uc.GetUserPermissions(ctx, actor.TenantID, 0, actor.UserID)
+	if err != nil {
+		return err
+	}
+	if !own.Allows(req.ModuleID, req.PermissionLevel) {
+		return fmt.Errorf("%w: you cannot grant %s on module %d, above your own permissions",
+			domain.ErrPermissionDenied, req.PermissionLevel, req.ModuleID)
+	}
+
+	before, err := uc.rolePermissionRepo.GetByRoleAndModule(ctx, roleID, req.ModuleID)
+	if err != nil {
+		return err
+	}
+	if err := uc.rolePermissionRepo.Assign(ctx, actor.TenantID, roleID, req.ModuleID, req.PermissionLevel); err != nil {
+		return err
+	}
+
+	entry := actor.AuditLog(domain.ActionAssignPermission)
+	entry.RoleID = int64Ptr(roleID)
+	entry.ModuleID = int64Ptr(req.ModuleID)
+	entry.Details.OldValue = before
+	entry.Details.NewValue = req.PermissionLevel
+	logPermissionChange(ctx, uc.auditLogRepo, entry)
+	return nil
+}
+
+// RevokePermission removes a role's permission on one module
+func (uc *PermissionUseCase) RevokePermission(ctx context.Context, actor domain.PermissionActor, roleID, moduleID int64) error {
+	role, err := tenantRole(uc.roleRepo, actor.TenantID, roleID)
+	if err != nil {
+		return err
+	}
+	if role.HasFullAccess() {
+		return domain.ErrSystemRoleImmutable
+	}
+
+	before, err := uc.rolePermissionRepo.GetByRoleAndModule(ctx, roleID, moduleID)
+	if err != nil {
+		return err
+	}
+	if err := uc.rolePermissionRepo.Revoke(ctx, roleID, moduleID); err != nil {
+		return err
+	}
+
+	entry := actor.AuditLog(domain.ActionRevokePermission)
+	entry.RoleID = int64Ptr(roleID)
+	entry.ModuleID = int64Ptr(moduleID)
+	entry.Details.OldValue = before
+	logPermissionChange(ctx, uc.auditLogRepo, entry)
+	return nil
+}
+
+// GetUserPermissions returns the user's effective level on each module in a restaurant: the
+// highest level granted by any of the user's roles there. Restaurant 0 counts only the roles
+// assigned in every restaurant of the tenant.
+func (uc *PermissionUseCase) GetUserPermissions(ctx context.Context, tenantID, restaurantID, userID int64) (domain.UserPermissions, error) {
+	roles, err := uc.userRoleRepo.GetUserRolesInRestaurant(ctx, userID, tenantID, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := domain.UserPermissions{}
+	for i := range roles {
+		granted, err := rolePermissions(ctx, uc.rolePermissionRepo, uc.moduleDefRepo, &roles[i])
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range granted {
+			permissions.Grant(p.ModuleID, p.PermissionLevel)
+		}
+	}
+	return permissions, nil
+}
+
+// HasPermission reports whether the user's roles in the restaurant grant at least level on
+// the module
+func (uc *PermissionUseCase) HasPermission(ctx context.Context, tenantID, restaurantID, userID, moduleID int64, level string) (bool, error) {
+	permissions, err := uc.GetUserPermissions(ctx, tenantID, restaurantID, userID)
+	if err != nil {
+		return false, err
+	}
+	return permissions.Allows(moduleID, level), nil
+}
+
+// ListAuditLogs returns the tenant's permission changes, newest first
+func (uc *PermissionUseCase) ListAuditLogs(ctx context.Context, tenantID int64, limit int) ([]domain.PermissionAuditLog, error) {
+	logs, err := uc.auditLogRepo.GetByTenant(ctx, tenantID, limit)
+	if logs == nil && err == nil {
+		logs = []domain.PermissionAuditLog{}
+	}
+	return logs, err
+}
+
+// UserRoleUseCase assigns roles to the tenant's users, in every restaurant or in some
+type UserRoleUseCase struct {
+	userRoleRepo *repository.UserRoleRepository
+	roleRepo     *repository.RoleRepository
+	userRepo     *repository.UserRepository
+	permissions  *PermissionUseCase
+	auditLogRepo *repository.PermissionAuditLogRepository
+}
+
+// NewUserRoleUseCase creates new user role use case
+func NewUserRoleUseCase(
+	userRoleRepo *repository.UserRoleRepository,
+	roleRepo *repository.RoleRepository,
+	userRepo *repository.UserRepository,
+	permissions *PermissionUseCase,
+	auditLogRepo *repository.PermissionAuditLogRepository,
+) *UserRoleUseCase {
+	return &UserRoleUseCase{
+		userRoleRepo: userRoleRepo,
+		roleRepo:     roleRepo,
+		userRepo:     userRepo,
+		permissions:  permissions,
+		auditLogRepo: auditLogRepo,
+	}
+}
+
+// GetUserRoles returns the roles of one of the tenant's users
+func (uc *UserRoleUseCase) GetUserRoles(ctx context.Context, tenantID, userID int64) ([]domain.Role, error) {
+	if _, err := uc.tenantUser(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+	return uc.userRoleRepo.GetUserRoles(ctx, userID, tenantID)
+}
+
+// GetRestaurantRoles returns the roles a user holds in one restaurant
+func (uc *UserRoleUseCase) GetRestaurantRoles(ctx context.Context, tenantID, restaurantID, userID int64) ([]domain.Role, error) {
+	return uc.userRoleRepo.GetUserRolesInRestaurant(ctx, userID, tenantID, restaurantID)
+}
+
+// GetUserRoleAssignments returns a user's role assignments and the restaurant each is limited to
+func (uc *UserRoleUseCase) GetUserRoleAssignments(ctx context.Context, tenantID, userID int64) ([]domain.UserRole, error) {
+	if _, err := uc.tenantUser(ctx, tenantID, userID); err != nil {
+		return nil, err
+	}
+	return uc.userRoleRepo.GetUserRoleAssignments(ctx, tenantID, userID)
+}
+
+// GetUserRolesByEmail returns the roles of the tenant's user with this email
+func (uc *UserRoleUseCase) GetUserRolesByEmail(ctx context.Context, tenantID int64, email string) ([]domain.Role, error) {
+	user, err := uc.userByEmail(ctx, tenantID, email)
+	if err != nil {
+		return nil, err
+	}
+	return uc.userRoleRepo.GetUserRoles(ctx, int64(user.ID), tenantID)
+}
+
+// AssignRole gives a user a role in every restaurant, or only in the requested restaurants.
+// Assigning a role the user already has there is a no-op.
+func (uc *UserRoleUseCase) AssignRole(ctx context.Context, actor domain.PermissionActor, userID int64, req *domain.AssignUserRoleRequest) error {
+	if _, err := uc.tenantUser(ctx, actor.TenantID, userID); err != nil {
+		return err
+	}
+	return uc.assignScoped(ctx, actor, userID, req)
+}
+
+// AssignRoleByEmail gives the tenant's user with this email a role
+func (uc *UserRoleUseCase) AssignRoleByEmail(ctx context.Context, actor domain.PermissionActor, email string, req *domain.AssignUserRoleRequest) error {
+	user, err := uc.userByEmail(ctx, actor.TenantID, email)
+	if err != nil {
+		return err
+	}
+	return uc.assignScoped(ctx, actor, int64(user.ID), req)
+}
+
+// RemoveRole takes a role away from a user in one restaurant, or everywhere when
+// restaurantID is nil. Removing a role the user does not have is a no-op, and the last
+// tenant-wide holder of the ADMIN role keeps it.
+func (uc *UserRoleUseCase) RemoveRole(ctx context.Context, actor domain.PermissionActor, userID, roleID int64, restaurantID *int64) error {
+	if _, err := uc.tenantUser(ctx, actor.TenantID, userID); err != nil {
+		return err
+	}
+	return uc.remove(ctx, actor, userID, roleID, restaurantID)
+}
+
+// RemoveRoleByEmail takes a role away from the tenant's user with this email
+func (uc *UserRoleUseCase) RemoveRoleByEmail(ctx context.Context, actor domain.PermissionActor, email string, roleID int64, restaurantID *int64) error {
+	user, err := uc.userByEmail(ctx, actor.TenantID, email)
+	if err != nil {
+		return err
+	}
+	return uc.remove(ctx, actor, int64(user.ID), roleID, restaurantID)
+}
+
+// assignScoped checks the request and the actor's reach, then assigns the role tenant-wide
+// or in each requested restaurant
+func (uc *UserRoleUseCase) assignScoped(ctx context.Context, actor domain.PermissionActor, userID int64, req *domain.AssignUserRoleRequest) error {
+	if err := req.Validate(); err != nil {
+		return err
+	}
+	role, err := tenantRole(uc.roleRepo, actor.TenantID, req.RoleID)
+	if err != nil {
+		return err
+	}
+	if len(req.RestaurantIDs) == 0 {
+		if err := uc.checkScope(ctx, actor, nil, domain.PermissionWrite); err != nil {
+			return err
+		}
+		if err := uc.checkGrant(ctx, actor, role, nil); err != nil {
+			return err
+		}
+		return uc.assign(ctx, actor, userID, req.RoleID, nil)
+	}
+	for _, restaurantID := range req.RestaurantIDs {
+		if err := uc.checkScope(ctx, actor, &restaurantID, domain.PermissionWrite); err != nil {
+			return err
+		}
+		if err := uc.checkGrant(ctx, actor, role, &restaurantID); err != nil {
+			return err
+		}
+	}
+	for i := range req.RestaurantIDs {
+		if err := uc.assign(ctx, actor, userID, req.RoleID, &req.RestaurantIDs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkScope makes sure the actor manages roles where an assignment applies: through a
+// tenant-wide role for tenant-wide assignments, otherwise in the assignment's restaurant.
+// This keeps a manager of one restaurant from granting roles in the others.
+func (uc *UserRoleUseCase) checkScope(ctx context.Context, actor domain.PermissionActor, restaurantID *int64, level string) error {
+	var scope int64
+	if restaurantID != nil {
+		ok, err := uc.userRoleRepo.IsTenantRestaurant(ctx, actor.TenantID, *restaurantID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return domain.ErrRestaurantNotFound
+		}
+		scope = *restaurantID
+	}
+	allowed, err := uc.permissions.HasPermission(ctx, actor.TenantID, scope, actor.UserID, domain.RolesModuleID, level)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return domain.ErrPermissionDenied
+	}
+	return nil
+}
+
+// checkGrant refuses a role that grants more than the actor's own permissions where it
+// applies, so managing roles is not a way to give anyone, including oneself, more access,
+// such as the full access ADMIN role
+func (uc *UserRoleUseCase) checkGrant(ctx context.Context, actor domain.PermissionActor, role *domain.Role, restaurantID *int64) error {
+	var scope int64
+	if restaurantID != nil {
+		scope = *restaurantID
+	}
+	own, err := uc.permissions.GetUserPermissions(ctx, actor.TenantID, scope, actor.UserID)
+	if err != nil {
+		return err
+	}
+	granted, err := rolePermissions(ctx, uc.permissions.rolePermissionRepo, uc.permissions.moduleDefRepo, role)
+	if err != nil {
+		return err
+	}
+	for _, p := range granted {
+		if !own.Allows(p.ModuleID, p.PermissionLevel) {
+			return fmt.Errorf("%w: role %s grants %s on module %d, above your own permissions",
+				domain.ErrPermissionDenied, role.RoleName, p.PermissionLevel, p.ModuleID)
+		}
+	}
+	return nil
+}
+
+// assign gives the role in one restaurant, or in every restaurant when restaurantID is nil
+func (uc *UserRoleUseCase) assign(ctx context.Context, actor domain.PermissionActor, userID, roleID int64, restaurantID *int64) error {
+	role, err := tenantRole(uc.roleRepo, actor.TenantID, roleID)
+	if err != nil {
+		return err
+	}
+	has, err := uc.userRoleRepo.HasRole(ctx, actor.TenantID, userID, roleID, restaurantID)
+	if err != nil || has {
+		return err
+	}
+	if err := uc.userRoleRepo.Assign(ctx, actor.TenantID, userID, roleID, restaurantID, actor.UserID); err != nil {
+		return err
+	}
+
+	entry := actor.AuditLog(domain.ActionAssignUserRole)
+	entry.UserID = int64Ptr(userID)
+	entry.RoleID = int64Ptr(roleID)
+	entry.Details.NewValue = role.RoleName
+	if restaurantID != nil {
+		entry.Details.Extra = map[string]interface{}{"restaurant_id": *restaurantID}
+	}
+	logPermissionChange(ctx, uc.auditLogRepo, entry)
+	return nil
+}
+
+func (uc *UserRoleUseCase) remove(ctx context.Context, actor domain.PermissionActor, userID, roleID int64, restaurantID *int64) error {
+	role, err := tenantRole(uc.roleRepo, actor.TenantID, roleID)
+	if err != nil {
+		return err
+	}
+	if err := uc.checkScope(ctx, actor, restaurantID, domain.PermissionDelete); err != nil {
+		return err
+	}
+	return uc.revoke(ctx, actor, userID, role, restaurantID)
+}
+
+// revoke takes the role away in one restaurant, or everywhere when restaurantID is nil.
+// The last tenant-wide holder of a full access role keeps it.
+func (uc *UserRoleUseCase) revoke(ctx context.Context, actor domain.PermissionActor, userID int64, role *domain.Role, restaurantID *int64) error {
+	roleID := int64(role.ID)
+	if role.HasFullAccess() && restaurantID == nil {
+		tenantWide, err := uc.userRoleRepo.HasRole(ctx, actor.TenantID, userID, roleID, nil)
+		if err != nil {
+			return err
+		}
+		if tenantWide {
+			admins, err := uc.userRoleRepo.GetUsersWithRole(ctx, actor.TenantID, roleID)
+			if err != nil {
+				return err
+			}
+			if len(admins) <= 1 {
+				return domain.ErrLastAdministrator
+			}
+		}
+	}
+	removed, err := uc.userRoleRepo.Remove(ctx, actor.TenantID, userID, roleID, restaurantID)
+	if err != nil || removed == 0 {
+		return err
+	}
+
+	entry := actor.AuditLog(domain.ActionRevokeUserRole)
+	entry.UserID = int64Ptr(userID)
+	entry.RoleID = int64Ptr(roleID)
+	entry.Details.OldValue = role.RoleName
+	if restaurantID != nil {
+		entry.Details.Extra = map[string]interface{}{"restaurant_id": *restaurantID}
+	}
+	logPermissionChange(ctx, uc.auditLogRepo, entry)
+	return nil
+}
+
+func (uc *UserRoleUseCase) tenantUser(ctx context.Context, tenantID, userID int64) (*domain.User, error) {
+	user, err := uc.userRepo.GetByID(ctx, int(userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrRBACUserNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if int64(user.TenantID) != tenantID {
+		return nil, domain.ErrRBACUserNotFound
+	}
+	return user, nil
+}
+
+func (uc *UserRoleUseCase) userByEmail(ctx context.Context, tenantID int64, email string) (*domain.User, error) {
+	user, err := uc.userRepo.GetByEmailInTenant(ctx, tenantID, email)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, domain.ErrRBACUserNotFound
+	}
+	return user, nil
+}
@@ -0,0 +1,172 @@
+package usecase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+	"time"
+)
+
+// ReviewUseCase handles customer feedback, product reviews and moderation
+type ReviewUseCase struct {
+	feedbackRepo *repository.FeedbackRepository
+	orderRepo    *repository.OrderRepository
+}
+
+// NewReviewUseCase creates new review use case
+func NewReviewUseCase(feedbackRepo *repository.FeedbackRepository, orderRepo *repository.OrderRepository) *ReviewUseCase {
+	return &ReviewUseCase{
+		feedbackRepo: feedbackRepo,
+		orderRepo:    orderRepo,
+	}
+}
+
+// generateReviewToken creates a random, URL-safe review token
+func generateReviewToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate review token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// requestReview creates the review request for a delivered order
+func requestReview(feedbackRepo *repository.FeedbackRepository, order *domain.Order) (*domain.ReviewRequest, error) {
+	token, err := generateReviewToken()
+	if err != nil {
+		return nil, err
+	}
+
+	return feedbackRepo.CreateReviewRequest(&domain.ReviewRequest{
+		TenantID:      order.TenantID,
+		RestaurantID:  order.RestaurantID,
+		OrderID:       order.ID,
+		Token:         token,
+		CustomerName:  order.CustomerName,
+		CustomerEmail: order.CustomerEmail,
+		CustomerPhone: order.CustomerPhone,
+		ExpiresAt:     time.Now().Add(domain.ReviewRequestTTL),
+	})
+}
+
+// loadRequest returns a review request that can still receive feedback
+func (uc *ReviewUseCase) loadRequest(token string) (*domain.ReviewRequest, *domain.Order, error) {
+	request, err := uc.feedbackRepo.GetReviewRequestByToken(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	order, err := uc.orderRepo.GetOrderByID(request.TenantID, request.RestaurantID, request.OrderID)
+	if err != nil {
+		return nil, nil, domain.ErrReviewRequestNotFound
+	}
+	return request, order, nil
+}
+
+// GetReviewRequest returns the order summary behind a review link
+func (uc *ReviewUseCase) GetReviewRequest(token string) (*domain.ReviewRequestView, error) {
+	request, order, err := uc.loadRequest(token)
+	if err != nil {
+		return nil, err
+	}
+
+	view := &domain.ReviewRequestView{
+		OrderNumber:  order.OrderNumber,
+		CustomerName: order.CustomerName,
+		Status:       request.Status,
+		ExpiresAt:    request.ExpiresAt,
+		Items:        order.Items,
+	}
+	if request.Status == domain.ReviewRequestPending && time.Now().After(request.ExpiresAt) {
+		view.Status = domain.ReviewRequestExpired
+	}
+	if request.Status == domain.ReviewRequestCompleted {
+		feedback, err := uc.feedbackRepo.GetOrderFeedback(order.ID)
+		if err == nil {
+			view.Feedback = feedback
+		}
+	}
+	return view, nil
+}
+
+// SubmitFeedback records the customer's ratings for the order, its items and the delivery
+func (uc *ReviewUseCase) SubmitFeedback(token string, req *domain.SubmitFeedbackRequest) (*domain.OrderFeedback, error) {
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("feedback validation failed: %w", err)
+	}
+
+	request, order, err := uc.loadRequest(token)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != domain.ReviewRequestPending {
+		return nil, domain.ErrReviewRequestUsed
+	}
+	if time.Now().After(request.ExpiresAt) {
+		return nil, domain.ErrReviewRequestExpired
+	}
+
+	feedback := &domain.OrderFeedback{
+		TenantID:        order.TenantID,
+		RestaurantID:    order.RestaurantID,
+		OrderID:         order.ID,
+		OverallRating:   req.OverallRating,
+		FoodRating:      req.FoodRating,
+		DeliveryRating:  req.DeliveryRating,
+		Comment:         req.Comment,
+		DeliveryComment: req.DeliveryComment,
+	}
+
+	// Only delivery orders have a driver to rate
+	if order.OrderSource == domain.OrderSourceDineIn {
+		feedback.DeliveryRating = nil
+		feedback.DeliveryComment = ""
+	}
+
+	reviews := []domain.ProductReview{}
+	for _, item := range domain.ReviewableItems(order, req.Items) {
+		reviews = append(reviews, domain.ProductReview{
+			TenantID:     order.TenantID,
+			RestaurantID: order.RestaurantID,
+			ProductID:    item.ProductID,
+			OrderID:      order.ID,
+			CustomerName: order.CustomerName,
+			Rating:       item.Rating,
+			Comment:      item.Comment,
+		})
+	}
+
+	if err := uc.feedbackRepo.SubmitFeedback(request, feedback, reviews); err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// ListReviews lists product reviews for moderation
+func (uc *ReviewUseCase) ListReviews(tenantID, restaurantID int64, filters *domain.ReviewListFilters) ([]domain.ProductReview, int, error) {
+	if filters.Status != "" && !domain.ValidReviewStatus(filters.Status) {
+		return nil, 0, errors.New("invalid review status")
+	}
+	return uc.feedbackRepo.ListReviews(tenantID, restaurantID, filters)
+}
+
+// ModerateReview approves or hides a product review
+func (uc *ReviewUseCase) ModerateReview(tenantID, restaurantID, reviewID int64, status string, moderatedBy int64) (*domain.ProductReview, error) {
+	if status != domain.ReviewStatusApproved && status != domain.ReviewStatusHidden {
+		return nil, errors.New("invalid review status")
+	}
+	return uc.feedbackRepo.ModerateReview(tenantID, restaurantID, reviewID, status, moderatedBy)
+}
+
+// ListProductReviews returns the approved reviews of a product for the public menu
+func (uc *ReviewUseCase) ListProductReviews(restaurantID, productID int64, limit int) ([]domain.ProductReview, error) {
+	return uc.feedbackRepo.ListApprovedProductReviews(restaurantID, productID, limit)
+}
+
+// GetRatingSummary returns the restaurant-level rating summary
+func (uc *ReviewUseCase) GetRatingSummary(restaurantID int64) (*domain.RatingSummary, error) {
+	return uc.feedbackRepo.GetRatingSummary(restaurantID)
+}
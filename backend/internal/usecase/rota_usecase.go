@@ -0,0 +1,435 @@
+package usecase
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/repository"
+	"time"
+)
+
+// RotaUseCase handles shift templates, staffing requirements and weekly rotas
+type RotaUseCase struct {
+	rotaRepo         *repository.RotaRepository
+	employeeRepo     *repository.EmployeeRepository
+	leaveRepo        *repository.LeaveRepository
+	notificationRepo *repository.NotificationRepository
+	attendanceUC     *AttendanceUseCase
+	auditRepo        *repository.HRAuditRepository
+}
+
+// NewRotaUseCase creates new rota use case
+func NewRotaUseCase(
+	rotaRepo *repository.RotaRepository,
+	employeeRepo *repository.EmployeeRepository,
+	leaveRepo *repository.LeaveRepository,
+	notificationRepo *repository.NotificationRepository,
+	attendanceUC *AttendanceUseCase,
+	auditRepo *repository.HRAuditRepository,
+) *RotaUseCase {
+	return &RotaUseCase{
+		rotaRepo:         rotaRepo,
+		employeeRepo:     employeeRepo,
+		leaveRepo:        leaveRepo,
+		notificationRepo: notificationRepo,
+		attendanceUC:     attendanceUC,
+		auditRepo:        auditRepo,
+	}
+}
+
+// ListShiftTemplates returns the restaurant's shift templates
+func (uc *RotaUseCase) ListShiftTemplates(tenantID, restaurantID int) ([]domain.ShiftTemplate, error) {
+	return uc.rotaRepo.ListShiftTemplates(tenantID, restaurantID)
+}
+
+// CreateShiftTemplate creates a shift template
+func (uc *RotaUseCase) CreateShiftTemplate(tenantID, restaurantID int, req *domain.ShiftTemplateRequest, userID int) (*domain.ShiftTemplate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	created, err := uc.rotaRepo.CreateShiftTemplate(newShiftTemplate(tenantID, restaurantID, req))
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditShiftTemplate, created.ID, created.Name, domain.AuditCreate, nil, created, userID)
+	return created, nil
+}
+
+// UpdateShiftTemplate updates a shift template
+func (uc *RotaUseCase) UpdateShiftTemplate(tenantID, restaurantID, id int, req *domain.ShiftTemplateRequest, userID int) (*domain.ShiftTemplate, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	before, err := uc.rotaRepo.GetShiftTemplate(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	template := newShiftTemplate(tenantID, restaurantID, req)
+	template.ID = id
+	updated, err := uc.rotaRepo.UpdateShiftTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditShiftTemplate, id, updated.Name, domain.AuditUpdate, before, updated, userID)
+	return updated, nil
+}
+
+// DeleteShiftTemplate deletes a shift template
+func (uc *RotaUseCase) DeleteShiftTemplate(tenantID, restaurantID, id, userID int) error {
+	before, err := uc.rotaRepo.GetShiftTemplate(tenantID, restaurantID, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.rotaRepo.DeleteShiftTemplate(tenantID, restaurantID, id); err != nil {
+		return err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditShiftTemplate, id, before.Name, domain.AuditDelete, before, nil, userID)
+	return nil
+}
+
+// ListStaffingRequirements returns the restaurant's staffing requirements
+func (uc *RotaUseCase) ListStaffingRequirements(tenantID, restaurantID int) ([]domain.StaffingRequirement, error) {
+	return uc.rotaRepo.ListStaffingRequirements(tenantID, restaurantID)
+}
+
+// CreateStaffingRequirement creates a staffing requirement
+func (uc *RotaUseCase) CreateStaffingRequirement(tenantID, restaurantID int, req *domain.StaffingRequirementRequest, userID int) (*domain.StaffingRequirement, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	created, err := uc.rotaRepo.CreateStaffingRequirement(&domain.StaffingRequirement{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		RoleID:       req.RoleID,
+		DayOfWeek:    req.DayOfWeek,
+		StartTime:    domain.FormatClock(req.StartTime),
+		EndTime:      domain.FormatClock(req.EndTime),
+		MinStaff:     req.MinStaff,
+	})
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditStaffingRequirement, created.ID, "", domain.AuditCreate, nil, created, userID)
+	return created, nil
+}
+
+// DeleteStaffingRequirement deletes a staffing requirement
+func (uc *RotaUseCase) DeleteStaffingRequirement(tenantID, restaurantID, id, userID int) error {
+	if err := uc.rotaRepo.DeleteStaffingRequirement(tenantID, restaurantID, id); err != nil {
+		return err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditStaffingRequirement, id, "", domain.AuditDelete, nil, nil, userID)
+	return nil
+}
+
+// ListRotas returns the rotas of weeks starting between two dates
+func (uc *RotaUseCase) ListRotas(tenantID, restaurantID int, from, to time.Time) ([]domain.Rota, error) {
+	return uc.rotaRepo.ListRotas(tenantID, restaurantID, from, to)
+}
+
+// CreateRota starts a draft rota for the week containing the requested date
+func (uc *RotaUseCase) CreateRota(tenantID, restaurantID int, req *domain.CreateRotaRequest, userID int) (*domain.Rota, error) {
+	date, err := time.ParseInLocation(domain.DateLayout, req.WeekStart, time.Local)
+	if err != nil {
+		return nil, errors.New("invalid week_start, expected YYYY-MM-DD")
+	}
+
+	rota, err := uc.rotaRepo.CreateRota(&domain.Rota{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		WeekStart:    domain.WeekStartOf(date),
+		Notes:        req.Notes,
+		CreatedBy:    &userID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditRota, rota.ID, rotaName(rota), domain.AuditCreate, nil, rota, userID)
+	return rota, nil
+}
+
+// GetRota returns a rota with its shifts and conflicts
+func (uc *RotaUseCase) GetRota(tenantID, restaurantID, id int) (*domain.Rota, error) {
+	rota, err := uc.rotaRepo.GetRota(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if rota.Shifts, rota.Conflicts, err = uc.shiftsAndConflicts(rota); err != nil {
+		return nil, err
+	}
+	return rota, nil
+}
+
+// GetConflicts returns the scheduling conflicts of a rota
+func (uc *RotaUseCase) GetConflicts(tenantID, restaurantID, id int) ([]domain.RotaConflict, error) {
+	rota, err := uc.rotaRepo.GetRota(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	_, conflicts, err := uc.shiftsAndConflicts(rota)
+	return conflicts, err
+}
+
+// AddShift adds a shift to a draft rota
+func (uc *RotaUseCase) AddShift(tenantID, restaurantID, rotaID int, req *domain.ShiftRequest, userID int) (*domain.Shift, error) {
+	rota, err := uc.draftRota(tenantID, restaurantID, rotaID)
+	if err != nil {
+		return nil, err
+	}
+	shift, err := uc.buildShift(rota, req)
+	if err != nil {
+		return nil, err
+	}
+	created, err := uc.rotaRepo.CreateShift(shift)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditShift, created.ID, created.EmployeeName, domain.AuditCreate, nil, created, userID)
+	return created, nil
+}
+
+// UpdateShift changes a shift of a draft rota
+func (uc *RotaUseCase) UpdateShift(tenantID, restaurantID, rotaID, id int, req *domain.ShiftRequest, userID int) (*domain.Shift, error) {
+	rota, err := uc.draftRota(tenantID, restaurantID, rotaID)
+	if err != nil {
+		return nil, err
+	}
+	before, err := uc.rotaRepo.GetShift(rotaID, id)
+	if err != nil {
+		return nil, err
+	}
+	shift, err := uc.buildShift(rota, req)
+	if err != nil {
+		return nil, err
+	}
+	shift.ID = id
+	updated, err := uc.rotaRepo.UpdateShift(shift)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditShift, id, updated.EmployeeName, domain.AuditUpdate, before, updated, userID)
+	return updated, nil
+}
+
+// DeleteShift removes a shift from a draft rota
+func (uc *RotaUseCase) DeleteShift(tenantID, restaurantID, rotaID, id, userID int) error {
+	if _, err := uc.draftRota(tenantID, restaurantID, rotaID); err != nil {
+		return err
+	}
+	before, err := uc.rotaRepo.GetShift(rotaID, id)
+	if err != nil {
+		return err
+	}
+	if err := uc.rotaRepo.DeleteShift(rotaID, id); err != nil {
+		return err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditShift, id, before.EmployeeName, domain.AuditDelete, before, nil, userID)
+	return nil
+}
+
+// PublishRota publishes a rota, notifies the scheduled employees and applies the
+// shifts to any attendance already recorded for the week. Blocking conflicts stop
+// publishing unless forced; the conflicts are returned with the error.
+func (uc *RotaUseCase) PublishRota(tenantID, restaurantID, id int, req *domain.PublishRotaRequest, userID int) (*domain.PublishRotaResponse, []domain.RotaConflict, error) {
+	rota, err := uc.rotaRepo.GetRota(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	shifts, conflicts, err := uc.shiftsAndConflicts(rota)
+	if err != nil {
+		return nil, nil, err
+	}
+	if domain.HasBlockingConflicts(conflicts) && !req.Force {
+		return nil, conflicts, domain.ErrRotaHasConflicts
+	}
+
+	published, err := uc.rotaRepo.SetRotaStatus(tenantID, restaurantID, id, domain.RotaStatusPublished, &userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditRota, id, rotaName(published), domain.AuditPublish, rota, published, userID)
+	published.Shifts, published.Conflicts = shifts, conflicts
+
+	notified := uc.notifyEmployees(published, shifts)
+
+	weekEnd := published.WeekStart.AddDate(0, 0, 6)
+	if _, err := uc.attendanceUC.ApplyPublishedShifts(tenantID, restaurantID, published.WeekStart, weekEnd); err != nil {
+		log.Printf("rota %d: failed to apply shifts to attendance: %v", id, err)
+	}
+
+	return &domain.PublishRotaResponse{Rota: published, Notified: notified}, nil, nil
+}
+
+// UnpublishRota returns a published rota to draft so its shifts can be changed
+func (uc *RotaUseCase) UnpublishRota(tenantID, restaurantID, id, userID int) (*domain.Rota, error) {
+	before, err := uc.rotaRepo.GetRota(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	rota, err := uc.rotaRepo.SetRotaStatus(tenantID, restaurantID, id, domain.RotaStatusDraft, nil)
+	if err != nil {
+		return nil, err
+	}
+	recordHRChange(uc.auditRepo, tenantID, restaurantID, domain.AuditRota, id, rotaName(rota), domain.AuditUnpublish, before, rota, userID)
+	return rota, nil
+}
+
+// ListEmployeeShifts returns an employee's published shifts between two dates
+func (uc *RotaUseCase) ListEmployeeShifts(tenantID, restaurantID, employeeID int, from, to time.Time) ([]domain.Shift, error) {
+	if _, err := uc.employee(tenantID, restaurantID, employeeID); err != nil {
+		return nil, err
+	}
+	return uc.rotaRepo.ListPublishedShifts(tenantID, restaurantID, employeeID, from, to)
+}
+
+// shiftsAndConflicts loads a rota's shifts and checks them against leave, hours and staffing
+func (uc *RotaUseCase) shiftsAndConflicts(rota *domain.Rota) ([]domain.Shift, []domain.RotaConflict, error) {
+	shifts, err := uc.rotaRepo.ListShifts(rota.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	employees, err := uc.employeeRepo.ListEmployees(rota.TenantID, rota.RestaurantID)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[int]*domain.Employee, len(employees))
+	for i := range employees {
+		byID[employees[i].ID] = &employees[i]
+	}
+
+	leaves, err := uc.leaveRepo.ListApprovedLeaves(rota.TenantID, rota.RestaurantID, rota.WeekStart, rota.WeekStart.AddDate(0, 0, 6))
+	if err != nil {
+		return nil, nil, err
+	}
+	requirements, err := uc.rotaRepo.ListStaffingRequirements(rota.TenantID, rota.RestaurantID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return shifts, domain.DetectRotaConflicts(rota.WeekStart, shifts, byID, leaves, requirements), nil
+}
+
+// draftRota loads a rota that can still be edited
+func (uc *RotaUseCase) draftRota(tenantID, restaurantID, id int) (*domain.Rota, error) {
+	rota, err := uc.rotaRepo.GetRota(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if rota.Status == domain.RotaStatusPublished {
+		return nil, domain.ErrRotaPublished
+	}
+	return rota, nil
+}
+
+// buildShift validates a shift request against the rota week, the employee and the template
+func (uc *RotaUseCase) buildShift(rota *domain.Rota, req *domain.ShiftRequest) (*domain.Shift, error) {
+	var template *domain.ShiftTemplate
+	if req.TemplateID != nil {
+		var err error
+		if template, err = uc.rotaRepo.GetShiftTemplate(rota.TenantID, rota.RestaurantID, *req.TemplateID); err != nil {
+			return nil, err
+		}
+	}
+
+	shift, err := domain.NewShift(req, template, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	if shift.ShiftDate.Before(rota.WeekStart) || shift.ShiftDate.After(rota.WeekStart.AddDate(0, 0, 6)) {
+		return nil, domain.ErrShiftOutsideRota
+	}
+	if _, err := uc.employee(rota.TenantID, rota.RestaurantID, shift.EmployeeID); err != nil {
+		return nil, err
+	}
+
+	shift.TenantID, shift.RestaurantID, shift.RotaID = rota.TenantID, rota.RestaurantID, rota.ID
+	return shift, nil
+}
+
+// employee loads an employee of the restaurant
+func (uc *RotaUseCase) employee(tenantID, restaurantID, id int) (*domain.Employee, error) {
+	emp, err := uc.employeeRepo.GetEmployeeByID(tenantID, restaurantID, id)
+	if err != nil {
+		return nil, err
+	}
+	if emp == nil {
+		return nil, fmt.Errorf("employee %d not found", id)
+	}
+	return emp, nil
+}
+
+// notifyEmployees tells every scheduled employee with a user account that the rota is out.
+// Failures are logged; returns the number of employees notified.
+func (uc *RotaUseCase) notifyEmployees(rota *domain.Rota, shifts []domain.Shift) int {
+	counts := map[int]int{}
+	employeeIDs := []int{}
+	for _, s := range shifts {
+		if counts[s.EmployeeID] == 0 {
+			employeeIDs = append(employeeIDs, s.EmployeeID)
+		}
+		counts[s.EmployeeID]++
+	}
+	if len(employeeIDs) == 0 {
+		return 0
+	}
+
+	users, err := uc.employeeRepo.GetEmployeeUserIDs(rota.TenantID, employeeIDs)
+	if err != nil {
+		log.Printf("rota %d: failed to resolve employee accounts: %v", rota.ID, err)
+		return 0
+	}
+
+	entityType := "rota"
+	week := rota.WeekStart.Format(domain.DateLayout)
+	notified := 0
+	for _, employeeID := range employeeIDs {
+		userID, ok := users[employeeID]
+		if !ok {
+			continue
+		}
+		_, err := uc.notificationRepo.CreateNotification(&domain.Notification{
+			TenantID:          rota.TenantID,
+			RestaurantID:      rota.RestaurantID,
+			UserID:            userID,
+			Type:              domain.NotificationTypeShift,
+			Module:            domain.ModuleHR,
+			Title:             "Your rota has been published",
+			Message:           fmt.Sprintf("You have %d shift(s) in the week starting %s", counts[employeeID], week),
+			RelatedEntityType: &entityType,
+			RelatedEntityID:   &rota.ID,
+			Priority:          domain.PriorityNormal,
+		})
+		if err != nil {
+			log.Printf("rota %d: failed to notify employee %d: %v", rota.ID, employeeID, err)
+			continue
+		}
+		notified++
+	}
+	return notified
+}
+
+// newShiftTemplate builds a shift template from a request; templates are active unless stated
+// rotaName names a rota in the audit trail by its week
+func rotaName(rota *domain.Rota) string {
+	return "Week of " + rota.WeekStart.Format(domain.DateLayout)
+}
+
+func newShiftTemplate(tenantID, restaurantID int, req *domain.ShiftTemplateRequest) *domain.ShiftTemplate {
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	return &domain.ShiftTemplate{
+		TenantID:     tenantID,
+		RestaurantID: restaurantID,
+		Name:         req.Name,
+		StartTime:    domain.FormatClock(req.StartTime),
+		EndTime:      domain.FormatClock(req.EndTime),
+		BreakMinutes: req.BreakMinutes,
+		RoleID:       req.RoleID,
+		Color:        req.Color,
+		IsActive:     isActive,
+	}
+}
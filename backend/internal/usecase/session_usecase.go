@@ -0,0 +1,281 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/pkg/jwt"
+	"pos-saas/internal/repository"
+)
+
+// SessionUseCase opens user sessions, rotates their refresh tokens and revokes them
+type SessionUseCase struct {
+	sessionRepo  *repository.SessionRepository
+	userRepo     *repository.UserRepository
+	userRoleRepo *repository.UserRoleRepository
+	tokenService *jwt.TokenService
+	refreshTTL   time.Duration
+}
+
+// NewSessionUseCase creates new session use case. refreshTTL is the lifetime of a refresh
+// token; each rotation extends the session by the same amount.
+func NewSessionUseCase(
+	sessionRepo *repository.SessionRepository,
+	userRepo *repository.UserRepository,
+	userRoleRepo *repository.UserRoleRepository,
+	tokenService *jwt.TokenService,
+	refreshTTL time.Duration,
+) *SessionUseCase {
+	if refreshTTL <= 0 {
+		refreshTTL = domain.DefaultRefreshTokenTTL
+	}
+	return &SessionUseCase{
+		sessionRepo:  sessionRepo,
+		userRepo:     userRepo,
+		userRoleRepo: userRoleRepo,
+		tokenService: tokenService,
+		refreshTTL:   refreshTTL,
+	}
+}
+
+// newSecureToken returns a random opaque token for refresh and account tokens
+func newSecureToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// StartSession opens a session for a signed-in user and returns its access and refresh tokens
+func (uc *SessionUseCase) StartSession(ctx context.Context, user *domain.User, client domain.SessionClient) (*domain.AuthResponse, error) {
+	refreshToken, err := newSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	client = client.Normalize()
+	session := &domain.UserSession{
+		ID:         uuid.NewString(),
+		TenantID:   user.TenantID,
+		UserID:     user.ID,
+		DeviceName: client.DeviceName,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		ExpiresAt:  time.Now().Add(uc.refreshTTL),
+	}
+	if err := uc.sessionRepo.CreateSession(ctx, session, domain.HashToken(refreshToken)); err != nil {
+		return nil, err
+	}
+
+	return uc.authResponse(user, session.ID, refreshToken)
+}
+
+func (uc *SessionUseCase) authResponse(user *domain.User, sessionID, refreshToken string) (*domain.AuthResponse, error) {
+	token, err := uc.tokenService.GenerateSessionToken(user.ID, user.TenantID, user.RestaurantID, user.Email, user.Role, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate token: %w", err)
+	}
+
+	// Clear sensitive data
+	user.PasswordHash = ""
+
+	return &domain.AuthResponse{
+		User:         user,
+		Token:        token,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(uc.tokenService.Expiry().Seconds()),
+		SessionID:    sessionID,
+	}, nil
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token.
+// The presented token is spent; presenting it again revokes the session.
+func (uc *SessionUseCase) Refresh(ctx context.Context, refreshToken string, client domain.SessionClient) (*domain.AuthResponse, error) {
+	if refreshToken == "" {
+		return nil, errors.New("refresh_token is required")
+	}
+
+	newToken, err := newSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
+	client = client.Normalize()
+	session, err := uc.sessionRepo.RotateRefreshToken(ctx,
+		domain.HashToken(refreshToken), domain.HashToken(newToken),
+		time.Now().Add(uc.refreshTTL), client)
+	if errors.Is(err, domain.ErrRefreshTokenReused) {
+		log.Printf("sessions: refresh token reuse detected, session revoked (ip %s)", client.IPAddress)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// The token carries the user's current role and restaurant, and a disabled user loses the session
+	user, err := uc.userRepo.GetByID(ctx, session.UserID)
+	if err != nil || user.Status != "active" || user.TenantID != session.TenantID {
+		if revokeErr := uc.sessionRepo.RevokeSession(ctx, session.TenantID, session.UserID, session.ID, domain.SessionRevokedUserDisabled); revokeErr != nil {
+			log.Printf("sessions: failed to revoke session %s of unavailable user %d: %v", session.ID, session.UserID, revokeErr)
+		}
+		return nil, domain.ErrInvalidRefreshToken
+	}
+
+	// Keep the restaurant the session switched to while the user still has a role there
+	if session.RestaurantID != nil {
+		restaurants, err := uc.accessibleRestaurants(ctx, user)
+		if err != nil {
+			return nil, err
+		}
+		if findRestaurant(restaurants, *session.RestaurantID) != nil {
+			user.RestaurantID = session.RestaurantID
+		}
+	}
+
+	return uc.authResponse(user, session.ID, newToken)
+}
+
+// Restaurants lists the restaurants the user can switch to and marks the current one
+func (uc *SessionUseCase) Restaurants(ctx context.Context, tenantID, userID, currentRestaurantID int) ([]domain.RestaurantAccess, error) {
+	user, err := uc.activeUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	restaurants, err := uc.accessibleRestaurants(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	for i := range restaurants {
+		restaurants[i].Current = restaurants[i].ID == currentRestaurantID
+	}
+	return restaurants, nil
+}
+
+// SwitchRestaurant issues an access token for another restaurant of the tenant in which the
+// user has a role. The session remembers the restaurant, so refreshed tokens keep it.
+func (uc *SessionUseCase) SwitchRestaurant(ctx context.Context, tenantID, userID int, sessionID string, restaurantID int) (*domain.AuthResponse, error) {
+	if restaurantID <= 0 {
+		return nil, errors.New("restaurant_id is required")
+	}
+	user, err := uc.activeUser(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	restaurants, err := uc.accessibleRestaurants(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	if findRestaurant(restaurants, restaurantID) == nil {
+		return nil, domain.ErrRestaurantAccess
+	}
+
+	if sessionID != "" {
+		if err := uc.sessionRepo.SetRestaurant(ctx, sessionID, userID, restaurantID); err != nil {
+			return nil, err
+		}
+	}
+	user.RestaurantID = &restaurantID
+	return uc.authResponse(user, sessionID, "")
+}
+
+// activeUser loads an active user of the tenant
+func (uc *SessionUseCase) activeUser(ctx context.Context, tenantID, userID int) (*domain.User, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && (user.TenantID != tenantID || user.Status != "active")) {
+		return nil, domain.ErrRBACUserNotFound
+	}
+	return user, err
+}
+
+// accessibleRestaurants lists the restaurants the user has a role in, and their default one
+func (uc *SessionUseCase) accessibleRestaurants(ctx context.Context, user *domain.User) ([]domain.RestaurantAccess, error) {
+	var defaultRestaurantID int64
+	if user.RestaurantID != nil {
+		defaultRestaurantID = int64(*user.RestaurantID)
+	}
+	return uc.userRoleRepo.GetAccessibleRestaurants(ctx, int64(user.TenantID), int64(user.ID), defaultRestaurantID)
+}
+
+func findRestaurant(restaurants []domain.RestaurantAccess, id int) *domain.RestaurantAccess {
+	for i := range restaurants {
+		if restaurants[i].ID == id {
+			return &restaurants[i]
+		}
+	}
+	return nil
+}
+
+// Logout revokes the session a refresh token belongs to. Unknown tokens are ignored.
+func (uc *SessionUseCase) Logout(ctx context.Context, refreshToken string) error {
+	if refreshToken == "" {
+		return errors.New("refresh_token is required")
+	}
+
+	session, err := uc.sessionRepo.GetSessionByRefreshToken(ctx, domain.HashToken(refreshToken))
+	if err != nil {
+		return err
+	}
+	if session == nil || session.RevokedAt != nil {
+		return nil
+	}
+
+	err = uc.sessionRepo.RevokeSession(ctx, session.TenantID, session.UserID, session.ID, domain.SessionRevokedLogout)
+	if errors.Is(err, domain.ErrSessionNotFound) {
+		return nil
+	}
+	return err
+}
+
+// ValidateSession returns domain.ErrSessionRevoked unless the session of an access token is active
+func (uc *SessionUseCase) ValidateSession(ctx context.Context, sessionID string, userID int) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return domain.ErrSessionRevoked
+	}
+	active, err := uc.sessionRepo.IsSessionActive(ctx, sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return domain.ErrSessionRevoked
+	}
+	return nil
+}
+
+// ListSessions returns the user's active sessions and marks the current one
+func (uc *SessionUseCase) ListSessions(ctx context.Context, tenantID, userID int, currentSessionID string) ([]domain.UserSession, error) {
+	sessions, err := uc.sessionRepo.ListActiveSessions(ctx, tenantID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes one of the user's sessions
+func (uc *SessionUseCase) RevokeSession(ctx context.Context, tenantID, userID int, sessionID, reason string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return domain.ErrSessionNotFound
+	}
+	return uc.sessionRepo.RevokeSession(ctx, tenantID, userID, sessionID, reason)
+}
+
+// RevokeOtherSessions signs the user out everywhere except the current session
+func (uc *SessionUseCase) RevokeOtherSessions(ctx context.Context, tenantID, userID int, currentSessionID string) (int64, error) {
+	return uc.sessionRepo.RevokeUserSessions(ctx, tenantID, userID, currentSessionID, domain.SessionRevokedByUser)
+}
+
+// RevokeAllSessions signs the user out of every session
+func (uc *SessionUseCase) RevokeAllSessions(ctx context.Context, tenantID, userID int, reason string) (int64, error) {
+	return uc.sessionRepo.RevokeUserSessions(ctx, tenantID, userID, "", reason)
+}
@@ -0,0 +1,600 @@
+package usecase
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"pos-saas/internal/domain"
+	"pos-saas/internal/pkg/oidc"
+	"pos-saas/internal/pkg/secretbox"
+	"pos-saas/internal/repository"
+)
+
+// ssoRoleReason is recorded in the permission audit log for roles changed by group mapping
+const ssoRoleReason = "sso group mapping"
+
+// SSOUseCase signs staff in through their tenant's OpenID Connect provider. Accounts are
+// created on first sign-in when the tenant allows it, and roles follow the provider's groups.
+//
+// The provider redirects back to the API, which resolves the user and then sends the browser
+// to the dashboard with a short-lived single-use code; the dashboard exchanges that code for
+// tokens so they never appear in a URL.
+type SSOUseCase struct {
+	repo           *repository.SSORepository
+	userRepo       *repository.UserRepository
+	userRoleRepo   *repository.UserRoleRepository
+	invitationRepo *repository.InvitationRepository
+	userRoles      *UserRoleUseCase
+	oidc           *oidc.Client
+	box            *secretbox.Box
+	apiURL         string
+	appURL         string
+}
+
+// NewSSOUseCase creates new SSO use case. box encrypts client secrets and PKCE verifiers;
+// apiURL is the public URL of this API, which providers redirect back to, and appURL the
+// dashboard URL users land on afterwards.
+func NewSSOUseCase(
+	repo *repository.SSORepository,
+	userRepo *repository.UserRepository,
+	userRoleRepo *repository.UserRoleRepository,
+	invitationRepo *repository.InvitationRepository,
+	userRoles *UserRoleUseCase,
+	client *oidc.Client,
+	box *secretbox.Box,
+	apiURL string,
+	appURL string,
+) *SSOUseCase {
+	if client == nil {
+		client = oidc.NewClient(nil)
+	}
+	return &SSOUseCase{
+		repo:           repo,
+		userRepo:       userRepo,
+		userRoleRepo:   userRoleRepo,
+		invitationRepo: invitationRepo,
+		userRoles:      userRoles,
+		oidc:           client,
+		box:            box,
+		apiURL:         strings.TrimRight(apiURL, "/"),
+		appURL:         strings.TrimRight(appURL, "/"),
+	}
+}
+
+// callbackURL is the redirect URI registered at every tenant's provider
+func (uc *SSOUseCase) callbackURL() string {
+	return uc.apiURL + "/api/v1/auth/sso/callback"
+}
+
+// GetConfig returns the tenant's identity provider without its client secret
+func (uc *SSOUseCase) GetConfig(ctx context.Context, tenantID int) (*domain.SSOConfig, error) {
+	cfg, err := uc.repo.GetConfig(ctx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	cfg.ClientSecret = ""
+	cfg.CallbackURL = uc.callbackURL()
+	return cfg, nil
+}
+
+// SaveConfig creates or replaces the tenant's identity provider. The issuer must be
+// reachable. Role mappings grant roles tenant-wide, so only admins who manage roles
+// tenant-wide can set them, and password login can only be turned off by an admin who has
+// signed in through the provider before.
+func (uc *SSOUseCase) SaveConfig(ctx context.Context, actor domain.PermissionActor, req *domain.SSOConfigRequest) (*domain.SSOConfig, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+	tenantID := int(actor.TenantID)
+
+	if len(req.RoleMappings) > 0 {
+		if err := uc.userRoles.checkScope(ctx, actor, nil, domain.PermissionWrite); err != nil {
+			return nil, err
+		}
+	}
+	for _, mapping := range req.RoleMappings {
+		if _, err := tenantRole(uc.userRoles.roleRepo, actor.TenantID, mapping.RoleID); err != nil {
+			return nil, err
+		}
+		if mapping.RestaurantID != nil {
+			if err := uc.checkRestaurant(ctx, actor.TenantID, *mapping.RestaurantID); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if req.DefaultRestaurantID != nil {
+		if err := uc.checkRestaurant(ctx, actor.TenantID, int64(*req.DefaultRestaurantID)); err != nil {
+			return nil, err
+		}
+	}
+
+	provider, err := uc.oidc.Discover(ctx, req.IssuerURL)
+	if err != nil {
+		log.Printf("sso: discovery of %s for tenant %d failed: %v", req.IssuerURL, tenantID, err)
+		return nil, domain.ErrSSOIssuerUnreachable
+	}
+	if req.PasswordLoginDisabled {
+		linked, err := uc.repo.HasIdentity(ctx, tenantID, int(actor.UserID), provider.Issuer)
+		if err != nil {
+			return nil, err
+		}
+		if !linked {
+			return nil, domain.ErrSSONotLinked
+		}
+	}
+
+	cfg := &domain.SSOConfig{
+		TenantID:              tenantID,
+		IssuerURL:             req.IssuerURL,
+		ClientID:              req.ClientID,
+		Scopes:                req.Scopes,
+		GroupsClaim:           req.GroupsClaim,
+		AllowedDomains:        req.AllowedDomains,
+		JITProvisioning:       req.JITProvisioning,
+		DefaultRestaurantID:   req.DefaultRestaurantID,
+		PasswordLoginDisabled: req.PasswordLoginDisabled,
+		Enabled:               req.Enabled,
+		RoleMappings:          req.RoleMappings,
+	}
+	if req.ClientSecret != "" {
+		if cfg.ClientSecret, err = uc.box.Seal(req.ClientSecret); err != nil {
+			return nil, fmt.Errorf("failed to encrypt client secret: %w", err)
+		}
+	} else {
+		existing, err := uc.repo.GetConfig(ctx, tenantID)
+		if err != nil && !errors.Is(err, domain.ErrSSONotConfigured) {
+			return nil, err
+		}
+		// The stored secret belongs to the client it was entered for
+		if existing != nil && existing.ClientID == req.ClientID && existing.IssuerURL == req.IssuerURL {
+			cfg.ClientSecret = existing.ClientSecret
+		}
+	}
+
+	if err := uc.repo.SaveConfig(ctx, cfg, int(actor.UserID)); err != nil {
+		return nil, err
+	}
+	log.Printf("sso: tenant %d identity provider set to %s by user %d (enabled=%t, password login disabled=%t)",
+		tenantID, cfg.IssuerURL, actor.UserID, cfg.Enabled, cfg.PasswordLoginDisabled)
+	return uc.GetConfig(ctx, tenantID)
+}
+
+// DeleteConfig removes the tenant's identity provider, which turns password login back on
+func (uc *SSOUseCase) DeleteConfig(ctx context.Context, tenantID int) error {
+	return uc.repo.DeleteConfig(ctx, tenantID)
+}
+
+func (uc *SSOUseCase) checkRestaurant(ctx context.Context, tenantID, restaurantID int64) error {
+	ok, err := uc.userRoleRepo.IsTenantRestaurant(ctx, tenantID, restaurantID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return domain.ErrRestaurantNotFound
+	}
+	return nil
+}
+
+// CheckPasswordLogin refuses password sign-in to tenants that require single sign-on
+func (uc *SSOUseCase) CheckPasswordLogin(ctx context.Context, tenantID int) error {
+	disabled, err := uc.repo.PasswordLoginDisabled(ctx, tenantID)
+	if err != nil {
+		return err
+	}
+	if disabled {
+		return domain.ErrPasswordLoginDisabled
+	}
+	return nil
+}
+
+// Provider tells the login page of a tenant whether to offer single sign-on
+func (uc *SSOUseCase) Provider(ctx context.Context, slug string) (*domain.SSOProviderInfo, error) {
+	info := &domain.SSOProviderInfo{TenantSlug: slug}
+	cfg, err := uc.repo.GetConfigBySlug(ctx, slug)
+	if errors.Is(err, domain.ErrSSONotConfigured) {
+		return info, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Enabled {
+		info.Enabled = true
+		info.PasswordLoginDisabled = cfg.PasswordLoginDisabled
+		info.StartURL = uc.apiURL + "/api/v1/auth/sso/start?" + url.Values{"tenant": {slug}}.Encode()
+	}
+	return info, nil
+}
+
+// Start begins a sign-in at the tenant's provider and returns the URL to send the browser
+// to: the provider's authorization page, or /login with an sso_error code. redirectPath is
+// the dashboard page to land on afterwards. binding is the state's hash, which the browser
+// must keep in the SSOStateCookie and present to Callback; it is empty when starting failed.
+func (uc *SSOUseCase) Start(ctx context.Context, slug, redirectPath string) (target, binding string) {
+	authURL, binding, err := uc.start(ctx, slug, redirectPath)
+	if err != nil {
+		log.Printf("sso: failed to start sign-in for tenant %q: %v", slug, err)
+		return uc.loginErrorURL(err), ""
+	}
+	return authURL, binding
+}
+
+func (uc *SSOUseCase) start(ctx context.Context, slug, redirectPath string) (string, string, error) {
+	cfg, err := uc.repo.GetConfigBySlug(ctx, slug)
+	if err != nil {
+		return "", "", err
+	}
+	if !cfg.Enabled {
+		return "", "", domain.ErrSSONotConfigured
+	}
+	provider, err := uc.oidc.Discover(ctx, cfg.IssuerURL)
+	if err != nil {
+		log.Printf("sso: discovery of %s for tenant %d failed: %v", cfg.IssuerURL, cfg.TenantID, err)
+		return "", "", domain.ErrSSOLoginFailed
+	}
+
+	state, err := oidc.NewState()
+	if err != nil {
+		return "", "", err
+	}
+	nonce, err := oidc.NewState()
+	if err != nil {
+		return "", "", err
+	}
+	verifier, err := oidc.NewCodeVerifier()
+	if err != nil {
+		return "", "", err
+	}
+	sealedVerifier, err := uc.box.Seal(verifier)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encrypt code verifier: %w", err)
+	}
+
+	req := &domain.SSOLoginRequest{
+		TenantID:     cfg.TenantID,
+		Nonce:        nonce,
+		CodeVerifier: sealedVerifier,
+		RedirectPath: domain.SafeRedirectPath(redirectPath),
+		ExpiresAt:    time.Now().Add(domain.SSOLoginTTL),
+	}
+	stateHash := domain.HashToken(state)
+	if err := uc.repo.CreateLoginRequest(ctx, req, stateHash); err != nil {
+		return "", "", fmt.Errorf("failed to store sign-in request: %w", err)
+	}
+
+	return provider.AuthCodeURL(oidc.AuthRequest{
+		ClientID:      cfg.ClientID,
+		RedirectURI:   uc.callbackURL(),
+		Scopes:        cfg.Scopes,
+		State:         state,
+		Nonce:         nonce,
+		CodeChallenge: oidc.CodeChallenge(verifier),
+	}), stateHash, nil
+}
+
+// Callback completes a sign-in the provider redirected back with and returns the dashboard
+// URL to send the browser to: /sso/complete with a code to exchange for tokens, or /login
+// with an sso_error code. binding is the SSOStateCookie of the browser; a state that was not
+// started in this browser is refused, so a sign-in cannot be planted in someone else's.
+func (uc *SSOUseCase) Callback(ctx context.Context, state, binding, code, providerError string, client domain.SessionClient) string {
+	handoff, redirectPath, err := uc.callback(ctx, state, binding, code, providerError, client)
+	if err != nil {
+		log.Printf("sso: sign-in failed: %v", err)
+		return uc.loginErrorURL(err)
+	}
+
+	params := url.Values{"code": {handoff}}
+	if redirectPath != "" {
+		params.Set("redirect", redirectPath)
+	}
+	return uc.appURL + "/sso/complete?" + params.Encode()
+}
+
+func (uc *SSOUseCase) callback(ctx context.Context, state, binding, code, providerError string, client domain.SessionClient) (string, string, error) {
+	if state == "" {
+		return "", "", domain.ErrSSOInvalidState
+	}
+	stateHash := domain.HashToken(state)
+	if subtle.ConstantTimeCompare([]byte(binding), []byte(stateHash)) != 1 {
+		return "", "", fmt.Errorf("%w: state was not started in this browser", domain.ErrSSOInvalidState)
+	}
+	req, err := uc.repo.ClaimLoginRequest(ctx, stateHash)
+	if err != nil {
+		return "", "", err
+	}
+	if providerError != "" {
+		return "", "", fmt.Errorf("%w: provider returned %s", domain.ErrSSOLoginFailed, providerError)
+	}
+	if code == "" {
+		return "", "", fmt.Errorf("%w: no authorization code", domain.ErrSSOLoginFailed)
+	}
+
+	cfg, err := uc.repo.GetConfig(ctx, req.TenantID)
+	if err != nil {
+		return "", "", err
+	}
+	if !cfg.Enabled {
+		return "", "", domain.ErrSSONotConfigured
+	}
+	claims, issuer, err := uc.redeem(ctx, cfg, req, code)
+	if err != nil {
+		return "", "", err
+	}
+
+	user, err := uc.resolveUser(ctx, cfg, issuer, claims)
+	if err != nil {
+		return "", "", err
+	}
+	uc.syncRoles(ctx, cfg, user, claims, client)
+
+	handoff, err := newSecureToken()
+	if err != nil {
+		return "", "", err
+	}
+	if err := uc.repo.SetHandoff(ctx, req.ID, user.ID, domain.HashToken(handoff), time.Now().Add(domain.SSOHandoffTTL)); err != nil {
+		return "", "", err
+	}
+	return handoff, req.RedirectPath, nil
+}
+
+// redeem exchanges the authorization code and verifies the ID token against the request's nonce
+func (uc *SSOUseCase) redeem(ctx context.Context, cfg *domain.SSOConfig, req *domain.SSOLoginRequest, code string) (*oidc.Claims, string, error) {
+	verifier, err := uc.box.Open(req.CodeVerifier)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt code verifier: %w", err)
+	}
+	var secret string
+	if cfg.ClientSecret != "" {
+		if secret, err = uc.box.Open(cfg.ClientSecret); err != nil {
+			return nil, "", fmt.Errorf("failed to decrypt client secret: %w", err)
+		}
+	}
+
+	provider, err := uc.oidc.Discover(ctx, cfg.IssuerURL)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", domain.ErrSSOLoginFailed, err)
+	}
+	token, err := uc.oidc.Exchange(ctx, provider, cfg.ClientID, secret, uc.callbackURL(), code, verifier)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", domain.ErrSSOLoginFailed, err)
+	}
+	claims, err := uc.oidc.VerifyIDToken(ctx, provider, cfg.ClientID, token.IDToken, req.Nonce)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %v", domain.ErrSSOLoginFailed, err)
+	}
+	return claims, provider.Issuer, nil
+}
+
+// resolveUser finds the account of a provider user: the one linked to their provider
+// account, else the tenant's account with their verified email, else a new account when
+// the tenant provisions them
+func (uc *SSOUseCase) resolveUser(ctx context.Context, cfg *domain.SSOConfig, issuer string, claims *oidc.Claims) (*domain.User, error) {
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if !cfg.AllowsEmail(email) {
+		return nil, domain.ErrSSOEmailNotAllowed
+	}
+
+	var user *domain.User
+	userID, err := uc.repo.GetIdentityUser(ctx, cfg.TenantID, issuer, claims.Subject)
+	if err != nil {
+		return nil, err
+	}
+	if userID != 0 {
+		user, err = uc.userRepo.GetByID(ctx, userID)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+	}
+
+	if user == nil {
+		if email, err = domain.NormalizeEmail(email); err != nil {
+			return nil, fmt.Errorf("%w: provider returned no valid email", domain.ErrSSOLoginFailed)
+		}
+		// Only an address the provider vouches for may take over an existing account
+		if !claims.EmailVerified {
+			return nil, domain.ErrSSOEmailNotVerified
+		}
+		user, err = uc.userRepo.GetByEmailInTenant(ctx, int64(cfg.TenantID), email)
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			if !cfg.JITProvisioning {
+				return nil, domain.ErrSSOUserNotProvisioned
+			}
+			if user, err = uc.provision(ctx, cfg, email, claims.Name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if user.TenantID != cfg.TenantID || user.Status != "active" {
+		return nil, domain.ErrSSOAccountDisabled
+	}
+	if err := uc.repo.LinkIdentity(ctx, cfg.TenantID, user.ID, issuer, claims.Subject, email); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// provision creates the account of a provider user signing in for the first time. Accounts
+// sharing an email share a password, so when the email has accounts in other tenants the
+// new one reuses their password hash; otherwise it gets an unusable random password, as the
+// user signs in through the provider.
+func (uc *SSOUseCase) provision(ctx context.Context, cfg *domain.SSOConfig, email, name string) (*domain.User, error) {
+	seats, err := uc.invitationRepo.CountSeats(ctx, cfg.TenantID, email)
+	if err != nil {
+		return nil, err
+	}
+	if seats.Available() == 0 {
+		return nil, domain.ErrUserLimitReached
+	}
+
+	hashed, err := uc.provisionPasswordHash(ctx, email)
+	if err != nil {
+		return nil, err
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = email[:strings.Index(email, "@")]
+	}
+
+	user, err := uc.userRepo.CreateUserInTenant(ctx, int64(cfg.TenantID), name, email, "", hashed, cfg.DefaultRestaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.userRepo.MarkEmailVerified(ctx, user.ID); err != nil {
+		log.Printf("sso: failed to verify email of user %d: %v", user.ID, err)
+	}
+	log.Printf("sso: provisioned user %d in tenant %d", user.ID, cfg.TenantID)
+	return user, nil
+}
+
+// provisionPasswordHash returns the password hash of the email's existing accounts, or of
+// a random password when it has none
+func (uc *SSOUseCase) provisionPasswordHash(ctx context.Context, email string) (string, error) {
+	accounts, err := uc.userRepo.FindByEmailAllTenants(ctx, email)
+	if err != nil {
+		return "", err
+	}
+	for _, account := range accounts {
+		if account.Status == "active" {
+			return account.PasswordHash, nil
+		}
+	}
+
+	password, err := newSecureToken()
+	if err != nil {
+		return "", err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hashed), nil
+}
+
+// syncRoles makes the user's mapped roles follow their provider groups: assignments that
+// some mapping covers are added or removed, others are left alone. Nothing changes when the
+// ID token carries no groups claim, so a provider that stops sending it does not strip roles.
+func (uc *SSOUseCase) syncRoles(ctx context.Context, cfg *domain.SSOConfig, user *domain.User, claims *oidc.Claims, client domain.SessionClient) {
+	groups, ok := claims.Groups(cfg.GroupsClaim)
+	if !ok || len(cfg.RoleMappings) == 0 {
+		return
+	}
+
+	type assignment struct {
+		role       int64
+		restaurant int64
+	}
+	keyOf := func(roleID int64, restaurantID *int64) assignment {
+		key := assignment{role: roleID}
+		if restaurantID != nil {
+			key.restaurant = *restaurantID
+		}
+		return key
+	}
+
+	current, err := uc.userRoleRepo.GetUserRoleAssignments(ctx, int64(cfg.TenantID), int64(user.ID))
+	if err != nil {
+		log.Printf("sso: failed to load roles of user %d: %v", user.ID, err)
+		return
+	}
+	held := make(map[assignment]bool, len(current))
+	for _, ur := range current {
+		held[keyOf(ur.RoleID, ur.RestaurantID)] = true
+	}
+	wanted := cfg.MappedRoles(groups)
+	want := make(map[assignment]bool, len(wanted))
+	for _, mapping := range wanted {
+		want[keyOf(mapping.RoleID, mapping.RestaurantID)] = true
+	}
+
+	actor := domain.PermissionActor{
+		TenantID:  int64(cfg.TenantID),
+		UserID:    int64(user.ID),
+		IPAddress: client.IPAddress,
+		UserAgent: client.UserAgent,
+		Reason:    ssoRoleReason,
+	}
+	for _, mapping := range cfg.RoleMappings {
+		key := keyOf(mapping.RoleID, mapping.RestaurantID)
+		if !held[key] || want[key] {
+			continue
+		}
+		held[key] = false
+		role, err := tenantRole(uc.userRoles.roleRepo, actor.TenantID, mapping.RoleID)
+		if err == nil {
+			err = uc.userRoles.revoke(ctx, actor, int64(user.ID), role, mapping.RestaurantID)
+		}
+		if err != nil {
+			log.Printf("sso: failed to revoke role %d from user %d: %v", mapping.RoleID, user.ID, err)
+		}
+	}
+	for _, mapping := range wanted {
+		if held[keyOf(mapping.RoleID, mapping.RestaurantID)] {
+			continue
+		}
+		if err := uc.userRoles.assign(ctx, actor, int64(user.ID), mapping.RoleID, mapping.RestaurantID); err != nil {
+			log.Printf("sso: failed to assign role %d to user %d: %v", mapping.RoleID, user.ID, err)
+		}
+	}
+}
+
+// Redeem claims the code the dashboard was redirected with and returns the user it signs
+// in. AuthUseCase.LoginSSO opens the session, so SSO sign-ins get the same second factor and
+// audit as password logins.
+func (uc *SSOUseCase) Redeem(ctx context.Context, code string) (*domain.User, error) {
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return nil, domain.ErrSSOInvalidState
+	}
+	tenantID, userID, err := uc.repo.ClaimHandoff(ctx, domain.HashToken(code))
+	if err != nil {
+		return nil, err
+	}
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, domain.ErrSSOInvalidState
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.TenantID != tenantID || user.Status != "active" {
+		return nil, domain.ErrSSOAccountDisabled
+	}
+	return user, nil
+}
+
+// loginErrorURL is the login page with the sso_error code of a failed sign-in
+func (uc *SSOUseCase) loginErrorURL(err error) string {
+	return uc.appURL + "/login?sso_error=" + ssoErrorCode(err)
+}
+
+// ssoErrorCode is the sso_error the login page is redirected with
+func ssoErrorCode(err error) string {
+	switch {
+	case errors.Is(err, domain.ErrSSOInvalidState):
+		return "invalid_state"
+	case errors.Is(err, domain.ErrSSONotConfigured):
+		return "not_configured"
+	case errors.Is(err, domain.ErrSSOEmailNotAllowed):
+		return "email_not_allowed"
+	case errors.Is(err, domain.ErrSSOEmailNotVerified):
+		return "email_not_verified"
+	case errors.Is(err, domain.ErrSSOUserNotProvisioned):
+		return "not_provisioned"
+	case errors.Is(err, domain.ErrSSOAccountDisabled):
+		return "account_disabled"
+	case errors.Is(err, domain.ErrUserLimitReached):
+		return "user_limit_reached"
+	}
+	return "failed"
+}
@@ -0,0 +1,462 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"time"
+
+	"pos-saas/internal/domain"
+	"pos-saas/internal/pkg/secretbox"
+	"pos-saas/internal/pkg/totp"
+	"pos-saas/internal/repository"
+)
+
+// recoveryCodeAlphabet leaves out 0/O and 1/I so codes can be read back from paper
+const recoveryCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var errTwoFactorSetupNotStarted = errors.New("start two-factor setup first")
+
+// TwoFactorUseCase handles TOTP enrollment, recovery codes, login challenges and
+// remembered devices
+type TwoFactorUseCase struct {
+	repo     *repository.TwoFactorRepository
+	userRepo *repository.UserRepository
+	box      *secretbox.Box
+	issuer   string
+}
+
+// NewTwoFactorUseCase creates new two-factor use case. box encrypts the TOTP secrets and
+// issuer is the name shown in authenticator apps.
+func NewTwoFactorUseCase(
+	repo *repository.TwoFactorRepository,
+	userRepo *repository.UserRepository,
+	box *secretbox.Box,
+	issuer string,
+) *TwoFactorUseCase {
+	return &TwoFactorUseCase{
+		repo:     repo,
+		userRepo: userRepo,
+		box:      box,
+		issuer:   issuer,
+	}
+}
+
+// Challenge decides whether a password login needs a second step and opens a challenge
+// for it. Returns nil when the user can be signed in directly.
+func (uc *TwoFactorUseCase) Challenge(ctx context.Context, user *domain.User, trustedDeviceToken, ip string) (*domain.TwoFactorChallengeResponse, error) {
+	tf, err := uc.repo.GetTwoFactor(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if tf != nil && tf.EnabledAt != nil {
+		if trustedDeviceToken != "" {
+			trusted, err := uc.repo.IsTrustedDevice(ctx, user.ID, domain.HashToken(trustedDeviceToken))
+			if err != nil {
+				return nil, err
+			}
+			if trusted {
+				return nil, nil
+			}
+		}
+		return uc.newChallenge(ctx, user.ID, false, ip)
+	}
+
+	policy, err := uc.repo.GetTenantPolicy(ctx, user.TenantID)
+	if err != nil {
+		return nil, err
+	}
+	if !domain.TwoFactorPolicyRequires(policy, user.Role) {
+		return nil, nil
+	}
+	return uc.newChallenge(ctx, user.ID, true, ip)
+}
+
+func (uc *TwoFactorUseCase) newChallenge(ctx context.Context, userID int, setupRequired bool, ip string) (*domain.TwoFactorChallengeResponse, error) {
+	token, err := newSecureToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate challenge: %w", err)
+	}
+
+	challenge := &domain.TwoFactorChallenge{
+		UserID:        userID,
+		SetupRequired: setupRequired,
+		ExpiresAt:     time.Now().Add(domain.TwoFactorChallengeTTL),
+	}
+	if err := uc.repo.CreateChallenge(ctx, challenge, domain.HashToken(token), ip); err != nil {
+		return nil, err
+	}
+
+	message := "Enter the code from your authenticator app"
+	if setupRequired {
+		message = "Your organization requires two-factor authentication. Set it up to continue."
+	}
+	return &domain.TwoFactorChallengeResponse{
+		Success:           true,
+		TwoFactorRequired: true,
+		SetupRequired:     setupRequired,
+		ChallengeToken:    token,
+		ExpiresIn:         int64(domain.TwoFactorChallengeTTL.Seconds()),
+		Message:           message,
+	}, nil
+}
+
+func (uc *TwoFactorUseCase) getChallenge(ctx context.Context, token string) (*domain.TwoFactorChallenge, error) {
+	challenge, err := uc.repo.GetChallenge(ctx, domain.HashToken(token))
+	if err != nil {
+		return nil, err
+	}
+	if challenge == nil {
+		return nil, domain.ErrInvalidTwoFactorChallenge
+	}
+	return challenge, nil
+}
+
+// SetupFromChallenge starts the enrollment a user must finish before signing in
+func (uc *TwoFactorUseCase) SetupFromChallenge(ctx context.Context, req *domain.TwoFactorChallengeRequest) (*domain.TwoFactorSetup, error) {
+	if req.ChallengeToken == "" {
+		return nil, errors.New("challenge_token is required")
+	}
+
+	challenge, err := uc.getChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, err
+	}
+	if !challenge.SetupRequired {
+		return nil, domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	user, err := uc.userRepo.GetByID(ctx, challenge.UserID)
+	if err != nil {
+		return nil, domain.ErrInvalidTwoFactorChallenge
+	}
+	return uc.startSetup(ctx, user)
+}
+
+// CompleteChallenge verifies the second login step and returns the user to open a session
+// for. When the challenge required enrollment, the code confirms it and the new recovery
+// codes are returned.
+func (uc *TwoFactorUseCase) CompleteChallenge(ctx context.Context, req *domain.TwoFactorVerifyRequest) (*domain.User, []string, error) {
+	if err := req.Validate(); err != nil {
+		return nil, nil, err
+	}
+
+	challenge, err := uc.getChallenge(ctx, req.ChallengeToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	user, err := uc.userRepo.GetByID(ctx, challenge.UserID)
+	if err != nil || user.Status != "active" {
+		return nil, nil, domain.ErrInvalidTwoFactorChallenge
+	}
+
+	if challenge.SetupRequired {
+		step, err := uc.checkPendingCode(ctx, user.ID, req.Code)
+		if errors.Is(err, errTwoFactorSetupNotStarted) {
+			return nil, nil, domain.ErrTwoFactorSetupRequired
+		}
+		if err != nil {
+			return nil, nil, uc.failChallenge(ctx, challenge, err)
+		}
+		if err := uc.repo.ConsumeChallenge(ctx, challenge.ID); err != nil {
+			return nil, nil, err
+		}
+		codes, err := uc.activate(ctx, user.ID, step)
+		if err != nil {
+			return nil, nil, err
+		}
+		return user, codes, nil
+	}
+
+	if err := uc.verifyCode(ctx, user.ID, req.Code, req.RecoveryCode); err != nil {
+		return nil, nil, uc.failChallenge(ctx, challenge, err)
+	}
+	if err := uc.repo.ConsumeChallenge(ctx, challenge.ID); err != nil {
+		return nil, nil, err
+	}
+	return user, nil, nil
+}
+
+// failChallenge counts a wrong code against the challenge
+func (uc *TwoFactorUseCase) failChallenge(ctx context.Context, challenge *domain.TwoFactorChallenge, err error) error {
+	if errors.Is(err, domain.ErrInvalidTwoFactorCode) {
+		if failErr := uc.repo.FailChallenge(ctx, challenge.ID); failErr != nil {
+			return failErr
+		}
+	}
+	return err
+}
+
+// verifyCode accepts a TOTP code, or a recovery code when one is given. Each code works once.
+func (uc *TwoFactorUseCase) verifyCode(ctx context.Context, userID int, code, recoveryCode string) error {
+	tf, err := uc.repo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if tf == nil || tf.EnabledAt == nil {
+		return domain.ErrTwoFactorNotEnabled
+	}
+
+	if recoveryCode != "" {
+		used, err := uc.repo.UseRecoveryCode(ctx, userID, domain.HashToken(domain.NormalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return domain.ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+
+	secret, err := uc.box.Open(tf.SecretEncrypted)
+	if err != nil {
+		return fmt.Errorf("failed to read two-factor secret: %w", err)
+	}
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	fresh, err := uc.repo.UseStep(ctx, userID, step)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return domain.ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// startSetup stores a new pending secret and returns what the authenticator app needs
+func (uc *TwoFactorUseCase) startSetup(ctx context.Context, user *domain.User) (*domain.TwoFactorSetup, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	sealed, err := uc.box.Seal(secret)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+	}
+	if err := uc.repo.SavePendingSecret(ctx, user.ID, sealed); err != nil {
+		return nil, err
+	}
+
+	return &domain.TwoFactorSetup{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(uc.issuer, user.Email, secret),
+		Issuer:          uc.issuer,
+		Account:         user.Email,
+	}, nil
+}
+
+// checkPendingCode validates a code against a pending enrollment and returns its step
+func (uc *TwoFactorUseCase) checkPendingCode(ctx context.Context, userID int, code string) (int64, error) {
+	tf, err := uc.repo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if tf == nil {
+		return 0, errTwoFactorSetupNotStarted
+	}
+	if tf.EnabledAt != nil {
+		return 0, domain.ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := uc.box.Open(tf.SecretEncrypted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read two-factor secret: %w", err)
+	}
+	step, ok := totp.Validate(secret, code, time.Now())
+	if !ok {
+		return 0, domain.ErrInvalidTwoFactorCode
+	}
+	return step, nil
+}
+
+// activate enables a pending enrollment and returns its recovery codes
+func (uc *TwoFactorUseCase) activate(ctx context.Context, userID int, step int64) ([]string, error) {
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.Enable(ctx, userID, step, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// generateRecoveryCodes returns new codes formatted as XXXXX-XXXXX and their hashes
+func generateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, domain.RecoveryCodeCount)
+	hashes := make([]string, 0, domain.RecoveryCodeCount)
+	for i := 0; i < domain.RecoveryCodeCount; i++ {
+		b := make([]byte, 10)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery codes: %w", err)
+		}
+		for j := range b {
+			b[j] = recoveryCodeAlphabet[int(b[j])%len(recoveryCodeAlphabet)]
+		}
+		code := string(b[:5]) + "-" + string(b[5:])
+		codes = append(codes, code)
+		hashes = append(hashes, domain.HashToken(domain.NormalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
+
+// TrustDevice remembers the device a login was verified on and returns the cookie token
+func (uc *TwoFactorUseCase) TrustDevice(ctx context.Context, userID int, client domain.SessionClient) (string, error) {
+	token, err := newSecureToken()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate device token: %w", err)
+	}
+	client = client.Normalize()
+	err = uc.repo.CreateTrustedDevice(ctx, userID, domain.HashToken(token), client.DeviceName, client.IPAddress,
+		time.Now().Add(domain.TrustedDeviceTTL))
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Status returns the user's 2FA state and whether the tenant policy requires it
+func (uc *TwoFactorUseCase) Status(ctx context.Context, userID int) (*domain.TwoFactorStatus, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := uc.repo.GetTenantPolicy(ctx, user.TenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &domain.TwoFactorStatus{
+		Policy:   policy,
+		Required: domain.TwoFactorPolicyRequires(policy, user.Role),
+	}
+	tf, err := uc.repo.GetTwoFactor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if tf == nil || tf.EnabledAt == nil {
+		return status, nil
+	}
+
+	status.Enabled = true
+	status.EnabledAt = tf.EnabledAt
+	if status.RecoveryCodesRemaining, err = uc.repo.CountRecoveryCodes(ctx, userID); err != nil {
+		return nil, err
+	}
+	if status.TrustedDevices, err = uc.repo.CountTrustedDevices(ctx, userID); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// BeginSetup starts enrollment for a signed-in user after checking the password
+func (uc *TwoFactorUseCase) BeginSetup(ctx context.Context, userID int, req *domain.TwoFactorPasswordRequest) (*domain.TwoFactorSetup, error) {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.VerifyPassword(req.CurrentPassword) {
+		return nil, domain.ErrIncorrectPassword
+	}
+	return uc.startSetup(ctx, user)
+}
+
+// Enable confirms enrollment with a first code and returns the recovery codes
+func (uc *TwoFactorUseCase) Enable(ctx context.Context, userID int, req *domain.TwoFactorCodeRequest) (*domain.RecoveryCodesResponse, error) {
+	if req.Code == "" {
+		return nil, errors.New("code is required")
+	}
+	step, err := uc.checkPendingCode(ctx, userID, req.Code)
+	if err != nil {
+		return nil, err
+	}
+	codes, err := uc.activate(ctx, userID, step)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns 2FA off after checking the password and a code, unless the tenant requires it
+func (uc *TwoFactorUseCase) Disable(ctx context.Context, userID int, req *domain.TwoFactorPasswordRequest) error {
+	user, err := uc.confirmChange(ctx, userID, req)
+	if err != nil {
+		return err
+	}
+
+	policy, err := uc.repo.GetTenantPolicy(ctx, user.TenantID)
+	if err != nil {
+		return err
+	}
+	if domain.TwoFactorPolicyRequires(policy, user.Role) {
+		return domain.ErrTwoFactorRequired
+	}
+	return uc.repo.Disable(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes
+func (uc *TwoFactorUseCase) RegenerateRecoveryCodes(ctx context.Context, userID int, req *domain.TwoFactorPasswordRequest) (*domain.RecoveryCodesResponse, error) {
+	if _, err := uc.confirmChange(ctx, userID, req); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := generateRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return &domain.RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// confirmChange checks the password and a current code before a 2FA change
+func (uc *TwoFactorUseCase) confirmChange(ctx context.Context, userID int, req *domain.TwoFactorPasswordRequest) (*domain.User, error) {
+	if req.Code == "" {
+		return nil, errors.New("code is required")
+	}
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.VerifyPassword(req.CurrentPassword) {
+		return nil, domain.ErrIncorrectPassword
+	}
+	if err := uc.verifyCode(ctx, userID, req.Code, ""); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// ForgetTrustedDevices makes every remembered device ask for a code again
+func (uc *TwoFactorUseCase) ForgetTrustedDevices(ctx context.Context, userID int) (int64, error) {
+	return uc.repo.DeleteTrustedDevices(ctx, userID)
+}
+
+// ResetUser removes the 2FA of a user of the tenant who lost their device. If the tenant
+// requires 2FA, the user sets it up again at the next login.
+func (uc *TwoFactorUseCase) ResetUser(ctx context.Context, tenantID, userID int) error {
+	user, err := uc.userRepo.GetByID(ctx, userID)
+	if err != nil || user.TenantID != tenantID {
+		return domain.ErrRBACUserNotFound
+	}
+	return uc.repo.Disable(ctx, userID)
+}
+
+// GetPolicy returns the tenant's two-factor policy
+func (uc *TwoFactorUseCase) GetPolicy(ctx context.Context, tenantID int) (string, error) {
+	return uc.repo.GetTenantPolicy(ctx, tenantID)
+}
+
+// SetPolicy updates the tenant's two-factor policy
+func (uc *TwoFactorUseCase) SetPolicy(ctx context.Context, tenantID int, req *domain.TwoFactorPolicyRequest) error {
+	if !domain.ValidTwoFactorPolicy(req.Policy) {
+		return errors.New("policy must be optional, managers or all")
+	}
+	return uc.repo.SetTenantPolicy(ctx, tenantID, req.Policy)
+}
@@ -0,0 +1,72 @@
+-- 107_create_dining_tables_and_tabs.sql
+-- Dine-in table service: physical tables with QR tokens and open tabs that collect order rounds
+
+CREATE TABLE IF NOT EXISTS dining_tables (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    restaurant_id BIGINT NOT NULL,
+    table_number VARCHAR(20) NOT NULL,
+    label VARCHAR(100),
+    area VARCHAR(100),
+    seats INT DEFAULT 4,
+    qr_token VARCHAR(64) NOT NULL UNIQUE,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_dining_tables_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_dining_tables_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE,
+    CONSTRAINT uq_dining_tables_number UNIQUE (restaurant_id, table_number)
+);
+
+CREATE INDEX IF NOT EXISTS idx_dining_tables_restaurant ON dining_tables(tenant_id, restaurant_id);
+CREATE INDEX IF NOT EXISTS idx_dining_tables_qr_token ON dining_tables(qr_token);
+
+COMMENT ON TABLE dining_tables IS 'Dine-in tables. qr_token is embedded in the table QR code and binds public orders to the table.';
+
+CREATE TABLE IF NOT EXISTS table_tabs (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    restaurant_id BIGINT NOT NULL,
+    table_id BIGINT NOT NULL,
+    tab_number VARCHAR(50) NOT NULL UNIQUE,
+    status VARCHAR(20) DEFAULT 'open', -- 'open', 'closed', 'merged'
+    guest_count INT DEFAULT 0,
+    merged_into_tab_id BIGINT,
+    opened_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP,
+    closed_by BIGINT,
+    subtotal DECIMAL(10, 2) DEFAULT 0,
+    tax_amount DECIMAL(10, 2) DEFAULT 0,
+    discount_amount DECIMAL(10, 2) DEFAULT 0,
+    service_charge DECIMAL(10, 2) DEFAULT 0,
+    total_amount DECIMAL(10, 2) DEFAULT 0,
+    payment_method VARCHAR(50),
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_table_tabs_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_table_tabs_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_table_tabs_table FOREIGN KEY (table_id) REFERENCES dining_tables(id) ON DELETE CASCADE,
+    CONSTRAINT fk_table_tabs_merged_into FOREIGN KEY (merged_into_tab_id) REFERENCES table_tabs(id) ON DELETE SET NULL,
+    CONSTRAINT chk_table_tab_status CHECK (status IN ('open', 'closed', 'merged'))
+);
+
+-- Only one open tab per table at a time
+CREATE UNIQUE INDEX IF NOT EXISTS idx_table_tabs_one_open ON table_tabs(table_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_table_tabs_restaurant_status ON table_tabs(restaurant_id, status);
+
+COMMENT ON TABLE table_tabs IS 'Open tabs for dine-in tables. Each guest round is an order linked through orders.tab_id; closing the tab produces the final bill.';
+
+-- Link orders to tables and tabs
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS table_id BIGINT REFERENCES dining_tables(id) ON DELETE SET NULL;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tab_id BIGINT REFERENCES table_tabs(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_tab_id ON orders(tab_id);
+
+-- Dine-in guests are not required to leave a phone number
+ALTER TABLE orders DROP CONSTRAINT IF EXISTS chk_phone_not_empty;
+ALTER TABLE orders ADD CONSTRAINT chk_phone_not_empty CHECK (order_source = 'dine_in' OR customer_phone != '');
+
+COMMENT ON COLUMN orders.order_source IS 'Order source: website, mobile_app, phone, in_store, dine_in';
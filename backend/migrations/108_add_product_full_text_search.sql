@@ -0,0 +1,38 @@
+-- 108_add_product_full_text_search.sql
+-- Full-text multilingual menu search: English stemming, Arabic normalization and trigram typo tolerance
+
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Normalizes Arabic text for matching:
+--   strips diacritics (tashkeel U+064B-U+0652, superscript alef U+0670) and tatweel (U+0640),
+--   folds alef variants (أ إ آ ٱ) to ا, alef maqsura ى to ي and taa marbuta ة to ه
+CREATE OR REPLACE FUNCTION normalize_arabic(input TEXT) RETURNS TEXT AS $$
+    SELECT translate(
+        regexp_replace(COALESCE(input, ''), '[ً-ْٰـ]', '', 'g'),
+        'أإآٱىة',
+        'ااااايه'
+    );
+$$ LANGUAGE SQL IMMUTABLE PARALLEL SAFE;
+
+COMMENT ON FUNCTION normalize_arabic(TEXT) IS 'Strips Arabic diacritics/tatweel and folds alef, yaa and taa marbuta variants for search';
+
+-- Weighted search document: names (A) rank above descriptions (C)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        setweight(to_tsvector('english', COALESCE(name_en, '')), 'A') ||
+        setweight(to_tsvector('simple', normalize_arabic(name_ar)), 'A') ||
+        setweight(to_tsvector('english', COALESCE(description_en, '')), 'C') ||
+        setweight(to_tsvector('simple', normalize_arabic(description_ar)), 'C')
+    ) STORED;
+
+-- Normalized names used for trigram similarity and autocomplete
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_names TEXT
+    GENERATED ALWAYS AS (
+        lower(COALESCE(name_en, '')) || ' ' || normalize_arabic(name_ar)
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_products_search_names_trgm ON products USING GIN (search_names gin_trgm_ops);
+
+COMMENT ON COLUMN products.search_vector IS 'Generated full-text document (English stemmed + normalized Arabic) for menu search';
+COMMENT ON COLUMN products.search_names IS 'Generated normalized names for trigram typo tolerance and autocomplete';
@@ -0,0 +1,49 @@
+-- 109_create_allergens.sql
+-- Structured allergen data: controlled vocabulary (14 EU allergens) with translations
+
+CREATE TABLE IF NOT EXISTS allergens (
+    code VARCHAR(50) PRIMARY KEY,
+    name_en VARCHAR(100) NOT NULL,
+    name_ar VARCHAR(100) NOT NULL,
+    display_order INT DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON TABLE allergens IS 'Controlled allergen vocabulary (EU Regulation 1169/2011 Annex II) with English and Arabic names';
+
+INSERT INTO allergens (code, name_en, name_ar, display_order) VALUES
+    ('gluten',      'Cereals containing gluten', 'حبوب تحتوي على الغلوتين', 1),
+    ('crustaceans', 'Crustaceans',               'القشريات',               2),
+    ('eggs',        'Eggs',                      'البيض',                  3),
+    ('fish',        'Fish',                      'الأسماك',                4),
+    ('peanuts',     'Peanuts',                   'الفول السوداني',          5),
+    ('soy',         'Soybeans',                  'فول الصويا',             6),
+    ('milk',        'Milk',                      'الحليب',                 7),
+    ('tree_nuts',   'Tree nuts',                 'المكسرات',               8),
+    ('celery',      'Celery',                    'الكرفس',                 9),
+    ('mustard',     'Mustard',                   'الخردل',                 10),
+    ('sesame',      'Sesame seeds',              'بذور السمسم',            11),
+    ('sulphites',   'Sulphur dioxide and sulphites', 'ثاني أكسيد الكبريت والكبريتيت', 12),
+    ('lupin',       'Lupin',                     'الترمس',                 13),
+    ('molluscs',    'Molluscs',                  'الرخويات',               14)
+ON CONFLICT (code) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS product_allergens (
+    product_id BIGINT NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    allergen_code VARCHAR(50) NOT NULL REFERENCES allergens(code) ON DELETE RESTRICT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (product_id, allergen_code)
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_allergens_code ON product_allergens(allergen_code);
+
+COMMENT ON TABLE product_allergens IS 'Allergens contained in a product. products.allergens is kept in sync as a JSON copy for older clients.';
+
+-- Backfill from the free-text products.allergens column (JSON arrays of known codes only)
+INSERT INTO product_allergens (product_id, allergen_code)
+SELECT DISTINCT p.id, lower(trim(a.value))
+FROM products p
+CROSS JOIN LATERAL json_array_elements_text(p.allergens::json) AS a(value)
+WHERE p.allergens ~ '^\s*\['
+  AND lower(trim(a.value)) IN (SELECT code FROM allergens)
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,82 @@
+-- 110_create_order_feedback_and_reviews.sql
+-- Customer order feedback (order, food and delivery ratings) and moderated product reviews
+
+-- One review request per delivered order; the token is sent to the customer
+CREATE TABLE IF NOT EXISTS review_requests (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    restaurant_id BIGINT NOT NULL,
+    order_id BIGINT NOT NULL UNIQUE,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    customer_name VARCHAR(255),
+    customer_email VARCHAR(255),
+    customer_phone VARCHAR(20),
+    status VARCHAR(20) DEFAULT 'pending', -- 'pending', 'completed', 'expired'
+    expires_at TIMESTAMP NOT NULL,
+    completed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_review_requests_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_review_requests_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_review_requests_order FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT chk_review_request_status CHECK (status IN ('pending', 'completed', 'expired'))
+);
+
+COMMENT ON TABLE review_requests IS 'Review invitations created when an order is delivered. token authorizes a single feedback submission.';
+
+CREATE TABLE IF NOT EXISTS order_feedback (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    restaurant_id BIGINT NOT NULL,
+    order_id BIGINT NOT NULL UNIQUE,
+    overall_rating SMALLINT NOT NULL,
+    food_rating SMALLINT,
+    delivery_rating SMALLINT,
+    comment TEXT,
+    delivery_comment TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_order_feedback_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_order_feedback_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_order_feedback_order FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT chk_overall_rating CHECK (overall_rating BETWEEN 1 AND 5),
+    CONSTRAINT chk_food_rating CHECK (food_rating IS NULL OR food_rating BETWEEN 1 AND 5),
+    CONSTRAINT chk_delivery_rating CHECK (delivery_rating IS NULL OR delivery_rating BETWEEN 1 AND 5)
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_feedback_restaurant ON order_feedback(restaurant_id, created_at DESC);
+
+COMMENT ON TABLE order_feedback IS 'Customer ratings for a delivered order. delivery_rating is also copied to driver_assignments.rating.';
+
+CREATE TABLE IF NOT EXISTS product_reviews (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    restaurant_id BIGINT NOT NULL,
+    product_id BIGINT NOT NULL,
+    order_id BIGINT NOT NULL,
+    customer_name VARCHAR(255),
+    rating SMALLINT NOT NULL,
+    comment TEXT,
+    status VARCHAR(20) DEFAULT 'pending', -- 'pending', 'approved', 'hidden'
+    moderated_by BIGINT,
+    moderated_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_product_reviews_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_product_reviews_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_product_reviews_product FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE,
+    CONSTRAINT fk_product_reviews_order FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT uq_product_reviews_order_product UNIQUE (order_id, product_id),
+    CONSTRAINT chk_product_review_rating CHECK (rating BETWEEN 1 AND 5),
+    CONSTRAINT chk_product_review_status CHECK (status IN ('pending', 'approved', 'hidden'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_reviews_product_status ON product_reviews(product_id, status);
+CREATE INDEX IF NOT EXISTS idx_product_reviews_restaurant_status ON product_reviews(restaurant_id, status, created_at DESC);
+
+COMMENT ON TABLE product_reviews IS 'Per-item customer reviews. Only approved reviews are public and counted in products.rating_average.';
+
+-- Aggregated ratings from approved reviews, refreshed on moderation
+ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_average DECIMAL(3, 2) DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS rating_count INT DEFAULT 0;
@@ -0,0 +1,35 @@
+-- 111_create_dispatch_offers.sql
+-- Driver auto-dispatch: job offers with acceptance timeout and manual dispatcher overrides
+
+CREATE TABLE IF NOT EXISTS dispatch_offers (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    restaurant_id BIGINT NOT NULL,
+    order_id BIGINT NOT NULL,
+    driver_id INT NOT NULL,
+    attempt INT NOT NULL DEFAULT 1,
+    status VARCHAR(20) NOT NULL DEFAULT 'offered', -- 'offered', 'accepted', 'declined', 'expired', 'cancelled'
+    score DECIMAL(6, 4),
+    distance_km DECIMAL(8, 3),
+    is_manual BOOLEAN DEFAULT false,
+    assigned_by BIGINT,
+    offered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    responded_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_dispatch_offers_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_dispatch_offers_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_dispatch_offers_order FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT fk_dispatch_offers_driver FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE CASCADE,
+    CONSTRAINT chk_dispatch_offer_status CHECK (status IN ('offered', 'accepted', 'declined', 'expired', 'cancelled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_dispatch_offers_order ON dispatch_offers(order_id, offered_at DESC);
+CREATE INDEX IF NOT EXISTS idx_dispatch_offers_driver_status ON dispatch_offers(driver_id, status);
+CREATE INDEX IF NOT EXISTS idx_dispatch_offers_pending_expiry ON dispatch_offers(expires_at) WHERE status = 'offered';
+
+-- Only one open offer per order at a time
+CREATE UNIQUE INDEX IF NOT EXISTS uq_dispatch_offers_open_order ON dispatch_offers(order_id) WHERE status = 'offered';
+
+COMMENT ON TABLE dispatch_offers IS 'Delivery job offers made to drivers by the dispatch engine. Expired or declined offers fall back to the next ranked driver; is_manual marks dispatcher overrides.';
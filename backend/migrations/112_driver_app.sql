@@ -0,0 +1,14 @@
+-- 112_driver_app.sql
+-- Driver app: driver credentials, pick-up/delivery proof on assignments and customer delivery PINs
+
+ALTER TABLE drivers ADD COLUMN IF NOT EXISTS password_hash VARCHAR(255);
+
+ALTER TABLE driver_assignments ADD COLUMN IF NOT EXISTS proof_type VARCHAR(20); -- 'photo', 'pin'
+ALTER TABLE driver_assignments ADD COLUMN IF NOT EXISTS proof_photo_url TEXT;
+ALTER TABLE driver_assignments ADD COLUMN IF NOT EXISTS proof_pin_verified BOOLEAN DEFAULT false;
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_pin VARCHAR(6);
+
+COMMENT ON COLUMN drivers.password_hash IS 'bcrypt hash of the driver app password; NULL until an admin sets one, which keeps the driver out of the app.';
+COMMENT ON COLUMN driver_assignments.proof_type IS 'How delivery was proven: photo (proof_photo_url) or pin (customer read the delivery PIN to the driver).';
+COMMENT ON COLUMN orders.delivery_pin IS 'Code generated at pick-up and shown on the customer tracking page while the order is out for delivery.';
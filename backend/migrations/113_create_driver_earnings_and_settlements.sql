@@ -0,0 +1,66 @@
+-- 113_create_driver_earnings_and_settlements.sql
+-- Driver pay rules, per-delivery earnings and cash collection, and end-of-shift cash settlements
+
+CREATE TABLE IF NOT EXISTS driver_earning_rules (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    restaurant_id BIGINT NOT NULL,
+    driver_id INT, -- NULL = restaurant default
+    per_delivery DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    per_km DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    incentive_every INT NOT NULL DEFAULT 0, -- bonus for every N deliveries in a settlement, 0 = none
+    incentive_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_driver_earning_rules_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_driver_earning_rules_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_driver_earning_rules_driver FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE CASCADE,
+    CONSTRAINT chk_driver_earning_rules_amounts CHECK (per_delivery >= 0 AND per_km >= 0 AND incentive_every >= 0 AND incentive_amount >= 0)
+);
+
+-- One default rule per restaurant and one override per driver
+CREATE UNIQUE INDEX IF NOT EXISTS uq_driver_earning_rules_default ON driver_earning_rules(restaurant_id) WHERE driver_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS uq_driver_earning_rules_driver ON driver_earning_rules(restaurant_id, driver_id) WHERE driver_id IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS driver_settlements (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    restaurant_id BIGINT NOT NULL,
+    driver_id INT NOT NULL,
+    period_start TIMESTAMP NOT NULL,
+    period_end TIMESTAMP NOT NULL,
+    deliveries_count INT NOT NULL DEFAULT 0,
+    distance_km DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    delivery_earnings DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    incentives DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    total_earnings DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    cash_expected DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    cash_collected DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    cash_handed_in DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    cash_variance DECIMAL(10, 2) NOT NULL DEFAULT 0, -- handed in minus expected
+    status VARCHAR(20) NOT NULL, -- 'balanced', 'short', 'over'
+    notes TEXT,
+    settled_by BIGINT,
+    settled_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_driver_settlements_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_driver_settlements_restaurant FOREIGN KEY (restaurant_id) REFERENCES restaurants(id) ON DELETE CASCADE,
+    CONSTRAINT fk_driver_settlements_driver FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE CASCADE,
+    CONSTRAINT chk_driver_settlement_status CHECK (status IN ('balanced', 'short', 'over'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_settlements_driver ON driver_settlements(driver_id, settled_at DESC);
+CREATE INDEX IF NOT EXISTS idx_driver_settlements_restaurant ON driver_settlements(restaurant_id, settled_at DESC);
+
+ALTER TABLE driver_assignments ADD COLUMN IF NOT EXISTS distance_km DECIMAL(8, 3);
+ALTER TABLE driver_assignments ADD COLUMN IF NOT EXISTS earning_amount DECIMAL(10, 2) DEFAULT 0;
+ALTER TABLE driver_assignments ADD COLUMN IF NOT EXISTS cash_expected DECIMAL(10, 2) DEFAULT 0;
+ALTER TABLE driver_assignments ADD COLUMN IF NOT EXISTS cash_collected DECIMAL(10, 2) DEFAULT 0;
+ALTER TABLE driver_assignments ADD COLUMN IF NOT EXISTS settlement_id BIGINT REFERENCES driver_settlements(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_driver_assignments_unsettled ON driver_assignments(driver_id, completed_at) WHERE status = 'completed' AND settlement_id IS NULL;
+
+COMMENT ON TABLE driver_earning_rules IS 'Driver pay: a fixed amount per delivery plus a per-km rate, with an incentive bonus for every N deliveries. A driver-specific rule overrides the restaurant default.';
+COMMENT ON TABLE driver_settlements IS 'End-of-shift reconciliation of a driver: completed deliveries since the previous settlement, earnings, and cash on delivery expected vs handed in.';
+COMMENT ON COLUMN driver_assignments.cash_collected IS 'Cash the driver reported collecting from the customer at delivery.';
@@ -0,0 +1,40 @@
+-- 114_create_geofence_events_and_location_retention.sql
+-- Geofence events derived from driver location streams, and per-tenant retention of location history
+
+CREATE TABLE IF NOT EXISTS driver_geofence_events (
+    id BIGSERIAL PRIMARY KEY,
+    assignment_id INT NOT NULL,
+    driver_id INT NOT NULL,
+    order_id INT NOT NULL,
+    event VARCHAR(30) NOT NULL, -- 'arrived_at_restaurant', 'picked_up', 'arrived_at_customer'
+    latitude DECIMAL(10, 8) NOT NULL,
+    longitude DECIMAL(11, 8) NOT NULL,
+    occurred_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_driver_geofence_events_assignment FOREIGN KEY (assignment_id) REFERENCES driver_assignments(id) ON DELETE CASCADE,
+    CONSTRAINT fk_driver_geofence_events_driver FOREIGN KEY (driver_id) REFERENCES drivers(id) ON DELETE CASCADE,
+    CONSTRAINT fk_driver_geofence_events_order FOREIGN KEY (order_id) REFERENCES orders(id) ON DELETE CASCADE,
+    CONSTRAINT chk_driver_geofence_events_event CHECK (event IN ('arrived_at_restaurant', 'picked_up', 'arrived_at_customer')),
+    -- Each event fires once per assignment
+    CONSTRAINT uq_driver_geofence_events UNIQUE (assignment_id, event)
+);
+
+CREATE INDEX IF NOT EXISTS idx_driver_geofence_events_order ON driver_geofence_events(order_id, occurred_at);
+
+CREATE TABLE IF NOT EXISTS location_retention_policies (
+    tenant_id BIGINT PRIMARY KEY,
+    keep_full_days INT NOT NULL DEFAULT 7,
+    downsample_interval_seconds INT NOT NULL DEFAULT 60,
+    purge_after_days INT NOT NULL DEFAULT 90,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT fk_location_retention_policies_tenant FOREIGN KEY (tenant_id) REFERENCES tenants(id) ON DELETE CASCADE,
+    CONSTRAINT chk_location_retention_policies_days CHECK (keep_full_days >= 1 AND purge_after_days >= keep_full_days),
+    CONSTRAINT chk_location_retention_policies_interval CHECK (downsample_interval_seconds BETWEEN 10 AND 3600)
+);
+
+-- Retention scans history by age per driver
+CREATE INDEX IF NOT EXISTS idx_driver_location_history_driver_recorded ON driver_location_history(driver_id, recorded_at);
+
+COMMENT ON TABLE driver_geofence_events IS 'Arrivals and departures detected from driver locations. picked_up sets driver_assignments.started_at and arrived_at_customer sets completed_at when they are still empty.';
+COMMENT ON TABLE location_retention_policies IS 'How long driver location history is kept: every point for keep_full_days, then one point per downsample interval until purge_after_days. Tenants without a row use the defaults.';
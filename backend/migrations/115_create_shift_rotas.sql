@@ -0,0 +1,78 @@
+-- 115_create_shift_rotas.sql
+-- Weekly shift rotas: shift templates, per-role staffing requirements and scheduled shifts
+
+CREATE TABLE IF NOT EXISTS shift_templates (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL, -- before start_time for shifts that end the next day
+    break_minutes INTEGER NOT NULL DEFAULT 0,
+    role_id INTEGER REFERENCES roles(id) ON DELETE SET NULL,
+    color VARCHAR(20),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT unique_shift_template_name UNIQUE (restaurant_id, name),
+    CONSTRAINT valid_shift_template_break CHECK (break_minutes >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS staffing_requirements (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    day_of_week INTEGER NOT NULL CHECK (day_of_week BETWEEN 0 AND 6), -- 0 = Sunday
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    min_staff INTEGER NOT NULL CHECK (min_staff > 0),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_staffing_requirements_restaurant ON staffing_requirements(restaurant_id, day_of_week);
+
+CREATE TABLE IF NOT EXISTS rotas (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    week_start DATE NOT NULL, -- Monday
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'published')),
+    notes TEXT,
+    published_at TIMESTAMP WITH TIME ZONE,
+    published_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+
+    CONSTRAINT unique_rota_week UNIQUE (restaurant_id, week_start),
+    CONSTRAINT valid_rota_week_start CHECK (EXTRACT(ISODOW FROM week_start) = 1)
+);
+
+CREATE TABLE IF NOT EXISTS shifts (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    rota_id INTEGER NOT NULL REFERENCES rotas(id) ON DELETE CASCADE,
+    employee_id INTEGER NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    role_id INTEGER REFERENCES roles(id) ON DELETE SET NULL,
+    template_id INTEGER REFERENCES shift_templates(id) ON DELETE SET NULL,
+    shift_date DATE NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL, -- before start_time for shifts that end the next day
+    break_minutes INTEGER NOT NULL DEFAULT 0,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT valid_shift_break CHECK (break_minutes >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_shifts_rota ON shifts(rota_id, shift_date);
+CREATE INDEX IF NOT EXISTS idx_shifts_employee_date ON shifts(employee_id, shift_date);
+
+COMMENT ON TABLE shift_templates IS 'Reusable shift times (e.g. Morning 08:00-16:00) used to fill rotas.';
+COMMENT ON TABLE staffing_requirements IS 'Minimum number of employees of a role that must be on shift for a time window on a weekday.';
+COMMENT ON TABLE rotas IS 'Weekly schedule of a restaurant. Shifts of published rotas drive attendance lateness and early departure.';
@@ -0,0 +1,29 @@
+-- 116_create_time_clock.sql
+-- Time clock: per-restaurant geofence and overtime settings, kiosk PIN/badge credentials,
+-- and attendance hours calculated by the application
+
+CREATE TABLE IF NOT EXISTS time_clock_settings (
+    restaurant_id INTEGER PRIMARY KEY REFERENCES restaurants(id) ON DELETE CASCADE,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    require_geofence BOOLEAN NOT NULL DEFAULT false,
+    geofence_radius_meters INTEGER NOT NULL DEFAULT 150 CHECK (geofence_radius_meters BETWEEN 10 AND 5000),
+    kiosk_enabled BOOLEAN NOT NULL DEFAULT false,
+    regular_hours_per_day DECIMAL(4,2) NOT NULL DEFAULT 8 CHECK (regular_hours_per_day > 0 AND regular_hours_per_day <= 24),
+    overtime_requires_approval BOOLEAN NOT NULL DEFAULT true,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+);
+
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS clock_pin_hash VARCHAR(255);
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS badge_code VARCHAR(100);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_employees_badge_code ON employees(tenant_id, badge_code) WHERE badge_code IS NOT NULL;
+
+-- Hours, overtime and lateness are calculated by the time clock, which knows the restaurant's
+-- regular day length, published shifts and late grace period
+DROP TRIGGER IF EXISTS trigger_calculate_attendance_hours ON attendance;
+DROP FUNCTION IF EXISTS calculate_attendance_hours();
+
+COMMENT ON TABLE time_clock_settings IS 'Per-restaurant time clock rules: geofence radius around the restaurant, kiosk mode and the day length after which hours count as overtime.';
+COMMENT ON COLUMN employees.clock_pin_hash IS 'bcrypt hash of the PIN used with the employee code at the time clock kiosk';
+COMMENT ON COLUMN employees.badge_code IS 'Badge or card code scanned at the time clock kiosk';
@@ -0,0 +1,101 @@
+-- 117_create_payroll_runs.sql
+-- Payroll runs: salaries computed for a period from attendance, unpaid leave and pooled order
+-- tips, with configurable deduction rules and an approval step before payment
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tip_amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (tip_amount >= 0);
+
+CREATE TABLE IF NOT EXISTS payroll_settings (
+    restaurant_id INTEGER PRIMARY KEY REFERENCES restaurants(id) ON DELETE CASCADE,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    overtime_multiplier DECIMAL(4,2) NOT NULL DEFAULT 1.5 CHECK (overtime_multiplier >= 1),
+    working_days_per_month INTEGER NOT NULL DEFAULT 26 CHECK (working_days_per_month BETWEEN 1 AND 31),
+    tip_pool_method VARCHAR(20) NOT NULL DEFAULT 'hours' CHECK (tip_pool_method IN ('hours', 'equal', 'none')),
+    payment_method VARCHAR(20) NOT NULL DEFAULT 'bank_transfer' CHECK (payment_method IN ('cash', 'bank_transfer', 'check', 'mobile_money')),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE TABLE IF NOT EXISTS payroll_deduction_rules (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    rule_type VARCHAR(20) NOT NULL CHECK (rule_type IN ('tax_bracket', 'percentage', 'fixed')),
+    component VARCHAR(30) NOT NULL CHECK (component IN ('tax', 'social_insurance', 'health_insurance', 'pension', 'other')),
+    rate DECIMAL(6,3) NOT NULL DEFAULT 0 CHECK (rate >= 0 AND rate <= 100),
+    amount DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (amount >= 0),
+    min_income DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (min_income >= 0),
+    max_income DECIMAL(12,2),
+    max_deduction DECIMAL(10,2),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_bracket CHECK (max_income IS NULL OR max_income > min_income)
+);
+
+CREATE INDEX idx_payroll_deduction_rules_restaurant ON payroll_deduction_rules(tenant_id, restaurant_id) WHERE is_active = true;
+
+CREATE TABLE IF NOT EXISTS payroll_runs (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'approved', 'paid', 'cancelled')),
+    tip_pool DECIMAL(12,2) NOT NULL DEFAULT 0,
+    employee_count INTEGER NOT NULL DEFAULT 0,
+    total_gross DECIMAL(12,2) NOT NULL DEFAULT 0,
+    total_deductions DECIMAL(12,2) NOT NULL DEFAULT 0,
+    total_net DECIMAL(12,2) NOT NULL DEFAULT 0,
+    notes TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    approved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    approved_at TIMESTAMP WITH TIME ZONE,
+    paid_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    paid_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_payroll_period CHECK (period_end >= period_start)
+);
+
+CREATE INDEX idx_payroll_runs_restaurant ON payroll_runs(tenant_id, restaurant_id, period_start DESC);
+
+ALTER TABLE salaries ADD COLUMN IF NOT EXISTS payroll_run_id INTEGER REFERENCES payroll_runs(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_salaries_payroll_run ON salaries(payroll_run_id);
+
+-- The original trigger summed gross salary before deriving overtime_amount, so overtime was
+-- left out of gross and net pay
+CREATE OR REPLACE FUNCTION calculate_salary_totals()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.overtime_hours > 0 AND NEW.overtime_rate > 0 THEN
+        NEW.overtime_amount := ROUND(NEW.overtime_hours * NEW.overtime_rate, 2);
+    END IF;
+
+    NEW.gross_salary := COALESCE(NEW.base_salary, 0) +
+                        COALESCE(NEW.overtime_amount, 0) +
+                        COALESCE(NEW.bonus, 0) +
+                        COALESCE(NEW.commission, 0) +
+                        COALESCE(NEW.allowances, 0) +
+                        COALESCE(NEW.tips, 0) +
+                        COALESCE(NEW.other_earnings, 0);
+
+    NEW.total_deductions := COALESCE(NEW.tax, 0) +
+                           COALESCE(NEW.social_insurance, 0) +
+                           COALESCE(NEW.health_insurance, 0) +
+                           COALESCE(NEW.pension, 0) +
+                           COALESCE(NEW.loan_deduction, 0) +
+                           COALESCE(NEW.advance_deduction, 0) +
+                           COALESCE(NEW.other_deductions, 0);
+
+    NEW.net_salary := NEW.gross_salary - NEW.total_deductions;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+COMMENT ON COLUMN orders.tip_amount IS 'Tip left by the customer, pooled among staff by payroll runs';
+COMMENT ON TABLE payroll_settings IS 'Per-restaurant payroll rules: overtime pay multiplier, working days used for the daily rate and how the tip pool is shared.';
+COMMENT ON TABLE payroll_deduction_rules IS 'Deductions applied to gross pay by payroll runs. tax_bracket rules are progressive: rate applies to the part of gross pay between min_income and max_income.';
+COMMENT ON TABLE payroll_runs IS 'A payroll for one restaurant and period. Salaries are generated as a draft, approved, then marked paid.';
+COMMENT ON COLUMN salaries.payroll_run_id IS 'Payroll run that generated this salary; NULL for salaries entered by hand';
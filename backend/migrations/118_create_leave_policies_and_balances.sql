@@ -0,0 +1,103 @@
+-- 118_create_leave_policies_and_balances.sql
+-- Leave policies per leave type, holiday calendars, maintained leave balances with a
+-- transaction history, and a manager approval chain for leave requests
+
+-- Half days were rounded to a whole day by the integer column
+ALTER TABLE leaves ALTER COLUMN total_days TYPE DECIMAL(5,1);
+ALTER TABLE leaves ADD COLUMN IF NOT EXISTS current_approval_level INTEGER NOT NULL DEFAULT 1;
+
+CREATE TABLE IF NOT EXISTS holiday_calendars (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    weekend_days INTEGER[] NOT NULL DEFAULT '{5,6}',
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_holiday_calendars_default ON holiday_calendars(restaurant_id) WHERE is_default = true;
+
+CREATE TABLE IF NOT EXISTS public_holidays (
+    id SERIAL PRIMARY KEY,
+    calendar_id INTEGER NOT NULL REFERENCES holiday_calendars(id) ON DELETE CASCADE,
+    holiday_date DATE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_calendar_holiday UNIQUE (calendar_id, holiday_date)
+);
+
+CREATE TABLE IF NOT EXISTS leave_policies (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    leave_type VARCHAR(30) NOT NULL,
+    leave_category VARCHAR(20) NOT NULL DEFAULT 'paid' CHECK (leave_category IN ('paid', 'unpaid')),
+    track_balance BOOLEAN NOT NULL DEFAULT true,
+    accrual_frequency VARCHAR(20) NOT NULL DEFAULT 'monthly' CHECK (accrual_frequency IN ('none', 'monthly', 'annually')),
+    accrual_days DECIMAL(5,2) NOT NULL DEFAULT 0 CHECK (accrual_days >= 0),
+    max_carry_over DECIMAL(5,2) CHECK (max_carry_over IS NULL OR max_carry_over >= 0),
+    probation_months INTEGER NOT NULL DEFAULT 0 CHECK (probation_months BETWEEN 0 AND 24),
+    accrue_during_probation BOOLEAN NOT NULL DEFAULT true,
+    approval_levels INTEGER NOT NULL DEFAULT 1 CHECK (approval_levels BETWEEN 1 AND 5),
+    holiday_calendar_id INTEGER REFERENCES holiday_calendars(id) ON DELETE SET NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_restaurant_leave_policy UNIQUE (restaurant_id, leave_type)
+);
+
+CREATE TABLE IF NOT EXISTS leave_balances (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    employee_id INTEGER NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    leave_type VARCHAR(30) NOT NULL,
+    year INTEGER NOT NULL CHECK (year >= 2000),
+    carried_over DECIMAL(6,2) NOT NULL DEFAULT 0,
+    accrued DECIMAL(6,2) NOT NULL DEFAULT 0,
+    used DECIMAL(6,2) NOT NULL DEFAULT 0,
+    adjusted DECIMAL(6,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_employee_leave_balance UNIQUE (employee_id, leave_type, year)
+);
+
+CREATE INDEX idx_leave_balances_restaurant ON leave_balances(tenant_id, restaurant_id, year);
+
+CREATE TABLE IF NOT EXISTS leave_balance_transactions (
+    id SERIAL PRIMARY KEY,
+    balance_id INTEGER NOT NULL REFERENCES leave_balances(id) ON DELETE CASCADE,
+    leave_id INTEGER REFERENCES leaves(id) ON DELETE SET NULL,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('carry_over', 'accrual', 'usage', 'reversal', 'adjustment')),
+    days DECIMAL(6,2) NOT NULL,
+    notes TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_leave_balance_transactions_balance ON leave_balance_transactions(balance_id, created_at);
+
+CREATE TABLE IF NOT EXISTS leave_approvals (
+    id SERIAL PRIMARY KEY,
+    leave_id INTEGER NOT NULL REFERENCES leaves(id) ON DELETE CASCADE,
+    level INTEGER NOT NULL CHECK (level >= 1),
+    approver_employee_id INTEGER REFERENCES employees(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    acted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    acted_at TIMESTAMP WITH TIME ZONE,
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_leave_approval_level UNIQUE (leave_id, level)
+);
+
+CREATE INDEX idx_leave_approvals_approver ON leave_approvals(approver_employee_id) WHERE status = 'pending';
+
+COMMENT ON TABLE holiday_calendars IS 'Weekend days and public holidays that are not counted as leave days';
+COMMENT ON COLUMN holiday_calendars.weekend_days IS 'Days of the week off, 0 = Sunday ... 6 = Saturday';
+COMMENT ON TABLE leave_policies IS 'Per leave type rules: accrual, carry-over cap, probation and how many managers must approve';
+COMMENT ON COLUMN leave_policies.accrual_days IS 'Days credited each month (monthly) or per year up front (annually, prorated in the hire year)';
+COMMENT ON COLUMN leave_policies.approval_levels IS 'Number of managers up the Employee.manager_id chain who approve in turn';
+COMMENT ON TABLE leave_balances IS 'Leave balance per employee, leave type and year. Available = carried_over + accrued + adjusted - used';
+COMMENT ON TABLE leave_approvals IS 'Approval chain of a leave request. A NULL approver is an HR approval, used when the employee has no manager';
@@ -0,0 +1,88 @@
+-- 119_create_review_cycles_and_goals.sql
+-- Performance review cycles with configurable rating criteria, self and manager assessments
+-- and goal tracking, and the extra HR entities recorded in hr_audit_logs
+
+CREATE TABLE IF NOT EXISTS review_cycles (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    review_type VARCHAR(20) NOT NULL CHECK (review_type IN ('probation', 'annual', 'mid_year', 'quarterly', 'ad_hoc')),
+    period_start DATE NOT NULL,
+    period_end DATE NOT NULL,
+    self_assessment_due DATE,
+    manager_assessment_due DATE,
+    status VARCHAR(20) NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'closed')),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT valid_review_cycle_period CHECK (period_end >= period_start)
+);
+
+CREATE INDEX idx_review_cycles_restaurant ON review_cycles(tenant_id, restaurant_id, period_start);
+
+CREATE TABLE IF NOT EXISTS review_criteria (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    key VARCHAR(50) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    weight DECIMAL(5,2) NOT NULL DEFAULT 1 CHECK (weight > 0),
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT unique_review_criterion_key UNIQUE (restaurant_id, key)
+);
+
+-- Reviews created by a cycle; criterion ratings live in custom_ratings (manager) and self_ratings
+ALTER TABLE performance_reviews ADD COLUMN IF NOT EXISTS cycle_id INTEGER REFERENCES review_cycles(id) ON DELETE SET NULL;
+ALTER TABLE performance_reviews ADD COLUMN IF NOT EXISTS reviewer_employee_id INTEGER REFERENCES employees(id) ON DELETE SET NULL;
+ALTER TABLE performance_reviews ADD COLUMN IF NOT EXISTS self_ratings JSONB NOT NULL DEFAULT '{}';
+ALTER TABLE performance_reviews ADD COLUMN IF NOT EXISTS self_comments TEXT;
+ALTER TABLE performance_reviews ADD COLUMN IF NOT EXISTS self_submitted_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE performance_reviews ADD COLUMN IF NOT EXISTS manager_submitted_at TIMESTAMP WITH TIME ZONE;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_performance_reviews_cycle_employee ON performance_reviews(cycle_id, employee_id) WHERE cycle_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_performance_reviews_reviewer_employee ON performance_reviews(reviewer_employee_id) WHERE status = 'draft';
+
+CREATE TABLE IF NOT EXISTS performance_goals (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    employee_id INTEGER NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    review_id INTEGER REFERENCES performance_reviews(id) ON DELETE SET NULL,
+    title VARCHAR(200) NOT NULL,
+    description TEXT,
+    target_date DATE,
+    progress INTEGER NOT NULL DEFAULT 0 CHECK (progress BETWEEN 0 AND 100),
+    status VARCHAR(20) NOT NULL DEFAULT 'not_started' CHECK (status IN ('not_started', 'in_progress', 'completed', 'missed', 'cancelled')),
+    completed_at TIMESTAMP WITH TIME ZONE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_performance_goals_employee ON performance_goals(employee_id, status);
+
+-- HR use cases write their own audit entries for everything added since migration 027
+ALTER TABLE hr_audit_logs DROP CONSTRAINT IF EXISTS hr_audit_logs_entity_type_check;
+ALTER TABLE hr_audit_logs ADD CONSTRAINT hr_audit_logs_entity_type_check CHECK (entity_type IN (
+    'employee', 'role', 'attendance', 'salary', 'leave', 'performance_review', 'employee_role',
+    'time_clock_settings', 'shift_template', 'staffing_requirement', 'rota', 'shift',
+    'payroll_settings', 'deduction_rule', 'payroll_run', 'leave_policy', 'holiday_calendar',
+    'leave_balance', 'review_cycle', 'review_criterion', 'performance_goal'
+));
+ALTER TABLE hr_audit_logs DROP CONSTRAINT IF EXISTS hr_audit_logs_action_check;
+ALTER TABLE hr_audit_logs ADD CONSTRAINT hr_audit_logs_action_check CHECK (action IN (
+    'create', 'update', 'delete', 'approve', 'reject', 'cancel',
+    'submit', 'acknowledge', 'finalize', 'publish', 'unpublish', 'pay'
+));
+
+COMMENT ON TABLE review_cycles IS 'A round of performance reviews; creating one opens a review for each employee in it';
+COMMENT ON TABLE review_criteria IS 'Rating criteria used by review cycles. Overall rating is the weight-averaged criterion rating';
+COMMENT ON COLUMN performance_reviews.custom_ratings IS 'Manager ratings by review criterion key (1-5 scale)';
+COMMENT ON COLUMN performance_reviews.self_ratings IS 'Employee self-assessment ratings by review criterion key (1-5 scale)';
+COMMENT ON COLUMN performance_reviews.reviewer_employee_id IS 'Manager who assesses the employee. NULL lets any HR user assess';
+COMMENT ON TABLE performance_goals IS 'Goals tracked for an employee, optionally set in a performance review';
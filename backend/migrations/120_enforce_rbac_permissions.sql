@@ -0,0 +1,78 @@
+-- 120_enforce_rbac_permissions.sql
+-- Database-backed RBAC: per-role module permissions on the HR roles table, module IDs that
+-- match the router and dashboard, and an ADMIN role for every tenant owner
+
+-- Routes and the dashboard refer to modules by fixed IDs (Products=1, HR=2, Notifications=3,
+-- Orders=4, Themes=5, Settings=6). Migration 100 seeded them in a different order, so
+-- renumber the seeded modules to those IDs. Custom modules keep IDs above the fixed range.
+INSERT INTO modules (name, display_name, description, path) VALUES
+('ORDERS', 'Orders', 'Orders, dine-in tables and delivery dispatch', '/dashboard/orders')
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE permission_audit_logs DROP CONSTRAINT IF EXISTS permission_audit_logs_module_id_fkey;
+
+CREATE TEMP TABLE module_id_map (name VARCHAR(100) PRIMARY KEY, new_id BIGINT NOT NULL UNIQUE);
+INSERT INTO module_id_map (name, new_id) VALUES
+('PRODUCTS', 1), ('HR', 2), ('NOTIFICATIONS', 3), ('ORDERS', 4), ('THEMES', 5),
+('SETTINGS', 6), ('CATEGORIES', 7), ('USERS', 8), ('ROLES', 9), ('REPORTS', 10);
+
+-- Park the seeded modules on negative IDs, move custom modules out of the fixed range,
+-- then flip the seeded ones to their final IDs
+UPDATE permission_audit_logs l SET module_id = -m.new_id
+FROM modules mo JOIN module_id_map m ON m.name = mo.name
+WHERE l.module_id = mo.id;
+UPDATE modules mo SET id = -m.new_id FROM module_id_map m WHERE m.name = mo.name;
+
+SELECT setval('modules_id_seq', GREATEST((SELECT MAX(id) FROM modules), 100));
+CREATE TEMP TABLE custom_module_ids AS
+SELECT id AS old_id, nextval('modules_id_seq') AS new_id FROM modules WHERE id BETWEEN 1 AND 100;
+UPDATE permission_audit_logs l SET module_id = c.new_id FROM custom_module_ids c WHERE l.module_id = c.old_id;
+UPDATE modules mo SET id = c.new_id FROM custom_module_ids c WHERE mo.id = c.old_id;
+
+UPDATE permission_audit_logs SET module_id = -module_id WHERE module_id < 0;
+UPDATE modules SET id = -id WHERE id < 0;
+SELECT setval('modules_id_seq', GREATEST((SELECT MAX(id) FROM modules), 100));
+
+DROP TABLE module_id_map;
+DROP TABLE custom_module_ids;
+
+ALTER TABLE permission_audit_logs ADD CONSTRAINT permission_audit_logs_module_id_fkey
+    FOREIGN KEY (module_id) REFERENCES modules(id) ON DELETE SET NULL;
+
+-- Module permissions granted by a role. A missing row means NONE.
+CREATE TABLE IF NOT EXISTS role_permissions (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    role_id INTEGER NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    module_id BIGINT NOT NULL REFERENCES modules(id) ON DELETE CASCADE,
+    permission_level VARCHAR(10) NOT NULL DEFAULT 'READ'
+        CHECK (permission_level IN ('NONE', 'READ', 'WRITE', 'DELETE', 'ADMIN')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (tenant_id, role_id, module_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_role_permissions_role ON role_permissions(tenant_id, role_id);
+CREATE INDEX IF NOT EXISTS idx_role_permissions_module ON role_permissions(module_id);
+
+-- Registration creates a system ADMIN role per tenant and assigns it to the owner. Tenants
+-- created before that get one now, on their first restaurant.
+INSERT INTO roles (tenant_id, restaurant_id, role_name, role_code, description, is_system_role, access_level)
+SELECT t.id, (SELECT MIN(rs.id) FROM restaurants rs WHERE rs.tenant_id = t.id),
+       'ADMIN', 'ADMIN', 'Administrator role with full system access', true, 'owner'
+FROM tenants t
+WHERE EXISTS (SELECT 1 FROM restaurants rs WHERE rs.tenant_id = t.id)
+  AND NOT EXISTS (SELECT 1 FROM roles r WHERE r.tenant_id = t.id AND r.role_code = 'ADMIN')
+ON CONFLICT DO NOTHING;
+
+UPDATE roles SET is_system_role = true WHERE role_code = 'ADMIN' AND is_system_role = false;
+
+INSERT INTO user_roles (tenant_id, user_id, role_id, assigned_by)
+SELECT u.tenant_id, u.id, r.id, u.id
+FROM users u
+JOIN roles r ON r.tenant_id = u.tenant_id AND r.role_code = 'ADMIN' AND r.is_system_role = true
+WHERE u.role IN ('owner', 'platform_admin')
+ON CONFLICT (tenant_id, user_id, role_id) DO NOTHING;
+
+COMMENT ON TABLE role_permissions IS 'Module permission level granted by a role; users get the highest level of any of their roles';
+COMMENT ON COLUMN roles.is_system_role IS 'System roles are predefined and cannot be edited or deleted; the ADMIN system role has full access to every module';
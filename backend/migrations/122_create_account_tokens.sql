@@ -0,0 +1,27 @@
+-- 122_create_account_tokens.sql
+-- Single-use tokens for password reset and email verification, and the verified state of
+-- a user's email address
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP WITH TIME ZONE;
+
+-- Accounts created before verification existed are treated as verified
+UPDATE users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP) WHERE email_verified_at IS NULL;
+
+-- Only the SHA-256 of a token is stored; the token itself is only ever sent by email
+CREATE TABLE IF NOT EXISTS account_tokens (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose VARCHAR(30) NOT NULL CHECK (purpose IN ('password_reset', 'email_verification')),
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    requested_ip VARCHAR(45),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_tokens_user ON account_tokens(user_id, purpose);
+
+-- Email change requests keep the SHA-256 of their verification token as well
+COMMENT ON COLUMN email_change_requests.verification_token IS 'SHA-256 hex digest of the token sent to the new address';
+COMMENT ON TABLE account_tokens IS 'Single-use password reset and email verification tokens, stored as SHA-256 hashes';
+COMMENT ON COLUMN users.email_verified_at IS 'When the user confirmed their email address; NULL until verified';
@@ -0,0 +1,63 @@
+-- 123_create_two_factor.sql
+-- TOTP two-factor authentication: enrollments, recovery codes, login challenges and
+-- remembered devices, plus a per-tenant policy that can make 2FA mandatory
+
+-- optional: users choose; managers: required for owner, admin and manager roles; all: required for everyone
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS two_factor_policy VARCHAR(20) NOT NULL DEFAULT 'optional'
+    CHECK (two_factor_policy IN ('optional', 'managers', 'all'));
+
+-- The secret is encrypted with TOTP_ENCRYPTION_KEY. enabled_at stays NULL until the user
+-- confirms enrollment with a first code. last_used_step blocks replaying a code.
+CREATE TABLE IF NOT EXISTS user_two_factor (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    secret_encrypted TEXT NOT NULL,
+    enabled_at TIMESTAMP WITH TIME ZONE,
+    last_used_step BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS two_factor_recovery_codes (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash CHAR(64) NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_two_factor_recovery_codes_user ON two_factor_recovery_codes(user_id, code_hash);
+
+-- Issued after a correct password; exchanged for a session once the second factor is verified
+CREATE TABLE IF NOT EXISTS two_factor_challenges (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    setup_required BOOLEAN NOT NULL DEFAULT FALSE,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE,
+    ip_address INET,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_two_factor_challenges_expires ON two_factor_challenges(expires_at);
+
+-- Devices where the user ticked "remember this device"; the cookie holds the token
+CREATE TABLE IF NOT EXISTS trusted_devices (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token_hash CHAR(64) NOT NULL UNIQUE,
+    device_name VARCHAR(100) NOT NULL DEFAULT '',
+    ip_address INET,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_trusted_devices_user ON trusted_devices(user_id);
+
+COMMENT ON COLUMN tenants.two_factor_policy IS 'Who must use two-factor authentication: optional, managers or all';
+COMMENT ON TABLE user_two_factor IS 'TOTP enrollment of a user; the secret is AES-GCM encrypted';
+COMMENT ON TABLE two_factor_recovery_codes IS 'Single-use recovery codes, stored as SHA-256 hashes';
+COMMENT ON TABLE two_factor_challenges IS 'Pending second login steps, stored as SHA-256 hashes';
+COMMENT ON TABLE trusted_devices IS 'Remembered devices that skip the second step until they expire';
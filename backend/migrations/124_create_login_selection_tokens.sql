@@ -0,0 +1,14 @@
+-- 124_create_login_selection_tokens.sql
+-- Tenant selection tokens are signed JWTs returned by a login that matches several tenants.
+-- The ID of each token is recorded here when login/confirm uses it, so a token works once.
+
+CREATE TABLE IF NOT EXISTS used_selection_tokens (
+    token_id UUID PRIMARY KEY,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    used_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Rows can be deleted once expired; an expired token is rejected by its signature check anyway
+CREATE INDEX IF NOT EXISTS idx_used_selection_tokens_expires ON used_selection_tokens(expires_at);
+
+COMMENT ON TABLE used_selection_tokens IS 'IDs of spent tenant selection tokens, kept until the tokens expire';
@@ -0,0 +1,44 @@
+-- 125_create_login_throttles.sql
+-- Failed sign-in counters for brute-force protection. Each email and each client IP has a
+-- row; backoff and lockouts are stored as timestamps so every API instance enforces them.
+
+CREATE TABLE IF NOT EXISTS login_throttles (
+    scope VARCHAR(20) NOT NULL CHECK (scope IN ('account', 'ip')),
+    key VARCHAR(255) NOT NULL,
+    failures INTEGER NOT NULL DEFAULT 0,
+    last_failure_at TIMESTAMP WITH TIME ZONE,
+    blocked_until TIMESTAMP WITH TIME ZONE,
+    locked_until TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_throttles_last_failure ON login_throttles(last_failure_at);
+
+COMMENT ON TABLE login_throttles IS 'Recent failed sign-ins per account email and per client IP';
+COMMENT ON COLUMN login_throttles.blocked_until IS 'Exponential backoff: attempts are refused until then';
+COMMENT ON COLUMN login_throttles.locked_until IS 'Account lockout after too many failures; cleared by an admin or by time';
+
+-- Login attempts are written to the security audit log. The table was only created by
+-- AuditLogManager.CreateAuditLogTable, so make sure it exists.
+CREATE TABLE IF NOT EXISTS audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id BIGINT NOT NULL,
+    user_id BIGINT NOT NULL,
+    action VARCHAR(100) NOT NULL,
+    resource_type VARCHAR(100),
+    resource_id VARCHAR(255),
+    details TEXT,
+    ip_address INET,
+    user_agent TEXT,
+    status VARCHAR(50) NOT NULL,
+    error_message TEXT,
+    duration_ms BIGINT,
+    created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_tenant_id ON audit_log(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_audit_user_id ON audit_log(user_id);
+CREATE INDEX IF NOT EXISTS idx_audit_action ON audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_audit_created_at ON audit_log(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_status ON audit_log(status);
+CREATE INDEX IF NOT EXISTS idx_audit_tenant_created ON audit_log(tenant_id, created_at DESC);
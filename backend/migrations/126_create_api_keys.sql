@@ -0,0 +1,43 @@
+-- 126_create_api_keys.sql
+-- Tenant API keys for integrations such as delivery aggregators. A key acts for the user
+-- who created it in one restaurant, limited to the module permissions listed on the key.
+-- Only a SHA-256 hash of the key is stored.
+
+CREATE TABLE IF NOT EXISTS api_keys (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    restaurant_id INTEGER NOT NULL REFERENCES restaurants(id) ON DELETE CASCADE,
+    key_hash VARCHAR(64) NOT NULL UNIQUE,
+    key_prefix VARCHAR(20) NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    -- Comma-separated module scopes such as "4:WRITE,1:READ"
+    permissions TEXT NOT NULL DEFAULT '',
+    allowed_ips TEXT NOT NULL DEFAULT '',
+    allowed_origins TEXT NOT NULL DEFAULT '',
+    -- Requests per minute
+    rate_limit INTEGER NOT NULL DEFAULT 600 CHECK (rate_limit > 0),
+    last_used_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant ON api_keys(tenant_id, created_at DESC);
+
+-- One row per request made with a key; used for usage statistics and rate limiting
+CREATE TABLE IF NOT EXISTS api_key_usage (
+    id BIGSERIAL PRIMARY KEY,
+    key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    tenant_id INTEGER NOT NULL,
+    endpoint VARCHAR(255) NOT NULL,
+    status_code INTEGER NOT NULL,
+    duration_ms BIGINT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_key_usage_key_created ON api_key_usage(key_id, created_at DESC);
+
+COMMENT ON TABLE api_keys IS 'Tenant API keys for integrations, scoped to module permissions';
+COMMENT ON TABLE api_key_usage IS 'Requests made with API keys';
@@ -0,0 +1,41 @@
+-- 127_create_user_invitations.sql
+-- Staff invitations. An admin invites someone by email or SMS with roles chosen up front;
+-- the invitee follows the link, sets a password and the account is created. Only the hash
+-- of the invitation token is stored.
+
+CREATE TABLE IF NOT EXISTS user_invitations (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    restaurant_id INTEGER REFERENCES restaurants(id) ON DELETE SET NULL,
+    employee_id INTEGER REFERENCES employees(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    phone VARCHAR(20),
+    channel VARCHAR(10) NOT NULL DEFAULT 'email' CHECK (channel IN ('email', 'sms')),
+    role_ids BIGINT[] NOT NULL DEFAULT '{}',
+    token_hash VARCHAR(64) NOT NULL UNIQUE,
+    invited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    accepted_at TIMESTAMP WITH TIME ZONE,
+    revoked_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One open invitation per email and tenant; inviting again replaces it
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_invitations_open_email
+    ON user_invitations(tenant_id, LOWER(email))
+    WHERE accepted_at IS NULL AND revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_user_invitations_tenant ON user_invitations(tenant_id, created_at DESC);
+
+COMMENT ON TABLE user_invitations IS 'Pending, accepted and revoked invitations to join a tenant';
+COMMENT ON COLUMN user_invitations.role_ids IS 'Roles assigned to the account when the invitation is accepted';
+COMMENT ON COLUMN user_invitations.employee_id IS 'HR employee record linked to the account when the invitation is accepted';
+COMMENT ON COLUMN user_invitations.user_id IS 'Account created by accepting the invitation';
+
+-- Link HR employees to login accounts. Until now employees were matched to users by email;
+-- an explicit link takes precedence and survives either email changing.
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS user_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_employees_user ON employees(tenant_id, restaurant_id, user_id) WHERE user_id IS NOT NULL;
+
+COMMENT ON COLUMN employees.user_id IS 'Login account of the employee, for self-service clock-in and leave requests';
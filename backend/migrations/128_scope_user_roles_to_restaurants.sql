@@ -0,0 +1,19 @@
+-- 128_scope_user_roles_to_restaurants.sql
+-- Role assignments can be limited to a restaurant, e.g. manager of one branch and cashier
+-- at another. A NULL restaurant_id keeps the assignment valid in every restaurant of the
+-- tenant, which is what all existing assignments become.
+
+ALTER TABLE user_roles
+    ADD COLUMN IF NOT EXISTS restaurant_id INTEGER REFERENCES restaurants(id) ON DELETE CASCADE;
+
+-- A role is held at most once tenant-wide and once per restaurant
+ALTER TABLE user_roles DROP CONSTRAINT IF EXISTS user_roles_tenant_id_user_id_role_id_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_roles_tenant_wide
+    ON user_roles(tenant_id, user_id, role_id) WHERE restaurant_id IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_roles_restaurant
+    ON user_roles(tenant_id, user_id, role_id, restaurant_id) WHERE restaurant_id IS NOT NULL;
+
+-- The restaurant a session switched to; access tokens issued on refresh keep it.
+-- NULL means the user's default restaurant.
+ALTER TABLE user_sessions
+    ADD COLUMN IF NOT EXISTS restaurant_id INTEGER REFERENCES restaurants(id) ON DELETE SET NULL;
@@ -0,0 +1,72 @@
+-- 129_create_tenant_sso.sql
+-- Single sign-on with OpenID Connect. A tenant configures its identity provider; staff sign
+-- in with the authorization code flow and PKCE, accounts are created on first sign-in and
+-- roles follow the provider's groups. The client secret and PKCE verifiers are stored
+-- encrypted, login state and handoff codes only as hashes.
+
+CREATE TABLE IF NOT EXISTS tenant_sso_configs (
+    tenant_id INTEGER PRIMARY KEY REFERENCES tenants(id) ON DELETE CASCADE,
+    issuer_url VARCHAR(500) NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    client_secret TEXT NOT NULL DEFAULT '',
+    scopes TEXT[] NOT NULL DEFAULT '{openid,email,profile}',
+    groups_claim VARCHAR(100) NOT NULL DEFAULT 'groups',
+    allowed_domains TEXT[] NOT NULL DEFAULT '{}',
+    jit_provisioning BOOLEAN NOT NULL DEFAULT TRUE,
+    default_restaurant_id INTEGER REFERENCES restaurants(id) ON DELETE SET NULL,
+    password_login_disabled BOOLEAN NOT NULL DEFAULT FALSE,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+COMMENT ON COLUMN tenant_sso_configs.client_secret IS 'Client secret sealed with the SSO encryption key; empty for public clients';
+COMMENT ON COLUMN tenant_sso_configs.allowed_domains IS 'Email domains that may sign in; empty allows any';
+COMMENT ON COLUMN tenant_sso_configs.password_login_disabled IS 'Staff of the tenant must sign in through the identity provider';
+
+-- Provider groups mapped to roles, tenant-wide or in one restaurant
+CREATE TABLE IF NOT EXISTS tenant_sso_role_mappings (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenant_sso_configs(tenant_id) ON DELETE CASCADE,
+    group_name VARCHAR(255) NOT NULL,
+    role_id BIGINT NOT NULL REFERENCES roles(id) ON DELETE CASCADE,
+    restaurant_id INTEGER REFERENCES restaurants(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tenant_sso_role_mappings_tenant ON tenant_sso_role_mappings(tenant_id);
+
+-- Provider accounts linked to users
+CREATE TABLE IF NOT EXISTS user_identities (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    issuer VARCHAR(500) NOT NULL,
+    subject VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_login_at TIMESTAMP WITH TIME ZONE,
+    UNIQUE (tenant_id, issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities(user_id);
+
+-- Sign-ins in progress. The state identifies the request on the callback; once the user is
+-- known a short-lived handoff code lets the frontend collect its tokens. Both are single use.
+CREATE TABLE IF NOT EXISTS sso_login_requests (
+    id BIGSERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    state_hash VARCHAR(64) NOT NULL UNIQUE,
+    nonce VARCHAR(100) NOT NULL,
+    code_verifier TEXT NOT NULL,
+    redirect_path VARCHAR(500) NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    callback_at TIMESTAMP WITH TIME ZONE,
+    user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+    handoff_hash VARCHAR(64) UNIQUE,
+    handoff_expires_at TIMESTAMP WITH TIME ZONE,
+    exchanged_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sso_login_requests_expires ON sso_login_requests(expires_at);
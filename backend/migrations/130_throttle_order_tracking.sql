@@ -0,0 +1,10 @@
+-- 130_throttle_order_tracking.sql
+-- Public order tracking is verified with the customer's phone number. Failed verifications
+-- back off per order and per client IP in login_throttles, so phone numbers cannot be
+-- guessed across sequential order numbers.
+
+ALTER TABLE login_throttles DROP CONSTRAINT IF EXISTS login_throttles_scope_check;
+ALTER TABLE login_throttles ADD CONSTRAINT login_throttles_scope_check
+    CHECK (scope IN ('account', 'ip', 'tracking_order', 'tracking_ip'));
+
+COMMENT ON TABLE login_throttles IS 'Recent failed sign-ins per account email and per client IP, and failed order tracking verifications per order and per client IP';
@@ -0,0 +1,15 @@
+-- 131_api_key_rate_windows.sql
+-- API key rate limits are counted per key and fixed window. The counter is incremented and
+-- read in one upsert, so concurrent requests cannot all pass the limit. Keys are looked up
+-- by their display prefix and the hash is compared afterwards.
+
+CREATE TABLE IF NOT EXISTS api_key_rate_windows (
+    key_id BIGINT NOT NULL REFERENCES api_keys(id) ON DELETE CASCADE,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    request_count INTEGER NOT NULL DEFAULT 0,
+    PRIMARY KEY (key_id, window_start)
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_prefix ON api_keys(key_prefix) WHERE is_active = true;
+
+COMMENT ON TABLE api_key_rate_windows IS 'Requests made with an API key per rate limit window';
@@ -16,6 +16,7 @@ interface TenantSelectorModalProps {
   isOpen: boolean
   email: string
   tenants: Tenant[]
+  selectionToken: string
   onTenantSelected?: (tenantId: number) => void
 }
 
@@ -23,6 +24,7 @@ export function TenantSelectorModal({
   isOpen,
   email,
   tenants,
+  selectionToken,
   onTenantSelected,
 }: TenantSelectorModalProps) {
   const router = useRouter()
@@ -49,6 +51,7 @@ export function TenantSelectorModal({
         body: JSON.stringify({
           email,
           tenant_id: tenantId,
+          selection_token: selectionToken,
         }),
       })
 
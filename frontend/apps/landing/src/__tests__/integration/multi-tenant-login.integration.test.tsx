@@ -123,6 +123,7 @@ describe('Multi-Tenant Login Integration Tests', () => {
               roles: ['editor'],
             },
           ],
+          selection_token: 'selection-token',
         },
       }
 
@@ -166,6 +167,7 @@ describe('Multi-Tenant Login Integration Tests', () => {
             body: JSON.stringify({
               email: 'multi@example.com',
               tenant_id: 2,
+              selection_token: 'selection-token',
             }),
           })
         )
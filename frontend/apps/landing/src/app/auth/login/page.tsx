@@ -39,6 +39,7 @@ export default function LoginPage() {
   const [showTenantSelector, setShowTenantSelector] = useState(false)
   const [selectedEmail, setSelectedEmail] = useState('')
   const [availableTenants, setAvailableTenants] = useState<Tenant[]>([])
+  const [selectionToken, setSelectionToken] = useState('')
 
   const {
     register,
@@ -70,6 +71,7 @@ export default function LoginPage() {
         console.log('Multiple tenants detected:', response.data.tenants);
         setSelectedEmail(data.email)
         setAvailableTenants(response.data.tenants)
+        setSelectionToken(response.data.selection_token)
         setShowTenantSelector(true)
         toast.info('You have accounts in multiple organizations. Please select one.')
         setIsLoading(false)
@@ -230,6 +232,7 @@ export default function LoginPage() {
         isOpen={showTenantSelector}
         email={selectedEmail}
         tenants={availableTenants}
+        selectionToken={selectionToken}
         onTenantSelected={handleTenantSelected}
       />
     </>
@@ -18,6 +18,7 @@ interface TenantSelectorModalProps {
   isOpen: boolean
   email: string
   tenants: Tenant[]
+  selectionToken: string
   onTenantSelected?: (tenantId: number) => void
 }
 
@@ -25,6 +26,7 @@ export function TenantSelectorModal({
   isOpen,
   email,
   tenants,
+  selectionToken,
   onTenantSelected,
 }: TenantSelectorModalProps) {
   const router = useRouter()
@@ -53,6 +55,7 @@ export function TenantSelectorModal({
           body: JSON.stringify({
             email,
             tenant_id: tenantId,
+            selection_token: selectionToken,
           }),
         }
       )
@@ -56,6 +56,7 @@ describe('TenantSelectorModal', () => {
           isOpen={false}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -64,7 +65,7 @@ describe('TenantSelectorModal', () => {
 
     it('should not render when tenants list is empty', () => {
       const { container } = render(
-        <TenantSelectorModal isOpen={true} email="user@test.com" tenants={[]} />
+        <TenantSelectorModal isOpen={true} email="user@test.com" tenants={[]} selectionToken="selection-token" />
       )
 
       expect(container.firstChild).toBeNull()
@@ -76,6 +77,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -90,6 +92,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="testuser@example.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -102,6 +105,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -128,6 +132,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -142,6 +147,7 @@ describe('TenantSelectorModal', () => {
             body: JSON.stringify({
               email: 'user@test.com',
               tenant_id: 1,
+              selection_token: 'selection-token',
             }),
           })
         )
@@ -168,6 +174,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -191,6 +198,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -227,6 +235,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -249,6 +258,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -270,6 +280,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -282,6 +293,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
         />
       )
 
@@ -309,6 +321,7 @@ describe('TenantSelectorModal', () => {
           isOpen={true}
           email="user@test.com"
           tenants={mockTenants}
+          selectionToken="selection-token"
           onTenantSelected={mockCallback}
         />
       )